@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package atomicx contains generic atomic types.
+package atomicx
+
+import (
+	"context"
+	"sync"
+)
+
+// Value holds a value of type T that can be read and updated concurrently,
+// and allows waiting until the value satisfies a condition.
+// The zero value holds the zero value of T and is ready to use.
+// A Value must not be copied after first use.
+type Value[T any] struct {
+	lock    sync.RWMutex
+	val     T
+	changed chan struct{}
+}
+
+// NewValue returns a new Value holding v.
+func NewValue[T any](v T) *Value[T] {
+	return &Value[T]{val: v}
+}
+
+// Load returns the value.
+func (v *Value[T]) Load() T {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.val
+}
+
+// Store sets the value to val.
+func (v *Value[T]) Store(val T) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.set(val)
+}
+
+// Swap stores val and returns the previous value.
+func (v *Value[T]) Swap(val T) (old T) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	old = v.val
+	v.set(val)
+	return old
+}
+
+// CompareAndSwap stores val if the current value is equal to old, and returns
+// true if the swap was performed.
+// Like atomic.Value, it panics if T is not comparable.
+func (v *Value[T]) CompareAndSwap(old, val T) (swapped bool) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if any(v.val) != any(old) {
+		return false
+	}
+	v.set(val)
+	return true
+}
+
+// Wait blocks until the value satisfies cond, and returns it.
+// cond is evaluated with the current value and then every time the value is
+// updated. It returns the context's error if ctx is canceled first.
+func (v *Value[T]) Wait(ctx context.Context, cond func(T) bool) (T, error) {
+	for {
+		v.lock.Lock()
+		val := v.val
+		if cond(val) {
+			v.lock.Unlock()
+			return val, nil
+		}
+		if v.changed == nil {
+			v.changed = make(chan struct{})
+		}
+		changed := v.changed
+		v.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// set updates the value and wakes up the waiters.
+// It must be called while holding the write lock.
+func (v *Value[T]) set(val T) {
+	v.val = val
+	if v.changed != nil {
+		close(v.changed)
+		v.changed = nil
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package atomicx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValue(t *testing.T) {
+	t.Run("zero value", func(t *testing.T) {
+		var v Value[string]
+		assert.Equal(t, "", v.Load())
+		v.Store("a")
+		assert.Equal(t, "a", v.Load())
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		v := NewValue(1)
+		assert.Equal(t, 1, v.Swap(2))
+		assert.Equal(t, 2, v.Load())
+	})
+
+	t.Run("compare and swap", func(t *testing.T) {
+		v := NewValue("a")
+		assert.False(t, v.CompareAndSwap("b", "c"))
+		assert.Equal(t, "a", v.Load())
+		assert.True(t, v.CompareAndSwap("a", "c"))
+		assert.Equal(t, "c", v.Load())
+	})
+
+	t.Run("compare and swap panics with incomparable types", func(t *testing.T) {
+		v := NewValue[any]([]int{1})
+		assert.Panics(t, func() {
+			v.CompareAndSwap([]int{1}, []int{2})
+		})
+	})
+
+	t.Run("wait returns immediately if the condition is met", func(t *testing.T) {
+		v := NewValue(5)
+		got, err := v.Wait(context.Background(), func(n int) bool { return n > 1 })
+		require.NoError(t, err)
+		assert.Equal(t, 5, got)
+	})
+
+	t.Run("wait blocks until the condition is met", func(t *testing.T) {
+		var v Value[int]
+		resCh := make(chan int)
+		go func() {
+			got, err := v.Wait(context.Background(), func(n int) bool { return n >= 3 })
+			assert.NoError(t, err)
+			resCh <- got
+		}()
+
+		for i := 1; i <= 3; i++ {
+			select {
+			case <-resCh:
+				require.Fail(t, "wait returned too early")
+			case <-time.After(10 * time.Millisecond):
+			}
+			v.Store(i)
+		}
+
+		select {
+		case got := <-resCh:
+			assert.Equal(t, 3, got)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout")
+		}
+	})
+
+	t.Run("wait returns when the context is canceled", func(t *testing.T) {
+		var v Value[bool]
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := v.Wait(ctx, func(b bool) bool { return b })
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,412 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cache contains a generic in-memory cache with LRU eviction and
+// expiration of the entries.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	kclock "k8s.io/utils/clock"
+
+	"github.com/dapr/kit/concurrency"
+)
+
+// EvictionReason is the reason an entry was evicted from the cache.
+type EvictionReason int
+
+const (
+	// EvictionReasonSize is the reason of entries evicted because the cache
+	// reached its maximum size; these are the least recently used entries.
+	EvictionReasonSize EvictionReason = iota
+	// EvictionReasonExpired is the reason of entries evicted because their TTL
+	// expired.
+	EvictionReasonExpired
+)
+
+// String implements fmt.Stringer.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionReasonSize:
+		return "size"
+	case EvictionReasonExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// Options are the options for New.
+type Options[K comparable, V any] struct {
+	// MaxSize is the maximum number of entries in the cache. When the cache is
+	// full, the least recently used entry is evicted to make room for a new one.
+	// Defaults to 0 (unlimited).
+	MaxSize int
+
+	// TTL is the time-to-live of the entries, unless a different one is given
+	// with SetWithTTL.
+	// Defaults to 0 (entries don't expire).
+	TTL time.Duration
+
+	// CleanupInterval is the interval at which expired entries are removed in
+	// the background. If 0, expired entries are only removed when they are
+	// accessed, or evicted because of the size limit. If set, the cache must
+	// be closed with Close.
+	// Defaults to 0.
+	CleanupInterval time.Duration
+
+	// OnEvict is invoked when an entry is evicted, because the cache is full or
+	// because the entry expired. It is not invoked for entries which are
+	// deleted or replaced. It is invoked without holding the cache's lock, so
+	// it can call methods of the cache. Optional.
+	OnEvict func(key K, val V, reason EvictionReason)
+
+	// Clock is the clock used to expire the entries.
+	// Defaults to the real clock; set it to a fake clock for deterministic
+	// tests.
+	Clock kclock.WithTicker
+}
+
+// Cache is a generic in-memory cache with LRU eviction and expiration of the
+// entries. It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	maxSize int
+	ttl     time.Duration
+	onEvict func(key K, val V, reason EvictionReason)
+	clock   kclock.WithTicker
+	loader  *concurrency.Deduper[K, V]
+
+	lock sync.Mutex
+	// items maps the keys to the elements of lru, whose values are *entry
+	items map[K]*list.Element
+	// lru contains the entries, from the most to the least recently used
+	lru *list.List
+	// loads contains the generations of the keys which are being loaded
+	loads map[K]*keyLoads
+
+	closed    atomic.Bool
+	closeCh   chan struct{}
+	runningCh chan struct{}
+}
+
+type entry[K comparable, V any] struct {
+	key K
+	val V
+	// exp is the expiration time, or the zero value if the entry doesn't expire
+	exp time.Time
+}
+
+// keyLoads tracks the loads of a key which are in progress.
+// gen is bumped each time the key is set or deleted, so loads which started
+// before don't overwrite the newer value.
+type keyLoads struct {
+	gen   uint64
+	count int
+}
+
+// evicted is an entry evicted from the cache, whose OnEvict callback is
+// pending.
+type evicted[K comparable, V any] struct {
+	entry  *entry[K, V]
+	reason EvictionReason
+}
+
+// New returns a new Cache.
+func New[K comparable, V any](opts Options[K, V]) *Cache[K, V] {
+	cl := opts.Clock
+	if cl == nil {
+		cl = kclock.RealClock{}
+	}
+
+	c := &Cache[K, V]{
+		maxSize: opts.MaxSize,
+		ttl:     opts.TTL,
+		onEvict: opts.OnEvict,
+		clock:   cl,
+		loader: concurrency.NewDeduper[K, V](concurrency.DeduperOptions{
+			Clock: cl,
+		}),
+		items:   make(map[K]*list.Element),
+		lru:     list.New(),
+		loads:   make(map[K]*keyLoads),
+		closeCh: make(chan struct{}),
+	}
+
+	if opts.CleanupInterval > 0 {
+		c.runningCh = make(chan struct{})
+		go c.runCleanup(opts.CleanupInterval)
+	}
+
+	return c
+}
+
+// Get returns the value of the entry with the given key, marking it as the
+// most recently used.
+// Expired entries are not returned.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	el, ok := c.items[key]
+	if !ok {
+		c.lock.Unlock()
+		var zero V
+		return zero, false
+	}
+
+	e := el.Value.(*entry[K, V])
+	if c.isExpired(e, c.clock.Now()) {
+		c.removeElement(el)
+		c.lock.Unlock()
+		c.notifyEvicted([]evicted[K, V]{{entry: e, reason: EvictionReasonExpired}})
+		var zero V
+		return zero, false
+	}
+
+	c.lru.MoveToFront(el)
+	c.lock.Unlock()
+	return e.val, true
+}
+
+// Set adds an entry to the cache, with the default TTL, replacing the
+// existing entry with the same key, if any.
+func (c *Cache[K, V]) Set(key K, val V) {
+	c.SetWithTTL(key, val, c.ttl)
+}
+
+// SetWithTTL adds an entry to the cache which expires after ttl, replacing the
+// existing entry with the same key, if any.
+// A ttl of 0 means that the entry doesn't expire.
+func (c *Cache[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	e := c.newEntry(key, val, ttl)
+
+	c.lock.Lock()
+	if l, ok := c.loads[key]; ok {
+		l.gen++
+	}
+	ev := c.setLocked(e)
+	c.lock.Unlock()
+
+	c.notifyEvicted(ev)
+}
+
+// GetOrLoad returns the value of the entry with the given key if it's in the
+// cache, or else invokes loader to load it and adds it to the cache with the
+// default TTL.
+// Concurrent calls with the same key invoke loader only once, and share its
+// result. Errors returned by loader are not cached.
+// If the key is set or deleted while loader runs, the loaded value is
+// returned but not added to the cache, so it doesn't overwrite the newer
+// value.
+// If ctx is canceled while waiting for loader, GetOrLoad returns the
+// context's error, but loader continues for the other callers; loader
+// receives a context which is not canceled when the callers' contexts are.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, error) {
+	if val, ok := c.Get(key); ok {
+		return val, nil
+	}
+
+	return c.loader.Do(ctx, key, func(ctx context.Context) (V, error) {
+		gen := c.startLoad(key)
+		// Deferred so the load is unregistered even if loader panics
+		var e *entry[K, V]
+		defer func() {
+			c.finishLoad(key, gen, e)
+		}()
+
+		val, err := loader(ctx)
+		if err == nil {
+			e = c.newEntry(key, val, c.ttl)
+		}
+		return val, err
+	})
+}
+
+// startLoad registers a load of the key, returning the current generation of
+// the key.
+func (c *Cache[K, V]) startLoad(key K) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	l, ok := c.loads[key]
+	if !ok {
+		l = &keyLoads{}
+		c.loads[key] = l
+	}
+	l.count++
+	return l.gen
+}
+
+// finishLoad unregisters a load of the key which started at generation gen,
+// and adds the loaded entry to the cache, if not nil, unless the key was set
+// or deleted in the meantime.
+func (c *Cache[K, V]) finishLoad(key K, gen uint64, e *entry[K, V]) {
+	c.lock.Lock()
+	l := c.loads[key]
+	l.count--
+	if l.count == 0 {
+		delete(c.loads, key)
+	}
+	var ev []evicted[K, V]
+	if e != nil && l.gen == gen {
+		ev = c.setLocked(e)
+	}
+	c.lock.Unlock()
+
+	c.notifyEvicted(ev)
+}
+
+// Delete removes the entry with the given key from the cache, if any.
+// Loads of the key which are in progress are not canceled, but they don't add
+// the entry again once they complete; the next call to GetOrLoad doesn't wait
+// for them, and invokes the loader again.
+func (c *Cache[K, V]) Delete(key K) {
+	c.lock.Lock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	if l, ok := c.loads[key]; ok {
+		l.gen++
+	}
+	c.lock.Unlock()
+
+	c.loader.Forget(key)
+}
+
+// Len returns the number of entries in the cache, including those which have
+// expired but have not been removed yet.
+func (c *Cache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// Reset removes all entries from the cache.
+// Like Delete, loads which are in progress don't add their entries, and the
+// next calls to GetOrLoad don't wait for them.
+func (c *Cache[K, V]) Reset() {
+	c.lock.Lock()
+	clear(c.items)
+	c.lru.Init()
+	loading := make([]K, 0, len(c.loads))
+	for key, l := range c.loads {
+		l.gen++
+		loading = append(loading, key)
+	}
+	c.lock.Unlock()
+
+	for _, key := range loading {
+		c.loader.Forget(key)
+	}
+}
+
+// Cleanup removes the expired entries from the cache.
+func (c *Cache[K, V]) Cleanup() {
+	now := c.clock.Now()
+
+	c.lock.Lock()
+	var ev []evicted[K, V]
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*entry[K, V]); c.isExpired(e, now) {
+			c.removeElement(el)
+			ev = append(ev, evicted[K, V]{entry: e, reason: EvictionReasonExpired})
+		}
+		el = next
+	}
+	c.lock.Unlock()
+
+	c.notifyEvicted(ev)
+}
+
+// Close stops the background cleanup of expired entries, if enabled.
+func (c *Cache[K, V]) Close() {
+	if c.closed.CompareAndSwap(false, true) {
+		close(c.closeCh)
+	}
+	if c.runningCh != nil {
+		<-c.runningCh
+	}
+}
+
+func (c *Cache[K, V]) runCleanup(interval time.Duration) {
+	defer close(c.runningCh)
+
+	t := c.clock.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.closeCh:
+			return
+		case <-t.C():
+			c.Cleanup()
+		}
+	}
+}
+
+// newEntry returns an entry which expires after ttl, or never if ttl is 0.
+func (c *Cache[K, V]) newEntry(key K, val V, ttl time.Duration) *entry[K, V] {
+	e := &entry[K, V]{
+		key: key,
+		val: val,
+	}
+	if ttl > 0 {
+		e.exp = c.clock.Now().Add(ttl)
+	}
+	return e
+}
+
+// setLocked adds the entry to the cache, replacing the existing entry with
+// the same key, if any, and returns the entries evicted to make room for it.
+// The caller must hold the lock.
+func (c *Cache[K, V]) setLocked(e *entry[K, V]) []evicted[K, V] {
+	if el, ok := c.items[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return nil
+	}
+
+	c.items[e.key] = c.lru.PushFront(e)
+	var ev []evicted[K, V]
+	for c.maxSize > 0 && c.lru.Len() > c.maxSize {
+		oldest := c.lru.Back()
+		c.removeElement(oldest)
+		ev = append(ev, evicted[K, V]{entry: oldest.Value.(*entry[K, V]), reason: EvictionReasonSize})
+	}
+	return ev
+}
+
+func (c *Cache[K, V]) isExpired(e *entry[K, V], now time.Time) bool {
+	return !e.exp.IsZero() && !now.Before(e.exp)
+}
+
+// removeElement removes an element from the cache.
+// The caller must hold the lock.
+func (c *Cache[K, V]) removeElement(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
+
+// notifyEvicted invokes the OnEvict callback for the evicted entries.
+// The caller must not hold the lock.
+func (c *Cache[K, V]) notifyEvicted(ev []evicted[K, V]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range ev {
+		c.onEvict(e.entry.key, e.entry.val, e.reason)
+	}
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type evictedEntry struct {
+	key    string
+	val    int
+	reason EvictionReason
+}
+
+func TestCache(t *testing.T) {
+	t.Run("get and set", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("a", 3)
+		v, ok := c.Get("a")
+		require.True(t, ok)
+		assert.Equal(t, 3, v)
+		assert.Equal(t, 2, c.Len())
+
+		c.Delete("a")
+		_, ok = c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 1, c.Len())
+
+		c.Reset()
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		var ev []evictedEntry
+		c := New(Options[string, int]{
+			MaxSize: 2,
+			OnEvict: func(key string, val int, reason EvictionReason) {
+				ev = append(ev, evictedEntry{key: key, val: val, reason: reason})
+			},
+		})
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		// Accessing "a" makes "b" the least recently used
+		_, ok := c.Get("a")
+		require.True(t, ok)
+		c.Set("c", 3)
+		assert.Equal(t, []evictedEntry{{key: "b", val: 2, reason: EvictionReasonSize}}, ev)
+		assert.Equal(t, 2, c.Len())
+
+		// Replacing an entry doesn't evict any
+		c.Set("a", 4)
+		assert.Len(t, ev, 1)
+		c.Set("d", 5)
+		assert.Equal(t, evictedEntry{key: "c", val: 3, reason: EvictionReasonSize}, ev[1])
+
+		_, ok = c.Get("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		var ev []evictedEntry
+		c := New(Options[string, int]{
+			TTL:   time.Minute,
+			Clock: clock,
+			OnEvict: func(key string, val int, reason EvictionReason) {
+				ev = append(ev, evictedEntry{key: key, val: val, reason: reason})
+			},
+		})
+
+		c.Set("a", 1)
+		c.SetWithTTL("b", 2, time.Hour)
+		c.SetWithTTL("c", 3, 0)
+
+		clock.Step(time.Minute - time.Second)
+		_, ok := c.Get("a")
+		assert.True(t, ok)
+
+		clock.Step(time.Second)
+		_, ok = c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, []evictedEntry{{key: "a", val: 1, reason: EvictionReasonExpired}}, ev)
+
+		clock.Step(time.Hour)
+		assert.Equal(t, 2, c.Len())
+		c.Cleanup()
+		assert.Equal(t, 1, c.Len())
+		assert.Equal(t, evictedEntry{key: "b", val: 2, reason: EvictionReasonExpired}, ev[1])
+
+		// Entries with no TTL don't expire
+		v, ok := c.Get("c")
+		require.True(t, ok)
+		assert.Equal(t, 3, v)
+	})
+
+	t.Run("expired entries are removed in the background", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		evictedCh := make(chan string, 1)
+		c := New(Options[string, int]{
+			TTL:             time.Minute,
+			CleanupInterval: 10 * time.Second,
+			Clock:           clock,
+			OnEvict: func(key string, _ int, reason EvictionReason) {
+				assert.Equal(t, EvictionReasonExpired, reason)
+				evictedCh <- key
+			},
+		})
+		t.Cleanup(c.Close)
+
+		c.Set("a", 1)
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Minute)
+
+		select {
+		case key := <-evictedCh:
+			assert.Equal(t, "a", key)
+		case <-time.After(time.Second):
+			require.Fail(t, "entry was not removed")
+		}
+		assert.Equal(t, 0, c.Len())
+	})
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+	t.Run("loads the value once", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		var calls atomic.Int32
+		releaseCh := make(chan struct{})
+		loader := func(context.Context) (int, error) {
+			calls.Add(1)
+			<-releaseCh
+			return 42, nil
+		}
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := c.GetOrLoad(context.Background(), "a", loader)
+				assert.NoError(t, err)
+				assert.Equal(t, 42, v)
+			}()
+		}
+		assert.Eventually(t, func() bool {
+			return calls.Load() == 1
+		}, time.Second, time.Millisecond)
+		close(releaseCh)
+		wg.Wait()
+		assert.Equal(t, int32(1), calls.Load())
+
+		// The loaded value is cached
+		v, err := c.GetOrLoad(context.Background(), "a", loader)
+		require.NoError(t, err)
+		assert.Equal(t, 42, v)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		loadErr := errors.New("load failed")
+		_, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+			return 0, loadErr
+		})
+		require.ErrorIs(t, err, loadErr)
+		assert.Equal(t, 0, c.Len())
+
+		v, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+			return 1, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	})
+
+	t.Run("loads in progress don't overwrite newer values", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		load := func(val int) (chan struct{}, chan int) {
+			releaseCh := make(chan struct{})
+			resCh := make(chan int)
+			go func() {
+				v, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+					<-releaseCh
+					return val, nil
+				})
+				assert.NoError(t, err)
+				resCh <- v
+			}()
+			return releaseCh, resCh
+		}
+		waitLoading := func() {
+			assert.Eventually(t, func() bool {
+				c.lock.Lock()
+				defer c.lock.Unlock()
+				return len(c.loads) > 0
+			}, time.Second, time.Millisecond)
+		}
+
+		// Deleted while loading
+		releaseCh, resCh := load(1)
+		waitLoading()
+		c.Delete("a")
+		v, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+			return 2, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, v)
+		close(releaseCh)
+		assert.Equal(t, 1, <-resCh)
+		v, ok := c.Get("a")
+		require.True(t, ok)
+		assert.Equal(t, 2, v)
+
+		// Set while loading
+		c.Delete("a")
+		releaseCh, resCh = load(3)
+		waitLoading()
+		c.Set("a", 4)
+		close(releaseCh)
+		assert.Equal(t, 3, <-resCh)
+		v, ok = c.Get("a")
+		require.True(t, ok)
+		assert.Equal(t, 4, v)
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		assert.Empty(t, c.loads)
+	})
+
+	t.Run("panicking loaders are unregistered", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		_, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+			panic("oops")
+		})
+		require.Error(t, err)
+
+		c.lock.Lock()
+		assert.Empty(t, c.loads)
+		c.lock.Unlock()
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("reset doesn't wait for loads in progress", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		releaseCh := make(chan struct{})
+		resCh := make(chan int)
+		go func() {
+			v, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+				<-releaseCh
+				return 1, nil
+			})
+			assert.NoError(t, err)
+			resCh <- v
+		}()
+		assert.Eventually(t, func() bool {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return len(c.loads) > 0
+		}, time.Second, time.Millisecond)
+
+		c.Reset()
+		v, err := c.GetOrLoad(context.Background(), "a", func(context.Context) (int, error) {
+			return 2, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, v)
+
+		close(releaseCh)
+		assert.Equal(t, 1, <-resCh)
+		v, ok := c.Get("a")
+		require.True(t, ok)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("canceled context returns without waiting", func(t *testing.T) {
+		c := New(Options[string, int]{})
+
+		releaseCh := make(chan struct{})
+		defer close(releaseCh)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := c.GetOrLoad(ctx, "a", func(context.Context) (int, error) {
+			<-releaseCh
+			return 1, nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
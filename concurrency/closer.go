@@ -41,6 +41,9 @@ type RunnerCloserManager struct {
 	// closers are the closers to be closed once the main runners are done.
 	closers []func() error
 
+	// phases are the phases of runners and closers, in startup order.
+	phases []*phase
+
 	// retErr is the error returned by the main runners and closers. Used to
 	// return the resulting error from Close().
 	retErr error
@@ -119,30 +122,42 @@ func (c *RunnerCloserManager) AddCloser(closers ...any) error {
 
 	var errs []error
 	for _, cl := range closers {
-		switch v := cl.(type) {
-		case io.Closer:
-			c.closers = append(c.closers, v.Close)
-		case func(context.Context) error:
-			c.closers = append(c.closers, func() error {
-				// We use a background context here since the fatalShutdownFn will kill
-				// the program if the grace period is exceeded.
-				return v(context.Background())
-			})
-		case func() error:
-			c.closers = append(c.closers, v)
-		case func():
-			c.closers = append(c.closers, func() error {
-				v()
-				return nil
-			})
-		default:
-			errs = append(errs, fmt.Errorf("unsupported closer type: %T", v))
+		fn, err := toCloserFn(cl)
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		c.closers = append(c.closers, fn)
 	}
 
 	return errors.Join(errs...)
 }
 
+// toCloserFn converts a supported closer type into a func() error.
+// Supported types are io.Closer, func(context.Context) error, func() error,
+// and func().
+func toCloserFn(closer any) (func() error, error) {
+	switch v := closer.(type) {
+	case io.Closer:
+		return v.Close, nil
+	case func(context.Context) error:
+		return func() error {
+			// We use a background context here since the fatalShutdownFn will kill
+			// the program if the grace period is exceeded.
+			return v(context.Background())
+		}, nil
+	case func() error:
+		return v, nil
+	case func():
+		return func() error {
+			v()
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported closer type: %T", v)
+	}
+}
+
 // Add implements RunnerManager.Run.
 func (c *RunnerCloserManager) Run(ctx context.Context) error {
 	if !c.running.CompareAndSwap(false, true) {
@@ -152,6 +167,10 @@ func (c *RunnerCloserManager) Run(ctx context.Context) error {
 	// Signal the manager is stopped.
 	defer close(c.stopped)
 
+	if len(c.phases) > 0 {
+		c.addPhaseRunners()
+	}
+
 	// If the main runner has at least one runner, add a closer that will
 	// close the context once Close() is called.
 	if len(c.mngr.runners) > 0 {
@@ -175,20 +194,26 @@ func (c *RunnerCloserManager) Run(ctx context.Context) error {
 	defer c.mngr.lock.Unlock()
 	c.closing.Store(true)
 
-	errs := make([]error, len(c.closers)+1)
+	// The phases are closed in sequence, concurrently with the other closers.
+	closers := c.closers
+	if len(c.phases) > 0 {
+		closers = append(closers, c.closePhases)
+	}
+
+	errs := make([]error, len(closers)+1)
 	errs[0] = rErr
 
-	for _, closer := range c.closers {
+	for _, closer := range closers {
 		go func(closer func() error) {
 			errCh <- closer()
 		}(closer)
 	}
 
 	// Wait for all closers to be done.
-	for i := 1; i < len(c.closers)+1; i++ {
+	for i := 1; i < len(closers)+1; i++ {
 		// Close the fatal shutdown goroutine if all closers are done. This is a
 		// no-op if the fatal go routine is not defined.
-		if i == len(c.closers) {
+		if i == len(closers) {
 			close(c.closeFatalShutdown)
 		}
 		errs[i] = <-errCh
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosersAlreadyClosed is returned when adding a closer to Closers which
+// are already closed.
+var ErrClosersAlreadyClosed = errors.New("closers already closed")
+
+// Closers is a collection of cleanup functions which are closed together, in
+// the reverse order they were added. Closing is only performed once; further
+// calls to Close return the same result.
+// The zero value is ready to use.
+type Closers struct {
+	lock    sync.Mutex
+	closers []*closerEntry
+	closed  bool
+	err     error
+	closeCh chan struct{}
+	doneCh  chan struct{}
+
+	// ctxErrs holds the errors of closers which were closed because their
+	// context was done, before Close was called.
+	ctxErrs []error
+}
+
+// closerEntry wraps a closer so it can be removed from the collection by
+// identity.
+type closerEntry struct {
+	fn func() error
+}
+
+// Add adds closers to the collection. Supported types are io.Closer,
+// func(context.Context) error, func() error, and func().
+// If the collection is already closed, the closers are closed immediately and
+// ErrClosersAlreadyClosed is returned along with any closing errors.
+func (c *Closers) Add(closers ...any) error {
+	fns, err := toCloserFns(closers)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.add(fns)
+	return err
+}
+
+// AddWithContext adds closers to the collection which are also closed as soon
+// as the given context is done. Each closer is only closed once, whichever of
+// the context being done or Close happens first.
+func (c *Closers) AddWithContext(ctx context.Context, closers ...any) error {
+	fns, err := toCloserFns(closers)
+	if err != nil {
+		return err
+	}
+
+	for i := range fns {
+		fns[i] = sync.OnceValue(fns[i])
+	}
+
+	entries, err := c.add(fns)
+	if err != nil {
+		return err
+	}
+
+	closeCh := c.closeChan()
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.closeEntries(entries)
+		case <-closeCh:
+		}
+	}()
+
+	return nil
+}
+
+// Close closes all closers in the reverse order they were added, returning
+// the joined errors. Subsequent calls return the same error.
+func (c *Closers) Close() error {
+	c.lock.Lock()
+	if c.closed {
+		doneCh := c.doneCh
+		c.lock.Unlock()
+		<-doneCh
+		return c.err
+	}
+	c.closed = true
+	c.doneCh = make(chan struct{})
+	defer close(c.doneCh)
+
+	if c.closeCh != nil {
+		close(c.closeCh)
+	}
+
+	entries := c.closers
+	ctxErrs := c.ctxErrs
+	c.closers = nil
+	c.ctxErrs = nil
+	c.lock.Unlock()
+
+	// Closers are called without holding the lock so they can safely interact
+	// with the collection.
+	fns := make([]func() error, len(entries))
+	for i, e := range entries {
+		fns[i] = e.fn
+	}
+	c.err = errors.Join(closeAll(fns), errors.Join(ctxErrs...))
+
+	return c.err
+}
+
+func (c *Closers) add(fns []func() error) ([]*closerEntry, error) {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil, errors.Join(ErrClosersAlreadyClosed, closeAll(fns))
+	}
+	entries := make([]*closerEntry, len(fns))
+	for i, fn := range fns {
+		entries[i] = &closerEntry{fn: fn}
+	}
+	c.closers = append(c.closers, entries...)
+	c.lock.Unlock()
+
+	return entries, nil
+}
+
+// closeEntries closes the given entries in reverse order because their
+// context is done. The entries are removed from the collection so their
+// result is only reported once by Close. If Close has already taken the
+// entries, their result is reported by Close instead.
+func (c *Closers) closeEntries(entries []*closerEntry) {
+	fns := make([]func() error, len(entries))
+	for i, e := range entries {
+		fns[i] = e.fn
+	}
+	err := closeAll(fns)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	remove := make(map[*closerEntry]struct{}, len(entries))
+	for _, e := range entries {
+		remove[e] = struct{}{}
+	}
+	kept := c.closers[:0]
+	for _, e := range c.closers {
+		if _, ok := remove[e]; !ok {
+			kept = append(kept, e)
+		}
+	}
+	clear(c.closers[len(kept):])
+	c.closers = kept
+
+	if err != nil {
+		c.ctxErrs = append(c.ctxErrs, err)
+	}
+}
+
+// closeChan returns a channel which is closed when the collection is closed.
+func (c *Closers) closeChan() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closeCh == nil {
+		c.closeCh = make(chan struct{})
+		if c.closed {
+			close(c.closeCh)
+		}
+	}
+
+	return c.closeCh
+}
+
+// closeAll calls fns in reverse order, returning the joined errors.
+func closeAll(fns []func() error) error {
+	errs := make([]error, 0, len(fns))
+	for i := len(fns) - 1; i >= 0; i-- {
+		errs = append(errs, fns[i]())
+	}
+	return errors.Join(errs...)
+}
+
+func toCloserFns(closers []any) ([]func() error, error) {
+	fns := make([]func() error, 0, len(closers))
+	var errs []error
+	for _, cl := range closers {
+		fn, err := toCloserFn(cl)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fns = append(fns, fn)
+	}
+
+	return fns, errors.Join(errs...)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClosers(t *testing.T) {
+	t.Run("closes in reverse order and joins errors", func(t *testing.T) {
+		var order []int
+		err1 := errors.New("error 1")
+		err3 := errors.New("error 3")
+
+		var c Closers
+		require.NoError(t, c.Add(
+			mockCloser(func() error {
+				order = append(order, 1)
+				return err1
+			}),
+			func() {
+				order = append(order, 2)
+			},
+		))
+		require.NoError(t, c.Add(func(context.Context) error {
+			order = append(order, 3)
+			return err3
+		}))
+
+		err := c.Close()
+		require.ErrorIs(t, err, err1)
+		require.ErrorIs(t, err, err3)
+		assert.Equal(t, []int{3, 2, 1}, order)
+
+		// Closing again is a no-op returning the same error
+		require.Equal(t, err, c.Close())
+		assert.Equal(t, []int{3, 2, 1}, order)
+	})
+
+	t.Run("unsupported closer type", func(t *testing.T) {
+		var c Closers
+		require.Error(t, c.Add("foo"))
+		require.NoError(t, c.Close())
+	})
+
+	t.Run("adding after close closes immediately", func(t *testing.T) {
+		var c Closers
+		require.NoError(t, c.Close())
+
+		var closed atomic.Bool
+		err := c.Add(func() { closed.Store(true) })
+		require.ErrorIs(t, err, ErrClosersAlreadyClosed)
+		assert.True(t, closed.Load())
+	})
+
+	t.Run("AddWithContext closes on context cancellation once", func(t *testing.T) {
+		var c Closers
+		var calls atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, c.AddWithContext(ctx, func() { calls.Add(1) }))
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			return calls.Load() == 1
+		}, time.Second, time.Millisecond*10)
+
+		require.NoError(t, c.Close())
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("AddWithContext closes on Close", func(t *testing.T) {
+		var c Closers
+		var calls atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		require.NoError(t, c.AddWithContext(ctx, func() { calls.Add(1) }))
+
+		require.NoError(t, c.Close())
+		assert.Equal(t, int32(1), calls.Load())
+
+		cancel()
+		time.Sleep(time.Millisecond * 10)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+	t.Run("AddWithContext error is reported once", func(t *testing.T) {
+		var c Closers
+		closeErr := errors.New("close error")
+		var calls atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, c.AddWithContext(ctx, func() error {
+			calls.Add(1)
+			return closeErr
+		}))
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return len(c.closers) == 0
+		}, time.Second, time.Millisecond*10)
+
+		err := c.Close()
+		require.ErrorIs(t, err, closeErr)
+		assert.Equal(t, "close error", err.Error())
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("closers are called without holding the lock", func(t *testing.T) {
+		var c Closers
+		var addErr error
+		require.NoError(t, c.Add(func() {
+			addErr = c.Add(func() {})
+		}))
+
+		done := make(chan struct{})
+		go func() {
+			assert.NoError(t, c.Close())
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.Fatal("Close deadlocked")
+		}
+		require.ErrorIs(t, addErr, ErrClosersAlreadyClosed)
+	})
+}
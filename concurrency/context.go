@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// mergedContext is a context which reports the earliest deadline of all of its
+// parents, and the error of the parent which was done first.
+// The embedded context is canceled with the cause of that parent. It has its
+// own Done channel, so the context package doesn't propagate the cancellation
+// to the children directly from the embedded context, with its
+// context.Canceled error, but with AfterFunc and the merged context's error.
+type mergedContext struct {
+	context.Context
+	deadline    time.Time
+	hasDeadline bool
+	done        chan struct{}
+
+	lock   sync.Mutex
+	err    error
+	cancel context.CancelCauseFunc
+}
+
+func (m *mergedContext) Deadline() (time.Time, bool) {
+	return m.deadline, m.hasDeadline
+}
+
+func (m *mergedContext) Done() <-chan struct{} {
+	return m.done
+}
+
+// AfterFunc arranges to call f after the context is done. It's used by
+// context.AfterFunc and to propagate the cancellation to the children.
+func (m *mergedContext) AfterFunc(f func()) func() bool {
+	return context.AfterFunc(m.Context, f)
+}
+
+func (m *mergedContext) Err() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.err
+}
+
+// cancelWith cancels the context with the given error and cause, unless it's
+// already canceled.
+func (m *mergedContext) cancelWith(err, cause error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.err != nil {
+		return
+	}
+	m.err = err
+	close(m.done)
+	m.cancel(cause)
+}
+
+// MergeContexts returns a context which is canceled as soon as any of the
+// given contexts is canceled, or when the returned CancelFunc is called.
+// Values are read from the first context only, and the error and cancellation
+// cause are the ones of the first parent which is canceled.
+// The returned context's deadline is the earliest of all the parents.
+// No goroutine is started: parents are watched with context.AfterFunc, which
+// are released when the CancelFunc is called, so it must always be called.
+func MergeContexts(ctxs ...context.Context) (context.Context, context.CancelFunc) {
+	if len(ctxs) == 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	// All parents, including the first one, are watched in the same way, so the
+	// error is always the one of the parent which was done.
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(ctxs[0]))
+	merged := &mergedContext{Context: ctx, cancel: cancel, done: make(chan struct{})}
+
+	stops := make([]func() bool, 0, len(ctxs))
+	for _, parent := range ctxs {
+		if d, ok := parent.Deadline(); ok && (!merged.hasDeadline || d.Before(merged.deadline)) {
+			merged.deadline, merged.hasDeadline = d, true
+		}
+		if parent.Err() != nil {
+			merged.cancelWith(parent.Err(), context.Cause(parent))
+			continue
+		}
+		stops = append(stops, context.AfterFunc(parent, func() {
+			merged.cancelWith(parent.Err(), context.Cause(parent))
+		}))
+	}
+
+	return merged, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		merged.cancelWith(context.Canceled, context.Canceled)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCtxKey struct{}
+
+func TestMergeContexts(t *testing.T) {
+	t.Run("no contexts", func(t *testing.T) {
+		ctx, cancel := MergeContexts()
+		require.NoError(t, ctx.Err())
+		cancel()
+		require.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+
+	t.Run("canceled when any parent is canceled", func(t *testing.T) {
+		for i := range 3 {
+			parents := make([]context.Context, 3)
+			cancels := make([]context.CancelCauseFunc, 3)
+			for j := range parents {
+				parents[j], cancels[j] = context.WithCancelCause(context.Background())
+			}
+
+			ctx, cancel := MergeContexts(parents...)
+			defer cancel()
+			require.NoError(t, ctx.Err())
+
+			errCause := errors.New("parent canceled")
+			cancels[i](errCause)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("merged context was not canceled")
+			}
+			require.ErrorIs(t, ctx.Err(), context.Canceled)
+			require.ErrorIs(t, context.Cause(ctx), errCause)
+
+			// Other parents are not affected
+			for j := range parents {
+				if j != i {
+					require.NoError(t, parents[j].Err())
+				}
+			}
+		}
+	})
+
+	t.Run("error of the parent which is done", func(t *testing.T) {
+		parent1, cancel1 := context.WithCancel(context.Background())
+		defer cancel1()
+		parent2, cancel2 := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel2()
+
+		ctx, cancel := MergeContexts(parent1, parent2)
+		defer cancel()
+		child, childCancel := context.WithCancel(ctx)
+		defer childCancel()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("merged context was not canceled")
+		}
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+		require.ErrorIs(t, context.Cause(ctx), context.DeadlineExceeded)
+		require.NoError(t, parent1.Err())
+
+		// Children see the same error
+		select {
+		case <-child.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("child context was not canceled")
+		}
+		require.ErrorIs(t, child.Err(), context.DeadlineExceeded)
+		require.ErrorIs(t, context.Cause(child), context.DeadlineExceeded)
+
+		// Canceling the other parent afterwards doesn't change the error
+		cancel1()
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+
+	t.Run("parent already canceled", func(t *testing.T) {
+		parent, parentCancel := context.WithCancel(context.Background())
+		parentCancel()
+
+		ctx, cancel := MergeContexts(context.Background(), parent)
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("merged context was not canceled")
+		}
+	})
+
+	t.Run("cancel does not affect parents", func(t *testing.T) {
+		parent1, cancel1 := context.WithCancel(context.Background())
+		defer cancel1()
+		parent2, cancel2 := context.WithCancel(context.Background())
+		defer cancel2()
+
+		ctx, cancel := MergeContexts(parent1, parent2)
+		cancel()
+		require.ErrorIs(t, ctx.Err(), context.Canceled)
+		require.NoError(t, parent1.Err())
+		require.NoError(t, parent2.Err())
+	})
+
+	t.Run("values from the first context", func(t *testing.T) {
+		ctx, cancel := MergeContexts(
+			context.WithValue(context.Background(), testCtxKey{}, "first"),
+			context.WithValue(context.Background(), testCtxKey{}, "second"),
+		)
+		defer cancel()
+		assert.Equal(t, "first", ctx.Value(testCtxKey{}))
+	})
+
+	t.Run("earliest deadline", func(t *testing.T) {
+		deadline := time.Now().Add(time.Hour)
+		parent1, cancel1 := context.WithDeadline(context.Background(), deadline.Add(time.Hour))
+		defer cancel1()
+		parent2, cancel2 := context.WithDeadline(context.Background(), deadline)
+		defer cancel2()
+
+		ctx, cancel := MergeContexts(context.Background(), parent1, parent2)
+		defer cancel()
+		got, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.True(t, deadline.Equal(got))
+
+		ctx, cancel = MergeContexts(context.Background())
+		defer cancel()
+		_, ok = ctx.Deadline()
+		assert.False(t, ok)
+	})
+
+	t.Run("no goroutines are started", func(t *testing.T) {
+		parent, parentCancel := context.WithCancel(context.Background())
+		defer parentCancel()
+
+		before := runtime.NumGoroutine()
+		cancels := make([]context.CancelFunc, 100)
+		for i := range cancels {
+			var ctx context.Context
+			ctx, cancels[i] = MergeContexts(context.Background(), parent)
+			// Nor to propagate the cancellation to the children
+			_, childCancel := context.WithCancel(ctx)
+			defer childCancel()
+		}
+		assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+		for _, cancel := range cancels {
+			cancel()
+		}
+	})
+}
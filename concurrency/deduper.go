@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kclock "k8s.io/utils/clock"
+)
+
+// DeduperOptions configures a Deduper.
+type DeduperOptions struct {
+	// TTL is the duration successful results are cached for, so calls with the
+	// same key made within the TTL return the cached result without invoking
+	// the function again.
+	// Defaults to 0, meaning that results are shared only among concurrent
+	// calls and are not cached.
+	TTL time.Duration
+
+	// Clock is the clock used to expire cached results. Used for testing.
+	Clock kclock.Clock
+}
+
+// Deduper collapses concurrent calls with the same key into a single
+// invocation of the function, whose result is shared among all callers, and
+// optionally caches successful results for a TTL.
+type Deduper[K comparable, V any] struct {
+	ttl   time.Duration
+	clock kclock.Clock
+
+	lock      sync.Mutex
+	calls     map[K]*dedupCall[V]
+	cache     map[K]dedupResult[V]
+	lastSweep time.Time
+}
+
+type dedupCall[V any] struct {
+	doneCh chan struct{}
+	res    dedupResult[V]
+}
+
+type dedupResult[V any] struct {
+	val V
+	err error
+	exp time.Time
+}
+
+// NewDeduper returns a new Deduper.
+func NewDeduper[K comparable, V any](opts DeduperOptions) *Deduper[K, V] {
+	cl := opts.Clock
+	if cl == nil {
+		cl = kclock.RealClock{}
+	}
+
+	return &Deduper[K, V]{
+		ttl:   opts.TTL,
+		clock: cl,
+		calls: make(map[K]*dedupCall[V]),
+		cache: make(map[K]dedupResult[V]),
+	}
+}
+
+// Do invokes fn and returns its result, making sure that only one invocation
+// with the same key is in flight at any time: callers with the same key wait
+// for the in-flight invocation and receive its result.
+// If a caller's context is canceled while waiting, Do returns the context's
+// error, but the invocation continues for the other callers; fn receives a
+// context which is not canceled when the callers' contexts are.
+// Panics in fn are returned as errors.
+func (d *Deduper[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	d.lock.Lock()
+	if res, ok := d.cache[key]; ok {
+		if d.clock.Now().Before(res.exp) {
+			d.lock.Unlock()
+			return res.val, res.err
+		}
+		delete(d.cache, key)
+	}
+
+	call, ok := d.calls[key]
+	if !ok {
+		call = &dedupCall[V]{
+			doneCh: make(chan struct{}),
+		}
+		d.calls[key] = call
+		go d.invoke(context.WithoutCancel(ctx), key, call, fn)
+	}
+	d.lock.Unlock()
+
+	select {
+	case <-call.doneCh:
+		return call.res.val, call.res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Forget removes the cached result for the key, if any, and detaches the
+// in-flight invocation, so the next call with the key invokes the function
+// again.
+func (d *Deduper[K, V]) Forget(key K) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.cache, key)
+	delete(d.calls, key)
+}
+
+func (d *Deduper[K, V]) invoke(ctx context.Context, key K, call *dedupCall[V], fn func(ctx context.Context) (V, error)) {
+	defer close(call.doneCh)
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				call.res.err = fmt.Errorf("panic while executing function: %v", rec)
+			}
+		}()
+		call.res.val, call.res.err = fn(ctx)
+	}()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	// The call may have been forgotten and replaced in the meantime
+	if d.calls[key] != call {
+		return
+	}
+	delete(d.calls, key)
+
+	if d.ttl <= 0 || call.res.err != nil {
+		return
+	}
+
+	now := d.clock.Now()
+	call.res.exp = now.Add(d.ttl)
+	d.cache[key] = call.res
+
+	// Periodically remove expired results
+	if now.Sub(d.lastSweep) >= d.ttl {
+		d.lastSweep = now
+		for k, res := range d.cache {
+			if !now.Before(res.exp) {
+				delete(d.cache, k)
+			}
+		}
+	}
+}
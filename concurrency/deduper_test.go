@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestDeduper(t *testing.T) {
+	t.Run("concurrent calls are collapsed", func(t *testing.T) {
+		d := NewDeduper[string, int](DeduperOptions{})
+
+		var invocations atomic.Int32
+		releaseCh := make(chan struct{})
+		fn := func(context.Context) (int, error) {
+			invocations.Add(1)
+			<-releaseCh
+			return 42, nil
+		}
+
+		const callers = 10
+		var wg sync.WaitGroup
+		wg.Add(callers)
+		for range callers {
+			go func() {
+				defer wg.Done()
+				v, err := d.Do(context.Background(), "key", fn)
+				assert.NoError(t, err)
+				assert.Equal(t, 42, v)
+			}()
+		}
+
+		assert.Eventually(t, func() bool {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			return len(d.calls) == 1
+		}, time.Second, time.Millisecond)
+		close(releaseCh)
+		wg.Wait()
+		assert.Equal(t, int32(1), invocations.Load())
+
+		// Without a TTL, results are not cached
+		_, err := d.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), invocations.Load())
+	})
+
+	t.Run("different keys are not collapsed", func(t *testing.T) {
+		d := NewDeduper[string, string](DeduperOptions{})
+		for _, key := range []string{"a", "b"} {
+			v, err := d.Do(context.Background(), key, func(context.Context) (string, error) {
+				return key, nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, key, v)
+		}
+	})
+
+	t.Run("results are cached for the TTL", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		d := NewDeduper[string, int](DeduperOptions{TTL: time.Minute, Clock: clock})
+
+		var invocations atomic.Int32
+		fn := func(context.Context) (int, error) {
+			return int(invocations.Add(1)), nil
+		}
+
+		v, err := d.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+
+		clock.Step(time.Second * 59)
+		v, err = d.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+
+		clock.Step(time.Second)
+		v, err = d.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		assert.Equal(t, 2, v)
+
+		d.Forget("key")
+		v, err = d.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		assert.Equal(t, 3, v)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		d := NewDeduper[string, int](DeduperOptions{TTL: time.Minute})
+
+		var invocations atomic.Int32
+		fn := func(context.Context) (int, error) {
+			invocations.Add(1)
+			return 0, errors.New("failed")
+		}
+
+		for range 2 {
+			_, err := d.Do(context.Background(), "key", fn)
+			require.EqualError(t, err, "failed")
+		}
+		assert.Equal(t, int32(2), invocations.Load())
+	})
+
+	t.Run("panics are returned as errors", func(t *testing.T) {
+		d := NewDeduper[string, int](DeduperOptions{})
+		_, err := d.Do(context.Background(), "key", func(context.Context) (int, error) {
+			panic("oh no")
+		})
+		require.ErrorContains(t, err, "oh no")
+	})
+
+	t.Run("canceled waiters detach without canceling the call", func(t *testing.T) {
+		d := NewDeduper[string, int](DeduperOptions{})
+
+		releaseCh := make(chan struct{})
+		fnCtxErrCh := make(chan error, 2)
+		fn := func(ctx context.Context) (int, error) {
+			<-releaseCh
+			fnCtxErrCh <- ctx.Err()
+			return 42, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			_, err := d.Do(ctx, "key", fn)
+			errCh <- err
+		}()
+		resCh := make(chan int)
+		go func() {
+			v, err := d.Do(context.Background(), "key", fn)
+			assert.NoError(t, err)
+			resCh <- v
+		}()
+
+		cancel()
+		select {
+		case err := <-errCh:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout")
+		}
+
+		close(releaseCh)
+		select {
+		case v := <-resCh:
+			assert.Equal(t, 42, v)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout")
+		}
+		require.NoError(t, <-fnCtxErrCh)
+	})
+}
@@ -25,6 +25,11 @@ import (
 type Options struct {
 	Log    logger.Logger
 	Target string
+
+	// Checksums enables writing the SHA-256 checksums of the files alongside
+	// them, in the ChecksumsFile file, and requires them to be present when
+	// the files are read with Read.
+	Checksums bool
 }
 
 // Dir atomically writes files to a given directory.
@@ -35,6 +40,8 @@ type Dir struct {
 	target    string
 	targetDir string
 
+	checksums bool
+
 	prev *string
 }
 
@@ -44,6 +51,7 @@ func New(opts Options) *Dir {
 		base:      filepath.Dir(opts.Target),
 		target:    opts.Target,
 		targetDir: filepath.Base(opts.Target),
+		checksums: opts.Checksums,
 	}
 }
 
@@ -66,6 +74,12 @@ func (d *Dir) Write(files map[string][]byte) error {
 		d.log.Infof("Written file %s", file)
 	}
 
+	if d.checksums {
+		if err := os.WriteFile(filepath.Join(newDir, ChecksumsFile), encodeChecksums(files), os.ModePerm); err != nil {
+			return err
+		}
+	}
+
 	if err := os.Symlink(newDir, d.target+".new"); err != nil {
 		return err
 	}
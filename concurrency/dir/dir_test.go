@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestRead(t *testing.T) {
+	newDir := func(t *testing.T, checksums bool) *Dir {
+		t.Helper()
+		return New(Options{
+			Log:       logger.NewLogger("test"),
+			Target:    filepath.Join(t.TempDir(), "target"),
+			Checksums: checksums,
+		})
+	}
+
+	files := map[string][]byte{
+		"a.txt": []byte("hello"),
+		"b.txt": []byte("world"),
+	}
+
+	t.Run("reads all files", func(t *testing.T) {
+		for _, checksums := range []bool{false, true} {
+			d := newDir(t, checksums)
+			require.NoError(t, d.Write(files))
+
+			res, err := d.Read()
+			require.NoError(t, err)
+			assert.Equal(t, files, res)
+		}
+	})
+
+	t.Run("reads named files from the latest version", func(t *testing.T) {
+		d := newDir(t, true)
+		require.NoError(t, d.Write(files))
+		require.NoError(t, d.Write(map[string][]byte{
+			"a.txt": []byte("hello again"),
+		}))
+
+		res, err := d.Read("a.txt")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"a.txt": []byte("hello again")}, res)
+
+		_, err = d.Read("b.txt")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		d := newDir(t, true)
+		require.NoError(t, d.Write(files))
+
+		require.NoError(t, os.WriteFile(filepath.Join(d.target, "b.txt"), []byte("tampered"), os.ModePerm))
+
+		_, err := d.Read("a.txt")
+		require.NoError(t, err)
+		_, err = d.Read()
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+		assert.Contains(t, err.Error(), "b.txt")
+	})
+
+	t.Run("missing checksums", func(t *testing.T) {
+		d := newDir(t, false)
+		require.NoError(t, d.Write(files))
+
+		// Not required if checksums are not enabled
+		_, err := d.Read()
+		require.NoError(t, err)
+
+		d.checksums = true
+		_, err = d.Read()
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("file without checksum", func(t *testing.T) {
+		d := newDir(t, true)
+		require.NoError(t, d.Write(files))
+
+		require.NoError(t, os.WriteFile(filepath.Join(d.target, "c.txt"), []byte("extra"), os.ModePerm))
+
+		_, err := d.Read("c.txt")
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("not written", func(t *testing.T) {
+		d := newDir(t, false)
+		_, err := d.Read()
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		d := newDir(t, true)
+		require.NoError(t, d.Write(files))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 100 {
+				if !assert.NoError(t, d.Write(files)) {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			res, err := d.Read()
+			if err != nil {
+				require.ErrorIs(t, err, ErrTornRead)
+				continue
+			}
+			assert.Equal(t, files, res)
+		}
+	})
+}
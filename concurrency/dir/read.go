@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dir
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ChecksumsFile is the name of the file containing the checksums of the files
+// written by a Dir with checksums enabled.
+// Each line contains the hex-encoded SHA-256 checksum of a file and its name,
+// separated by two spaces, as in the output of sha256sum.
+const ChecksumsFile = ".checksums.sha256"
+
+var (
+	// ErrTornRead is returned by Read when the directory was swapped by a
+	// concurrent Write while being read. Reading again normally succeeds.
+	ErrTornRead = errors.New("directory was swapped while being read")
+
+	// ErrChecksumMismatch is returned by Read when the checksum of a file
+	// doesn't match the one written alongside it, or is missing.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// Read reads the given files from the current version of the directory, or all
+// the files if no name is given.
+// All the files are read from the same version, and ErrTornRead is returned if
+// the directory is swapped while reading.
+// If the directory contains checksums, the files are verified against them,
+// returning ErrChecksumMismatch if a file doesn't match. Checksums are
+// required if they are enabled in the Options.
+func (d *Dir) Read(names ...string) (map[string][]byte, error) {
+	link, err := os.Readlink(d.target)
+	if err != nil {
+		return nil, err
+	}
+	versionDir := link
+	if !filepath.IsAbs(versionDir) {
+		versionDir = filepath.Join(d.base, versionDir)
+	}
+
+	res, err := d.readVersion(versionDir, names)
+
+	// Check that the directory has not been swapped, which can also cause
+	// the version being read to be removed
+	current, lerr := os.Readlink(d.target)
+	if lerr != nil || current != link {
+		return nil, ErrTornRead
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (d *Dir) readVersion(versionDir string, names []string) (map[string][]byte, error) {
+	if len(names) == 0 {
+		entries, err := os.ReadDir(versionDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && e.Name() != ChecksumsFile {
+				names = append(names, e.Name())
+			}
+		}
+	}
+
+	res := make(map[string][]byte, len(names))
+	for _, name := range names {
+		b, err := os.ReadFile(filepath.Join(versionDir, name))
+		if err != nil {
+			return nil, err
+		}
+		res[name] = b
+	}
+
+	checksumsData, err := os.ReadFile(filepath.Join(versionDir, ChecksumsFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if d.checksums {
+			return nil, fmt.Errorf("%w: %s not found", ErrChecksumMismatch, ChecksumsFile)
+		}
+		return res, nil
+	case err != nil:
+		return nil, err
+	}
+
+	checksums, err := decodeChecksums(checksumsData)
+	if err != nil {
+		return nil, err
+	}
+	for name, b := range res {
+		expect, ok := checksums[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: no checksum for file %s", ErrChecksumMismatch, name)
+		}
+		sum := sha256.Sum256(b)
+		if subtle.ConstantTimeCompare(sum[:], expect) != 1 {
+			return nil, fmt.Errorf("%w: file %s", ErrChecksumMismatch, name)
+		}
+	}
+
+	return res, nil
+}
+
+// encodeChecksums returns the content of the checksums file for the given
+// files.
+func encodeChecksums(files map[string][]byte) []byte {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		sum := sha256.Sum256(files[name])
+		buf.WriteString(hex.EncodeToString(sum[:]))
+		buf.WriteString("  ")
+		buf.WriteString(name)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// decodeChecksums parses the content of a checksums file.
+func decodeChecksums(data []byte) (map[string][]byte, error) {
+	res := make(map[string][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line in %s: %q", ChecksumsFile, line)
+		}
+		b, err := hex.DecodeString(sum)
+		if err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid checksum in %s for file %s", ChecksumsFile, name)
+		}
+		res[name] = b
+	}
+	return res, scanner.Err()
+}
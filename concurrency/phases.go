@@ -0,0 +1,282 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPhaseDrainTimeout is returned when the closers of a shutdown phase
+	// don't return within the phase's drain timeout.
+	ErrPhaseDrainTimeout = errors.New("phase drain timeout exceeded")
+
+	// ErrPhaseNotFound is returned when adding runners or closers to a phase
+	// which was not added.
+	ErrPhaseNotFound = errors.New("phase not found")
+)
+
+// PhaseOptions configures a phase of a RunnerCloserManager.
+type PhaseOptions struct {
+	// Name is the name of the phase, such as "stores" or "servers". It must be
+	// unique within the manager.
+	Name string
+
+	// DrainTimeout is the maximum time to wait for the closers of the phase to
+	// return before moving on to the next phase. The closers which haven't
+	// returned are not interrupted, and ErrPhaseDrainTimeout is returned by
+	// Run.
+	// Defaults to 0, in which case the closers are waited for indefinitely,
+	// bounded only by the grace period of the manager.
+	DrainTimeout time.Duration
+
+	// ReadyFn is an optional function which blocks until the runners of the
+	// phase are ready, for example until a server is listening. The runners of
+	// the next phase are started once it returns. If it returns an error, the
+	// manager is stopped.
+	ReadyFn func(ctx context.Context) error
+}
+
+// phase is a group of runners and closers which are started and closed
+// together.
+type phase struct {
+	opts    PhaseOptions
+	runners []Runner
+	closers []func() error
+}
+
+// AddPhase adds a phase to the manager. Phases are started in the order they
+// are added, and closed in the reverse order: for example, phases added as
+// "stores", "consumers", "servers" are closed as "servers", "consumers",
+// "stores".
+// The runners of a phase are started once the previous phase is ready; see
+// PhaseOptions.ReadyFn. When the manager is closed, or any runner returns,
+// the runners of the phases are stopped in the reverse order: the context of
+// the runners of a phase is cancelled only once the runners of the next phase
+// have returned.
+// Once the runners are done, the closers of each phase are run concurrently,
+// and the closers of the next phase only once those of the previous phase
+// have returned, or the phase's drain timeout is exceeded. Closers added with
+// AddCloser are run concurrently with the phases.
+func (c *RunnerCloserManager) AddPhase(opts PhaseOptions) error {
+	if c.running.Load() {
+		return ErrManagerAlreadyStarted
+	}
+	if opts.Name == "" {
+		return errors.New("phase name is required")
+	}
+
+	c.mngr.lock.Lock()
+	defer c.mngr.lock.Unlock()
+
+	if c.phaseLocked(opts.Name) != nil {
+		return fmt.Errorf("phase %q already exists", opts.Name)
+	}
+	c.phases = append(c.phases, &phase{opts: opts})
+
+	return nil
+}
+
+// AddPhaseRunner adds runners to the phase with the given name.
+func (c *RunnerCloserManager) AddPhaseRunner(name string, runners ...Runner) error {
+	if c.running.Load() {
+		return ErrManagerAlreadyStarted
+	}
+
+	c.mngr.lock.Lock()
+	defer c.mngr.lock.Unlock()
+
+	p := c.phaseLocked(name)
+	if p == nil {
+		return fmt.Errorf("%w: %q", ErrPhaseNotFound, name)
+	}
+	p.runners = append(p.runners, runners...)
+
+	return nil
+}
+
+// AddPhaseCloser adds closers to the phase with the given name. Supported
+// types are the same as AddCloser.
+func (c *RunnerCloserManager) AddPhaseCloser(name string, closers ...any) error {
+	if c.closing.Load() {
+		return ErrManagerAlreadyClosed
+	}
+
+	fns, err := toCloserFns(closers)
+	if err != nil {
+		return err
+	}
+
+	c.mngr.lock.Lock()
+	defer c.mngr.lock.Unlock()
+
+	p := c.phaseLocked(name)
+	if p == nil {
+		return fmt.Errorf("%w: %q", ErrPhaseNotFound, name)
+	}
+	p.closers = append(p.closers, fns...)
+
+	return nil
+}
+
+// phaseLocked returns the phase with the given name, or nil if it doesn't
+// exist. This must be invoked while the caller has a lock.
+func (c *RunnerCloserManager) phaseLocked(name string) *phase {
+	i := slices.IndexFunc(c.phases, func(p *phase) bool {
+		return p.opts.Name == name
+	})
+	if i < 0 {
+		return nil
+	}
+	return c.phases[i]
+}
+
+// addPhaseRunners adds a runner to the main runners which runs the runners of
+// the phases; see runPhases.
+// Nothing is added if no phase has runners, so the manager returns right away
+// like a manager without runners.
+func (c *RunnerCloserManager) addPhaseRunners() {
+	if !slices.ContainsFunc(c.phases, func(p *phase) bool {
+		return len(p.runners) > 0
+	}) {
+		return
+	}
+
+	c.mngr.Add(c.runPhases)
+}
+
+// runPhases starts the runners of each phase once the previous phase is
+// ready. Once ctx is cancelled, or any runner returns, it cancels the runners
+// of each phase and waits for them to return, in the reverse order the phases
+// were added.
+func (c *RunnerCloserManager) runPhases(ctx context.Context) error {
+	// Cancelled when any runner returns, which stops all the phases
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var (
+		errsLock sync.Mutex
+		errs     []error
+	)
+	addErr := func(err error) {
+		errsLock.Lock()
+		defer errsLock.Unlock()
+		errs = append(errs, err)
+	}
+
+	// The contexts of the runners are not derived from ctx, so that each phase
+	// is cancelled only once the next one has stopped
+	cancels := make([]context.CancelFunc, 0, len(c.phases))
+	wgs := make([]*sync.WaitGroup, 0, len(c.phases))
+
+	for _, p := range c.phases {
+		if stopCtx.Err() != nil {
+			break
+		}
+
+		phaseCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		wg := &sync.WaitGroup{}
+		cancels = append(cancels, cancel)
+		wgs = append(wgs, wg)
+
+		for _, runner := range p.runners {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer stop()
+
+				// Context cancelled errors are ignored like in RunnerManager
+				if err := runner(phaseCtx); err != nil && !errors.Is(err, context.Canceled) {
+					addErr(err)
+				}
+			}()
+		}
+
+		if p.opts.ReadyFn != nil {
+			log.Debugf("Waiting for phase %q to be ready", p.opts.Name)
+			if err := p.opts.ReadyFn(stopCtx); err != nil {
+				if stopCtx.Err() == nil {
+					addErr(fmt.Errorf("phase %q failed to become ready: %w", p.opts.Name, err))
+				}
+				stop()
+				break
+			}
+		}
+	}
+
+	<-stopCtx.Done()
+	for i := len(cancels) - 1; i >= 0; i-- {
+		log.Debugf("Stopping runners of phase %q", c.phases[i].opts.Name)
+		cancels[i]()
+		wgs[i].Wait()
+	}
+
+	errsLock.Lock()
+	defer errsLock.Unlock()
+	return errors.Join(errs...)
+}
+
+// closePhases runs the closers of the phases, in the reverse order the phases
+// were added.
+func (c *RunnerCloserManager) closePhases() error {
+	var errs []error
+	for _, p := range slices.Backward(c.phases) {
+		log.Debugf("Closing phase %q", p.opts.Name)
+		if err := c.closePhase(p); err != nil {
+			errs = append(errs, fmt.Errorf("phase %q: %w", p.opts.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closePhase runs the closers of the phase concurrently, waiting for them to
+// return or for the drain timeout to be exceeded.
+// The closers are not modified concurrently, since Run holds the lock while
+// closing.
+func (c *RunnerCloserManager) closePhase(p *phase) error {
+	closers := p.closers
+
+	// Buffered so closers which return after the drain timeout don't block
+	errCh := make(chan error, len(closers))
+	for _, closer := range closers {
+		go func() {
+			errCh <- closer()
+		}()
+	}
+
+	var timeoutCh <-chan time.Time
+	if p.opts.DrainTimeout > 0 {
+		t := c.clock.NewTimer(p.opts.DrainTimeout)
+		defer t.Stop()
+		timeoutCh = t.C()
+	}
+
+	errs := make([]error, 0, len(closers))
+	for range closers {
+		select {
+		case err := <-errCh:
+			errs = append(errs, err)
+		case <-timeoutCh:
+			log.Warnf("Drain timeout of %s exceeded for phase %q, moving on to the next phase", p.opts.DrainTimeout, p.opts.Name)
+			errs = append(errs, ErrPhaseDrainTimeout)
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestPhases(t *testing.T) {
+	t.Run("runners are started in phase order", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		storesReady := make(chan struct{})
+		serverStarted := make(chan struct{})
+
+		require.NoError(t, mngr.AddPhase(PhaseOptions{
+			Name: "stores",
+			ReadyFn: func(ctx context.Context) error {
+				select {
+				case <-storesReady:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		}))
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+		require.NoError(t, mngr.AddPhaseRunner("stores", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}))
+		require.NoError(t, mngr.AddPhaseRunner("servers", func(ctx context.Context) error {
+			close(serverStarted)
+			<-ctx.Done()
+			return nil
+		}))
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- mngr.Run(context.Background())
+		}()
+
+		select {
+		case <-serverStarted:
+			require.Fail(t, "servers should not start before stores are ready")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(storesReady)
+		select {
+		case <-serverStarted:
+		case <-time.After(time.Second):
+			require.Fail(t, "servers should start once stores are ready")
+		}
+
+		require.NoError(t, mngr.Close())
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("a phase failing to become ready stops the manager", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		require.NoError(t, mngr.AddPhase(PhaseOptions{
+			Name: "stores",
+			ReadyFn: func(context.Context) error {
+				return errors.New("not ready")
+			},
+		}))
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+
+		var serverStarted bool
+		require.NoError(t, mngr.AddPhaseRunner("servers", func(ctx context.Context) error {
+			serverStarted = true
+			<-ctx.Done()
+			return nil
+		}))
+
+		err := mngr.Run(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `phase "stores" failed to become ready: not ready`)
+		assert.False(t, serverStarted)
+	})
+
+	t.Run("runners are stopped in reverse phase order", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "stores"}))
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+
+		var (
+			lock    sync.Mutex
+			stopped []string
+		)
+		runner := func(name string) Runner {
+			return func(ctx context.Context) error {
+				<-ctx.Done()
+				// Give the runners of earlier phases time to return if they
+				// were cancelled at the same time
+				time.Sleep(20 * time.Millisecond)
+				lock.Lock()
+				defer lock.Unlock()
+				stopped = append(stopped, name)
+				return nil
+			}
+		}
+		require.NoError(t, mngr.AddPhaseRunner("stores", runner("store1"), runner("store2")))
+		require.NoError(t, mngr.AddPhaseRunner("servers", runner("server")))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- mngr.Run(ctx)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		require.NoError(t, <-errCh)
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, stopped, 3)
+		assert.Equal(t, "server", stopped[0])
+		assert.ElementsMatch(t, []string{"store1", "store2"}, stopped[1:])
+	})
+
+	t.Run("a runner returning stops all the phases", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "stores"}))
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+		require.NoError(t, mngr.AddPhaseRunner("stores", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}))
+		require.NoError(t, mngr.AddPhaseRunner("servers", func(context.Context) error {
+			return errors.New("listen failed")
+		}))
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- mngr.Run(context.Background())
+		}()
+
+		select {
+		case err := <-errCh:
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "listen failed")
+		case <-time.After(time.Second):
+			require.Fail(t, "manager should stop when a runner returns")
+		}
+	})
+
+	t.Run("closers are run in reverse phase order", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+
+		var (
+			lock  sync.Mutex
+			order []string
+		)
+		closer := func(name string) func() {
+			return func() {
+				lock.Lock()
+				defer lock.Unlock()
+				order = append(order, name)
+			}
+		}
+
+		for _, name := range []string{"stores", "consumers", "servers"} {
+			require.NoError(t, mngr.AddPhase(PhaseOptions{Name: name}))
+			require.NoError(t, mngr.AddPhaseCloser(name, closer(name), closer(name)))
+		}
+		unphasedCalled := make(chan struct{})
+		require.NoError(t, mngr.AddCloser(func() {
+			close(unphasedCalled)
+		}))
+
+		require.NoError(t, mngr.Run(context.Background()))
+		assert.Equal(t, []string{"servers", "servers", "consumers", "consumers", "stores", "stores"}, order)
+		select {
+		case <-unphasedCalled:
+		default:
+			assert.Fail(t, "unphased closer should be called")
+		}
+	})
+
+	t.Run("closer errors are returned", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+		require.NoError(t, mngr.AddPhaseCloser("servers", func() error {
+			return errors.New("closer error")
+		}))
+
+		err := mngr.Run(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, `phase "servers": closer error`, err.Error())
+	})
+
+	t.Run("exceeding the drain timeout moves on to the next phase", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		clock := clocktesting.NewFakeClock(time.Now())
+		mngr.clock = clock
+
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "stores"}))
+		require.NoError(t, mngr.AddPhase(PhaseOptions{
+			Name:         "servers",
+			DrainTimeout: time.Second,
+		}))
+
+		blockCh := make(chan struct{})
+		t.Cleanup(func() { close(blockCh) })
+		require.NoError(t, mngr.AddPhaseCloser("servers", func() {
+			<-blockCh
+		}))
+		storesClosed := make(chan struct{})
+		require.NoError(t, mngr.AddPhaseCloser("stores", func() {
+			close(storesClosed)
+		}))
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- mngr.Run(context.Background())
+		}()
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		select {
+		case <-storesClosed:
+			require.Fail(t, "stores should not be closed before the servers drain timeout")
+		default:
+		}
+
+		clock.Step(time.Second)
+		select {
+		case <-storesClosed:
+		case <-time.After(time.Second):
+			require.Fail(t, "stores should be closed after the servers drain timeout")
+		}
+
+		select {
+		case err := <-errCh:
+			require.ErrorIs(t, err, ErrPhaseDrainTimeout)
+		case <-time.After(time.Second):
+			require.Fail(t, "Run should return")
+		}
+	})
+
+	t.Run("invalid phases", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		require.Error(t, mngr.AddPhase(PhaseOptions{}))
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+		require.Error(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+
+		require.ErrorIs(t, mngr.AddPhaseRunner("stores", func(context.Context) error { return nil }), ErrPhaseNotFound)
+		require.ErrorIs(t, mngr.AddPhaseCloser("stores", func() {}), ErrPhaseNotFound)
+		require.Error(t, mngr.AddPhaseCloser("servers", "not a closer"))
+	})
+
+	t.Run("phases can't be added to a started manager", func(t *testing.T) {
+		mngr := NewRunnerCloserManager(nil)
+		require.NoError(t, mngr.AddPhase(PhaseOptions{Name: "servers"}))
+		require.NoError(t, mngr.Run(context.Background()))
+
+		require.ErrorIs(t, mngr.AddPhase(PhaseOptions{Name: "stores"}), ErrManagerAlreadyStarted)
+		require.ErrorIs(t, mngr.AddPhaseRunner("servers", func(context.Context) error { return nil }), ErrManagerAlreadyStarted)
+		require.ErrorIs(t, mngr.AddPhaseCloser("servers", func() {}), ErrManagerAlreadyClosed)
+	})
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+var (
+	// ErrPoolClosed is returned when submitting a job to a pool which is draining or drained.
+	ErrPoolClosed = errors.New("pool is closed")
+	// ErrPoolFull is returned when submitting a job to a pool whose queue is full, if the pool rejects jobs when full.
+	ErrPoolFull = errors.New("pool queue is full")
+)
+
+// PoolOptions configures a Pool.
+type PoolOptions[T any] struct {
+	// Handler is the function invoked by the workers to process each job.
+	// The context is canceled if the pool is not drained within the deadline
+	// passed to Drain.
+	Handler func(ctx context.Context, job T) error
+
+	// ErrorHandler is invoked with the job and the error if Handler returns an
+	// error or panics. Optional.
+	ErrorHandler func(job T, err error)
+
+	// Workers is the number of workers processing jobs concurrently.
+	// Defaults to the number of CPUs.
+	Workers int
+
+	// QueueSize is the number of jobs which can be waiting for a worker.
+	// When the queue is full, Submit blocks until there's room in the queue,
+	// unless RejectWhenFull is true.
+	// Defaults to 0, meaning that jobs are handed over directly to idle workers.
+	QueueSize int
+
+	// RejectWhenFull makes Submit return ErrPoolFull rather than blocking when
+	// the queue is full.
+	RejectWhenFull bool
+}
+
+// Pool is a pool of workers which process jobs of type T from a bounded
+// queue.
+type Pool[T any] struct {
+	handler        func(ctx context.Context, job T) error
+	errorHandler   func(job T, err error)
+	rejectWhenFull bool
+
+	queue      chan T
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lock       sync.RWMutex
+	closed     bool
+	closeCh    chan struct{}
+	closeOnce  sync.Once
+	doneCh     chan struct{}
+	submitting sync.WaitGroup
+	workers    sync.WaitGroup
+}
+
+// NewPool returns a new Pool and starts its workers.
+// The workers run until the pool is drained with Drain.
+func NewPool[T any](opts PoolOptions[T]) (*Pool[T], error) {
+	if opts.Handler == nil {
+		return nil, errors.New("handler is required")
+	}
+	if opts.Workers < 0 {
+		return nil, errors.New("number of workers must not be negative")
+	}
+	if opts.QueueSize < 0 {
+		return nil, errors.New("queue size must not be negative")
+	}
+
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T]{
+		handler:        opts.Handler,
+		errorHandler:   opts.ErrorHandler,
+		rejectWhenFull: opts.RejectWhenFull,
+		queue:          make(chan T, opts.QueueSize),
+		ctx:            ctx,
+		cancel:         cancel,
+		closeCh:        make(chan struct{}),
+		doneCh:         make(chan struct{}),
+	}
+
+	p.workers.Add(workers)
+	for range workers {
+		go func() {
+			defer p.workers.Done()
+			for job := range p.queue {
+				p.run(job)
+			}
+		}()
+	}
+
+	return p, nil
+}
+
+// Submit adds a job to the queue.
+// If the queue is full, it blocks until there's room in the queue or ctx is
+// canceled, or it returns ErrPoolFull if the pool rejects jobs when full.
+// It returns ErrPoolClosed if the pool is draining.
+func (p *Pool[T]) Submit(ctx context.Context, job T) error {
+	p.lock.RLock()
+	if p.closed {
+		p.lock.RUnlock()
+		return ErrPoolClosed
+	}
+	p.submitting.Add(1)
+	p.lock.RUnlock()
+	defer p.submitting.Done()
+
+	if p.rejectWhenFull {
+		select {
+		case p.queue <- job:
+			return nil
+		case <-p.closeCh:
+			return ErrPoolClosed
+		default:
+			return ErrPoolFull
+		}
+	}
+
+	select {
+	case p.queue <- job:
+		return nil
+	case <-p.closeCh:
+		return ErrPoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pending returns the number of jobs waiting in the queue.
+func (p *Pool[T]) Pending() int {
+	return len(p.queue)
+}
+
+// Drain stops accepting new jobs and waits until all queued and in-flight
+// jobs are completed, or ctx is canceled.
+// If ctx is canceled first, the context passed to the running jobs is
+// canceled and ctx's error is returned; the workers still process the
+// remaining jobs in the background.
+// It is safe to call Drain multiple times.
+func (p *Pool[T]) Drain(ctx context.Context) error {
+	p.closeOnce.Do(func() {
+		p.lock.Lock()
+		p.closed = true
+		p.lock.Unlock()
+		close(p.closeCh)
+
+		go func() {
+			// Wait for any in-progress Submit to return before closing the queue
+			p.submitting.Wait()
+			close(p.queue)
+			p.workers.Wait()
+			p.cancel()
+			close(p.doneCh)
+		}()
+	})
+
+	select {
+	case <-p.doneCh:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// run processes a job, recovering from panics.
+func (p *Pool[T]) run(job T) {
+	err := func() (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("panic while processing job: %v", rec)
+			}
+		}()
+		return p.handler(p.ctx, job)
+	}()
+
+	if err != nil && p.errorHandler != nil {
+		p.errorHandler(job, err)
+	}
+}
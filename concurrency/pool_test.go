@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewPool(PoolOptions[int]{})
+		require.Error(t, err)
+
+		_, err = NewPool(PoolOptions[int]{
+			Handler: func(context.Context, int) error { return nil },
+			Workers: -1,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("processes all jobs and drains", func(t *testing.T) {
+		var sum atomic.Int64
+		p, err := NewPool(PoolOptions[int]{
+			Handler: func(_ context.Context, job int) error {
+				sum.Add(int64(job))
+				return nil
+			},
+			Workers:   4,
+			QueueSize: 10,
+		})
+		require.NoError(t, err)
+
+		for i := 1; i <= 100; i++ {
+			require.NoError(t, p.Submit(context.Background(), i))
+		}
+		require.NoError(t, p.Drain(context.Background()))
+		assert.Equal(t, int64(5050), sum.Load())
+
+		require.ErrorIs(t, p.Submit(context.Background(), 1), ErrPoolClosed)
+		require.NoError(t, p.Drain(context.Background()))
+	})
+
+	t.Run("errors and panics are passed to the error handler", func(t *testing.T) {
+		errCh := make(chan error, 2)
+		p, err := NewPool(PoolOptions[int]{
+			Handler: func(_ context.Context, job int) error {
+				if job == 1 {
+					return errors.New("failed")
+				}
+				panic("oops")
+			},
+			ErrorHandler: func(_ int, err error) {
+				errCh <- err
+			},
+			Workers: 1,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.Submit(context.Background(), 1))
+		require.NoError(t, p.Submit(context.Background(), 2))
+		require.NoError(t, p.Drain(context.Background()))
+
+		require.EqualError(t, <-errCh, "failed")
+		require.ErrorContains(t, <-errCh, "oops")
+	})
+
+	t.Run("rejects jobs when full", func(t *testing.T) {
+		release := make(chan struct{})
+		started := make(chan struct{})
+		p, err := NewPool(PoolOptions[int]{
+			Handler: func(context.Context, int) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			},
+			Workers:        1,
+			QueueSize:      1,
+			RejectWhenFull: true,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.Submit(context.Background(), 1))
+		<-started
+		require.NoError(t, p.Submit(context.Background(), 2))
+		assert.Equal(t, 1, p.Pending())
+		require.ErrorIs(t, p.Submit(context.Background(), 3), ErrPoolFull)
+
+		close(release)
+		<-started
+		require.NoError(t, p.Drain(context.Background()))
+	})
+
+	t.Run("submit blocks until there's room or context is canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		p, err := NewPool(PoolOptions[int]{
+			Handler: func(context.Context, int) error {
+				<-release
+				return nil
+			},
+			Workers: 1,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.Submit(context.Background(), 1))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, p.Submit(ctx, 2), context.DeadlineExceeded)
+
+		submitErr := make(chan error)
+		go func() {
+			submitErr <- p.Submit(context.Background(), 3)
+		}()
+		close(release)
+		require.NoError(t, <-submitErr)
+		require.NoError(t, p.Drain(context.Background()))
+	})
+
+	t.Run("drain times out and cancels running jobs", func(t *testing.T) {
+		canceled := make(chan struct{})
+		started := make(chan struct{})
+		p, err := NewPool(PoolOptions[int]{
+			Handler: func(ctx context.Context, _ int) error {
+				close(started)
+				<-ctx.Done()
+				close(canceled)
+				return ctx.Err()
+			},
+			Workers: 1,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.Submit(context.Background(), 1))
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, p.Drain(ctx), context.DeadlineExceeded)
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected job context to be canceled")
+		}
+		require.NoError(t, p.Drain(context.Background()))
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// SleepCtx pauses for the duration d on the clock, or until the context is
+// canceled. It returns the context's error if it was canceled before d
+// elapsed, or nil otherwise.
+// If clk is nil, the real clock is used. Durations of 0 or less return as
+// soon as the clock's timers fire, which for fake clocks is the next step.
+func SleepCtx(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+
+	t := clk.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ticker is a ticker on a clock which is stopped when a context is canceled.
+// Use NewTicker to create one.
+type Ticker struct {
+	ctx    context.Context
+	ticker clock.Ticker
+	stop   func() bool
+}
+
+// NewTicker returns a Ticker which ticks every d on the clock until ctx is
+// canceled or Stop is called. If clk is nil, the real clock is used.
+// No goroutine is started; the ticker is stopped with context.AfterFunc.
+//
+// A typical loop is:
+//
+//	t := NewTicker(ctx, clk, interval)
+//	defer t.Stop()
+//	for t.Next() == nil {
+//		// ...
+//	}
+func NewTicker(ctx context.Context, clk clock.WithTicker, d time.Duration) *Ticker {
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	t := &Ticker{
+		ctx:    ctx,
+		ticker: clk.NewTicker(d),
+	}
+	t.stop = context.AfterFunc(ctx, t.ticker.Stop)
+	return t
+}
+
+// C returns the channel on which the ticks are delivered, for use in select
+// statements together with other channels. Note that the channel isn't
+// closed when the ticker is stopped.
+func (t *Ticker) C() <-chan time.Time {
+	return t.ticker.C()
+}
+
+// Next waits for the next tick. It returns the context's error if the context
+// is canceled first, or nil otherwise.
+func (t *Ticker) Next() error {
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-t.ticker.C():
+		return nil
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
+
+// Stop stops the ticker and releases its resources. No more ticks are
+// delivered after it returns.
+func (t *Ticker) Stop() {
+	t.stop()
+	t.ticker.Stop()
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestSleepCtx(t *testing.T) {
+	t.Run("returns after the duration", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		errCh := make(chan error)
+		go func() {
+			errCh <- SleepCtx(context.Background(), clock, time.Minute)
+		}()
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Minute - time.Second)
+		select {
+		case <-errCh:
+			require.Fail(t, "returned before the duration elapsed")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clock.Step(time.Second)
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "did not return after the duration elapsed")
+		}
+		assert.False(t, clock.HasWaiters())
+	})
+
+	t.Run("returns when the context is canceled", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- SleepCtx(ctx, clock, time.Minute)
+		}()
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		cancel()
+		select {
+		case err := <-errCh:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			require.Fail(t, "did not return after the context was canceled")
+		}
+
+		// The timer is stopped
+		assert.False(t, clock.HasWaiters())
+	})
+
+	t.Run("context already canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		clock := clocktesting.NewFakeClock(time.Now())
+		require.ErrorIs(t, SleepCtx(ctx, clock, 0), context.Canceled)
+		assert.False(t, clock.HasWaiters())
+	})
+
+	t.Run("real clock", func(t *testing.T) {
+		start := time.Now()
+		require.NoError(t, SleepCtx(context.Background(), nil, 10*time.Millisecond))
+		assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	})
+}
+
+func TestTicker(t *testing.T) {
+	t.Run("ticks until the context is canceled", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ticker := NewTicker(ctx, clock, time.Second)
+		defer ticker.Stop()
+
+		ticks := make(chan struct{})
+		errCh := make(chan error)
+		go func() {
+			for {
+				if err := ticker.Next(); err != nil {
+					errCh <- err
+					return
+				}
+				ticks <- struct{}{}
+			}
+		}()
+
+		for range 3 {
+			clock.Step(time.Second)
+			select {
+			case <-ticks:
+			case <-time.After(time.Second):
+				require.Fail(t, "did not tick")
+			}
+		}
+
+		cancel()
+		select {
+		case err := <-errCh:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			require.Fail(t, "did not return after the context was canceled")
+		}
+		require.ErrorIs(t, ticker.Next(), context.Canceled)
+	})
+
+	t.Run("Stop stops the ticker", func(t *testing.T) {
+		// Stopping fake tickers is a no-op, so this uses the real clock
+		ticker := NewTicker(context.Background(), nil, time.Millisecond)
+		require.NoError(t, ticker.Next())
+		ticker.Stop()
+
+		// Drain a tick which may have been delivered before Stop
+		select {
+		case <-ticker.C():
+		default:
+		}
+		select {
+		case <-ticker.C():
+			require.Fail(t, "ticked after being stopped")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		// Stop can be called more than once
+		ticker.Stop()
+	})
+
+	t.Run("real clock", func(t *testing.T) {
+		ticker := NewTicker(context.Background(), nil, time.Millisecond)
+		defer ticker.Stop()
+		require.NoError(t, ticker.Next())
+		require.NoError(t, ticker.Next())
+	})
+}
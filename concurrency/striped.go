@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"sync"
+)
+
+// defaultStripes is the number of stripes used when NewStripedLock is called
+// with a non-positive number.
+const defaultStripes = 64
+
+// StripedLock is a fixed set of read-write mutexes ("stripes"), where each key
+// is mapped to a stripe by its hash.
+// Unlike cmap.Mutex, locking a key doesn't allocate, which makes it suitable
+// for very hot paths, at the cost of keys sharing a stripe contending for the
+// same lock.
+// Because different keys can share a stripe, callers must not lock a key
+// while holding the lock of another key, which could deadlock.
+type StripedLock struct {
+	seed    maphash.Seed
+	mask    uint64
+	stripes []stripe
+}
+
+// stripe is padded to a cache line to avoid false sharing between adjacent
+// stripes.
+type stripe struct {
+	sync.RWMutex
+	_ [64 - 24]byte
+}
+
+// NewStripedLock returns a StripedLock with n stripes, rounded up to the next
+// power of two. If n is not positive, a default of 64 stripes is used.
+func NewStripedLock(n int) *StripedLock {
+	if n <= 0 {
+		n = defaultStripes
+	}
+	if n&(n-1) != 0 {
+		n = 1 << bits.Len(uint(n))
+	}
+
+	return &StripedLock{
+		seed:    maphash.MakeSeed(),
+		mask:    uint64(n - 1),
+		stripes: make([]stripe, n),
+	}
+}
+
+// Stripes returns the number of stripes.
+func (s *StripedLock) Stripes() int {
+	return len(s.stripes)
+}
+
+// Lock acquires an exclusive lock on the stripe of the given key.
+func (s *StripedLock) Lock(key string) {
+	s.stripe(key).Lock()
+}
+
+// Unlock releases the exclusive lock on the stripe of the given key.
+func (s *StripedLock) Unlock(key string) {
+	s.stripe(key).Unlock()
+}
+
+// RLock acquires a read lock on the stripe of the given key.
+func (s *StripedLock) RLock(key string) {
+	s.stripe(key).RLock()
+}
+
+// RUnlock releases the read lock on the stripe of the given key.
+func (s *StripedLock) RUnlock(key string) {
+	s.stripe(key).RUnlock()
+}
+
+// Locker returns the sync.Locker of the stripe of the given key.
+func (s *StripedLock) Locker(key string) sync.Locker {
+	return s.stripe(key)
+}
+
+func (s *StripedLock) stripe(key string) *stripe {
+	return &s.stripes[maphash.String(s.seed, key)&s.mask]
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package concurrency
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripedLock(t *testing.T) {
+	t.Run("number of stripes", func(t *testing.T) {
+		assert.Equal(t, defaultStripes, NewStripedLock(0).Stripes())
+		assert.Equal(t, defaultStripes, NewStripedLock(-1).Stripes())
+		assert.Equal(t, 1, NewStripedLock(1).Stripes())
+		assert.Equal(t, 8, NewStripedLock(8).Stripes())
+		assert.Equal(t, 16, NewStripedLock(9).Stripes())
+	})
+
+	t.Run("stripes are padded to a cache line", func(t *testing.T) {
+		assert.Equal(t, uintptr(64), unsafe.Sizeof(stripe{}))
+	})
+
+	t.Run("same key maps to the same stripe", func(t *testing.T) {
+		l := NewStripedLock(16)
+		assert.Same(t, l.stripe("foo"), l.stripe("foo"))
+		assert.Same(t, l.Locker("foo"), l.Locker("foo"))
+	})
+
+	t.Run("lock is exclusive", func(t *testing.T) {
+		l := NewStripedLock(16)
+		l.Lock("foo")
+
+		lockedCh := make(chan struct{})
+		go func() {
+			l.RLock("foo")
+			close(lockedCh)
+			l.RUnlock("foo")
+		}()
+
+		select {
+		case <-lockedCh:
+			assert.Fail(t, "RLock should block while the key is locked")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		l.Unlock("foo")
+		select {
+		case <-lockedCh:
+		case <-time.After(time.Second):
+			assert.Fail(t, "RLock should succeed after Unlock")
+		}
+	})
+
+	t.Run("read locks are shared", func(t *testing.T) {
+		l := NewStripedLock(16)
+		l.RLock("foo")
+		l.RLock("foo")
+		l.RUnlock("foo")
+		l.RUnlock("foo")
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		l := NewStripedLock(4)
+		// Each counter is only protected by the lock of its key
+		counters := make([]int, 10)
+
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				key := strconv.Itoa(i % 10)
+				for range 100 {
+					l.Lock(key)
+					counters[i%10]++
+					l.Unlock(key)
+				}
+			}()
+		}
+		wg.Wait()
+
+		for i := range 10 {
+			assert.Equal(t, 500, counters[i])
+		}
+	})
+}
+
+func BenchmarkStripedLock(b *testing.B) {
+	l := NewStripedLock(0)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key-" + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			l.Lock(key)
+			l.Unlock(key)
+			i++
+		}
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"time"
+)
+
+// CatchUpMode controls how a Cron handles the activations of an entry that
+// were missed, for example because the process was paused for a long time.
+type CatchUpMode int
+
+const (
+	// CatchUpRunOnce runs the job once for all the missed activations.
+	// This is the default.
+	CatchUpRunOnce CatchUpMode = iota
+	// CatchUpSkip skips the missed activations. The job is run if the
+	// activation being handled is the latest one, however late, or else if
+	// the latest of the missed activations is within the tolerance.
+	CatchUpSkip
+	// CatchUpRunAll runs the job once for each missed activation, up to
+	// MaxRuns times.
+	CatchUpRunAll
+)
+
+// defaultCatchUpMaxRuns is the default cap on the number of runs for
+// CatchUpRunAll.
+const defaultCatchUpMaxRuns = 100
+
+// CatchUpPolicy configures how a Cron handles missed activations.
+type CatchUpPolicy struct {
+	// Mode is the catch-up mode.
+	Mode CatchUpMode
+
+	// Tolerance is how late an activation can be and still not be considered
+	// missed, when a later activation is due too. Activations within the
+	// tolerance are always run.
+	Tolerance time.Duration
+
+	// MaxRuns is the maximum number of runs with CatchUpRunAll, including the
+	// latest activation. Missed activations above the cap are skipped.
+	// Defaults to 100.
+	MaxRuns int
+}
+
+// runDue runs the entry, whose next activation time is not after now,
+// according to the catch-up policy, and updates its Prev time.
+func (c *Cron) runDue(e *Entry, now time.Time) {
+	switch c.catchUp.Mode {
+	case CatchUpSkip:
+		// Timers always fire slightly late, so the next activation is run
+		// when it's the latest one; otherwise, at least one whole activation
+		// was missed, and the latest activation is run if the schedule has an
+		// activation between now - tolerance and now.
+		latest := e.Next
+		if next := e.Schedule.Next(e.Next); !next.IsZero() && !next.After(now) &&
+			now.Sub(e.Next) > c.catchUp.Tolerance {
+			latest = e.Schedule.Next(now.Add(-c.catchUp.Tolerance - time.Nanosecond))
+			if latest.IsZero() || latest.After(now) {
+				c.logger.Info("skip", "now", now, "entry", e.ID, "missed", e.Next)
+				return
+			}
+		}
+		c.startJob(e.WrappedJob)
+		e.Prev = latest
+
+	case CatchUpRunAll:
+		maxRuns := c.catchUp.MaxRuns
+		if maxRuns <= 0 {
+			maxRuns = defaultCatchUpMaxRuns
+		}
+		var runs int
+		for t := e.Next; !t.IsZero() && !t.After(now); t = e.Schedule.Next(t) {
+			if runs == maxRuns {
+				c.logger.Info("skip", "now", now, "entry", e.ID, "missed", t)
+				break
+			}
+			c.startJob(e.WrappedJob)
+			e.Prev = t
+			runs++
+		}
+
+	default:
+		c.startJob(e.WrappedJob)
+		e.Prev = e.Next
+	}
+}
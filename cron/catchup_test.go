@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestCatchUpPolicy(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+
+	tests := map[string]struct {
+		policy CatchUpPolicy
+		// step is how long the process is paused; defaults to 10 minutes
+		step    time.Duration
+		expRuns int64
+		expPrev time.Time
+	}{
+		"default runs once": {
+			policy:  CatchUpPolicy{},
+			expRuns: 1,
+			expPrev: start.Add(30 * time.Second),
+		},
+		"skip missed activations": {
+			policy:  CatchUpPolicy{Mode: CatchUpSkip},
+			expRuns: 0,
+		},
+		"skip runs the latest activation handled late": {
+			policy:  CatchUpPolicy{Mode: CatchUpSkip},
+			step:    30*time.Second + time.Millisecond,
+			expRuns: 1,
+			expPrev: start.Add(30 * time.Second),
+		},
+		"skip runs latest activation within tolerance": {
+			policy:  CatchUpPolicy{Mode: CatchUpSkip, Tolerance: time.Minute},
+			expRuns: 1,
+			expPrev: start.Add(10*time.Minute - 30*time.Second),
+		},
+		"run all missed activations": {
+			policy:  CatchUpPolicy{Mode: CatchUpRunAll},
+			expRuns: 10,
+			expPrev: start.Add(10*time.Minute - 30*time.Second),
+		},
+		"run all missed activations up to the cap": {
+			policy:  CatchUpPolicy{Mode: CatchUpRunAll, MaxRuns: 3},
+			expRuns: 3,
+			expPrev: start.Add(3*time.Minute - 30*time.Second),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			clock := clocktesting.NewFakeClock(start)
+			cron := New(
+				WithParser(secondParser),
+				WithChain(),
+				WithClock(clock),
+				WithLocation(time.UTC),
+				WithCatchUpPolicy(tc.policy),
+			)
+
+			var runs atomic.Int64
+			id, err := cron.AddFunc("0 * * * * *", func() { runs.Add(1) })
+			require.NoError(t, err)
+
+			cron.Start()
+			assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+
+			// Simulate a pause of 10 minutes, unless otherwise specified
+			step := tc.step
+			if step == 0 {
+				step = 10 * time.Minute
+			}
+			clock.Step(step)
+			expNext := start.Add(step).Truncate(time.Minute).Add(time.Minute)
+			assert.Eventually(t, func() bool {
+				return cron.Entry(id).Next.Equal(expNext)
+			}, OneSecond, 10*time.Millisecond)
+
+			<-cron.Stop().Done()
+			assert.Equal(t, tc.expRuns, runs.Load())
+			assert.Equal(t, tc.expPrev, cron.Entry(id).Prev)
+		})
+	}
+}
@@ -58,7 +58,7 @@ func (c Chain) Then(j Job) Job {
 // Recover panics in wrapped jobs and log them with the provided logger.
 func Recover(logger Logger) JobWrapper {
 	return func(j Job) Job {
-		return FuncJob(func() {
+		return wrapperJob{inner: j, run: func() {
 			defer func() {
 				if r := recover(); r != nil {
 					const size = 64 << 10
@@ -72,7 +72,7 @@ func Recover(logger Logger) JobWrapper {
 				}
 			}()
 			j.Run()
-		})
+		}}
 	}
 }
 
@@ -88,7 +88,7 @@ func DelayIfStillRunning(logger Logger) JobWrapper {
 func DelayIfStillRunningWithClock(logger Logger, clk clock.Clock) JobWrapper {
 	return func(j Job) Job {
 		var mu sync.Mutex
-		return FuncJob(func() {
+		return wrapperJob{inner: j, run: func() {
 			start := clk.Now()
 			mu.Lock()
 			defer mu.Unlock()
@@ -96,24 +96,26 @@ func DelayIfStillRunningWithClock(logger Logger, clk clock.Clock) JobWrapper {
 				logger.Info("delay", "duration", dur)
 			}
 			j.Run()
-		})
+		}}
 	}
 }
 
 // SkipIfStillRunning skips an invocation of the Job if a previous invocation is
-// still running. It logs skips to the given logger at Info level.
+// still running. It logs skips to the given logger at Info level, and reports
+// them to the Observer of the Cron, if any.
 func SkipIfStillRunning(logger Logger) JobWrapper {
 	return func(j Job) Job {
 		ch := make(chan struct{}, 1)
 		ch <- struct{}{}
-		return FuncJob(func() {
+		return wrapperJob{inner: j, run: func() {
 			select {
 			case v := <-ch:
 				j.Run()
 				ch <- v
 			default:
 				logger.Info("skip")
+				notifySkip(j)
 			}
-		})
+		}}
 	}
 }
@@ -60,6 +60,39 @@ func TestChain(t *testing.T) {
 	}
 }
 
+func TestEntryChain(t *testing.T) {
+	var nums []int
+	var (
+		append1 = appendingWrapper(&nums, 1)
+		append2 = appendingWrapper(&nums, 2)
+		append3 = appendingWrapper(&nums, 3)
+		append4 = appendingJob(&nums, 4)
+	)
+	c := New(WithChain(append1))
+
+	withChain, err := c.AddJobWithChain("* * * * *", NewChain(append2, append3), append4)
+	assert.NoError(t, err)
+	withoutChain, err := c.AddJob("* * * * *", append4)
+	assert.NoError(t, err)
+
+	// The entry's chain is composed after the global one
+	c.Entry(withChain).WrappedJob.Run()
+	assert.Equal(t, []int{1, 2, 3, 4}, nums)
+
+	nums = nil
+	c.Entry(withoutChain).WrappedJob.Run()
+	assert.Equal(t, []int{1, 4}, nums)
+
+	nums = nil
+	funcID, err := c.AddFuncWithChain("* * * * *", NewChain(append3), func() { append4.Run() })
+	assert.NoError(t, err)
+	c.Entry(funcID).WrappedJob.Run()
+	assert.Equal(t, []int{1, 3, 4}, nums)
+
+	_, err = c.AddJobWithChain("invalid spec", NewChain(append2), append4)
+	assert.Error(t, err)
+}
+
 func TestChainRecover(t *testing.T) {
 	panickingJob := FuncJob(func() {
 		panic("panickingJob panics")
@@ -20,6 +20,7 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/utils/clock"
@@ -34,6 +35,7 @@ type Cron struct {
 	stop      chan struct{}
 	add       chan *Entry
 	remove    chan EntryID
+	pause     chan entryPause
 	snapshot  chan chan []Entry
 	running   bool
 	logger    Logger
@@ -43,6 +45,15 @@ type Cron struct {
 	nextID    EntryID
 	jobWaiter sync.WaitGroup
 	clk       clock.Clock
+	catchUp   CatchUpPolicy
+	observer  Observer
+	paused    atomic.Bool
+}
+
+// entryPause is a request to pause or resume an entry.
+type entryPause struct {
+	id     EntryID
+	paused bool
 }
 
 // ScheduleParser is an interface for schedule spec parsers that return a Schedule
@@ -74,6 +85,11 @@ type Entry struct {
 	// Schedule on which this job should be run.
 	Schedule Schedule
 
+	// Location is the time zone in which the schedule is interpreted. It is
+	// the time zone set in the spec with CRON_TZ= or TZ=, or the one passed
+	// to AddJobInLocation, or otherwise the time zone of the Cron instance.
+	Location *time.Location
+
 	// Next time the job will run, or the zero time if Cron has not been
 	// started or this entry's schedule is unsatisfiable
 	Next time.Time
@@ -81,6 +97,12 @@ type Entry struct {
 	// Prev is the last time this job was run, or the zero time if never.
 	Prev time.Time
 
+	// Paused is true if the entry has been paused with Pause. Activations of
+	// paused entries are skipped, but their Next time keeps being updated.
+	// Note that entries are not reported as paused when the whole Cron is
+	// paused with PauseAll.
+	Paused bool
+
 	// WrappedJob is the thing to run when the Schedule is activated.
 	WrappedJob Job
 
@@ -128,6 +150,14 @@ func (s byTime) Less(i, j int) bool {
 //	  Description: Wrap submitted jobs to customize behavior.
 //	  Default:     A chain that recovers panics and logs them to stderr.
 //
+//	Catch-up policy
+//	  Description: How missed activations are handled after a long pause.
+//	  Default:     The job is run once for all missed activations.
+//
+//	Observer
+//	  Description: Receives notifications about the lifecycle of jobs.
+//	  Default:     None
+//
 // See "cron.With*" to modify the default behavior.
 func New(opts ...Option) *Cron {
 	c := &Cron{
@@ -137,6 +167,7 @@ func New(opts ...Option) *Cron {
 		stop:      make(chan struct{}),
 		snapshot:  make(chan chan []Entry),
 		remove:    make(chan EntryID),
+		pause:     make(chan entryPause),
 		running:   false,
 		runningMu: sync.Mutex{},
 		logger:    DefaultLogger,
@@ -165,6 +196,8 @@ func (c *Cron) AddFunc(spec string, cmd func()) (EntryID, error) {
 // AddJob adds a Job to the Cron to be run on the given schedule.
 // The spec is parsed using the time zone of this Cron instance as the default.
 // An opaque ID is returned that can be used to later remove it.
+// If the spec is not valid, the error returned by the parser is returned, which
+// is a *ParseError with the default parser.
 func (c *Cron) AddJob(spec string, cmd Job) (EntryID, error) {
 	schedule, err := c.parser.Parse(spec)
 	if err != nil {
@@ -173,16 +206,74 @@ func (c *Cron) AddJob(spec string, cmd Job) (EntryID, error) {
 	return c.Schedule(schedule, cmd), nil
 }
 
+// AddFuncWithChain adds a func to the Cron to be run on the given schedule,
+// like AddFunc, wrapped with the given chain. See AddJobWithChain.
+func (c *Cron) AddFuncWithChain(spec string, chain Chain, cmd func()) (EntryID, error) {
+	return c.AddJobWithChain(spec, chain, FuncJob(cmd))
+}
+
+// AddJobWithChain adds a Job to the Cron to be run on the given schedule,
+// like AddJob, wrapped with the given chain in addition to the Chain of this
+// Cron instance. The entry's chain is composed after the Cron's one, so the
+// Cron's wrappers are the outermost. This allows, for example, one job to
+// use SkipIfStillRunning while another uses DelayIfStillRunning.
+func (c *Cron) AddJobWithChain(spec string, chain Chain, cmd Job) (EntryID, error) {
+	schedule, err := c.parser.Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	return c.ScheduleWithChain(schedule, chain, cmd), nil
+}
+
+// AddFuncInLocation adds a func to the Cron to be run on the given schedule,
+// interpreted in the given time zone instead of the one of this Cron instance.
+// A time zone set in the spec with CRON_TZ= or TZ= takes precedence over loc.
+// An opaque ID is returned that can be used to later remove it.
+func (c *Cron) AddFuncInLocation(spec string, loc *time.Location, cmd func()) (EntryID, error) {
+	return c.AddJobInLocation(spec, loc, FuncJob(cmd))
+}
+
+// AddJobInLocation adds a Job to the Cron to be run on the given schedule,
+// interpreted in the given time zone instead of the one of this Cron instance.
+// A time zone set in the spec with CRON_TZ= or TZ= takes precedence over loc.
+// An opaque ID is returned that can be used to later remove it.
+func (c *Cron) AddJobInLocation(spec string, loc *time.Location, cmd Job) (EntryID, error) {
+	schedule, err := c.parser.Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	if s, ok := schedule.(*SpecSchedule); ok && loc != nil && s.Location == time.Local { //nolint:gosmopolitan
+		// The spec doesn't set a time zone
+		s.Location = loc
+	}
+	return c.schedule(schedule, cmd, loc, Chain{}), nil
+}
+
 // Schedule adds a Job to the Cron to be run on the given schedule.
 // The job is wrapped with the configured Chain.
 func (c *Cron) Schedule(schedule Schedule, cmd Job) EntryID {
+	return c.schedule(schedule, cmd, nil, Chain{})
+}
+
+// ScheduleWithChain adds a Job to the Cron to be run on the given schedule.
+// The job is wrapped with the given chain, and then with the configured Chain.
+func (c *Cron) ScheduleWithChain(schedule Schedule, chain Chain, cmd Job) EntryID {
+	return c.schedule(schedule, cmd, nil, chain)
+}
+
+func (c *Cron) schedule(schedule Schedule, cmd Job, loc *time.Location, chain Chain) EntryID {
 	c.runningMu.Lock()
 	defer c.runningMu.Unlock()
 	c.nextID++
+	wrapped := cmd
+	if c.observer != nil {
+		wrapped = &observedJob{id: c.nextID, job: cmd, observer: c.observer, clk: c.clk}
+	}
 	entry := &Entry{
 		ID:         c.nextID,
 		Schedule:   schedule,
-		WrappedJob: c.chain.Then(cmd),
+		Location:   c.entryLocation(schedule, loc),
+		WrappedJob: c.chain.Then(chain.Then(wrapped)),
 		Job:        cmd,
 	}
 	if !c.running {
@@ -231,6 +322,48 @@ func (c *Cron) Remove(id EntryID) {
 	}
 }
 
+// Pause pauses an entry: it stays registered, but its activations are
+// skipped until it's resumed with Resume. Missed activations are not run when
+// the entry is resumed.
+// It is a nop if the entry doesn't exist.
+func (c *Cron) Pause(id EntryID) {
+	c.setEntryPaused(id, true)
+}
+
+// Resume resumes an entry paused with Pause.
+// It is a nop if the entry doesn't exist or is not paused.
+func (c *Cron) Resume(id EntryID) {
+	c.setEntryPaused(id, false)
+}
+
+func (c *Cron) setEntryPaused(id EntryID, paused bool) {
+	c.runningMu.Lock()
+	defer c.runningMu.Unlock()
+	if c.running {
+		c.pause <- entryPause{id: id, paused: paused}
+	} else {
+		c.applyEntryPause(entryPause{id: id, paused: paused})
+	}
+}
+
+// PauseAll pauses the whole scheduler: entries stay registered and can be
+// added or removed, but all activations are skipped until ResumeAll is called.
+// Missed activations are not run when the scheduler is resumed.
+func (c *Cron) PauseAll() {
+	c.paused.Store(true)
+}
+
+// ResumeAll resumes the scheduler after PauseAll. Entries paused individually
+// with Pause stay paused.
+func (c *Cron) ResumeAll() {
+	c.paused.Store(false)
+}
+
+// Paused returns true if the scheduler has been paused with PauseAll.
+func (c *Cron) Paused() bool {
+	return c.paused.Load()
+}
+
 // Start the cron scheduler in its own goroutine, or no-op if already started.
 func (c *Cron) Start() {
 	c.runningMu.Lock()
@@ -264,6 +397,7 @@ func (c *Cron) run() {
 	for _, entry := range c.entries {
 		entry.Next = entry.Schedule.Next(now)
 		c.logger.Info("schedule", "now", now, "entry", entry.ID, "next", entry.Next)
+		c.notifyScheduled(entry)
 	}
 
 	for {
@@ -297,10 +431,14 @@ func (c *Cron) run() {
 					if e.Next.After(now) || e.Next.IsZero() {
 						break
 					}
-					c.startJob(e.WrappedJob)
-					e.Prev = e.Next
+					if e.Paused || c.paused.Load() {
+						c.logger.Info("paused", "now", now, "entry", e.ID, "missed", e.Next)
+					} else {
+						c.runDue(e, now)
+					}
 					e.Next = e.Schedule.Next(now)
 					c.logger.Info("run", "now", now, "entry", e.ID, "next", e.Next)
+					c.notifyScheduled(e)
 				}
 
 			case newEntry := <-c.add:
@@ -308,11 +446,16 @@ func (c *Cron) run() {
 				newEntry.Next = newEntry.Schedule.Next(now)
 				c.entries = append(c.entries, newEntry)
 				c.logger.Info("added", "now", now, "entry", newEntry.ID, "next", newEntry.Next)
+				c.notifyScheduled(newEntry)
 
 			case replyChan := <-c.snapshot:
 				replyChan <- c.entrySnapshot()
 				continue
 
+			case p := <-c.pause:
+				c.applyEntryPause(p)
+				continue
+
 			case <-c.stop:
 				if timer != nil && !timer.Stop() {
 					<-timer.C()
@@ -346,6 +489,24 @@ func (c *Cron) startJob(j Job) {
 	}()
 }
 
+// notifyScheduled notifies the Observer of the next activation time of e.
+func (c *Cron) notifyScheduled(e *Entry) {
+	if c.observer != nil {
+		c.observer.JobScheduled(e.ID, e.Next)
+	}
+}
+
+// entryLocation returns the time zone in which the schedule is interpreted.
+func (c *Cron) entryLocation(schedule Schedule, loc *time.Location) *time.Location {
+	if s, ok := schedule.(*SpecSchedule); ok && s.Location != nil && s.Location != time.Local { //nolint:gosmopolitan
+		return s.Location
+	}
+	if loc != nil {
+		return loc
+	}
+	return c.location
+}
+
 // now returns current time in c location
 func (c *Cron) now() time.Time {
 	return c.clk.Now().In(c.location)
@@ -377,6 +538,20 @@ func (c *Cron) entrySnapshot() []Entry {
 	return entries
 }
 
+func (c *Cron) applyEntryPause(p entryPause) {
+	for _, e := range c.entries {
+		if e.ID == p.id {
+			e.Paused = p.paused
+			if p.paused {
+				c.logger.Info("pause", "entry", p.id)
+			} else {
+				c.logger.Info("resume", "entry", p.id)
+			}
+			return
+		}
+	}
+}
+
 func (c *Cron) removeEntry(id EntryID) {
 	var entries []*Entry
 	for _, e := range c.entries {
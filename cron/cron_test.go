@@ -417,6 +417,43 @@ func TestNonLocalTimezone(t *testing.T) {
 	}
 }
 
+func TestPerEntryTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	// 01:00 in New York, one hour before the DST transition
+	now := time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakeClock(now)
+	cron := New(WithLocation(time.UTC), WithParser(secondParser), WithClock(clock))
+
+	defaultID, err := cron.AddFunc("0 0 3 * * ?", func() {})
+	require.NoError(t, err)
+	newYorkID, err := cron.AddFuncInLocation("0 0 3 * * ?", newYork, func() {})
+	require.NoError(t, err)
+	tokyoID, err := cron.AddFuncInLocation("CRON_TZ=Asia/Tokyo 0 0 3 * * ?", newYork, func() {})
+	require.NoError(t, err)
+
+	cron.Start()
+	defer cron.Stop()
+	assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+
+	entry := cron.Entry(defaultID)
+	assert.Equal(t, time.UTC, entry.Location)
+	assert.True(t, time.Date(2024, 3, 11, 3, 0, 0, 0, time.UTC).Equal(entry.Next), entry.Next)
+
+	// 03:00 EDT is right after the DST transition
+	entry = cron.Entry(newYorkID)
+	assert.Equal(t, newYork, entry.Location)
+	assert.True(t, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC).Equal(entry.Next), entry.Next)
+
+	// The time zone in the spec takes precedence
+	entry = cron.Entry(tokyoID)
+	assert.Equal(t, tokyo.String(), entry.Location.String())
+	assert.True(t, time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC).Equal(entry.Next), entry.Next)
+}
+
 // Test that calling stop before start silently returns without
 // blocking the stop channel.
 func TestStopWithoutStart(*testing.T) {
@@ -819,3 +856,105 @@ func newWithSeconds() (*Cron, *clocktesting.FakeClock) {
 	clock := clocktesting.NewFakeClock(time.Now())
 	return New(WithParser(secondParser), WithChain(), WithClock(clock)), clock
 }
+
+func TestPauseResume(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+
+	setup := func(t *testing.T) (*Cron, *clocktesting.FakeClock, []EntryID, []*atomic.Int64) {
+		t.Helper()
+		clock := clocktesting.NewFakeClock(start)
+		cron := New(
+			WithParser(secondParser),
+			WithChain(),
+			WithClock(clock),
+			WithLocation(time.UTC),
+		)
+
+		ids := make([]EntryID, 2)
+		runs := make([]*atomic.Int64, 2)
+		for i := range ids {
+			runs[i] = &atomic.Int64{}
+			var err error
+			ids[i], err = cron.AddFunc("0 * * * * *", func() { runs[i].Add(1) })
+			require.NoError(t, err)
+		}
+		return cron, clock, ids, runs
+	}
+
+	// step moves the clock to the next activation and waits for the entries to
+	// be rescheduled.
+	step := func(t *testing.T, cron *Cron, clock *clocktesting.FakeClock) {
+		t.Helper()
+		assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+		next := cron.Entries()[0].Next
+		clock.SetTime(next)
+		assert.Eventually(t, func() bool {
+			for _, e := range cron.Entries() {
+				if !e.Next.After(next) {
+					return false
+				}
+			}
+			return true
+		}, OneSecond, 10*time.Millisecond)
+	}
+
+	t.Run("pause and resume an entry", func(t *testing.T) {
+		cron, clock, ids, runs := setup(t)
+
+		// Pause before starting
+		cron.Pause(ids[0])
+		assert.True(t, cron.Entry(ids[0]).Paused)
+		assert.False(t, cron.Entry(ids[1]).Paused)
+
+		cron.Start()
+		step(t, cron, clock)
+		assert.Eventually(t, func() bool { return runs[1].Load() == 1 }, OneSecond, 10*time.Millisecond)
+		assert.Equal(t, int64(0), runs[0].Load())
+		assert.True(t, cron.Entry(ids[0]).Prev.IsZero())
+		assert.Equal(t, start.Add(90*time.Second), cron.Entry(ids[0]).Next)
+
+		// Resume while running
+		cron.Resume(ids[0])
+		assert.False(t, cron.Entry(ids[0]).Paused)
+		step(t, cron, clock)
+		assert.Eventually(t, func() bool { return runs[0].Load() == 1 }, OneSecond, 10*time.Millisecond)
+
+		// Pause while running
+		cron.Pause(ids[1])
+		assert.True(t, cron.Entry(ids[1]).Paused)
+		step(t, cron, clock)
+		assert.Eventually(t, func() bool { return runs[0].Load() == 2 }, OneSecond, 10*time.Millisecond)
+
+		<-cron.Stop().Done()
+		assert.Equal(t, int64(2), runs[1].Load())
+	})
+
+	t.Run("pause and resume all", func(t *testing.T) {
+		cron, clock, ids, runs := setup(t)
+		cron.Pause(ids[1])
+
+		cron.Start()
+		cron.PauseAll()
+		assert.True(t, cron.Paused())
+		step(t, cron, clock)
+		step(t, cron, clock)
+
+		// Individually paused entries stay paused
+		cron.ResumeAll()
+		assert.False(t, cron.Paused())
+		step(t, cron, clock)
+		assert.Eventually(t, func() bool { return runs[0].Load() == 1 }, OneSecond, 10*time.Millisecond)
+
+		<-cron.Stop().Done()
+		assert.Equal(t, int64(1), runs[0].Load())
+		assert.Equal(t, int64(0), runs[1].Load())
+	})
+
+	t.Run("unknown entries are ignored", func(t *testing.T) {
+		cron, _, _, _ := setup(t)
+		cron.Pause(100)
+		cron.Start()
+		cron.Resume(100)
+		<-cron.Stop().Done()
+	})
+}
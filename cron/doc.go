@@ -31,6 +31,12 @@ them in their own goroutines.
 	// Inspect the cron job entries' next and previous run times.
 	inspect(c.Entries())
 	..
+	// Temporarily skip the activations of an entry, or of all entries.
+	c.Pause(id)
+	c.Resume(id)
+	c.PauseAll()
+	c.ResumeAll()
+	..
 	c.Stop()  // Stop the scheduler (does not stop any jobs already running).
 
 # Time mocking
@@ -179,6 +185,17 @@ For example:
 	c.SetLocation("America/New_York")
 	c.AddFunc("CRON_TZ=Asia/Tokyo 0 6 * * ?", ...)
 
+The time zone may also be set per entry with AddFuncInLocation or
+AddJobInLocation, which is useful when entries belonging to different tenants
+share the same Cron. A time zone set in the spec still takes precedence:
+
+	# Runs at 6am in Europe/Rome
+	rome, _ := time.LoadLocation("Europe/Rome")
+	c := cron.New(cron.WithLocation(nyc))
+	c.AddFuncInLocation("0 6 * * ?", rome, ...)
+
+The time zone an entry is interpreted in is exposed as Entry.Location.
+
 The prefix "TZ=(TIME ZONE)" is also supported for legacy compatibility.
 
 Be aware that jobs scheduled during daylight-savings leap-ahead transitions will
@@ -201,12 +218,30 @@ Install wrappers for all jobs added to a cron using the `cron.WithChain` option:
 		cron.SkipIfStillRunning(logger),
 	))
 
-Install wrappers for individual jobs by explicitly wrapping them:
+Install wrappers for individual jobs with AddJobWithChain, AddFuncWithChain,
+or ScheduleWithChain. The job's chain is applied after the Cron's chain, so for
+example one job can be skipped while another is delayed if still running:
+
+	c.AddJobWithChain("@every 1m", cron.NewChain(
+		cron.SkipIfStillRunning(logger),
+	), job)
+
+Wrappers can also be installed by explicitly wrapping jobs:
 
 	job = cron.NewChain(
 		cron.SkipIfStillRunning(logger),
 	).Then(job)
 
+# Observers
+
+An Observer receives notifications when jobs are scheduled, started, completed,
+skipped by SkipIfStillRunning, or panic, for example to emit metrics without
+wrapping every job. Install it with the `cron.WithObserver` option:
+
+	cron.New(cron.WithObserver(observer))
+
+Embed cron.NopObserver to implement only some of the notifications.
+
 # Thread safety
 
 Since the Cron service runs concurrently with the calling code, some amount of
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidNumber is returned when a value in a field is neither a
+	// non-negative number nor a name allowed for the field.
+	ErrInvalidNumber = errors.New("invalid number")
+	// ErrOutOfRange is returned when a value is outside the bounds of the field.
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrInvalidRange is returned when a range or step is malformed.
+	ErrInvalidRange = errors.New("invalid range")
+)
+
+// SpecField is a field of a cron spec.
+type SpecField string
+
+const (
+	FieldSecond     SpecField = "second"
+	FieldMinute     SpecField = "minute"
+	FieldHour       SpecField = "hour"
+	FieldDom        SpecField = "day of month"
+	FieldMonth      SpecField = "month"
+	FieldDow        SpecField = "day of week"
+	FieldLocation   SpecField = "time zone"
+	FieldDescriptor SpecField = "descriptor"
+)
+
+// specFields are the fields of a spec, in the same order as places.
+var specFields = []SpecField{
+	FieldSecond,
+	FieldMinute,
+	FieldHour,
+	FieldDom,
+	FieldMonth,
+	FieldDow,
+}
+
+// ParseError is returned by Parser.Parse, and by the methods of Cron which
+// parse a spec with the default parser, when a spec is not valid.
+// Use errors.Is on it to check for ErrInvalidNumber, ErrOutOfRange and
+// ErrInvalidRange.
+type ParseError struct {
+	// Spec is the spec which failed to parse.
+	Spec string
+	// Field is the field which failed to parse. It's empty if the error
+	// isn't specific to a field, for example when the number of fields is
+	// wrong.
+	Field SpecField
+	// Value is the value of the field which failed to parse.
+	Value string
+	// Position is the 1-based position of the field among the fields of the
+	// spec, or 0 if the error isn't specific to one of them.
+	Position int
+	// Offset is the offset in bytes of Value in Spec, or -1 if the error
+	// isn't specific to a field.
+	Offset int
+	// Err is the reason for the error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	switch {
+	case e.Field == "":
+		return fmt.Sprintf("invalid cron spec %q: %v", e.Spec, e.Err)
+	case e.Position > 0:
+		return fmt.Sprintf("invalid %s field %q at position %d in cron spec %q: %v", e.Field, e.Value, e.Position, e.Spec, e.Err)
+	default:
+		return fmt.Sprintf("invalid %s %q in cron spec %q: %v", e.Field, e.Value, e.Spec, e.Err)
+	}
+}
+
+// Unwrap returns the reason for the error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   Parser
+		spec     string
+		field    SpecField
+		value    string
+		position int
+		offset   int
+		is       error
+	}{
+		{
+			name:     "invalid day of week",
+			parser:   standardParser,
+			spec:     "0 0 * * 2#2",
+			field:    FieldDow,
+			value:    "2#2",
+			position: 5,
+			offset:   8,
+			is:       ErrInvalidNumber,
+		},
+		{
+			name:     "seconds out of range",
+			parser:   secondParser,
+			spec:     "60 * * * *",
+			field:    FieldSecond,
+			value:    "60",
+			position: 1,
+			offset:   0,
+			is:       ErrOutOfRange,
+		},
+		{
+			name:     "minutes after optional seconds",
+			parser:   NewParser(SecondOptional | Minute | Hour | Dom | Month | Dow),
+			spec:     "5-1 * * * *",
+			field:    FieldMinute,
+			value:    "5-1",
+			position: 1,
+			offset:   0,
+			is:       ErrInvalidRange,
+		},
+		{
+			name:     "offset after time zone",
+			parser:   standardParser,
+			spec:     "CRON_TZ=UTC  0  25 * * *",
+			field:    FieldHour,
+			value:    "25",
+			position: 2,
+			offset:   16,
+			is:       ErrOutOfRange,
+		},
+		{
+			name:     "zero step",
+			parser:   standardParser,
+			spec:     "*/0 * * * *",
+			field:    FieldMinute,
+			value:    "*/0",
+			position: 1,
+			offset:   0,
+			is:       ErrInvalidRange,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.parser.Parse(tc.spec)
+			var pe *ParseError
+			require.ErrorAs(t, err, &pe)
+			assert.Equal(t, tc.spec, pe.Spec)
+			assert.Equal(t, tc.field, pe.Field)
+			assert.Equal(t, tc.value, pe.Value)
+			assert.Equal(t, tc.position, pe.Position)
+			assert.Equal(t, tc.offset, pe.Offset)
+			assert.Equal(t, tc.value, tc.spec[pe.Offset:pe.Offset+len(pe.Value)])
+			require.ErrorIs(t, err, tc.is)
+			assert.Contains(t, err.Error(), string(tc.field))
+		})
+	}
+
+	t.Run("errors not specific to a field", func(t *testing.T) {
+		for _, spec := range []string{"", "* * * *", "@every Xm", "CRON_TZ=Nowhere/Nothing * * * * *"} {
+			_, err := standardParser.Parse(spec)
+			var pe *ParseError
+			require.ErrorAs(t, err, &pe, spec)
+			assert.Equal(t, 0, pe.Position, spec)
+		}
+	})
+
+	t.Run("descriptor and time zone fields", func(t *testing.T) {
+		_, err := standardParser.Parse("@every Xm")
+		var pe *ParseError
+		require.ErrorAs(t, err, &pe)
+		assert.Equal(t, FieldDescriptor, pe.Field)
+		assert.Equal(t, "@every Xm", pe.Value)
+
+		_, err = standardParser.Parse("TZ=Nowhere/Nothing * * * * *")
+		require.ErrorAs(t, err, &pe)
+		assert.Equal(t, FieldLocation, pe.Field)
+		assert.Equal(t, "Nowhere/Nothing", pe.Value)
+		assert.Equal(t, 3, pe.Offset)
+	})
+
+	t.Run("returned by AddFunc", func(t *testing.T) {
+		c := New()
+		_, err := c.AddFunc("* * * * 2#2", func() {})
+		var pe *ParseError
+		require.ErrorAs(t, err, &pe)
+		assert.Equal(t, FieldDow, pe.Field)
+		assert.ErrorIs(t, err, ErrInvalidNumber)
+	})
+}
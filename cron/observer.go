@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// Observer receives notifications about the lifecycle of the jobs of a Cron,
+// for example to emit metrics.
+// JobScheduled is invoked by the scheduler goroutine, and the other methods by
+// the goroutine running the job, so implementations must be safe for
+// concurrent use and should return quickly.
+type Observer interface {
+	// JobScheduled is invoked when the next activation time of an entry is
+	// computed. next is the zero time if the schedule is unsatisfiable.
+	JobScheduled(id EntryID, next time.Time)
+
+	// JobStarted is invoked right before the job is run.
+	JobStarted(id EntryID)
+
+	// JobCompleted is invoked when the job returns, with the time it took.
+	JobCompleted(id EntryID, duration time.Duration)
+
+	// JobSkipped is invoked when an activation of the job is skipped by
+	// SkipIfStillRunning.
+	JobSkipped(id EntryID)
+
+	// JobPanicked is invoked when the job panics, with the recovered value.
+	// The panic is propagated to the wrappers in the chain, such as Recover.
+	JobPanicked(id EntryID, recovered any)
+}
+
+// NopObserver is an Observer which does nothing. It can be embedded to
+// implement only some of the methods of Observer.
+type NopObserver struct{}
+
+func (NopObserver) JobScheduled(EntryID, time.Time)     {}
+func (NopObserver) JobStarted(EntryID)                  {}
+func (NopObserver) JobCompleted(EntryID, time.Duration) {}
+func (NopObserver) JobSkipped(EntryID)                  {}
+func (NopObserver) JobPanicked(EntryID, any)            {}
+
+// observedJob wraps the job of an entry to notify the Observer.
+// It is the innermost job of the chain, so it's invoked only when the job
+// actually runs.
+type observedJob struct {
+	id       EntryID
+	job      Job
+	observer Observer
+	clk      clock.Clock
+}
+
+func (j *observedJob) Run() {
+	j.observer.JobStarted(j.id)
+	start := j.clk.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			j.observer.JobPanicked(j.id, r)
+			panic(r)
+		}
+		j.observer.JobCompleted(j.id, j.clk.Since(start))
+	}()
+	j.job.Run()
+}
+
+func (j *observedJob) skip() {
+	j.observer.JobSkipped(j.id)
+}
+
+// skipper is implemented by jobs which are notified when a wrapper skips them.
+type skipper interface {
+	skip()
+}
+
+// wrapperJob is the Job returned by the JobWrappers in this package.
+// It forwards skip notifications to the wrapped job.
+type wrapperJob struct {
+	run   func()
+	inner Job
+}
+
+func (w wrapperJob) Run() { w.run() }
+
+func (w wrapperJob) skip() {
+	notifySkip(w.inner)
+}
+
+// notifySkip notifies j that it has been skipped, if it supports it.
+func notifySkip(j Job) {
+	if s, ok := j.(skipper); ok {
+		s.skip()
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type recordingObserver struct {
+	lock   sync.Mutex
+	events []string
+	durs   []time.Duration
+}
+
+func (o *recordingObserver) record(format string, args ...any) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+}
+
+func (o *recordingObserver) JobScheduled(id EntryID, next time.Time) {
+	o.record("scheduled %d", id)
+}
+
+func (o *recordingObserver) JobStarted(id EntryID) {
+	o.record("started %d", id)
+}
+
+func (o *recordingObserver) JobCompleted(id EntryID, duration time.Duration) {
+	o.lock.Lock()
+	o.durs = append(o.durs, duration)
+	o.lock.Unlock()
+	o.record("completed %d", id)
+}
+
+func (o *recordingObserver) JobSkipped(id EntryID) {
+	o.record("skipped %d", id)
+}
+
+func (o *recordingObserver) JobPanicked(id EntryID, recovered any) {
+	o.record("panicked %d: %v", id, recovered)
+}
+
+func (o *recordingObserver) Events() []string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return append([]string(nil), o.events...)
+}
+
+func TestObserver(t *testing.T) {
+	t.Run("scheduled, started and completed", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		observer := &recordingObserver{}
+		cron := New(WithClock(clock), WithObserver(observer))
+
+		done := make(chan struct{})
+		id, err := cron.AddFunc("@every 1s", func() {
+			clock.Step(5 * time.Millisecond)
+			close(done)
+		})
+		require.NoError(t, err)
+
+		cron.Start()
+		defer cron.Stop()
+
+		assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+		clock.Step(time.Second)
+
+		select {
+		case <-done:
+		case <-time.After(OneSecond):
+			t.Fatal("job did not run")
+		}
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.Equal(c, []string{
+				fmt.Sprintf("scheduled %d", id),
+				fmt.Sprintf("scheduled %d", id),
+				fmt.Sprintf("started %d", id),
+				fmt.Sprintf("completed %d", id),
+			}, observer.Events())
+		}, OneSecond, 10*time.Millisecond)
+
+		observer.lock.Lock()
+		defer observer.lock.Unlock()
+		assert.Equal(t, []time.Duration{5 * time.Millisecond}, observer.durs)
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		observer := &recordingObserver{}
+		cron := New(
+			WithClock(clock),
+			WithObserver(observer),
+			WithChain(Recover(DiscardLogger), SkipIfStillRunning(DiscardLogger)),
+		)
+
+		release := make(chan struct{})
+		id, err := cron.AddFunc("@every 1s", func() {
+			<-release
+		})
+		require.NoError(t, err)
+
+		cron.Start()
+		defer cron.Stop()
+
+		assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+		clock.Step(time.Second)
+		assert.Eventually(t, func() bool {
+			return slices.Contains(observer.Events(), fmt.Sprintf("started %d", id))
+		}, OneSecond, 10*time.Millisecond)
+
+		assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+		clock.Step(time.Second)
+		assert.Eventually(t, func() bool {
+			return slices.Contains(observer.Events(), fmt.Sprintf("skipped %d", id))
+		}, OneSecond, 10*time.Millisecond)
+
+		close(release)
+		assert.Eventually(t, func() bool {
+			return slices.Contains(observer.Events(), fmt.Sprintf("completed %d", id))
+		}, OneSecond, 10*time.Millisecond)
+	})
+
+	t.Run("panicked", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		observer := &recordingObserver{}
+		cron := New(
+			WithClock(clock),
+			WithObserver(observer),
+			WithChain(Recover(DiscardLogger)),
+		)
+
+		id, err := cron.AddFunc("@every 1s", func() {
+			panic("YOLO")
+		})
+		require.NoError(t, err)
+
+		cron.Start()
+		defer cron.Stop()
+
+		assert.Eventually(t, clock.HasWaiters, OneSecond, 10*time.Millisecond)
+		clock.Step(time.Second)
+		assert.Eventually(t, func() bool {
+			return slices.Contains(observer.Events(), fmt.Sprintf("panicked %d: YOLO", id))
+		}, OneSecond, 10*time.Millisecond)
+		assert.NotContains(t, observer.Events(), fmt.Sprintf("completed %d", id))
+	})
+
+	t.Run("NopObserver", func(t *testing.T) {
+		var _ Observer = NopObserver{}
+	})
+}
@@ -68,3 +68,19 @@ func WithClock(clk clock.Clock) Option {
 		c.clk = clk
 	}
 }
+
+// WithCatchUpPolicy sets how missed activations are handled, for example when
+// the process wakes up after a long pause.
+func WithCatchUpPolicy(policy CatchUpPolicy) Option {
+	return func(c *Cron) {
+		c.catchUp = policy
+	}
+}
+
+// WithObserver sets an Observer which is notified of the lifecycle events of
+// the jobs added to this cron.
+func WithObserver(observer Observer) Option {
+	return func(c *Cron) {
+		c.observer = observer
+	}
+}
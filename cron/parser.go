@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Configuration options for creating a parser. Most options specify which
@@ -99,59 +100,99 @@ func NewParser(options ParseOption) Parser {
 }
 
 // Parse returns a new crontab schedule representing the given spec.
-// It returns a descriptive error if the spec is not valid.
+// It returns a *ParseError describing the failure if the spec is not valid.
 // It accepts crontab specs and features configured by NewParser.
 func (p Parser) Parse(spec string) (Schedule, error) {
+	fullSpec := spec
+	specErr := func(err error) error {
+		return &ParseError{Spec: fullSpec, Offset: -1, Err: err}
+	}
+
 	if len(spec) == 0 {
-		return nil, fmt.Errorf("empty spec string")
+		return nil, specErr(fmt.Errorf("empty spec string"))
 	}
 
 	// Extract timezone if present
 	loc := time.Local
+	offset := 0
 	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
 		var err error
 		i := strings.Index(spec, " ")
+		if i < 0 {
+			i = len(spec)
+		}
 		eq := strings.Index(spec, "=")
 		if loc, err = time.LoadLocation(spec[eq+1 : i]); err != nil {
-			return nil, fmt.Errorf("provided bad location %s: %v", spec[eq+1:i], err)
+			return nil, &ParseError{
+				Spec:   fullSpec,
+				Field:  FieldLocation,
+				Value:  spec[eq+1 : i],
+				Offset: eq + 1,
+				Err:    fmt.Errorf("provided bad location %s: %v", spec[eq+1:i], err),
+			}
 		}
-		spec = strings.TrimSpace(spec[i:])
+		rest := spec[i:]
+		spec = strings.TrimSpace(rest)
+		offset = i + strings.Index(rest, spec)
 	}
 
 	// Handle named schedules (descriptors), if configured
 	if strings.HasPrefix(spec, "@") {
 		if p.options&Descriptor == 0 {
-			return nil, fmt.Errorf("parser does not accept descriptors: %v", spec)
+			return nil, specErr(fmt.Errorf("parser does not accept descriptors: %v", spec))
+		}
+		schedule, err := parseDescriptor(spec, loc)
+		if err != nil {
+			return nil, &ParseError{
+				Spec:   fullSpec,
+				Field:  FieldDescriptor,
+				Value:  spec,
+				Offset: offset,
+				Err:    err,
+			}
 		}
-		return parseDescriptor(spec, loc)
+		return schedule, nil
 	}
 
 	// Split on whitespace.
-	fields := strings.Fields(spec)
+	fields, offsets := splitFields(spec, offset)
 
 	// Validate & fill in any omitted or optional fields
-	var err error
-	fields, err = normalizeFields(fields, p.options)
+	fields, positions, err := normalizeFieldPositions(fields, p.options)
 	if err != nil {
-		return nil, err
+		return nil, specErr(err)
 	}
 
-	field := func(field string, r bounds) uint64 {
+	field := func(i int, r bounds) uint64 {
 		if err != nil {
 			return 0
 		}
 		var bits uint64
-		bits, err = getField(field, r)
+		bits, err = getField(fields[i], r)
+		if err != nil {
+			pe := &ParseError{
+				Spec:     fullSpec,
+				Field:    specFields[i],
+				Value:    fields[i],
+				Position: positions[i],
+				Offset:   -1,
+				Err:      err,
+			}
+			if pe.Position > 0 {
+				pe.Offset = offsets[pe.Position-1]
+			}
+			err = pe
+		}
 		return bits
 	}
 
 	var (
-		second     = field(fields[0], seconds)
-		minute     = field(fields[1], minutes)
-		hour       = field(fields[2], hours)
-		dayofmonth = field(fields[3], dom)
-		month      = field(fields[4], months)
-		dayofweek  = field(fields[5], dow)
+		second     = field(0, seconds)
+		minute     = field(1, minutes)
+		hour       = field(2, hours)
+		dayofmonth = field(3, dom)
+		month      = field(4, months)
+		dayofweek  = field(5, dow)
 	)
 	if err != nil {
 		return nil, err
@@ -168,12 +209,42 @@ func (p Parser) Parse(spec string) (Schedule, error) {
 	}, nil
 }
 
+// splitFields splits the spec on whitespace, returning the fields and their
+// offsets in bytes, starting from base.
+func splitFields(spec string, base int) ([]string, []int) {
+	var (
+		fields  []string
+		offsets []int
+	)
+	start := -1
+	for i, r := range spec + " " {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, spec[start:i])
+				offsets = append(offsets, base+start)
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	return fields, offsets
+}
+
 // normalizeFields takes a subset set of the time fields and returns the full set
 // with defaults (zeroes) populated for unset fields.
 //
 // As part of performing this function, it also validates that the provided
 // fields are compatible with the configured options.
 func normalizeFields(fields []string, options ParseOption) ([]string, error) {
+	expandedFields, _, err := normalizeFieldPositions(fields, options)
+	return expandedFields, err
+}
+
+// normalizeFieldPositions is like normalizeFields, and it also returns the
+// 1-based position in the given fields of each of the returned fields, or 0
+// for those populated with defaults.
+func normalizeFieldPositions(fields []string, options ParseOption) ([]string, []int, error) {
 	// Validate optionals & add their field to options
 	optionals := 0
 	if options&SecondOptional > 0 {
@@ -185,7 +256,7 @@ func normalizeFields(fields []string, options ParseOption) ([]string, error) {
 		optionals++
 	}
 	if optionals > 1 {
-		return nil, fmt.Errorf("multiple optionals may not be configured")
+		return nil, nil, fmt.Errorf("multiple optionals may not be configured")
 	}
 
 	// Figure out how many fields we need
@@ -200,9 +271,15 @@ func normalizeFields(fields []string, options ParseOption) ([]string, error) {
 	// Validate number of fields
 	if count := len(fields); count < min || count > max {
 		if min == max {
-			return nil, fmt.Errorf("expected exactly %d fields, found %d: %s", min, count, fields)
+			return nil, nil, fmt.Errorf("expected exactly %d fields, found %d: %s", min, count, fields)
 		}
-		return nil, fmt.Errorf("expected %d to %d fields, found %d: %s", min, max, count, fields)
+		return nil, nil, fmt.Errorf("expected %d to %d fields, found %d: %s", min, max, count, fields)
+	}
+
+	// Positions of the given fields, 0 for the populated ones
+	fieldPositions := make([]int, len(fields))
+	for i := range fieldPositions {
+		fieldPositions[i] = i + 1
 	}
 
 	// Populate the optional field if not provided
@@ -210,24 +287,28 @@ func normalizeFields(fields []string, options ParseOption) ([]string, error) {
 		switch {
 		case options&DowOptional > 0:
 			fields = append(fields, defaults[5]) // TODO: improve access to default
+			fieldPositions = append(fieldPositions, 0)
 		case options&SecondOptional > 0:
 			fields = append([]string{defaults[0]}, fields...)
+			fieldPositions = append([]int{0}, fieldPositions...)
 		default:
-			return nil, fmt.Errorf("unknown optional field")
+			return nil, nil, fmt.Errorf("unknown optional field")
 		}
 	}
 
 	// Populate all fields not part of options with their defaults
 	n := 0
 	expandedFields := make([]string, len(places))
+	positions := make([]int, len(places))
 	copy(expandedFields, defaults)
 	for i, place := range places {
 		if options&place > 0 {
 			expandedFields[i] = fields[n]
+			positions[i] = fieldPositions[n]
 			n++
 		}
 	}
-	return expandedFields, nil
+	return expandedFields, positions, nil
 }
 
 var standardParser = NewParser(
@@ -295,7 +376,7 @@ func getRange(expr string, r bounds) (uint64, error) {
 				return 0, err
 			}
 		default:
-			return 0, fmt.Errorf("too many hyphens: %s", expr)
+			return 0, fmt.Errorf("%w: too many hyphens: %s", ErrInvalidRange, expr)
 		}
 	}
 
@@ -316,20 +397,20 @@ func getRange(expr string, r bounds) (uint64, error) {
 			extra = 0
 		}
 	default:
-		return 0, fmt.Errorf("too many slashes: %s", expr)
+		return 0, fmt.Errorf("%w: too many slashes: %s", ErrInvalidRange, expr)
 	}
 
 	if start < r.min {
-		return 0, fmt.Errorf("beginning of range (%d) below minimum (%d): %s", start, r.min, expr)
+		return 0, fmt.Errorf("%w: beginning of range (%d) below minimum (%d): %s", ErrOutOfRange, start, r.min, expr)
 	}
 	if end > r.max {
-		return 0, fmt.Errorf("end of range (%d) above maximum (%d): %s", end, r.max, expr)
+		return 0, fmt.Errorf("%w: end of range (%d) above maximum (%d): %s", ErrOutOfRange, end, r.max, expr)
 	}
 	if start > end {
-		return 0, fmt.Errorf("beginning of range (%d) beyond end of range (%d): %s", start, end, expr)
+		return 0, fmt.Errorf("%w: beginning of range (%d) beyond end of range (%d): %s", ErrInvalidRange, start, end, expr)
 	}
 	if step == 0 {
-		return 0, fmt.Errorf("step of range should be a positive number: %s", expr)
+		return 0, fmt.Errorf("%w: step of range should be a positive number: %s", ErrInvalidRange, expr)
 	}
 
 	return getBits(start, end, step) | extra, nil
@@ -349,10 +430,10 @@ func parseIntOrName(expr string, names map[string]uint) (uint, error) {
 func mustParseInt(expr string) (uint, error) {
 	num, err := strconv.Atoi(expr)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse int from %s: %s", expr, err)
+		return 0, fmt.Errorf("%w: failed to parse int from %s: %s", ErrInvalidNumber, expr, err)
 	}
 	if num < 0 {
-		return 0, fmt.Errorf("negative number (%d) not allowed: %s", num, expr)
+		return 0, fmt.Errorf("%w: negative number (%d) not allowed: %s", ErrInvalidNumber, num, expr)
 	}
 
 	return uint(num), nil
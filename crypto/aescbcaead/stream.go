@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aescbcaead
+
+// This implements streaming encryption with the AES-CBC+HMAC AEADs.
+// The plaintext is split into segments of StreamSegmentSize bytes, and each segment is sealed independently, similarly to what schemes/enc/v1 does.
+// The nonce of each segment is made of:
+// - The nonce prefix (StreamNoncePrefixSize bytes)
+// - The segment number (uint32, big-endian)
+// - A flag which is 0x01 for the last segment and 0x00 otherwise
+// CBC requires an unpredictable IV, so the segment nonce isn't used as IV directly: the IV is the segment nonce encrypted with AES under the encryption key (see NIST SP 800-38A, appendix C).
+// Because AES is a permutation, the IV uniquely identifies the segment nonce; the IV is included in the HMAC input, so the segment number and last flag are authenticated too.
+// Because every segment is authenticated before it's returned, and the last segment is marked, this prevents reordering and truncation of the stream.
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// StreamSegmentSize is the size of each plaintext segment in a stream.
+	StreamSegmentSize = 64 << 10
+	// StreamNoncePrefixSize is the size of the nonce prefix for streams.
+	StreamNoncePrefixSize = aes.BlockSize - 5
+)
+
+// NewSealWriter returns a WriteCloser that encrypts data written to it and writes the ciphertext to w.
+// The AEAD must be one returned by this package.
+// The nonce prefix must be StreamNoncePrefixSize bytes long and must never be reused with the same key.
+// The additional data is authenticated for every segment.
+// Callers must invoke Close to write the last segment; Close does not close w.
+func NewSealWriter(aead cipher.AEAD, w io.Writer, noncePrefix, additionalData []byte) (io.WriteCloser, error) {
+	s, err := newStream(aead, noncePrefix, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return &sealWriter{
+		stream: s,
+		w:      w,
+		buf:    make([]byte, 0, StreamSegmentSize+1),
+	}, nil
+}
+
+// NewOpenReader returns a Reader that decrypts a stream created with NewSealWriter, reading the ciphertext from r.
+// Data is returned only after the segment it belongs to has been authenticated; however, callers must still treat the whole stream as invalid if Read returns an error.
+func NewOpenReader(aead cipher.AEAD, r io.Reader, noncePrefix, additionalData []byte) (io.Reader, error) {
+	s, err := newStream(aead, noncePrefix, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return &openReader{
+		stream: s,
+		r:      r,
+		// Segments are padded to the next block, then the tag is appended
+		segmentSize: StreamSegmentSize + aes.BlockSize + s.aead.tagSize,
+	}, nil
+}
+
+type stream struct {
+	aead           *aesCBCAEAD
+	block          cipher.Block
+	noncePrefix    []byte
+	additionalData []byte
+	num            uint32
+	done           bool
+}
+
+func newStream(aead cipher.AEAD, noncePrefix, additionalData []byte) (*stream, error) {
+	a, ok := aead.(*aesCBCAEAD)
+	if !ok {
+		return nil, errors.New("AEAD is not an AES-CBC+HMAC AEAD from this package")
+	}
+	if len(noncePrefix) != StreamNoncePrefixSize {
+		return nil, fmt.Errorf("nonce prefix must be %d bytes long", StreamNoncePrefixSize)
+	}
+	block, err := aes.NewCipher(a.encKey)
+	if err != nil {
+		return nil, err
+	}
+	return &stream{
+		aead:           a,
+		block:          block,
+		noncePrefix:    noncePrefix,
+		additionalData: additionalData,
+	}, nil
+}
+
+// nextIV returns the IV for the next segment, derived from its nonce.
+func (s *stream) nextIV(last bool) ([]byte, error) {
+	if s.done {
+		return nil, errors.New("stream has already ended")
+	}
+	if s.num == math.MaxUint32 && !last {
+		return nil, errors.New("stream is too long")
+	}
+
+	nonce := make([]byte, aes.BlockSize)
+	copy(nonce, s.noncePrefix)
+	binary.BigEndian.PutUint32(nonce[StreamNoncePrefixSize:], s.num)
+	if last {
+		nonce[aes.BlockSize-1] = 0x01
+		s.done = true
+	}
+	s.num++
+
+	s.block.Encrypt(nonce, nonce)
+	return nonce, nil
+}
+
+type sealWriter struct {
+	*stream
+	w   io.Writer
+	buf []byte
+	out []byte
+	err error
+}
+
+func (sw *sealWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	if sw.done {
+		return 0, errors.New("writer is closed")
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		c := min(len(p), cap(sw.buf)-len(sw.buf))
+		sw.buf = append(sw.buf, p[:c]...)
+		p = p[c:]
+
+		// Seal a full segment only once we know there's more data after it, so the last segment can be marked as such
+		if len(sw.buf) > StreamSegmentSize {
+			if err := sw.seal(sw.buf[:StreamSegmentSize], false); err != nil {
+				return n - len(p), err
+			}
+			sw.buf = append(sw.buf[:0], sw.buf[StreamSegmentSize:]...)
+		}
+	}
+	return n, nil
+}
+
+// Close seals the last segment.
+func (sw *sealWriter) Close() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	if sw.done {
+		return nil
+	}
+	return sw.seal(sw.buf, true)
+}
+
+// seal encrypts a segment and writes it. After an error, the writer can't be used anymore.
+func (sw *sealWriter) seal(segment []byte, last bool) error {
+	iv, err := sw.nextIV(last)
+	if err == nil {
+		sw.out = sw.aead.Seal(sw.out[:0], iv, segment, sw.additionalData)
+		_, err = sw.w.Write(sw.out)
+	}
+	sw.err = err
+	return err
+}
+
+type openReader struct {
+	*stream
+	r           io.Reader
+	segmentSize int
+	buf         []byte
+	plaintext   []byte
+	err         error
+}
+
+func (or *openReader) Read(p []byte) (int, error) {
+	for len(or.plaintext) == 0 {
+		if or.err != nil {
+			return 0, or.err
+		}
+		if or.done {
+			return 0, io.EOF
+		}
+		or.err = or.readSegment()
+	}
+
+	n := copy(p, or.plaintext)
+	or.plaintext = or.plaintext[n:]
+	return n, nil
+}
+
+// readSegment reads and decrypts the next segment.
+func (or *openReader) readSegment() error {
+	if or.buf == nil {
+		or.buf = make([]byte, 0, or.segmentSize+1)
+	}
+
+	// Read one byte more than the segment to know if this is the last one
+	n, err := io.ReadFull(or.r, or.buf[len(or.buf):or.segmentSize+1])
+	or.buf = or.buf[:len(or.buf)+n]
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// This is the last segment
+	case err != nil:
+		return err
+	}
+
+	last := len(or.buf) <= or.segmentSize
+	segment := or.buf
+	if !last {
+		segment = or.buf[:or.segmentSize]
+	}
+
+	iv, err := or.nextIV(last)
+	if err != nil {
+		return err
+	}
+	plaintext, err := or.aead.Open(nil, iv, segment, or.additionalData)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt segment %d: %w", or.num-1, err)
+	}
+	or.plaintext = plaintext
+
+	// Keep the extra byte for the next segment
+	if last {
+		or.buf = or.buf[:0]
+	} else {
+		or.buf = append(or.buf[:0], or.buf[or.segmentSize:]...)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aescbcaead
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	key := make([]byte, 64)
+	_, err := io.ReadFull(rand.Reader, key)
+	require.NoError(t, err)
+	aead, err := NewAESCBC256SHA512(key)
+	require.NoError(t, err)
+
+	noncePrefix := make([]byte, StreamNoncePrefixSize)
+	_, err = io.ReadFull(rand.Reader, noncePrefix)
+	require.NoError(t, err)
+	aad := []byte("additional data")
+
+	seal := func(t *testing.T, plaintext []byte, writeSize int) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		w, err := NewSealWriter(aead, &out, noncePrefix, aad)
+		require.NoError(t, err)
+		for len(plaintext) > 0 {
+			c := min(writeSize, len(plaintext))
+			n, err := w.Write(plaintext[:c])
+			require.NoError(t, err)
+			require.Equal(t, c, n)
+			plaintext = plaintext[c:]
+		}
+		require.NoError(t, w.Close())
+		return out.Bytes()
+	}
+
+	open := func(ciphertext []byte, aad []byte) ([]byte, error) {
+		r, err := NewOpenReader(aead, bytes.NewReader(ciphertext), noncePrefix, aad)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+
+	sizes := []int{0, 1, StreamSegmentSize - 1, StreamSegmentSize, StreamSegmentSize + 1, 3*StreamSegmentSize + 5}
+	for _, size := range sizes {
+		plaintext := make([]byte, size)
+		_, err = io.ReadFull(rand.Reader, plaintext)
+		require.NoError(t, err)
+
+		for _, writeSize := range []int{1000, StreamSegmentSize * 2} {
+			ciphertext := seal(t, plaintext, writeSize)
+			got, err := open(ciphertext, aad)
+			require.NoErrorf(t, err, "size %d, write size %d", size, writeSize)
+			assert.Truef(t, bytes.Equal(plaintext, got), "size %d, write size %d", size, writeSize)
+		}
+	}
+
+	t.Run("single segment uses an IV derived from the nonce", func(t *testing.T) {
+		plaintext := []byte("hello world")
+		nonce := append(bytes.Clone(noncePrefix), 0, 0, 0, 0, 1)
+		block, err := aes.NewCipher(aead.(*aesCBCAEAD).encKey)
+		require.NoError(t, err)
+		iv := make([]byte, aes.BlockSize)
+		block.Encrypt(iv, nonce)
+
+		ciphertext := seal(t, plaintext, 100)
+		assert.Equal(t, aead.Seal(nil, iv, plaintext, aad), ciphertext)
+		assert.NotEqual(t, aead.Seal(nil, nonce, plaintext, aad), ciphertext)
+	})
+
+	plaintext := make([]byte, 2*StreamSegmentSize+100)
+	ciphertext := seal(t, plaintext, StreamSegmentSize)
+
+	t.Run("wrong additional data", func(t *testing.T) {
+		_, err := open(ciphertext, []byte("other"))
+		require.ErrorContains(t, err, "message authentication failed")
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		tampered := bytes.Clone(ciphertext)
+		tampered[len(tampered)-50] ^= 0x01
+		_, err := open(tampered, aad)
+		require.ErrorContains(t, err, "failed to decrypt segment 2")
+	})
+
+	t.Run("truncated at a segment boundary", func(t *testing.T) {
+		segmentSize := StreamSegmentSize + 16 + aead.Overhead()
+		_, err := open(ciphertext[:2*segmentSize], aad)
+		require.ErrorContains(t, err, "failed to decrypt segment 1")
+	})
+
+	t.Run("reordered segments", func(t *testing.T) {
+		segmentSize := StreamSegmentSize + 16 + aead.Overhead()
+		reordered := append(bytes.Clone(ciphertext[segmentSize:2*segmentSize]), ciphertext[:segmentSize]...)
+		reordered = append(reordered, ciphertext[2*segmentSize:]...)
+		_, err := open(reordered, aad)
+		require.ErrorContains(t, err, "failed to decrypt segment 0")
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewSealWriter(aead, io.Discard, []byte("short"), nil)
+		require.Error(t, err)
+		_, err = NewOpenReader(aead, bytes.NewReader(nil), []byte("short"), nil)
+		require.Error(t, err)
+	})
+
+	t.Run("writing after close fails", func(t *testing.T) {
+		w, err := NewSealWriter(aead, io.Discard, noncePrefix, nil)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		_, err = w.Write([]byte("hello"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aesgcmsiv
+
+// This implements AES-GCM-SIV, a nonce misuse-resistant AEAD.
+// Encrypting the same message with the same key, nonce and additional data
+// always returns the same ciphertext, and reusing a nonce only reveals
+// whether two messages are identical.
+// Spec: https://www.rfc-editor.org/rfc/rfc8452
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// NonceSize is the size of the nonces, in bytes.
+	NonceSize = 12
+	// TagSize is the size of the authentication tags, in bytes.
+	TagSize = 16
+
+	blockSize = aes.BlockSize
+	// Maximum size of the plaintext and of the additional data, per specs.
+	maxInputSize = 1 << 36
+)
+
+var errOpen = errors.New("message authentication failed")
+
+// New returns an AEAD_AES_128_GCM_SIV or AEAD_AES_256_GCM_SIV instance given
+// a 16-byte or 32-byte key respectively, or an error if the key is the wrong
+// size.
+func New(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, fmt.Errorf("key must be 16 or 32 bytes long, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCMSIV{
+		keyGen:  block,
+		keySize: len(key),
+	}, nil
+}
+
+type aesGCMSIV struct {
+	// Block cipher with the key-generating key
+	keyGen  cipher.Block
+	keySize int
+}
+
+func (aead *aesGCMSIV) NonceSize() int {
+	return NonceSize
+}
+
+func (aead *aesGCMSIV) Overhead() int {
+	return TagSize
+}
+
+func (aead *aesGCMSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	// Like the AEADs in the standard library, we panic on invalid input because the interface doesn't allow returning errors
+	if len(nonce) != NonceSize {
+		panic("aesgcmsiv: incorrect nonce length given to AES-GCM-SIV")
+	}
+	if uint64(len(plaintext)) > maxInputSize || uint64(len(additionalData)) > maxInputSize {
+		panic("aesgcmsiv: message too large for AES-GCM-SIV")
+	}
+
+	authKey, encBlock := aead.deriveKeys(nonce)
+
+	// The tag is computed over the plaintext first, then it's used as initial counter
+	tag := computeTag(authKey, encBlock, nonce, plaintext, additionalData)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+TagSize)
+	ctr(encBlock, tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func (aead *aesGCMSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic("aesgcmsiv: incorrect nonce length given to AES-GCM-SIV")
+	}
+	if len(ciphertext) < TagSize ||
+		uint64(len(ciphertext)) > maxInputSize+TagSize ||
+		uint64(len(additionalData)) > maxInputSize {
+		return nil, errOpen
+	}
+
+	// Copy the tag because out may overlap with the ciphertext
+	var tag [TagSize]byte
+	copy(tag[:], ciphertext[len(ciphertext)-TagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-TagSize]
+
+	authKey, encBlock := aead.deriveKeys(nonce)
+
+	// Decrypt first, because the tag is computed over the plaintext
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	ctr(encBlock, tag, out, ciphertext)
+
+	expectTag := computeTag(authKey, encBlock, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(tag[:], expectTag[:]) != 1 {
+		// Do not leak the unauthenticated plaintext
+		clear(out)
+		return nil, errOpen
+	}
+
+	return ret, nil
+}
+
+// deriveKeys returns the message-authentication key and the block cipher with
+// the message-encryption key for the nonce.
+func (aead *aesGCMSIV) deriveKeys(nonce []byte) (authKey []byte, encBlock cipher.Block) {
+	// Each block of key material is the first half of the encryption of a little-endian counter followed by the nonce
+	keys := make([]byte, blockSize+aead.keySize)
+	var in, out [blockSize]byte
+	copy(in[4:], nonce)
+	for i := 0; i*8 < len(keys); i++ {
+		binary.LittleEndian.PutUint32(in[:4], uint32(i))
+		aead.keyGen.Encrypt(out[:], in[:])
+		copy(keys[i*8:], out[:8])
+	}
+
+	encBlock, err := aes.NewCipher(keys[blockSize:])
+	if err != nil {
+		// Should never happen as the key size is always valid
+		panic(err)
+	}
+	return keys[:blockSize], encBlock
+}
+
+func computeTag(authKey []byte, encBlock cipher.Block, nonce, plaintext, additionalData []byte) [TagSize]byte {
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+
+	// The last block contains the lengths in bits
+	var lengths [blockSize]byte
+	binary.LittleEndian.PutUint64(lengths[0:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:16], uint64(len(plaintext))*8)
+	p.updateBlock(lengths[:])
+
+	s := p.sum()
+	subtle.XORBytes(s[:NonceSize], s[:NonceSize], nonce)
+	s[15] &= 0x7f
+
+	var tag [TagSize]byte
+	encBlock.Encrypt(tag[:], s[:])
+	return tag
+}
+
+// ctr encrypts or decrypts src into dst in counter mode, with the tag as
+// initial counter block and a 32-bit little-endian counter.
+func ctr(encBlock cipher.Block, tag [TagSize]byte, dst, src []byte) {
+	counter := tag
+	counter[15] |= 0x80
+
+	var keystream [blockSize]byte
+	for len(src) > 0 {
+		encBlock.Encrypt(keystream[:], counter[:])
+		n := subtle.XORBytes(dst, src, keystream[:])
+		dst = dst[n:]
+		src = src[n:]
+
+		// The counter wraps around per specs
+		c := binary.LittleEndian.Uint32(counter[:4])
+		binary.LittleEndian.PutUint32(counter[:4], c+1)
+	}
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and
+// a second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return head, tail
+}
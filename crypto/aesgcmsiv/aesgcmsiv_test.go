@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aesgcmsiv
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESGCMSIV(t *testing.T) {
+	t.Run("test cases from RFC", func(t *testing.T) {
+		// These test cases come from https://www.rfc-editor.org/rfc/rfc8452#appendix-C
+		tests := []struct {
+			name       string
+			key        string
+			plaintext  string
+			ciphertext string
+		}{
+			{name: "AEAD_AES_128_GCM_SIV empty", key: "01000000000000000000000000000000", plaintext: "", ciphertext: "dc20e2d83f25705bb49e439eca56de25"},
+			{name: "AEAD_AES_128_GCM_SIV 8 bytes", key: "01000000000000000000000000000000", plaintext: "0100000000000000", ciphertext: "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+			{name: "AEAD_AES_128_GCM_SIV 12 bytes", key: "01000000000000000000000000000000", plaintext: "010000000000000000000000", ciphertext: "7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639"},
+			{name: "AEAD_AES_128_GCM_SIV 16 bytes", key: "01000000000000000000000000000000", plaintext: "01000000000000000000000000000000", ciphertext: "743f7c8077ab25f8624e2e948579cf77303aaf90f6fe21199c6068577437a0c4"},
+			{name: "AEAD_AES_256_GCM_SIV empty", key: "0100000000000000000000000000000000000000000000000000000000000000", plaintext: "", ciphertext: "07f5f4169bbf55a8400cd47ea6fd400f"},
+			{name: "AEAD_AES_256_GCM_SIV 8 bytes", key: "0100000000000000000000000000000000000000000000000000000000000000", plaintext: "0100000000000000", ciphertext: "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28"},
+		}
+		nonce, _ := hex.DecodeString("030000000000000000000000")
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				key, _ := hex.DecodeString(tc.key)
+				plaintext, _ := hex.DecodeString(tc.plaintext)
+
+				aead, err := New(key)
+				require.NoError(t, err)
+				require.Len(t, nonce, aead.NonceSize())
+				require.Equal(t, 16, aead.Overhead())
+
+				gotCiphertext := aead.Seal(nil, nonce, plaintext, nil)
+				require.Equal(t, tc.ciphertext, hex.EncodeToString(gotCiphertext))
+
+				gotPlaintext, err := aead.Open(nil, nonce, gotCiphertext, nil)
+				require.NoError(t, err)
+				require.Equal(t, tc.plaintext, hex.EncodeToString(gotPlaintext))
+			})
+		}
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		_, err := New(make([]byte, 24))
+		require.Error(t, err)
+	})
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	aead, err := New(key)
+	require.NoError(t, err)
+
+	nonce := make([]byte, NonceSize)
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+	plaintext := []byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit!")
+	aad := []byte("aad")
+
+	t.Run("encryption is deterministic", func(t *testing.T) {
+		ct1 := aead.Seal(nil, nonce, plaintext, aad)
+		ct2 := aead.Seal(nil, nonce, plaintext, aad)
+		assert.Equal(t, ct1, ct2)
+
+		// A different nonce or additional data changes the ciphertext
+		otherNonce := make([]byte, NonceSize)
+		assert.NotEqual(t, ct1, aead.Seal(nil, otherNonce, plaintext, aad))
+		assert.NotEqual(t, ct1, aead.Seal(nil, nonce, plaintext, []byte("other")))
+	})
+
+	t.Run("tampered messages are rejected", func(t *testing.T) {
+		ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+
+		for i := range ciphertext {
+			tampered := append([]byte{}, ciphertext...)
+			tampered[i] ^= 0x01
+			_, err := aead.Open(nil, nonce, tampered, aad)
+			require.ErrorIsf(t, err, errOpen, "byte %d", i)
+		}
+
+		_, err := aead.Open(nil, nonce, ciphertext, []byte("other"))
+		require.ErrorIs(t, err, errOpen)
+		_, err = aead.Open(nil, nonce, ciphertext[:TagSize-1], aad)
+		require.ErrorIs(t, err, errOpen)
+	})
+
+	t.Run("seal and open in place", func(t *testing.T) {
+		buf := make([]byte, len(plaintext), len(plaintext)+TagSize)
+		copy(buf, plaintext)
+		ciphertext := aead.Seal(buf[:0], nonce, buf, aad)
+		assert.Equal(t, aead.Seal(nil, nonce, plaintext, aad), ciphertext)
+
+		decrypted, err := aead.Open(ciphertext[:0], nonce, ciphertext, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("appends to dst", func(t *testing.T) {
+		sealed := aead.Seal([]byte("prefix"), nonce, plaintext, aad)
+		assert.Equal(t, "prefix", string(sealed[:6]))
+
+		opened, err := aead.Open([]byte("prefix"), nonce, sealed[6:], aad)
+		require.NoError(t, err)
+		assert.Equal(t, "prefix"+string(plaintext), string(opened))
+	})
+
+	t.Run("invalid nonce size panics", func(t *testing.T) {
+		assert.Panics(t, func() {
+			aead.Seal(nil, nonce[:8], plaintext, aad)
+		})
+	})
+}
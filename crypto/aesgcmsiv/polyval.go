@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aesgcmsiv
+
+import (
+	"encoding/binary"
+)
+
+// polyval implements the POLYVAL universal hash function.
+// Spec: https://www.rfc-editor.org/rfc/rfc8452#section-3
+type polyval struct {
+	h fieldElement
+	s fieldElement
+}
+
+// fieldElement is an element of GF(2^128) as defined for POLYVAL, where the
+// least significant bit of lo is the coefficient of x^0.
+type fieldElement struct {
+	lo, hi uint64
+}
+
+func newPolyval(key []byte) *polyval {
+	return &polyval{
+		h: loadFieldElement(key),
+	}
+}
+
+func loadFieldElement(b []byte) fieldElement {
+	return fieldElement{
+		lo: binary.LittleEndian.Uint64(b[0:8]),
+		hi: binary.LittleEndian.Uint64(b[8:16]),
+	}
+}
+
+// update adds data to the hash, padding it with zeros to a multiple of the
+// block size.
+func (p *polyval) update(data []byte) {
+	for len(data) >= blockSize {
+		p.updateBlock(data[:blockSize])
+		data = data[blockSize:]
+	}
+	if len(data) > 0 {
+		var block [blockSize]byte
+		copy(block[:], data)
+		p.updateBlock(block[:])
+	}
+}
+
+func (p *polyval) updateBlock(block []byte) {
+	x := loadFieldElement(block)
+	p.s.lo ^= x.lo
+	p.s.hi ^= x.hi
+	p.s = dot(p.s, p.h)
+}
+
+// sum returns the current value of the hash.
+func (p *polyval) sum() [blockSize]byte {
+	var out [blockSize]byte
+	binary.LittleEndian.PutUint64(out[0:8], p.s.lo)
+	binary.LittleEndian.PutUint64(out[8:16], p.s.hi)
+	return out
+}
+
+// dot returns a*b*x^-128 in the POLYVAL field, whose modulus is
+// x^128 + x^127 + x^126 + x^121 + 1.
+// It processes one bit of b at a time without branching on secret data, so
+// it's slow but constant-time; that's acceptable for the short messages this
+// package is meant for.
+func dot(a, b fieldElement) fieldElement {
+	var acc fieldElement
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = (b.lo >> i) & 1
+		} else {
+			bit = (b.hi >> (i - 64)) & 1
+		}
+		mask := -bit
+		acc.lo ^= a.lo & mask
+		acc.hi ^= a.hi & mask
+
+		// Multiply acc by x^-1, which is x^127 + x^126 + x^125 + x^120
+		carry := -(acc.lo & 1)
+		acc.lo = acc.lo>>1 | acc.hi<<63
+		acc.hi = acc.hi>>1 ^ (0xe100000000000000 & carry)
+	}
+	return acc
+}
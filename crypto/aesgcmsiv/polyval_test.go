@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aesgcmsiv
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolyval(t *testing.T) {
+	// Test case from https://www.rfc-editor.org/rfc/rfc8452#appendix-A
+	h, _ := hex.DecodeString("25629347589242761d31f826ba4b757b")
+	x1, _ := hex.DecodeString("4f4f95668c83dfb6401762bb2d01a262")
+	x2, _ := hex.DecodeString("d1a24ddd2721d006bbe45f20d3c9f362")
+
+	p := newPolyval(h)
+	p.update(x1)
+	p.update(x2)
+	sum := p.sum()
+	assert.Equal(t, "f7a3b47b846119fae5b7866cf5e5b77e", hex.EncodeToString(sum[:]))
+
+	t.Run("partial blocks are padded with zeros", func(t *testing.T) {
+		p1 := newPolyval(h)
+		p1.update(x1[:5])
+		p2 := newPolyval(h)
+		p2.update(append(x1[:5:5], make([]byte, 11)...))
+		assert.Equal(t, p2.sum(), p1.sum())
+	})
+}
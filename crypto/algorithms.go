@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/aes"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AlgorithmCapabilities describes the requirements and capabilities of an algorithm.
+type AlgorithmCapabilities struct {
+	// Algorithm is the name of the algorithm.
+	Algorithm string
+	// KeyType is the type of key the algorithm requires.
+	KeyType jwa.KeyType
+	// Curve is the elliptic curve the key must use, for EC and OKP keys.
+	Curve jwa.EllipticCurveAlgorithm
+	// KeySize is the size in bytes of the key, for symmetric algorithms.
+	KeySize int
+	// NonceSize is the size in bytes of the nonce (or IV), if one is required.
+	NonceSize int
+	// TagSize is the size in bytes of the authentication tag, for AEAD ciphers.
+	TagSize int
+	// AEAD is true if the algorithm is an authenticated encryption cipher with support for associated data.
+	AEAD bool
+	// KeyWrap is true if the algorithm can be used to wrap keys.
+	KeyWrap bool
+	// Signature is true if the algorithm is a signature algorithm rather than an encryption one.
+	Signature bool
+}
+
+// Asymmetric returns true if the algorithm uses asymmetric keys.
+func (c AlgorithmCapabilities) Asymmetric() bool {
+	return c.KeyType != jwa.OctetSeq
+}
+
+// AlgorithmInfo returns the capabilities of the given algorithm.
+// It returns ErrUnsupportedAlgorithm if the algorithm is not supported, or if
+// FIPS mode is enabled and the algorithm is not approved.
+func AlgorithmInfo(alg string) (AlgorithmCapabilities, error) {
+	if err := checkFIPS(alg); err != nil {
+		return AlgorithmCapabilities{}, err
+	}
+
+	c := AlgorithmCapabilities{Algorithm: alg}
+
+	switch alg {
+	case Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
+		Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
+		c.KeyType = jwa.OctetSeq
+		c.KeySize = expectedKeySize(alg)
+		c.NonceSize = aes.BlockSize
+
+	case Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM,
+		Algorithm_A128GCM_SIV, Algorithm_A256GCM_SIV:
+		c.KeyType = jwa.OctetSeq
+		c.KeySize = expectedKeySize(alg)
+		c.NonceSize = 12
+		c.TagSize = 16
+		c.AEAD = true
+
+	case Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512:
+		// Keys include both the encryption and the MAC keys
+		c.KeyType = jwa.OctetSeq
+		c.KeySize = expectedKeySize(alg) * 2
+		c.NonceSize = aes.BlockSize
+		c.TagSize = expectedKeySize(alg)
+		c.AEAD = true
+
+	case Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW:
+		c.KeyType = jwa.OctetSeq
+		c.KeySize = expectedKeySize(alg)
+		c.KeyWrap = true
+
+	case Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW:
+		c.KeyType = jwa.OctetSeq
+		c.KeySize = chacha20poly1305.KeySize
+		c.NonceSize = chacha20poly1305.NonceSize
+		if alg == Algorithm_XC20P || alg == Algorithm_XC20PKW {
+			c.NonceSize = chacha20poly1305.NonceSizeX
+		}
+		c.TagSize = chacha20poly1305.Overhead
+		c.AEAD = true
+		c.KeyWrap = alg == Algorithm_C20PKW || alg == Algorithm_XC20PKW
+
+	case Algorithm_RSA1_5,
+		Algorithm_RSA_OAEP, Algorithm_RSA_OAEP_256, Algorithm_RSA_OAEP_384, Algorithm_RSA_OAEP_512:
+		c.KeyType = jwa.RSA
+		c.KeyWrap = true
+
+	case Algorithm_RS256, Algorithm_RS384, Algorithm_RS512,
+		Algorithm_PS256, Algorithm_PS384, Algorithm_PS512:
+		c.KeyType = jwa.RSA
+		c.Signature = true
+
+	case Algorithm_ES256, Algorithm_ES384, Algorithm_ES512:
+		c.KeyType = jwa.EC
+		c.Signature = true
+		switch alg {
+		case Algorithm_ES256:
+			c.Curve = jwa.P256
+		case Algorithm_ES384:
+			c.Curve = jwa.P384
+		case Algorithm_ES512:
+			c.Curve = jwa.P521
+		}
+
+	case Algorithm_EdDSA:
+		c.KeyType = jwa.OKP
+		c.Curve = jwa.Ed25519
+		c.Signature = true
+
+	default:
+		return AlgorithmCapabilities{}, ErrUnsupportedAlgorithm
+	}
+
+	return c, nil
+}
+
+// SupportedAlgorithmsFor returns the list of supported algorithms that can be
+// used with the given key.
+func SupportedAlgorithmsFor(key jwk.Key) []string {
+	algs := make([]string, 0)
+	if key == nil {
+		return algs
+	}
+
+	all := SupportedSymmetricAlgorithms()
+	all = append(all, SupportedAsymmetricAlgorithms()...)
+	all = append(all, SupportedSignatureAlgorithms()...)
+
+	for _, alg := range all {
+		c, err := AlgorithmInfo(alg)
+		if err != nil || c.KeyType != key.KeyType() {
+			continue
+		}
+		if keyMatchesCapabilities(key, c) {
+			algs = append(algs, alg)
+		}
+	}
+
+	return algs
+}
+
+// keyMatchesCapabilities returns true if the key has the size or curve
+// required by the algorithm.
+func keyMatchesCapabilities(key jwk.Key, c AlgorithmCapabilities) bool {
+	switch k := key.(type) {
+	case jwk.SymmetricKey:
+		return len(k.Octets()) == c.KeySize
+	case jwk.ECDSAPrivateKey:
+		return k.Crv() == c.Curve
+	case jwk.ECDSAPublicKey:
+		return k.Crv() == c.Curve
+	case jwk.OKPPrivateKey:
+		return k.Crv() == c.Curve
+	case jwk.OKPPublicKey:
+		return k.Crv() == c.Curve
+	default:
+		return true
+	}
+}
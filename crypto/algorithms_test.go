@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlgorithmInfo(t *testing.T) {
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := AlgorithmInfo("foo")
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		_, err = AlgorithmInfo(Algorithm_A128GCMKW)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+
+	t.Run("all supported algorithms have info", func(t *testing.T) {
+		all := SupportedSymmetricAlgorithms()
+		all = append(all, SupportedAsymmetricAlgorithms()...)
+		all = append(all, SupportedSignatureAlgorithms()...)
+		for _, alg := range all {
+			c, err := AlgorithmInfo(alg)
+			require.NoError(t, err, alg)
+			assert.Equal(t, alg, c.Algorithm)
+		}
+	})
+
+	// Ensure the sizes reported match the ones enforced by EncryptSymmetric
+	for _, alg := range SupportedSymmetricAlgorithms() {
+		t.Run("symmetric "+alg, func(t *testing.T) {
+			c, err := AlgorithmInfo(alg)
+			require.NoError(t, err)
+			assert.False(t, c.Asymmetric())
+
+			rawKey := make([]byte, c.KeySize)
+			_, err = rand.Read(rawKey)
+			require.NoError(t, err)
+			key, err := jwk.FromRaw(rawKey)
+			require.NoError(t, err)
+
+			nonce := make([]byte, c.NonceSize)
+			_, tag, err := EncryptSymmetric(make([]byte, 32), alg, key, nonce, nil)
+			require.NoError(t, err)
+			assert.Len(t, tag, c.TagSize)
+		})
+	}
+
+	t.Run("curves", func(t *testing.T) {
+		c, err := AlgorithmInfo(Algorithm_ES384)
+		require.NoError(t, err)
+		assert.Equal(t, jwa.EC, c.KeyType)
+		assert.Equal(t, jwa.P384, c.Curve)
+		assert.True(t, c.Signature)
+		assert.True(t, c.Asymmetric())
+	})
+}
+
+func TestSupportedAlgorithmsFor(t *testing.T) {
+	t.Run("symmetric key", func(t *testing.T) {
+		key, err := jwk.FromRaw(make([]byte, 32))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			Algorithm_A256CBC, Algorithm_A256CBC_NOPAD, Algorithm_A256GCM, Algorithm_A256GCM_SIV,
+			Algorithm_A128CBC_HS256, Algorithm_A256KW,
+			Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW,
+		}, SupportedAlgorithmsFor(key))
+	})
+
+	t.Run("RSA key", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(rsaKey)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			Algorithm_RSA1_5, Algorithm_RSA_OAEP,
+			Algorithm_RSA_OAEP_256, Algorithm_RSA_OAEP_384, Algorithm_RSA_OAEP_512,
+			Algorithm_RS256, Algorithm_RS384, Algorithm_RS512,
+			Algorithm_PS256, Algorithm_PS384, Algorithm_PS512,
+		}, SupportedAlgorithmsFor(key))
+	})
+
+	t.Run("EC key", func(t *testing.T) {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(ecKey.Public())
+		require.NoError(t, err)
+		assert.Equal(t, []string{Algorithm_ES256}, SupportedAlgorithmsFor(key))
+	})
+
+	t.Run("Ed25519 key", func(t *testing.T) {
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(edKey)
+		require.NoError(t, err)
+		assert.Equal(t, []string{Algorithm_EdDSA}, SupportedAlgorithmsFor(key))
+	})
+
+	t.Run("nil key", func(t *testing.T) {
+		assert.Empty(t, SupportedAlgorithmsFor(nil))
+	})
+}
@@ -24,17 +24,22 @@ import (
 
 // SupportedAsymmetricAlgorithms returns the list of supported asymmetric encryption algorithms.
 // This is a subset of the algorithms defined in consts.go.
+// If FIPS mode is enabled, algorithms which are not approved are not included.
 func SupportedAsymmetricAlgorithms() []string {
-	return []string{
+	return filterFIPS([]string{
 		Algorithm_RSA1_5,
 		Algorithm_RSA_OAEP,
 		Algorithm_RSA_OAEP_256, Algorithm_RSA_OAEP_384, Algorithm_RSA_OAEP_512,
-	}
+	})
 }
 
 // EncryptPublicKey encrypts a message using a public key and the specified algorithm.
 // Note that "associatedData" is ignored if the cipher does not support labels/AAD.
 func EncryptPublicKey(plaintext []byte, algorithm string, key jwk.Key, associatedData []byte) (ciphertext []byte, err error) {
+	if err = checkFIPS(algorithm); err != nil {
+		return nil, err
+	}
+
 	// Ensure we are using a public key
 	key, err = key.PublicKey()
 	if err != nil {
@@ -75,6 +80,10 @@ func encryptPublicKeyRSAOAEP(plaintext []byte, key jwk.Key, hash crypto.Hash, la
 // DecryptPrivateKey decrypts a message using a private key and the specified algorithm.
 // Note that "associatedData" is ignored if the cipher does not support labels/AAD.
 func DecryptPrivateKey(ciphertext []byte, algorithm string, key jwk.Key, associatedData []byte) (plaintext []byte, err error) {
+	if err = checkFIPS(algorithm); err != nil {
+		return nil, err
+	}
+
 	switch algorithm {
 	case Algorithm_RSA1_5:
 		return decryptPrivateKeyRSAPKCS1v15(ciphertext, key)
@@ -32,6 +32,14 @@ var (
 	ErrInvalidPlaintextLength = errors.New("invalid plaintext length")
 	// ErrInvalidCiphertextLength is returned when the ciphertext's length is invalid.
 	ErrInvalidCiphertextLength = errors.New("invalid ciphertext length")
+	// ErrInvalidJWE is returned when a JWE token is malformed or is not in the compact serialization.
+	ErrInvalidJWE = errors.New("invalid JWE")
+	// ErrInvalidKeyLength is returned when the key's length is invalid.
+	ErrInvalidKeyLength = errors.New("invalid key length")
+	// ErrInvalidSharedSecret is returned when a key agreement results in an invalid shared secret, for example because the public key is a low-order point.
+	ErrInvalidSharedSecret = errors.New("invalid shared secret")
+	// ErrInvalidEnvelope is returned when an envelope is malformed.
+	ErrInvalidEnvelope = errors.New("invalid envelope")
 )
 
 // Algorithms
@@ -45,6 +53,8 @@ const (
 	Algorithm_A128GCM        = "A128GCM"        // Encryption: AES-GCM, 128-bit key
 	Algorithm_A192GCM        = "A192GCM"        // Encryption: AES-GCM, 192-bit key
 	Algorithm_A256GCM        = "A256GCM"        // Encryption: AES-GCM, 256-bit key
+	Algorithm_A128GCM_SIV    = "A128GCM-SIV"    // Encryption: AES-GCM-SIV (RFC 8452), 128-bit key, deterministic
+	Algorithm_A256GCM_SIV    = "A256GCM-SIV"    // Encryption: AES-GCM-SIV (RFC 8452), 256-bit key, deterministic
 	Algorithm_A128CBC_HS256  = "A128CBC-HS256"  // Encryption: AES-CBC + HMAC-SHA256, 128-bit key
 	Algorithm_A192CBC_HS384  = "A192CBC-HS384"  // Encryption: AES-CBC + HMAC-SHA384, 192-bit key
 	Algorithm_A256CBC_HS512  = "A256CBC-HS512"  // Encryption: AES-CBC + HMAC-SHA512, 256-bit key
@@ -74,6 +84,8 @@ const (
 	Algorithm_HS256          = "HS256"          // Signature: HMAC using SHA-256
 	Algorithm_HS384          = "HS384"          // Signature: HMAC using SHA-384
 	Algorithm_HS512          = "HS512"          // Signature: HMAC using SHA-512
+	Algorithm_KMAC128        = "KMAC128"        // Signature: KMAC128, 256-bit output
+	Algorithm_KMAC256        = "KMAC256"        // Signature: KMAC256, 512-bit output
 	Algorithm_PS256          = "PS256"          // Signature: RSASSA-PSS using SHA256 and MGF1-SHA256
 	Algorithm_PS384          = "PS384"          // Signature: RSASSA-PSS using SHA384 and MGF1-SHA384
 	Algorithm_PS512          = "PS512"          // Signature: RSASSA-PSS using SHA512 and MGF1-SHA512
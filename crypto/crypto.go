@@ -26,6 +26,7 @@ func Encrypt(plaintext []byte, algorithm string, key jwk.Key, nonce []byte, asso
 	switch algorithm {
 	case Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
 		Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM,
+		Algorithm_A128GCM_SIV, Algorithm_A256GCM_SIV,
 		Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512,
 		Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW,
 		Algorithm_A128GCMKW, Algorithm_A192GCMKW, Algorithm_A256GCMKW,
@@ -50,6 +51,7 @@ func Decrypt(ciphertext []byte, algorithm string, key jwk.Key, nonce []byte, tag
 	switch algorithm {
 	case Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
 		Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM,
+		Algorithm_A128GCM_SIV, Algorithm_A256GCM_SIV,
 		Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512,
 		Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW,
 		Algorithm_A128GCMKW, Algorithm_A192GCMKW, Algorithm_A256GCMKW,
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+)
+
+// DeriveSharedSecret performs an Elliptic Curve Diffie-Hellman key agreement
+// between a private key and a public key, and returns the raw shared secret.
+// Supported keys are EC keys on the P-256, P-384 and P-521 curves, and OKP
+// keys on the X25519 curve; both keys must be on the same curve. If
+// publicKey is a private key, its public part is used.
+// The shared secret must not be used as a key directly: it should be passed
+// to a key derivation function, such as Concat KDF for ECDH-ES or HKDF.
+// X25519 is not available in FIPS mode.
+func DeriveSharedSecret(privateKey, publicKey jwk.Key) ([]byte, error) {
+	priv, err := toECDHPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err = publicKey.PublicKey()
+	if err != nil {
+		return nil, ErrKeyTypeMismatch
+	}
+	pub, err := toECDHPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if priv.Curve() != pub.Curve() {
+		return nil, fmt.Errorf("%w: keys are on different curves", ErrKeyTypeMismatch)
+	}
+	if fipsMode.Load() && priv.Curve() == ecdh.X25519() {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	// For X25519, this fails if the public key is a low-order point
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSharedSecret, err)
+	}
+
+	// Defense in depth: reject all-zero shared secrets, which are the result
+	// of small-subgroup attacks
+	if subtle.ConstantTimeCompare(secret, make([]byte, len(secret))) == 1 {
+		return nil, ErrInvalidSharedSecret
+	}
+
+	return secret, nil
+}
+
+func toECDHPrivateKey(key jwk.Key) (*ecdh.PrivateKey, error) {
+	switch key.KeyType() {
+	case jwa.EC:
+		ecdsaKey := &ecdsa.PrivateKey{}
+		if key.Raw(ecdsaKey) != nil {
+			return nil, ErrKeyTypeMismatch
+		}
+		// This returns an error for curves which are not supported by ECDH
+		ecdhKey, err := ecdsaKey.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKeyTypeMismatch, err)
+		}
+		return ecdhKey, nil
+
+	case jwa.OKP:
+		okpKey, ok := key.(jwk.OKPPrivateKey)
+		if !ok || okpKey.Crv() != jwa.X25519 {
+			return nil, ErrKeyTypeMismatch
+		}
+		x25519Key := x25519.PrivateKey{}
+		if okpKey.Raw(&x25519Key) != nil {
+			return nil, ErrKeyTypeMismatch
+		}
+		ecdhKey, err := ecdh.X25519().NewPrivateKey(x25519Key.Seed())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKeyTypeMismatch, err)
+		}
+		return ecdhKey, nil
+
+	default:
+		return nil, ErrKeyTypeMismatch
+	}
+}
+
+func toECDHPublicKey(key jwk.Key) (*ecdh.PublicKey, error) {
+	switch key.KeyType() {
+	case jwa.EC:
+		ecdsaKey := &ecdsa.PublicKey{}
+		if key.Raw(ecdsaKey) != nil {
+			return nil, ErrKeyTypeMismatch
+		}
+		// This returns an error for curves which are not supported by ECDH,
+		// and for points which are not on the curve
+		ecdhKey, err := ecdsaKey.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKeyTypeMismatch, err)
+		}
+		return ecdhKey, nil
+
+	case jwa.OKP:
+		okpKey, ok := key.(jwk.OKPPublicKey)
+		if !ok || okpKey.Crv() != jwa.X25519 {
+			return nil, ErrKeyTypeMismatch
+		}
+		x25519Key := x25519.PublicKey{}
+		if okpKey.Raw(&x25519Key) != nil {
+			return nil, ErrKeyTypeMismatch
+		}
+		ecdhKey, err := ecdh.X25519().NewPublicKey(x25519Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKeyTypeMismatch, err)
+		}
+		return ecdhKey, nil
+
+	default:
+		return nil, ErrKeyTypeMismatch
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveSharedSecret(t *testing.T) {
+	newECKey := func(t *testing.T, curve elliptic.Curve) jwk.Key {
+		t.Helper()
+		raw, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(raw)
+		require.NoError(t, err)
+		return key
+	}
+	newX25519Key := func(t *testing.T) jwk.Key {
+		t.Helper()
+		_, raw, err := x25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(raw)
+		require.NoError(t, err)
+		return key
+	}
+	publicKey := func(t *testing.T, key jwk.Key) jwk.Key {
+		t.Helper()
+		pub, err := key.PublicKey()
+		require.NoError(t, err)
+		return pub
+	}
+
+	keyPairs := map[string]func(t *testing.T) jwk.Key{
+		"P-256":  func(t *testing.T) jwk.Key { return newECKey(t, elliptic.P256()) },
+		"P-384":  func(t *testing.T) jwk.Key { return newECKey(t, elliptic.P384()) },
+		"P-521":  func(t *testing.T) jwk.Key { return newECKey(t, elliptic.P521()) },
+		"X25519": newX25519Key,
+	}
+	for name, newKey := range keyPairs {
+		t.Run(name+" both parties derive the same secret", func(t *testing.T) {
+			alice, bob := newKey(t), newKey(t)
+
+			secretAlice, err := DeriveSharedSecret(alice, publicKey(t, bob))
+			require.NoError(t, err)
+			secretBob, err := DeriveSharedSecret(bob, publicKey(t, alice))
+			require.NoError(t, err)
+			assert.Equal(t, secretAlice, secretBob)
+			assert.NotEmpty(t, secretAlice)
+
+			// The public part of a private key is used
+			secret, err := DeriveSharedSecret(alice, bob)
+			require.NoError(t, err)
+			assert.Equal(t, secretAlice, secret)
+		})
+	}
+
+	t.Run("keys on different curves", func(t *testing.T) {
+		_, err := DeriveSharedSecret(newECKey(t, elliptic.P256()), publicKey(t, newECKey(t, elliptic.P384())))
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		_, err = DeriveSharedSecret(newECKey(t, elliptic.P256()), publicKey(t, newX25519Key(t)))
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+	})
+
+	t.Run("private key is required", func(t *testing.T) {
+		alice, bob := newX25519Key(t), newX25519Key(t)
+		_, err := DeriveSharedSecret(publicKey(t, alice), publicKey(t, bob))
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+	})
+
+	t.Run("unsupported key types", func(t *testing.T) {
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		ed, err := jwk.FromRaw(edKey)
+		require.NoError(t, err)
+		_, err = DeriveSharedSecret(ed, publicKey(t, ed))
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		rsa, err := ParseKey([]byte(privateKeyRSAPKCS8), "application/x-pem-file")
+		require.NoError(t, err)
+		_, err = DeriveSharedSecret(rsa, publicKey(t, rsa))
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		oct, err := jwk.FromRaw(make([]byte, 32))
+		require.NoError(t, err)
+		_, err = DeriveSharedSecret(oct, oct)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+	})
+
+	t.Run("low-order X25519 public key", func(t *testing.T) {
+		lowOrder, err := jwk.FromRaw(x25519.PublicKey(make([]byte, x25519.PublicKeySize)))
+		require.NoError(t, err)
+		_, err = DeriveSharedSecret(newX25519Key(t), lowOrder)
+		require.ErrorIs(t, err, ErrInvalidSharedSecret)
+	})
+
+	t.Run("X25519 is not available in FIPS mode", func(t *testing.T) {
+		SetFIPSMode(true)
+		t.Cleanup(func() {
+			SetFIPSMode(false)
+		})
+
+		alice, bob := newX25519Key(t), newX25519Key(t)
+		_, err := DeriveSharedSecret(alice, publicKey(t, bob))
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		alice, bob = newECKey(t, elliptic.P256()), newECKey(t, elliptic.P256())
+		_, err = DeriveSharedSecret(alice, publicKey(t, bob))
+		require.NoError(t, err)
+	})
+
+	t.Run("matches crypto/ecdh", func(t *testing.T) {
+		alice, err := ecdh.X25519().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		bob, err := ecdh.X25519().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		expect, err := alice.ECDH(bob.PublicKey())
+		require.NoError(t, err)
+
+		aliceRaw, err := x25519.NewKeyFromSeed(alice.Bytes())
+		require.NoError(t, err)
+		aliceKey, err := jwk.FromRaw(aliceRaw)
+		require.NoError(t, err)
+		bobPub, err := jwk.FromRaw(x25519.PublicKey(bob.PublicKey().Bytes()))
+		require.NoError(t, err)
+		secret, err := DeriveSharedSecret(aliceKey, bobPub)
+		require.NoError(t, err)
+		assert.Equal(t, expect, secret)
+	})
+}
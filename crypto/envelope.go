@@ -0,0 +1,314 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// envelopeVersion is the version of the serialization format of envelopes.
+const envelopeVersion = 1
+
+// Envelope contains a payload encrypted with a random data encryption key (DEK), and the DEK wrapped with a key encryption key (KEK).
+// The algorithms and the wrapped key are authenticated as associated data of the payload.
+// Envelopes can be serialized in a compact binary format with MarshalBinary.
+type Envelope struct {
+	// WrapAlgorithm is the algorithm used to wrap the DEK with the KEK.
+	WrapAlgorithm string
+	// PayloadAlgorithm is the algorithm used to encrypt the payload with the DEK.
+	PayloadAlgorithm string
+	// WrappedKey is the wrapped DEK.
+	// For algorithms that use a nonce and a tag, such as C20PKW, they are included (as nonce || ciphertext || tag).
+	WrappedKey []byte
+	// Nonce is the nonce (or IV) used to encrypt the payload.
+	Nonce []byte
+	// Ciphertext is the encrypted payload.
+	Ciphertext []byte
+	// Tag is the authentication tag of the encrypted payload.
+	Tag []byte
+}
+
+// SupportedEnvelopeWrapAlgorithms returns the list of algorithms supported to wrap the DEK of envelopes.
+// RSA1_5 is intentionally not included.
+// If FIPS mode is enabled, algorithms which are not approved are not included.
+func SupportedEnvelopeWrapAlgorithms() []string {
+	return filterFIPS([]string{
+		Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW,
+		Algorithm_C20PKW, Algorithm_XC20PKW,
+		Algorithm_RSA_OAEP, Algorithm_RSA_OAEP_256, Algorithm_RSA_OAEP_384, Algorithm_RSA_OAEP_512,
+	})
+}
+
+// SupportedEnvelopePayloadAlgorithms returns the list of algorithms supported to encrypt the payload of envelopes.
+// Only AEAD ciphers are supported.
+// If FIPS mode is enabled, algorithms which are not approved are not included.
+func SupportedEnvelopePayloadAlgorithms() []string {
+	return filterFIPS([]string{
+		Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM,
+		Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512,
+		Algorithm_C20P, Algorithm_XC20P,
+	})
+}
+
+// EnvelopeEncrypt encrypts the plaintext with a new random DEK using payloadAlg, and wraps the DEK with kek using wrapAlg.
+// This is meant for single-shot encryption of small payloads; to encrypt streams and files, use the schemes/enc package.
+// For asymmetric algorithms, kek can be either the public or the private key.
+func EnvelopeEncrypt(plaintext []byte, kek jwk.Key, wrapAlg string, payloadAlg string) (*Envelope, error) {
+	wrapCaps, payloadCaps, err := envelopeAlgorithms(kek, wrapAlg, payloadAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the DEK and wrap it
+	dek := make([]byte, payloadCaps.KeySize)
+	_, err = rand.Read(dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	env := &Envelope{
+		WrapAlgorithm:    wrapAlg,
+		PayloadAlgorithm: payloadAlg,
+	}
+	env.WrappedKey, err = wrapEnvelopeKey(dek, kek, wrapAlg, wrapCaps)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt the payload, authenticating the header as associated data
+	c, err := newSymmetricCipher(dek, payloadAlg)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, c.NonceSize())
+	_, err = rand.Read(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	env.Ciphertext, env.Tag, err = c.Encrypt(plaintext, env.Nonce, env.appendHeader(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	return env, nil
+}
+
+// EnvelopeDecrypt unwraps the DEK of the envelope with kek, and decrypts the payload.
+// For asymmetric algorithms, kek must be the private key.
+func EnvelopeDecrypt(env *Envelope, kek jwk.Key) ([]byte, error) {
+	if env == nil {
+		return nil, ErrInvalidEnvelope
+	}
+	wrapCaps, payloadCaps, err := envelopeAlgorithms(kek, env.WrapAlgorithm, env.PayloadAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := unwrapEnvelopeKey(env.WrappedKey, kek, env.WrapAlgorithm, wrapCaps)
+	if err != nil {
+		return nil, err
+	}
+	if len(dek) != payloadCaps.KeySize {
+		return nil, fmt.Errorf("%w: wrapped key has an invalid length", ErrInvalidEnvelope)
+	}
+
+	c, err := newSymmetricCipher(dek, env.PayloadAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.Decrypt(env.Ciphertext, env.Nonce, env.Tag, env.appendHeader(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// MarshalBinary returns the envelope in the compact binary serialization, which is:
+//
+//	version (1 byte) ||
+//	len(WrapAlgorithm) (1 byte) || WrapAlgorithm ||
+//	len(PayloadAlgorithm) (1 byte) || PayloadAlgorithm ||
+//	len(WrappedKey) (2 bytes, big endian) || WrappedKey ||
+//	len(Nonce) (1 byte) || Nonce ||
+//	len(Tag) (1 byte) || Tag ||
+//	Ciphertext
+//
+// It implements encoding.BinaryMarshaler.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if len(e.WrapAlgorithm) > 255 || len(e.PayloadAlgorithm) > 255 ||
+		len(e.WrappedKey) > 65535 || len(e.Nonce) > 255 || len(e.Tag) > 255 {
+		return nil, ErrInvalidEnvelope
+	}
+
+	out := make([]byte, 0, 7+len(e.WrapAlgorithm)+len(e.PayloadAlgorithm)+len(e.WrappedKey)+len(e.Nonce)+len(e.Tag)+len(e.Ciphertext))
+	out = e.appendHeader(out)
+	out = append(out, byte(len(e.Nonce)))
+	out = append(out, e.Nonce...)
+	out = append(out, byte(len(e.Tag)))
+	out = append(out, e.Tag...)
+	out = append(out, e.Ciphertext...)
+	return out, nil
+}
+
+// UnmarshalBinary parses an envelope in the compact binary serialization returned by MarshalBinary.
+// It implements encoding.BinaryUnmarshaler.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != envelopeVersion {
+		return fmt.Errorf("%w: unsupported version", ErrInvalidEnvelope)
+	}
+	data = data[1:]
+
+	var (
+		env Envelope
+		b   []byte
+		ok  bool
+	)
+	if b, data, ok = readEnvelopeField(data, 1); !ok {
+		return ErrInvalidEnvelope
+	}
+	env.WrapAlgorithm = string(b)
+	if b, data, ok = readEnvelopeField(data, 1); !ok {
+		return ErrInvalidEnvelope
+	}
+	env.PayloadAlgorithm = string(b)
+	if env.WrappedKey, data, ok = readEnvelopeField(data, 2); !ok {
+		return ErrInvalidEnvelope
+	}
+	if env.Nonce, data, ok = readEnvelopeField(data, 1); !ok {
+		return ErrInvalidEnvelope
+	}
+	if env.Tag, data, ok = readEnvelopeField(data, 1); !ok {
+		return ErrInvalidEnvelope
+	}
+	env.Ciphertext = data
+
+	*e = env
+	return nil
+}
+
+// ParseEnvelope parses an envelope in the compact binary serialization returned by MarshalBinary.
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	env := &Envelope{}
+	err := env.UnmarshalBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// appendHeader appends the header of the envelope, which is used as associated data of the payload, to dst.
+// The header includes the version, the algorithms, and the wrapped key.
+func (e *Envelope) appendHeader(dst []byte) []byte {
+	dst = append(dst, envelopeVersion)
+	dst = append(dst, byte(len(e.WrapAlgorithm)))
+	dst = append(dst, e.WrapAlgorithm...)
+	dst = append(dst, byte(len(e.PayloadAlgorithm)))
+	dst = append(dst, e.PayloadAlgorithm...)
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(e.WrappedKey))) //nolint:gosec
+	dst = append(dst, e.WrappedKey...)
+	return dst
+}
+
+// readEnvelopeField reads a field prefixed by its length, which is encoded in lenSize bytes (1 or 2).
+// It returns the field, the rest of data, and false if data is too short.
+func readEnvelopeField(data []byte, lenSize int) (field []byte, rest []byte, ok bool) {
+	if len(data) < lenSize {
+		return nil, nil, false
+	}
+	var n int
+	if lenSize == 2 {
+		n = int(binary.BigEndian.Uint16(data))
+	} else {
+		n = int(data[0])
+	}
+	data = data[lenSize:]
+	if len(data) < n {
+		return nil, nil, false
+	}
+	return data[:n:n], data[n:], true
+}
+
+// envelopeAlgorithms validates the algorithms of an envelope and the type of the KEK, and returns their capabilities.
+func envelopeAlgorithms(kek jwk.Key, wrapAlg string, payloadAlg string) (wrapCaps AlgorithmCapabilities, payloadCaps AlgorithmCapabilities, err error) {
+	if !slices.Contains(SupportedEnvelopeWrapAlgorithms(), wrapAlg) || !slices.Contains(SupportedEnvelopePayloadAlgorithms(), payloadAlg) {
+		return wrapCaps, payloadCaps, ErrUnsupportedAlgorithm
+	}
+	wrapCaps, err = AlgorithmInfo(wrapAlg)
+	if err != nil {
+		return wrapCaps, payloadCaps, err
+	}
+	payloadCaps, err = AlgorithmInfo(payloadAlg)
+	if err != nil {
+		return wrapCaps, payloadCaps, err
+	}
+	if kek == nil || kek.KeyType() != wrapCaps.KeyType {
+		return wrapCaps, payloadCaps, ErrKeyTypeMismatch
+	}
+	return wrapCaps, payloadCaps, nil
+}
+
+// wrapEnvelopeKey wraps the DEK with the KEK.
+func wrapEnvelopeKey(dek []byte, kek jwk.Key, wrapAlg string, wrapCaps AlgorithmCapabilities) ([]byte, error) {
+	if wrapCaps.Asymmetric() {
+		wrapped, err := EncryptPublicKey(dek, wrapAlg, kek, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap key: %w", err)
+		}
+		return wrapped, nil
+	}
+
+	// For algorithms that use a nonce, the wrapped key is nonce || ciphertext || tag
+	nonce := make([]byte, wrapCaps.NonceSize)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	ciphertext, tag, err := EncryptSymmetric(dek, wrapAlg, kek, nonce, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %w", err)
+	}
+	wrapped := make([]byte, 0, len(nonce)+len(ciphertext)+len(tag))
+	wrapped = append(wrapped, nonce...)
+	wrapped = append(wrapped, ciphertext...)
+	wrapped = append(wrapped, tag...)
+	return wrapped, nil
+}
+
+// unwrapEnvelopeKey unwraps the DEK with the KEK.
+func unwrapEnvelopeKey(wrapped []byte, kek jwk.Key, wrapAlg string, wrapCaps AlgorithmCapabilities) ([]byte, error) {
+	var (
+		dek []byte
+		err error
+	)
+	if wrapCaps.Asymmetric() {
+		dek, err = DecryptPrivateKey(wrapped, wrapAlg, kek, nil)
+	} else {
+		if len(wrapped) < wrapCaps.NonceSize+wrapCaps.TagSize {
+			return nil, fmt.Errorf("%w: wrapped key is too short", ErrInvalidEnvelope)
+		}
+		nonce := wrapped[:wrapCaps.NonceSize]
+		ciphertext := wrapped[wrapCaps.NonceSize : len(wrapped)-wrapCaps.TagSize]
+		tag := wrapped[len(wrapped)-wrapCaps.TagSize:]
+		dek, err = DecryptSymmetric(ciphertext, wrapAlg, kek, nonce, tag, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key: %w", err)
+	}
+	return dek, nil
+}
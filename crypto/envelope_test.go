@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope(t *testing.T) {
+	plaintext := []byte("hello world")
+
+	aesKey, err := jwk.FromRaw([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	rsaRaw, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaKey, err := jwk.FromRaw(rsaRaw)
+	require.NoError(t, err)
+	rsaPub, err := rsaKey.PublicKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		encryptKey jwk.Key
+		decryptKey jwk.Key
+		wrapAlg    string
+		payloadAlg string
+	}{
+		{name: "A256KW+A256GCM", encryptKey: aesKey, decryptKey: aesKey, wrapAlg: Algorithm_A256KW, payloadAlg: Algorithm_A256GCM},
+		{name: "A256KW+A256CBC-HS512", encryptKey: aesKey, decryptKey: aesKey, wrapAlg: Algorithm_A256KW, payloadAlg: Algorithm_A256CBC_HS512},
+		{name: "XC20PKW+C20P", encryptKey: aesKey, decryptKey: aesKey, wrapAlg: Algorithm_XC20PKW, payloadAlg: Algorithm_C20P},
+		{name: "C20PKW+XC20P", encryptKey: aesKey, decryptKey: aesKey, wrapAlg: Algorithm_C20PKW, payloadAlg: Algorithm_XC20P},
+		{name: "RSA-OAEP-256+A128GCM", encryptKey: rsaPub, decryptKey: rsaKey, wrapAlg: Algorithm_RSA_OAEP_256, payloadAlg: Algorithm_A128GCM},
+		{name: "RSA-OAEP with private key", encryptKey: rsaKey, decryptKey: rsaKey, wrapAlg: Algorithm_RSA_OAEP, payloadAlg: Algorithm_A192GCM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := EnvelopeEncrypt(plaintext, tt.encryptKey, tt.wrapAlg, tt.payloadAlg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wrapAlg, env.WrapAlgorithm)
+			assert.Equal(t, tt.payloadAlg, env.PayloadAlgorithm)
+			assert.NotEqual(t, plaintext, env.Ciphertext)
+
+			decrypted, err := EnvelopeDecrypt(env, tt.decryptKey)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+
+			// Round-trip through the binary serialization
+			data, err := env.MarshalBinary()
+			require.NoError(t, err)
+			parsed, err := ParseEnvelope(data)
+			require.NoError(t, err)
+			assert.Equal(t, env, parsed)
+
+			decrypted, err = EnvelopeDecrypt(parsed, tt.decryptKey)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		})
+	}
+
+	t.Run("empty plaintext", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(nil, aesKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.NoError(t, err)
+		decrypted, err := EnvelopeDecrypt(env, aesKey)
+		require.NoError(t, err)
+		assert.Empty(t, decrypted)
+	})
+
+	t.Run("unsupported algorithms", func(t *testing.T) {
+		_, err := EnvelopeEncrypt(plaintext, rsaKey, Algorithm_RSA1_5, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		_, err = EnvelopeEncrypt(plaintext, aesKey, Algorithm_A256KW, Algorithm_A256CBC)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		_, err = EnvelopeEncrypt(plaintext, aesKey, Algorithm_A256GCM, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+
+	t.Run("key type mismatch", func(t *testing.T) {
+		_, err := EnvelopeEncrypt(plaintext, aesKey, Algorithm_RSA_OAEP, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		_, err = EnvelopeEncrypt(plaintext, rsaKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		_, err = EnvelopeEncrypt(plaintext, nil, Algorithm_A256KW, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(plaintext, aesKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.NoError(t, err)
+
+		otherKey, err := jwk.FromRaw([]byte("fedcba9876543210fedcba9876543210"))
+		require.NoError(t, err)
+		_, err = EnvelopeDecrypt(env, otherKey)
+		require.ErrorContains(t, err, "failed to unwrap key")
+	})
+
+	t.Run("tampered envelope", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(plaintext, aesKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.NoError(t, err)
+
+		tampered := *env
+		tampered.Ciphertext = append([]byte{}, env.Ciphertext...)
+		tampered.Ciphertext[0] ^= 1
+		_, err = EnvelopeDecrypt(&tampered, aesKey)
+		require.ErrorContains(t, err, "failed to decrypt payload")
+
+		// The algorithms are authenticated too
+		tampered = *env
+		tampered.PayloadAlgorithm = Algorithm_A256CBC_HS512
+		_, err = EnvelopeDecrypt(&tampered, aesKey)
+		require.Error(t, err)
+
+		_, err = EnvelopeDecrypt(nil, aesKey)
+		require.ErrorIs(t, err, ErrInvalidEnvelope)
+	})
+
+	t.Run("invalid serialization", func(t *testing.T) {
+		env, err := EnvelopeEncrypt(plaintext, aesKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.NoError(t, err)
+		data, err := env.MarshalBinary()
+		require.NoError(t, err)
+
+		_, err = ParseEnvelope(nil)
+		require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+		// Unsupported version
+		invalid := append([]byte{}, data...)
+		invalid[0] = 2
+		_, err = ParseEnvelope(invalid)
+		require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+		// Truncated header
+		_, err = ParseEnvelope(data[:10])
+		require.ErrorIs(t, err, ErrInvalidEnvelope)
+	})
+}
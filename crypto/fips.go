@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+var fipsMode atomic.Bool
+
+// SetFIPSMode enables or disables FIPS mode for the package.
+// When FIPS mode is enabled, algorithms which are not approved by FIPS 140
+// are rejected with ErrUnsupportedAlgorithm, and they are not included in the
+// lists of supported algorithms.
+// Note that this only restricts the algorithms that can be selected: it does
+// not make the underlying implementations FIPS-validated.
+func SetFIPSMode(enabled bool) {
+	fipsMode.Store(enabled)
+}
+
+// FIPSMode returns true if FIPS mode is enabled.
+func FIPSMode() bool {
+	return fipsMode.Load()
+}
+
+// IsFIPSApproved returns true if the algorithm is approved by FIPS 140.
+// The algorithms which are not approved are:
+// - ChaCha20-Poly1305 and XChaCha20-Poly1305, including the key wrap variants
+// - AES-CBC without HMAC, which is not authenticated
+// - RSA-PKCS1v1.5 encryption, which is disallowed for key transport
+// - AES-GCM-SIV, which is not an approved mode of operation
+func IsFIPSApproved(alg string) bool {
+	switch alg {
+	case Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW,
+		Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
+		Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD,
+		Algorithm_A128GCM_SIV, Algorithm_A256GCM_SIV,
+		Algorithm_RSA1_5:
+		return false
+	default:
+		return true
+	}
+}
+
+// checkFIPS returns ErrUnsupportedAlgorithm if FIPS mode is enabled and the
+// algorithm is not approved.
+func checkFIPS(alg string) error {
+	if fipsMode.Load() && !IsFIPSApproved(alg) {
+		return ErrUnsupportedAlgorithm
+	}
+	return nil
+}
+
+// filterFIPS removes the algorithms which are not approved if FIPS mode is
+// enabled.
+func filterFIPS(algs []string) []string {
+	if !fipsMode.Load() {
+		return algs
+	}
+	return slices.DeleteFunc(algs, func(alg string) bool {
+		return !IsFIPSApproved(alg)
+	})
+}
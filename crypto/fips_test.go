@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFIPSMode(t *testing.T) {
+	SetFIPSMode(true)
+	t.Cleanup(func() {
+		SetFIPSMode(false)
+	})
+	require.True(t, FIPSMode())
+
+	t.Run("supported algorithms are filtered", func(t *testing.T) {
+		for _, alg := range append(SupportedSymmetricAlgorithms(), SupportedAsymmetricAlgorithms()...) {
+			assert.True(t, IsFIPSApproved(alg), alg)
+		}
+		assert.Contains(t, SupportedSymmetricAlgorithms(), Algorithm_A256GCM)
+		assert.Contains(t, SupportedSymmetricAlgorithms(), Algorithm_A256CBC_HS512)
+		assert.Contains(t, SupportedSymmetricAlgorithms(), Algorithm_A256KW)
+		assert.NotContains(t, SupportedSymmetricAlgorithms(), Algorithm_C20P)
+		assert.NotContains(t, SupportedSymmetricAlgorithms(), Algorithm_XC20P)
+		assert.NotContains(t, SupportedSymmetricAlgorithms(), Algorithm_A256CBC)
+		assert.NotContains(t, SupportedSymmetricAlgorithms(), Algorithm_A256GCM_SIV)
+		assert.NotContains(t, SupportedAsymmetricAlgorithms(), Algorithm_RSA1_5)
+		assert.Contains(t, SupportedAsymmetricAlgorithms(), Algorithm_RSA_OAEP_256)
+	})
+
+	t.Run("symmetric algorithms", func(t *testing.T) {
+		key, err := jwk.FromRaw(make([]byte, 32))
+		require.NoError(t, err)
+
+		_, _, err = EncryptSymmetric([]byte("hello"), Algorithm_XC20P, key, make([]byte, 24), nil)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		_, err = DecryptSymmetric([]byte("hello"), Algorithm_C20P, key, make([]byte, 12), make([]byte, 16), nil)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		_, _, err = EncryptSymmetric(make([]byte, 16), Algorithm_A256CBC_NOPAD, key, make([]byte, 16), nil)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		ciphertext, tag, err := EncryptSymmetric([]byte("hello"), Algorithm_A256GCM, key, make([]byte, 12), nil)
+		require.NoError(t, err)
+		plaintext, err := DecryptSymmetric(ciphertext, Algorithm_A256GCM, key, make([]byte, 12), tag, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello"), plaintext)
+	})
+
+	t.Run("asymmetric algorithms", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(rsaKey)
+		require.NoError(t, err)
+
+		_, err = EncryptPublicKey([]byte("hello"), Algorithm_RSA1_5, key, nil)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		_, err = DecryptPrivateKey([]byte("hello"), Algorithm_RSA1_5, key, nil)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		_, err = EncryptPublicKey([]byte("hello"), Algorithm_RSA_OAEP_256, key, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("algorithm info", func(t *testing.T) {
+		_, err := AlgorithmInfo(Algorithm_C20PKW)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		_, err = AlgorithmInfo(Algorithm_A128GCM)
+		require.NoError(t, err)
+	})
+
+	t.Run("disabling FIPS mode restores all algorithms", func(t *testing.T) {
+		SetFIPSMode(false)
+		t.Cleanup(func() {
+			SetFIPSMode(true)
+		})
+
+		assert.Contains(t, SupportedSymmetricAlgorithms(), Algorithm_C20P)
+		_, err := AlgorithmInfo(Algorithm_C20PKW)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// SupportedMACAlgorithms returns the list of supported message authentication code algorithms.
+// This is a subset of the algorithms defined in consts.go.
+func SupportedMACAlgorithms() []string {
+	return filterFIPS([]string{
+		Algorithm_HS256, Algorithm_HS384, Algorithm_HS512,
+		Algorithm_KMAC128, Algorithm_KMAC256,
+	})
+}
+
+// ComputeHMAC computes the message authentication code of data using the given algorithm and key.
+// Supported algorithms are HMAC with SHA-256, SHA-384 and SHA-512 (HS256, HS384, HS512), and KMAC128 and KMAC256 as defined by NIST SP 800-185, with an empty customization string.
+// The key must not be empty.
+func ComputeHMAC(algorithm string, key []byte, data []byte) ([]byte, error) {
+	if err := checkFIPS(algorithm); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, ErrInvalidKeyLength
+	}
+
+	switch algorithm {
+	case Algorithm_HS256:
+		return computeHMACSHA(sha256.New, key, data), nil
+	case Algorithm_HS384:
+		return computeHMACSHA(sha512.New384, key, data), nil
+	case Algorithm_HS512:
+		return computeHMACSHA(sha512.New, key, data), nil
+	case Algorithm_KMAC128:
+		return computeKMAC(sha3.NewCShake128, 32, key, data, nil), nil
+	case Algorithm_KMAC256:
+		return computeKMAC(sha3.NewCShake256, 64, key, data, nil), nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// VerifyHMAC returns true if mac is the message authentication code of data computed with the given algorithm and key.
+// The comparison is performed in constant time.
+func VerifyHMAC(algorithm string, key []byte, data []byte, mac []byte) (bool, error) {
+	expect, err := ComputeHMAC(algorithm, key, data)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(expect, mac) == 1, nil
+}
+
+func computeHMACSHA(h func() hash.Hash, key []byte, data []byte) []byte {
+	mac := hmac.New(h, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// computeKMAC computes KMAC128 or KMAC256 (NIST SP 800-185, section 4) with an output of size bytes.
+func computeKMAC(newCShake func(N, S []byte) sha3.ShakeHash, size int, key []byte, data []byte, customization []byte) []byte {
+	h := newCShake([]byte("KMAC"), customization)
+	// The key is padded to the rate of the sponge, which is its block size
+	h.Write(bytepad(encodeString(key), h.BlockSize()))
+	h.Write(data)
+	h.Write(rightEncode(uint64(size) * 8))
+
+	out := make([]byte, size)
+	h.Read(out)
+	return out
+}
+
+// leftEncode implements left_encode from NIST SP 800-185.
+func leftEncode(x uint64) []byte {
+	var b [9]byte
+	binary.BigEndian.PutUint64(b[1:], x)
+	// Skip leading zero bytes, but always keep at least one byte
+	i := 1
+	for i < 8 && b[i] == 0 {
+		i++
+	}
+	b[i-1] = byte(9 - i)
+	return b[i-1:]
+}
+
+// rightEncode implements right_encode from NIST SP 800-185.
+func rightEncode(x uint64) []byte {
+	var b [9]byte
+	binary.BigEndian.PutUint64(b[:8], x)
+	i := 0
+	for i < 7 && b[i] == 0 {
+		i++
+	}
+	b[8] = byte(8 - i)
+	return b[i:]
+}
+
+// encodeString implements encode_string from NIST SP 800-185.
+func encodeString(s []byte) []byte {
+	return append(leftEncode(uint64(len(s))*8), s...)
+}
+
+// bytepad implements bytepad from NIST SP 800-185.
+func bytepad(x []byte, w int) []byte {
+	res := append(leftEncode(uint64(w)), x...)
+	if pad := len(res) % w; pad != 0 {
+		res = append(res, make([]byte, w-pad)...)
+	}
+	return res
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestComputeHMAC(t *testing.T) {
+	// Test vectors from RFC 4231, test case 2
+	key := []byte("Jefe")
+	data := []byte("what do ya want for nothing?")
+
+	tests := map[string]string{
+		Algorithm_HS256: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		Algorithm_HS384: "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+		Algorithm_HS512: "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+	}
+	for alg, expect := range tests {
+		t.Run(alg, func(t *testing.T) {
+			mac, err := ComputeHMAC(alg, key, data)
+			require.NoError(t, err)
+			assert.Equal(t, expect, hex.EncodeToString(mac))
+
+			ok, err := VerifyHMAC(alg, key, data, mac)
+			require.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+
+	t.Run("KMAC output sizes", func(t *testing.T) {
+		mac, err := ComputeHMAC(Algorithm_KMAC128, key, data)
+		require.NoError(t, err)
+		assert.Len(t, mac, 32)
+
+		mac, err = ComputeHMAC(Algorithm_KMAC256, key, data)
+		require.NoError(t, err)
+		assert.Len(t, mac, 64)
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		_, err := ComputeHMAC(Algorithm_HS256, nil, data)
+		require.ErrorIs(t, err, ErrInvalidKeyLength)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := ComputeHMAC(Algorithm_ES256, key, data)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		_, err = VerifyHMAC(Algorithm_ES256, key, data, nil)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	key := []byte("secret")
+	data := []byte("message")
+
+	for _, alg := range SupportedMACAlgorithms() {
+		t.Run(alg, func(t *testing.T) {
+			mac, err := ComputeHMAC(alg, key, data)
+			require.NoError(t, err)
+
+			ok, err := VerifyHMAC(alg, key, data, mac)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			// Tampered MAC
+			tampered := append([]byte{}, mac...)
+			tampered[0] ^= 1
+			ok, err = VerifyHMAC(alg, key, data, tampered)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// Truncated MAC
+			ok, err = VerifyHMAC(alg, key, data, mac[:len(mac)-1])
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// Wrong key and data
+			ok, err = VerifyHMAC(alg, []byte("other"), data, mac)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			ok, err = VerifyHMAC(alg, key, []byte("other"), mac)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestKMAC(t *testing.T) {
+	// Samples from NIST SP 800-185
+	key, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f")
+	data, _ := hex.DecodeString("00010203")
+
+	t.Run("KMAC128 sample 1", func(t *testing.T) {
+		mac := computeKMAC(sha3.NewCShake128, 32, key, data, nil)
+		assert.Equal(t, "e5780b0d3ea6f7d3a429c5706aa43a00fadbd7d49628839e3187243f456ee14e", hex.EncodeToString(mac))
+	})
+
+	t.Run("KMAC128 sample 2", func(t *testing.T) {
+		mac := computeKMAC(sha3.NewCShake128, 32, key, data, []byte("My Tagged Application"))
+		assert.Equal(t, "3b1fba963cd8b0b59e8c1a6d71888b7143651af8ba0a7070c0979e2811324aa5", hex.EncodeToString(mac))
+	})
+
+	t.Run("KMAC256 sample 4", func(t *testing.T) {
+		mac := computeKMAC(sha3.NewCShake256, 64, key, data, []byte("My Tagged Application"))
+		assert.Equal(t, "20c570c31346f703c9ac36c61c03cb64c3970d0cfc787e9b79599d273a68d2f7f69d4cc3de9d104a351689f27cf6f5951f0103f33f4f24871024d9c27773a8dd", hex.EncodeToString(mac))
+	})
+}
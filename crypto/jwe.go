@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// SupportedJWEKeyAlgorithms returns the list of key management algorithms supported for JWE tokens.
+// RSA1_5 is intentionally not included.
+func SupportedJWEKeyAlgorithms() []string {
+	return []string{
+		Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW,
+		Algorithm_A128GCMKW, Algorithm_A192GCMKW, Algorithm_A256GCMKW,
+		Algorithm_ECDH_ES,
+		Algorithm_ECDH_ES_A128KW, Algorithm_ECDH_ES_A192KW, Algorithm_ECDH_ES_A256KW,
+		Algorithm_RSA_OAEP, Algorithm_RSA_OAEP_256, Algorithm_RSA_OAEP_384, Algorithm_RSA_OAEP_512,
+	}
+}
+
+// SupportedJWEContentAlgorithms returns the list of content encryption algorithms supported for JWE tokens.
+func SupportedJWEContentAlgorithms() []string {
+	return []string{
+		Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM,
+		Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512,
+	}
+}
+
+// EncryptJWE encrypts the payload and returns a JWE token in the compact serialization.
+// "alg" is the key management algorithm and "enc" is the content encryption algorithm; they must be one of the values returned by SupportedJWEKeyAlgorithms and SupportedJWEContentAlgorithms respectively.
+// For asymmetric algorithms, key can be either the public or the private key.
+func EncryptJWE(payload []byte, key jwk.Key, alg string, enc string) ([]byte, error) {
+	if !slices.Contains(SupportedJWEKeyAlgorithms(), alg) || !slices.Contains(SupportedJWEContentAlgorithms(), enc) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if key == nil || key.KeyType() != jweKeyType(alg) {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	// Ensure we are using a public key
+	if key.KeyType() != jwa.OctetSeq {
+		var err error
+		key, err = key.PublicKey()
+		if err != nil {
+			return nil, ErrKeyTypeMismatch
+		}
+	}
+
+	token, err := jwe.Encrypt(payload,
+		jwe.WithKey(jwa.KeyEncryptionAlgorithm(alg), key),
+		jwe.WithContentEncryption(jwa.ContentEncryptionAlgorithm(enc)),
+		jwe.WithCompact(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt JWE: %w", err)
+	}
+	return token, nil
+}
+
+// DecryptJWE decrypts a JWE token in the compact serialization and returns the payload.
+// The algorithms are read from the token's protected header, and they must be one of the supported ones.
+func DecryptJWE(token []byte, key jwk.Key) ([]byte, error) {
+	// The compact serialization has exactly 5 parts
+	token = bytes.TrimSpace(token)
+	if bytes.Count(token, []byte{'.'}) != 4 {
+		return nil, ErrInvalidJWE
+	}
+
+	msg, err := jwe.Parse(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWE, err)
+	}
+	headers := msg.ProtectedHeaders()
+	alg := headers.Algorithm().String()
+	enc := headers.ContentEncryption().String()
+	if !slices.Contains(SupportedJWEKeyAlgorithms(), alg) || !slices.Contains(SupportedJWEContentAlgorithms(), enc) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if key == nil || key.KeyType() != jweKeyType(alg) {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	payload, err := jwe.Decrypt(token, jwe.WithKey(jwa.KeyEncryptionAlgorithm(alg), key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt JWE: %w", err)
+	}
+	return payload, nil
+}
+
+// jweKeyType returns the type of key required by a JWE key management algorithm.
+func jweKeyType(alg string) jwa.KeyType {
+	switch alg {
+	case Algorithm_RSA_OAEP, Algorithm_RSA_OAEP_256, Algorithm_RSA_OAEP_384, Algorithm_RSA_OAEP_512:
+		return jwa.RSA
+	case Algorithm_ECDH_ES, Algorithm_ECDH_ES_A128KW, Algorithm_ECDH_ES_A192KW, Algorithm_ECDH_ES_A256KW:
+		return jwa.EC
+	default:
+		return jwa.OctetSeq
+	}
+}
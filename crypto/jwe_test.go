@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWE(t *testing.T) {
+	payload := []byte("hello world")
+
+	symKey, err := jwk.FromRaw([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	rsaRaw, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaKey, err := jwk.FromRaw(rsaRaw)
+	require.NoError(t, err)
+	ecRaw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := jwk.FromRaw(ecRaw)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  jwk.Key
+		alg  string
+		enc  string
+	}{
+		{name: "A256KW", key: symKey, alg: Algorithm_A256KW, enc: Algorithm_A256GCM},
+		{name: "A256GCMKW", key: symKey, alg: Algorithm_A256GCMKW, enc: Algorithm_A128CBC_HS256},
+		{name: "RSA-OAEP-256", key: rsaKey, alg: Algorithm_RSA_OAEP_256, enc: Algorithm_A256GCM},
+		{name: "ECDH-ES+A128KW", key: ecKey, alg: Algorithm_ECDH_ES_A128KW, enc: Algorithm_A128GCM},
+		{name: "ECDH-ES", key: ecKey, alg: Algorithm_ECDH_ES, enc: Algorithm_A256GCM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := EncryptJWE(payload, tt.key, tt.alg, tt.enc)
+			require.NoError(t, err)
+			assert.Len(t, strings.Split(string(token), "."), 5)
+
+			decrypted, err := DecryptJWE(token, tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, payload, decrypted)
+		})
+	}
+
+	t.Run("unsupported algorithms", func(t *testing.T) {
+		_, err := EncryptJWE(payload, rsaKey, Algorithm_RSA1_5, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		_, err = EncryptJWE(payload, symKey, Algorithm_A256KW, Algorithm_C20P)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		// Tokens created with RSA1_5 are rejected before attempting decryption
+		pub, err := rsaKey.PublicKey()
+		require.NoError(t, err)
+		token, err := jwe.Encrypt(payload, jwe.WithKey(jwa.RSA1_5, pub), jwe.WithContentEncryption(jwa.A256GCM))
+		require.NoError(t, err)
+		_, err = DecryptJWE(token, rsaKey)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+
+	t.Run("key type mismatch", func(t *testing.T) {
+		_, err := EncryptJWE(payload, symKey, Algorithm_RSA_OAEP_256, Algorithm_A256GCM)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		token, err := EncryptJWE(payload, symKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.NoError(t, err)
+		_, err = DecryptJWE(token, rsaKey)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		otherKey, err := jwk.FromRaw([]byte("fedcba9876543210fedcba9876543210"))
+		require.NoError(t, err)
+
+		token, err := EncryptJWE(payload, symKey, Algorithm_A256KW, Algorithm_A256GCM)
+		require.NoError(t, err)
+		_, err = DecryptJWE(token, otherKey)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := DecryptJWE([]byte("not-a-token"), symKey)
+		require.ErrorIs(t, err, ErrInvalidJWE)
+
+		_, err = DecryptJWE([]byte("a.b.c.d.e"), symKey)
+		require.ErrorIs(t, err, ErrInvalidJWE)
+	})
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nonce contains generators of nonces (or IVs) for symmetric
+// encryption, which enforce the maximum number of nonces that can be safely
+// generated for a single key.
+//
+//nolint:nosnakecase
+package nonce
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/dapr/kit/crypto"
+)
+
+var (
+	// ErrExhausted is returned when the maximum number of nonces has been
+	// generated for a key, and generating more could cause a nonce to be
+	// re-used. The key must be rotated.
+	ErrExhausted = errors.New("nonces exhausted for the key: the key must be rotated")
+
+	// ErrPredictableNonce is returned when creating a counter-based generator
+	// for an algorithm which requires unpredictable nonces, such as AES-CBC.
+	ErrPredictableNonce = errors.New("algorithm requires unpredictable nonces")
+)
+
+// Size of the counter in counter-based nonces, in bytes.
+const counterSize = 8
+
+// Generator generates the nonces for a single key.
+type Generator interface {
+	// Next returns a new nonce.
+	// It returns ErrExhausted if no more nonces can be generated safely.
+	Next() ([]byte, error)
+	// Size returns the size of the nonces, in bytes.
+	Size() int
+	// Remaining returns the number of nonces that can still be generated.
+	Remaining() uint64
+}
+
+// Options contains the options for creating a Generator.
+type Options struct {
+	// Algorithm the nonces are used with, which determines their size.
+	// This is one of the symmetric encryption algorithms in the crypto package.
+	Algorithm string
+
+	// Limit is the maximum number of nonces to generate.
+	// If 0, or greater than the safe limit for the generator, the safe limit is
+	// used.
+	Limit uint64
+}
+
+// Size returns the size of the nonces used by the algorithm, in bytes.
+// It returns 0 for algorithms which don't use nonces, such as AES-KW.
+func Size(algorithm string) (int, error) {
+	switch algorithm {
+	case crypto.Algorithm_A128CBC, crypto.Algorithm_A192CBC, crypto.Algorithm_A256CBC,
+		crypto.Algorithm_A128CBC_NOPAD, crypto.Algorithm_A192CBC_NOPAD, crypto.Algorithm_A256CBC_NOPAD,
+		crypto.Algorithm_A128CBC_HS256, crypto.Algorithm_A192CBC_HS384, crypto.Algorithm_A256CBC_HS512:
+		return aes.BlockSize, nil
+	case crypto.Algorithm_A128GCM, crypto.Algorithm_A192GCM, crypto.Algorithm_A256GCM,
+		crypto.Algorithm_A128GCMKW, crypto.Algorithm_A192GCMKW, crypto.Algorithm_A256GCMKW,
+		crypto.Algorithm_A128GCM_SIV, crypto.Algorithm_A256GCM_SIV:
+		// Standard nonce size for AES-GCM and AES-GCM-SIV
+		return 12, nil
+	case crypto.Algorithm_C20P, crypto.Algorithm_C20PKW:
+		return chacha20poly1305.NonceSize, nil
+	case crypto.Algorithm_XC20P, crypto.Algorithm_XC20PKW:
+		return chacha20poly1305.NonceSizeX, nil
+	case crypto.Algorithm_A128KW, crypto.Algorithm_A192KW, crypto.Algorithm_A256KW:
+		return 0, nil
+	default:
+		return 0, crypto.ErrUnsupportedAlgorithm
+	}
+}
+
+// requiresUnpredictable returns true if the algorithm requires nonces that
+// can't be predicted by an attacker.
+func requiresUnpredictable(algorithm string) bool {
+	switch algorithm {
+	case crypto.Algorithm_A128CBC, crypto.Algorithm_A192CBC, crypto.Algorithm_A256CBC,
+		crypto.Algorithm_A128CBC_NOPAD, crypto.Algorithm_A192CBC_NOPAD, crypto.Algorithm_A256CBC_NOPAD,
+		crypto.Algorithm_A128CBC_HS256, crypto.Algorithm_A192CBC_HS384, crypto.Algorithm_A256CBC_HS512:
+		return true
+	default:
+		return false
+	}
+}
+
+func generatorSize(algorithm string) (int, error) {
+	size, err := Size(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return 0, fmt.Errorf("algorithm %s does not use nonces", algorithm)
+	}
+	return size, nil
+}
+
+// Counter is a Generator of nonces made of a random fixed field, followed by
+// an 8-byte big-endian counter, as in the deterministic construction of NIST
+// SP 800-38D.
+// Nonces are unique as long as a single Counter is used for each key: the
+// fixed field is chosen when the Counter is created, so separate instances
+// (for example, in different processes) using the same key can only rely on
+// the fixed fields being different, which is not guaranteed. In that case,
+// Random should be used instead.
+// Counter is not allowed with AES-CBC, which requires unpredictable nonces.
+// It is safe for concurrent use.
+type Counter struct {
+	fixed []byte
+	limit uint64
+	next  atomic.Uint64
+}
+
+// NewCounter returns a new Counter for the algorithm.
+// Up to 2^64-1 nonces can be generated.
+func NewCounter(opts Options) (*Counter, error) {
+	size, err := generatorSize(opts.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if requiresUnpredictable(opts.Algorithm) {
+		return nil, fmt.Errorf("%w: %s", ErrPredictableNonce, opts.Algorithm)
+	}
+
+	fixed := make([]byte, size-counterSize)
+	if _, err = rand.Read(fixed); err != nil {
+		return nil, fmt.Errorf("failed to generate fixed field: %w", err)
+	}
+
+	return &Counter{
+		fixed: fixed,
+		limit: limit(opts.Limit, math.MaxUint64),
+	}, nil
+}
+
+// Next returns a new nonce.
+func (c *Counter) Next() ([]byte, error) {
+	n := c.next.Add(1)
+	if n > c.limit || n == 0 {
+		// Do not let the counter wrap around
+		c.next.Store(c.limit)
+		return nil, ErrExhausted
+	}
+
+	nonce := make([]byte, len(c.fixed)+counterSize)
+	copy(nonce, c.fixed)
+	binary.BigEndian.PutUint64(nonce[len(c.fixed):], n-1)
+	return nonce, nil
+}
+
+// Size returns the size of the nonces, in bytes.
+func (c *Counter) Size() int {
+	return len(c.fixed) + counterSize
+}
+
+// Remaining returns the number of nonces that can still be generated.
+func (c *Counter) Remaining() uint64 {
+	return c.limit - min(c.next.Load(), c.limit)
+}
+
+// Random is a Generator of random nonces.
+// Random nonces can collide: the number of nonces is limited so that the
+// probability of a collision stays below 2^-32, which is 2^32 nonces for
+// 96-bit nonces (as in NIST SP 800-38D), 2^48 for 128-bit nonces, and
+// practically unlimited for 192-bit nonces.
+// Unlike Counter, multiple instances can be used with the same key. However,
+// each instance only counts the nonces it generates itself, so when several
+// instances share a key, the limit of each must be lowered so that their sum
+// stays within the safe limit for the key. Within a process, PerKey can be
+// used to share a single instance for each key.
+// It is safe for concurrent use.
+type Random struct {
+	size  int
+	limit uint64
+	count atomic.Uint64
+}
+
+// NewRandom returns a new Random for the algorithm.
+func NewRandom(opts Options) (*Random, error) {
+	size, err := generatorSize(opts.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Random{
+		size:  size,
+		limit: limit(opts.Limit, BirthdayBound(size)),
+	}, nil
+}
+
+// Next returns a new nonce.
+func (r *Random) Next() ([]byte, error) {
+	n := r.count.Add(1)
+	if n > r.limit || n == 0 {
+		r.count.Store(r.limit)
+		return nil, ErrExhausted
+	}
+
+	nonce := make([]byte, r.size)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+// Size returns the size of the nonces, in bytes.
+func (r *Random) Size() int {
+	return r.size
+}
+
+// Remaining returns the number of nonces that can still be generated.
+func (r *Random) Remaining() uint64 {
+	return r.limit - min(r.count.Load(), r.limit)
+}
+
+// BirthdayBound returns the maximum number of random nonces of the given size,
+// in bytes, that can be generated for a single key while keeping the
+// probability of a collision below 2^-32.
+func BirthdayBound(size int) uint64 {
+	// With n random values of b bits, the probability of a collision is
+	// approximately n^2 / 2^(b+1), so n = 2^((b-31)/2); this is rounded down
+	// to 2^((b-32)/2)
+	bits := size*8 - 32
+	if bits <= 0 {
+		return 1
+	}
+	if bits/2 >= 64 {
+		return math.MaxUint64
+	}
+	return 1 << (bits / 2)
+}
+
+func limit(requested uint64, safe uint64) uint64 {
+	if requested == 0 || requested > safe {
+		return safe
+	}
+	return requested
+}
+
+// PerKey manages a Generator for each key, which is created when the first
+// nonce is requested for the key.
+// It is safe for concurrent use.
+type PerKey struct {
+	newFn      func() (Generator, error)
+	generators map[string]Generator
+	lock       sync.Mutex
+}
+
+// NewPerKey returns a new PerKey which creates Generators with newFn.
+func NewPerKey(newFn func() (Generator, error)) *PerKey {
+	return &PerKey{
+		newFn:      newFn,
+		generators: make(map[string]Generator),
+	}
+}
+
+// Next returns a new nonce for the key with the given ID.
+func (p *PerKey) Next(keyID string) ([]byte, error) {
+	g, err := p.generator(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return g.Next()
+}
+
+// Remaining returns the number of nonces that can still be generated for the
+// key with the given ID.
+func (p *PerKey) Remaining(keyID string) (uint64, error) {
+	g, err := p.generator(keyID)
+	if err != nil {
+		return 0, err
+	}
+	return g.Remaining(), nil
+}
+
+// Forget removes the Generator of the key with the given ID, for example after
+// the key has been deleted.
+// The key must not be used again: a new Generator would not know about the
+// nonces generated before.
+func (p *PerKey) Forget(keyID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.generators, keyID)
+}
+
+func (p *PerKey) generator(keyID string) (Generator, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	g, ok := p.generators[keyID]
+	if ok {
+		return g, nil
+	}
+
+	g, err := p.newFn()
+	if err != nil {
+		return nil, err
+	}
+	p.generators[keyID] = g
+	return g, nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nonce
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/crypto"
+)
+
+func TestSize(t *testing.T) {
+	tests := map[string]int{
+		crypto.Algorithm_A128GCM:       12,
+		crypto.Algorithm_A256GCMKW:     12,
+		crypto.Algorithm_A128GCM_SIV:   12,
+		crypto.Algorithm_A256CBC:       16,
+		crypto.Algorithm_A128CBC_HS256: 16,
+		crypto.Algorithm_C20P:          12,
+		crypto.Algorithm_XC20P:         24,
+		crypto.Algorithm_A256KW:        0,
+	}
+	for alg, expect := range tests {
+		size, err := Size(alg)
+		require.NoError(t, err, alg)
+		assert.Equal(t, expect, size, alg)
+	}
+
+	_, err := Size(crypto.Algorithm_RS256)
+	require.ErrorIs(t, err, crypto.ErrUnsupportedAlgorithm)
+}
+
+func TestCounter(t *testing.T) {
+	t.Run("nonces are sequential", func(t *testing.T) {
+		c, err := NewCounter(Options{Algorithm: crypto.Algorithm_A256GCM})
+		require.NoError(t, err)
+		assert.Equal(t, 12, c.Size())
+
+		first, err := c.Next()
+		require.NoError(t, err)
+		second, err := c.Next()
+		require.NoError(t, err)
+
+		require.Len(t, first, 12)
+		require.Len(t, second, 12)
+		assert.Equal(t, first[:4], second[:4])
+		assert.Equal(t, uint64(0), binary.BigEndian.Uint64(first[4:]))
+		assert.Equal(t, uint64(1), binary.BigEndian.Uint64(second[4:]))
+	})
+
+	t.Run("sized for the algorithm", func(t *testing.T) {
+		c, err := NewCounter(Options{Algorithm: crypto.Algorithm_XC20P})
+		require.NoError(t, err)
+		nonce, err := c.Next()
+		require.NoError(t, err)
+		assert.Len(t, nonce, 24)
+
+		key, err := jwk.FromRaw(make([]byte, 32))
+		require.NoError(t, err)
+		ciphertext, tag, err := crypto.EncryptSymmetric([]byte("hello"), crypto.Algorithm_XC20P, key, nonce, nil)
+		require.NoError(t, err)
+		assert.NotEmpty(t, ciphertext)
+		assert.NotEmpty(t, tag)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		c, err := NewCounter(Options{Algorithm: crypto.Algorithm_C20P, Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), c.Remaining())
+
+		for range 3 {
+			_, err = c.Next()
+			require.NoError(t, err)
+		}
+		assert.Equal(t, uint64(0), c.Remaining())
+
+		_, err = c.Next()
+		require.ErrorIs(t, err, ErrExhausted)
+		_, err = c.Next()
+		require.ErrorIs(t, err, ErrExhausted)
+		assert.Equal(t, uint64(0), c.Remaining())
+	})
+
+	t.Run("not allowed with CBC", func(t *testing.T) {
+		_, err := NewCounter(Options{Algorithm: crypto.Algorithm_A128CBC})
+		require.ErrorIs(t, err, ErrPredictableNonce)
+		_, err = NewCounter(Options{Algorithm: crypto.Algorithm_A256CBC_HS512})
+		require.ErrorIs(t, err, ErrPredictableNonce)
+	})
+
+	t.Run("algorithms without nonces", func(t *testing.T) {
+		_, err := NewCounter(Options{Algorithm: crypto.Algorithm_A128KW})
+		require.Error(t, err)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		c, err := NewCounter(Options{Algorithm: crypto.Algorithm_A128GCM, Limit: 1000})
+		require.NoError(t, err)
+
+		var (
+			lock sync.Mutex
+			wg   sync.WaitGroup
+		)
+		seen := make(map[string]struct{})
+		exhausted := 0
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 200 {
+					nonce, err := c.Next()
+					lock.Lock()
+					if errors.Is(err, ErrExhausted) {
+						exhausted++
+					} else {
+						seen[string(nonce)] = struct{}{}
+					}
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, 1000)
+		assert.Equal(t, 1000, exhausted)
+	})
+}
+
+func TestRandom(t *testing.T) {
+	t.Run("nonces are random", func(t *testing.T) {
+		r, err := NewRandom(Options{Algorithm: crypto.Algorithm_A256CBC})
+		require.NoError(t, err)
+		assert.Equal(t, 16, r.Size())
+		assert.Equal(t, uint64(1)<<48, r.Remaining())
+
+		first, err := r.Next()
+		require.NoError(t, err)
+		second, err := r.Next()
+		require.NoError(t, err)
+		require.Len(t, first, 16)
+		assert.False(t, bytes.Equal(first, second))
+		assert.Equal(t, uint64(1)<<48-2, r.Remaining())
+	})
+
+	t.Run("limit is capped to the birthday bound", func(t *testing.T) {
+		r, err := NewRandom(Options{Algorithm: crypto.Algorithm_A128GCM, Limit: 1 << 40})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1)<<32, r.Remaining())
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		r, err := NewRandom(Options{Algorithm: crypto.Algorithm_C20P, Limit: 1})
+		require.NoError(t, err)
+		_, err = r.Next()
+		require.NoError(t, err)
+		_, err = r.Next()
+		require.ErrorIs(t, err, ErrExhausted)
+	})
+}
+
+func TestBirthdayBound(t *testing.T) {
+	assert.Equal(t, uint64(1)<<32, BirthdayBound(12))
+	assert.Equal(t, uint64(1)<<48, BirthdayBound(16))
+	assert.Equal(t, uint64(1<<64-1), BirthdayBound(24))
+	assert.Equal(t, uint64(1), BirthdayBound(4))
+}
+
+func TestPerKey(t *testing.T) {
+	p := NewPerKey(func() (Generator, error) {
+		return NewCounter(Options{Algorithm: crypto.Algorithm_A128GCM, Limit: 2})
+	})
+
+	a1, err := p.Next("a")
+	require.NoError(t, err)
+	b1, err := p.Next("b")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), binary.BigEndian.Uint64(a1[4:]))
+	assert.Equal(t, uint64(0), binary.BigEndian.Uint64(b1[4:]))
+
+	_, err = p.Next("a")
+	require.NoError(t, err)
+	_, err = p.Next("a")
+	require.ErrorIs(t, err, ErrExhausted)
+
+	remaining, err := p.Remaining("b")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), remaining)
+
+	p.Forget("a")
+	remaining, err = p.Remaining("a")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), remaining)
+
+	p = NewPerKey(func() (Generator, error) {
+		return NewRandom(Options{Algorithm: "invalid"})
+	})
+	_, err = p.Next("a")
+	require.ErrorIs(t, err, crypto.ErrUnsupportedAlgorithm)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pem
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+)
+
+// PEM block types.
+const (
+	blockTypePrivateKey    = "PRIVATE KEY"
+	blockTypeRSAPrivateKey = "RSA PRIVATE KEY"
+	blockTypeECPrivateKey  = "EC PRIVATE KEY"
+	blockTypePublicKey     = "PUBLIC KEY"
+	blockTypeRSAPublicKey  = "RSA PUBLIC KEY"
+	blockTypeCertificate   = "CERTIFICATE"
+	// There's no standard PEM encoding for symmetric keys: the raw bytes of the
+	// key are used.
+	blockTypeSecretKey = "SECRET KEY"
+)
+
+var (
+	// ErrUnsupportedKeyType is returned when the type of a key can't be
+	// converted.
+	ErrUnsupportedKeyType = errors.New("unsupported key type")
+	// ErrNotPEM is returned when the data is not PEM-encoded.
+	ErrNotPEM = errors.New("key is not PEM encoded")
+)
+
+// FromJWK encodes a JWK as PEM.
+// Private keys (RSA, EC and OKP) are encoded as PKCS#8 with the "PRIVATE KEY"
+// block type, and public keys as PKIX (SPKI) with the "PUBLIC KEY" block type.
+// Symmetric keys are encoded as their raw bytes with the "SECRET KEY" block
+// type.
+func FromJWK(key jwk.Key) ([]byte, error) {
+	if key == nil {
+		return nil, fmt.Errorf("%w: key is nil", ErrUnsupportedKeyType)
+	}
+
+	var raw any
+	err := key.Raw(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract raw key: %w", err)
+	}
+
+	var block *pem.Block
+	switch r := raw.(type) {
+	case []byte:
+		block = &pem.Block{Type: blockTypeSecretKey, Bytes: r}
+
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey, x25519.PrivateKey:
+		if x, ok := r.(x25519.PrivateKey); ok {
+			r, err = ecdh.X25519().NewPrivateKey(x.Seed())
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert X25519 private key: %w", err)
+			}
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal private key: %w", err)
+		}
+		block = &pem.Block{Type: blockTypePrivateKey, Bytes: der}
+
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey, x25519.PublicKey:
+		if x, ok := r.(x25519.PublicKey); ok {
+			r, err = ecdh.X25519().NewPublicKey(x)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert X25519 public key: %w", err)
+			}
+		}
+		der, err := x509.MarshalPKIXPublicKey(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal public key: %w", err)
+		}
+		block = &pem.Block{Type: blockTypePublicKey, Bytes: der}
+
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, raw)
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// ToJWK decodes the first PEM block in pemBytes as a JWK.
+// It supports private keys encoded as PKCS#8, PKCS#1 (RSA) or SEC 1 (EC),
+// public keys encoded as PKIX (SPKI) or PKCS#1 (RSA), the public key of an
+// X.509 certificate, and symmetric keys encoded by FromJWK.
+func ToJWK(pemBytes []byte) (jwk.Key, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, ErrNotPEM
+	}
+
+	var (
+		raw any
+		err error
+	)
+	switch block.Type {
+	case blockTypePrivateKey:
+		raw, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case blockTypeRSAPrivateKey:
+		raw, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case blockTypeECPrivateKey:
+		raw, err = x509.ParseECPrivateKey(block.Bytes)
+	case blockTypePublicKey:
+		raw, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case blockTypeRSAPublicKey:
+		raw, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case blockTypeCertificate:
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			raw = cert.PublicKey
+		}
+	case blockTypeSecretKey:
+		raw = block.Bytes
+	default:
+		return nil, fmt.Errorf("%w: unsupported block type %s", ErrUnsupportedKeyType, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", block.Type, err)
+	}
+
+	// The x509 package returns X25519 keys as ecdh keys, while jwk uses the
+	// x25519 package
+	switch r := raw.(type) {
+	case *ecdh.PrivateKey:
+		if r.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: ECDH key on curve %s", ErrUnsupportedKeyType, r.Curve())
+		}
+		raw, err = x25519.NewKeyFromSeed(r.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert X25519 private key: %w", err)
+		}
+	case *ecdh.PublicKey:
+		if r.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: ECDH key on curve %s", ErrUnsupportedKeyType, r.Curve())
+		}
+		raw = x25519.PublicKey(r.Bytes())
+	}
+
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK: %w", err)
+	}
+	return key, nil
+}
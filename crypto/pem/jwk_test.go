@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pem
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/x25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, xKey, err := x25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		raw       any
+		blockType string
+		kty       jwa.KeyType
+	}{
+		"RSA private": {raw: rsaKey, blockType: "PRIVATE KEY", kty: jwa.RSA},
+		"RSA public":  {raw: &rsaKey.PublicKey, blockType: "PUBLIC KEY", kty: jwa.RSA},
+		"EC private":  {raw: ecKey, blockType: "PRIVATE KEY", kty: jwa.EC},
+		"EC public":   {raw: &ecKey.PublicKey, blockType: "PUBLIC KEY", kty: jwa.EC},
+		"Ed25519 private": {
+			raw: edKey, blockType: "PRIVATE KEY", kty: jwa.OKP,
+		},
+		"Ed25519 public": {
+			raw: edKey.Public(), blockType: "PUBLIC KEY", kty: jwa.OKP,
+		},
+		"X25519 private": {
+			raw: xKey, blockType: "PRIVATE KEY", kty: jwa.OKP,
+		},
+		"X25519 public": {
+			raw: xKey.Public(), blockType: "PUBLIC KEY", kty: jwa.OKP,
+		},
+		"symmetric": {
+			raw: []byte("0123456789abcdef"), blockType: "SECRET KEY", kty: jwa.OctetSeq,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := jwk.FromRaw(tc.raw)
+			require.NoError(t, err)
+
+			enc, err := FromJWK(key)
+			require.NoError(t, err)
+
+			block, rest := pem.Decode(enc)
+			require.NotNil(t, block)
+			assert.Empty(t, rest)
+			assert.Equal(t, tc.blockType, block.Type)
+
+			decoded, err := ToJWK(enc)
+			require.NoError(t, err)
+			assert.Equal(t, tc.kty, decoded.KeyType())
+
+			expectThumb, err := key.Thumbprint(crypto.SHA256)
+			require.NoError(t, err)
+			gotThumb, err := decoded.Thumbprint(crypto.SHA256)
+			require.NoError(t, err)
+			assert.Equal(t, expectThumb, gotThumb)
+		})
+	}
+}
+
+func TestToJWK(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("PKCS#1 private key", func(t *testing.T) {
+		key, err := ToJWK(pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		}))
+		require.NoError(t, err)
+		var raw rsa.PrivateKey
+		require.NoError(t, key.Raw(&raw))
+		assert.True(t, rsaKey.Equal(&raw))
+	})
+
+	t.Run("PKCS#1 public key", func(t *testing.T) {
+		key, err := ToJWK(pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey),
+		}))
+		require.NoError(t, err)
+		var raw rsa.PublicKey
+		require.NoError(t, key.Raw(&raw))
+		assert.True(t, rsaKey.PublicKey.Equal(&raw))
+	})
+
+	t.Run("SEC 1 private key", func(t *testing.T) {
+		der, err := x509.MarshalECPrivateKey(ecKey)
+		require.NoError(t, err)
+		key, err := ToJWK(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+		require.NoError(t, err)
+		var raw ecdsa.PrivateKey
+		require.NoError(t, key.Raw(&raw))
+		assert.True(t, ecKey.Equal(&raw))
+	})
+
+	t.Run("certificate", func(t *testing.T) {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &ecKey.PublicKey, ecKey)
+		require.NoError(t, err)
+
+		key, err := ToJWK(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		require.NoError(t, err)
+		var raw ecdsa.PublicKey
+		require.NoError(t, key.Raw(&raw))
+		assert.True(t, ecKey.PublicKey.Equal(&raw))
+	})
+
+	t.Run("not PEM", func(t *testing.T) {
+		_, err := ToJWK([]byte("not a key"))
+		require.ErrorIs(t, err, ErrNotPEM)
+	})
+
+	t.Run("unsupported block type", func(t *testing.T) {
+		_, err := ToJWK(pem.EncodeToMemory(&pem.Block{Type: "FOO", Bytes: []byte("bar")}))
+		require.ErrorIs(t, err, ErrUnsupportedKeyType)
+	})
+
+	t.Run("invalid DER", func(t *testing.T) {
+		_, err := ToJWK(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("bar")}))
+		require.ErrorContains(t, err, "failed to parse PRIVATE KEY")
+	})
+}
+
+func TestFromJWKNil(t *testing.T) {
+	_, err := FromJWK(nil)
+	require.ErrorIs(t, err, ErrUnsupportedKeyType)
+}
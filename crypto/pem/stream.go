@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pem
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrNoCertificates is returned when the data doesn't contain any
+	// certificate.
+	ErrNoCertificates = errors.New("no certificates found")
+	// ErrTooManyCertificates is returned when the data contains more
+	// certificates than the limit.
+	ErrTooManyCertificates = errors.New("too many certificates")
+	// ErrInputTooLarge is returned when the data is larger than the limit.
+	ErrInputTooLarge = errors.New("input too large")
+	// ErrGarbageData is returned when the data contains text outside of PEM
+	// blocks which is not a comment.
+	ErrGarbageData = errors.New("unexpected data outside of PEM blocks")
+	// ErrPartialCertificate is returned when the data ends in the middle of a
+	// PEM block, for example because it was truncated.
+	ErrPartialCertificate = errors.New("partial PEM block")
+	// ErrUnexpectedBlockType is returned when the data contains a PEM block
+	// which is not a certificate.
+	ErrUnexpectedBlockType = errors.New("unexpected PEM block type")
+	// ErrInvalidCertificate is returned when a PEM block can't be decoded or
+	// doesn't contain a valid certificate.
+	ErrInvalidCertificate = errors.New("invalid certificate")
+)
+
+var (
+	pemBegin = []byte("-----BEGIN ")
+	pemEnd   = []byte("-----END ")
+	pemDash  = []byte("-----")
+)
+
+// DecodeX509Stream reads PEM-encoded x509 certificates from r, parsing them
+// incrementally, so the whole input doesn't need to be held in memory.
+// Besides certificates, the data can only contain blank lines and comment
+// lines starting with "#", as found in common CA bundles.
+// At most maxCerts certificates and maxBytes bytes are read from r; a value
+// of 0 or less disables the limit.
+// Errors wrap one of the ErrXxx errors of this package, such as
+// ErrGarbageData or ErrPartialCertificate, and include the line number.
+func DecodeX509Stream(r io.Reader, maxCerts int, maxBytes int64) ([]*x509.Certificate, error) {
+	if maxBytes > 0 {
+		// Read one more byte to detect inputs exceeding the limit
+		r = io.LimitReader(r, maxBytes+1)
+	}
+	br := bufio.NewReader(r)
+
+	var (
+		certs     []*x509.Certificate
+		block     bytes.Buffer
+		inBlock   bool
+		blockLine int
+		line      int
+		read      int64
+	)
+	for {
+		data, err := br.ReadBytes('\n')
+		read += int64(len(data))
+		if maxBytes > 0 && read > maxBytes {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, maxBytes)
+		}
+		if len(data) > 0 {
+			line++
+			trimmed := bytes.TrimSpace(data)
+
+			switch {
+			case inBlock:
+				block.Write(data)
+				if !bytes.HasPrefix(trimmed, pemEnd) {
+					break
+				}
+				inBlock = false
+				if maxCerts > 0 && len(certs) == maxCerts {
+					return nil, fmt.Errorf("%w: more than %d", ErrTooManyCertificates, maxCerts)
+				}
+				cert, decodeErr := decodeX509Block(block.Bytes())
+				if decodeErr != nil {
+					return nil, fmt.Errorf("%w at line %d", decodeErr, blockLine)
+				}
+				certs = append(certs, cert)
+
+			case len(trimmed) == 0 || trimmed[0] == '#':
+				// Blank line or comment
+
+			case bytes.HasPrefix(trimmed, pemBegin) && bytes.HasSuffix(trimmed, pemDash):
+				inBlock = true
+				blockLine = line
+				block.Reset()
+				block.Write(data)
+
+			default:
+				return nil, fmt.Errorf("%w at line %d", ErrGarbageData, line)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read certificates: %w", err)
+		}
+	}
+
+	if inBlock {
+		return nil, fmt.Errorf("%w starting at line %d", ErrPartialCertificate, blockLine)
+	}
+	if len(certs) == 0 {
+		return nil, ErrNoCertificates
+	}
+
+	return certs, nil
+}
+
+// decodeX509Block decodes a single PEM block containing a certificate.
+func decodeX509Block(data []byte) (*x509.Certificate, error) {
+	block, rest := pem.Decode(data)
+	if block == nil || len(bytes.TrimSpace(rest)) > 0 {
+		return nil, fmt.Errorf("%w: malformed PEM block", ErrInvalidCertificate)
+	}
+	if block.Type != blockTypeCertificate {
+		return nil, fmt.Errorf("%w %q", ErrUnexpectedBlockType, block.Type)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+	return cert, nil
+}
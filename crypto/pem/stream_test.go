@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pem
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func genTestCertPEM(t *testing.T, cn string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: blockTypeCertificate, Bytes: der})
+}
+
+func TestDecodeX509Stream(t *testing.T) {
+	cert1 := genTestCertPEM(t, "cert1")
+	cert2 := genTestCertPEM(t, "cert2")
+	bundle := string(cert1) + string(cert2)
+
+	t.Run("decodes certificates with comments and blank lines", func(t *testing.T) {
+		data := "# Issuer: CN=cert1\n" + string(cert1) + "\n\n# Issuer: CN=cert2\r\n" + string(cert2) + "\n"
+		certs, err := DecodeX509Stream(strings.NewReader(data), 0, 0)
+		require.NoError(t, err)
+		require.Len(t, certs, 2)
+		assert.Equal(t, "cert1", certs[0].Subject.CommonName)
+		assert.Equal(t, "cert2", certs[1].Subject.CommonName)
+	})
+
+	t.Run("matches DecodePEMCertificates", func(t *testing.T) {
+		certs, err := DecodeX509Stream(strings.NewReader(bundle), 2, int64(len(bundle)))
+		require.NoError(t, err)
+		expect, err := DecodePEMCertificates([]byte(bundle))
+		require.NoError(t, err)
+		assert.Equal(t, expect, certs)
+	})
+
+	tests := map[string]struct {
+		data      string
+		maxCerts  int
+		maxBytes  int64
+		expectErr error
+		errLine   string
+	}{
+		"empty": {
+			data:      "",
+			expectErr: ErrNoCertificates,
+		},
+		"only comments": {
+			data:      "# nothing here\n\n",
+			expectErr: ErrNoCertificates,
+		},
+		"trailing garbage": {
+			data:      bundle + "garbage",
+			expectErr: ErrGarbageData,
+			errLine:   "line " + lineOf(bundle+"garbage", "garbage"),
+		},
+		"leading garbage": {
+			data:      "not a certificate\n" + bundle,
+			expectErr: ErrGarbageData,
+			errLine:   "line 1",
+		},
+		"truncated certificate": {
+			data:      bundle[:len(cert1)+len(cert2)/2],
+			expectErr: ErrPartialCertificate,
+			errLine:   "line " + lineOf(bundle, "-----BEGIN", 2),
+		},
+		"invalid base64": {
+			data:      "-----BEGIN CERTIFICATE-----\n!!!\n-----END CERTIFICATE-----\n",
+			expectErr: ErrInvalidCertificate,
+		},
+		"invalid certificate": {
+			data:      string(pem.EncodeToMemory(&pem.Block{Type: blockTypeCertificate, Bytes: []byte("nope")})),
+			expectErr: ErrInvalidCertificate,
+		},
+		"private key block": {
+			data:      string(cert1) + string(pem.EncodeToMemory(&pem.Block{Type: blockTypePrivateKey, Bytes: []byte("key")})),
+			expectErr: ErrUnexpectedBlockType,
+		},
+		"too many certificates": {
+			data:      bundle,
+			maxCerts:  1,
+			expectErr: ErrTooManyCertificates,
+		},
+		"too large": {
+			data:      bundle,
+			maxBytes:  int64(len(bundle) - 1),
+			expectErr: ErrInputTooLarge,
+		},
+		"too large without newlines": {
+			data:      strings.Repeat("a", 1024),
+			maxBytes:  100,
+			expectErr: ErrInputTooLarge,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			certs, err := DecodeX509Stream(strings.NewReader(tt.data), tt.maxCerts, tt.maxBytes)
+			require.ErrorIs(t, err, tt.expectErr)
+			assert.Nil(t, certs)
+			if tt.errLine != "" {
+				assert.Contains(t, err.Error(), tt.errLine)
+			}
+		})
+	}
+}
+
+// lineOf returns the 1-based line number of the n-th occurrence (1 if
+// omitted) of substr in s, as a string.
+func lineOf(s string, substr string, n ...int) string {
+	nth := 1
+	if len(n) > 0 {
+		nth = n[0]
+	}
+	idx := -1
+	for range nth {
+		next := strings.Index(s[idx+1:], substr)
+		idx += next + 1
+	}
+	return strconv.Itoa(strings.Count(s[:idx], "\n") + 1)
+}
@@ -23,7 +23,10 @@ import (
 
 type ctxkey int
 
-const svidKey ctxkey = iota
+const (
+	svidKey ctxkey = iota
+	peerIDKey
+)
 
 func With(ctx context.Context, spiffe *spiffe.SPIFFE) context.Context {
 	return context.WithValue(ctx, svidKey, spiffe.SVIDSource())
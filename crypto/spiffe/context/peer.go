@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/spiffe/go-spiffe/v2/spiffegrpc/grpccredentials"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// WithPeerID returns a context which stores the SPIFFE ID of the peer.
+func WithPeerID(ctx context.Context, id spiffeid.ID) context.Context {
+	return context.WithValue(ctx, peerIDKey, id)
+}
+
+// PeerIDFromContext returns the SPIFFE ID of the peer. This is the ID stored
+// in the context with WithPeerID (for example by the interceptors in this
+// package), if any, or else the ID in the certificate presented by the peer
+// of the gRPC connection, if it used mTLS, with either the credentials from
+// go-spiffe or the standard TLS credentials.
+// It returns false if the peer didn't present a certificate with a valid
+// SPIFFE ID.
+func PeerIDFromContext(ctx context.Context) (spiffeid.ID, bool) {
+	if id, ok := ctx.Value(peerIDKey).(spiffeid.ID); ok {
+		return id, true
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return spiffeid.ID{}, false
+	}
+	// Connections with the credentials from go-spiffe have already
+	// extracted the ID
+	if id, ok := grpccredentials.PeerIDFromPeer(p); ok {
+		return id, true
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return spiffeid.ID{}, false
+	}
+	id, err := PeerIDFromTLS(&tlsInfo.State)
+	if err != nil {
+		return spiffeid.ID{}, false
+	}
+	return id, true
+}
+
+// PeerIDFromRequest returns the SPIFFE ID of the client which sent the HTTP
+// request, from the context of the request or else from the certificate
+// presented by the client, if the connection used mTLS.
+// It returns false if the client didn't present a certificate with a valid
+// SPIFFE ID.
+func PeerIDFromRequest(r *http.Request) (spiffeid.ID, bool) {
+	if id, ok := r.Context().Value(peerIDKey).(spiffeid.ID); ok {
+		return id, true
+	}
+	id, err := PeerIDFromTLS(r.TLS)
+	if err != nil {
+		return spiffeid.ID{}, false
+	}
+	return id, true
+}
+
+// PeerIDFromTLS returns the SPIFFE ID in the certificate presented by the
+// peer of a TLS connection. The certificate must have exactly one URI SAN,
+// which must be a valid SPIFFE ID.
+// Only certificates which were verified by crypto/tls during the handshake
+// are accepted, so connections which verify the peer certificate only in
+// tls.Config.VerifyPeerCertificate, such as those configured with go-spiffe's
+// tlsconfig package, return an error: use x509svid.Verify on the peer
+// certificates for those instead.
+func PeerIDFromTLS(state *tls.ConnectionState) (spiffeid.ID, error) {
+	if state == nil || !state.HandshakeComplete {
+		return spiffeid.ID{}, errors.New("connection doesn't use TLS")
+	}
+	if len(state.PeerCertificates) == 0 {
+		return spiffeid.ID{}, errors.New("peer didn't present a certificate")
+	}
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return spiffeid.ID{}, errors.New("peer certificate was not verified")
+	}
+	id, err := x509svid.IDFromCert(state.VerifiedChains[0][0])
+	if err != nil {
+		return spiffeid.ID{}, fmt.Errorf("invalid peer certificate: %w", err)
+	}
+	return id, nil
+}
+
+// UnaryServerInterceptor returns a gRPC unary server interceptor that stores
+// the SPIFFE ID of the peer, if any, in the context of each request, so
+// handlers can retrieve it with PeerIDFromContext.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withPeerIDFromContext(ctx), req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC stream server interceptor that stores
+// the SPIFFE ID of the peer, if any, in the context of each stream, so
+// handlers can retrieve it with PeerIDFromContext.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextServerStream{
+			ServerStream: ss,
+			ctx:          withPeerIDFromContext(ss.Context()),
+		})
+	}
+}
+
+// HTTPMiddleware returns a middleware that stores the SPIFFE ID of the
+// client, if any, in the context of each request, so handlers can retrieve
+// it with PeerIDFromContext or PeerIDFromRequest.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := PeerIDFromRequest(r); ok {
+			r = r.WithContext(WithPeerID(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func withPeerIDFromContext(ctx context.Context) context.Context {
+	id, ok := PeerIDFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return WithPeerID(ctx, id)
+}
+
+// contextServerStream is a grpc.ServerStream with a custom context.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
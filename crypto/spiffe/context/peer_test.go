@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/dapr/kit/crypto/test"
+)
+
+func TestPeerIDFromContext(t *testing.T) {
+	clientID := spiffeid.RequireFromString("spiffe://example.org/ns/default/app")
+	pki := test.GenPKI(t, test.PKIOptions{
+		LeafID:   spiffeid.RequireFromString("spiffe://example.org/server"),
+		ClientID: clientID,
+	})
+
+	t.Run("gRPC peer with mTLS", func(t *testing.T) {
+		id, ok := PeerIDFromContext(pki.ClientGRPCCtx(t))
+		require.True(t, ok)
+		assert.Equal(t, clientID, id)
+	})
+
+	t.Run("no peer", func(t *testing.T) {
+		_, ok := PeerIDFromContext(context.Background())
+		assert.False(t, ok)
+	})
+
+	t.Run("gRPC peer without TLS", func(t *testing.T) {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{})
+		_, ok := PeerIDFromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("ID stored in the context", func(t *testing.T) {
+		other := spiffeid.RequireFromString("spiffe://example.org/other")
+		id, ok := PeerIDFromContext(WithPeerID(pki.ClientGRPCCtx(t), other))
+		require.True(t, ok)
+		assert.Equal(t, other, id)
+	})
+
+	t.Run("interceptors", func(t *testing.T) {
+		ctx := pki.ClientGRPCCtx(t)
+
+		var got spiffeid.ID
+		_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			got, _ = ctx.Value(peerIDKey).(spiffeid.ID)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, clientID, got)
+
+		got = spiffeid.ID{}
+		err = StreamServerInterceptor()(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+			got, _ = ss.Context().Value(peerIDKey).(spiffeid.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, clientID, got)
+	})
+}
+
+func TestPeerIDFromTLS(t *testing.T) {
+	clientID := spiffeid.RequireFromString("spiffe://example.org/ns/default/app")
+	pki := test.GenPKI(t, test.PKIOptions{
+		LeafID:   spiffeid.RequireFromString("spiffe://example.org/server"),
+		ClientID: clientID,
+	})
+
+	t.Run("valid certificate", func(t *testing.T) {
+		id, err := PeerIDFromTLS(&tls.ConnectionState{
+			HandshakeComplete: true,
+			PeerCertificates:  []*x509.Certificate{pki.ClientCert},
+			VerifiedChains:    [][]*x509.Certificate{{pki.ClientCert, pki.RootCert}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, clientID, id)
+	})
+
+	t.Run("certificate not verified", func(t *testing.T) {
+		_, err := PeerIDFromTLS(&tls.ConnectionState{
+			HandshakeComplete: true,
+			PeerCertificates:  []*x509.Certificate{pki.ClientCert},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("no TLS", func(t *testing.T) {
+		_, err := PeerIDFromTLS(nil)
+		require.Error(t, err)
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		_, err := PeerIDFromTLS(&tls.ConnectionState{HandshakeComplete: true})
+		require.Error(t, err)
+	})
+
+	t.Run("certificate without a SPIFFE ID", func(t *testing.T) {
+		_, err := PeerIDFromTLS(&tls.ConnectionState{
+			HandshakeComplete: true,
+			PeerCertificates:  []*x509.Certificate{pki.RootCert},
+			VerifiedChains:    [][]*x509.Certificate{{pki.RootCert}},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("gRPC TLS info", func(t *testing.T) {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				HandshakeComplete: true,
+				PeerCertificates:  []*x509.Certificate{pki.ClientCert},
+				VerifiedChains:    [][]*x509.Certificate{{pki.ClientCert, pki.RootCert}},
+			}},
+		})
+		id, ok := PeerIDFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, clientID, id)
+	})
+
+	t.Run("HTTP request", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "https://example.org/", nil)
+		_, ok := PeerIDFromRequest(r)
+		assert.False(t, ok)
+
+		r.TLS = &tls.ConnectionState{
+			HandshakeComplete: true,
+			PeerCertificates:  []*x509.Certificate{pki.ClientCert},
+			VerifiedChains:    [][]*x509.Certificate{{pki.ClientCert, pki.RootCert}},
+		}
+		id, ok := PeerIDFromRequest(r)
+		require.True(t, ok)
+		assert.Equal(t, clientID, id)
+
+		var got spiffeid.ID
+		var gotOK bool
+		HTTPMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			got, gotOK = PeerIDFromContext(r.Context())
+		})).ServeHTTP(httptest.NewRecorder(), r)
+		require.True(t, gotOK)
+		assert.Equal(t, clientID, got)
+	})
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
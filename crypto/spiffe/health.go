@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffe
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+// ErrNoIdentity is returned when no identity has been fetched yet.
+var ErrNoIdentity = errors.New("no identity available")
+
+// Identity is a snapshot of the current workload identity.
+type Identity struct {
+	// ID is the SPIFFE ID of the workload.
+	ID spiffeid.ID
+
+	// Certificates is the X.509 certificate chain of the SVID, leaf first.
+	Certificates []*x509.Certificate
+
+	// NotBefore is the time from which the SVID is valid.
+	NotBefore time.Time
+
+	// NotAfter is the time at which the SVID expires.
+	NotAfter time.Time
+}
+
+// CurrentIdentity returns the current workload identity, or ErrNoIdentity if
+// no identity has been fetched yet. It does not block waiting for SPIFFE to
+// become ready.
+func (s *SPIFFE) CurrentIdentity() (Identity, error) {
+	return s.CurrentIdentityFor(DefaultHint)
+}
+
+// CurrentIdentityFor returns the current identity with the given hint, or
+// ErrNoIdentity if there's no such identity or it hasn't been fetched yet.
+// It does not block waiting for SPIFFE to become ready.
+func (s *SPIFFE) CurrentIdentityFor(hint string) (Identity, error) {
+	id, ok := s.identities[hint]
+	if !ok {
+		return Identity{}, ErrNoIdentity
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if id.currentSVID == nil || len(id.currentSVID.Certificates) == 0 {
+		return Identity{}, ErrNoIdentity
+	}
+
+	leaf := id.currentSVID.Certificates[0]
+	return Identity{
+		ID:           id.currentSVID.ID,
+		Certificates: id.currentSVID.Certificates,
+		NotBefore:    leaf.NotBefore,
+		NotAfter:     leaf.NotAfter,
+	}, nil
+}
+
+// ExpiresAt returns the expiry time of the current SVID, or the zero time if
+// no identity has been fetched yet.
+func (s *SPIFFE) ExpiresAt() time.Time {
+	id, err := s.CurrentIdentity()
+	if err != nil {
+		return time.Time{}
+	}
+	return id.NotAfter
+}
+
+// Healthz returns nil if the current SVIDs of all identities are valid and not
+// within the configured expiry threshold, or an error describing why an
+// identity is unhealthy otherwise. Suitable for wiring into readiness probes.
+func (s *SPIFFE) Healthz() error {
+	for _, hint := range s.Hints() {
+		if err := s.healthzFor(s.identities[hint]); err != nil {
+			if hint != DefaultHint {
+				err = fmt.Errorf("identity with hint %q is unhealthy: %w", hint, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// healthzFor returns nil if the current SVID of the identity is healthy.
+func (s *SPIFFE) healthzFor(identity *identity) error {
+	s.lock.RLock()
+	renewalErr := identity.lastRenewalErr
+	s.lock.RUnlock()
+
+	id, err := s.CurrentIdentityFor(identity.hint)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	switch {
+	case now.Before(id.NotBefore):
+		err = fmt.Errorf("identity certificate is not valid until %s", id.NotBefore)
+	case !now.Before(id.NotAfter):
+		err = fmt.Errorf("identity certificate expired at %s", id.NotAfter)
+	case id.NotAfter.Sub(now) <= s.healthExpiryThreshold:
+		err = fmt.Errorf("identity certificate expires at %s, within health threshold of %s",
+			id.NotAfter, s.healthExpiryThreshold)
+	default:
+		return nil
+	}
+
+	if renewalErr != nil {
+		err = fmt.Errorf("%w; last renewal error: %w", err, renewalErr)
+	}
+
+	return err
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffe
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/kit/crypto/test"
+	"github.com/dapr/kit/logger"
+)
+
+func Test_Healthz(t *testing.T) {
+	id := spiffeid.RequireFromString("spiffe://example.com/foo/bar")
+	pki := test.GenPKI(t, test.PKIOptions{LeafID: id})
+	leaf := pki.LeafCert
+
+	newSPIFFE := func(threshold time.Duration, now time.Time) (*SPIFFE, *clocktesting.FakeClock) {
+		s := New(Options{
+			Log:                   logger.NewLogger("test"),
+			HealthExpiryThreshold: threshold,
+		})
+		clock := clocktesting.NewFakeClock(now)
+		s.clock = clock
+		return s, clock
+	}
+
+	t.Run("no identity", func(t *testing.T) {
+		s, _ := newSPIFFE(0, time.Now())
+		_, err := s.CurrentIdentity()
+		require.ErrorIs(t, err, ErrNoIdentity)
+		require.ErrorIs(t, s.Healthz(), ErrNoIdentity)
+		assert.True(t, s.ExpiresAt().IsZero())
+	})
+
+	t.Run("valid identity", func(t *testing.T) {
+		s, _ := newSPIFFE(0, leaf.NotBefore.Add(time.Second))
+		s.identities[DefaultHint].currentSVID = &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{leaf}}
+
+		got, err := s.CurrentIdentity()
+		require.NoError(t, err)
+		assert.Equal(t, id, got.ID)
+		assert.Equal(t, leaf.NotAfter, got.NotAfter)
+		assert.Equal(t, leaf.NotAfter, s.ExpiresAt())
+		require.NoError(t, s.Healthz())
+	})
+
+	t.Run("expired identity", func(t *testing.T) {
+		s, _ := newSPIFFE(0, leaf.NotAfter)
+		s.identities[DefaultHint].currentSVID = &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{leaf}}
+		require.Error(t, s.Healthz())
+	})
+
+	t.Run("within expiry threshold includes renewal error", func(t *testing.T) {
+		s, clock := newSPIFFE(time.Minute, leaf.NotBefore.Add(time.Second))
+		s.identities[DefaultHint].currentSVID = &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{leaf}}
+		require.NoError(t, s.Healthz())
+
+		renewalErr := errors.New("renewal error")
+		s.identities[DefaultHint].lastRenewalErr = renewalErr
+		clock.SetTime(leaf.NotAfter.Add(-time.Minute))
+		err := s.Healthz()
+		require.Error(t, err)
+		require.ErrorIs(t, err, renewalErr)
+	})
+
+	t.Run("unhealthy additional identity", func(t *testing.T) {
+		s := New(Options{
+			Log: logger.NewLogger("test"),
+			Identities: map[string]IdentityOptions{
+				"client": {},
+			},
+		})
+		s.clock = clocktesting.NewFakeClock(leaf.NotBefore.Add(time.Second))
+		s.identities[DefaultHint].currentSVID = &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{leaf}}
+
+		err := s.Healthz()
+		require.ErrorIs(t, err, ErrNoIdentity)
+		require.ErrorContains(t, err, `identity with hint "client" is unhealthy`)
+
+		s.identities["client"].currentSVID = &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{leaf}}
+		require.NoError(t, s.Healthz())
+	})
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package insecure contains an in-memory SPIFFE certificate authority, which
+// issues X.509 SVIDs without any attestation of the workloads.
+// It is meant to be used in tests and in development mode only, for example
+// to exercise certificate and trust anchors rotation without Sentry or SPIRE.
+package insecure
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"k8s.io/utils/clock"
+
+	"github.com/dapr/kit/crypto/spiffe/trustanchors"
+)
+
+const (
+	defaultSVIDTTL = 5 * time.Minute
+	defaultRootTTL = 24 * time.Hour
+)
+
+// Options configures a CA.
+type Options struct {
+	// TrustDomain is the trust domain of the CA. Required.
+	TrustDomain spiffeid.TrustDomain
+
+	// SVIDTTL is the validity of the issued SVIDs.
+	// Defaults to 5 minutes.
+	SVIDTTL time.Duration
+
+	// RootTTL is the validity of the root certificates.
+	// Defaults to 24 hours.
+	RootTTL time.Duration
+
+	// Clock is the clock used for the validity of the certificates.
+	// Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// CA is an in-memory SPIFFE certificate authority.
+// It signs SVIDs with its current root, and implements trustanchors.Interface
+// to serve its trust anchors, which contain the current root and any previous
+// root which has not been retired yet.
+type CA struct {
+	trustDomain spiffeid.TrustDomain
+	svidTTL     time.Duration
+	rootTTL     time.Duration
+	clock       clock.Clock
+
+	lock  sync.RWMutex
+	key   *ecdsa.PrivateKey
+	root  *x509.Certificate
+	roots []*x509.Certificate
+	// subs is a list of channels to notify when the trust anchors are updated.
+	subs []chan<- struct{}
+
+	running atomic.Bool
+	closeCh chan struct{}
+}
+
+// New creates a new CA with a newly generated root.
+func New(opts Options) (*CA, error) {
+	if opts.TrustDomain.IsZero() {
+		return nil, errors.New("trust domain is required")
+	}
+
+	c := &CA{
+		trustDomain: opts.TrustDomain,
+		svidTTL:     opts.SVIDTTL,
+		rootTTL:     opts.RootTTL,
+		clock:       opts.Clock,
+		closeCh:     make(chan struct{}),
+	}
+	if c.svidTTL <= 0 {
+		c.svidTTL = defaultSVIDTTL
+	}
+	if c.rootTTL <= 0 {
+		c.rootTTL = defaultRootTTL
+	}
+	if c.clock == nil {
+		c.clock = clock.RealClock{}
+	}
+
+	key, root, err := c.genRoot()
+	if err != nil {
+		return nil, err
+	}
+	c.key = key
+	c.root = root
+	c.roots = []*x509.Certificate{root}
+
+	return c, nil
+}
+
+// Root returns the root certificate currently used to sign SVIDs.
+func (c *CA) Root() *x509.Certificate {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.root
+}
+
+// Rotate generates a new root, which is used to sign SVIDs from now on.
+// The previous roots are kept in the trust anchors, so SVIDs they signed are
+// still trusted until they are removed with RetirePreviousRoots.
+func (c *CA) Rotate() error {
+	key, root, err := c.genRoot()
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.key = key
+	c.root = root
+	c.roots = append(c.roots, root)
+	c.notify()
+	return nil
+}
+
+// RetirePreviousRoots removes all roots but the current one from the trust
+// anchors.
+func (c *CA) RetirePreviousRoots() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.roots) == 1 {
+		return
+	}
+	c.roots = []*x509.Certificate{c.root}
+	c.notify()
+}
+
+// RequestSVIDFn returns a function which signs certificate signing requests
+// with the given SPIFFE ID, which must be in the trust domain of the CA. It
+// can be used as the spiffe.RequestSVIDFn of a workload.
+// The DNS names in the request are included in the SVIDs.
+func (c *CA) RequestSVIDFn(id spiffeid.ID) func(context.Context, []byte) ([]*x509.Certificate, error) {
+	return func(_ context.Context, csrDER []byte) ([]*x509.Certificate, error) {
+		if !id.MemberOf(c.trustDomain) {
+			return nil, fmt.Errorf("SPIFFE ID %q is not a member of trust domain %q", id, c.trustDomain)
+		}
+
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate signing request: %w", err)
+		}
+		if err = csr.CheckSignature(); err != nil {
+			return nil, fmt.Errorf("invalid certificate signing request signature: %w", err)
+		}
+
+		serial, err := newSerial()
+		if err != nil {
+			return nil, err
+		}
+
+		now := c.clock.Now()
+		tmpl := &x509.Certificate{
+			SerialNumber: serial,
+			NotBefore:    now,
+			NotAfter:     now.Add(c.svidTTL),
+			KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			ExtKeyUsage: []x509.ExtKeyUsage{
+				x509.ExtKeyUsageServerAuth,
+				x509.ExtKeyUsageClientAuth,
+			},
+			URIs:     []*url.URL{id.URL()},
+			DNSNames: csr.DNSNames,
+		}
+
+		c.lock.RLock()
+		key, root := c.key, c.root
+		c.lock.RUnlock()
+
+		certDER, err := x509.CreateCertificate(rand.Reader, tmpl, root, csr.PublicKey, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign SVID: %w", err)
+		}
+		cert, err := x509.ParseCertificate(certDER)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*x509.Certificate{cert}, nil
+	}
+}
+
+// CurrentTrustAnchors returns the PEM bundle of the roots of the CA.
+func (c *CA) CurrentTrustAnchors(context.Context) ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var anchors []byte
+	for _, root := range c.roots {
+		anchors = append(anchors, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})...)
+	}
+	return anchors, nil
+}
+
+// GetX509BundleForTrustDomain returns the X.509 bundle of the roots of the
+// CA, if td is its trust domain.
+func (c *CA) GetX509BundleForTrustDomain(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	if td != c.trustDomain {
+		return nil, fmt.Errorf("%w: %s", trustanchors.ErrTrustDomainNotFound, td)
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return x509bundle.FromX509Authorities(c.trustDomain, c.roots), nil
+}
+
+// GetJWTBundleForTrustDomain always returns trustanchors.ErrNoJWTBundle, as
+// the CA doesn't issue JWT SVIDs.
+func (c *CA) GetJWTBundleForTrustDomain(spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	return nil, trustanchors.ErrNoJWTBundle
+}
+
+// Run blocks until ctx is canceled. Watchers are stopped when it returns.
+func (c *CA) Run(ctx context.Context) error {
+	if !c.running.CompareAndSwap(false, true) {
+		return errors.New("trust anchors source is already running")
+	}
+	<-ctx.Done()
+	close(c.closeCh)
+	return nil
+}
+
+// Watch sends the PEM bundle of the roots of the CA to ch each time they
+// change, until ctx is canceled or the CA stops running.
+func (c *CA) Watch(ctx context.Context, ch chan<- []byte) {
+	c.lock.Lock()
+	sub := make(chan struct{}, 5)
+	c.subs = append(c.subs, sub)
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.subs = slices.DeleteFunc(c.subs, func(s chan<- struct{}) bool {
+			return s == sub
+		})
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.closeCh:
+			return
+		case <-sub:
+			anchors, err := c.CurrentTrustAnchors(ctx)
+			if err != nil {
+				continue
+			}
+
+			select {
+			case ch <- anchors:
+			case <-ctx.Done():
+			case <-c.closeCh:
+			}
+		}
+	}
+}
+
+// notify notifies the watchers that the trust anchors have changed.
+// The caller must hold the lock.
+func (c *CA) notify() {
+	for _, sub := range c.subs {
+		select {
+		case sub <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// genRoot generates a new root key and self-signed certificate.
+func (c *CA) genRoot() (*ecdsa.PrivateKey, *x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate root key: %w", err)
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := c.clock.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Dapr Insecure Root CA", SerialNumber: serial.String()},
+		NotBefore:             now,
+		NotAfter:              now.Add(c.rootTTL),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{c.trustDomain.ID().URL()},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create root certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, cert, nil
+}
+
+func newSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+var _ trustanchors.Interface = (*CA)(nil)
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package insecure
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/crypto/pem"
+	"github.com/dapr/kit/crypto/spiffe"
+	"github.com/dapr/kit/crypto/spiffe/trustanchors"
+	"github.com/dapr/kit/logger"
+)
+
+var (
+	testTD = spiffeid.RequireTrustDomainFromString("example.org")
+	testID = spiffeid.RequireFromString("spiffe://example.org/ns/default/app")
+)
+
+func requestSVID(t *testing.T, ca *CA, id spiffeid.ID) []*x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{"app.default.svc"},
+	}, key)
+	require.NoError(t, err)
+
+	certs, err := ca.RequestSVIDFn(id)(context.Background(), csr)
+	require.NoError(t, err)
+	return certs
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{})
+	require.Error(t, err)
+
+	ca, err := New(Options{TrustDomain: testTD})
+	require.NoError(t, err)
+	root := ca.Root()
+	assert.True(t, root.IsCA)
+	assert.Equal(t, defaultRootTTL, root.NotAfter.Sub(root.NotBefore))
+}
+
+func TestRequestSVIDFn(t *testing.T) {
+	ca, err := New(Options{TrustDomain: testTD, SVIDTTL: time.Minute})
+	require.NoError(t, err)
+
+	t.Run("issues SVIDs", func(t *testing.T) {
+		certs := requestSVID(t, ca, testID)
+		require.Len(t, certs, 1)
+		assert.Equal(t, time.Minute, certs[0].NotAfter.Sub(certs[0].NotBefore))
+		assert.Equal(t, []string{"app.default.svc"}, certs[0].DNSNames)
+
+		id, _, err := x509svid.Verify(certs, ca)
+		require.NoError(t, err)
+		assert.Equal(t, testID, id)
+	})
+
+	t.Run("rejects IDs of other trust domains", func(t *testing.T) {
+		_, err := ca.RequestSVIDFn(spiffeid.RequireFromString("spiffe://other.org/app"))(context.Background(), nil)
+		require.Error(t, err)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		_, err := ca.RequestSVIDFn(testID)(context.Background(), []byte("invalid"))
+		require.Error(t, err)
+	})
+
+	t.Run("other trust domains are not found", func(t *testing.T) {
+		_, err := ca.GetX509BundleForTrustDomain(spiffeid.RequireTrustDomainFromString("other.org"))
+		require.ErrorIs(t, err, trustanchors.ErrTrustDomainNotFound)
+		_, err = ca.GetJWTBundleForTrustDomain(testTD)
+		require.ErrorIs(t, err, trustanchors.ErrNoJWTBundle)
+	})
+}
+
+func TestRotate(t *testing.T) {
+	ca, err := New(Options{TrustDomain: testTD})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ca.Run(ctx)
+	}()
+	watchCh := make(chan []byte)
+	go ca.Watch(ctx, watchCh)
+
+	oldSVID := requestSVID(t, ca, testID)
+	oldRoot := ca.Root()
+
+	// Wait for the watcher to be registered
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		ca.lock.RLock()
+		defer ca.lock.RUnlock()
+		assert.Len(c, ca.subs, 1)
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, ca.Rotate())
+	assert.NotEqual(t, oldRoot, ca.Root())
+
+	select {
+	case anchors := <-watchCh:
+		certs, err := pem.DecodePEMCertificates(anchors)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{oldRoot, ca.Root()}, certs)
+	case <-time.After(time.Second):
+		t.Fatal("trust anchors were not sent to the watcher")
+	}
+
+	// SVIDs issued by both roots are trusted
+	newSVID := requestSVID(t, ca, testID)
+	require.NoError(t, newSVID[0].CheckSignatureFrom(ca.Root()))
+	_, _, err = x509svid.Verify(oldSVID, ca)
+	require.NoError(t, err)
+	_, _, err = x509svid.Verify(newSVID, ca)
+	require.NoError(t, err)
+
+	ca.RetirePreviousRoots()
+	select {
+	case anchors := <-watchCh:
+		certs, err := pem.DecodePEMCertificates(anchors)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{ca.Root()}, certs)
+	case <-time.After(time.Second):
+		t.Fatal("trust anchors were not sent to the watcher")
+	}
+
+	_, _, err = x509svid.Verify(oldSVID, ca)
+	require.Error(t, err)
+	_, _, err = x509svid.Verify(newSVID, ca)
+	require.NoError(t, err)
+
+	cancel()
+	require.NoError(t, <-errCh)
+
+	// The watcher is removed once its context is done
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		ca.lock.RLock()
+		defer ca.lock.RUnlock()
+		assert.Empty(c, ca.subs)
+	}, time.Second, time.Millisecond)
+}
+
+func TestWithSPIFFE(t *testing.T) {
+	ca, err := New(Options{TrustDomain: testTD})
+	require.NoError(t, err)
+
+	s := spiffe.New(spiffe.Options{
+		Log:           logger.NewLogger("test"),
+		RequestSVIDFn: ca.RequestSVIDFn(testID),
+		TrustAnchors:  ca,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Run(ctx)
+	}()
+
+	readyCtx, readyCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer readyCancel()
+	require.NoError(t, s.Ready(readyCtx))
+
+	svid, err := s.SVIDSource().GetX509SVID()
+	require.NoError(t, err)
+	assert.Equal(t, testID, svid.ID)
+	_, _, err = x509svid.Verify(svid.Certificates, ca)
+	require.NoError(t, err)
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffe
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+)
+
+// KeyType is the type of the private key generated for an identity.
+type KeyType string
+
+const (
+	// KeyTypeP256 is an ECDSA key using the P-256 curve.
+	KeyTypeP256 KeyType = "P-256"
+	// KeyTypeP384 is an ECDSA key using the P-384 curve.
+	KeyTypeP384 KeyType = "P-384"
+	// KeyTypeEd25519 is an Ed25519 key.
+	KeyTypeEd25519 KeyType = "Ed25519"
+	// KeyTypeRSA2048 is a 2048-bit RSA key.
+	KeyTypeRSA2048 KeyType = "RSA-2048"
+	// KeyTypeRSA4096 is a 4096-bit RSA key.
+	KeyTypeRSA4096 KeyType = "RSA-4096"
+)
+
+// generateKey generates a new private key of the given type.
+func (k KeyType) generateKey() (crypto.Signer, error) {
+	switch k {
+	case KeyTypeP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyTypeEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k)
+	}
+}
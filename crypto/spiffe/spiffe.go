@@ -15,29 +15,47 @@ package spiffe
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"maps"
+	mathrand "math/rand/v2"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"k8s.io/utils/clock"
 
+	"github.com/dapr/kit/concurrency"
 	"github.com/dapr/kit/concurrency/dir"
 	"github.com/dapr/kit/crypto/pem"
 	"github.com/dapr/kit/crypto/spiffe/trustanchors"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 type (
 	RequestSVIDFn func(context.Context, []byte) ([]*x509.Certificate, error)
 )
 
+// DefaultHint is the hint of the default identity, which is configured by the
+// top-level Options.
+const DefaultHint = ""
+
+const (
+	// defaultRenewalThreshold is the fraction of the validity period of an
+	// SVID after which it's renewed, when not configured.
+	defaultRenewalThreshold = 0.5
+
+	// defaultRenewalRetryInterval is the interval between retries of failed
+	// renewals, when not configured.
+	defaultRenewalRetryInterval = 10 * time.Second
+)
+
 type Options struct {
 	Log           logger.Logger
 	RequestSVIDFn RequestSVIDFn
@@ -49,17 +67,85 @@ type Options struct {
 	WriteIdentityToFile *string
 
 	TrustAnchors trustanchors.Interface
+
+	// HealthExpiryThreshold is the duration before the SVID expires from
+	// which Healthz reports the identity as unhealthy. Defaults to 0, in which
+	// case the identity is only unhealthy once expired.
+	HealthExpiryThreshold time.Duration
+
+	// KeyType is the type of the private key generated for each identity.
+	// Defaults to KeyTypeP256.
+	KeyType KeyType
+
+	// CSRTemplateFn is an optional function which customizes the template of
+	// the certificate signing request sent to RequestSVIDFn, for example to
+	// add DNS names or other SANs required by the CA.
+	CSRTemplateFn func(csr *x509.CertificateRequest) error
+
+	// Identities are additional identities managed alongside the default one,
+	// keyed by a hint, such as separate server and client SVIDs, or per-app
+	// identities. Each identity is fetched and rotated independently, and can
+	// be accessed with X509SVIDSourceFor.
+	// The DefaultHint key is reserved for the default identity, and is ignored.
+	Identities map[string]IdentityOptions
+
+	// RenewalThreshold is the fraction of the validity period of an SVID
+	// after which it's renewed, greater than 0 and less than 1. For example,
+	// with 0.5 an SVID valid for 1 hour is renewed after 30 minutes.
+	// Defaults to 0.5.
+	RenewalThreshold float64
+
+	// RenewalJitter is the maximum fraction of the validity period of an SVID
+	// by which its renewal is randomly brought forward, so the renewals of
+	// workloads whose SVIDs were issued at the same time are spread out.
+	// Defaults to 0 (no jitter).
+	RenewalJitter float64
+
+	// RenewalRetry is the backoff configuration used to retry failed renewals.
+	// Renewals are retried until they succeed: once the backoff is exhausted,
+	// for example because MaxRetries is reached, it starts over.
+	// Defaults to a constant backoff of 10 seconds.
+	RenewalRetry *retry.Config
+
+	// RenewalErrorFn is an optional function invoked with the hint of the
+	// identity and the error each time the renewal of an SVID fails.
+	// It's invoked synchronously, and should not block.
+	RenewalErrorFn func(hint string, err error)
+}
+
+// IdentityOptions configures an additional identity managed by SPIFFE.
+type IdentityOptions struct {
+	// RequestSVIDFn is the function used to request the SVID of the identity.
+	RequestSVIDFn RequestSVIDFn
+
+	// WriteIdentityToFile is an optional directory the identity private key
+	// and certificate chain are written to. See Options.WriteIdentityToFile.
+	WriteIdentityToFile *string
+
+	// CSRTemplateFn is an optional function which customizes the template of
+	// the certificate signing request sent to RequestSVIDFn.
+	CSRTemplateFn func(csr *x509.CertificateRequest) error
 }
 
-// SPIFFE is a readable/writeable store of a SPIFFE X.509 SVID.
-// Used to manage a workload SVID, and share read-only interfaces to consumers.
+// SPIFFE is a readable/writeable store of SPIFFE X.509 SVIDs.
+// Used to manage the workload SVIDs, and share read-only interfaces to
+// consumers.
 type SPIFFE struct {
-	currentSVID   *x509svid.SVID
-	requestSVIDFn RequestSVIDFn
+	// identities contains the managed identities, keyed by hint.
+	// The map is never modified after New.
+	identities map[string]*identity
 
-	dir          *dir.Dir
 	trustAnchors trustanchors.Interface
 
+	healthExpiryThreshold time.Duration
+
+	keyType KeyType
+
+	renewalThreshold float64
+	renewalJitter    float64
+	renewalRetry     retry.Config
+	renewalErrorFn   func(hint string, err error)
+
 	log     logger.Logger
 	lock    sync.RWMutex
 	clock   clock.Clock
@@ -67,22 +153,83 @@ type SPIFFE struct {
 	readyCh chan struct{}
 }
 
+// identity is an SVID managed by SPIFFE.
+type identity struct {
+	hint          string
+	requestSVIDFn RequestSVIDFn
+	csrTemplateFn func(csr *x509.CertificateRequest) error
+	dir           *dir.Dir
+
+	// Protected by the SPIFFE lock
+	currentSVID    *x509svid.SVID
+	lastRenewalErr error
+}
+
 func New(opts Options) *SPIFFE {
+	keyType := opts.KeyType
+	if keyType == "" {
+		keyType = KeyTypeP256
+	}
+
+	renewalThreshold := opts.RenewalThreshold
+	if renewalThreshold <= 0 || renewalThreshold >= 1 {
+		renewalThreshold = defaultRenewalThreshold
+	}
+
+	renewalRetry := retry.DefaultConfig()
+	renewalRetry.Duration = defaultRenewalRetryInterval
+	if opts.RenewalRetry != nil {
+		renewalRetry = *opts.RenewalRetry
+	}
+
+	s := &SPIFFE{
+		identities:   make(map[string]*identity, len(opts.Identities)+1),
+		trustAnchors: opts.TrustAnchors,
+
+		healthExpiryThreshold: opts.HealthExpiryThreshold,
+
+		keyType: keyType,
+
+		renewalThreshold: renewalThreshold,
+		renewalJitter:    max(opts.RenewalJitter, 0),
+		renewalRetry:     renewalRetry,
+		renewalErrorFn:   opts.RenewalErrorFn,
+
+		log:     opts.Log,
+		clock:   clock.RealClock{},
+		readyCh: make(chan struct{}),
+	}
+
+	s.identities[DefaultHint] = s.newIdentity(DefaultHint, IdentityOptions{
+		RequestSVIDFn:       opts.RequestSVIDFn,
+		WriteIdentityToFile: opts.WriteIdentityToFile,
+		CSRTemplateFn:       opts.CSRTemplateFn,
+	})
+	for hint, idOpts := range opts.Identities {
+		if hint == DefaultHint {
+			s.log.Warn("Ignoring additional identity with the default hint")
+			continue
+		}
+		s.identities[hint] = s.newIdentity(hint, idOpts)
+	}
+
+	return s
+}
+
+func (s *SPIFFE) newIdentity(hint string, opts IdentityOptions) *identity {
 	var sdir *dir.Dir
 	if opts.WriteIdentityToFile != nil {
 		sdir = dir.New(dir.Options{
-			Log:    opts.Log,
+			Log:    s.log,
 			Target: *opts.WriteIdentityToFile,
 		})
 	}
 
-	return &SPIFFE{
+	return &identity{
+		hint:          hint,
 		requestSVIDFn: opts.RequestSVIDFn,
+		csrTemplateFn: opts.CSRTemplateFn,
 		dir:           sdir,
-		trustAnchors:  opts.TrustAnchors,
-		log:           opts.Log,
-		clock:         clock.RealClock{},
-		readyCh:       make(chan struct{}),
 	}
 }
 
@@ -91,21 +238,35 @@ func (s *SPIFFE) Run(ctx context.Context) error {
 		return errors.New("already running")
 	}
 
+	// Fetch the identities in a consistent order, starting from the default one
+	hints := slices.Sorted(maps.Keys(s.identities))
+
 	s.lock.Lock()
-	s.log.Info("Fetching initial identity certificate")
-	initialCert, err := s.fetchIdentityCertificate(ctx)
-	if err != nil {
-		close(s.readyCh)
-		s.lock.Unlock()
-		return fmt.Errorf("failed to retrieve the initial identity certificate: %w", err)
+	for _, hint := range hints {
+		id := s.identities[hint]
+		s.log.Info("Fetching initial identity certificate" + id.logSuffix())
+		initialCert, err := s.fetchIdentityCertificate(ctx, id)
+		if err != nil {
+			close(s.readyCh)
+			s.lock.Unlock()
+			return fmt.Errorf("failed to retrieve the initial identity certificate%s: %w", id.logSuffix(), err)
+		}
+		id.currentSVID = initialCert
 	}
-
-	s.currentSVID = initialCert
 	close(s.readyCh)
 	s.lock.Unlock()
 
 	s.log.Infof("Security is initialized successfully")
-	s.runRotation(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(len(hints))
+	for _, hint := range hints {
+		go func(id *identity) {
+			defer wg.Done()
+			s.runRotation(ctx, id)
+		}(s.identities[hint])
+	}
+	wg.Wait()
 
 	return nil
 }
@@ -121,17 +282,21 @@ func (s *SPIFFE) Ready(ctx context.Context) error {
 	}
 }
 
-// runRotation starts up the manager responsible for renewing the workload
-// certificate. Receives the initial certificate to calculate the next rotation
+// runRotation starts up the manager responsible for renewing the certificate
+// of an identity. Uses the initial certificate to calculate the next rotation
 // time.
-func (s *SPIFFE) runRotation(ctx context.Context) {
-	defer s.log.Debug("stopping workload cert expiry watcher")
+func (s *SPIFFE) runRotation(ctx context.Context, id *identity) {
+	defer s.log.Debug("stopping workload cert expiry watcher" + id.logSuffix())
 	s.lock.RLock()
-	cert := s.currentSVID.Certificates[0]
+	cert := id.currentSVID.Certificates[0]
 	s.lock.RUnlock()
-	renewTime := renewalTime(cert.NotBefore, cert.NotAfter)
-	s.log.Infof("Starting workload cert expiry watcher; current cert expires on: %s, renewing at %s",
-		cert.NotAfter.String(), renewTime.String())
+	renewTime := s.renewalTime(cert.NotBefore, cert.NotAfter)
+	s.log.Infof("Starting workload cert expiry watcher%s; current cert expires on: %s, renewing at %s",
+		id.logSuffix(), cert.NotAfter.String(), renewTime.String())
+
+	// retryBackOff is the backoff of the retries of the current renewal, if it
+	// failed
+	var retryBackOff backoff.BackOff
 
 	for {
 		select {
@@ -139,23 +304,38 @@ func (s *SPIFFE) runRotation(ctx context.Context) {
 			if s.clock.Now().Before(renewTime) {
 				continue
 			}
-			s.log.Infof("Renewing workload cert; current cert expires on: %s", cert.NotAfter.String())
-			svid, err := s.fetchIdentityCertificate(ctx)
+			s.log.Infof("Renewing workload cert%s; current cert expires on: %s", id.logSuffix(), cert.NotAfter.String())
+			svid, err := s.fetchIdentityCertificate(ctx, id)
 			if err != nil {
-				s.log.Errorf("Error renewing identity certificate, trying again in 10 seconds: %s", err)
-				select {
-				case <-s.clock.After(10 * time.Second):
-					continue
-				case <-ctx.Done():
+				if retryBackOff == nil {
+					retryBackOff = s.renewalRetry.NewBackOff()
+				}
+				next := retryBackOff.NextBackOff()
+				if next == backoff.Stop {
+					retryBackOff.Reset()
+					next = retryBackOff.NextBackOff()
+				}
+
+				s.log.Errorf("Error renewing identity certificate%s, trying again in %s: %s", id.logSuffix(), next, err)
+				s.lock.Lock()
+				id.lastRenewalErr = err
+				s.lock.Unlock()
+				if s.renewalErrorFn != nil {
+					s.renewalErrorFn(id.hint, err)
+				}
+				if concurrency.SleepCtx(ctx, s.clock, next) != nil {
 					return
 				}
+				continue
 			}
+			retryBackOff = nil
 			s.lock.Lock()
-			s.currentSVID = svid
+			id.currentSVID = svid
+			id.lastRenewalErr = nil
 			cert = svid.Certificates[0]
 			s.lock.Unlock()
-			renewTime = renewalTime(cert.NotBefore, cert.NotAfter)
-			s.log.Infof("Successfully renewed workload cert; new cert expires on: %s", cert.NotAfter.String())
+			renewTime = s.renewalTime(cert.NotBefore, cert.NotAfter)
+			s.log.Infof("Successfully renewed workload cert%s; new cert expires on: %s", id.logSuffix(), cert.NotAfter.String())
 
 		case <-ctx.Done():
 			return
@@ -163,19 +343,27 @@ func (s *SPIFFE) runRotation(ctx context.Context) {
 	}
 }
 
-// fetchIdentityCertificate fetches a new SVID using the configured requester.
-func (s *SPIFFE) fetchIdentityCertificate(ctx context.Context) (*x509svid.SVID, error) {
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+// fetchIdentityCertificate fetches a new SVID for the identity using its
+// configured requester.
+func (s *SPIFFE) fetchIdentityCertificate(ctx context.Context, id *identity) (*x509svid.SVID, error) {
+	key, err := s.keyType.generateKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	csrDER, err := x509.CreateCertificateRequest(rand.Reader, new(x509.CertificateRequest), key)
+	csr := new(x509.CertificateRequest)
+	if id.csrTemplateFn != nil {
+		if err = id.csrTemplateFn(csr); err != nil {
+			return nil, fmt.Errorf("failed to customize sidecar csr: %w", err)
+		}
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, csr, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sidecar csr: %w", err)
 	}
 
-	workloadcert, err := s.requestSVIDFn(ctx, csrDER)
+	workloadcert, err := id.requestSVIDFn(ctx, csrDER)
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +377,7 @@ func (s *SPIFFE) fetchIdentityCertificate(ctx context.Context) (*x509svid.SVID,
 		return nil, fmt.Errorf("error parsing spiffe id from newly signed certificate: %w", err)
 	}
 
-	if s.dir != nil {
+	if id.dir != nil {
 		pkPEM, err := pem.EncodePrivateKey(key)
 		if err != nil {
 			return nil, err
@@ -205,7 +393,7 @@ func (s *SPIFFE) fetchIdentityCertificate(ctx context.Context) (*x509svid.SVID,
 			return nil, err
 		}
 
-		if err := s.dir.Write(map[string][]byte{
+		if err := id.dir.Write(map[string][]byte{
 			"key.pem":  pkPEM,
 			"cert.pem": certPEM,
 			"ca.pem":   td,
@@ -221,11 +409,40 @@ func (s *SPIFFE) fetchIdentityCertificate(ctx context.Context) (*x509svid.SVID,
 	}, nil
 }
 
+// SVIDSource returns a source of the default identity.
 func (s *SPIFFE) SVIDSource() x509svid.Source {
-	return &svidSource{spiffe: s}
+	return s.X509SVIDSourceFor(DefaultHint)
 }
 
-// renewalTime is 50% through the certificate validity period.
-func renewalTime(notBefore, notAfter time.Time) time.Time {
-	return notBefore.Add(notAfter.Sub(notBefore) / 2)
+// X509SVIDSourceFor returns a source of the identity with the given hint.
+// The source returns an error if there's no identity with that hint.
+func (s *SPIFFE) X509SVIDSourceFor(hint string) x509svid.Source {
+	return &svidSource{spiffe: s, hint: hint}
+}
+
+// Hints returns the hints of all the managed identities, sorted, including
+// DefaultHint.
+func (s *SPIFFE) Hints() []string {
+	return slices.Sorted(maps.Keys(s.identities))
+}
+
+// logSuffix returns the suffix added to log messages and errors about the
+// identity, which is empty for the default identity.
+func (i *identity) logSuffix() string {
+	if i.hint == DefaultHint {
+		return ""
+	}
+	return fmt.Sprintf(" for hint %q", i.hint)
+}
+
+// renewalTime is RenewalThreshold through the certificate validity period,
+// brought forward by a random jitter of up to RenewalJitter of the period.
+func (s *SPIFFE) renewalTime(notBefore, notAfter time.Time) time.Time {
+	validity := notAfter.Sub(notBefore)
+	renewAfter := time.Duration(float64(validity) * s.renewalThreshold)
+	if s.renewalJitter > 0 {
+		//nolint:gosec
+		renewAfter -= time.Duration(mathrand.Float64() * s.renewalJitter * float64(validity))
+	}
+	return notBefore.Add(max(renewAfter, 0))
 }
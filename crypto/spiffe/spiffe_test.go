@@ -15,8 +15,15 @@ package spiffe
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -26,17 +33,65 @@ import (
 	"github.com/stretchr/testify/require"
 	clocktesting "k8s.io/utils/clock/testing"
 
+	"github.com/dapr/kit/crypto/pem"
+	"github.com/dapr/kit/crypto/spiffe/trustanchors"
 	"github.com/dapr/kit/crypto/test"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 func Test_renewalTime(t *testing.T) {
 	now := time.Now()
-	assert.Equal(t, now, renewalTime(now, now))
-
 	in1Min := now.Add(time.Minute)
-	in30 := now.Add(time.Second * 30)
-	assert.Equal(t, in30, renewalTime(now, in1Min))
+
+	t.Run("defaults to 50% of the validity period", func(t *testing.T) {
+		s := New(Options{Log: logger.NewLogger("test")})
+		assert.Equal(t, now, s.renewalTime(now, now))
+
+		in30 := now.Add(time.Second * 30)
+		assert.Equal(t, in30, s.renewalTime(now, in1Min))
+	})
+
+	t.Run("uses the configured threshold", func(t *testing.T) {
+		s := New(Options{
+			Log:              logger.NewLogger("test"),
+			RenewalThreshold: 0.75,
+		})
+		assert.Equal(t, now.Add(time.Second*45), s.renewalTime(now, in1Min))
+	})
+
+	t.Run("invalid thresholds fall back to the default", func(t *testing.T) {
+		for _, threshold := range []float64{-1, 1, 2} {
+			s := New(Options{
+				Log:              logger.NewLogger("test"),
+				RenewalThreshold: threshold,
+			})
+			assert.Equal(t, now.Add(time.Second*30), s.renewalTime(now, in1Min))
+		}
+	})
+
+	t.Run("jitter brings the renewal forward", func(t *testing.T) {
+		s := New(Options{
+			Log:           logger.NewLogger("test"),
+			RenewalJitter: 0.1,
+		})
+		for range 100 {
+			renewTime := s.renewalTime(now, in1Min)
+			assert.False(t, renewTime.After(now.Add(time.Second*30)))
+			assert.False(t, renewTime.Before(now.Add(time.Second*24)))
+		}
+	})
+
+	t.Run("jitter never brings the renewal before the start of the validity period", func(t *testing.T) {
+		s := New(Options{
+			Log:              logger.NewLogger("test"),
+			RenewalThreshold: 0.1,
+			RenewalJitter:    1,
+		})
+		for range 100 {
+			assert.False(t, s.renewalTime(now, in1Min).Before(now))
+		}
+	})
 }
 
 func Test_Run(t *testing.T) {
@@ -196,3 +251,271 @@ func Test_Run(t *testing.T) {
 		}
 	})
 }
+
+func Test_RunRenewalRetry(t *testing.T) {
+	pki := test.GenPKI(t, test.PKIOptions{
+		LeafID: spiffeid.RequireFromString("spiffe://example.com/foo/bar"),
+	})
+
+	var (
+		lock    sync.Mutex
+		respErr error
+		fetches atomic.Int32
+	)
+	renewalErrCh := make(chan error, 10)
+	s := New(Options{
+		Log: logger.NewLogger("test"),
+		RequestSVIDFn: func(context.Context, []byte) ([]*x509.Certificate, error) {
+			fetches.Add(1)
+			lock.Lock()
+			defer lock.Unlock()
+			if respErr != nil {
+				return nil, respErr
+			}
+			return []*x509.Certificate{pki.LeafCert}, nil
+		},
+		RenewalRetry: &retry.Config{
+			Policy:   retry.PolicyConstant,
+			Duration: time.Second,
+			// The backoff starts over once exhausted
+			MaxRetries: 1,
+		},
+		RenewalErrorFn: func(hint string, err error) {
+			assert.Equal(t, DefaultHint, hint)
+			renewalErrCh <- err
+		},
+	})
+	now := time.Now()
+	clock := clocktesting.NewFakeClock(now)
+	s.clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- s.Run(ctx)
+	}()
+
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	fetchErr := errors.New("this is an error")
+	lock.Lock()
+	respErr = fetchErr
+	lock.Unlock()
+	clock.Step(pki.LeafCert.NotAfter.Sub(now) / 2)
+
+	for i := int32(2); i <= 4; i++ {
+		select {
+		case err := <-renewalErrCh:
+			require.ErrorIs(t, err, fetchErr)
+		case <-time.After(time.Second):
+			require.Fail(t, "expected renewal error to be reported")
+		}
+		assert.Equal(t, i, fetches.Load())
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second)
+		// The renewal time has passed, so the loop retries on the next tick
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(1)
+	}
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, int32(5), fetches.Load())
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "Run should have returned and returned no error")
+	}
+}
+
+func Test_RunIdentities(t *testing.T) {
+	defaultPKI := test.GenPKI(t, test.PKIOptions{
+		LeafID: spiffeid.RequireFromString("spiffe://example.com/foo/bar"),
+	})
+	clientPKI := test.GenPKI(t, test.PKIOptions{
+		LeafID: spiffeid.RequireFromString("spiffe://example.com/foo/client"),
+	})
+
+	// The renewed channels are closed once the identity has been renewed the
+	// first time.
+	var defaultFetches, clientFetches atomic.Int32
+	defaultRenewed, clientRenewed := make(chan struct{}), make(chan struct{})
+	s := New(Options{
+		Log: logger.NewLogger("test"),
+		RequestSVIDFn: func(context.Context, []byte) ([]*x509.Certificate, error) {
+			if defaultFetches.Add(1) == 2 {
+				close(defaultRenewed)
+			}
+			return []*x509.Certificate{defaultPKI.LeafCert}, nil
+		},
+		Identities: map[string]IdentityOptions{
+			"client": {
+				RequestSVIDFn: func(context.Context, []byte) ([]*x509.Certificate, error) {
+					if clientFetches.Add(1) == 2 {
+						close(clientRenewed)
+					}
+					return []*x509.Certificate{clientPKI.LeafCert}, nil
+				},
+			},
+		},
+	})
+	now := time.Now()
+	clock := clocktesting.NewFakeClock(now)
+	s.clock = clock
+
+	assert.Equal(t, []string{DefaultHint, "client"}, s.Hints())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- s.Run(ctx)
+	}()
+	require.NoError(t, s.Ready(ctx))
+
+	svid, err := s.SVIDSource().GetX509SVID()
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://example.com/foo/bar", svid.ID.String())
+
+	svid, err = s.X509SVIDSourceFor("client").GetX509SVID()
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://example.com/foo/client", svid.ID.String())
+
+	id, err := s.CurrentIdentityFor("client")
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://example.com/foo/client", id.ID.String())
+
+	_, err = s.X509SVIDSourceFor("unknown").GetX509SVID()
+	require.ErrorContains(t, err, `no identity with hint "unknown"`)
+	_, err = s.CurrentIdentityFor("unknown")
+	require.ErrorIs(t, err, ErrNoIdentity)
+
+	// Each identity is renewed by its own watcher
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), defaultFetches.Load())
+	assert.Equal(t, int32(1), clientFetches.Load())
+
+	// Since the renewed certificates are the same, watchers keep renewing them
+	// after this, so only wait for the first renewal of each identity.
+	clock.Step(clientPKI.LeafCert.NotAfter.Sub(now) / 2)
+	timeout := time.After(5 * time.Second)
+	for _, renewed := range []<-chan struct{}{defaultRenewed, clientRenewed} {
+		for waiting := true; waiting; {
+			select {
+			case <-renewed:
+				waiting = false
+			case <-time.After(10 * time.Millisecond):
+				// Fire the timers of watchers which started waiting after the step
+				clock.Step(0)
+			case <-timeout:
+				require.Fail(t, "identities were not renewed")
+			}
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "Run should have returned and returned no error")
+	}
+}
+
+func Test_fetchIdentityCertificate(t *testing.T) {
+	pki := test.GenPKI(t, test.PKIOptions{
+		LeafID: spiffeid.RequireFromString("spiffe://example.com/foo/bar"),
+	})
+
+	tests := map[string]struct {
+		keyType   KeyType
+		assertKey func(t *testing.T, key any)
+	}{
+		"default": {
+			assertKey: func(t *testing.T, key any) {
+				require.IsType(t, &ecdsa.PublicKey{}, key)
+				assert.Equal(t, elliptic.P256(), key.(*ecdsa.PublicKey).Curve)
+			},
+		},
+		"P-384": {
+			keyType: KeyTypeP384,
+			assertKey: func(t *testing.T, key any) {
+				require.IsType(t, &ecdsa.PublicKey{}, key)
+				assert.Equal(t, elliptic.P384(), key.(*ecdsa.PublicKey).Curve)
+			},
+		},
+		"Ed25519": {
+			keyType: KeyTypeEd25519,
+			assertKey: func(t *testing.T, key any) {
+				assert.IsType(t, ed25519.PublicKey{}, key)
+			},
+		},
+		"RSA-2048": {
+			keyType: KeyTypeRSA2048,
+			assertKey: func(t *testing.T, key any) {
+				require.IsType(t, &rsa.PublicKey{}, key)
+				assert.Equal(t, 2048, key.(*rsa.PublicKey).N.BitLen())
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "identity")
+			ta, err := trustanchors.FromStatic(pki.RootCertPEM)
+			require.NoError(t, err)
+			s := New(Options{
+				Log: logger.NewLogger("test"),
+				RequestSVIDFn: func(_ context.Context, csrDER []byte) ([]*x509.Certificate, error) {
+					csr, err := x509.ParseCertificateRequest(csrDER)
+					require.NoError(t, err)
+					require.NoError(t, csr.CheckSignature())
+					tc.assertKey(t, csr.PublicKey)
+					assert.Equal(t, []string{"foo.example.com"}, csr.DNSNames)
+					return []*x509.Certificate{pki.LeafCert}, nil
+				},
+				WriteIdentityToFile: &dir,
+				TrustAnchors:        ta,
+				KeyType:             tc.keyType,
+				CSRTemplateFn: func(csr *x509.CertificateRequest) error {
+					csr.DNSNames = []string{"foo.example.com"}
+					return nil
+				},
+			})
+
+			svid, err := s.fetchIdentityCertificate(context.Background(), s.identities[DefaultHint])
+			require.NoError(t, err)
+			tc.assertKey(t, svid.PrivateKey.Public())
+
+			keyPEM, err := os.ReadFile(filepath.Join(dir, "key.pem"))
+			require.NoError(t, err)
+			key, err := pem.DecodePEMPrivateKey(keyPEM)
+			require.NoError(t, err)
+			tc.assertKey(t, key.Public())
+		})
+	}
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		s := New(Options{
+			Log:     logger.NewLogger("test"),
+			KeyType: "foo",
+		})
+		_, err := s.fetchIdentityCertificate(context.Background(), s.identities[DefaultHint])
+		require.ErrorContains(t, err, "unsupported key type")
+	})
+
+	t.Run("CSR template error", func(t *testing.T) {
+		s := New(Options{
+			Log: logger.NewLogger("test"),
+			CSRTemplateFn: func(*x509.CertificateRequest) error {
+				return errors.New("this is an error")
+			},
+		})
+		_, err := s.fetchIdentityCertificate(context.Background(), s.identities[DefaultHint])
+		require.ErrorContains(t, err, "this is an error")
+	})
+}
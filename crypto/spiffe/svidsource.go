@@ -15,6 +15,7 @@ package spiffe
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 )
@@ -22,17 +23,23 @@ import (
 // svidSource is an implementation of the Go spiffe x509svid Source interface.
 type svidSource struct {
 	spiffe *SPIFFE
+	hint   string
 }
 
 // GetX509SVID returns the current X.509 certificate identity as a SPIFFE SVID.
 // Implements the go-spiffe x509 source interface.
 func (s *svidSource) GetX509SVID() (*x509svid.SVID, error) {
+	id, ok := s.spiffe.identities[s.hint]
+	if !ok {
+		return nil, fmt.Errorf("no identity with hint %q", s.hint)
+	}
+
 	s.spiffe.lock.RLock()
 	defer s.spiffe.lock.RUnlock()
 
 	<-s.spiffe.readyCh
 
-	svid := s.spiffe.currentSVID
+	svid := id.currentSVID
 	if svid == nil {
 		return nil, errors.New("no SVID available")
 	}
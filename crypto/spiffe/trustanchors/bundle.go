@@ -0,0 +1,355 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustanchors
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"k8s.io/utils/clock"
+
+	"github.com/dapr/kit/concurrency"
+	"github.com/dapr/kit/crypto/pem"
+	"github.com/dapr/kit/logger"
+)
+
+// BundleEndpointProfile is the profile used to authenticate a SPIFFE bundle
+// endpoint, as defined by the SPIFFE federation specification.
+type BundleEndpointProfile string
+
+const (
+	// BundleEndpointProfileHTTPSWeb authenticates the bundle endpoint using
+	// Web PKI.
+	BundleEndpointProfileHTTPSWeb BundleEndpointProfile = "https_web"
+	// BundleEndpointProfileHTTPSSPIFFE authenticates the bundle endpoint using
+	// the X.509-SVID it presents.
+	BundleEndpointProfileHTTPSSPIFFE BundleEndpointProfile = "https_spiffe"
+)
+
+const (
+	defaultBundleRefreshInterval = 5 * time.Minute
+	defaultBundleRetryInterval   = 10 * time.Second
+)
+
+type OptionsBundleEndpoint struct {
+	Log logger.Logger
+
+	// TrustDomain is the trust domain whose bundle is served by the endpoint.
+	TrustDomain spiffeid.TrustDomain
+
+	// URL is the URL of the bundle endpoint.
+	URL string
+
+	// Profile is the endpoint profile. Defaults to https_web.
+	Profile BundleEndpointProfile
+
+	// WebPKIRoots are the root CAs used to authenticate the endpoint with the
+	// https_web profile. If nil, the system roots are used.
+	WebPKIRoots *x509.CertPool
+
+	// EndpointSPIFFEID is the SPIFFE ID the endpoint must present with the
+	// https_spiffe profile.
+	EndpointSPIFFEID spiffeid.ID
+
+	// EndpointBundle is the PEM-encoded bundle of the endpoint's trust domain,
+	// used to authenticate the endpoint with the https_spiffe profile. When the
+	// endpoint belongs to the trust domain it serves, the bundle is only used
+	// for the first fetch, after which the fetched bundle is used.
+	EndpointBundle []byte
+
+	// RefreshInterval is the interval at which the bundle is refreshed, when
+	// the bundle doesn't contain a refresh hint. Defaults to 5 minutes.
+	RefreshInterval *time.Duration
+}
+
+// bundleEndpoint is a TrustAnchors implementation that fetches the trust
+// anchors from a SPIFFE bundle endpoint, refreshing them periodically.
+type bundleEndpoint struct {
+	log             logger.Logger
+	trustDomain     spiffeid.TrustDomain
+	url             string
+	profile         BundleEndpointProfile
+	webPKIRoots     *x509.CertPool
+	endpointID      spiffeid.ID
+	refreshInterval time.Duration
+
+	// retryInterval is the interval at which a failed fetch is retried. Used
+	// for testing only, and 10 seconds otherwise.
+	retryInterval time.Duration
+
+	bundle     *x509bundle.Bundle
+	jwtBundle  *jwtbundle.Bundle
+	rootPEM    []byte
+	authBundle *x509bundle.Bundle
+
+	// subs is a list of channels to notify when the trust anchors are updated.
+	subs []chan<- struct{}
+
+	lock    sync.RWMutex
+	clock   clock.Clock
+	running atomic.Bool
+	readyCh chan struct{}
+	closeCh chan struct{}
+}
+
+func FromBundleEndpoint(opts OptionsBundleEndpoint) (Interface, error) {
+	if opts.TrustDomain.IsZero() {
+		return nil, errors.New("trust domain is required")
+	}
+	if opts.URL == "" {
+		return nil, errors.New("bundle endpoint URL is required")
+	}
+
+	b := &bundleEndpoint{
+		log:             opts.Log,
+		trustDomain:     opts.TrustDomain,
+		url:             opts.URL,
+		profile:         opts.Profile,
+		webPKIRoots:     opts.WebPKIRoots,
+		endpointID:      opts.EndpointSPIFFEID,
+		refreshInterval: defaultBundleRefreshInterval,
+		retryInterval:   defaultBundleRetryInterval,
+		clock:           clock.RealClock{},
+		readyCh:         make(chan struct{}),
+		closeCh:         make(chan struct{}),
+	}
+
+	if opts.RefreshInterval != nil {
+		if *opts.RefreshInterval <= 0 {
+			return nil, errors.New("refresh interval must be positive")
+		}
+		b.refreshInterval = *opts.RefreshInterval
+	}
+
+	switch b.profile {
+	case "":
+		b.profile = BundleEndpointProfileHTTPSWeb
+	case BundleEndpointProfileHTTPSWeb:
+	case BundleEndpointProfileHTTPSSPIFFE:
+		if opts.EndpointSPIFFEID.IsZero() {
+			return nil, errors.New("endpoint SPIFFE ID is required with the https_spiffe profile")
+		}
+		certs, err := pem.DecodePEMCertificates(opts.EndpointBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode endpoint bundle: %w", err)
+		}
+		b.authBundle = x509bundle.FromX509Authorities(opts.EndpointSPIFFEID.TrustDomain(), certs)
+	default:
+		return nil, fmt.Errorf("unsupported bundle endpoint profile: %s", b.profile)
+	}
+
+	return b, nil
+}
+
+func (b *bundleEndpoint) Run(ctx context.Context) error {
+	if !b.running.CompareAndSwap(false, true) {
+		return errors.New("trust anchors is already running")
+	}
+
+	defer close(b.closeCh)
+
+	var ready bool
+	for {
+		next, err := b.updateAnchors(ctx)
+		if err != nil {
+			b.log.Errorf("Failed to fetch trust anchors from bundle endpoint '%s': %v", b.url, err)
+			next = b.retryInterval
+		} else if !ready {
+			b.log.Infof("Fetched trust anchors from bundle endpoint '%s'", b.url)
+			close(b.readyCh)
+			ready = true
+		}
+
+		if err = concurrency.SleepCtx(ctx, b.clock, next); err != nil {
+			if !ready {
+				return fmt.Errorf("failed to fetch trust anchors from bundle endpoint '%s': %w", b.url, err)
+			}
+			return nil
+		}
+	}
+}
+
+// updateAnchors fetches the bundle from the endpoint and returns the interval
+// after which it should be refreshed.
+func (b *bundleEndpoint) updateAnchors(ctx context.Context) (time.Duration, error) {
+	var fetchOpt federation.FetchOption
+	if b.profile == BundleEndpointProfileHTTPSSPIFFE {
+		b.lock.RLock()
+		fetchOpt = federation.WithSPIFFEAuth(b.authBundle, b.endpointID)
+		b.lock.RUnlock()
+	} else if b.webPKIRoots != nil {
+		fetchOpt = federation.WithWebPKIRoots(b.webPKIRoots)
+	}
+
+	var fetchOpts []federation.FetchOption
+	if fetchOpt != nil {
+		fetchOpts = append(fetchOpts, fetchOpt)
+	}
+
+	bundle, err := federation.FetchBundle(ctx, b.trustDomain, b.url, fetchOpts...)
+	if err != nil {
+		return 0, err
+	}
+
+	rootPEM, err := validateBundle(bundle)
+	if err != nil {
+		return 0, err
+	}
+
+	refresh := b.refreshInterval
+	if hint, ok := bundle.RefreshHint(); ok && hint > 0 {
+		refresh = hint
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// With the https_spiffe profile, the latest bundle of the endpoint's trust
+	// domain is used to authenticate the following requests.
+	if b.profile == BundleEndpointProfileHTTPSSPIFFE && b.endpointID.TrustDomain() == b.trustDomain {
+		b.authBundle = bundle.X509Bundle()
+	}
+
+	// JWT authorities may be rotated independently of the X.509 authorities.
+	b.jwtBundle = bundle.JWTBundle()
+
+	if bytes.Equal(rootPEM, b.rootPEM) {
+		return refresh, nil
+	}
+
+	b.rootPEM = rootPEM
+	b.bundle = bundle.X509Bundle()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(len(b.subs))
+	for _, ch := range b.subs {
+		go func(chi chan<- struct{}) {
+			defer wg.Done()
+			select {
+			case chi <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}(ch)
+	}
+
+	return refresh, nil
+}
+
+// validateBundle validates the bundle fetched from the endpoint and returns
+// its X.509 authorities encoded as PEM.
+func validateBundle(bundle *spiffebundle.Bundle) ([]byte, error) {
+	authorities := bundle.X509Authorities()
+	if len(authorities) == 0 {
+		return nil, errors.New("bundle does not contain any X.509 authority")
+	}
+
+	var rootPEM []byte
+	for _, cert := range authorities {
+		certPEM, err := pem.EncodeX509(cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode trust anchors: %w", err)
+		}
+		rootPEM = append(rootPEM, certPEM...)
+	}
+
+	return rootPEM, nil
+}
+
+func (b *bundleEndpoint) CurrentTrustAnchors(ctx context.Context) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-b.closeCh:
+		return nil, errors.New("trust anchors is closed")
+	case <-b.readyCh:
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	rootPEM := make([]byte, len(b.rootPEM))
+	copy(rootPEM, b.rootPEM)
+	return rootPEM, nil
+}
+
+func (b *bundleEndpoint) GetX509BundleForTrustDomain(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	if td != b.trustDomain {
+		return nil, ErrTrustDomainNotFound
+	}
+
+	select {
+	case <-b.closeCh:
+		return nil, errors.New("trust anchors is closed")
+	case <-b.readyCh:
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.bundle, nil
+}
+
+func (b *bundleEndpoint) GetJWTBundleForTrustDomain(td spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	if td != b.trustDomain {
+		return nil, ErrTrustDomainNotFound
+	}
+
+	select {
+	case <-b.closeCh:
+		return nil, errors.New("trust anchors is closed")
+	case <-b.readyCh:
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.jwtBundle, nil
+}
+
+func (b *bundleEndpoint) Watch(ctx context.Context, ch chan<- []byte) {
+	b.lock.Lock()
+	sub := make(chan struct{}, 5)
+	b.subs = append(b.subs, sub)
+	b.lock.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.closeCh:
+			return
+		case <-sub:
+			b.lock.RLock()
+			rootPEM := make([]byte, len(b.rootPEM))
+			copy(rootPEM, b.rootPEM)
+			b.lock.RUnlock()
+
+			select {
+			case ch <- rootPEM:
+			case <-ctx.Done():
+			case <-b.closeCh:
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustanchors
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/kit/crypto/test"
+	"github.com/dapr/kit/logger"
+)
+
+func TestFromBundleEndpoint(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("example.com")
+
+	tests := map[string]struct {
+		opts   OptionsBundleEndpoint
+		expErr string
+	}{
+		"missing trust domain": {
+			opts:   OptionsBundleEndpoint{URL: "https://example.com"},
+			expErr: "trust domain is required",
+		},
+		"missing URL": {
+			opts:   OptionsBundleEndpoint{TrustDomain: td},
+			expErr: "bundle endpoint URL is required",
+		},
+		"unsupported profile": {
+			opts:   OptionsBundleEndpoint{TrustDomain: td, URL: "https://example.com", Profile: "foo"},
+			expErr: "unsupported bundle endpoint profile: foo",
+		},
+		"https_spiffe without endpoint ID": {
+			opts:   OptionsBundleEndpoint{TrustDomain: td, URL: "https://example.com", Profile: BundleEndpointProfileHTTPSSPIFFE},
+			expErr: "endpoint SPIFFE ID is required",
+		},
+		"https_spiffe without endpoint bundle": {
+			opts: OptionsBundleEndpoint{
+				TrustDomain:      td,
+				URL:              "https://example.com",
+				Profile:          BundleEndpointProfileHTTPSSPIFFE,
+				EndpointSPIFFEID: spiffeid.RequireFromString("spiffe://example.com/bundle"),
+			},
+			expErr: "failed to decode endpoint bundle",
+		},
+		"https_web": {
+			opts: OptionsBundleEndpoint{TrustDomain: td, URL: "https://example.com"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := FromBundleEndpoint(tc.opts)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBundleEndpoint_Run(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("example.com")
+	pki1 := test.GenPKI(t, test.PKIOptions{LeafID: spiffeid.RequireFromString("spiffe://example.com/bundle")})
+	pki2 := test.GenPKI(t, test.PKIOptions{})
+
+	marshal := func(t *testing.T, cert *x509.Certificate) []byte {
+		t.Helper()
+		b, err := spiffebundle.FromX509Authorities(td, []*x509.Certificate{cert}).Marshal()
+		require.NoError(t, err)
+		return b
+	}
+	bundle1 := marshal(t, pki1.RootCert)
+	bundle2 := marshal(t, pki2.RootCert)
+
+	newServer := func(t *testing.T, served *atomic.Pointer[[]byte]) *httptest.Server {
+		t.Helper()
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(*served.Load())
+		}))
+		return srv
+	}
+
+	t.Run("https_web fetches and refreshes the bundle", func(t *testing.T) {
+		var served atomic.Pointer[[]byte]
+		served.Store(&bundle1)
+		srv := newServer(t, &served)
+		srv.StartTLS()
+		t.Cleanup(srv.Close)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(srv.Certificate())
+
+		refresh := time.Minute
+		ta, err := FromBundleEndpoint(OptionsBundleEndpoint{
+			Log:             logger.NewLogger("test"),
+			TrustDomain:     td,
+			URL:             srv.URL,
+			WebPKIRoots:     roots,
+			RefreshInterval: &refresh,
+		})
+		require.NoError(t, err)
+		b := ta.(*bundleEndpoint)
+		clock := clocktesting.NewFakeClock(time.Now())
+		b.clock = clock
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- ta.Run(ctx)
+		}()
+
+		anchors, err := ta.CurrentTrustAnchors(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, pki1.RootCertPEM, anchors)
+
+		x509Bundle, err := ta.GetX509BundleForTrustDomain(td)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki1.RootCert}, x509Bundle.X509Authorities())
+
+		_, err = ta.GetX509BundleForTrustDomain(spiffeid.RequireTrustDomainFromString("other.com"))
+		require.ErrorIs(t, err, ErrTrustDomainNotFound)
+
+		jwtBundle, err := ta.GetJWTBundleForTrustDomain(td)
+		require.NoError(t, err)
+		assert.True(t, jwtBundle.Empty())
+
+		_, err = ta.GetJWTBundleForTrustDomain(spiffeid.RequireTrustDomainFromString("other.com"))
+		require.ErrorIs(t, err, ErrTrustDomainNotFound)
+
+		watchCh := make(chan []byte)
+		go ta.Watch(ctx, watchCh)
+
+		served.Store(&bundle2)
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond*10)
+		clock.Step(refresh)
+
+		select {
+		case anchors = <-watchCh:
+			assert.Equal(t, pki2.RootCertPEM, anchors)
+		case <-time.After(time.Second * 5):
+			assert.Fail(t, "expected trust anchors to be updated")
+		}
+
+		cancel()
+		select {
+		case err = <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected Run to return")
+		}
+	})
+
+	t.Run("https_spiffe authenticates the endpoint SPIFFE ID", func(t *testing.T) {
+		var served atomic.Pointer[[]byte]
+		served.Store(&bundle1)
+		srv := newServer(t, &served)
+		srv.TLS = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{pki1.LeafCert.Raw},
+				PrivateKey:  pki1.LeafPK,
+			}},
+		}
+		srv.StartTLS()
+		t.Cleanup(srv.Close)
+
+		ta, err := FromBundleEndpoint(OptionsBundleEndpoint{
+			Log:              logger.NewLogger("test"),
+			TrustDomain:      td,
+			URL:              srv.URL,
+			Profile:          BundleEndpointProfileHTTPSSPIFFE,
+			EndpointSPIFFEID: spiffeid.RequireFromString("spiffe://example.com/bundle"),
+			EndpointBundle:   pki1.RootCertPEM,
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- ta.Run(ctx)
+		}()
+
+		anchors, err := ta.CurrentTrustAnchors(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, pki1.RootCertPEM, anchors)
+
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("https_spiffe with unexpected endpoint SPIFFE ID never becomes ready", func(t *testing.T) {
+		var served atomic.Pointer[[]byte]
+		served.Store(&bundle1)
+		srv := newServer(t, &served)
+		srv.TLS = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{pki1.LeafCert.Raw},
+				PrivateKey:  pki1.LeafPK,
+			}},
+		}
+		srv.StartTLS()
+		t.Cleanup(srv.Close)
+
+		ta, err := FromBundleEndpoint(OptionsBundleEndpoint{
+			Log:              logger.NewLogger("test"),
+			TrustDomain:      td,
+			URL:              srv.URL,
+			Profile:          BundleEndpointProfileHTTPSSPIFFE,
+			EndpointSPIFFEID: spiffeid.RequireFromString("spiffe://example.com/other"),
+			EndpointBundle:   pki1.RootCertPEM,
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err = ta.Run(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		_, err = ta.CurrentTrustAnchors(context.Background())
+		require.ErrorContains(t, err, "trust anchors is closed")
+	})
+}
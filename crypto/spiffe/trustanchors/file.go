@@ -23,6 +23,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
 	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"k8s.io/utils/clock"
@@ -36,15 +37,22 @@ import (
 type OptionsFile struct {
 	Log  logger.Logger
 	Path string
+
+	// JWKSPath is the optional path to a JWKS document containing the JWT
+	// bundle, used to validate JWT SVIDs. The file is watched for changes
+	// together with the trust anchors file.
+	JWKSPath string
 }
 
 // file is a TrustAnchors implementation that uses a file as the source of trust
 // anchors. The trust anchors will be updated when the file changes.
 type file struct {
-	log     logger.Logger
-	path    string
-	bundle  *x509bundle.Bundle
-	rootPEM []byte
+	log       logger.Logger
+	path      string
+	jwksPath  string
+	bundle    *x509bundle.Bundle
+	jwtBundle *jwtbundle.Bundle
+	rootPEM   []byte
 
 	// fswatcherInterval is the interval at which the trust anchors file changes
 	// are batched. Used for testing only, and 500ms otherwise.
@@ -70,12 +78,13 @@ func FromFile(opts OptionsFile) Interface {
 		fsWatcherInterval:     time.Millisecond * 500,
 		initFileWatchInterval: time.Second,
 
-		log:     opts.Log,
-		path:    opts.Path,
-		clock:   clock.RealClock{},
-		readyCh: make(chan struct{}),
-		closeCh: make(chan struct{}),
-		caEvent: make(chan struct{}),
+		log:      opts.Log,
+		path:     opts.Path,
+		jwksPath: opts.JWKSPath,
+		clock:    clock.RealClock{},
+		readyCh:  make(chan struct{}),
+		closeCh:  make(chan struct{}),
+		caEvent:  make(chan struct{}),
 	}
 }
 
@@ -86,22 +95,13 @@ func (f *file) Run(ctx context.Context) error {
 
 	defer close(f.closeCh)
 
-	for {
-		_, err := os.Stat(f.path)
-		if err == nil {
-			break
-		}
-		if !errors.Is(err, os.ErrNotExist) {
+	if err := f.waitForFile(ctx, f.path); err != nil {
+		return err
+	}
+	if f.jwksPath != "" {
+		if err := f.waitForFile(ctx, f.jwksPath); err != nil {
 			return err
 		}
-
-		// Trust anchors file not be provided yet, wait.
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("failed to find trust anchors file '%s': %w", f.path, ctx.Err())
-		case <-f.clock.After(f.initFileWatchInterval):
-			f.log.Warnf("Trust anchors file '%s' not found, waiting...", f.path)
-		}
 	}
 
 	f.log.Infof("Trust anchors file '%s' found", f.path)
@@ -110,8 +110,13 @@ func (f *file) Run(ctx context.Context) error {
 		return err
 	}
 
+	targets := []string{filepath.Dir(f.path)}
+	if f.jwksPath != "" && filepath.Dir(f.jwksPath) != targets[0] {
+		targets = append(targets, filepath.Dir(f.jwksPath))
+	}
+
 	fs, err := fswatcher.New(fswatcher.Options{
-		Targets:  []string{filepath.Dir(f.path)},
+		Targets:  targets,
 		Interval: &f.fsWatcherInterval,
 	})
 	if err != nil {
@@ -142,6 +147,25 @@ func (f *file) Run(ctx context.Context) error {
 	).Run(ctx)
 }
 
+// waitForFile waits for the file at path to exist.
+func (f *file) waitForFile(ctx context.Context, path string) error {
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		// Trust anchors file not be provided yet, wait.
+		if err = concurrency.SleepCtx(ctx, f.clock, f.initFileWatchInterval); err != nil {
+			return fmt.Errorf("failed to find trust anchors file '%s': %w", path, err)
+		}
+		f.log.Warnf("Trust anchors file '%s' not found, waiting...", path)
+	}
+}
+
 func (f *file) CurrentTrustAnchors(ctx context.Context) ([]byte, error) {
 	select {
 	case <-ctx.Done():
@@ -172,8 +196,17 @@ func (f *file) updateAnchors(ctx context.Context) error {
 		return fmt.Errorf("failed to decode trust anchors: %w", err)
 	}
 
+	var jwtBundle *jwtbundle.Bundle
+	if f.jwksPath != "" {
+		jwtBundle, err = jwtbundle.Load(spiffeid.TrustDomain{}, f.jwksPath)
+		if err != nil {
+			return fmt.Errorf("failed to load JWT bundle file '%s': %w", f.jwksPath, err)
+		}
+	}
+
 	f.rootPEM = rootPEMs
 	f.bundle = x509bundle.FromX509Authorities(spiffeid.TrustDomain{}, trustAnchorCerts)
+	f.jwtBundle = jwtBundle
 
 	var wg sync.WaitGroup
 	defer wg.Wait()
@@ -205,6 +238,22 @@ func (f *file) GetX509BundleForTrustDomain(_ spiffeid.TrustDomain) (*x509bundle.
 	return bundle, nil
 }
 
+func (f *file) GetJWTBundleForTrustDomain(_ spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	if f.jwksPath == "" {
+		return nil, ErrNoJWTBundle
+	}
+
+	select {
+	case <-f.closeCh:
+		return nil, errors.New("trust anchors is closed")
+	case <-f.readyCh:
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.jwtBundle, nil
+}
+
 func (f *file) Watch(ctx context.Context, ch chan<- []byte) {
 	f.lock.Lock()
 	sub := make(chan struct{}, 5)
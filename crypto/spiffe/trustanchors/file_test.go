@@ -572,3 +572,73 @@ func TestFile_CurrentTrustAnchors(t *testing.T) {
 		}
 	})
 }
+
+func TestFile_GetJWTBundleForTrustDomain(t *testing.T) {
+	t.Run("without JWKS path should return ErrNoJWTBundle", func(t *testing.T) {
+		ta := FromFile(OptionsFile{
+			Log:  logger.NewLogger("test"),
+			Path: filepath.Join(t.TempDir(), "ca.crt"),
+		})
+		_, err := ta.GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, ErrNoJWTBundle)
+	})
+
+	t.Run("returns JWT bundle as it changes", func(t *testing.T) {
+		pki := test.GenPKI(t, test.PKIOptions{})
+		jwks1, _ := genJWKS(t, "kid1")
+		jwks2, _ := genJWKS(t, "kid2")
+
+		dir := t.TempDir()
+		caPath := filepath.Join(dir, "ca.crt")
+		jwksPath := filepath.Join(dir, "jwks.json")
+		require.NoError(t, os.WriteFile(caPath, pki.RootCertPEM, 0o600))
+
+		ta := FromFile(OptionsFile{
+			Log:      logger.NewLogger("test"),
+			Path:     caPath,
+			JWKSPath: jwksPath,
+		})
+		f, ok := ta.(*file)
+		require.True(t, ok)
+		f.initFileWatchInterval = time.Millisecond
+		f.fsWatcherInterval = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- f.Run(ctx)
+		}()
+
+		// Run waits for the JWKS file to exist
+		select {
+		case <-f.readyCh:
+			assert.Fail(t, "trust anchors should not be ready before the JWKS file exists")
+		case <-time.After(time.Millisecond * 50):
+		}
+		require.NoError(t, os.WriteFile(jwksPath, jwks1, 0o600))
+
+		td := spiffeid.RequireTrustDomainFromString("example.com")
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			bundle, err := ta.GetJWTBundleForTrustDomain(td)
+			require.NoError(c, err)
+			assert.True(c, bundle.HasJWTAuthority("kid1"))
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, os.WriteFile(jwksPath, jwks2, 0o600))
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			bundle, err := ta.GetJWTBundleForTrustDomain(td)
+			require.NoError(c, err)
+			assert.False(c, bundle.HasJWTAuthority("kid1"))
+			assert.True(c, bundle.HasJWTAuthority("kid2"))
+		}, time.Second, time.Millisecond)
+
+		cancel()
+
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected Run to return")
+		}
+	})
+}
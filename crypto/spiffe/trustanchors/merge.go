@@ -0,0 +1,286 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustanchors
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/dapr/kit/concurrency"
+	"github.com/dapr/kit/crypto/pem"
+	"github.com/dapr/kit/logger"
+)
+
+// MergePolicy determines how a merged source of trust anchors handles the
+// failures of some of its sources.
+type MergePolicy int
+
+const (
+	// MergePolicyRequireAll fails if any of the sources fails. This is the
+	// default.
+	MergePolicyRequireAll MergePolicy = iota
+	// MergePolicyBestEffort ignores the sources which fail, as long as at least
+	// one of them succeeds.
+	MergePolicyBestEffort
+)
+
+type OptionsMerge struct {
+	Log logger.Logger
+
+	// Sources are the sources of trust anchors to merge.
+	Sources []Interface
+
+	// Policy determines how failures of some of the sources are handled.
+	// Defaults to MergePolicyRequireAll.
+	Policy MergePolicy
+}
+
+// merge is a TrustAnchors implementation which merges the trust anchors of
+// multiple sources, for example to trust both an old and a new CA during a
+// migration.
+type merge struct {
+	log     logger.Logger
+	sources []Interface
+	policy  MergePolicy
+	running atomic.Bool
+}
+
+// Merge returns a source of trust anchors which merges the trust anchors of
+// the given sources, failing if any of them fails.
+func Merge(sources ...Interface) Interface {
+	return FromMerge(OptionsMerge{Sources: sources})
+}
+
+// FromMerge returns a source of trust anchors which merges the trust anchors
+// of the given sources.
+// The bundles of the sources are concatenated, and subscribers of Watch are
+// notified with the merged trust anchors when any of the sources changes.
+// Running the merged source runs all the sources.
+func FromMerge(opts OptionsMerge) Interface {
+	log := opts.Log
+	if log == nil {
+		log = logger.NewLogger("dapr.kit.trustanchors")
+	}
+
+	return &merge{
+		log:     log,
+		sources: opts.Sources,
+		policy:  opts.Policy,
+	}
+}
+
+func (m *merge) Run(ctx context.Context) error {
+	if !m.running.CompareAndSwap(false, true) {
+		return errors.New("trust anchors source is already running")
+	}
+	if len(m.sources) == 0 {
+		return errors.New("no trust anchors sources to merge")
+	}
+
+	r := concurrency.NewRunnerManager()
+	var failed atomic.Int32
+	for i, src := range m.sources {
+		runner := src.Run
+		if m.policy == MergePolicyBestEffort {
+			runner = func(ctx context.Context) error {
+				err := src.Run(ctx)
+				if err == nil || ctx.Err() != nil {
+					return err
+				}
+				if int(failed.Add(1)) == len(m.sources) {
+					return fmt.Errorf("all trust anchors sources failed, last error: %w", err)
+				}
+
+				// Keep running the other sources
+				m.log.Warnf("Trust anchors source %d failed, ignoring it: %v", i, err)
+				<-ctx.Done()
+				return nil
+			}
+		}
+		if err := r.Add(runner); err != nil {
+			return err
+		}
+	}
+
+	return r.Run(ctx)
+}
+
+func (m *merge) CurrentTrustAnchors(ctx context.Context) ([]byte, error) {
+	var certs []*x509.Certificate
+	for i, src := range m.sources {
+		anchors, err := src.CurrentTrustAnchors(ctx)
+		if err == nil {
+			var srcCerts []*x509.Certificate
+			srcCerts, err = pem.DecodePEMCertificates(anchors)
+			certs = appendUniqueCerts(certs, srcCerts...)
+		}
+		if err = m.handleSourceError(i, err); err != nil {
+			return nil, fmt.Errorf("failed to get trust anchors from source %d: %w", i, err)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no trust anchors available from any source")
+	}
+
+	// Trust anchors are usually self-signed, so they can't be encoded with
+	// pem.EncodeX509Chain
+	var anchors []byte
+	for _, c := range certs {
+		b, err := pem.EncodeX509(c)
+		if err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, b...)
+	}
+	return anchors, nil
+}
+
+func (m *merge) GetX509BundleForTrustDomain(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	var (
+		certs []*x509.Certificate
+		found bool
+	)
+	for i, src := range m.sources {
+		bundle, err := src.GetX509BundleForTrustDomain(td)
+		if errors.Is(err, ErrTrustDomainNotFound) {
+			// Sources for other trust domains are not failing
+			continue
+		}
+		if err == nil {
+			found = true
+			certs = appendUniqueCerts(certs, bundle.X509Authorities()...)
+		} else if err = m.handleSourceError(i, err); err != nil {
+			return nil, fmt.Errorf("failed to get X.509 bundle from source %d: %w", i, err)
+		}
+	}
+	if !found {
+		return nil, ErrTrustDomainNotFound
+	}
+
+	return x509bundle.FromX509Authorities(td, certs), nil
+}
+
+func (m *merge) GetJWTBundleForTrustDomain(td spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	var (
+		merged *jwtbundle.Bundle
+		found  bool
+	)
+	for i, src := range m.sources {
+		bundle, err := src.GetJWTBundleForTrustDomain(td)
+		if errors.Is(err, ErrTrustDomainNotFound) {
+			// Sources for other trust domains are not failing
+			continue
+		}
+		if errors.Is(err, ErrNoJWTBundle) {
+			// Sources without a JWT bundle are not failing either
+			found = true
+			continue
+		}
+		if err != nil {
+			if err = m.handleSourceError(i, err); err != nil {
+				return nil, fmt.Errorf("failed to get JWT bundle from source %d: %w", i, err)
+			}
+			continue
+		}
+
+		found = true
+		if merged == nil {
+			merged = jwtbundle.New(td)
+		}
+		for keyID, key := range bundle.JWTAuthorities() {
+			// In case of conflicts, the key of the first source wins
+			if !merged.HasJWTAuthority(keyID) {
+				_ = merged.AddJWTAuthority(keyID, key)
+			}
+		}
+	}
+	if !found {
+		return nil, ErrTrustDomainNotFound
+	}
+	if merged == nil {
+		return nil, ErrNoJWTBundle
+	}
+
+	return merged, nil
+}
+
+// Watch notifies ch with the merged trust anchors each time any of the
+// sources changes.
+func (m *merge) Watch(ctx context.Context, ch chan<- []byte) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The anchors sent by the sources are discarded, as the merged ones are
+	// sent instead
+	updateCh := make(chan []byte)
+	wg.Add(len(m.sources))
+	for _, src := range m.sources {
+		go func() {
+			defer wg.Done()
+			src.Watch(ctx, updateCh)
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-updateCh:
+			anchors, err := m.CurrentTrustAnchors(ctx)
+			if err != nil {
+				m.log.Errorf("Failed to merge updated trust anchors: %v", err)
+				continue
+			}
+
+			select {
+			case ch <- anchors:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// handleSourceError returns the error of a source if it must fail the
+// operation according to the policy, or nil if it is ignored.
+func (m *merge) handleSourceError(i int, err error) error {
+	if err == nil || m.policy == MergePolicyRequireAll {
+		return err
+	}
+
+	m.log.Debugf("Ignoring failed trust anchors source %d: %v", i, err)
+	return nil
+}
+
+// appendUniqueCerts appends the certificates to certs, skipping duplicates.
+func appendUniqueCerts(certs []*x509.Certificate, add ...*x509.Certificate) []*x509.Certificate {
+	for _, c := range add {
+		if !slices.ContainsFunc(certs, c.Equal) {
+			certs = append(certs, c)
+		}
+	}
+	return certs
+}
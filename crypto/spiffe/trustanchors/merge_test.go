@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustanchors
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/crypto/pem"
+	"github.com/dapr/kit/crypto/test"
+)
+
+// failingSource is a source of trust anchors which always fails.
+type failingSource struct {
+	err error
+}
+
+func (f failingSource) Run(context.Context) error { return f.err }
+
+func (f failingSource) CurrentTrustAnchors(context.Context) ([]byte, error) { return nil, f.err }
+
+func (f failingSource) GetX509BundleForTrustDomain(spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	return nil, f.err
+}
+
+func (f failingSource) GetJWTBundleForTrustDomain(spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	return nil, f.err
+}
+
+func (f failingSource) Watch(ctx context.Context, _ chan<- []byte) { <-ctx.Done() }
+
+func TestMerge(t *testing.T) {
+	pki1, pki2 := test.GenPKI(t, test.PKIOptions{}), test.GenPKI(t, test.PKIOptions{})
+	ta1, err := FromStatic(pki1.RootCertPEM)
+	require.NoError(t, err)
+	jwks, pub := genJWKS(t, "kid1")
+	ta2, err := FromStaticWithJWKS(pki2.RootCertPEM, jwks)
+	require.NoError(t, err)
+	failing := failingSource{err: errors.New("source failed")}
+
+	t.Run("should concatenate the trust anchors", func(t *testing.T) {
+		// Duplicate anchors are removed
+		ta := Merge(ta1, ta2, ta1)
+
+		taPEM, err := ta.CurrentTrustAnchors(context.Background())
+		require.NoError(t, err)
+		certs, err := pem.DecodePEMCertificates(taPEM)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki1.RootCert, pki2.RootCert}, certs)
+
+		td := spiffeid.RequireTrustDomainFromString("example.com")
+		bundle, err := ta.GetX509BundleForTrustDomain(td)
+		require.NoError(t, err)
+		assert.Equal(t, td, bundle.TrustDomain())
+		assert.Equal(t, []*x509.Certificate{pki1.RootCert, pki2.RootCert}, bundle.X509Authorities())
+	})
+
+	t.Run("should merge the JWT bundles of the sources which have one", func(t *testing.T) {
+		ta := Merge(ta1, ta2)
+		bundle, err := ta.GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.NoError(t, err)
+		key, ok := bundle.FindJWTAuthority("kid1")
+		require.True(t, ok)
+		assert.Equal(t, pub, key)
+
+		_, err = Merge(ta1).GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, ErrNoJWTBundle)
+	})
+
+	t.Run("should merge sources of different trust domains", func(t *testing.T) {
+		td1 := spiffeid.RequireTrustDomainFromString("example.com")
+		td2 := spiffeid.RequireTrustDomainFromString("example.org")
+		ta := Merge(
+			FromMulti(OptionsMulti{TrustAnchors: map[spiffeid.TrustDomain]Interface{td1: ta1}}),
+			FromMulti(OptionsMulti{TrustAnchors: map[spiffeid.TrustDomain]Interface{td2: ta2}}),
+		)
+
+		bundle, err := ta.GetX509BundleForTrustDomain(td1)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki1.RootCert}, bundle.X509Authorities())
+		bundle, err = ta.GetX509BundleForTrustDomain(td2)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki2.RootCert}, bundle.X509Authorities())
+
+		jwtBundle, err := ta.GetJWTBundleForTrustDomain(td2)
+		require.NoError(t, err)
+		key, ok := jwtBundle.FindJWTAuthority("kid1")
+		require.True(t, ok)
+		assert.Equal(t, pub, key)
+		_, err = ta.GetJWTBundleForTrustDomain(td1)
+		require.ErrorIs(t, err, ErrNoJWTBundle)
+
+		other := spiffeid.RequireTrustDomainFromString("example.net")
+		_, err = ta.GetX509BundleForTrustDomain(other)
+		require.ErrorIs(t, err, ErrTrustDomainNotFound)
+		_, err = ta.GetJWTBundleForTrustDomain(other)
+		require.ErrorIs(t, err, ErrTrustDomainNotFound)
+	})
+
+	t.Run("require all policy should fail if any source fails", func(t *testing.T) {
+		ta := Merge(ta1, failing)
+		runTA, err := FromStatic(pki1.RootCertPEM)
+		require.NoError(t, err)
+
+		_, err = ta.CurrentTrustAnchors(context.Background())
+		require.ErrorIs(t, err, failing.err)
+		_, err = ta.GetX509BundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, failing.err)
+		_, err = ta.GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, failing.err)
+		require.ErrorIs(t, Merge(runTA, failing).Run(context.Background()), failing.err)
+	})
+
+	t.Run("best effort policy should ignore failed sources", func(t *testing.T) {
+		ta := FromMerge(OptionsMerge{
+			Sources: []Interface{failing, ta1},
+			Policy:  MergePolicyBestEffort,
+		})
+
+		taPEM, err := ta.CurrentTrustAnchors(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, pki1.RootCertPEM, taPEM)
+		bundle, err := ta.GetX509BundleForTrustDomain(spiffeid.TrustDomain{})
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki1.RootCert}, bundle.X509Authorities())
+		_, err = ta.GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, ErrNoJWTBundle)
+
+		// Run keeps running the other sources
+		runTA, err := FromStatic(pki1.RootCertPEM)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- FromMerge(OptionsMerge{
+				Sources: []Interface{failing, runTA},
+				Policy:  MergePolicyBestEffort,
+			}).Run(ctx)
+		}()
+		select {
+		case err := <-errCh:
+			require.Fail(t, "Run returned early", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		cancel()
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			assert.Fail(t, "Expected Run to return")
+		}
+	})
+
+	t.Run("best effort policy should fail if all sources fail", func(t *testing.T) {
+		ta := FromMerge(OptionsMerge{
+			Sources: []Interface{failing, failing},
+			Policy:  MergePolicyBestEffort,
+		})
+
+		_, err := ta.CurrentTrustAnchors(context.Background())
+		require.Error(t, err)
+		_, err = ta.GetX509BundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, ErrTrustDomainNotFound)
+		require.ErrorIs(t, ta.Run(context.Background()), failing.err)
+	})
+
+	t.Run("Run multiple times should return error", func(t *testing.T) {
+		ta := Merge(failing)
+		require.Error(t, ta.Run(context.Background()))
+		require.ErrorContains(t, ta.Run(context.Background()), "already running")
+		require.Error(t, Merge().Run(context.Background()))
+	})
+}
+
+func TestMerge_Watch(t *testing.T) {
+	pki1, pki2, pki3 := test.GenPKI(t, test.PKIOptions{}), test.GenPKI(t, test.PKIOptions{}), test.GenPKI(t, test.PKIOptions{})
+	ta1, err := FromStatic(pki1.RootCertPEM)
+	require.NoError(t, err)
+	ta2, err := FromStatic(pki2.RootCertPEM)
+	require.NoError(t, err)
+	ta := Merge(ta1, ta2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	watchCh := make(chan []byte)
+	doneCh := make(chan struct{})
+	go func() {
+		ta.Watch(ctx, watchCh)
+		close(doneCh)
+	}()
+	assert.Eventually(t, func() bool {
+		s := ta2.(*static)
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+		return len(s.subs) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Updating any source notifies the merged anchors
+	require.NoError(t, ta2.Update(pki3.RootCertPEM))
+	select {
+	case got := <-watchCh:
+		certs, err := pem.DecodePEMCertificates(got)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki1.RootCert, pki3.RootCert}, certs)
+	case <-time.After(time.Second):
+		assert.Fail(t, "Expected anchors to be sent to the watcher")
+	}
+
+	cancel()
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		assert.Fail(t, "Expected Watch to return")
+	}
+}
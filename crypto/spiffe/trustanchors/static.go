@@ -17,42 +17,115 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
 	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 
 	"github.com/dapr/kit/crypto/pem"
 )
 
+// Static is a source of trust anchors which are not loaded from a file, but
+// which can be replaced at runtime, for example when they are received over a
+// control channel.
+type Static interface {
+	Interface
+
+	// Update replaces the trust anchors with the given PEM bundle, and
+	// notifies the subscribers of Watch.
+	// If the bundle is not valid, the current trust anchors are kept.
+	Update(anchors []byte) error
+}
+
 // static is a TrustAcnhors implementation that uses a static list of trust
 // anchors.
 type static struct {
-	bundle  *x509bundle.Bundle
-	anchors []byte
-	running atomic.Bool
-	closeCh chan struct{}
+	bundle    *x509bundle.Bundle
+	jwtBundle *jwtbundle.Bundle
+	anchors   []byte
+	running   atomic.Bool
+	closeCh   chan struct{}
+
+	// subs is a list of channels to notify when the trust anchors are updated.
+	subs []chan struct{}
+	lock sync.RWMutex
 }
 
-func FromStatic(anchors []byte) (Interface, error) {
-	trustAnchorCerts, err := pem.DecodePEMCertificates(anchors)
+func FromStatic(anchors []byte) (Static, error) {
+	bundle, err := parseStaticAnchors(anchors)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode trust anchors: %w", err)
+		return nil, err
 	}
 
 	return &static{
 		anchors: anchors,
-		bundle:  x509bundle.FromX509Authorities(spiffeid.TrustDomain{}, trustAnchorCerts),
+		bundle:  bundle,
 		closeCh: make(chan struct{}),
 	}, nil
 }
 
+func parseStaticAnchors(anchors []byte) (*x509bundle.Bundle, error) {
+	trustAnchorCerts, err := pem.DecodePEMCertificates(anchors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode trust anchors: %w", err)
+	}
+
+	return x509bundle.FromX509Authorities(spiffeid.TrustDomain{}, trustAnchorCerts), nil
+}
+
+// FromStaticWithJWKS is like FromStatic, but the source also carries the JWT
+// bundle parsed from the given JWKS document, used to validate JWT SVIDs.
+func FromStaticWithJWKS(anchors, jwks []byte) (Static, error) {
+	ta, err := FromStatic(anchors)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtBundle, err := jwtbundle.Parse(spiffeid.TrustDomain{}, jwks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT bundle: %w", err)
+	}
+
+	ta.(*static).jwtBundle = jwtBundle
+	return ta, nil
+}
+
 func (s *static) CurrentTrustAnchors(context.Context) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
 	bundle := make([]byte, len(s.anchors))
 	copy(bundle, s.anchors)
 	return bundle, nil
 }
 
+func (s *static) Update(anchors []byte) error {
+	bundle, err := parseStaticAnchors(anchors)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.anchors = make([]byte, len(anchors))
+	copy(s.anchors, anchors)
+	s.bundle = bundle
+
+	// Subscribers read the latest anchors when notified, so notifications
+	// which are still pending can be skipped
+	for _, sub := range s.subs {
+		select {
+		case sub <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
 func (s *static) Run(ctx context.Context) error {
 	if !s.running.CompareAndSwap(false, true) {
 		return errors.New("trust anchors source is already running")
@@ -63,12 +136,49 @@ func (s *static) Run(ctx context.Context) error {
 }
 
 func (s *static) GetX509BundleForTrustDomain(spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return s.bundle, nil
 }
 
-func (s *static) Watch(ctx context.Context, _ chan<- []byte) {
-	select {
-	case <-ctx.Done():
-	case <-s.closeCh:
+func (s *static) GetJWTBundleForTrustDomain(spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	if s.jwtBundle == nil {
+		return nil, ErrNoJWTBundle
+	}
+	return s.jwtBundle, nil
+}
+
+func (s *static) Watch(ctx context.Context, ch chan<- []byte) {
+	s.lock.Lock()
+	sub := make(chan struct{}, 1)
+	s.subs = append(s.subs, sub)
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		for i, v := range s.subs {
+			if v == sub {
+				s.subs = append(s.subs[:i], s.subs[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closeCh:
+			return
+		case <-sub:
+			anchors, _ := s.CurrentTrustAnchors(ctx)
+
+			select {
+			case ch <- anchors:
+			case <-ctx.Done():
+			case <-s.closeCh:
+			}
+		}
 	}
 }
@@ -15,9 +15,15 @@ package trustanchors
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"testing"
 	"time"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -208,3 +214,133 @@ func TestStatic_Watch(t *testing.T) {
 		}
 	})
 }
+
+func TestStatic_Update(t *testing.T) {
+	t.Run("invalid anchors should return error and keep the current ones", func(t *testing.T) {
+		pki := test.GenPKI(t, test.PKIOptions{})
+		ta, err := FromStatic(pki.RootCertPEM)
+		require.NoError(t, err)
+
+		require.Error(t, ta.Update([]byte("garbage data")))
+
+		taPEM, err := ta.CurrentTrustAnchors(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, pki.RootCertPEM, taPEM)
+	})
+
+	t.Run("should swap anchors and notify watchers", func(t *testing.T) {
+		pki1, pki2 := test.GenPKI(t, test.PKIOptions{}), test.GenPKI(t, test.PKIOptions{})
+		ta, err := FromStatic(pki1.RootCertPEM)
+		require.NoError(t, err)
+		s, ok := ta.(*static)
+		require.True(t, ok)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		watchCh := make(chan []byte)
+		doneCh := make(chan struct{})
+		go func() {
+			ta.Watch(ctx, watchCh)
+			close(doneCh)
+		}()
+		assert.Eventually(t, func() bool {
+			s.lock.RLock()
+			defer s.lock.RUnlock()
+			return len(s.subs) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, ta.Update(pki2.RootCertPEM))
+
+		select {
+		case got := <-watchCh:
+			assert.Equal(t, pki2.RootCertPEM, got)
+		case <-time.After(time.Second):
+			assert.Fail(t, "Expected anchors to be sent to the watcher")
+		}
+
+		taPEM, err := ta.CurrentTrustAnchors(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, pki2.RootCertPEM, taPEM)
+		bundle, err := ta.GetX509BundleForTrustDomain(spiffeid.TrustDomain{})
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki2.RootCert}, bundle.X509Authorities())
+
+		cancel()
+		select {
+		case <-doneCh:
+		case <-time.After(time.Second):
+			assert.Fail(t, "Expected Watch to return")
+		}
+		s.lock.RLock()
+		assert.Empty(t, s.subs)
+		s.lock.RUnlock()
+	})
+
+	t.Run("should keep the JWT bundle", func(t *testing.T) {
+		pki1, pki2 := test.GenPKI(t, test.PKIOptions{}), test.GenPKI(t, test.PKIOptions{})
+		jwks, _ := genJWKS(t, "kid1")
+		ta, err := FromStaticWithJWKS(pki1.RootCertPEM, jwks)
+		require.NoError(t, err)
+
+		require.NoError(t, ta.Update(pki2.RootCertPEM))
+		bundle, err := ta.GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.NoError(t, err)
+		_, ok := bundle.FindJWTAuthority("kid1")
+		assert.True(t, ok)
+	})
+}
+
+func TestFromStaticWithJWKS(t *testing.T) {
+	pki := test.GenPKI(t, test.PKIOptions{})
+
+	t.Run("without JWKS should return ErrNoJWTBundle", func(t *testing.T) {
+		ta, err := FromStatic(pki.RootCertPEM)
+		require.NoError(t, err)
+		_, err = ta.GetJWTBundleForTrustDomain(spiffeid.TrustDomain{})
+		require.ErrorIs(t, err, ErrNoJWTBundle)
+	})
+
+	t.Run("invalid JWKS should return error", func(t *testing.T) {
+		_, err := FromStaticWithJWKS(pki.RootCertPEM, []byte("garbage data"))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid roots should return error", func(t *testing.T) {
+		jwks, _ := genJWKS(t, "kid1")
+		_, err := FromStaticWithJWKS([]byte("garbage data"), jwks)
+		require.Error(t, err)
+	})
+
+	t.Run("should return JWT bundle regardless given trust domain", func(t *testing.T) {
+		jwks, pub := genJWKS(t, "kid1")
+		ta, err := FromStaticWithJWKS(pki.RootCertPEM, jwks)
+		require.NoError(t, err)
+
+		for _, td := range []string{"example.com", "foo.bar"} {
+			bundle, err := ta.GetJWTBundleForTrustDomain(spiffeid.RequireTrustDomainFromString(td))
+			require.NoError(t, err)
+			key, ok := bundle.FindJWTAuthority("kid1")
+			require.True(t, ok)
+			assert.True(t, pub.(*ecdsa.PublicKey).Equal(key))
+		}
+
+		x509Bundle, err := ta.GetX509BundleForTrustDomain(spiffeid.TrustDomain{})
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{pki.RootCert}, x509Bundle.X509Authorities())
+	})
+}
+
+// genJWKS returns a JWKS document containing a new public key with the given
+// key ID.
+func genJWKS(t *testing.T, kid string) ([]byte, crypto.PublicKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := jwtbundle.FromJWTAuthorities(spiffeid.TrustDomain{}, map[string]crypto.PublicKey{
+		kid: key.Public(),
+	}).Marshal()
+	require.NoError(t, err)
+	return jwks, key.Public()
+}
@@ -15,10 +15,16 @@ package trustanchors
 
 import (
 	"context"
+	"errors"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
 	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 )
 
+// ErrNoJWTBundle is returned when a JWT bundle is requested from a trust
+// anchor source which has not been configured with one.
+var ErrNoJWTBundle = errors.New("trust anchors source has no JWT bundle")
+
 // Interface exposes a SPIFFE trust anchor from a source.
 // Allows consumers to get the current trust anchor bundle, and subscribe to
 // bundle updates.
@@ -26,6 +32,10 @@ type Interface interface {
 	// Source implements the SPIFFE trust anchor bundle source.
 	x509bundle.Source
 
+	// Source implements the SPIFFE JWT bundle source, used to validate JWT
+	// SVIDs.
+	jwtbundle.Source
+
 	// CurrentTrustAnchors returns the current trust anchor PEM bundle.
 	CurrentTrustAnchors(ctx context.Context) ([]byte, error)
 
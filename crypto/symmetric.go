@@ -15,324 +15,152 @@ limitations under the License.
 package crypto
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
 	"errors"
 
-	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 	"golang.org/x/crypto/chacha20poly1305"
 
 	"github.com/dapr/kit/crypto/aescbcaead"
-	"github.com/dapr/kit/crypto/aeskw"
-	"github.com/dapr/kit/crypto/padding"
 )
 
 // SupportedSymmetricAlgorithms returns the list of supported symmetric encryption algorithms.
 // This is a subset of the algorithms defined in consts.go.
+// If FIPS mode is enabled, algorithms which are not approved are not included.
 func SupportedSymmetricAlgorithms() []string {
-	return []string{
+	return filterFIPS([]string{
 		Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
 		Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD,
 		Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM,
+		Algorithm_A128GCM_SIV, Algorithm_A256GCM_SIV,
 		Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512,
 		Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW,
 		Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW,
-	}
+	})
 }
 
 // EncryptSymmetric encrypts a message using a symmetric key and the specified algorithm.
 // Note that "associatedData" is ignored if the cipher does not support labels/AAD.
+// To encrypt multiple messages with the same key, NewSymmetricCipher is more efficient.
 func EncryptSymmetric(plaintext []byte, algorithm string, key jwk.Key, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
-	var keyBytes []byte
-	if key.KeyType() != jwa.OctetSeq || key.Raw(&keyBytes) != nil {
-		return nil, nil, ErrKeyTypeMismatch
+	c, err := NewSymmetricCipher(key, algorithm)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	switch algorithm {
-	case Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
-		Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
-		ciphertext, err = encryptSymmetricAESCBC(plaintext, algorithm, keyBytes, nonce)
-		return ciphertext, tag, err
-
-	case Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM:
-		return encryptSymmetricAESGCM(plaintext, algorithm, keyBytes, nonce, associatedData)
-
-	case Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512:
-		return encryptSymmetricAESCBCHMAC(plaintext, algorithm, keyBytes, nonce, associatedData)
-
-	case Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW:
-		ciphertext, err = encryptSymmetricAESKW(plaintext, algorithm, keyBytes)
-		return ciphertext, tag, err
-
-	case Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW:
-		return encryptSymmetricChaCha20Poly1305(plaintext, algorithm, keyBytes, nonce, associatedData)
-
-	default:
-		return nil, nil, ErrUnsupportedAlgorithm
-	}
+	return c.Encrypt(plaintext, nonce, associatedData)
 }
 
 // DecryptSymmetric decrypts an encrypted message using a symmetric key and the specified algorithm.
 // Note that "associatedData" is ignored if the cipher does not support labels/AAD.
+// To decrypt multiple messages with the same key, NewSymmetricCipher is more efficient.
 func DecryptSymmetric(ciphertext []byte, algorithm string, key jwk.Key, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
-	var keyBytes []byte
-	if key.KeyType() != jwa.OctetSeq || key.Raw(&keyBytes) != nil {
-		return nil, ErrKeyTypeMismatch
+	c, err := NewSymmetricCipher(key, algorithm)
+	if err != nil {
+		return nil, err
 	}
 
-	switch algorithm {
-	case Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
-		Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
-		return decryptSymmetricAESCBC(ciphertext, algorithm, keyBytes, nonce)
-
-	case Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM:
-		return decryptSymmetricAESGCM(ciphertext, algorithm, keyBytes, nonce, tag, associatedData)
-
-	case Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512:
-		return decryptSymmetricAESCBCHMAC(ciphertext, algorithm, keyBytes, nonce, tag, associatedData)
-
-	case Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW:
-		return decryptSymmetricAESKW(ciphertext, algorithm, keyBytes)
-
-	case Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW:
-		return decryptSymmetricChaCha20Poly1305(ciphertext, algorithm, keyBytes, nonce, tag, associatedData)
-
-	default:
-		return nil, ErrUnsupportedAlgorithm
-	}
+	return c.Decrypt(ciphertext, nonce, tag, associatedData)
 }
 
 func encryptSymmetricAESCBC(plaintext []byte, algorithm string, key []byte, iv []byte) (ciphertext []byte, err error) {
-	if len(key) != expectedKeySize(algorithm) {
-		return nil, ErrKeyTypeMismatch
-	}
-	if len(iv) != aes.BlockSize {
-		return nil, ErrInvalidNonce
-	}
-
-	switch algorithm {
-	case Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
-		if (len(plaintext) % aes.BlockSize) != 0 {
-			return nil, ErrInvalidPlaintextLength
-		}
-	}
-
-	block, err := aes.NewCipher(key)
+	c, err := newAESCBCCipher(key, algorithm)
 	if err != nil {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	switch algorithm {
-	case Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
-		// nop
-	default:
-		plaintext, err = padding.PadPKCS7(plaintext, aes.BlockSize)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
-
-	ciphertext = make([]byte, len(plaintext))
-	cipher.NewCBCEncrypter(block, iv).
-		CryptBlocks(ciphertext, plaintext)
-
-	return ciphertext, nil
+	ciphertext, _, err = c.Encrypt(plaintext, iv, nil)
+	return ciphertext, err
 }
 
 // Note that when using PKCS#7 padding, this returns a specific error if padding mismatches.
 // Callers are responsible for handling these errors in a way that doesn't introduce the possibility of padding oracle attacks.
 // See: https://research.nccgroup.com/2021/02/17/cryptopals-exploiting-cbc-padding-oracles/
 func decryptSymmetricAESCBC(ciphertext []byte, algorithm string, key []byte, iv []byte) (plaintext []byte, err error) {
-	if len(key) != expectedKeySize(algorithm) {
-		return nil, ErrKeyTypeMismatch
-	}
-	if len(iv) != aes.BlockSize {
-		return nil, ErrInvalidNonce
-	}
-	if (len(ciphertext) % aes.BlockSize) != 0 {
-		return nil, ErrInvalidCiphertextLength
-	}
-
-	block, err := aes.NewCipher(key)
+	c, err := newAESCBCCipher(key, algorithm)
 	if err != nil {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	plaintext = make([]byte, len(ciphertext))
-	cipher.NewCBCDecrypter(block, iv).
-		CryptBlocks(plaintext, ciphertext)
-
-	switch algorithm {
-	case Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
-		// nop
-	default:
-		plaintext, err = padding.UnpadPKCS7(plaintext, aes.BlockSize)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
-
-	return plaintext, nil
+	return c.Decrypt(ciphertext, iv, nil, nil)
 }
 
 func encryptSymmetricAESGCM(plaintext []byte, algorithm string, key []byte, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
-	if len(key) != expectedKeySize(algorithm) {
-		return nil, nil, ErrKeyTypeMismatch
-	}
-
-	block, err := aes.NewCipher(key)
+	c, err := newAESGCMCipher(key, algorithm)
 	if err != nil {
-		return nil, nil, ErrKeyTypeMismatch
-	}
-
-	aead, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, nil, ErrKeyTypeMismatch
+		return nil, nil, err
 	}
-
-	return encryptSymmetricAEAD(aead, plaintext, nonce, associatedData)
+	return c.Encrypt(plaintext, nonce, associatedData)
 }
 
 func encryptSymmetricAESCBCHMAC(plaintext []byte, algorithm string, key []byte, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
-	aead, err := getAESCBCHMACCipher(algorithm, key)
+	c, err := newAESCBCHMACCipher(key, algorithm)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	return encryptSymmetricAEAD(aead, plaintext, nonce, associatedData)
-}
-
-func encryptSymmetricAEAD(aead cipher.AEAD, plaintext []byte, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
-	if len(nonce) != aead.NonceSize() {
-		return nil, nil, ErrInvalidNonce
-	}
-
-	out := aead.Seal(nil, nonce, plaintext, associatedData)
-	// Tag is added at the end
-	tagSize := aead.Overhead()
-	return out[0 : len(out)-tagSize], out[len(out)-tagSize:], nil
+	return c.Encrypt(plaintext, nonce, associatedData)
 }
 
 func decryptSymmetricAESGCM(ciphertext []byte, algorithm string, key []byte, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
-	if len(key) != expectedKeySize(algorithm) {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	aead, err := cipher.NewGCM(block)
+	c, err := newAESGCMCipher(key, algorithm)
 	if err != nil {
-		return nil, ErrKeyTypeMismatch
+		return nil, err
 	}
-
-	return decryptSymmetricAEAD(aead, ciphertext, nonce, tag, associatedData)
+	return c.Decrypt(ciphertext, nonce, tag, associatedData)
 }
 
 func decryptSymmetricAESCBCHMAC(ciphertext []byte, algorithm string, key []byte, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
-	aead, err := getAESCBCHMACCipher(algorithm, key)
+	c, err := newAESCBCHMACCipher(key, algorithm)
 	if err != nil {
 		return nil, err
 	}
-
-	return decryptSymmetricAEAD(aead, ciphertext, nonce, tag, associatedData)
-}
-
-func decryptSymmetricAEAD(aead cipher.AEAD, ciphertext []byte, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
-	if len(nonce) != aead.NonceSize() {
-		return nil, ErrInvalidNonce
-	}
-
-	if len(tag) != aead.Overhead() {
-		return nil, ErrInvalidTag
-	}
-
-	// Add the tag at the end of the ciphertext
-	ciphertext = append(ciphertext, tag...)
-	return aead.Open(nil, nonce, ciphertext, associatedData)
+	return c.Decrypt(ciphertext, nonce, tag, associatedData)
 }
 
 func encryptSymmetricAESKW(plaintext []byte, algorithm string, key []byte) (ciphertext []byte, err error) {
-	if len(key) != expectedKeySize(algorithm) {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	block, err := aes.NewCipher(key)
+	c, err := newAESKWCipher(key, algorithm)
 	if err != nil {
-		return nil, ErrKeyTypeMismatch
+		return nil, err
 	}
-
-	return aeskw.Wrap(block, plaintext)
+	ciphertext, _, err = c.Encrypt(plaintext, nil, nil)
+	return ciphertext, err
 }
 
 func decryptSymmetricAESKW(ciphertext []byte, algorithm string, key []byte) (plaintext []byte, err error) {
-	if len(key) != expectedKeySize(algorithm) {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	block, err := aes.NewCipher(key)
+	c, err := newAESKWCipher(key, algorithm)
 	if err != nil {
-		return nil, ErrKeyTypeMismatch
+		return nil, err
 	}
-
-	return aeskw.Unwrap(block, ciphertext)
+	return c.Decrypt(ciphertext, nil, nil, nil)
 }
 
 func encryptSymmetricChaCha20Poly1305(plaintext []byte, algorithm string, key []byte, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
-	if len(key) != chacha20poly1305.KeySize {
-		return nil, nil, ErrKeyTypeMismatch
-	}
-
-	aead, err := getChaCha20Poly1305Cipher(algorithm, key, nonce)
+	c, err := newChaCha20Poly1305Cipher(key, algorithm)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	// Tag is added at the end
-	out := aead.Seal(nil, nonce, plaintext, associatedData)
-	return out[0 : len(out)-chacha20poly1305.Overhead], out[len(out)-chacha20poly1305.Overhead:], nil
+	return c.Encrypt(plaintext, nonce, associatedData)
 }
 
 func decryptSymmetricChaCha20Poly1305(ciphertext []byte, algorithm string, key []byte, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
-	if len(key) != chacha20poly1305.KeySize {
-		return nil, ErrKeyTypeMismatch
-	}
-
-	aead, err := getChaCha20Poly1305Cipher(algorithm, key, nonce)
+	c, err := newChaCha20Poly1305Cipher(key, algorithm)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(tag) != aead.Overhead() {
-		return nil, ErrInvalidTag
-	}
-
-	// Add the tag at the end of the ciphertext
-	ciphertext = append(ciphertext, tag...)
-	return aead.Open(nil, nonce, ciphertext, associatedData)
+	return c.Decrypt(ciphertext, nonce, tag, associatedData)
 }
 
-func getChaCha20Poly1305Cipher(algorithm string, key []byte, nonce []byte) (aead cipher.AEAD, err error) {
+func getChaCha20Poly1305Cipher(algorithm string, key []byte) (aead cipher.AEAD, err error) {
 	switch algorithm {
 	case Algorithm_C20P, Algorithm_C20PKW:
 		aead, err = chacha20poly1305.New(key)
-		if err == nil && len(nonce) != chacha20poly1305.NonceSize {
-			err = ErrInvalidNonce
-		}
-		return
-
 	case Algorithm_XC20P, Algorithm_XC20PKW:
 		aead, err = chacha20poly1305.NewX(key)
-		if err == nil && len(nonce) != chacha20poly1305.NonceSizeX {
-			err = ErrInvalidNonce
-		}
-		return
+	default:
+		return nil, errors.New("invalid algorithm")
 	}
-
-	return nil, errors.New("invalid algorithm")
+	if err != nil {
+		return nil, ErrKeyTypeMismatch
+	}
+	return aead, nil
 }
 
 func getAESCBCHMACCipher(algorithm string, key []byte) (aead cipher.AEAD, err error) {
@@ -0,0 +1,378 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/dapr/kit/crypto/aesgcmsiv"
+	"github.com/dapr/kit/crypto/aeskw"
+	"github.com/dapr/kit/crypto/padding"
+)
+
+// SymmetricCipher encrypts and decrypts messages with a symmetric key and an
+// algorithm which are prepared once, so repeated operations with the same key
+// don't need to parse the key and derive the key schedule every time.
+// Implementations are safe for concurrent use.
+type SymmetricCipher interface {
+	// Algorithm returns the algorithm of the cipher.
+	Algorithm() string
+	// NonceSize returns the size of the nonce (or IV) required by the cipher,
+	// which is 0 for algorithms that don't use one, such as AES-KW.
+	NonceSize() int
+	// Overhead returns the maximum difference between the length of the
+	// output of Seal and the length of the plaintext.
+	Overhead() int
+
+	// Encrypt encrypts a message, like EncryptSymmetric.
+	// Note that "associatedData" is ignored if the cipher does not support labels/AAD.
+	Encrypt(plaintext []byte, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error)
+	// Decrypt decrypts a message, like DecryptSymmetric.
+	// Note that "associatedData" is ignored if the cipher does not support labels/AAD.
+	Decrypt(ciphertext []byte, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error)
+
+	// Seal encrypts a message and appends the ciphertext, followed by the tag
+	// if any, to dst, returning the updated slice.
+	// If dst has a capacity of at least len(dst)+len(plaintext)+Overhead(),
+	// AEAD ciphers don't allocate memory.
+	Seal(dst []byte, nonce []byte, plaintext []byte, associatedData []byte) ([]byte, error)
+	// Open decrypts a message sealed with Seal, whose tag (if any) is at the
+	// end of ciphertext, and appends the plaintext to dst, returning the
+	// updated slice.
+	Open(dst []byte, nonce []byte, ciphertext []byte, associatedData []byte) ([]byte, error)
+}
+
+// NewSymmetricCipher returns a SymmetricCipher for the key and algorithm,
+// which must be one of SupportedSymmetricAlgorithms.
+func NewSymmetricCipher(key jwk.Key, algorithm string) (SymmetricCipher, error) {
+	if err := checkFIPS(algorithm); err != nil {
+		return nil, err
+	}
+
+	var keyBytes []byte
+	if key.KeyType() != jwa.OctetSeq || key.Raw(&keyBytes) != nil {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	return newSymmetricCipher(keyBytes, algorithm)
+}
+
+func newSymmetricCipher(key []byte, algorithm string) (SymmetricCipher, error) {
+	switch algorithm {
+	case Algorithm_A128CBC, Algorithm_A192CBC, Algorithm_A256CBC,
+		Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
+		return newAESCBCCipher(key, algorithm)
+	case Algorithm_A128GCM, Algorithm_A192GCM, Algorithm_A256GCM:
+		return newAESGCMCipher(key, algorithm)
+	case Algorithm_A128GCM_SIV, Algorithm_A256GCM_SIV:
+		return newAESGCMSIVCipher(key, algorithm)
+	case Algorithm_A128CBC_HS256, Algorithm_A192CBC_HS384, Algorithm_A256CBC_HS512:
+		return newAESCBCHMACCipher(key, algorithm)
+	case Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW:
+		return newAESKWCipher(key, algorithm)
+	case Algorithm_C20P, Algorithm_C20PKW, Algorithm_XC20P, Algorithm_XC20PKW:
+		return newChaCha20Poly1305Cipher(key, algorithm)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+func newAESCBCCipher(key []byte, algorithm string) (*cbcCipher, error) {
+	block, err := newAESBlock(key, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cbcCipher{algorithm: algorithm, block: block, pad: true}
+	switch algorithm {
+	case Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD:
+		c.pad = false
+	}
+	return c, nil
+}
+
+func newAESGCMCipher(key []byte, algorithm string) (*aeadCipher, error) {
+	block, err := newAESBlock(key, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ErrKeyTypeMismatch
+	}
+	return &aeadCipher{algorithm: algorithm, aead: aead}, nil
+}
+
+func newAESGCMSIVCipher(key []byte, algorithm string) (*aeadCipher, error) {
+	if len(key) != expectedKeySize(algorithm) {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	aead, err := aesgcmsiv.New(key)
+	if err != nil {
+		return nil, ErrKeyTypeMismatch
+	}
+	return &aeadCipher{algorithm: algorithm, aead: aead}, nil
+}
+
+func newAESCBCHMACCipher(key []byte, algorithm string) (*aeadCipher, error) {
+	aead, err := getAESCBCHMACCipher(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	// The plaintext is padded with PKCS#7
+	return &aeadCipher{algorithm: algorithm, aead: aead, maxPadding: aes.BlockSize}, nil
+}
+
+func newAESKWCipher(key []byte, algorithm string) (*kwCipher, error) {
+	block, err := newAESBlock(key, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &kwCipher{algorithm: algorithm, block: block}, nil
+}
+
+func newChaCha20Poly1305Cipher(key []byte, algorithm string) (*aeadCipher, error) {
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	aead, err := getChaCha20Poly1305Cipher(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	return &aeadCipher{algorithm: algorithm, aead: aead}, nil
+}
+
+func newAESBlock(key []byte, algorithm string) (cipher.Block, error) {
+	if len(key) != expectedKeySize(algorithm) {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrKeyTypeMismatch
+	}
+	return block, nil
+}
+
+// aeadCipher is a SymmetricCipher for AEAD algorithms.
+type aeadCipher struct {
+	algorithm string
+	aead      cipher.AEAD
+	// Maximum length of the padding added to the plaintext, if any
+	maxPadding int
+}
+
+func (c *aeadCipher) Algorithm() string {
+	return c.algorithm
+}
+
+func (c *aeadCipher) NonceSize() int {
+	return c.aead.NonceSize()
+}
+
+func (c *aeadCipher) Overhead() int {
+	return c.aead.Overhead() + c.maxPadding
+}
+
+func (c *aeadCipher) Encrypt(plaintext []byte, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	out, err := c.Seal(nil, nonce, plaintext, associatedData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Tag is added at the end
+	tagSize := c.aead.Overhead()
+	return out[0 : len(out)-tagSize], out[len(out)-tagSize:], nil
+}
+
+func (c *aeadCipher) Decrypt(ciphertext []byte, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	if len(nonce) != c.aead.NonceSize() {
+		return nil, ErrInvalidNonce
+	}
+	if len(tag) != c.aead.Overhead() {
+		return nil, ErrInvalidTag
+	}
+
+	// Add the tag at the end of the ciphertext, in a new buffer which is then
+	// re-used for the plaintext
+	buf := make([]byte, len(ciphertext)+len(tag))
+	copy(buf, ciphertext)
+	copy(buf[len(ciphertext):], tag)
+	return c.aead.Open(buf[:0], nonce, buf, associatedData)
+}
+
+func (c *aeadCipher) Seal(dst []byte, nonce []byte, plaintext []byte, associatedData []byte) ([]byte, error) {
+	if len(nonce) != c.aead.NonceSize() {
+		return nil, ErrInvalidNonce
+	}
+
+	return c.aead.Seal(dst, nonce, plaintext, associatedData), nil
+}
+
+func (c *aeadCipher) Open(dst []byte, nonce []byte, ciphertext []byte, associatedData []byte) ([]byte, error) {
+	if len(nonce) != c.aead.NonceSize() {
+		return nil, ErrInvalidNonce
+	}
+	if len(ciphertext) < c.aead.Overhead() {
+		return nil, ErrInvalidCiphertextLength
+	}
+
+	return c.aead.Open(dst, nonce, ciphertext, associatedData)
+}
+
+// cbcCipher is a SymmetricCipher for AES-CBC, with or without PKCS#7 padding.
+type cbcCipher struct {
+	algorithm string
+	block     cipher.Block
+	pad       bool
+}
+
+func (c *cbcCipher) Algorithm() string {
+	return c.algorithm
+}
+
+func (c *cbcCipher) NonceSize() int {
+	return aes.BlockSize
+}
+
+func (c *cbcCipher) Overhead() int {
+	if c.pad {
+		return aes.BlockSize
+	}
+	return 0
+}
+
+func (c *cbcCipher) Encrypt(plaintext []byte, nonce []byte, _ []byte) (ciphertext []byte, tag []byte, err error) {
+	ciphertext, err = c.Seal(nil, nonce, plaintext, nil)
+	return ciphertext, nil, err
+}
+
+func (c *cbcCipher) Decrypt(ciphertext []byte, nonce []byte, _ []byte, _ []byte) (plaintext []byte, err error) {
+	return c.Open(nil, nonce, ciphertext, nil)
+}
+
+func (c *cbcCipher) Seal(dst []byte, nonce []byte, plaintext []byte, _ []byte) ([]byte, error) {
+	if len(nonce) != aes.BlockSize {
+		return nil, ErrInvalidNonce
+	}
+
+	size := len(plaintext)
+	if c.pad {
+		size += aes.BlockSize - len(plaintext)%aes.BlockSize
+	} else if len(plaintext)%aes.BlockSize != 0 {
+		return nil, ErrInvalidPlaintextLength
+	}
+
+	// Pad the plaintext in the destination slice, then encrypt it in place
+	res, out := sliceForAppend(dst, size)
+	copy(out, plaintext)
+	for i := len(plaintext); i < size; i++ {
+		out[i] = byte(size - len(plaintext))
+	}
+	cipher.NewCBCEncrypter(c.block, nonce).
+		CryptBlocks(out, out)
+
+	return res, nil
+}
+
+// Note that when using PKCS#7 padding, this returns a specific error if padding mismatches.
+// Callers are responsible for handling these errors in a way that doesn't introduce the possibility of padding oracle attacks.
+// See: https://research.nccgroup.com/2021/02/17/cryptopals-exploiting-cbc-padding-oracles/
+func (c *cbcCipher) Open(dst []byte, nonce []byte, ciphertext []byte, _ []byte) ([]byte, error) {
+	if len(nonce) != aes.BlockSize {
+		return nil, ErrInvalidNonce
+	}
+	if (len(ciphertext) % aes.BlockSize) != 0 {
+		return nil, ErrInvalidCiphertextLength
+	}
+
+	res, out := sliceForAppend(dst, len(ciphertext))
+	cipher.NewCBCDecrypter(c.block, nonce).
+		CryptBlocks(out, ciphertext)
+
+	if !c.pad {
+		return res, nil
+	}
+
+	unpadded, err := padding.UnpadPKCS7(out, aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return res[:len(dst)+len(unpadded)], nil
+}
+
+// kwCipher is a SymmetricCipher for AES Key Wrap, which doesn't use a nonce.
+type kwCipher struct {
+	algorithm string
+	block     cipher.Block
+}
+
+func (c *kwCipher) Algorithm() string {
+	return c.algorithm
+}
+
+func (c *kwCipher) NonceSize() int {
+	return 0
+}
+
+func (c *kwCipher) Overhead() int {
+	return 8
+}
+
+func (c *kwCipher) Encrypt(plaintext []byte, _ []byte, _ []byte) (ciphertext []byte, tag []byte, err error) {
+	ciphertext, err = aeskw.Wrap(c.block, plaintext)
+	return ciphertext, nil, err
+}
+
+func (c *kwCipher) Decrypt(ciphertext []byte, _ []byte, _ []byte, _ []byte) (plaintext []byte, err error) {
+	return aeskw.Unwrap(c.block, ciphertext)
+}
+
+func (c *kwCipher) Seal(dst []byte, _ []byte, plaintext []byte, _ []byte) ([]byte, error) {
+	out, err := aeskw.Wrap(c.block, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, out...), nil
+}
+
+func (c *kwCipher) Open(dst []byte, _ []byte, ciphertext []byte, _ []byte) ([]byte, error) {
+	out, err := aeskw.Unwrap(c.block, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, out...), nil
+}
+
+// sliceForAppend extends in by n bytes, re-allocating it only if its capacity
+// is not enough. It returns the extended slice and the slice of the n new bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return head, tail
+}
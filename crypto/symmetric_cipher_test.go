@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:nosnakecase
+package crypto
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSymmetricKey(t testing.TB, size int) jwk.Key {
+	t.Helper()
+
+	rawKey := make([]byte, size)
+	_, err := rand.Read(rawKey)
+	require.NoError(t, err)
+	key, err := jwk.FromRaw(rawKey)
+	require.NoError(t, err)
+	return key
+}
+
+func TestSymmetricCipher(t *testing.T) {
+	plaintext := []byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit!")
+	aad := []byte("aad")
+
+	for _, alg := range SupportedSymmetricAlgorithms() {
+		t.Run(alg, func(t *testing.T) {
+			info, err := AlgorithmInfo(alg)
+			require.NoError(t, err)
+
+			key := newTestSymmetricKey(t, info.KeySize)
+			c, err := NewSymmetricCipher(key, alg)
+			require.NoError(t, err)
+			assert.Equal(t, alg, c.Algorithm())
+			assert.Equal(t, info.NonceSize, c.NonceSize())
+
+			msg := plaintext
+			switch alg {
+			case Algorithm_A128CBC_NOPAD, Algorithm_A192CBC_NOPAD, Algorithm_A256CBC_NOPAD,
+				Algorithm_A128KW, Algorithm_A192KW, Algorithm_A256KW:
+				msg = plaintext[:32]
+			}
+			nonce := make([]byte, c.NonceSize())
+			_, err = rand.Read(nonce)
+			require.NoError(t, err)
+
+			// Output matches EncryptSymmetric
+			ciphertext, tag, err := c.Encrypt(msg, nonce, aad)
+			require.NoError(t, err)
+			expectCiphertext, expectTag, err := EncryptSymmetric(msg, alg, key, nonce, aad)
+			require.NoError(t, err)
+			assert.Equal(t, expectCiphertext, ciphertext)
+			assert.Equal(t, expectTag, tag)
+
+			decrypted, err := c.Decrypt(ciphertext, nonce, tag, aad)
+			require.NoError(t, err)
+			assert.Equal(t, msg, decrypted)
+
+			// Seal appends the ciphertext and the tag to dst
+			prefix := []byte("prefix")
+			sealed, err := c.Seal(prefix, nonce, msg, aad)
+			require.NoError(t, err)
+			assert.Equal(t, "prefix", string(sealed[:len(prefix)]))
+			assert.Equal(t, append(append([]byte{}, ciphertext...), tag...), sealed[len(prefix):])
+			assert.LessOrEqual(t, len(sealed)-len(prefix)-len(msg), c.Overhead())
+
+			opened, err := c.Open(prefix, nonce, sealed[len(prefix):], aad)
+			require.NoError(t, err)
+			assert.Equal(t, "prefix"+string(msg), string(opened))
+		})
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := NewSymmetricCipher(newTestSymmetricKey(t, 10), Algorithm_A128GCM)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		_, err = NewSymmetricCipher(newTestSymmetricKey(t, 16), Algorithm_C20P)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+
+		_, err = NewSymmetricCipher(newTestSymmetricKey(t, 16), Algorithm_A256GCM_SIV)
+		require.ErrorIs(t, err, ErrKeyTypeMismatch)
+	})
+
+	t.Run("deterministic encryption with AES-GCM-SIV", func(t *testing.T) {
+		c, err := NewSymmetricCipher(newTestSymmetricKey(t, 32), Algorithm_A256GCM_SIV)
+		require.NoError(t, err)
+
+		// With a fixed nonce, the same plaintext is always encrypted to the same ciphertext, so it can be used for lookups
+		nonce := make([]byte, c.NonceSize())
+		sealed1, err := c.Seal(nil, nonce, []byte("key1"), aad)
+		require.NoError(t, err)
+		sealed2, err := c.Seal(nil, nonce, []byte("key1"), aad)
+		require.NoError(t, err)
+		sealed3, err := c.Seal(nil, nonce, []byte("key2"), aad)
+		require.NoError(t, err)
+		assert.Equal(t, sealed1, sealed2)
+		assert.NotEqual(t, sealed1, sealed3)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := NewSymmetricCipher(newTestSymmetricKey(t, 16), Algorithm_RSA_OAEP)
+		require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+
+	t.Run("invalid nonce", func(t *testing.T) {
+		c, err := NewSymmetricCipher(newTestSymmetricKey(t, 16), Algorithm_A128GCM)
+		require.NoError(t, err)
+		_, err = c.Seal(nil, make([]byte, 8), plaintext, nil)
+		require.ErrorIs(t, err, ErrInvalidNonce)
+		_, err = c.Open(nil, make([]byte, 8), plaintext, nil)
+		require.ErrorIs(t, err, ErrInvalidNonce)
+	})
+
+	t.Run("does not modify the inputs", func(t *testing.T) {
+		c, err := NewSymmetricCipher(newTestSymmetricKey(t, 16), Algorithm_A128CBC)
+		require.NoError(t, err)
+
+		// Extra capacity could be used by padding
+		msg := make([]byte, 10, 32)
+		copy(msg, "0123456789")
+		backing := msg[:32]
+		_, _, err = c.Encrypt(msg, make([]byte, 16), nil)
+		require.NoError(t, err)
+		assert.Equal(t, make([]byte, 22), backing[10:])
+	})
+}
+
+func TestSymmetricCipherAllocations(t *testing.T) {
+	c, err := NewSymmetricCipher(newTestSymmetricKey(t, 32), Algorithm_A256GCM)
+	require.NoError(t, err)
+
+	plaintext := make([]byte, 128)
+	nonce := make([]byte, c.NonceSize())
+	buf := make([]byte, 0, len(plaintext)+c.Overhead())
+	allocs := testing.AllocsPerRun(100, func() {
+		sealed, err := c.Seal(buf[:0], nonce, plaintext, nil)
+		if err != nil {
+			panic(err)
+		}
+		_, err = c.Open(plaintext[:0], nonce, sealed, nil)
+		if err != nil {
+			panic(err)
+		}
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkSymmetric(b *testing.B) {
+	for _, alg := range []string{Algorithm_A256GCM, Algorithm_C20P, Algorithm_A256CBC_HS512} {
+		info, err := AlgorithmInfo(alg)
+		require.NoError(b, err)
+		key := newTestSymmetricKey(b, info.KeySize)
+		plaintext := make([]byte, 256)
+		nonce := make([]byte, info.NonceSize)
+
+		b.Run(alg+"/EncryptSymmetric", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_, _, err := EncryptSymmetric(plaintext, alg, key, nonce, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(alg+"/SymmetricCipher.Seal", func(b *testing.B) {
+			c, err := NewSymmetricCipher(key, alg)
+			require.NoError(b, err)
+			buf := make([]byte, 0, len(plaintext)+c.Overhead())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				_, err := c.Seal(buf[:0], nonce, plaintext, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -20,6 +20,10 @@ const (
 	CodeNotSupported  = "NOT_SUPPORTED"
 	CodeIllegalKey    = "ILLEGAL_KEY"
 
+	// Transport
+	CodeComponentUnreachable = "DAPR_COMPONENT_UNREACHABLE"
+	CodeComponentTimeout     = "DAPR_COMPONENT_TIMEOUT"
+
 	// Components
 	CodePrefixStateStore         = "DAPR_STATE_"
 	CodePrefixPubSub             = "DAPR_PUBSUB_"
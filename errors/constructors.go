@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"net/http"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// The constructors in this file return an ErrorBuilder for common families of
+// errors, with the status codes and the error details required by the
+// proposal already set. The reason is used as both the tag and the ErrorInfo
+// reason. Further details can be added before calling Build.
+
+// NotFound returns an ErrorBuilder for a resource which doesn't exist, with
+// ResourceInfo details.
+func NotFound(resourceType string, resourceName string, reason string, message string) *ErrorBuilder {
+	return NewBuilder(grpcCodes.NotFound, http.StatusNotFound, message, reason, "").
+		WithErrorInfo(reason, nil).
+		WithResourceInfo(resourceType, resourceName, "", message)
+}
+
+// InvalidArgument returns an ErrorBuilder for an invalid request. If field is
+// not empty, a BadRequest field violation is added for it.
+func InvalidArgument(field string, reason string, message string) *ErrorBuilder {
+	b := NewBuilder(grpcCodes.InvalidArgument, http.StatusBadRequest, message, reason, "").
+		WithErrorInfo(reason, nil)
+	if field != "" {
+		b.WithFieldViolation(field, message)
+	}
+	return b
+}
+
+// Timeout returns an ErrorBuilder for an operation on a resource which didn't
+// complete in time, with ResourceInfo details.
+func Timeout(resourceType string, resourceName string, reason string, message string) *ErrorBuilder {
+	return NewBuilder(grpcCodes.DeadlineExceeded, http.StatusGatewayTimeout, message, reason, "").
+		WithErrorInfo(reason, nil).
+		WithResourceInfo(resourceType, resourceName, "", message)
+}
+
+// TooManyRequests returns an ErrorBuilder for a request rejected because of
+// rate limiting. If retryAfter is greater than 0, RetryInfo details are added
+// with it as the retry delay.
+func TooManyRequests(retryAfter time.Duration, reason string, message string) *ErrorBuilder {
+	b := NewBuilder(grpcCodes.ResourceExhausted, http.StatusTooManyRequests, message, reason, "").
+		WithErrorInfo(reason, nil)
+	if retryAfter > 0 {
+		b.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	}
+	return b
+}
+
+// Unavailable returns an ErrorBuilder for a resource which can't be reached,
+// with ResourceInfo details. If retryAfter is greater than 0, RetryInfo
+// details are added with it as the retry delay.
+func Unavailable(resourceType string, resourceName string, retryAfter time.Duration, reason string, message string) *ErrorBuilder {
+	b := NewBuilder(grpcCodes.Unavailable, http.StatusServiceUnavailable, message, reason, "").
+		WithErrorInfo(reason, nil).
+		WithResourceInfo(resourceType, resourceName, "", message)
+	if retryAfter > 0 {
+		b.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	}
+	return b
+}
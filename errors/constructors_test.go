@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestConstructors(t *testing.T) {
+	const reason = "DAPR_TEST_REASON"
+	errorInfo := &errdetails.ErrorInfo{Domain: Domain, Reason: reason}
+
+	tests := map[string]struct {
+		builder     *ErrorBuilder
+		grpcCode    grpcCodes.Code
+		httpCode    int
+		wantDetails []proto.Message
+	}{
+		"NotFound": {
+			builder:  NotFound("state", "mystore", reason, "not found"),
+			grpcCode: grpcCodes.NotFound,
+			httpCode: http.StatusNotFound,
+			wantDetails: []proto.Message{
+				errorInfo,
+				&errdetails.ResourceInfo{ResourceType: "state", ResourceName: "mystore", Description: "not found"},
+			},
+		},
+		"InvalidArgument": {
+			builder:  InvalidArgument("key", reason, "invalid key"),
+			grpcCode: grpcCodes.InvalidArgument,
+			httpCode: http.StatusBadRequest,
+			wantDetails: []proto.Message{
+				errorInfo,
+				&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "key", Description: "invalid key"},
+				}},
+			},
+		},
+		"InvalidArgument without field": {
+			builder:     InvalidArgument("", reason, "invalid request"),
+			grpcCode:    grpcCodes.InvalidArgument,
+			httpCode:    http.StatusBadRequest,
+			wantDetails: []proto.Message{errorInfo},
+		},
+		"Timeout": {
+			builder:  Timeout("pubsub", "mypubsub", reason, "timed out"),
+			grpcCode: grpcCodes.DeadlineExceeded,
+			httpCode: http.StatusGatewayTimeout,
+			wantDetails: []proto.Message{
+				errorInfo,
+				&errdetails.ResourceInfo{ResourceType: "pubsub", ResourceName: "mypubsub", Description: "timed out"},
+			},
+		},
+		"TooManyRequests": {
+			builder:  TooManyRequests(2*time.Second, reason, "slow down"),
+			grpcCode: grpcCodes.ResourceExhausted,
+			httpCode: http.StatusTooManyRequests,
+			wantDetails: []proto.Message{
+				errorInfo,
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(2 * time.Second)},
+			},
+		},
+		"TooManyRequests without retry delay": {
+			builder:     TooManyRequests(0, reason, "slow down"),
+			grpcCode:    grpcCodes.ResourceExhausted,
+			httpCode:    http.StatusTooManyRequests,
+			wantDetails: []proto.Message{errorInfo},
+		},
+		"Unavailable": {
+			builder:  Unavailable("binding", "mybinding", time.Second, reason, "unreachable"),
+			grpcCode: grpcCodes.Unavailable,
+			httpCode: http.StatusServiceUnavailable,
+			wantDetails: []proto.Message{
+				errorInfo,
+				&errdetails.ResourceInfo{ResourceType: "binding", ResourceName: "mybinding", Description: "unreachable"},
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var kitErr Error
+			require.NotPanics(t, func() {
+				kitErr = tc.builder.Build().(Error)
+			})
+
+			assert.Equal(t, tc.grpcCode, kitErr.GrpcStatusCode())
+			assert.Equal(t, tc.httpCode, kitErr.HTTPStatusCode())
+			assert.Equal(t, reason, kitErr.ErrorCode())
+			require.Len(t, kitErr.details, len(tc.wantDetails))
+			for i, d := range tc.wantDetails {
+				assert.True(t, proto.Equal(d, kitErr.details[i]), "detail %d: %v", i, kitErr.details[i])
+			}
+		})
+	}
+
+	t.Run("details can be added", func(t *testing.T) {
+		kitErr := NotFound("state", "mystore", reason, "not found").
+			WithHelpLink("https://docs.dapr.io", "docs").
+			Build().(Error)
+		assert.Len(t, kitErr.details, 3)
+	})
+}
@@ -178,7 +178,14 @@ func (e Error) GRPCStatus() *status.Status {
 /*** HTTP Methods ***/
 
 // JSONErrorValue implements the errorResponseValue interface.
+// The details are encoded with the format set with SetJSONDetailsFormat.
 func (e Error) JSONErrorValue() []byte {
+	return e.JSONErrorValueWith(JSONOptions{})
+}
+
+// JSONErrorValueWith returns the JSON representation of the error, encoded
+// with the given options.
+func (e Error) JSONErrorValueWith(opts JSONOptions) []byte {
 	grpcStatus := e.GRPCStatus().Proto()
 
 	// Make httpCode human readable
@@ -196,18 +203,11 @@ func (e Error) JSONErrorValue() []byte {
 	}
 
 	// Handle err details
-	details := e.details
-	if len(details) > 0 {
-		errJSON.Details = make([]any, len(details))
-		for i, detail := range details {
-			detailMap, errorCode := convertErrorDetails(detail, e)
-			errJSON.Details[i] = detailMap
-
-			// If there is an errorCode, update the overall ErrorCode
-			if errorCode != "" {
-				errJSON.ErrorCode = errorCode
-			}
-		}
+	var errorCode string
+	errJSON.Details, errorCode = e.jsonDetails(opts.DetailsFormat)
+	// If there is an errorCode, update the overall ErrorCode
+	if errorCode != "" {
+		errJSON.ErrorCode = errorCode
 	}
 
 	errBytes, err := json.Marshal(errJSON)
@@ -218,6 +218,38 @@ func (e Error) JSONErrorValue() []byte {
 	return errBytes
 }
 
+// jsonDetails converts the error details into their JSON representation,
+// with the given format.
+// It also returns the error code from the last ErrorInfo detail, if any.
+func (e Error) jsonDetails(format JSONDetailsFormat) ([]any, string) {
+	if len(e.details) == 0 {
+		return nil, ""
+	}
+
+	format = format.resolve()
+	var errorCode string
+	details := make([]any, len(e.details))
+	for i, detail := range e.details {
+		detailMap, detailErrorCode := convertErrorDetails(detail, e)
+		details[i] = detailMap
+		if detailErrorCode != "" {
+			errorCode = detailErrorCode
+		}
+
+		if format == JSONDetailsFormatGRPCGateway {
+			raw, err := gatewayJSONDetail(detail)
+			if err != nil {
+				// Keep the legacy representation
+				log.Debugf("Failed to encode error details: %s", err)
+				continue
+			}
+			details[i] = raw
+		}
+	}
+
+	return details, errorCode
+}
+
 func convertErrorDetails(detail any, e Error) (map[string]interface{}, string) {
 	// cast to interface to be able to do type switch
 	// over all possible error_details defined
@@ -368,6 +400,12 @@ func NewBuilder(grpcCode grpcCodes.Code, httpCode int, message string, tag strin
 	}
 }
 
+// NewFromGRPC creates a new ErrorBuilder with the given gRPC status code, and
+// the HTTP status code mapped from it by HTTPStatusFromGRPCCode.
+func NewFromGRPC(grpcCode grpcCodes.Code, message string, tag string) *ErrorBuilder {
+	return NewBuilder(grpcCode, HTTPStatusFromGRPCCode(grpcCode), message, tag, "")
+}
+
 // WithResourceInfo is used to pass ResourceInfo error details to the Error struct.
 func (b *ErrorBuilder) WithResourceInfo(resourceType string, resourceName string, owner string, description string) *ErrorBuilder {
 	resourceInfo := &errdetails.ResourceInfo{
@@ -860,6 +860,20 @@ func TestErrorBuilder_Build(t *testing.T) {
 	})
 }
 
+func TestNewFromGRPC(t *testing.T) {
+	built := NewFromGRPC(grpcCodes.NotFound, "Test Msg", "SOME_ERROR").
+		WithErrorInfo("fake", nil).
+		Build()
+
+	builtErr, ok := built.(Error)
+	require.True(t, ok)
+	assert.Equal(t, grpcCodes.NotFound, builtErr.GrpcStatusCode())
+	assert.Equal(t, http.StatusNotFound, builtErr.HTTPStatusCode())
+	assert.Equal(t, "Test Msg", builtErr.message)
+	assert.Equal(t, "SOME_ERROR", builtErr.ErrorCode())
+	assert.Empty(t, builtErr.Category())
+}
+
 // This test ensures that all the error details google provides are covered in our switch case
 // in errors.go. If google adds an error detail, this test should fail, and we should add
 // that specific error detail to the switch case
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+
+	"github.com/dapr/kit/grpccodes"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	// ErrorCodesFeatureMetadataKey is the key in a component's metadata that
+	// enables returning the rich error model to HTTP callers.
+	ErrorCodesFeatureMetadataKey = "error_codes_feature"
+
+	// legacyErrorCode is the error code returned to HTTP callers when the
+	// error codes feature is disabled and the error doesn't have a tag.
+	legacyErrorCode = "ERR_INTERNAL"
+
+	// statusClientClosedRequest is the non-standard HTTP status code used for
+	// requests canceled by the client.
+	statusClientClosedRequest = 499
+
+	// HTTP headers with the retry and rate limit hints of errors.
+	// RateLimit-* headers follow the IETF draft "RateLimit header fields for HTTP".
+	headerRetryAfter         = "Retry-After"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+)
+
+// HTTPStatusFromGRPCCode returns the HTTP status code corresponding to a gRPC
+// status code, following the HTTP mapping of google.rpc.Code used by
+// gRPC-Gateway for transcoding.
+// Unlike grpccodes.HTTPStatusFromCode, Canceled is mapped to 499 (Client
+// Closed Request).
+// See: https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+func HTTPStatusFromGRPCCode(code grpcCodes.Code) int {
+	if code == grpcCodes.Canceled {
+		return statusClientClosedRequest
+	}
+	return grpccodes.HTTPStatusFromCode(code)
+}
+
+// ErrorCodesFeatureEnabled returns true if the error codes feature is enabled
+// in the given metadata.
+func ErrorCodesFeatureEnabled(md map[string]string) bool {
+	return utils.IsTruthy(md[ErrorCodesFeatureMetadataKey])
+}
+
+// WriteHTTP writes err to the HTTP response.
+// If the error codes feature is enabled in md and err is a kit Error or a
+// MultiError, the response uses the error's HTTP status code and its JSON
+// value, including the details; for kit Errors, the headers set by
+// WriteHTTPHeaders are included too.
+// Otherwise, it falls back to the legacy behavior: the response has status
+// code 500 and only contains the error code (the error's tag, or
+// "ERR_INTERNAL") and the message.
+func WriteHTTP(w http.ResponseWriter, err error, md map[string]string) {
+	if err == nil {
+		return
+	}
+
+	featureEnabled := ErrorCodesFeatureEnabled(md)
+	code, body := httpResponse(err, featureEnabled)
+	if featureEnabled {
+		var multiErr *MultiError
+		if kitErr, ok := FromError(err); ok && !errors.As(err, &multiErr) {
+			kitErr.WriteHTTPHeaders(w.Header())
+		}
+	}
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// httpResponse returns the status code and the body of the HTTP response for err.
+func httpResponse(err error, featureEnabled bool) (int, []byte) {
+	var multiErr *MultiError
+	if errors.As(err, &multiErr) && featureEnabled {
+		return multiErr.HTTPStatusCode(), multiErr.JSONErrorValue()
+	}
+
+	kitErr, ok := FromError(err)
+	if ok && featureEnabled {
+		return kitErr.HTTPStatusCode(), kitErr.JSONErrorValue()
+	}
+
+	errJSON := errorJSON{
+		ErrorCode: legacyErrorCode,
+		Message:   err.Error(),
+	}
+	if ok {
+		if kitErr.tag != "" {
+			errJSON.ErrorCode = kitErr.tag
+		}
+		errJSON.Message = kitErr.message
+	}
+
+	body, _ := json.Marshal(errJSON)
+	return http.StatusInternalServerError, body
+}
+
+// WriteHTTPHeaders sets the HTTP response headers which surface the retry and
+// rate limit hints in the error's details, so clients don't need to parse the
+// body:
+//   - A RetryInfo detail sets Retry-After to its delay, in seconds rounded up.
+//   - A QuotaFailure detail sets RateLimit-Remaining to 0 and, if the error has
+//     a RetryInfo detail too, RateLimit-Reset to the same delay.
+//
+// Headers are not modified if the error has no such details.
+func (e *Error) WriteHTTPHeaders(h http.Header) {
+	var (
+		retryDelay   time.Duration
+		hasRetry     bool
+		quotaFailure bool
+	)
+	for _, detail := range e.details {
+		switch typedDetail := detail.(type) {
+		case *errdetails.RetryInfo:
+			if typedDetail.GetRetryDelay() != nil && !hasRetry {
+				retryDelay = typedDetail.GetRetryDelay().AsDuration()
+				hasRetry = true
+			}
+		case *errdetails.QuotaFailure:
+			quotaFailure = true
+		}
+	}
+
+	var delaySeconds string
+	if hasRetry {
+		delaySeconds = strconv.FormatInt(int64(math.Ceil(max(retryDelay, 0).Seconds())), 10)
+		h.Set(headerRetryAfter, delaySeconds)
+	}
+	if quotaFailure {
+		h.Set(headerRateLimitRemaining, "0")
+		if hasRetry {
+			h.Set(headerRateLimitReset, delaySeconds)
+		}
+	}
+}
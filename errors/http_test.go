@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestWriteHTTP(t *testing.T) {
+	kitErr := NewBuilder(grpcCodes.NotFound, http.StatusNotFound, "state store not found", "ERR_STATE_STORE_NOT_FOUND", "").
+		WithErrorInfo(CodePrefixStateStore+CodeNotFound, nil).
+		Build()
+	multiErr := Join("bulk failed", kitErr, errors.New("plain"))
+	enabled := map[string]string{ErrorCodesFeatureMetadataKey: "true"}
+
+	tests := map[string]struct {
+		err      error
+		md       map[string]string
+		wantCode int
+		wantBody string
+	}{
+		"kit error, feature enabled": {
+			err:      kitErr,
+			md:       enabled,
+			wantCode: http.StatusNotFound,
+			wantBody: string(kitErr.(Error).JSONErrorValue()),
+		},
+		"wrapped kit error, feature enabled": {
+			err:      fmt.Errorf("wrapped: %w", kitErr),
+			md:       enabled,
+			wantCode: http.StatusNotFound,
+			wantBody: string(kitErr.(Error).JSONErrorValue()),
+		},
+		"multi error, feature enabled": {
+			err:      multiErr,
+			md:       enabled,
+			wantCode: http.StatusInternalServerError,
+			wantBody: string(multiErr.(*MultiError).JSONErrorValue()),
+		},
+		"kit error, feature disabled": {
+			err:      kitErr,
+			md:       map[string]string{ErrorCodesFeatureMetadataKey: "false"},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"errorCode":"ERR_STATE_STORE_NOT_FOUND","message":"state store not found"}`,
+		},
+		"kit error, nil metadata": {
+			err:      kitErr,
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"errorCode":"ERR_STATE_STORE_NOT_FOUND","message":"state store not found"}`,
+		},
+		"plain error, feature enabled": {
+			err:      errors.New("something failed"),
+			md:       enabled,
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"errorCode":"ERR_INTERNAL","message":"something failed"}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteHTTP(rec, tc.err, tc.md)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, ContentTypeJSON, rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.wantBody, rec.Body.String())
+		})
+	}
+
+	t.Run("nil error writes nothing", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteHTTP(rec, nil, enabled)
+
+		assert.False(t, rec.Flushed)
+		assert.Empty(t, rec.Header())
+		assert.Zero(t, rec.Body.Len())
+	})
+}
+
+func TestWriteHTTPHeaders(t *testing.T) {
+	retryInfo := func(d time.Duration) *errdetails.RetryInfo {
+		return &errdetails.RetryInfo{RetryDelay: durationpb.New(d)}
+	}
+	quotaFailure := &errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{Subject: "app:myapp", Description: "too many requests"}},
+	}
+
+	tests := map[string]struct {
+		details []proto.Message
+		want    http.Header
+	}{
+		"no details": {
+			want: http.Header{},
+		},
+		"retry info": {
+			details: []proto.Message{retryInfo(5 * time.Second)},
+			want:    http.Header{"Retry-After": {"5"}},
+		},
+		"retry delay rounded up": {
+			details: []proto.Message{retryInfo(1500 * time.Millisecond)},
+			want:    http.Header{"Retry-After": {"2"}},
+		},
+		"negative retry delay": {
+			details: []proto.Message{retryInfo(-time.Second)},
+			want:    http.Header{"Retry-After": {"0"}},
+		},
+		"quota failure": {
+			details: []proto.Message{quotaFailure},
+			want:    http.Header{"Ratelimit-Remaining": {"0"}},
+		},
+		"quota failure with retry info": {
+			details: []proto.Message{quotaFailure, retryInfo(time.Minute)},
+			want: http.Header{
+				"Retry-After":         {"60"},
+				"Ratelimit-Remaining": {"0"},
+				"Ratelimit-Reset":     {"60"},
+			},
+		},
+		"retry info without delay": {
+			details: []proto.Message{&errdetails.RetryInfo{}},
+			want:    http.Header{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kitErr, ok := FromError(NewBuilder(grpcCodes.ResourceExhausted, http.StatusTooManyRequests, "rate limited", "ERR_RATE_LIMITED", "").
+				WithErrorInfo("DAPR_RATE_LIMITED", nil).
+				WithDetails(tc.details...).
+				Build())
+			require.True(t, ok)
+
+			h := http.Header{}
+			kitErr.WriteHTTPHeaders(h)
+			assert.Equal(t, tc.want, h)
+		})
+	}
+
+	t.Run("written by WriteHTTP", func(t *testing.T) {
+		err := NewBuilder(grpcCodes.ResourceExhausted, http.StatusTooManyRequests, "rate limited", "ERR_RATE_LIMITED", "").
+			WithErrorInfo("DAPR_RATE_LIMITED", nil).
+			WithDetails(quotaFailure, retryInfo(10*time.Second)).
+			Build()
+
+		w := httptest.NewRecorder()
+		WriteHTTP(w, err, map[string]string{ErrorCodesFeatureMetadataKey: "true"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "10", w.Header().Get("Retry-After"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "10", w.Header().Get("RateLimit-Reset"))
+
+		// Not written with the legacy response
+		w = httptest.NewRecorder()
+		WriteHTTP(w, err, nil)
+		assert.Empty(t, w.Header().Get("Retry-After"))
+	})
+}
+
+func TestHTTPStatusFromGRPCCode(t *testing.T) {
+	tests := map[grpcCodes.Code]int{
+		grpcCodes.OK:                 http.StatusOK,
+		grpcCodes.Canceled:           499,
+		grpcCodes.Unknown:            http.StatusInternalServerError,
+		grpcCodes.InvalidArgument:    http.StatusBadRequest,
+		grpcCodes.DeadlineExceeded:   http.StatusGatewayTimeout,
+		grpcCodes.NotFound:           http.StatusNotFound,
+		grpcCodes.AlreadyExists:      http.StatusConflict,
+		grpcCodes.PermissionDenied:   http.StatusForbidden,
+		grpcCodes.Unauthenticated:    http.StatusUnauthorized,
+		grpcCodes.ResourceExhausted:  http.StatusTooManyRequests,
+		grpcCodes.FailedPrecondition: http.StatusBadRequest,
+		grpcCodes.Aborted:            http.StatusConflict,
+		grpcCodes.OutOfRange:         http.StatusBadRequest,
+		grpcCodes.Unimplemented:      http.StatusNotImplemented,
+		grpcCodes.Internal:           http.StatusInternalServerError,
+		grpcCodes.Unavailable:        http.StatusServiceUnavailable,
+		grpcCodes.DataLoss:           http.StatusInternalServerError,
+		grpcCodes.Code(100):          http.StatusInternalServerError,
+	}
+
+	for code, want := range tests {
+		t.Run(code.String(), func(t *testing.T) {
+			assert.Equal(t, want, HTTPStatusFromGRPCCode(code))
+		})
+	}
+}
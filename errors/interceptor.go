@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataKeyResourceType is the key of the outgoing gRPC metadata with the type of the resource being invoked, such as "state".
+	// It's used by the client interceptors to populate the ResourceInfo of the errors.
+	MetadataKeyResourceType = "dapr-resource-type"
+	// MetadataKeyResourceName is the key of the outgoing gRPC metadata with the name of the resource being invoked.
+	// It's used by the client interceptors to populate the ResourceInfo of the errors.
+	MetadataKeyResourceName = "dapr-resource-name"
+
+	categoryTransport = "transport"
+)
+
+// UnaryClientInterceptor returns a gRPC unary client interceptor that converts transport failures into Errors.
+// See EnrichTransportError for details.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		return EnrichTransportError(ctx, method, err)
+	}
+}
+
+// StreamClientInterceptor returns a gRPC stream client interceptor that converts transport failures that occur while establishing a stream into Errors.
+// See EnrichTransportError for details.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		return cs, EnrichTransportError(ctx, method, err)
+	}
+}
+
+// EnrichTransportError converts gRPC errors with the Unavailable and DeadlineExceeded codes into Errors with a standardized ErrorInfo reason:
+// CodeComponentUnreachable and CodeComponentTimeout respectively.
+// If the outgoing metadata in ctx contains the MetadataKeyResourceType and MetadataKeyResourceName keys, they're used to add a ResourceInfo detail.
+// Errors which are already Errors, which have an ErrorInfo detail, or which have other codes are returned as-is.
+func EnrichTransportError(ctx context.Context, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := FromError(err); ok {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var (
+		httpCode int
+		reason   string
+	)
+	switch st.Code() {
+	case grpcCodes.Unavailable:
+		httpCode = http.StatusServiceUnavailable
+		reason = CodeComponentUnreachable
+	case grpcCodes.DeadlineExceeded:
+		httpCode = http.StatusGatewayTimeout
+		reason = CodeComponentTimeout
+	default:
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		if _, ok := detail.(*errdetails.ErrorInfo); ok {
+			// The error has already been standardized by the server
+			return err
+		}
+	}
+
+	builder := NewBuilder(st.Code(), httpCode, st.Message(), "", categoryTransport).
+		WithErrorInfo(reason, map[string]string{"method": method})
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	resourceType := firstMetadataValue(md, MetadataKeyResourceType)
+	resourceName := firstMetadataValue(md, MetadataKeyResourceName)
+	if resourceType != "" || resourceName != "" {
+		builder = builder.WithResourceInfo(resourceType, resourceName, "", st.Message())
+	}
+
+	return builder.Build()
+}
+
+func firstMetadataValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
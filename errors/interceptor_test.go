@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestEnrichTransportError(t *testing.T) {
+	const method = "/dapr.proto.components.v1.StateStore/Get"
+
+	t.Run("nil error", func(t *testing.T) {
+		require.NoError(t, EnrichTransportError(context.Background(), method, nil))
+	})
+
+	t.Run("non-status error is returned as-is", func(t *testing.T) {
+		err := errors.New("boom")
+		assert.Equal(t, err, EnrichTransportError(context.Background(), method, err))
+	})
+
+	t.Run("other codes are returned as-is", func(t *testing.T) {
+		err := status.Error(grpcCodes.NotFound, "not found")
+		assert.Equal(t, err, EnrichTransportError(context.Background(), method, err))
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			MetadataKeyResourceType, "state",
+			MetadataKeyResourceName, "mystore",
+		)
+		err := EnrichTransportError(ctx, method, status.Error(grpcCodes.Unavailable, "connection refused"))
+
+		kitErr, ok := FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusServiceUnavailable, kitErr.HTTPStatusCode())
+		assert.Equal(t, grpcCodes.Unavailable, kitErr.GRPCStatus().Code())
+		assert.Equal(t, "connection refused", kitErr.GRPCStatus().Message())
+
+		var (
+			errInfo      *errdetails.ErrorInfo
+			resourceInfo *errdetails.ResourceInfo
+		)
+		for _, d := range kitErr.GRPCStatus().Details() {
+			switch v := d.(type) {
+			case *errdetails.ErrorInfo:
+				errInfo = v
+			case *errdetails.ResourceInfo:
+				resourceInfo = v
+			}
+		}
+		require.NotNil(t, errInfo)
+		assert.Equal(t, CodeComponentUnreachable, errInfo.GetReason())
+		assert.Equal(t, method, errInfo.GetMetadata()["method"])
+		require.NotNil(t, resourceInfo)
+		assert.Equal(t, "state", resourceInfo.GetResourceType())
+		assert.Equal(t, "mystore", resourceInfo.GetResourceName())
+	})
+
+	t.Run("deadline exceeded without resource metadata", func(t *testing.T) {
+		err := EnrichTransportError(context.Background(), method, status.Error(grpcCodes.DeadlineExceeded, "deadline exceeded"))
+
+		kitErr, ok := FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusGatewayTimeout, kitErr.HTTPStatusCode())
+		assert.Equal(t, CodeComponentTimeout, kitErr.ErrorCode())
+		for _, d := range kitErr.GRPCStatus().Details() {
+			_, isResourceInfo := d.(*errdetails.ResourceInfo)
+			assert.False(t, isResourceInfo)
+		}
+	})
+
+	t.Run("errors with ErrorInfo are returned as-is", func(t *testing.T) {
+		st, err := status.New(grpcCodes.Unavailable, "unavailable").
+			WithDetails(&errdetails.ErrorInfo{Reason: "DAPR_STATE_UNAVAILABLE", Domain: Domain})
+		require.NoError(t, err)
+		assert.Equal(t, st.Err(), EnrichTransportError(context.Background(), method, st.Err()))
+	})
+
+	t.Run("kit errors are returned as-is", func(t *testing.T) {
+		kitErr := NewBuilder(grpcCodes.Unavailable, http.StatusServiceUnavailable, "unavailable", "", "").
+			WithErrorInfo("DAPR_STATE_UNAVAILABLE", nil).
+			Build()
+		assert.Equal(t, kitErr, EnrichTransportError(context.Background(), method, kitErr))
+	})
+}
+
+func TestClientInterceptors(t *testing.T) {
+	const method = "/test/Method"
+	transportErr := status.Error(grpcCodes.Unavailable, "connection refused")
+
+	t.Run("unary", func(t *testing.T) {
+		err := UnaryClientInterceptor()(context.Background(), method, nil, nil, nil,
+			func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				return transportErr
+			},
+		)
+		kitErr, ok := FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, CodeComponentUnreachable, kitErr.ErrorCode())
+
+		err = UnaryClientInterceptor()(context.Background(), method, nil, nil, nil,
+			func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				return nil
+			},
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		_, err := StreamClientInterceptor()(context.Background(), &grpc.StreamDesc{}, nil, method,
+			func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				return nil, transportErr
+			},
+		)
+		kitErr, ok := FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, CodeComponentUnreachable, kitErr.ErrorCode())
+	})
+}
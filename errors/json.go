@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// JSONDetailsFormat is the encoding of the details in the JSON
+// representation of errors.
+type JSONDetailsFormat int32
+
+const (
+	// JSONDetailsFormatDefault uses the format set with SetJSONDetailsFormat,
+	// which is JSONDetailsFormatLegacy unless changed.
+	JSONDetailsFormatDefault JSONDetailsFormat = iota
+	// JSONDetailsFormatLegacy encodes the known error details as objects with
+	// snake_case field names, and the unknown ones with their Go
+	// representation.
+	JSONDetailsFormatLegacy
+	// JSONDetailsFormatGRPCGateway encodes the details with protojson as
+	// google.protobuf.Any messages, with camelCase field names and unpopulated
+	// fields included. This is identical to the details of the errors
+	// returned by grpc-gateway, so clients can parse both the same way.
+	JSONDetailsFormatGRPCGateway
+)
+
+var defaultJSONDetailsFormat atomic.Int32
+
+// gatewayMarshalOptions are the options used by the default marshaler of
+// grpc-gateway.
+var gatewayMarshalOptions = protojson.MarshalOptions{
+	EmitUnpopulated: true,
+}
+
+// SetJSONDetailsFormat sets the format of the details used by
+// JSONErrorValue and ProblemJSON, for all errors.
+// Setting JSONDetailsFormatDefault restores JSONDetailsFormatLegacy.
+func SetJSONDetailsFormat(format JSONDetailsFormat) {
+	defaultJSONDetailsFormat.Store(int32(format))
+}
+
+// JSONOptions configures the JSON representation of errors returned by
+// JSONErrorValueWith.
+type JSONOptions struct {
+	// DetailsFormat is the encoding of the details.
+	// Defaults to the format set with SetJSONDetailsFormat.
+	DetailsFormat JSONDetailsFormat
+}
+
+// resolve returns the format to use, replacing JSONDetailsFormatDefault with
+// the format set with SetJSONDetailsFormat.
+func (f JSONDetailsFormat) resolve() JSONDetailsFormat {
+	if f == JSONDetailsFormatDefault {
+		f = JSONDetailsFormat(defaultJSONDetailsFormat.Load())
+	}
+	if f == JSONDetailsFormatDefault {
+		f = JSONDetailsFormatLegacy
+	}
+	return f
+}
+
+// gatewayJSONDetail encodes the detail as grpc-gateway does, wrapped in a
+// google.protobuf.Any.
+func gatewayJSONDetail(detail proto.Message) (json.RawMessage, error) {
+	a, err := anypb.New(detail)
+	if err != nil {
+		return nil, err
+	}
+	return gatewayMarshalOptions.Marshal(a)
+}
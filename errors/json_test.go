@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestJSONDetailsFormat(t *testing.T) {
+	build := func() Error {
+		return NewBuilder(grpcCodes.ResourceExhausted, http.StatusTooManyRequests, "too many requests", "", "").
+			WithErrorInfo("DAPR_STATE_TOO_MANY_REQUESTS", map[string]string{"storeName": "mystore"}).
+			WithResourceInfo("state", "mystore", "", "too many requests").
+			WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(5 * time.Second)}).
+			Build().(Error)
+	}
+
+	type body struct {
+		ErrorCode string            `json:"errorCode"`
+		Message   string            `json:"message"`
+		Details   []json.RawMessage `json:"details"`
+	}
+
+	t.Run("legacy format by default", func(t *testing.T) {
+		err := build()
+		assert.Equal(t, err.JSONErrorValue(), err.JSONErrorValueWith(JSONOptions{DetailsFormat: JSONDetailsFormatLegacy}))
+		assert.Contains(t, string(err.JSONErrorValue()), `"resource_type":"state"`)
+	})
+
+	t.Run("grpc-gateway format", func(t *testing.T) {
+		err := build()
+
+		var res body
+		require.NoError(t, json.Unmarshal(err.JSONErrorValueWith(JSONOptions{DetailsFormat: JSONDetailsFormatGRPCGateway}), &res))
+		assert.Equal(t, "DAPR_STATE_TOO_MANY_REQUESTS", res.ErrorCode)
+		assert.Equal(t, "too many requests", res.Message)
+		require.Len(t, res.Details, 3)
+		assert.JSONEq(t, `{
+			"@type": "type.googleapis.com/google.rpc.ErrorInfo",
+			"reason": "DAPR_STATE_TOO_MANY_REQUESTS",
+			"domain": "dapr.io",
+			"metadata": {"storeName": "mystore"}
+		}`, string(res.Details[0]))
+		assert.JSONEq(t, `{
+			"@type": "type.googleapis.com/google.rpc.ResourceInfo",
+			"resourceType": "state",
+			"resourceName": "mystore",
+			"owner": "",
+			"description": "too many requests"
+		}`, string(res.Details[1]))
+		assert.JSONEq(t, `{
+			"@type": "type.googleapis.com/google.rpc.RetryInfo",
+			"retryDelay": "5s"
+		}`, string(res.Details[2]))
+
+		// The details are the same as in the status encoded by grpc-gateway
+		gatewayJSON, gwErr := gatewayMarshalOptions.Marshal(err.GRPCStatus().Proto())
+		require.NoError(t, gwErr)
+		var gateway body
+		require.NoError(t, json.Unmarshal(gatewayJSON, &gateway))
+		require.Len(t, gateway.Details, len(res.Details))
+		for i := range gateway.Details {
+			assert.JSONEq(t, string(gateway.Details[i]), string(res.Details[i]))
+		}
+	})
+
+	t.Run("global format", func(t *testing.T) {
+		SetJSONDetailsFormat(JSONDetailsFormatGRPCGateway)
+		t.Cleanup(func() {
+			SetJSONDetailsFormat(JSONDetailsFormatDefault)
+		})
+
+		err := build()
+		assert.Contains(t, string(err.JSONErrorValue()), `"resourceType":"state"`)
+		assert.Contains(t, string(err.ProblemJSON()), `"resourceType":"state"`)
+
+		// Per-call options take precedence
+		assert.Contains(t, string(err.JSONErrorValueWith(JSONOptions{DetailsFormat: JSONDetailsFormatLegacy})), `"resource_type":"state"`)
+
+		// Aggregated errors use the same format
+		multiErr := Join("bulk failed", err).(*MultiError)
+		assert.Contains(t, string(multiErr.JSONErrorValue()), `"resourceType":"state"`)
+		assert.Contains(t, string(multiErr.JSONErrorValueWith(JSONOptions{DetailsFormat: JSONDetailsFormatLegacy})), `"resource_type":"state"`)
+	})
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultLocale is the locale used by catalogs when no message is registered
+// for the requested locale, unless changed with SetFallbackLocale.
+const DefaultLocale = "en-US"
+
+// DefaultCatalog is the message catalog used by Error.Localize.
+var DefaultCatalog = NewMessageCatalog()
+
+// RegisterLocalizedMessage registers a localized message template in
+// DefaultCatalog. See MessageCatalog.Register.
+func RegisterLocalizedMessage(key string, locale string, template string) {
+	DefaultCatalog.Register(key, locale, template)
+}
+
+// MessageCatalog contains localized message templates, keyed by error tag or
+// ErrorInfo reason and by locale.
+// It is safe for concurrent use.
+type MessageCatalog struct {
+	lock     sync.RWMutex
+	fallback string
+	// Key -> normalized locale -> template
+	messages map[string]map[string]localizedTemplate
+}
+
+type localizedTemplate struct {
+	locale   string
+	template string
+}
+
+// NewMessageCatalog returns a new, empty MessageCatalog which falls back to
+// DefaultLocale.
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{
+		fallback: normalizeLocale(DefaultLocale),
+		messages: make(map[string]map[string]localizedTemplate),
+	}
+}
+
+// SetFallbackLocale sets the locale whose message is used when none is
+// registered for the requested locale or its base language.
+func (c *MessageCatalog) SetFallbackLocale(locale string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.fallback = normalizeLocale(locale)
+}
+
+// Register adds the message template for the given error tag or ErrorInfo
+// reason, in a locale identified by a BCP 47 tag such as "en-US" or "fr".
+// Templates can contain placeholders in the form "{name}", which are replaced
+// with the value of the ErrorInfo metadata with the same key.
+// Registering a template for an existing key and locale replaces it.
+func (c *MessageCatalog) Register(key string, locale string, template string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.messages[key] == nil {
+		c.messages[key] = make(map[string]localizedTemplate)
+	}
+	c.messages[key][normalizeLocale(locale)] = localizedTemplate{
+		locale:   strings.TrimSpace(locale),
+		template: template,
+	}
+}
+
+// Lookup returns the message template for the key in the requested locale,
+// and the locale of the template as registered. Lookups fall back, in order, to:
+//   - The base language of the locale, e.g. "pt" for "pt-BR".
+//   - Any other region of the same language, e.g. "pt-PT" for "pt-BR".
+//   - The fallback locale of the catalog, and its base language.
+//
+// Locales are compared case-insensitively, and "_" is accepted in place of
+// "-". The last return value is false if there's no match.
+func (c *MessageCatalog) Lookup(key string, locale string) (template string, matchedLocale string, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	byLocale := c.messages[key]
+	if len(byLocale) == 0 {
+		return "", "", false
+	}
+
+	for _, l := range []string{normalizeLocale(locale), c.fallback} {
+		if l == "" {
+			continue
+		}
+		if t, ok := byLocale[l]; ok {
+			return t.template, t.locale, true
+		}
+		lang, _, _ := strings.Cut(l, "-")
+		if t, ok := byLocale[lang]; ok {
+			return t.template, t.locale, true
+		}
+		// Pick the smallest matching locale so results are deterministic
+		var found string
+		for candidate := range byLocale {
+			if strings.HasPrefix(candidate, lang+"-") && (found == "" || candidate < found) {
+				found = candidate
+			}
+		}
+		if found != "" {
+			return byLocale[found].template, byLocale[found].locale, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Localize returns a copy of the error with a LocalizedMessage detail in the
+// requested locale, using the templates in DefaultCatalog. See LocalizeWith.
+func (e Error) Localize(locale string) Error {
+	return e.LocalizeWith(DefaultCatalog, locale)
+}
+
+// LocalizeWith returns a copy of the error with a LocalizedMessage detail in
+// the requested locale, using the templates in catalog.
+// The template is looked up by the error's tag first, then by the reason of
+// each ErrorInfo detail. Placeholders are filled in from the metadata of the
+// ErrorInfo details; if the same key is in multiple details, the value from
+// the first one is used.
+// Any existing LocalizedMessage detail is replaced. If there's no matching
+// template, the error is returned unchanged.
+func (e Error) LocalizeWith(catalog *MessageCatalog, locale string) Error {
+	var (
+		infos    []*errdetails.ErrorInfo
+		template string
+		matched  string
+		ok       bool
+	)
+	for _, detail := range e.details {
+		if info, isInfo := detail.(*errdetails.ErrorInfo); isInfo {
+			infos = append(infos, info)
+		}
+	}
+
+	if e.tag != "" {
+		template, matched, ok = catalog.Lookup(e.tag, locale)
+	}
+	for i := 0; !ok && i < len(infos); i++ {
+		template, matched, ok = catalog.Lookup(infos[i].GetReason(), locale)
+	}
+	if !ok {
+		return e
+	}
+
+	// Details are iterated in order and their keys are sorted, so the result
+	// is deterministic: if a key is in multiple details, the first one wins
+	var replacements []string
+	for _, info := range infos {
+		md := info.GetMetadata()
+		for _, k := range slices.Sorted(maps.Keys(md)) {
+			replacements = append(replacements, "{"+k+"}", md[k])
+		}
+	}
+
+	res := e
+	res.details = make([]proto.Message, 0, len(e.details)+1)
+	for _, detail := range e.details {
+		if _, isLocalized := detail.(*errdetails.LocalizedMessage); !isLocalized {
+			res.details = append(res.details, detail)
+		}
+	}
+	res.details = append(res.details, &errdetails.LocalizedMessage{
+		Locale:  matched,
+		Message: strings.NewReplacer(replacements...).Replace(template),
+	})
+
+	return res
+}
+
+// normalizeLocale returns the locale in lowercase, with "-" as separator.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+)
+
+func TestMessageCatalogLookup(t *testing.T) {
+	c := NewMessageCatalog()
+	c.Register("DAPR_TEST", "en-US", "english")
+	c.Register("DAPR_TEST", "fr", "french")
+	c.Register("DAPR_TEST", "pt-PT", "portuguese (Portugal)")
+	c.Register("DAPR_TEST", "pt-BR", "portuguese (Brazil)")
+
+	tests := []struct {
+		locale         string
+		expectTemplate string
+		expectLocale   string
+	}{
+		{locale: "en-US", expectTemplate: "english", expectLocale: "en-US"},
+		{locale: "EN_us", expectTemplate: "english", expectLocale: "en-US"},
+		{locale: "fr-CA", expectTemplate: "french", expectLocale: "fr"},
+		{locale: "pt-BR", expectTemplate: "portuguese (Brazil)", expectLocale: "pt-BR"},
+		{locale: "pt", expectTemplate: "portuguese (Brazil)", expectLocale: "pt-BR"},
+		{locale: "pt-AO", expectTemplate: "portuguese (Brazil)", expectLocale: "pt-BR"},
+		{locale: "de-DE", expectTemplate: "english", expectLocale: "en-US"},
+		{locale: "", expectTemplate: "english", expectLocale: "en-US"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			template, locale, ok := c.Lookup("DAPR_TEST", tt.locale)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectTemplate, template)
+			assert.Equal(t, tt.expectLocale, locale)
+		})
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, _, ok := c.Lookup("DAPR_OTHER", "en-US")
+		assert.False(t, ok)
+	})
+
+	t.Run("no fallback match", func(t *testing.T) {
+		c := NewMessageCatalog()
+		c.Register("DAPR_TEST", "fr", "french")
+		_, _, ok := c.Lookup("DAPR_TEST", "de")
+		assert.False(t, ok)
+
+		c.SetFallbackLocale("fr-FR")
+		template, locale, ok := c.Lookup("DAPR_TEST", "de")
+		require.True(t, ok)
+		assert.Equal(t, "french", template)
+		assert.Equal(t, "fr", locale)
+	})
+}
+
+func TestLocalize(t *testing.T) {
+	c := NewMessageCatalog()
+	c.Register("DAPR_STATE_NOT_FOUND", "en", "State store {storeName} was not found")
+	c.Register("DAPR_STATE_NOT_FOUND", "it", "Lo state store {storeName} non è stato trovato")
+	c.Register("ERR_LEGACY", "it", "Errore legacy")
+
+	build := func(tag string) Error {
+		return NewBuilder(grpcCodes.NotFound, http.StatusNotFound, "state store not found", tag, "").
+			WithErrorInfo("DAPR_STATE_NOT_FOUND", map[string]string{"storeName": "mystore"}).
+			Build().(Error)
+	}
+
+	t.Run("by reason with placeholders", func(t *testing.T) {
+		err := build("")
+		localized := err.LocalizeWith(c, "it-IT")
+
+		require.Len(t, localized.details, 2)
+		msg, ok := localized.details[1].(*errdetails.LocalizedMessage)
+		require.True(t, ok)
+		assert.Equal(t, "it", msg.GetLocale())
+		assert.Equal(t, "Lo state store mystore non è stato trovato", msg.GetMessage())
+
+		// The original error is not modified
+		assert.Len(t, err.details, 1)
+
+		assert.Contains(t, string(localized.JSONErrorValue()), "Lo state store mystore")
+	})
+
+	t.Run("tag takes precedence", func(t *testing.T) {
+		localized := build("ERR_LEGACY").LocalizeWith(c, "it")
+
+		require.Len(t, localized.details, 2)
+		msg, ok := localized.details[1].(*errdetails.LocalizedMessage)
+		require.True(t, ok)
+		assert.Equal(t, "Errore legacy", msg.GetMessage())
+	})
+
+	t.Run("replaces existing localized message", func(t *testing.T) {
+		localized := build("").LocalizeWith(c, "it").LocalizeWith(c, "ja")
+
+		require.Len(t, localized.details, 2)
+		msg, ok := localized.details[1].(*errdetails.LocalizedMessage)
+		require.True(t, ok)
+		assert.Equal(t, "en", msg.GetLocale())
+		assert.Equal(t, "State store mystore was not found", msg.GetMessage())
+	})
+
+	t.Run("first detail wins for duplicate placeholders", func(t *testing.T) {
+		err := build("")
+		err.details = append(err.details, &errdetails.ErrorInfo{
+			Reason:   "DAPR_OTHER",
+			Metadata: map[string]string{"storeName": "other", "a": "b"},
+		})
+
+		for range 20 {
+			localized := err.LocalizeWith(c, "en")
+			msg, ok := localized.details[len(localized.details)-1].(*errdetails.LocalizedMessage)
+			require.True(t, ok)
+			assert.Equal(t, "State store mystore was not found", msg.GetMessage())
+		}
+	})
+
+	t.Run("no template", func(t *testing.T) {
+		err := NewBuilder(grpcCodes.Internal, http.StatusInternalServerError, "failed", "", "").
+			WithErrorInfo("DAPR_OTHER", nil).
+			Build().(Error)
+		localized := err.LocalizeWith(c, "it")
+		assert.Equal(t, err.details, localized.details)
+	})
+
+	t.Run("default catalog", func(t *testing.T) {
+		RegisterLocalizedMessage("DAPR_TEST_DEFAULT_CATALOG", "es", "Error de prueba")
+		t.Cleanup(func() {
+			DefaultCatalog = NewMessageCatalog()
+		})
+
+		localized := NewBuilder(grpcCodes.Internal, http.StatusInternalServerError, "failed", "", "").
+			WithErrorInfo("DAPR_TEST_DEFAULT_CATALOG", nil).
+			Build().(Error).
+			Localize("es-MX")
+		require.Len(t, localized.details, 2)
+		msg, ok := localized.details[1].(*errdetails.LocalizedMessage)
+		require.True(t, ok)
+		assert.Equal(t, "Error de prueba", msg.GetMessage())
+	})
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MultiError is an error which aggregates multiple errors, such as the
+// per-item failures of a bulk operation.
+// It is compatible with the standard library's errors.Is and errors.As, which
+// traverse all the aggregated errors.
+type MultiError struct {
+	message string
+	errs    []error
+}
+
+// multiErrorJSON is used to build the error for the HTTP Methods json output
+type multiErrorJSON struct {
+	ErrorCode string            `json:"errorCode"`
+	Message   string            `json:"message"`
+	Errors    []json.RawMessage `json:"errors"`
+}
+
+// Join returns an error that aggregates the given errors, with message as the
+// overall error message. Nil errors are discarded.
+// It returns nil if all errors are nil.
+func Join(message string, errs ...error) error {
+	me := &MultiError{
+		message: message,
+		errs:    make([]error, 0, len(errs)),
+	}
+	for _, err := range errs {
+		if err != nil {
+			me.errs = append(me.errs, err)
+		}
+	}
+	if len(me.errs) == 0 {
+		return nil
+	}
+	return me
+}
+
+// Errors returns the aggregated errors.
+func (m *MultiError) Errors() []error {
+	return m.errs
+}
+
+// Unwrap returns the aggregated errors.
+func (m *MultiError) Unwrap() []error {
+	return m.errs
+}
+
+// Error implements the error interface.
+func (m *MultiError) Error() string {
+	if len(m.errs) == 0 {
+		return m.message
+	}
+	msgs := make([]string, len(m.errs))
+	for i, err := range m.errs {
+		msgs[i] = err.Error()
+	}
+	return m.message + ": " + strings.Join(msgs, "; ")
+}
+
+// HTTPStatusCode returns the HTTP status code of the most severe aggregated error.
+func (m *MultiError) HTTPStatusCode() int {
+	return m.representative().httpCode
+}
+
+// GrpcStatusCode returns the gRPC status code of the most severe aggregated error.
+func (m *MultiError) GrpcStatusCode() grpcCodes.Code {
+	return m.representative().grpcCode
+}
+
+// ErrorCode returns the error code of the most severe aggregated error.
+func (m *MultiError) ErrorCode() string {
+	return m.representative().ErrorCode()
+}
+
+// GRPCStatus returns the gRPC status.Status object.
+// The status has the code of the most severe aggregated error, and contains
+// the details of all aggregated errors.
+func (m *MultiError) GRPCStatus() *status.Status {
+	rep := m.representative()
+	var details []proto.Message
+	for _, err := range m.errs {
+		details = append(details, asKitError(err).details...)
+	}
+
+	return Error{
+		details:  details,
+		grpcCode: rep.grpcCode,
+		message:  m.message,
+	}.GRPCStatus()
+}
+
+// JSONErrorValue implements the errorResponseValue interface.
+// The JSON object contains the error code of the most severe aggregated error,
+// and the JSON representation of each aggregated error under "errors".
+// The details are encoded with the format set with SetJSONDetailsFormat.
+func (m *MultiError) JSONErrorValue() []byte {
+	return m.JSONErrorValueWith(JSONOptions{})
+}
+
+// JSONErrorValueWith returns the JSON representation of the error, encoded
+// with the given options.
+func (m *MultiError) JSONErrorValueWith(opts JSONOptions) []byte {
+	errJSON := multiErrorJSON{
+		ErrorCode: m.ErrorCode(),
+		Message:   m.message,
+		Errors:    make([]json.RawMessage, len(m.errs)),
+	}
+	if errJSON.ErrorCode == "" {
+		errJSON.ErrorCode = http.StatusText(m.HTTPStatusCode())
+	}
+	for i, err := range m.errs {
+		errJSON.Errors[i] = asKitError(err).JSONErrorValueWith(opts)
+	}
+
+	errBytes, err := json.Marshal(errJSON)
+	if err != nil {
+		errJSON, _ := json.Marshal(fmt.Sprintf("failed to encode proto to JSON: %v", err))
+		return errJSON
+	}
+	return errBytes
+}
+
+// representative returns the most severe aggregated error, which is the one
+// with the highest HTTP status code. In case of ties, the first one is used.
+// If there are no aggregated errors, which is only possible for a MultiError
+// not created with Join, it returns an Error with an Unknown code.
+func (m *MultiError) representative() *Error {
+	var rep *Error
+	for _, err := range m.errs {
+		kitErr := asKitError(err)
+		if rep == nil || kitErr.httpCode > rep.httpCode {
+			rep = kitErr
+		}
+	}
+	if rep == nil {
+		rep = &Error{
+			grpcCode: grpcCodes.Unknown,
+			httpCode: http.StatusInternalServerError,
+			message:  m.message,
+		}
+	}
+	return rep
+}
+
+// asKitError returns the Error wrapped by err, or an Error with an Unknown
+// code and the error's message if err is not a kit Error.
+func asKitError(err error) *Error {
+	if kitErr, ok := FromError(err); ok {
+		return kitErr
+	}
+	return &Error{
+		grpcCode: grpcCodes.Unknown,
+		httpCode: http.StatusInternalServerError,
+		message:  err.Error(),
+	}
+}
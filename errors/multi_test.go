@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMultiError(t *testing.T) {
+	notFound := NewBuilder(grpcCodes.NotFound, http.StatusNotFound, "key not found", "", "").
+		WithErrorInfo("DAPR_STATE_NOT_FOUND", map[string]string{"key": "a"}).
+		Build()
+	unavailable := NewBuilder(grpcCodes.Unavailable, http.StatusServiceUnavailable, "store unavailable", "", "").
+		WithErrorInfo("DAPR_STATE_UNAVAILABLE", map[string]string{"key": "b"}).
+		Build()
+
+	t.Run("nil errors", func(t *testing.T) {
+		require.NoError(t, Join("bulk failed"))
+		require.NoError(t, Join("bulk failed", nil, nil))
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var me MultiError
+		assert.Equal(t, "", me.Error())
+		assert.Empty(t, me.Unwrap())
+		assert.Equal(t, http.StatusInternalServerError, me.HTTPStatusCode())
+		assert.Equal(t, grpcCodes.Unknown, me.GrpcStatusCode())
+		assert.Equal(t, grpcCodes.Unknown, me.GRPCStatus().Code())
+		assert.JSONEq(t, `{"errorCode":"Internal Server Error","message":"","errors":[]}`, string(me.JSONErrorValue()))
+	})
+
+	t.Run("codes are from the most severe error", func(t *testing.T) {
+		err := Join("bulk failed", notFound, nil, unavailable)
+		var me *MultiError
+		require.ErrorAs(t, err, &me)
+		assert.Len(t, me.Errors(), 2)
+		assert.Equal(t, http.StatusServiceUnavailable, me.HTTPStatusCode())
+		assert.Equal(t, grpcCodes.Unavailable, me.GrpcStatusCode())
+		assert.Equal(t, "DAPR_STATE_UNAVAILABLE", me.ErrorCode())
+		assert.Equal(t, "bulk failed: "+notFound.Error()+"; "+unavailable.Error(), me.Error())
+	})
+
+	t.Run("errors.Is and errors.As", func(t *testing.T) {
+		sentinel := errors.New("sentinel")
+		err := Join("bulk failed", notFound, sentinel)
+		require.ErrorIs(t, err, sentinel)
+
+		kitErr, ok := FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, kitErr.HTTPStatusCode())
+	})
+
+	t.Run("gRPC status contains all details", func(t *testing.T) {
+		err := Join("bulk failed", notFound, unavailable)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, grpcCodes.Unavailable, st.Code())
+		assert.Equal(t, "bulk failed", st.Message())
+
+		var reasons []string
+		for _, d := range st.Details() {
+			if ei, ok := d.(*errdetails.ErrorInfo); ok {
+				reasons = append(reasons, ei.GetReason())
+			}
+		}
+		assert.Equal(t, []string{"DAPR_STATE_NOT_FOUND", "DAPR_STATE_UNAVAILABLE"}, reasons)
+	})
+
+	t.Run("JSON contains all errors", func(t *testing.T) {
+		err := Join("bulk failed", notFound, errors.New("plain error"))
+		var res struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+			Errors    []struct {
+				ErrorCode string `json:"errorCode"`
+				Message   string `json:"message"`
+				Details   []any  `json:"details"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(err.(*MultiError).JSONErrorValue(), &res))
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), res.ErrorCode)
+		assert.Equal(t, "bulk failed", res.Message)
+		require.Len(t, res.Errors, 2)
+		assert.Equal(t, "DAPR_STATE_NOT_FOUND", res.Errors[0].ErrorCode)
+		assert.Equal(t, "key not found", res.Errors[0].Message)
+		assert.Len(t, res.Errors[0].Details, 1)
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), res.Errors[1].ErrorCode)
+		assert.Equal(t, "plain error", res.Errors[1].Message)
+	})
+}
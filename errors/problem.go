@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+const (
+	// ContentTypeJSON is the content type of the errors serialized with JSONErrorValue.
+	ContentTypeJSON = "application/json"
+	// ContentTypeProblemJSON is the content type of the errors serialized with ProblemJSON.
+	ContentTypeProblemJSON = "application/problem+json"
+
+	problemTypeDefault = "about:blank"
+)
+
+// problemJSON is used to build the error for the HTTP Problem Details (RFC 7807) output.
+type problemJSON struct {
+	Type     string `json:"type"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members
+	ErrorCode string `json:"errorCode,omitempty"`
+	Details   []any  `json:"details,omitempty"`
+}
+
+// ProblemJSON returns the error serialized as an HTTP Problem Details object
+// (RFC 7807), with content type "application/problem+json".
+// The fields are derived from the error as follows:
+// - type: the URL of the first help link, or "about:blank"
+// - title: the error code, or the HTTP status text if there's no error code
+// - status: the HTTP status code
+// - detail: the error message
+// - instance: the type and name of the resource from ResourceInfo, if any
+// The error code and details are included as extension members; the details
+// are encoded with the format set with SetJSONDetailsFormat.
+func (e Error) ProblemJSON() []byte {
+	problem := problemJSON{
+		Type:      problemTypeDefault,
+		Title:     e.tag,
+		Status:    e.httpCode,
+		Detail:    e.message,
+		ErrorCode: e.tag,
+	}
+
+	var errorCode string
+	problem.Details, errorCode = e.jsonDetails(JSONDetailsFormatDefault)
+	if errorCode != "" {
+		problem.Title = errorCode
+		problem.ErrorCode = errorCode
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(e.httpCode)
+	}
+
+	for _, detail := range e.details {
+		switch d := detail.(type) {
+		case *errdetails.Help:
+			if problem.Type == problemTypeDefault && len(d.GetLinks()) > 0 && d.GetLinks()[0].GetUrl() != "" {
+				problem.Type = d.GetLinks()[0].GetUrl()
+			}
+		case *errdetails.ResourceInfo:
+			if problem.Instance == "" && d.GetResourceName() != "" {
+				problem.Instance = d.GetResourceType() + "/" + d.GetResourceName()
+			}
+		}
+	}
+
+	errBytes, err := json.Marshal(problem)
+	if err != nil {
+		errJSON, _ := json.Marshal(fmt.Sprintf("failed to encode proto to JSON: %v", err))
+		return errJSON
+	}
+	return errBytes
+}
+
+// HTTPResponseBody returns the serialized error and its content type, based
+// on the value of the Accept header of the request.
+// The error is serialized with ProblemJSON if the client accepts
+// "application/problem+json" with a preference at least as high as
+// "application/json"; otherwise, it uses JSONErrorValue for compatibility.
+func (e Error) HTTPResponseBody(accept string) (contentType string, body []byte) {
+	if prefersProblemJSON(accept) {
+		return ContentTypeProblemJSON, e.ProblemJSON()
+	}
+	return ContentTypeJSON, e.JSONErrorValue()
+}
+
+// prefersProblemJSON returns true if the Accept header value accepts
+// "application/problem+json" with a q-value greater than or equal to the one
+// of "application/json". Ties go to "application/problem+json", as it's the
+// more specific media type.
+func prefersProblemJSON(accept string) bool {
+	var problemQ, jsonQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case ContentTypeProblemJSON:
+			problemQ = max(problemQ, q)
+		case ContentTypeJSON:
+			jsonQ = max(jsonQ, q)
+		}
+	}
+
+	return problemQ > 0 && problemQ >= jsonQ
+}
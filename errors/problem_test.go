@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcCodes "google.golang.org/grpc/codes"
+)
+
+func TestError_ProblemJSON(t *testing.T) {
+	t.Run("all fields", func(t *testing.T) {
+		err := NewBuilder(grpcCodes.NotFound, http.StatusNotFound, "state store not found", "ERR_STATE_STORE_NOT_FOUND", "").
+			WithErrorInfo("DAPR_STATE_NOT_FOUND", nil).
+			WithHelpLink("https://docs.dapr.io/state", "State docs").
+			WithResourceInfo("state", "mystore", "", "").
+			Build().(Error)
+
+		var problem map[string]any
+		require.NoError(t, json.Unmarshal(err.ProblemJSON(), &problem))
+		assert.Equal(t, "https://docs.dapr.io/state", problem["type"])
+		assert.Equal(t, "ERR_STATE_STORE_NOT_FOUND", problem["title"])
+		assert.InDelta(t, float64(http.StatusNotFound), problem["status"], 0)
+		assert.Equal(t, "state store not found", problem["detail"])
+		assert.Equal(t, "state/mystore", problem["instance"])
+		assert.Equal(t, "ERR_STATE_STORE_NOT_FOUND", problem["errorCode"])
+		assert.Len(t, problem["details"], 3)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		err := Error{
+			httpCode: http.StatusBadRequest,
+			message:  "bad request",
+		}
+
+		var problem map[string]any
+		require.NoError(t, json.Unmarshal(err.ProblemJSON(), &problem))
+		assert.Equal(t, map[string]any{
+			"type":   "about:blank",
+			"title":  "Bad Request",
+			"status": float64(http.StatusBadRequest),
+			"detail": "bad request",
+		}, problem)
+	})
+}
+
+func TestError_HTTPResponseBody(t *testing.T) {
+	err := Error{
+		httpCode: http.StatusBadRequest,
+		message:  "bad request",
+		tag:      "ERR_BAD_REQUEST",
+	}
+
+	tests := map[string]struct {
+		accept      string
+		contentType string
+	}{
+		"empty":                 {accept: "", contentType: ContentTypeJSON},
+		"wildcard":              {accept: "*/*", contentType: ContentTypeJSON},
+		"json":                  {accept: "application/json", contentType: ContentTypeJSON},
+		"problem":               {accept: "application/problem+json", contentType: ContentTypeProblemJSON},
+		"both, same preference": {accept: "application/json, application/problem+json", contentType: ContentTypeProblemJSON},
+		"json preferred":        {accept: "application/problem+json;q=0.5, application/json", contentType: ContentTypeJSON},
+		"problem preferred":     {accept: "application/problem+json, application/json;q=0.9", contentType: ContentTypeProblemJSON},
+		"problem refused":       {accept: "application/problem+json;q=0", contentType: ContentTypeJSON},
+		"invalid":               {accept: "application/problem+json;q=foo", contentType: ContentTypeJSON},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			contentType, body := err.HTTPResponseBody(tc.accept)
+			assert.Equal(t, tc.contentType, contentType)
+			if tc.contentType == ContentTypeProblemJSON {
+				assert.Equal(t, err.ProblemJSON(), body)
+			} else {
+				assert.Equal(t, err.JSONErrorValue(), body)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+
+	grpcCodes "google.golang.org/grpc/codes"
+)
+
+// ReasonCategory is the category of the components an error reason applies
+// to. It's used as the category of the errors built from the reason.
+type ReasonCategory string
+
+const (
+	ReasonCategoryComponent     ReasonCategory = "component"
+	ReasonCategoryState         ReasonCategory = "state"
+	ReasonCategoryPubSub        ReasonCategory = "pubsub"
+	ReasonCategoryBindings      ReasonCategory = "bindings"
+	ReasonCategorySecretStore   ReasonCategory = "secretstore"
+	ReasonCategoryConfiguration ReasonCategory = "configuration"
+	ReasonCategoryLock          ReasonCategory = "lock"
+	ReasonCategoryCryptography  ReasonCategory = "cryptography"
+)
+
+// Canonical reasons of the errors returned by components, used as the
+// ErrorInfo reason and the tag of the errors.
+const (
+	// Component
+	ComponentUnreachableReason = CodeComponentUnreachable
+	ComponentTimeoutReason     = CodeComponentTimeout
+
+	// State
+	StateNotFoundReason            = CodePrefixStateStore + CodeNotFound
+	StateNotConfiguredReason       = CodePrefixStateStore + CodeNotConfigured
+	StateETagMismatchReason        = CodePrefixStateStore + "ETAG_MISMATCH"
+	StateETagInvalidReason         = CodePrefixStateStore + "ETAG_INVALID"
+	StateIllegalKeyReason          = CodePrefixStateStore + CodeIllegalKey
+	StateGetFailedReason           = CodePrefixStateStore + CodePostfixGetStateFailed
+	StateTooManyTransactionsReason = CodePrefixStateStore + CodePostfixTooManyTransactions
+	StateQueryFailedReason         = CodePrefixStateStore + CodePostfixQueryFailed
+	StateQueryNotSupportedReason   = CodePrefixStateStore + "QUERY_" + CodeNotSupported
+
+	// Pub/sub
+	PubSubNotFoundReason        = CodePrefixPubSub + CodeNotFound
+	PubSubNotConfiguredReason   = CodePrefixPubSub + CodeNotConfigured
+	PubSubTopicNameEmptyReason  = CodePrefixPubSub + "TOPIC_NAME_EMPTY"
+	PubSubForbiddenReason       = CodePrefixPubSub + "FORBIDDEN"
+	PubSubPublishFailedReason   = CodePrefixPubSub + "PUBLISH_MESSAGE"
+	PubSubTooManyRequestsReason = CodePrefixPubSub + "TOO_MANY_REQUESTS"
+
+	// Bindings
+	BindingNotFoundReason     = CodePrefixBindings + CodeNotFound
+	BindingInvokeFailedReason = CodePrefixBindings + "INVOKE_FAILED"
+	BindingNotSupportedReason = CodePrefixBindings + "OPERATION_" + CodeNotSupported
+
+	// Secret stores
+	SecretStoreNotFoundReason      = CodePrefixSecretStore + "STORE_" + CodeNotFound
+	SecretStoreNotConfiguredReason = CodePrefixSecretStore + "STORE_" + CodeNotConfigured
+	SecretNotFoundReason           = CodePrefixSecretStore + CodeNotFound
+	SecretGetFailedReason          = CodePrefixSecretStore + "GET_FAILED"
+
+	// Configuration stores
+	ConfigurationNotFoundReason        = CodePrefixConfigurationStore + CodeNotFound
+	ConfigurationGetFailedReason       = CodePrefixConfigurationStore + "GET_FAILED"
+	ConfigurationSubscribeFailedReason = CodePrefixConfigurationStore + "SUBSCRIBE_FAILED"
+
+	// Lock
+	LockNotFoundReason      = CodePrefixLock + CodeNotFound
+	LockTryLockFailedReason = CodePrefixLock + "TRY_LOCK_FAILED"
+	LockUnlockFailedReason  = CodePrefixLock + "UNLOCK_FAILED"
+
+	// Cryptography
+	CryptographyKeyNotFoundReason   = CodePrefixCryptography + "KEY_" + CodeNotFound
+	CryptographyEncryptFailedReason = CodePrefixCryptography + "ENCRYPT_FAILED"
+	CryptographyDecryptFailedReason = CodePrefixCryptography + "DECRYPT_FAILED"
+)
+
+// ReasonInfo describes a canonical error reason, with the default status codes
+// of the errors with that reason.
+type ReasonInfo struct {
+	Reason      string
+	Category    ReasonCategory
+	GRPCCode    grpcCodes.Code
+	HTTPCode    int
+	Description string
+}
+
+// NewBuilder returns an ErrorBuilder with the default status codes and the
+// category of the reason, and ErrorInfo details with the reason and the given
+// metadata.
+func (r ReasonInfo) NewBuilder(message string, metadata map[string]string) *ErrorBuilder {
+	return NewBuilder(r.GRPCCode, r.HTTPCode, message, r.Reason, string(r.Category)).
+		WithErrorInfo(r.Reason, metadata)
+}
+
+// reasonCatalog contains the canonical reasons, keyed by reason.
+var reasonCatalog = map[string]ReasonInfo{}
+
+func init() {
+	for _, r := range []ReasonInfo{
+		{ComponentUnreachableReason, ReasonCategoryComponent, grpcCodes.Unavailable, http.StatusServiceUnavailable, "The component can't be reached"},
+		{ComponentTimeoutReason, ReasonCategoryComponent, grpcCodes.DeadlineExceeded, http.StatusGatewayTimeout, "The component didn't respond in time"},
+
+		{StateNotFoundReason, ReasonCategoryState, grpcCodes.InvalidArgument, http.StatusBadRequest, "The state store doesn't exist"},
+		{StateNotConfiguredReason, ReasonCategoryState, grpcCodes.FailedPrecondition, http.StatusInternalServerError, "No state store is configured"},
+		{StateETagMismatchReason, ReasonCategoryState, grpcCodes.Aborted, http.StatusConflict, "The ETag doesn't match the one of the stored item"},
+		{StateETagInvalidReason, ReasonCategoryState, grpcCodes.InvalidArgument, http.StatusBadRequest, "The ETag is not valid"},
+		{StateIllegalKeyReason, ReasonCategoryState, grpcCodes.InvalidArgument, http.StatusBadRequest, "The key is not valid"},
+		{StateGetFailedReason, ReasonCategoryState, grpcCodes.Internal, http.StatusInternalServerError, "The state could not be retrieved"},
+		{StateTooManyTransactionsReason, ReasonCategoryState, grpcCodes.InvalidArgument, http.StatusBadRequest, "The transaction has more operations than allowed"},
+		{StateQueryFailedReason, ReasonCategoryState, grpcCodes.Internal, http.StatusInternalServerError, "The state query failed"},
+		{StateQueryNotSupportedReason, ReasonCategoryState, grpcCodes.Unimplemented, http.StatusNotImplemented, "The state store doesn't support queries"},
+
+		{PubSubNotFoundReason, ReasonCategoryPubSub, grpcCodes.NotFound, http.StatusBadRequest, "The pub/sub component doesn't exist"},
+		{PubSubNotConfiguredReason, ReasonCategoryPubSub, grpcCodes.FailedPrecondition, http.StatusBadRequest, "No pub/sub component is configured"},
+		{PubSubTopicNameEmptyReason, ReasonCategoryPubSub, grpcCodes.InvalidArgument, http.StatusBadRequest, "The topic name is empty"},
+		{PubSubForbiddenReason, ReasonCategoryPubSub, grpcCodes.PermissionDenied, http.StatusForbidden, "The app is not allowed to use the topic"},
+		{PubSubPublishFailedReason, ReasonCategoryPubSub, grpcCodes.Internal, http.StatusInternalServerError, "The message could not be published"},
+		{PubSubTooManyRequestsReason, ReasonCategoryPubSub, grpcCodes.ResourceExhausted, http.StatusTooManyRequests, "The broker is throttling requests"},
+
+		{BindingNotFoundReason, ReasonCategoryBindings, grpcCodes.NotFound, http.StatusNotFound, "The binding doesn't exist"},
+		{BindingInvokeFailedReason, ReasonCategoryBindings, grpcCodes.Internal, http.StatusInternalServerError, "The binding could not be invoked"},
+		{BindingNotSupportedReason, ReasonCategoryBindings, grpcCodes.Unimplemented, http.StatusNotImplemented, "The binding doesn't support the operation"},
+
+		{SecretStoreNotFoundReason, ReasonCategorySecretStore, grpcCodes.InvalidArgument, http.StatusBadRequest, "The secret store doesn't exist"},
+		{SecretStoreNotConfiguredReason, ReasonCategorySecretStore, grpcCodes.FailedPrecondition, http.StatusInternalServerError, "No secret store is configured"},
+		{SecretNotFoundReason, ReasonCategorySecretStore, grpcCodes.NotFound, http.StatusNotFound, "The secret doesn't exist"},
+		{SecretGetFailedReason, ReasonCategorySecretStore, grpcCodes.Internal, http.StatusInternalServerError, "The secret could not be retrieved"},
+
+		{ConfigurationNotFoundReason, ReasonCategoryConfiguration, grpcCodes.InvalidArgument, http.StatusBadRequest, "The configuration store doesn't exist"},
+		{ConfigurationGetFailedReason, ReasonCategoryConfiguration, grpcCodes.Internal, http.StatusInternalServerError, "The configuration could not be retrieved"},
+		{ConfigurationSubscribeFailedReason, ReasonCategoryConfiguration, grpcCodes.Internal, http.StatusInternalServerError, "The subscription to the configuration failed"},
+
+		{LockNotFoundReason, ReasonCategoryLock, grpcCodes.InvalidArgument, http.StatusBadRequest, "The lock store doesn't exist"},
+		{LockTryLockFailedReason, ReasonCategoryLock, grpcCodes.Internal, http.StatusInternalServerError, "The lock could not be acquired"},
+		{LockUnlockFailedReason, ReasonCategoryLock, grpcCodes.Internal, http.StatusInternalServerError, "The lock could not be released"},
+
+		{CryptographyKeyNotFoundReason, ReasonCategoryCryptography, grpcCodes.NotFound, http.StatusNotFound, "The key doesn't exist"},
+		{CryptographyEncryptFailedReason, ReasonCategoryCryptography, grpcCodes.Internal, http.StatusInternalServerError, "The data could not be encrypted"},
+		{CryptographyDecryptFailedReason, ReasonCategoryCryptography, grpcCodes.Internal, http.StatusInternalServerError, "The data could not be decrypted"},
+	} {
+		reasonCatalog[r.Reason] = r
+	}
+}
+
+// LookupReason returns the canonical reason with the given name.
+// The returned boolean value is false if the reason is not in the catalog.
+func LookupReason(reason string) (ReasonInfo, bool) {
+	r, ok := reasonCatalog[reason]
+	return r, ok
+}
+
+// Reasons returns all the canonical reasons, sorted by category and reason.
+func Reasons() []ReasonInfo {
+	reasons := make([]ReasonInfo, 0, len(reasonCatalog))
+	for _, r := range reasonCatalog {
+		reasons = append(reasons, r)
+	}
+	slices.SortFunc(reasons, func(a, b ReasonInfo) int {
+		return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.Reason, b.Reason))
+	})
+	return reasons
+}
+
+// ReasonsByCategory returns the canonical reasons of the given category,
+// sorted by reason.
+func ReasonsByCategory(category ReasonCategory) []ReasonInfo {
+	return slices.DeleteFunc(Reasons(), func(r ReasonInfo) bool {
+		return r.Category != category
+	})
+}
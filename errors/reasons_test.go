@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+)
+
+func TestReasonCatalog(t *testing.T) {
+	prefixes := map[ReasonCategory]string{
+		ReasonCategoryComponent:     "DAPR_COMPONENT_",
+		ReasonCategoryState:         CodePrefixStateStore,
+		ReasonCategoryPubSub:        CodePrefixPubSub,
+		ReasonCategoryBindings:      CodePrefixBindings,
+		ReasonCategorySecretStore:   CodePrefixSecretStore,
+		ReasonCategoryConfiguration: CodePrefixConfigurationStore,
+		ReasonCategoryLock:          CodePrefixLock,
+		ReasonCategoryCryptography:  CodePrefixCryptography,
+	}
+
+	reasons := Reasons()
+	require.NotEmpty(t, reasons)
+	for _, r := range reasons {
+		prefix, ok := prefixes[r.Category]
+		require.True(t, ok, "unknown category %q for reason %s", r.Category, r.Reason)
+		assert.True(t, strings.HasPrefix(r.Reason, prefix), "reason %s should start with %s", r.Reason, prefix)
+		assert.NotEqual(t, grpcCodes.OK, r.GRPCCode, r.Reason)
+		assert.GreaterOrEqual(t, r.HTTPCode, 400, r.Reason)
+		assert.NotEmpty(t, r.Description, r.Reason)
+	}
+}
+
+func TestReasons(t *testing.T) {
+	reasons := Reasons()
+	assert.Len(t, reasons, len(reasonCatalog))
+	for i := 1; i < len(reasons); i++ {
+		prev, cur := reasons[i-1], reasons[i]
+		assert.True(t, prev.Category < cur.Category || (prev.Category == cur.Category && prev.Reason < cur.Reason),
+			"reasons are not sorted: %s, %s", prev.Reason, cur.Reason)
+	}
+
+	// The returned slice can be modified by the caller
+	reasons[0].Reason = "modified"
+	assert.NotEqual(t, "modified", Reasons()[0].Reason)
+}
+
+func TestReasonsByCategory(t *testing.T) {
+	state := ReasonsByCategory(ReasonCategoryState)
+	require.NotEmpty(t, state)
+	for _, r := range state {
+		assert.Equal(t, ReasonCategoryState, r.Category)
+	}
+	assert.Contains(t, state, reasonCatalog[StateETagMismatchReason])
+
+	assert.Empty(t, ReasonsByCategory("unknown"))
+}
+
+func TestLookupReason(t *testing.T) {
+	r, ok := LookupReason(StateETagMismatchReason)
+	require.True(t, ok)
+	assert.Equal(t, ReasonInfo{
+		Reason:      "DAPR_STATE_ETAG_MISMATCH",
+		Category:    ReasonCategoryState,
+		GRPCCode:    grpcCodes.Aborted,
+		HTTPCode:    http.StatusConflict,
+		Description: "The ETag doesn't match the one of the stored item",
+	}, r)
+
+	_, ok = LookupReason("DAPR_UNKNOWN")
+	assert.False(t, ok)
+}
+
+func TestReasonInfoNewBuilder(t *testing.T) {
+	r, ok := LookupReason(PubSubNotFoundReason)
+	require.True(t, ok)
+
+	kitErr := r.NewBuilder("pubsub mypubsub is not found", map[string]string{"name": "mypubsub"}).Build()
+	err, ok := FromError(kitErr)
+	require.True(t, ok)
+
+	assert.Equal(t, grpcCodes.NotFound, err.GRPCStatus().Code())
+	assert.Equal(t, http.StatusBadRequest, err.HTTPStatusCode())
+	assert.Equal(t, "DAPR_PUBSUB_NOT_FOUND", err.ErrorCode())
+	assert.Equal(t, "pubsub", err.Category())
+	assert.Equal(t, "pubsub mypubsub is not found", err.GRPCStatus().Message())
+
+	details := err.GRPCStatus().Details()
+	require.Len(t, details, 1)
+	errInfo, ok := details[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "DAPR_PUBSUB_NOT_FOUND", errInfo.GetReason())
+	assert.Equal(t, map[string]string{"name": "mypubsub"}, errInfo.GetMetadata())
+}
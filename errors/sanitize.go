@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// RedactedValue replaces the values of sensitive metadata in sanitized
+	// errors.
+	RedactedValue = "[REDACTED]"
+
+	// DefaultMaxMessageLength is the default maximum length of the message of
+	// sanitized errors, in bytes.
+	DefaultMaxMessageLength = 1024
+
+	truncatedSuffix = "..."
+)
+
+// DefaultSensitiveKeys matches the metadata keys whose values are redacted
+// by default in sanitized errors.
+var DefaultSensitiveKeys = regexp.MustCompile(`(?i)password|passwd|secret|token|key|credential|auth`)
+
+// SanitizeOptions configures how errors are sanitized by SanitizedWith.
+type SanitizeOptions struct {
+	// SensitiveKeys matches the keys of the ErrorInfo metadata whose values
+	// are redacted.
+	// Defaults to DefaultSensitiveKeys.
+	SensitiveKeys *regexp.Regexp
+
+	// MaxMessageLength is the maximum length of the message, in bytes. Longer
+	// messages are truncated.
+	// Defaults to DefaultMaxMessageLength; set to a negative value to disable.
+	MaxMessageLength int
+
+	// KeepDebugInfo keeps the DebugInfo details, which are removed by default.
+	KeepDebugInfo bool
+}
+
+// Sanitized returns a copy of the error which is safe to return to external
+// clients, with the default options. See SanitizedWith.
+func (e Error) Sanitized() Error {
+	return e.SanitizedWith(SanitizeOptions{})
+}
+
+// SanitizedWith returns a copy of the error which is safe to return to
+// external clients, as JSON or gRPC status:
+//   - DebugInfo details, which contain stack entries, are removed.
+//   - The values of the ErrorInfo metadata whose keys match SensitiveKeys are
+//     replaced with RedactedValue.
+//   - The message is truncated to MaxMessageLength.
+//
+// The error is not modified, so it can still be logged in full.
+func (e Error) SanitizedWith(opts SanitizeOptions) Error {
+	if opts.SensitiveKeys == nil {
+		opts.SensitiveKeys = DefaultSensitiveKeys
+	}
+	if opts.MaxMessageLength == 0 {
+		opts.MaxMessageLength = DefaultMaxMessageLength
+	}
+
+	res := e
+	res.message = truncateMessage(e.message, opts.MaxMessageLength)
+	res.details = make([]proto.Message, 0, len(e.details))
+	for _, detail := range e.details {
+		switch d := detail.(type) {
+		case *errdetails.DebugInfo:
+			if !opts.KeepDebugInfo {
+				continue
+			}
+		case *errdetails.ErrorInfo:
+			detail = sanitizeErrorInfo(d, opts.SensitiveKeys)
+		}
+		res.details = append(res.details, detail)
+	}
+
+	return res
+}
+
+// sanitizeErrorInfo returns a copy of info with the values of the sensitive
+// metadata redacted, or info itself if there's nothing to redact.
+func sanitizeErrorInfo(info *errdetails.ErrorInfo, sensitiveKeys *regexp.Regexp) *errdetails.ErrorInfo {
+	var res *errdetails.ErrorInfo
+	for k := range info.GetMetadata() {
+		if !sensitiveKeys.MatchString(k) {
+			continue
+		}
+		if res == nil {
+			res = proto.Clone(info).(*errdetails.ErrorInfo)
+		}
+		res.Metadata[k] = RedactedValue
+	}
+
+	if res == nil {
+		return info
+	}
+	return res
+}
+
+// truncateMessage truncates msg to at most maxLength bytes, without splitting
+// multi-byte characters.
+func truncateMessage(msg string, maxLength int) string {
+	if maxLength < 0 || len(msg) <= maxLength {
+		return msg
+	}
+
+	cut := maxLength - len(truncatedSuffix)
+	if cut <= 0 {
+		return truncatedSuffix[:maxLength]
+	}
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + truncatedSuffix
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+)
+
+func TestSanitized(t *testing.T) {
+	build := func(message string) Error {
+		err := NewBuilder(grpcCodes.Internal, http.StatusInternalServerError, message, "", "").
+			WithErrorInfo("DAPR_TEST", map[string]string{
+				"componentName": "mystore",
+				"password":      "hunter2",
+				"accessToken":   "abc",
+			}).
+			Build().(Error)
+		err.AddDetails(&errdetails.DebugInfo{
+			StackEntries: []string{"main.go:1"},
+			Detail:       "internal",
+		})
+		return err
+	}
+
+	t.Run("default options", func(t *testing.T) {
+		err := build("failed")
+		sanitized := err.Sanitized()
+
+		require.Len(t, sanitized.details, 1)
+		info, ok := sanitized.details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, map[string]string{
+			"componentName": "mystore",
+			"password":      RedactedValue,
+			"accessToken":   RedactedValue,
+		}, info.GetMetadata())
+
+		// JSON and gRPC outputs are sanitized
+		assert.NotContains(t, string(sanitized.JSONErrorValue()), "hunter2")
+		assert.NotContains(t, string(sanitized.JSONErrorValue()), "main.go")
+		assert.Len(t, sanitized.GRPCStatus().Details(), 1)
+
+		// The original error is not modified
+		require.Len(t, err.details, 2)
+		assert.Equal(t, "hunter2", err.details[0].(*errdetails.ErrorInfo).GetMetadata()["password"])
+		assert.Contains(t, string(err.JSONErrorValue()), "main.go")
+	})
+
+	t.Run("message is truncated", func(t *testing.T) {
+		sanitized := build(strings.Repeat("a", 2000)).Sanitized()
+		assert.Len(t, sanitized.message, DefaultMaxMessageLength)
+		assert.True(t, strings.HasSuffix(sanitized.message, "..."))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(sanitized.JSONErrorValue(), &body))
+		assert.Len(t, body["message"], DefaultMaxMessageLength)
+	})
+
+	t.Run("custom options", func(t *testing.T) {
+		sanitized := build("héllo world").SanitizedWith(SanitizeOptions{
+			SensitiveKeys:    regexp.MustCompile(`^componentName$`),
+			MaxMessageLength: 5,
+			KeepDebugInfo:    true,
+		})
+
+		// The multi-byte character is not split
+		assert.Equal(t, "h...", sanitized.message)
+		require.Len(t, sanitized.details, 2)
+		assert.Equal(t, map[string]string{
+			"componentName": RedactedValue,
+			"password":      "hunter2",
+			"accessToken":   "abc",
+		}, sanitized.details[0].(*errdetails.ErrorInfo).GetMetadata())
+		assert.IsType(t, &errdetails.DebugInfo{}, sanitized.details[1])
+	})
+
+	t.Run("no truncation", func(t *testing.T) {
+		msg := strings.Repeat("a", 2000)
+		sanitized := build(msg).SanitizedWith(SanitizeOptions{MaxMessageLength: -1})
+		assert.Equal(t, msg, sanitized.message)
+	})
+}
+
+func TestTruncateMessage(t *testing.T) {
+	assert.Equal(t, "hello", truncateMessage("hello", 5))
+	assert.Equal(t, "he...", truncateMessage("hello world", 5))
+	assert.Equal(t, "..", truncateMessage("hello world", 2))
+	assert.Equal(t, "...", truncateMessage("日本語", 4))
+	assert.Equal(t, "日...", truncateMessage("日本語", 7))
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TraceparentHeader is the W3C Trace Context header with the trace ID.
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the W3C Trace Context header with the vendor-specific trace state.
+	TracestateHeader = "tracestate"
+)
+
+type traceContextKey struct{}
+
+// traceContext contains the values of the W3C Trace Context headers.
+type traceContext struct {
+	traceparent string
+	tracestate  string
+}
+
+// ContextWithTraceContext returns a copy of ctx which contains the values of
+// the W3C Trace Context headers, which are read by
+// ErrorBuilder.WithTraceContext.
+// This is useful for HTTP servers, as the trace context of gRPC requests is
+// read from the incoming metadata.
+func ContextWithTraceContext(ctx context.Context, traceparent string, tracestate string) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceContext{
+		traceparent: traceparent,
+		tracestate:  tracestate,
+	})
+}
+
+// WithTraceContext adds a RequestInfo detail to the Error struct with the ID of
+// the trace in ctx, so errors returned to users can be correlated with traces.
+// The trace context is read from the value set with ContextWithTraceContext,
+// or from the incoming or outgoing gRPC metadata, in this order.
+// The request ID is the trace ID, and the serving data contains the traceparent
+// and tracestate headers.
+// If ctx doesn't contain a valid traceparent, no detail is added.
+func (b *ErrorBuilder) WithTraceContext(ctx context.Context) *ErrorBuilder {
+	tc, ok := traceContextFromContext(ctx)
+	if !ok {
+		return b
+	}
+
+	traceID, ok := traceIDFromTraceparent(tc.traceparent)
+	if !ok {
+		return b
+	}
+
+	servingData := TraceparentHeader + "=" + tc.traceparent
+	if tc.tracestate != "" {
+		servingData += ";" + TracestateHeader + "=" + tc.tracestate
+	}
+
+	b.err.details = append(b.err.details, &errdetails.RequestInfo{
+		RequestId:   traceID,
+		ServingData: servingData,
+	})
+
+	return b
+}
+
+// traceContextFromContext returns the W3C Trace Context headers from ctx.
+func traceContextFromContext(ctx context.Context) (traceContext, bool) {
+	if tc, ok := ctx.Value(traceContextKey{}).(traceContext); ok && tc.traceparent != "" {
+		return tc, true
+	}
+
+	for _, fromContext := range []func(context.Context) (metadata.MD, bool){
+		metadata.FromIncomingContext,
+		metadata.FromOutgoingContext,
+	} {
+		md, ok := fromContext(ctx)
+		if !ok {
+			continue
+		}
+		traceparent := md.Get(TraceparentHeader)
+		if len(traceparent) == 0 || traceparent[0] == "" {
+			continue
+		}
+		tc := traceContext{traceparent: traceparent[0]}
+		// Multiple tracestate headers can be combined
+		tc.tracestate = strings.Join(md.Get(TracestateHeader), ",")
+		return tc, true
+	}
+
+	return traceContext{}, false
+}
+
+// traceIDFromTraceparent returns the trace ID from a W3C traceparent header
+// value, in the format "version-traceid-parentid-flags".
+func traceIDFromTraceparent(traceparent string) (string, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+
+	traceID := parts[1]
+	if _, err := hex.DecodeString(traceID); err != nil || traceID == strings.Repeat("0", 32) {
+		return "", false
+	}
+	return strings.ToLower(traceID), true
+}
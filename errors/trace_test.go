@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestErrorBuilder_WithTraceContext(t *testing.T) {
+	const (
+		traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	)
+
+	build := func(ctx context.Context) Error {
+		return NewBuilder(grpcCodes.Internal, http.StatusInternalServerError, "failed", "", "").
+			WithErrorInfo("DAPR_TEST", nil).
+			WithTraceContext(ctx).
+			Build().(Error)
+	}
+
+	requestInfo := func(t *testing.T, err Error) *errdetails.RequestInfo {
+		t.Helper()
+		for _, detail := range err.details {
+			if ri, ok := detail.(*errdetails.RequestInfo); ok {
+				return ri
+			}
+		}
+		return nil
+	}
+
+	t.Run("from context value", func(t *testing.T) {
+		ctx := ContextWithTraceContext(context.Background(), traceparent, "congo=t61rcWkgMzE")
+		err := build(ctx)
+
+		ri := requestInfo(t, err)
+		require.NotNil(t, ri)
+		assert.Equal(t, traceID, ri.GetRequestId())
+		assert.Equal(t, "traceparent="+traceparent+";tracestate=congo=t61rcWkgMzE", ri.GetServingData())
+
+		// Included in both the gRPC status and the JSON body
+		var found bool
+		for _, d := range err.GRPCStatus().Details() {
+			if ri, ok := d.(*errdetails.RequestInfo); ok {
+				found = true
+				assert.Equal(t, traceID, ri.GetRequestId())
+			}
+		}
+		assert.True(t, found)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(err.JSONErrorValue(), &body))
+		assert.Contains(t, body["details"], map[string]any{
+			"@type":        "type.googleapis.com/google.rpc.RequestInfo",
+			"request_id":   traceID,
+			"serving_data": "traceparent=" + traceparent + ";tracestate=congo=t61rcWkgMzE",
+		})
+	})
+
+	t.Run("from incoming gRPC metadata", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"traceparent", traceparent,
+			"tracestate", "a=1",
+			"tracestate", "b=2",
+		))
+
+		ri := requestInfo(t, build(ctx))
+		require.NotNil(t, ri)
+		assert.Equal(t, traceID, ri.GetRequestId())
+		assert.Equal(t, "traceparent="+traceparent+";tracestate=a=1,b=2", ri.GetServingData())
+	})
+
+	t.Run("from outgoing gRPC metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", traceparent)
+
+		ri := requestInfo(t, build(ctx))
+		require.NotNil(t, ri)
+		assert.Equal(t, traceID, ri.GetRequestId())
+		assert.Equal(t, "traceparent="+traceparent, ri.GetServingData())
+	})
+
+	t.Run("no trace context", func(t *testing.T) {
+		assert.Nil(t, requestInfo(t, build(context.Background())))
+	})
+
+	t.Run("invalid traceparent", func(t *testing.T) {
+		for _, tp := range []string{
+			"foo",
+			"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		} {
+			ctx := ContextWithTraceContext(context.Background(), tp, "")
+			assert.Nil(t, requestInfo(t, build(ctx)), tp)
+		}
+	})
+}
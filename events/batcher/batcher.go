@@ -15,6 +15,7 @@ package batcher
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -39,6 +40,14 @@ type Batcher[K comparable, T any] struct {
 	queue     *queue.Processor[K, *item[K, T]]
 	currentID int
 
+	// pending contains the events waiting to be sent, by key.
+	pending     map[K]*item[K, T]
+	pendingLock sync.Mutex
+
+	maxBytesPerKey int
+	sizeFn         func(T) int
+	onOverflow     func(K, T)
+
 	clock   clock.Clock
 	lock    sync.Mutex
 	wg      sync.WaitGroup
@@ -50,6 +59,7 @@ type Batcher[K comparable, T any] struct {
 func New[K comparable, T any](interval time.Duration) *Batcher[K, T] {
 	b := &Batcher[K, T]{
 		interval: interval,
+		pending:  make(map[K]*item[K, T]),
 		clock:    clock.RealClock{},
 		closeCh:  make(chan struct{}),
 	}
@@ -65,6 +75,18 @@ func (b *Batcher[K, T]) WithClock(clock clock.Clock) {
 	b.clock = clock
 }
 
+// WithMaxBytesPerKey caps the memory used by the event of each key, as
+// computed by sizeFn. Events larger than maxBytes are not batched: the pending
+// event for the same key, which the new one supersedes, is discarded, and
+// onOverflow (if not nil) is invoked with the key and the event so the caller
+// can deliver it in another way.
+// It must be called before the batcher is used.
+func (b *Batcher[K, T]) WithMaxBytesPerKey(maxBytes int, sizeFn func(T) int, onOverflow func(K, T)) {
+	b.maxBytesPerKey = maxBytes
+	b.sizeFn = sizeFn
+	b.onOverflow = onOverflow
+}
+
 // Subscribe adds a new event channel subscriber. If the batcher is closed, the
 // subscriber is silently dropped.
 func (b *Batcher[K, T]) Subscribe(ctx context.Context, ch ...chan<- T) {
@@ -121,6 +143,20 @@ func (b *Batcher[K, T]) subscribe(ctx context.Context, ch chan<- T) {
 }
 
 func (b *Batcher[K, T]) execute(i *item[K, T]) {
+	// Skip events which have been flushed or superseded in the meanwhile
+	b.pendingLock.Lock()
+	if b.pending[i.key] != i {
+		b.pendingLock.Unlock()
+		return
+	}
+	delete(b.pending, i.key)
+	b.pendingLock.Unlock()
+
+	b.send(i)
+}
+
+// send sends the event to all subscribers.
+func (b *Batcher[K, T]) send(i *item[K, T]) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 	if b.closed.Load() {
@@ -138,11 +174,71 @@ func (b *Batcher[K, T]) execute(i *item[K, T]) {
 // active, the timer is reset. If the batcher is closed, the key is silently
 // dropped.
 func (b *Batcher[K, T]) Batch(key K, value T) {
-	b.queue.Enqueue(&item[K, T]{
+	if b.closed.Load() {
+		return
+	}
+
+	if b.maxBytesPerKey > 0 && b.sizeFn(value) > b.maxBytesPerKey {
+		b.pendingLock.Lock()
+		delete(b.pending, key)
+		b.queue.Dequeue(key)
+		b.pendingLock.Unlock()
+
+		if b.onOverflow != nil {
+			b.onOverflow(key, value)
+		}
+		return
+	}
+
+	i := &item[K, T]{
 		key:   key,
 		value: value,
 		ttl:   b.clock.Now().Add(b.interval),
+	}
+
+	// The pending map and the queue are updated under the same lock, so
+	// concurrent flushes can't leave a pending event that isn't queued.
+	b.pendingLock.Lock()
+	b.pending[key] = i
+	b.queue.Enqueue(i)
+	b.pendingLock.Unlock()
+}
+
+// FlushKey sends the pending event for the given key, if any, to the
+// subscribers right away, without waiting for the interval to elapse.
+func (b *Batcher[K, T]) FlushKey(key K) {
+	b.pendingLock.Lock()
+	i, ok := b.pending[key]
+	if ok {
+		delete(b.pending, key)
+		b.queue.Dequeue(key)
+	}
+	b.pendingLock.Unlock()
+
+	if ok {
+		b.send(i)
+	}
+}
+
+// FlushAll sends all pending events to the subscribers right away, in the
+// order they would have been sent. This is useful to force delivery before
+// closing the batcher.
+func (b *Batcher[K, T]) FlushAll() {
+	b.pendingLock.Lock()
+	items := make([]*item[K, T], 0, len(b.pending))
+	for _, i := range b.pending {
+		items = append(items, i)
+		b.queue.Dequeue(i.key)
+	}
+	clear(b.pending)
+	b.pendingLock.Unlock()
+
+	slices.SortFunc(items, func(a, b *item[K, T]) int {
+		return a.ttl.Compare(b.ttl)
 	})
+	for _, i := range items {
+		b.send(i)
+	}
 }
 
 // Close closes the batcher. It blocks until all events have been sent to the
@@ -15,6 +15,7 @@ package batcher
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -154,3 +155,161 @@ func TestSubscribeAfterClose(t *testing.T) {
 	b.Subscribe(context.Background(), ch)
 	assert.Empty(t, b.eventChs)
 }
+
+func TestFlushKey(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	b := New[string, int](time.Millisecond * 10)
+	b.WithClock(fakeClock)
+	t.Cleanup(b.Close)
+	ch := make(chan int, 10)
+	b.Subscribe(context.Background(), ch)
+
+	b.Batch("key1", 1)
+	b.Batch("key2", 2)
+	b.FlushKey("key2")
+	b.FlushKey("unknown")
+
+	select {
+	case v := <-ch:
+		assert.Equal(t, 2, v)
+	case <-time.After(time.Second):
+		assert.Fail(t, "should be triggered")
+	}
+
+	// The flushed key is not sent again once the interval elapses
+	fakeClock.Step(time.Millisecond * 10)
+	select {
+	case v := <-ch:
+		assert.Equal(t, 1, v)
+	case <-time.After(time.Second):
+		assert.Fail(t, "should be triggered")
+	}
+	select {
+	case v := <-ch:
+		assert.Fail(t, "should not be triggered", "received %d", v)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestFlushKeyConcurrentBatch(t *testing.T) {
+	t.Parallel()
+
+	const keys = 200
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	b := New[int, int](time.Millisecond * 10)
+	b.WithClock(fakeClock)
+	t.Cleanup(b.Close)
+	ch := make(chan int, keys*2)
+	b.Subscribe(context.Background(), ch)
+
+	// Values are key*10+n, where n is 0 for the first event and 1 for the
+	// second one, which is batched concurrently with the key being flushed.
+	var wg sync.WaitGroup
+	for k := range keys {
+		b.Batch(k, k*10)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			b.Batch(k, k*10+1)
+		}()
+		go func() {
+			defer wg.Done()
+			b.FlushKey(k)
+		}()
+	}
+	wg.Wait()
+
+	// Whether it was flushed or not, the latest event of every key must be
+	// delivered once the interval elapses.
+	fakeClock.Step(time.Millisecond * 10)
+	received := make(map[int]bool, keys)
+	for len(received) < keys {
+		select {
+		case v := <-ch:
+			if v%10 == 1 {
+				received[v/10] = true
+			}
+		case <-time.After(time.Second * 5):
+			assert.Fail(t, "latest events were lost", "received %d of %d", len(received), keys)
+			return
+		}
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	b := New[int, int](time.Millisecond * 10)
+	b.WithClock(fakeClock)
+	t.Cleanup(b.Close)
+	ch := make(chan int, 10)
+	b.Subscribe(context.Background(), ch)
+
+	for i := range 5 {
+		b.Batch(i, i)
+		fakeClock.Step(time.Millisecond)
+	}
+	b.FlushAll()
+
+	for i := range 5 {
+		select {
+		case v := <-ch:
+			assert.Equal(t, i, v)
+		case <-time.After(time.Second):
+			assert.Fail(t, "should be triggered")
+		}
+	}
+
+	fakeClock.Step(time.Millisecond * 10)
+	select {
+	case v := <-ch:
+		assert.Fail(t, "should not be triggered", "received %d", v)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestMaxBytesPerKey(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	b := New[string, string](time.Millisecond * 10)
+	b.WithClock(fakeClock)
+	t.Cleanup(b.Close)
+
+	type overflow struct{ key, value string }
+	overflows := make(chan overflow, 10)
+	b.WithMaxBytesPerKey(4, func(v string) int { return len(v) }, func(k string, v string) {
+		overflows <- overflow{key: k, value: v}
+	})
+	ch := make(chan string, 10)
+	b.Subscribe(context.Background(), ch)
+
+	b.Batch("key1", "abc")
+	b.Batch("key1", "abcdef")
+	b.Batch("key2", "xyz")
+
+	select {
+	case o := <-overflows:
+		assert.Equal(t, overflow{key: "key1", value: "abcdef"}, o)
+	case <-time.After(time.Second):
+		assert.Fail(t, "overflow callback should be invoked")
+	}
+
+	// The pending event for key1 was superseded by the oversized one
+	fakeClock.Step(time.Millisecond * 10)
+	select {
+	case v := <-ch:
+		assert.Equal(t, "xyz", v)
+	case <-time.After(time.Second):
+		assert.Fail(t, "should be triggered")
+	}
+	select {
+	case v := <-ch:
+		assert.Fail(t, "should not be triggered", "received %s", v)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
@@ -19,11 +19,48 @@ import (
 	"sync/atomic"
 )
 
-const bufferSize = 10
+// defaultBufferSize is the number of events buffered for each subscriber when
+// no buffer size is configured.
+const defaultBufferSize = 10
+
+// BufferPolicy determines what happens when an event is broadcast to a
+// subscriber whose buffer is full.
+// Only BufferPolicyDropNewest and BufferPolicyDropOldest make Broadcast
+// non-blocking for the subscriber.
+type BufferPolicy int
+
+const (
+	// BufferPolicyBlock blocks Broadcast until there's room in the buffer of
+	// the subscriber. This is the default.
+	BufferPolicyBlock BufferPolicy = iota
+	// BufferPolicyDropNewest discards the event being broadcast, keeping the
+	// events already in the buffer.
+	BufferPolicyDropNewest
+	// BufferPolicyDropOldest discards the oldest event in the buffer to make
+	// room for the event being broadcast. The event already taken from the
+	// buffer for delivery to the subscriber is never discarded.
+	BufferPolicyDropOldest
+)
+
+// SubscribeOptions configures a subscriber.
+type SubscribeOptions struct {
+	// BufferSize is the number of events buffered for the subscriber while it
+	// is not receiving. One more event is held while waiting for the
+	// subscriber to receive it, so up to BufferSize+1 events can be pending.
+	// Defaults to 10.
+	BufferSize int
+
+	// BufferPolicy determines what happens to events broadcast while the
+	// buffer of the subscriber is full.
+	// Defaults to BufferPolicyBlock, with which a slow subscriber blocks
+	// Broadcast; use a drop policy for a non-blocking Broadcast.
+	BufferPolicy BufferPolicy
+}
 
 type eventCh[T any] struct {
 	id           uint64
-	ch           chan<- T
+	ch           chan T
+	policy       BufferPolicy
 	closeEventCh chan struct{}
 }
 
@@ -50,13 +87,38 @@ func (b *Broadcaster[T]) Subscribe(ctx context.Context, ch ...chan<- T) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 	for _, c := range ch {
-		b.subscribe(ctx, c)
+		b.subscribe(ctx, c, SubscribeOptions{}, false)
 	}
 }
 
-func (b *Broadcaster[T]) subscribe(ctx context.Context, ch chan<- T) {
+// SubscribeWithOptions adds a new subscriber configured with the given options,
+// and returns the channel the events are delivered on. The channel is closed
+// once ctx is done or the Broadcaster is closed; if the Broadcaster is already
+// closed, the returned channel is closed right away.
+// Unless opts sets a drop policy, Broadcast blocks while the buffer of the
+// subscriber is full.
+func (b *Broadcaster[T]) SubscribeWithOptions(ctx context.Context, opts SubscribeOptions) <-chan T {
+	ch := make(chan T)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.subscribe(ctx, ch, opts, true) {
+		close(ch)
+	}
+	return ch
+}
+
+// subscribe adds a subscriber which receives the events on ch. If closeCh is
+// true, ch is closed when the subscriber is removed.
+// It returns false if the Broadcaster is closed.
+func (b *Broadcaster[T]) subscribe(ctx context.Context, ch chan<- T, opts SubscribeOptions, closeCh bool) bool {
 	if b.closed.Load() {
-		return
+		return false
+	}
+
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
 	}
 
 	id := b.currentID
@@ -66,6 +128,7 @@ func (b *Broadcaster[T]) subscribe(ctx context.Context, ch chan<- T) {
 	b.eventChs = append(b.eventChs, &eventCh[T]{
 		id:           id,
 		ch:           bufferedCh,
+		policy:       opts.BufferPolicy,
 		closeEventCh: closeEventCh,
 	})
 
@@ -73,6 +136,9 @@ func (b *Broadcaster[T]) subscribe(ctx context.Context, ch chan<- T) {
 	go func() {
 		defer func() {
 			close(closeEventCh)
+			if closeCh {
+				close(ch)
+			}
 
 			b.lock.Lock()
 			for i, eventCh := range b.eventChs {
@@ -102,9 +168,13 @@ func (b *Broadcaster[T]) subscribe(ctx context.Context, ch chan<- T) {
 			}
 		}
 	}()
+
+	return true
 }
 
 // Broadcast sends the given value to all subscribers.
+// It blocks while the buffer of any subscriber with BufferPolicyBlock is full;
+// for the other subscribers, events are dropped according to their policy.
 func (b *Broadcaster[T]) Broadcast(value T) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
@@ -112,10 +182,39 @@ func (b *Broadcaster[T]) Broadcast(value T) {
 		return
 	}
 	for _, ev := range b.eventChs {
+		switch ev.policy {
+		case BufferPolicyDropNewest:
+			select {
+			case ev.ch <- value:
+			default:
+			}
+		case BufferPolicyDropOldest:
+			ev.sendDropOldest(value)
+		default:
+			select {
+			case <-ev.closeEventCh:
+			case ev.ch <- value:
+			case <-b.closeCh:
+			}
+		}
+	}
+}
+
+// sendDropOldest sends the value to the buffer of the subscriber, discarding
+// the oldest events in the buffer until there's room for it.
+func (e *eventCh[T]) sendDropOldest(value T) {
+	for {
+		select {
+		case e.ch <- value:
+			return
+		default:
+		}
+
+		// The subscriber may receive the oldest event concurrently, in which
+		// case there's room in the buffer already
 		select {
-		case <-ev.closeEventCh:
-		case ev.ch <- value:
-		case <-b.closeCh:
+		case <-e.ch:
+		default:
 		}
 	}
 }
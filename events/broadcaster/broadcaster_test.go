@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broadcaster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	b := New[int]()
+	t.Cleanup(b.Close)
+
+	ch1, ch2 := make(chan int), make(chan int)
+	b.Subscribe(context.Background(), ch1, ch2)
+
+	b.Broadcast(1)
+	for _, ch := range []chan int{ch1, ch2} {
+		select {
+		case v := <-ch:
+			assert.Equal(t, 1, v)
+		case <-time.After(time.Second):
+			require.Fail(t, "expected to receive event")
+		}
+	}
+}
+
+func TestSubscribeWithOptions(t *testing.T) {
+	t.Run("channel is closed when the context is done", func(t *testing.T) {
+		b := New[int]()
+		t.Cleanup(b.Close)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := b.SubscribeWithOptions(ctx, SubscribeOptions{})
+
+		b.Broadcast(1)
+		assert.Equal(t, 1, receive(t, ch))
+
+		cancel()
+		assertClosed(t, ch)
+		assert.Eventually(t, func() bool {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			return len(b.eventChs) == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("channel is closed when the broadcaster is closed", func(t *testing.T) {
+		b := New[int]()
+		ch := b.SubscribeWithOptions(context.Background(), SubscribeOptions{})
+		b.Close()
+		assertClosed(t, ch)
+	})
+
+	t.Run("subscribing to a closed broadcaster returns a closed channel", func(t *testing.T) {
+		b := New[int]()
+		b.Close()
+		assertClosed(t, b.SubscribeWithOptions(context.Background(), SubscribeOptions{}))
+	})
+
+	t.Run("default policy blocks when the buffer is full", func(t *testing.T) {
+		b := New[int]()
+		t.Cleanup(b.Close)
+
+		ch := b.SubscribeWithOptions(context.Background(), SubscribeOptions{BufferSize: 1})
+		fillBuffer(t, b)
+
+		doneCh := make(chan struct{})
+		go func() {
+			b.Broadcast(3)
+			close(doneCh)
+		}()
+		select {
+		case <-doneCh:
+			require.Fail(t, "expected Broadcast to block")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		assert.Equal(t, 1, receive(t, ch))
+		select {
+		case <-doneCh:
+		case <-time.After(time.Second):
+			require.Fail(t, "expected Broadcast to return")
+		}
+		assert.Equal(t, 2, receive(t, ch))
+		assert.Equal(t, 3, receive(t, ch))
+	})
+
+	t.Run("drop newest discards new events when the buffer is full", func(t *testing.T) {
+		b := New[int]()
+		t.Cleanup(b.Close)
+
+		ch := b.SubscribeWithOptions(context.Background(), SubscribeOptions{
+			BufferSize:   2,
+			BufferPolicy: BufferPolicyDropNewest,
+		})
+		fillBuffer(t, b)
+		for i := 3; i <= 5; i++ {
+			b.Broadcast(i)
+		}
+
+		assert.Equal(t, 1, receive(t, ch))
+		assert.Equal(t, 2, receive(t, ch))
+		assert.Equal(t, 3, receive(t, ch))
+		assertNoEvent(t, ch)
+	})
+
+	t.Run("drop oldest discards buffered events when the buffer is full", func(t *testing.T) {
+		b := New[int]()
+		t.Cleanup(b.Close)
+
+		ch := b.SubscribeWithOptions(context.Background(), SubscribeOptions{
+			BufferSize:   2,
+			BufferPolicy: BufferPolicyDropOldest,
+		})
+		fillBuffer(t, b)
+		for i := 3; i <= 5; i++ {
+			b.Broadcast(i)
+		}
+
+		assert.Equal(t, 1, receive(t, ch))
+		assert.Equal(t, 4, receive(t, ch))
+		assert.Equal(t, 5, receive(t, ch))
+		assertNoEvent(t, ch)
+	})
+
+	t.Run("slow subscribers don't block the others", func(t *testing.T) {
+		b := New[int]()
+		t.Cleanup(b.Close)
+
+		slow := b.SubscribeWithOptions(context.Background(), SubscribeOptions{
+			BufferSize:   1,
+			BufferPolicy: BufferPolicyDropNewest,
+		})
+		fast := b.SubscribeWithOptions(context.Background(), SubscribeOptions{})
+
+		for i := range 5 {
+			b.Broadcast(i)
+			assert.Equal(t, i, receive(t, fast))
+		}
+		assert.Equal(t, 0, receive(t, slow))
+	})
+}
+
+// fillBuffer broadcasts 1, waits for the only subscriber to hold it while
+// waiting for the receiver, then broadcasts 2, which remains in the buffer.
+func fillBuffer(t *testing.T, b *Broadcaster[int]) {
+	t.Helper()
+
+	b.Broadcast(1)
+	require.Eventually(t, func() bool {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		return len(b.eventChs[0].ch) == 0
+	}, time.Second, time.Millisecond)
+	b.Broadcast(2)
+}
+
+func receive(t *testing.T, ch <-chan int) int {
+	t.Helper()
+
+	select {
+	case v, ok := <-ch:
+		require.True(t, ok, "expected channel to be open")
+		return v
+	case <-time.After(time.Second):
+		require.Fail(t, "expected to receive event")
+		return 0
+	}
+}
+
+func assertNoEvent(t *testing.T, ch <-chan int) {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		assert.Fail(t, "unexpected event", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func assertClosed(t *testing.T, ch <-chan int) {
+	t.Helper()
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok, "expected channel to be closed")
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected channel to be closed")
+	}
+}
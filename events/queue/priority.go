@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Priority is the priority class of an item.
+// Among the items which are due, those with a higher priority are executed
+// first.
+type Priority int
+
+const (
+	// PriorityLow is the class of items which can be delayed in favor of the
+	// others, such as background jobs.
+	PriorityLow Priority = -1
+	// PriorityNormal is the class of items which don't implement Prioritized.
+	PriorityNormal Priority = 0
+	// PriorityHigh is the class of items which should be executed before the
+	// others, such as health-critical reminders.
+	PriorityHigh Priority = 1
+)
+
+// Prioritized is implemented by items which have a priority class.
+// Priority classes are only used by processors with PriorityScheduling
+// enabled; items which don't implement this interface are in the
+// PriorityNormal class.
+type Prioritized interface {
+	Priority() Priority
+}
+
+// priorityOf returns the priority class of the item.
+func priorityOf[K comparable, T Queueable[K]](r T) Priority {
+	if p, ok := any(r).(Prioritized); ok {
+		return p.Priority()
+	}
+	return PriorityNormal
+}
+
+// priorityQueue implements the same operations as queue, keeping a separate
+// queue for each priority class.
+// The next item is the item with the highest priority among those which are
+// due, or the item scheduled first if none is due. Items which have been due
+// for longer than the starvation threshold are promoted, and are returned
+// first regardless of their priority, in the order they are scheduled.
+// Note: methods in this struct are not safe for concurrent use. Callers should use locks to ensure consistency.
+type priorityQueue[K comparable, T Queueable[K]] struct {
+	// classes contains the queues of the priority classes
+	classes map[Priority]itemQueue[K, T]
+	// priorities contains the priorities of the classes, in descending order
+	priorities []Priority
+	// keys contains the priority class of each item in the queue
+	keys map[K]Priority
+
+	newQueue            func() itemQueue[K, T]
+	now                 func() time.Time
+	starvationThreshold time.Duration
+}
+
+// newPriorityQueue creates a new priorityQueue, using newQueue to create the
+// queues of the priority classes.
+func newPriorityQueue[K comparable, T Queueable[K]](newQueue func() itemQueue[K, T], now func() time.Time, starvationThreshold time.Duration) *priorityQueue[K, T] {
+	return &priorityQueue[K, T]{
+		classes:             make(map[Priority]itemQueue[K, T]),
+		keys:                make(map[K]Priority),
+		newQueue:            newQueue,
+		now:                 now,
+		starvationThreshold: starvationThreshold,
+	}
+}
+
+// Len returns the number of items in the queue.
+func (p *priorityQueue[K, T]) Len() int {
+	return len(p.keys)
+}
+
+// Insert inserts a new item into the queue.
+// If replace is true, existing items are replaced
+func (p *priorityQueue[K, T]) Insert(r T, replace bool) {
+	p.InsertAt(r, r.ScheduledTime(), replace)
+}
+
+// InsertAt inserts a new item into the queue, scheduled at the given time
+// rather than the item's own scheduled time.
+// If replace is true, existing items are replaced, including when the
+// priority of the item changed.
+// Returns true if the item was inserted or replaced.
+func (p *priorityQueue[K, T]) InsertAt(r T, scheduledTime time.Time, replace bool) bool {
+	key := r.Key()
+	priority := priorityOf[K](r)
+
+	current, ok := p.keys[key]
+	if ok {
+		if !replace {
+			return false
+		}
+		if current != priority {
+			p.Remove(key)
+		}
+	}
+
+	if !p.class(priority).InsertAt(r, scheduledTime, replace) {
+		return false
+	}
+	p.keys[key] = priority
+	return true
+}
+
+// Pop removes the next item in the queue and returns it.
+// The returned boolean value will be "true" if an item was found.
+func (p *priorityQueue[K, T]) Pop() (T, bool) {
+	q := p.next()
+	if q == nil {
+		var zero T
+		return zero, false
+	}
+
+	r, ok := q.Pop()
+	if ok {
+		delete(p.keys, r.Key())
+	}
+	return r, ok
+}
+
+// Peek returns the next item in the queue, without removing it.
+// The returned boolean value will be "true" if an item was found.
+func (p *priorityQueue[K, T]) Peek() (T, bool) {
+	r, _, ok := p.PeekScheduled()
+	return r, ok
+}
+
+// PeekScheduled returns the next item in the queue and the time it's
+// scheduled at, without removing it.
+// The returned boolean value will be "true" if an item was found.
+func (p *priorityQueue[K, T]) PeekScheduled() (T, time.Time, bool) {
+	q := p.next()
+	if q == nil {
+		var zero T
+		return zero, time.Time{}, false
+	}
+	return q.PeekScheduled()
+}
+
+// Snapshot returns the keys and scheduled times of the items in the queue, in
+// the order they are scheduled, without modifying the queue.
+// Items scheduled at the same time are sorted by descending priority.
+// If limit is greater than 0, at most limit items are returned.
+func (p *priorityQueue[K, T]) Snapshot(limit int) []ItemInfo[K] {
+	var res []ItemInfo[K]
+	for _, priority := range p.priorities {
+		res = append(res, p.classes[priority].Snapshot(limit)...)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].ScheduledTime.Before(res[j].ScheduledTime)
+	})
+
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// ScheduledTime returns the time the item with the given key is scheduled at.
+// The returned boolean value will be "true" if the item was found.
+func (p *priorityQueue[K, T]) ScheduledTime(key K) (time.Time, bool) {
+	priority, ok := p.keys[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return p.classes[priority].ScheduledTime(key)
+}
+
+// Remove an item from the queue.
+func (p *priorityQueue[K, T]) Remove(key K) {
+	// If the item is not in the queue, this is a nop
+	priority, ok := p.keys[key]
+	if !ok {
+		return
+	}
+
+	p.classes[priority].Remove(key)
+	delete(p.keys, key)
+}
+
+// Update an item in the queue.
+func (p *priorityQueue[K, T]) Update(r T) {
+	// If the item is not in the queue, this is a nop
+	current, ok := p.keys[r.Key()]
+	if !ok {
+		return
+	}
+
+	if priority := priorityOf[K](r); priority != current {
+		p.Remove(r.Key())
+		p.InsertAt(r, r.ScheduledTime(), true)
+		return
+	}
+	p.classes[current].Update(r)
+}
+
+// class returns the queue of the priority class, creating it if needed.
+func (p *priorityQueue[K, T]) class(priority Priority) itemQueue[K, T] {
+	q, ok := p.classes[priority]
+	if !ok {
+		q = p.newQueue()
+		p.classes[priority] = q
+		i, _ := slices.BinarySearchFunc(p.priorities, priority, func(a, b Priority) int {
+			// Descending order
+			return cmp.Compare(b, a)
+		})
+		p.priorities = slices.Insert(p.priorities, i, priority)
+	}
+	return q
+}
+
+// next returns the queue of the class whose first item is the next item, or
+// nil if the queue is empty.
+func (p *priorityQueue[K, T]) next() itemQueue[K, T] {
+	now := p.now()
+
+	var (
+		// Queue with the highest priority among those whose first item is due
+		due itemQueue[K, T]
+		// Queue whose first item has been due for the longest time among those
+		// over the starvation threshold
+		starving     itemQueue[K, T]
+		starvingTime time.Time
+		// Queue whose first item is scheduled first
+		first     itemQueue[K, T]
+		firstTime time.Time
+	)
+	// Priorities are in descending order, so for items scheduled at the same
+	// time, the one with the highest priority is selected
+	for _, priority := range p.priorities {
+		q := p.classes[priority]
+		_, scheduledTime, ok := q.PeekScheduled()
+		if !ok {
+			continue
+		}
+
+		if first == nil || scheduledTime.Before(firstTime) {
+			first, firstTime = q, scheduledTime
+		}
+		if scheduledTime.After(now) {
+			continue
+		}
+		if due == nil {
+			due = q
+		}
+		if p.starvationThreshold > 0 && now.Sub(scheduledTime) >= p.starvationThreshold &&
+			(starving == nil || scheduledTime.Before(starvingTime)) {
+			starving, starvingTime = q, scheduledTime
+		}
+	}
+
+	switch {
+	case starving != nil:
+		return starving
+	case due != nil:
+		return due
+	default:
+		return first
+	}
+}
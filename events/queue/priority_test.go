@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// prioritizedItem is a queueableItem with a priority class.
+type prioritizedItem struct {
+	queueableItem
+	Prio Priority
+}
+
+func (r prioritizedItem) Priority() Priority {
+	return r.Prio
+}
+
+func newPrioritizedItem(n int, dueTime time.Time, priority Priority) *prioritizedItem {
+	return &prioritizedItem{
+		queueableItem: queueableItem{
+			Name:          strconv.Itoa(n),
+			ExecutionTime: dueTime,
+		},
+		Prio: priority,
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	implementations := map[string]func() itemQueue[string, *prioritizedItem]{
+		"heap": func() itemQueue[string, *prioritizedItem] {
+			q := newQueue[string, *prioritizedItem]()
+			return &q
+		},
+		"timing wheel": func() itemQueue[string, *prioritizedItem] {
+			return newTimingWheel[string, *prioritizedItem](time.Millisecond)
+		},
+	}
+
+	now := time.Now()
+	nowFn := func() time.Time { return now }
+
+	popAll := func(t *testing.T, q *priorityQueue[string, *prioritizedItem]) []string {
+		t.Helper()
+		var res []string
+		for q.Len() > 0 {
+			peek, ok := q.Peek()
+			require.True(t, ok)
+			r, ok := q.Pop()
+			require.True(t, ok)
+			require.Same(t, peek, r)
+			res = append(res, r.Name)
+		}
+		_, ok := q.Pop()
+		assert.False(t, ok)
+		return res
+	}
+
+	for name, newItemQueue := range implementations {
+		t.Run(name, func(t *testing.T) {
+			t.Run("due items are ordered by priority", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 0)
+				q.Insert(newPrioritizedItem(1, now.Add(-10*time.Second), PriorityLow), false)
+				q.Insert(newPrioritizedItem(2, now.Add(-5*time.Second), PriorityNormal), false)
+				q.Insert(newPrioritizedItem(3, now.Add(-time.Second), PriorityHigh), false)
+				q.Insert(newPrioritizedItem(4, now.Add(-2*time.Second), PriorityHigh), false)
+				assert.Equal(t, 4, q.Len())
+
+				assert.Equal(t, []string{"4", "3", "2", "1"}, popAll(t, q))
+			})
+
+			t.Run("items which are not due are ordered by time", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 0)
+				q.Insert(newPrioritizedItem(1, now.Add(time.Second), PriorityLow), false)
+				q.Insert(newPrioritizedItem(2, now.Add(2*time.Second), PriorityHigh), false)
+				q.Insert(newPrioritizedItem(3, now.Add(3*time.Second), PriorityNormal), false)
+
+				r, scheduledTime, ok := q.PeekScheduled()
+				require.True(t, ok)
+				assert.Equal(t, "1", r.Name)
+				assert.Equal(t, now.Add(time.Second), scheduledTime)
+				assert.Equal(t, []string{"1", "2", "3"}, popAll(t, q))
+			})
+
+			t.Run("items scheduled at the same time are ordered by priority", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 0)
+				q.Insert(newPrioritizedItem(1, now.Add(time.Second), PriorityLow), false)
+				q.Insert(newPrioritizedItem(2, now.Add(time.Second), PriorityHigh), false)
+				q.Insert(newPrioritizedItem(3, now.Add(time.Second), PriorityNormal), false)
+
+				assert.Equal(t, []string{"2", "3", "1"}, popAll(t, q))
+			})
+
+			t.Run("starving items are promoted", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 8*time.Second)
+				q.Insert(newPrioritizedItem(1, now.Add(-10*time.Second), PriorityLow), false)
+				q.Insert(newPrioritizedItem(2, now.Add(-9*time.Second), PriorityNormal), false)
+				q.Insert(newPrioritizedItem(3, now.Add(-5*time.Second), PriorityLow), false)
+				q.Insert(newPrioritizedItem(4, now.Add(-time.Second), PriorityHigh), false)
+
+				assert.Equal(t, []string{"1", "2", "4", "3"}, popAll(t, q))
+			})
+
+			t.Run("items which are not Prioritized have normal priority", func(t *testing.T) {
+				q := newPriorityQueue(func() itemQueue[string, *queueableItem] {
+					q := newQueue[string, *queueableItem]()
+					return &q
+				}, nowFn, 0)
+				q.Insert(newTestItem(1, now.Add(-time.Second)), false)
+				assert.Equal(t, []Priority{PriorityNormal}, q.priorities)
+			})
+
+			t.Run("replacing an item can change its priority", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 0)
+				q.Insert(newPrioritizedItem(1, now.Add(-time.Second), PriorityHigh), false)
+				q.Insert(newPrioritizedItem(2, now.Add(-2*time.Second), PriorityNormal), false)
+
+				assert.False(t, q.InsertAt(newPrioritizedItem(2, now, PriorityHigh), now, false))
+				assert.True(t, q.InsertAt(newPrioritizedItem(2, now.Add(-2*time.Second), PriorityHigh), now.Add(-2*time.Second), true))
+				assert.Equal(t, 2, q.Len())
+
+				q.Update(newPrioritizedItem(1, now.Add(-time.Second), PriorityLow))
+				assert.Equal(t, 2, q.Len())
+
+				assert.Equal(t, []string{"2", "1"}, popAll(t, q))
+			})
+
+			t.Run("remove and scheduled time", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 0)
+				q.Insert(newPrioritizedItem(1, now.Add(time.Second), PriorityHigh), false)
+				q.InsertAt(newPrioritizedItem(2, now.Add(time.Second), PriorityLow), now.Add(time.Minute), false)
+
+				scheduledTime, ok := q.ScheduledTime("2")
+				require.True(t, ok)
+				assert.Equal(t, now.Add(time.Minute), scheduledTime)
+
+				q.Remove("1")
+				q.Remove("3")
+				_, ok = q.ScheduledTime("1")
+				assert.False(t, ok)
+				assert.Equal(t, 1, q.Len())
+				assert.Equal(t, []string{"2"}, popAll(t, q))
+			})
+
+			t.Run("snapshot", func(t *testing.T) {
+				q := newPriorityQueue(newItemQueue, nowFn, 0)
+				assert.Empty(t, q.Snapshot(0))
+
+				q.Insert(newPrioritizedItem(1, now.Add(3*time.Second), PriorityHigh), false)
+				q.Insert(newPrioritizedItem(2, now.Add(time.Second), PriorityLow), false)
+				q.Insert(newPrioritizedItem(3, now.Add(2*time.Second), PriorityNormal), false)
+				q.Insert(newPrioritizedItem(4, now.Add(time.Second), PriorityHigh), false)
+
+				keys := func(items []ItemInfo[string]) []string {
+					res := make([]string, len(items))
+					for i, item := range items {
+						res[i] = item.Key
+					}
+					return res
+				}
+				assert.Equal(t, []string{"4", "2", "3", "1"}, keys(q.Snapshot(0)))
+				assert.Equal(t, []string{"4", "2"}, keys(q.Snapshot(2)))
+				assert.Equal(t, 4, q.Len())
+			})
+		})
+	}
+}
+
+func TestProcessorPriorityScheduling(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	executeCh := make(chan *prioritizedItem)
+	processor := NewProcessorWithOptions(ProcessorOptions[string, *prioritizedItem]{
+		ExecuteFn: func(r *prioritizedItem) {
+			executeCh <- r
+		},
+		MinExecutionInterval: time.Second,
+		PriorityScheduling:   true,
+		StarvationThreshold:  time.Minute,
+		Clock:                clock,
+	})
+	t.Cleanup(func() { require.NoError(t, processor.Close()) })
+
+	// All items are overdue; the low priority item is starving
+	processor.Enqueue(newPrioritizedItem(0, clock.Now().Add(-2*time.Minute), PriorityLow))
+	processor.Enqueue(newPrioritizedItem(1, clock.Now().Add(-30*time.Second), PriorityLow))
+	processor.Enqueue(newPrioritizedItem(2, clock.Now().Add(-20*time.Second), PriorityNormal))
+	processor.Enqueue(newPrioritizedItem(3, clock.Now().Add(-10*time.Second), PriorityHigh))
+
+	assertExecuted := func(t *testing.T, name string) {
+		t.Helper()
+		select {
+		case r := <-executeCh:
+			assert.Equal(t, name, r.Name)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for execution")
+		}
+	}
+
+	assertExecuted(t, "0")
+	for _, name := range []string{"3", "2", "1"} {
+		assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+		clock.Step(time.Second)
+		assertExecuted(t, name)
+	}
+}
@@ -14,17 +14,192 @@ limitations under the License.
 package queue
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	kclock "k8s.io/utils/clock"
+
+	"github.com/dapr/kit/retry"
+)
+
+// defaultMaxRetries is the maximum number of retries of a failed execution
+// when no retry configuration is given.
+const defaultMaxRetries = 5
+
+// ErrLateExecution is the error wrapped by LateExecutionError.
+var ErrLateExecution = errors.New("item executed late")
+
+// LateExecutionError describes an item whose execution started later than it
+// was due by more than the LateExecutionThreshold, typically because other
+// executions were blocking the processing loop.
+type LateExecutionError[K comparable] struct {
+	// Key is the key of the item.
+	Key K
+	// DueTime is the time the item was due to be executed at.
+	DueTime time.Time
+	// Delay is the time between DueTime and the start of the execution.
+	Delay time.Duration
+}
+
+// Error implements the error interface.
+func (e *LateExecutionError[K]) Error() string {
+	return fmt.Sprintf("item '%v' executed %v after it was due", e.Key, e.Delay)
+}
+
+// Unwrap returns ErrLateExecution.
+func (e *LateExecutionError[K]) Unwrap() error {
+	return ErrLateExecution
+}
+
+// QueueImplementation is the internal implementation of the queue of a
+// Processor.
+type QueueImplementation int
+
+const (
+	// QueueImplementationHeap stores items in a binary heap, with O(log N)
+	// insertions and removals. This is the default.
+	QueueImplementationHeap QueueImplementation = iota
+	// QueueImplementationTimingWheel stores items in a hierarchical timing
+	// wheel, with O(1) insertions and removals. It is faster for queues with a
+	// large number of items, especially if they are scheduled far in the
+	// future.
+	QueueImplementationTimingWheel
 )
 
+// ProcessorOptions configures a Processor created with NewProcessorWithOptions.
+type ProcessorOptions[K comparable, T Queueable[K]] struct {
+	// ExecuteFn is the callback invoked when the item is to be executed; this
+	// will be invoked in a background goroutine.
+	ExecuteFn func(r T)
+
+	// ExecuteErrFn is a variant of ExecuteFn which returns an error. If it
+	// returns an error or panics, the item is re-enqueued with a delay
+	// according to Retry.
+	// If set, ExecuteFn is ignored.
+	ExecuteErrFn func(r T) error
+
+	// Retry is the backoff configuration used to re-enqueue items which failed
+	// to execute with ExecuteErrFn.
+	// Defaults to an exponential backoff with a maximum of 5 retries.
+	Retry *retry.Config
+
+	// DeadLetterFn is invoked with the item and the last error once an item
+	// failed to execute and has no retries left. Optional.
+	DeadLetterFn func(r T, err error)
+
+	// MaxConcurrentExecutions is the maximum number of items that can be
+	// executed concurrently. If set, each item is executed in its own
+	// goroutine, and items that are due while the limit is reached remain in
+	// the queue until an execution completes.
+	// Defaults to 0, in which case items are executed one at a time in the
+	// processing loop, unless AsyncExecution is set.
+	MaxConcurrentExecutions int
+
+	// AsyncExecution executes each item in its own goroutine, without limiting
+	// the number of concurrent executions, so a slow execution doesn't delay
+	// the execution of the other items.
+	// Ignored if MaxConcurrentExecutions is set.
+	AsyncExecution bool
+
+	// LateExecutionThreshold enables the detection of items which are executed
+	// later than they are due by more than this threshold, for example because
+	// a slow execution blocked the processing loop. Late executions are counted
+	// by LateExecutions, and reported to OnLateExecution.
+	// Delays introduced by MinExecutionInterval are not considered late.
+	// Defaults to 0 (disabled).
+	LateExecutionThreshold time.Duration
+
+	// OnLateExecution is invoked, before the item is executed, for each item
+	// executed late according to LateExecutionThreshold. It is invoked in the
+	// processing loop, so it must not block. Optional.
+	OnLateExecution func(err *LateExecutionError[K])
+
+	// MinExecutionInterval is the minimum interval between the start of two
+	// executions. Items that are overdue, for example after a clock jump, are
+	// executed spaced by this interval rather than all at once.
+	// Defaults to 0 (no spacing).
+	MinExecutionInterval time.Duration
+
+	// QueueImplementation is the internal implementation of the queue.
+	// Defaults to QueueImplementationHeap.
+	QueueImplementation QueueImplementation
+
+	// TimingWheelResolution is the duration of a tick of the timing wheel, when
+	// QueueImplementation is QueueImplementationTimingWheel. Items scheduled
+	// within the same tick are still executed in order.
+	// Defaults to 1ms.
+	TimingWheelResolution time.Duration
+
+	// DriftCheckInterval enables wall-clock drift correction. Timers are based
+	// on the monotonic clock, which doesn't advance while the system is
+	// suspended (for example, a paused VM), so after resuming items would be
+	// executed late by the duration of the suspension. If set, while waiting
+	// for the next item the processor re-checks the wall-clock time at this
+	// interval, and executes the items which became due in the meantime.
+	// Deadlines are computed from the wall-clock time in this mode.
+	// See also Reconcile.
+	// Defaults to 0 (disabled).
+	DriftCheckInterval time.Duration
+
+	// PriorityScheduling enables priority classes: among the items which are
+	// due, those with a higher priority are executed first, regardless of
+	// the time they are scheduled at. The priority class of items is given by
+	// the Prioritized interface.
+	// This only makes a difference when items can't be executed as soon as
+	// they are due, for example because of MaxConcurrentExecutions or
+	// MinExecutionInterval, or after a clock jump.
+	PriorityScheduling bool
+
+	// StarvationThreshold is the time after which items which are due are
+	// promoted, when PriorityScheduling is enabled, so lower priority items
+	// are not delayed indefinitely by higher priority ones. Promoted items are
+	// executed first, regardless of their priority, in the order they are
+	// scheduled.
+	// Defaults to 0, in which case items are never promoted.
+	StarvationThreshold time.Duration
+
+	// DrainAllPending configures CloseAndDrain to execute all the items in the
+	// queue, including those which are not due yet. By default, only the items
+	// which are due are executed, and the others are discarded.
+	DrainAllPending bool
+
+	// Clock is the clock used to schedule the execution of items.
+	// Defaults to the real clock; set it to a fake clock for deterministic
+	// tests.
+	Clock kclock.Clock
+}
+
+// retryState tracks the retries of an item executed with executeErrFn.
+// It's created when the item is first executed, and cancelled if the item is
+// dequeued while it's executing, so it's not re-enqueued.
+type retryState struct {
+	backoff   backoff.BackOff
+	attempts  int
+	cancelled bool
+}
+
 // Processor manages the queue of items and processes them at the correct time.
 type Processor[K comparable, T Queueable[K]] struct {
 	executeFn          func(r T)
-	queue              queue[K, T]
+	executeErrFn       func(r T) error
+	retryConfig        retry.Config
+	deadLetterFn       func(r T, err error)
+	retries            map[K]*retryState
+	queue              itemQueue[K, T]
+	executionSlots     chan struct{}
+	asyncExecution     bool
+	lateThreshold      time.Duration
+	onLateExecution    func(err *LateExecutionError[K])
+	lateExecutions     atomic.Uint64
+	minInterval        time.Duration
+	driftCheckInterval time.Duration
+	drainAllPending    bool
+	lastExecution      time.Time
 	clock              kclock.Clock
 	lock               sync.Mutex
 	wg                 sync.WaitGroup
@@ -32,19 +207,72 @@ type Processor[K comparable, T Queueable[K]] struct {
 	stopCh             chan struct{}
 	resetCh            chan struct{}
 	stopped            atomic.Bool
+	reconcile          atomic.Bool
 }
 
 // NewProcessor returns a new Processor object.
 // executeFn is the callback invoked when the item is to be executed; this will be invoked in a background goroutine.
 func NewProcessor[K comparable, T Queueable[K]](executeFn func(r T)) *Processor[K, T] {
-	return &Processor[K, T]{
-		executeFn:          executeFn,
-		queue:              newQueue[K, T](),
+	return NewProcessorWithOptions(ProcessorOptions[K, T]{
+		ExecuteFn: executeFn,
+	})
+}
+
+// NewProcessorWithOptions returns a new Processor object configured with the
+// given options.
+func NewProcessorWithOptions[K comparable, T Queueable[K]](opts ProcessorOptions[K, T]) *Processor[K, T] {
+	retryConfig := retry.DefaultConfig()
+	retryConfig.Policy = retry.PolicyExponential
+	retryConfig.MaxRetries = defaultMaxRetries
+	if opts.Retry != nil {
+		retryConfig = *opts.Retry
+	}
+
+	p := &Processor[K, T]{
+		executeFn:          opts.ExecuteFn,
+		executeErrFn:       opts.ExecuteErrFn,
+		retryConfig:        retryConfig,
+		deadLetterFn:       opts.DeadLetterFn,
+		retries:            make(map[K]*retryState),
 		processorRunningCh: make(chan struct{}, 1),
 		stopCh:             make(chan struct{}),
 		resetCh:            make(chan struct{}, 1),
-		clock:              kclock.RealClock{},
+		asyncExecution:     opts.AsyncExecution,
+		lateThreshold:      opts.LateExecutionThreshold,
+		onLateExecution:    opts.OnLateExecution,
+		minInterval:        opts.MinExecutionInterval,
+		driftCheckInterval: opts.DriftCheckInterval,
+		drainAllPending:    opts.DrainAllPending,
+		clock:              opts.Clock,
+	}
+	newItemQueue := func() itemQueue[K, T] {
+		switch opts.QueueImplementation {
+		case QueueImplementationTimingWheel:
+			return newTimingWheel[K, T](opts.TimingWheelResolution)
+		default:
+			q := newQueue[K, T]()
+			return &q
+		}
+	}
+	if opts.PriorityScheduling {
+		p.queue = newPriorityQueue(newItemQueue, func() time.Time {
+			return p.clock.Now()
+		}, opts.StarvationThreshold)
+	} else {
+		p.queue = newItemQueue()
+	}
+	if opts.MaxConcurrentExecutions > 0 {
+		p.executionSlots = make(chan struct{}, opts.MaxConcurrentExecutions)
+	}
+	if p.clock == nil {
+		p.clock = kclock.RealClock{}
+	}
+
+	if p.executeErrFn != nil {
+		p.executeFn = p.executeWithRetry
 	}
+
+	return p
 }
 
 // WithClock sets the clock used by the processor. Used for testing.
@@ -63,6 +291,8 @@ func (p *Processor[K, T]) Enqueue(r T) {
 	// Insert or replace the item in the queue
 	// If the item added or replaced is the first one in the queue, we need to know that
 	p.lock.Lock()
+	// Enqueueing an item resets its retries, if any
+	delete(p.retries, r.Key())
 	peek, ok := p.queue.Peek()
 	isFirst := (ok && peek.Key() == r.Key()) // This is going to be true if the item being replaced is the first one in the queue
 	p.queue.Insert(r, true)
@@ -73,6 +303,7 @@ func (p *Processor[K, T]) Enqueue(r T) {
 }
 
 // Dequeue removes a item from the queue.
+// If the item is being executed, it's not retried if the execution fails.
 func (p *Processor[K, T]) Dequeue(key K) {
 	if p.stopped.Load() {
 		return
@@ -80,6 +311,11 @@ func (p *Processor[K, T]) Dequeue(key K) {
 
 	// We need to check if this is the next item in the queue, as that requires stopping the processor
 	p.lock.Lock()
+	// If the item is executing, don't re-enqueue it if the execution fails
+	if state, ok := p.retries[key]; ok {
+		state.cancelled = true
+		delete(p.retries, key)
+	}
 	peek, ok := p.queue.Peek()
 	p.queue.Remove(key)
 	if ok && peek.Key() == key {
@@ -89,6 +325,72 @@ func (p *Processor[K, T]) Dequeue(key K) {
 	p.lock.Unlock()
 }
 
+// Peek returns the next item in the queue and the time it's scheduled to be
+// executed at, without removing it.
+// The returned boolean value will be "true" if an item was found.
+func (p *Processor[K, T]) Peek() (T, time.Time, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.queue.PeekScheduled()
+}
+
+// Contains returns true if an item with the given key is in the queue.
+// Items are removed from the queue when they are executed, so this returns
+// false for items being executed, unless they are scheduled again for a retry.
+func (p *Processor[K, T]) Contains(key K) bool {
+	_, ok := p.GetDueTime(key)
+	return ok
+}
+
+// GetDueTime returns the time the item with the given key is scheduled to be
+// executed at, without removing it from the queue.
+// This differs from the item's own scheduled time if it's being retried.
+// The returned boolean value will be "true" if the item was found.
+func (p *Processor[K, T]) GetDueTime(key K) (time.Time, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.queue.ScheduledTime(key)
+}
+
+// Reconcile signals the processor that the system time may have jumped, for
+// example because the system was resumed from suspension. The processor
+// re-computes the time to wait for the next item from the wall-clock time,
+// executing right away the items which became due in the meantime.
+// This allows reacting to external signals immediately, without waiting for
+// the DriftCheckInterval.
+func (p *Processor[K, T]) Reconcile() {
+	if p.stopped.Load() {
+		return
+	}
+
+	p.lock.Lock()
+	p.reconcile.Store(true)
+	p.process(true)
+	p.lock.Unlock()
+}
+
+// Snapshot returns information about the items in the queue, in the order they
+// are scheduled to be executed. It does not affect the processing of the items.
+// If limit is greater than 0, at most limit items are returned.
+func (p *Processor[K, T]) Snapshot(limit int) []ItemInfo[K] {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	items := p.queue.Snapshot(limit)
+	for i := range items {
+		if state, ok := p.retries[items[i].Key]; ok {
+			items[i].Attempts = state.attempts
+		}
+	}
+	return items
+}
+
+// LateExecutions returns the number of items which were executed late
+// according to the LateExecutionThreshold.
+func (p *Processor[K, T]) LateExecutions() uint64 {
+	return p.lateExecutions.Load()
+}
+
 // Close stops the processor.
 // This method blocks until the processor loop returns.
 func (p *Processor[K, T]) Close() error {
@@ -104,6 +406,88 @@ func (p *Processor[K, T]) Close() error {
 	return nil
 }
 
+// CloseAndDrain stops the processor like Close, but executes the items which
+// are due before returning, or all the items in the queue if DrainAllPending
+// is set. New items are not accepted once CloseAndDrain is invoked.
+// Items are executed as soon as possible, without enforcing
+// MinExecutionInterval, and items which fail to execute are not retried.
+// If ctx is done before all the items have been executed, the remaining items
+// are discarded and the context's error is returned; executions which are in
+// progress are not interrupted.
+func (p *Processor[K, T]) CloseAndDrain(ctx context.Context) error {
+	if !p.stopped.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	// Stop the processing loop and wait for it to return, so items are not
+	// executed concurrently by the loop
+	close(p.stopCh)
+	select {
+	case p.processorRunningCh <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain queue: %w", ctx.Err())
+	}
+
+	err := p.drain(ctx)
+
+	// Wait for the executions in progress
+	doneCh := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-ctx.Done():
+		if err == nil {
+			err = fmt.Errorf("failed to wait for executions in progress: %w", ctx.Err())
+		}
+	}
+
+	return err
+}
+
+// drain executes the items to drain until the queue is empty or ctx is done.
+func (p *Processor[K, T]) drain(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			p.lock.Lock()
+			n := p.queue.Len()
+			p.lock.Unlock()
+			return fmt.Errorf("failed to drain queue, %d items not executed: %w", n, err)
+		}
+
+		if p.executionSlots != nil {
+			select {
+			case p.executionSlots <- struct{}{}:
+			case <-ctx.Done():
+				continue
+			}
+		}
+
+		p.lock.Lock()
+		_, scheduledTime, ok := p.queue.PeekScheduled()
+		if ok && !p.drainAllPending && scheduledTime.After(p.clock.Now()) {
+			ok = false
+		}
+		var r T
+		if ok {
+			r, _ = p.queue.Pop()
+			p.lastExecution = p.clock.Now()
+		}
+		p.lock.Unlock()
+
+		if !ok {
+			if p.executionSlots != nil {
+				<-p.executionSlots
+			}
+			return nil
+		}
+
+		p.executeItem(r)
+	}
+}
+
 // Start the processing loop if it's not already running.
 // This must be invoked while the caller has a lock.
 func (p *Processor[K, T]) process(isNext bool) {
@@ -143,13 +527,16 @@ func (p *Processor[K, T]) processLoop() {
 		ok            bool
 		t             kclock.Timer
 		scheduledTime time.Time
+		lastExecution time.Time
 		deadline      time.Duration
+		wait          time.Duration
 	)
 
 	for {
 		// Continue processing items until the queue is empty
 		p.lock.Lock()
-		r, ok = p.queue.Peek()
+		r, scheduledTime, ok = p.queue.PeekScheduled()
+		lastExecution = p.lastExecution
 		p.lock.Unlock()
 		if !ok {
 			return
@@ -168,21 +555,42 @@ func (p *Processor[K, T]) processLoop() {
 			// Nop, proceed
 		}
 
-		scheduledTime = r.ScheduledTime()
-		deadline = scheduledTime.Sub(p.clock.Now())
+		// Enforce the minimum interval between executions
+		if p.minInterval > 0 && !lastExecution.IsZero() {
+			if next := lastExecution.Add(p.minInterval); next.After(scheduledTime) {
+				scheduledTime = next
+			}
+		}
+
+		if p.driftCheckInterval > 0 || p.reconcile.Swap(false) {
+			// Strip the monotonic clock readings so the deadline is computed
+			// from the wall-clock time
+			deadline = scheduledTime.Round(0).Sub(p.clock.Now().Round(0))
+		} else {
+			deadline = scheduledTime.Sub(p.clock.Now())
+		}
 
 		// If the deadline is less than 0.5ms away, execute it right away
 		// This is more efficient than creating a timer
 		if deadline < 500*time.Microsecond {
-			p.execute(r)
+			p.execute(r, scheduledTime)
 			continue
 		}
 
-		t = p.clock.NewTimer(deadline)
+		// With drift correction, wake up at least every interval to re-check
+		// the wall-clock time
+		wait = deadline
+		if p.driftCheckInterval > 0 && wait > p.driftCheckInterval {
+			wait = p.driftCheckInterval
+		}
+
+		t = p.clock.NewTimer(wait)
 		select {
-		// Wait for when it's time to execute the item
+		// Wait for when it's time to execute the item, or to re-check the time
 		case <-t.C():
-			p.execute(r)
+			if wait == deadline {
+				p.execute(r, scheduledTime)
+			}
 
 		// If we get a reset signal, restart the loop
 		case <-p.resetCh:
@@ -201,22 +609,149 @@ func (p *Processor[K, T]) processLoop() {
 }
 
 // Executes a item when it's time.
-func (p *Processor[K, T]) execute(r T) {
+// due is the time the item was due to be executed at.
+func (p *Processor[K, T]) execute(r T, due time.Time) {
+	// If concurrency is limited, wait for an execution slot before popping the
+	// item, so overdue items remain in the queue
+	if p.executionSlots != nil {
+		select {
+		case p.executionSlots <- struct{}{}:
+		case <-p.stopCh:
+			return
+		}
+	}
+
 	// Pop the item now that we're ready to process it
 	// There's a small chance this is a different item than the one we peeked before
 	p.lock.Lock()
 	// For safety, let's peek at the first item before popping it and make sure it's the same object
 	// It's unlikely, but if it's a different object then restart the loop
 	peek, ok := p.queue.Peek()
-	if !ok || peek != r {
-		p.lock.Unlock()
-		return
+	if ok && peek == r {
+		r, ok = p.queue.Pop()
+	} else {
+		ok = false
+	}
+	var now time.Time
+	if ok {
+		now = p.clock.Now()
+		p.lastExecution = now
 	}
-	r, ok = p.queue.Pop()
 	p.lock.Unlock()
 	if !ok {
+		if p.executionSlots != nil {
+			<-p.executionSlots
+		}
 		return
 	}
 
-	p.executeFn(r)
+	if p.lateThreshold > 0 {
+		if delay := now.Sub(due); delay > p.lateThreshold {
+			p.lateExecutions.Add(1)
+			if p.onLateExecution != nil {
+				p.onLateExecution(&LateExecutionError[K]{
+					Key:     r.Key(),
+					DueTime: due,
+					Delay:   delay,
+				})
+			}
+		}
+	}
+
+	p.executeItem(r)
+}
+
+// executeItem executes an item which has been popped from the queue, in the
+// current goroutine or, if executions are concurrent, in a new one.
+// If executions are limited, the caller must have acquired an execution slot,
+// which is released once the execution completes.
+func (p *Processor[K, T]) executeItem(r T) {
+	if p.executionSlots == nil && !p.asyncExecution {
+		p.executeFn(r)
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			if p.executionSlots != nil {
+				<-p.executionSlots
+			}
+			p.wg.Done()
+		}()
+		p.executeFn(r)
+	}()
+}
+
+// executeWithRetry executes an item with executeErrFn, re-enqueueing it with a
+// backoff delay if it fails. Once the item has no retries left, it's passed to
+// deadLetterFn. Items dequeued while executing are not re-enqueued.
+func (p *Processor[K, T]) executeWithRetry(r T) {
+	key := r.Key()
+
+	p.lock.Lock()
+	state, ok := p.retries[key]
+	if !ok {
+		state = &retryState{}
+		p.retries[key] = state
+	}
+	p.lock.Unlock()
+
+	err := p.safeExecuteErr(r)
+
+	p.lock.Lock()
+	if state.cancelled {
+		// The item was dequeued, which already removed its retry state
+		p.lock.Unlock()
+		return
+	}
+	if err == nil || p.stopped.Load() {
+		p.deleteRetryState(key, state)
+		p.lock.Unlock()
+		return
+	}
+
+	if state.backoff == nil {
+		state.backoff = p.retryConfig.NewBackOff()
+	}
+	state.attempts++
+
+	next := state.backoff.NextBackOff()
+	if next == backoff.Stop {
+		p.deleteRetryState(key, state)
+		p.lock.Unlock()
+		if p.deadLetterFn != nil {
+			p.deadLetterFn(r, fmt.Errorf("item failed after %d attempts: %w", state.attempts, err))
+		}
+		return
+	}
+
+	// Do not replace the item if it has been enqueued again in the meantime
+	if p.queue.InsertAt(r, p.clock.Now().Add(next), false) {
+		peek, _ := p.queue.Peek()
+		p.process(peek == r)
+	} else {
+		p.deleteRetryState(key, state)
+	}
+	p.lock.Unlock()
+}
+
+// deleteRetryState removes the retry state of the key, unless it was replaced
+// in the meantime, for example because the item was enqueued again.
+// This must be invoked while the caller has a lock.
+func (p *Processor[K, T]) deleteRetryState(key K, state *retryState) {
+	if p.retries[key] == state {
+		delete(p.retries, key)
+	}
+}
+
+// safeExecuteErr invokes executeErrFn, converting panics into errors.
+func (p *Processor[K, T]) safeExecuteErr(r T) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic while executing item: %v", rec)
+		}
+	}()
+
+	return p.executeErrFn(r)
 }
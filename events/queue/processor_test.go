@@ -14,6 +14,8 @@ limitations under the License.
 package queue
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"runtime"
 	"strconv"
@@ -25,6 +27,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/kit/retry"
 )
 
 func TestProcessor(t *testing.T) {
@@ -409,3 +413,629 @@ func TestClose(t *testing.T) {
 
 	require.NoError(t, processor.Close())
 }
+
+func TestCloseAndDrain(t *testing.T) {
+	// newProcessor returns a processor whose processing loop is blocked
+	// executing item 0, with items 1 and 2 due and item 3 due in 1 hour.
+	newProcessor := func(t *testing.T, opts ProcessorOptions[string, *queueableItem], fn func(r *queueableItem)) (*Processor[string, *queueableItem], chan struct{}) {
+		t.Helper()
+
+		clock := clocktesting.NewFakeClock(time.Now())
+		startedCh := make(chan struct{})
+		releaseCh := make(chan struct{})
+		opts.Clock = clock
+		opts.ExecuteFn = func(r *queueableItem) {
+			if r.Name == "0" {
+				close(startedCh)
+				<-releaseCh
+			}
+			fn(r)
+		}
+		processor := NewProcessorWithOptions(opts)
+
+		processor.Enqueue(newTestItem(0, clock.Now()))
+		select {
+		case <-startedCh:
+		case <-time.After(time.Second):
+			t.Fatal("item 0 should have been executed")
+		}
+
+		processor.Enqueue(newTestItem(1, clock.Now()))
+		processor.Enqueue(newTestItem(2, clock.Now()))
+		processor.Enqueue(newTestItem(3, clock.Now().Add(time.Hour)))
+
+		return processor, releaseCh
+	}
+
+	t.Run("executes due items", func(t *testing.T) {
+		var executed []string
+		processor, releaseCh := newProcessor(t, ProcessorOptions[string, *queueableItem]{}, func(r *queueableItem) {
+			executed = append(executed, r.Name)
+		})
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- processor.CloseAndDrain(context.Background())
+		}()
+
+		// Items can't be enqueued once closing
+		<-processor.stopCh
+		processor.Enqueue(newTestItem(4, time.Now().Add(-time.Hour)))
+
+		close(releaseCh)
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("CloseAndDrain should have returned")
+		}
+
+		assert.Equal(t, []string{"0", "1", "2"}, executed)
+		require.NoError(t, processor.CloseAndDrain(context.Background()))
+		require.NoError(t, processor.Close())
+	})
+
+	t.Run("executes all pending items", func(t *testing.T) {
+		var (
+			executed     []string
+			executedLock sync.Mutex
+		)
+		processor, releaseCh := newProcessor(t, ProcessorOptions[string, *queueableItem]{
+			DrainAllPending:         true,
+			MaxConcurrentExecutions: 2,
+		}, func(r *queueableItem) {
+			executedLock.Lock()
+			executed = append(executed, r.Name)
+			executedLock.Unlock()
+		})
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- processor.CloseAndDrain(context.Background())
+		}()
+
+		<-processor.stopCh
+		close(releaseCh)
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("CloseAndDrain should have returned")
+		}
+
+		assert.ElementsMatch(t, []string{"0", "1", "2", "3"}, executed)
+		assert.Equal(t, 0, processor.queue.Len())
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var executed []string
+		processor, releaseCh := newProcessor(t, ProcessorOptions[string, *queueableItem]{
+			DrainAllPending: true,
+		}, func(r *queueableItem) {
+			executed = append(executed, r.Name)
+			if r.Name == "1" {
+				cancel()
+			}
+		})
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- processor.CloseAndDrain(ctx)
+		}()
+
+		<-processor.stopCh
+		close(releaseCh)
+		select {
+		case err := <-errCh:
+			require.ErrorIs(t, err, context.Canceled)
+			require.ErrorContains(t, err, "2 items not executed")
+		case <-time.After(time.Second):
+			t.Fatal("CloseAndDrain should have returned")
+		}
+
+		assert.Equal(t, []string{"0", "1"}, executed)
+	})
+}
+
+func TestProcessorRetry(t *testing.T) {
+	newProcessor := func(t *testing.T, executeErrFn func(r *queueableItem) error) (*Processor[string, *queueableItem], *clocktesting.FakeClock, chan error) {
+		t.Helper()
+
+		clock := clocktesting.NewFakeClock(time.Now())
+		deadLetterCh := make(chan error, 1)
+		processor := NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+			ExecuteErrFn: executeErrFn,
+			Retry: &retry.Config{
+				Policy:     retry.PolicyConstant,
+				Duration:   time.Second,
+				MaxRetries: 2,
+			},
+			DeadLetterFn: func(r *queueableItem, err error) {
+				deadLetterCh <- err
+			},
+			Clock: clock,
+		})
+		t.Cleanup(func() { require.NoError(t, processor.Close()) })
+
+		return processor, clock, deadLetterCh
+	}
+
+	t.Run("failed items are retried until they succeed", func(t *testing.T) {
+		var attempts atomic.Int32
+		executed := make(chan struct{}, 3)
+		processor, clock, deadLetterCh := newProcessor(t, func(r *queueableItem) error {
+			defer func() { executed <- struct{}{} }()
+			if attempts.Add(1) == 1 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+
+		processor.Enqueue(newTestItem(1, clock.Now()))
+		<-executed
+		assert.Equal(t, int32(1), attempts.Load())
+
+		require.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+		clock.Step(time.Second)
+		<-executed
+		assert.Equal(t, int32(2), attempts.Load())
+
+		assert.Eventually(t, func() bool {
+			processor.lock.Lock()
+			defer processor.lock.Unlock()
+			return processor.queue.Len() == 0 && len(processor.retries) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		select {
+		case err := <-deadLetterCh:
+			t.Fatalf("unexpected dead letter: %v", err)
+		default:
+		}
+	})
+
+	t.Run("panicking items are sent to dead letter once out of retries", func(t *testing.T) {
+		var attempts atomic.Int32
+		processor, clock, deadLetterCh := newProcessor(t, func(r *queueableItem) error {
+			attempts.Add(1)
+			panic("oops")
+		})
+
+		processor.Enqueue(newTestItem(1, clock.Now()))
+		for i := 0; i < 2; i++ {
+			require.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+			clock.Step(time.Second)
+		}
+
+		select {
+		case err := <-deadLetterCh:
+			require.ErrorContains(t, err, "oops")
+		case <-time.After(time.Second):
+			t.Fatal("expected item to be sent to dead letter")
+		}
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("enqueueing an item again cancels its retries", func(t *testing.T) {
+		executed := make(chan struct{}, 3)
+		processor, clock, _ := newProcessor(t, func(r *queueableItem) error {
+			executed <- struct{}{}
+			return errors.New("failed")
+		})
+
+		processor.Enqueue(newTestItem(1, clock.Now()))
+		<-executed
+		require.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+
+		processor.Enqueue(newTestItem(1, clock.Now().Add(time.Hour)))
+		processor.lock.Lock()
+		assert.Empty(t, processor.retries)
+		processor.lock.Unlock()
+
+		clock.Step(time.Second)
+		select {
+		case <-executed:
+			t.Fatal("item should not have been retried")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("dequeueing an item while it's executing cancels its retries", func(t *testing.T) {
+		executed := make(chan struct{}, 3)
+		var processor *Processor[string, *queueableItem]
+		processor, clock, deadLetterCh := newProcessor(t, func(r *queueableItem) error {
+			processor.Dequeue(r.Key())
+			executed <- struct{}{}
+			return errors.New("failed")
+		})
+
+		processor.Enqueue(newTestItem(1, clock.Now()))
+		<-executed
+
+		assert.Eventually(t, func() bool {
+			processor.lock.Lock()
+			defer processor.lock.Unlock()
+			return processor.queue.Len() == 0 && len(processor.retries) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		clock.Step(time.Second)
+		select {
+		case <-executed:
+			t.Fatal("item should not have been retried")
+		case err := <-deadLetterCh:
+			t.Fatalf("unexpected dead letter: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+func TestProcessorPeekAndSnapshot(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	executed := make(chan struct{}, 1)
+	processor := NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+		ExecuteErrFn: func(r *queueableItem) error {
+			executed <- struct{}{}
+			return errors.New("failed")
+		},
+		Retry: &retry.Config{
+			Policy:     retry.PolicyConstant,
+			Duration:   time.Minute,
+			MaxRetries: 2,
+		},
+	})
+	processor.clock = clock
+	t.Cleanup(func() { require.NoError(t, processor.Close()) })
+
+	_, _, ok := processor.Peek()
+	assert.False(t, ok)
+	assert.Empty(t, processor.Snapshot(0))
+
+	now := clock.Now()
+	processor.Enqueue(newTestItem(2, now.Add(2*time.Hour)))
+	processor.Enqueue(newTestItem(3, now.Add(3*time.Hour)))
+	processor.Enqueue(newTestItem(1, now.Add(time.Hour)))
+
+	r, scheduledTime, ok := processor.Peek()
+	require.True(t, ok)
+	assert.Equal(t, "1", r.Name)
+	assert.Equal(t, now.Add(time.Hour), scheduledTime)
+
+	items := processor.Snapshot(2)
+	require.Len(t, items, 2)
+	assert.Equal(t, ItemInfo[string]{Key: "1", ScheduledTime: now.Add(time.Hour)}, items[0])
+	assert.Equal(t, ItemInfo[string]{Key: "2", ScheduledTime: now.Add(2 * time.Hour)}, items[1])
+
+	assert.True(t, processor.Contains("3"))
+	assert.False(t, processor.Contains("4"))
+	dueTime, ok := processor.GetDueTime("3")
+	require.True(t, ok)
+	assert.Equal(t, now.Add(3*time.Hour), dueTime)
+	_, ok = processor.GetDueTime("4")
+	assert.False(t, ok)
+
+	// Peeking should not disturb the timer
+	require.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+	clock.Step(time.Hour)
+	<-executed
+
+	// The failed item is rescheduled with its attempts
+	assert.Eventually(t, func() bool {
+		items = processor.Snapshot(0)
+		return len(items) == 3 && items[0].Attempts == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "1", items[0].Key)
+	assert.Equal(t, now.Add(time.Hour+time.Minute), items[0].ScheduledTime)
+	assert.Zero(t, items[1].Attempts)
+
+	// The due time of the item being retried is the retry's
+	dueTime, ok = processor.GetDueTime("1")
+	require.True(t, ok)
+	assert.Equal(t, now.Add(time.Hour+time.Minute), dueTime)
+
+	processor.Dequeue("1")
+	assert.False(t, processor.Contains("1"))
+}
+
+func TestProcessorMaxConcurrentExecutions(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	startedCh := make(chan string, 5)
+	releaseCh := make(chan struct{})
+	var running, maxRunning atomic.Int32
+	processor := NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+		ExecuteFn: func(r *queueableItem) {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			startedCh <- r.Name
+			<-releaseCh
+			running.Add(-1)
+		},
+		MaxConcurrentExecutions: 2,
+		Clock:                   clock,
+	})
+	t.Cleanup(func() {
+		close(releaseCh)
+		require.NoError(t, processor.Close())
+	})
+
+	for i := range 5 {
+		processor.Enqueue(newTestItem(i, clock.Now().Add(-time.Second)))
+	}
+
+	assertStarted := func(t *testing.T, n int) {
+		t.Helper()
+		for range n {
+			select {
+			case <-startedCh:
+			case <-time.After(time.Second):
+				require.Fail(t, "timeout waiting for execution")
+			}
+		}
+		select {
+		case name := <-startedCh:
+			require.Failf(t, "unexpected execution", "item %s", name)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// Only 2 items are executed, the others remain in the queue
+	assertStarted(t, 2)
+	assert.Equal(t, 3, processor.queue.Len())
+
+	// Completing one execution allows the next one to start
+	releaseCh <- struct{}{}
+	assertStarted(t, 1)
+	assert.Equal(t, 2, processor.queue.Len())
+
+	releaseCh <- struct{}{}
+	releaseCh <- struct{}{}
+	assertStarted(t, 2)
+	assert.Equal(t, 0, processor.queue.Len())
+	assert.Equal(t, int32(2), maxRunning.Load())
+}
+
+func TestProcessorLateExecution(t *testing.T) {
+	type testProcessor struct {
+		*Processor[string, *queueableItem]
+		clock     *clocktesting.FakeClock
+		startedCh chan string
+		releaseCh chan struct{}
+		lateCh    chan *LateExecutionError[string]
+	}
+
+	newProcessor := func(t *testing.T, async bool) testProcessor {
+		t.Helper()
+
+		tp := testProcessor{
+			clock:     clocktesting.NewFakeClock(time.Now()),
+			startedCh: make(chan string, 5),
+			releaseCh: make(chan struct{}),
+			lateCh:    make(chan *LateExecutionError[string], 5),
+		}
+		tp.Processor = NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+			ExecuteFn: func(r *queueableItem) {
+				tp.startedCh <- r.Name
+				if r.Name == "1" {
+					<-tp.releaseCh
+				}
+			},
+			AsyncExecution:         async,
+			LateExecutionThreshold: 500 * time.Millisecond,
+			OnLateExecution: func(err *LateExecutionError[string]) {
+				tp.lateCh <- err
+			},
+			Clock: tp.clock,
+		})
+		t.Cleanup(func() {
+			close(tp.releaseCh)
+			require.NoError(t, tp.Close())
+		})
+
+		tp.Enqueue(newTestItem(1, tp.clock.Now()))
+		tp.Enqueue(newTestItem(2, tp.clock.Now().Add(time.Second)))
+		return tp
+	}
+
+	assertStarted := func(t *testing.T, startedCh chan string, expect string) {
+		t.Helper()
+		select {
+		case name := <-startedCh:
+			assert.Equal(t, expect, name)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for execution")
+		}
+	}
+
+	t.Run("blocking execution delays the next item", func(t *testing.T) {
+		tp := newProcessor(t, false)
+		due := tp.clock.Now().Add(time.Second)
+
+		// Item 1 blocks the processing loop while item 2 becomes due
+		assertStarted(t, tp.startedCh, "1")
+		tp.clock.Step(5 * time.Second)
+		select {
+		case name := <-tp.startedCh:
+			require.Failf(t, "unexpected execution", "item %s", name)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// Once item 1 completes, item 2 is executed late
+		tp.releaseCh <- struct{}{}
+		assertStarted(t, tp.startedCh, "2")
+
+		select {
+		case err := <-tp.lateCh:
+			require.ErrorIs(t, err, ErrLateExecution)
+			assert.Equal(t, "2", err.Key)
+			assert.Equal(t, due, err.DueTime)
+			assert.Equal(t, 4*time.Second, err.Delay)
+			assert.Equal(t, "item '2' executed 4s after it was due", err.Error())
+		case <-time.After(time.Second):
+			require.Fail(t, "late execution was not reported")
+		}
+		assert.Equal(t, uint64(1), tp.LateExecutions())
+	})
+
+	t.Run("asynchronous execution doesn't delay the next item", func(t *testing.T) {
+		tp := newProcessor(t, true)
+
+		assertStarted(t, tp.startedCh, "1")
+		assert.Eventually(t, tp.clock.HasWaiters, time.Second, time.Millisecond)
+		tp.clock.Step(time.Second)
+		assertStarted(t, tp.startedCh, "2")
+
+		assert.Empty(t, tp.lateCh)
+		assert.Equal(t, uint64(0), tp.LateExecutions())
+	})
+}
+
+func TestProcessorMinExecutionInterval(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	executeCh := make(chan *queueableItem)
+	processor := NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+		ExecuteFn: func(r *queueableItem) {
+			executeCh <- r
+		},
+		MinExecutionInterval: time.Second,
+		Clock:                clock,
+	})
+	t.Cleanup(func() { require.NoError(t, processor.Close()) })
+
+	// All items are overdue
+	for i := range 3 {
+		processor.Enqueue(newTestItem(i, clock.Now().Add(-time.Minute+time.Duration(i)*time.Second)))
+	}
+
+	assertExecuted := func(t *testing.T, name string) {
+		t.Helper()
+		select {
+		case r := <-executeCh:
+			assert.Equal(t, name, r.Name)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for execution")
+		}
+	}
+
+	// The first item is executed right away, the others are spaced
+	assertExecuted(t, "0")
+	assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+	select {
+	case <-executeCh:
+		require.Fail(t, "item executed before the interval elapsed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.Step(time.Second)
+	assertExecuted(t, "1")
+	assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+	clock.Step(time.Second)
+	assertExecuted(t, "2")
+}
+
+// jumpClock is a fake clock whose wall-clock time can jump without firing
+// timers, like after a system suspension.
+type jumpClock struct {
+	*clocktesting.FakeClock
+	offset atomic.Int64
+}
+
+func (c *jumpClock) Now() time.Time {
+	return c.FakeClock.Now().Add(time.Duration(c.offset.Load()))
+}
+
+func (c *jumpClock) Since(ts time.Time) time.Duration {
+	return c.Now().Sub(ts)
+}
+
+func (c *jumpClock) Jump(d time.Duration) {
+	c.offset.Add(int64(d))
+}
+
+func TestProcessorDriftCorrection(t *testing.T) {
+	newProcessor := func(t *testing.T, driftCheckInterval time.Duration) (*Processor[string, *queueableItem], *jumpClock, chan *queueableItem) {
+		t.Helper()
+
+		clock := &jumpClock{FakeClock: clocktesting.NewFakeClock(time.Now())}
+		executeCh := make(chan *queueableItem)
+		processor := NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+			ExecuteFn: func(r *queueableItem) {
+				executeCh <- r
+			},
+			DriftCheckInterval: driftCheckInterval,
+			Clock:              clock,
+		})
+		t.Cleanup(func() { require.NoError(t, processor.Close()) })
+		return processor, clock, executeCh
+	}
+
+	assertExecuted := func(t *testing.T, executeCh chan *queueableItem, name string) {
+		t.Helper()
+		select {
+		case r := <-executeCh:
+			assert.Equal(t, name, r.Name)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for execution")
+		}
+	}
+
+	assertNotExecuted := func(t *testing.T, executeCh chan *queueableItem) {
+		t.Helper()
+		select {
+		case r := <-executeCh:
+			require.Fail(t, "unexpected execution of item "+r.Name)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Run("without drift correction items are late", func(t *testing.T) {
+		processor, clock, executeCh := newProcessor(t, 0)
+
+		processor.Enqueue(newTestItem(1, clock.Now().Add(time.Hour)))
+		assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+
+		clock.Jump(2 * time.Hour)
+		clock.Step(time.Minute)
+		assertNotExecuted(t, executeCh)
+
+		clock.Step(time.Hour)
+		assertExecuted(t, executeCh, "1")
+	})
+
+	t.Run("drift check executes items due after a jump", func(t *testing.T) {
+		processor, clock, executeCh := newProcessor(t, 10*time.Second)
+
+		processor.Enqueue(newTestItem(1, clock.Now().Add(time.Hour)))
+		processor.Enqueue(newTestItem(2, clock.Now().Add(3*time.Hour)))
+		assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+
+		// Before the jump, the processor keeps waiting
+		clock.Step(10 * time.Second)
+		assertNotExecuted(t, executeCh)
+		assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+
+		clock.Jump(2 * time.Hour)
+		clock.Step(10 * time.Second)
+		assertExecuted(t, executeCh, "1")
+		assertNotExecuted(t, executeCh)
+		assert.True(t, processor.Contains("2"))
+	})
+
+	t.Run("reconcile executes items due after a jump", func(t *testing.T) {
+		processor, clock, executeCh := newProcessor(t, 0)
+
+		processor.Enqueue(newTestItem(1, clock.Now().Add(time.Hour)))
+		assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+
+		clock.Jump(2 * time.Hour)
+		assertNotExecuted(t, executeCh)
+
+		processor.Reconcile()
+		assertExecuted(t, executeCh, "1")
+	})
+}
@@ -15,6 +15,7 @@ package queue
 
 import (
 	"container/heap"
+	"sort"
 	"time"
 )
 
@@ -25,6 +26,31 @@ type Queueable[T comparable] interface {
 	ScheduledTime() time.Time
 }
 
+// ItemInfo contains information about an item in the queue.
+type ItemInfo[K comparable] struct {
+	// Key is the key of the item.
+	Key K
+	// ScheduledTime is the time the item is scheduled to be executed at.
+	// This differs from the item's own scheduled time if it's being retried.
+	ScheduledTime time.Time
+	// Attempts is the number of failed executions of the item.
+	Attempts int
+}
+
+// itemQueue is the interface of the internal implementations of the queue.
+type itemQueue[K comparable, T Queueable[K]] interface {
+	Len() int
+	Insert(r T, replace bool)
+	InsertAt(r T, scheduledTime time.Time, replace bool) bool
+	Pop() (T, bool)
+	Peek() (T, bool)
+	PeekScheduled() (T, time.Time, bool)
+	Snapshot(limit int) []ItemInfo[K]
+	ScheduledTime(key K) (time.Time, bool)
+	Remove(key K)
+	Update(r T)
+}
+
 // queue implements a queue for items that are scheduled to be executed at a later time.
 // It acts as a "priority queue", in which items are added in order of when they're scheduled.
 // Internally, it uses a heap (from container/heap) that allows Insert and Pop operations to be completed in O(log N) time (where N is the queue's length).
@@ -50,6 +76,14 @@ func (p *queue[K, T]) Len() int {
 // Insert inserts a new item into the queue.
 // If replace is true, existing items are replaced
 func (p *queue[K, T]) Insert(r T, replace bool) {
+	p.InsertAt(r, r.ScheduledTime(), replace)
+}
+
+// InsertAt inserts a new item into the queue, scheduled at the given time
+// rather than the item's own scheduled time.
+// If replace is true, existing items are replaced.
+// Returns true if the item was inserted or replaced.
+func (p *queue[K, T]) InsertAt(r T, scheduledTime time.Time, replace bool) bool {
 	key := r.Key()
 
 	// Check if the item already exists
@@ -57,16 +91,19 @@ func (p *queue[K, T]) Insert(r T, replace bool) {
 	if ok {
 		if replace {
 			item.value = r
+			item.scheduledTime = scheduledTime
 			heap.Fix(p.heap, item.index)
 		}
-		return
+		return replace
 	}
 
 	item = &queueItem[K, T]{
-		value: r,
+		value:         r,
+		scheduledTime: scheduledTime,
 	}
 	heap.Push(p.heap, item)
 	p.items[key] = item
+	return true
 }
 
 // Pop removes the next item in the queue and returns it.
@@ -98,6 +135,53 @@ func (p *queue[K, T]) Peek() (T, bool) {
 	return (*p.heap)[0].value, true
 }
 
+// PeekScheduled returns the next item in the queue and the time it's
+// scheduled at, without removing it.
+// The returned boolean value will be "true" if an item was found.
+func (p *queue[K, T]) PeekScheduled() (T, time.Time, bool) {
+	if p.Len() == 0 {
+		var zero T
+		return zero, time.Time{}, false
+	}
+
+	item := (*p.heap)[0]
+	return item.value, item.scheduledTime, true
+}
+
+// Snapshot returns the keys and scheduled times of the items in the queue, in
+// the order they are scheduled, without modifying the queue.
+// If limit is greater than 0, at most limit items are returned.
+func (p *queue[K, T]) Snapshot(limit int) []ItemInfo[K] {
+	items := make([]*queueItem[K, T], len(*p.heap))
+	copy(items, *p.heap)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].scheduledTime.Before(items[j].scheduledTime)
+	})
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	res := make([]ItemInfo[K], len(items))
+	for i, item := range items {
+		res[i] = ItemInfo[K]{
+			Key:           item.value.Key(),
+			ScheduledTime: item.scheduledTime,
+		}
+	}
+	return res
+}
+
+// ScheduledTime returns the time the item with the given key is scheduled at.
+// The returned boolean value will be "true" if the item was found.
+func (p *queue[K, T]) ScheduledTime(key K) (time.Time, bool) {
+	item, ok := p.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return item.scheduledTime, true
+}
+
 // Remove an item from the queue.
 func (p *queue[K, T]) Remove(key K) {
 	// If the item is not in the queue, this is a nop
@@ -119,12 +203,17 @@ func (p *queue[K, T]) Update(r T) {
 	}
 
 	item.value = r
+	item.scheduledTime = r.ScheduledTime()
 	heap.Fix(p.heap, item.index)
 }
 
 type queueItem[K comparable, T Queueable[K]] struct {
 	value T
 
+	// The time the item is scheduled at. This is normally the value's
+	// ScheduledTime, but may differ for items being retried.
+	scheduledTime time.Time
+
 	// The index of the item in the heap. This is maintained by the heap.Interface methods.
 	index int
 }
@@ -136,7 +225,7 @@ func (pq queueHeap[K, T]) Len() int {
 }
 
 func (pq queueHeap[K, T]) Less(i, j int) bool {
-	return pq[i].value.ScheduledTime().Before(pq[j].value.ScheduledTime())
+	return pq[i].scheduledTime.Before(pq[j].scheduledTime)
 }
 
 func (pq queueHeap[K, T]) Swap(i, j int) {
@@ -314,3 +314,34 @@ func peekAndCompare(t *testing.T, q *queue[string, *queueableItem], expectN int,
 	assert.Equal(t, strconv.Itoa(expectN), r.Name)
 	assert.Equal(t, expectDueTime, r.ScheduledTime().Format(time.RFC3339))
 }
+
+func TestQueueSnapshot(t *testing.T) {
+	queue := newQueue[string, *queueableItem]()
+	assert.Empty(t, queue.Snapshot(0))
+
+	queue.Insert(newTestItem(2, "2022-02-02T02:02:02Z"), false)
+	queue.Insert(newTestItem(3, "2023-03-03T03:03:03Z"), false)
+	queue.Insert(newTestItem(1, "2021-01-01T01:01:01Z"), false)
+
+	items := queue.Snapshot(0)
+	require.Len(t, items, 3)
+	for i, item := range items {
+		assert.Equal(t, strconv.Itoa(i+1), item.Key)
+	}
+	assert.Equal(t, "2021-01-01T01:01:01Z", items[0].ScheduledTime.Format(time.RFC3339))
+
+	items = queue.Snapshot(2)
+	require.Len(t, items, 2)
+	assert.Equal(t, "1", items[0].Key)
+	assert.Equal(t, "2", items[1].Key)
+
+	scheduledTime, ok := queue.ScheduledTime("3")
+	require.True(t, ok)
+	assert.Equal(t, "2023-03-03T03:03:03Z", scheduledTime.Format(time.RFC3339))
+	_, ok = queue.ScheduledTime("4")
+	assert.False(t, ok)
+
+	// The queue should not be modified
+	require.Equal(t, 3, queue.Len())
+	popAndCompare(t, &queue, 1, "2021-01-01T01:01:01Z")
+}
@@ -0,0 +1,426 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"container/heap"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+const (
+	// Number of bits of the tick used by each level of the timing wheel.
+	wheelBits = 6
+	// Number of slots in each level of the timing wheel.
+	wheelSize = 1 << wheelBits
+	wheelMask = wheelSize - 1
+	// Number of levels needed to cover all 64 bits of the ticks.
+	wheelLevels = (64 + wheelBits - 1) / wheelBits
+
+	// defaultTimingWheelResolution is the default duration of a tick of the
+	// timing wheel.
+	defaultTimingWheelResolution = time.Millisecond
+)
+
+// Range of the times which can be represented in nanoseconds since the epoch.
+var (
+	minUnixNanoTime = time.Unix(0, math.MinInt64)
+	maxUnixNanoTime = time.Unix(0, math.MaxInt64)
+)
+
+// timingWheel implements the same operations as queue, using a hierarchical
+// timing wheel rather than a heap.
+// Time is divided in ticks of the given resolution. Each level of the wheel has
+// 64 slots: items are stored in the lowest level in which their tick shares all
+// the higher bits with the wheel's cursor, in the slot given by the bits of
+// that level. Insert and Remove are completed in O(1) time, and items are
+// moved to lower levels ("cascaded") only when the wheel advances to their
+// slot, so far-future items are not touched until they are about to be due.
+// Inserting an item before the cursor moves the cursor back ("rebases" the
+// wheel): only the items in the levels below the highest one whose bits
+// change are moved, all to a single slot, so this is cheap when those items
+// are few, as they are the ones closest to the cursor.
+// Items in the same tick are kept in a small heap, so they are returned in the
+// order they are scheduled.
+// Note: methods in this struct are not safe for concurrent use. Callers should use locks to ensure consistency.
+type timingWheel[K comparable, T Queueable[K]] struct {
+	resolution int64
+	cursor     uint64
+	slots      [wheelLevels][wheelSize]wheelSlot[K, T]
+	// Bitmaps of the non-empty slots in each level.
+	occupied [wheelLevels]uint64
+	// Number of items in the slots.
+	wheelLen int
+	items    map[K]*wheelItem[K, T]
+}
+
+// newTimingWheel creates a new timingWheel with ticks of the given resolution.
+func newTimingWheel[K comparable, T Queueable[K]](resolution time.Duration) *timingWheel[K, T] {
+	if resolution <= 0 {
+		resolution = defaultTimingWheelResolution
+	}
+	return &timingWheel[K, T]{
+		resolution: int64(resolution),
+		items:      make(map[K]*wheelItem[K, T]),
+	}
+}
+
+// Len returns the number of items in the queue.
+func (w *timingWheel[K, T]) Len() int {
+	return len(w.items)
+}
+
+// Insert inserts a new item into the queue.
+// If replace is true, existing items are replaced
+func (w *timingWheel[K, T]) Insert(r T, replace bool) {
+	w.InsertAt(r, r.ScheduledTime(), replace)
+}
+
+// InsertAt inserts a new item into the queue, scheduled at the given time
+// rather than the item's own scheduled time.
+// If replace is true, existing items are replaced.
+// Returns true if the item was inserted or replaced.
+func (w *timingWheel[K, T]) InsertAt(r T, scheduledTime time.Time, replace bool) bool {
+	key := r.Key()
+
+	item, ok := w.items[key]
+	if ok {
+		if replace {
+			w.remove(item)
+			item.value = r
+			item.scheduledTime = scheduledTime
+			w.add(item)
+		}
+		return replace
+	}
+
+	item = &wheelItem[K, T]{
+		value:         r,
+		scheduledTime: scheduledTime,
+	}
+	w.add(item)
+	w.items[key] = item
+	return true
+}
+
+// Pop removes the next item in the queue and returns it.
+// The returned boolean value will be "true" if an item was found.
+func (w *timingWheel[K, T]) Pop() (T, bool) {
+	item := w.first()
+	if item == nil {
+		var zero T
+		return zero, false
+	}
+
+	w.remove(item)
+	delete(w.items, item.value.Key())
+	return item.value, true
+}
+
+// Peek returns the next item in the queue, without removing it.
+// The returned boolean value will be "true" if an item was found.
+func (w *timingWheel[K, T]) Peek() (T, bool) {
+	r, _, ok := w.PeekScheduled()
+	return r, ok
+}
+
+// PeekScheduled returns the next item in the queue and the time it's
+// scheduled at, without removing it.
+// The returned boolean value will be "true" if an item was found.
+func (w *timingWheel[K, T]) PeekScheduled() (T, time.Time, bool) {
+	item := w.first()
+	if item == nil {
+		var zero T
+		return zero, time.Time{}, false
+	}
+
+	return item.value, item.scheduledTime, true
+}
+
+// Snapshot returns the keys and scheduled times of the items in the queue, in
+// the order they are scheduled, without modifying the queue.
+// If limit is greater than 0, at most limit items are returned.
+func (w *timingWheel[K, T]) Snapshot(limit int) []ItemInfo[K] {
+	items := make([]*wheelItem[K, T], 0, len(w.items))
+	for _, item := range w.items {
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].scheduledTime.Before(items[j].scheduledTime)
+	})
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	res := make([]ItemInfo[K], len(items))
+	for i, item := range items {
+		res[i] = ItemInfo[K]{
+			Key:           item.value.Key(),
+			ScheduledTime: item.scheduledTime,
+		}
+	}
+	return res
+}
+
+// ScheduledTime returns the time the item with the given key is scheduled at.
+// The returned boolean value will be "true" if the item was found.
+func (w *timingWheel[K, T]) ScheduledTime(key K) (time.Time, bool) {
+	item, ok := w.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return item.scheduledTime, true
+}
+
+// Remove an item from the queue.
+func (w *timingWheel[K, T]) Remove(key K) {
+	// If the item is not in the queue, this is a nop
+	item, ok := w.items[key]
+	if !ok {
+		return
+	}
+
+	w.remove(item)
+	delete(w.items, key)
+}
+
+// Update an item in the queue.
+func (w *timingWheel[K, T]) Update(r T) {
+	// If the item is not in the queue, this is a nop
+	item, ok := w.items[r.Key()]
+	if !ok {
+		return
+	}
+
+	w.remove(item)
+	item.value = r
+	item.scheduledTime = r.ScheduledTime()
+	w.add(item)
+}
+
+// tick returns the tick of t.
+// Ticks are offset so that their order as unsigned integers is the same as the
+// order of the times, including those before the Unix epoch. Times which can't
+// be represented in nanoseconds since the epoch, i.e. before 1678 or after
+// 2262, are clamped to the first or last tick; items in the same tick are
+// still returned in order.
+func (w *timingWheel[K, T]) tick(t time.Time) uint64 {
+	var ns int64
+	switch {
+	case t.Before(minUnixNanoTime):
+		ns = math.MinInt64
+	case t.After(maxUnixNanoTime):
+		ns = math.MaxInt64
+	default:
+		ns = t.UnixNano()
+	}
+	tick := ns / w.resolution
+	if ns < 0 && ns%w.resolution != 0 {
+		tick--
+	}
+	return uint64(tick) ^ (1 << 63)
+}
+
+// add adds an item to the slots, rebasing the wheel first if it's scheduled
+// before the cursor.
+func (w *timingWheel[K, T]) add(item *wheelItem[K, T]) {
+	item.tick = w.tick(item.scheduledTime)
+
+	switch {
+	case w.wheelLen == 0:
+		// The cursor can be moved freely when the slots are empty
+		w.cursor = item.tick
+	case item.tick < w.cursor:
+		w.rebase(item.tick)
+	}
+
+	w.addToSlot(item)
+}
+
+// rebase moves the cursor back to the given tick, which is before the cursor.
+// Let r be the level of the highest bit which differs between the two ticks.
+// The items in the levels above r share all their higher bits with the new
+// cursor too, so they remain in their slots, as do those in level r, whose
+// bits of that level are after the cursor's. The items in the levels below r
+// share the bits of level r with the old cursor, so they're all moved to the
+// slot of level r given by those bits.
+func (w *timingWheel[K, T]) rebase(tick uint64) {
+	r := (bits.Len64(tick^w.cursor) - 1) / wheelBits
+	slot := int((w.cursor >> (wheelBits * r)) & wheelMask)
+	w.cursor = tick
+
+	for level := range r {
+		for occupied := w.occupied[level]; occupied != 0; occupied &= occupied - 1 {
+			s := bits.TrailingZeros64(occupied)
+			items := w.slots[level][s]
+			for _, item := range items {
+				item.level = r
+				item.slot = slot
+				w.slots[r][slot].append(item)
+			}
+			clear(items)
+			w.slots[level][s] = items[:0]
+			w.occupied[r] |= 1 << slot
+		}
+		w.occupied[level] = 0
+	}
+}
+
+// addToSlot adds an item, whose tick is not before the cursor, to its slot.
+func (w *timingWheel[K, T]) addToSlot(item *wheelItem[K, T]) {
+	level := 0
+	if diff := item.tick ^ w.cursor; diff != 0 {
+		level = (bits.Len64(diff) - 1) / wheelBits
+	}
+	slot := int((item.tick >> (wheelBits * level)) & wheelMask)
+
+	item.level = level
+	item.slot = slot
+	if level == 0 {
+		heap.Push(&w.slots[0][slot], item)
+	} else {
+		w.slots[level][slot].append(item)
+	}
+	w.occupied[level] |= 1 << slot
+	w.wheelLen++
+}
+
+// remove removes an item from its slot.
+func (w *timingWheel[K, T]) remove(item *wheelItem[K, T]) {
+	switch item.level {
+	case 0:
+		heap.Remove(&w.slots[0][item.slot], item.index)
+	default:
+		w.slots[item.level][item.slot].remove(item.index)
+	}
+
+	if len(w.slots[item.level][item.slot]) == 0 {
+		w.occupied[item.level] &^= 1 << item.slot
+	}
+	w.wheelLen--
+}
+
+// first returns the item scheduled first, or nil if the queue is empty.
+func (w *timingWheel[K, T]) first() *wheelItem[K, T] {
+	if w.wheelLen == 0 {
+		return nil
+	}
+	return w.firstInSlots()
+}
+
+// firstInSlots returns the item scheduled first in the slots, advancing the
+// cursor and cascading items to the lower levels as needed.
+// There must be at least one item in the slots.
+func (w *timingWheel[K, T]) firstInSlots() *wheelItem[K, T] {
+	for {
+		// All the items in a slot of the first level have the same tick, and no
+		// slot before the cursor's is occupied, so the first occupied slot
+		// contains the next item
+		if w.occupied[0] != 0 {
+			slot := bits.TrailingZeros64(w.occupied[0])
+			return w.slots[0][slot][0]
+		}
+
+		// Find the first occupied slot in the lowest level and move the cursor
+		// to its start, then cascade its items to the lower levels
+		for level := 1; level < wheelLevels; level++ {
+			if w.occupied[level] == 0 {
+				continue
+			}
+
+			slot := bits.TrailingZeros64(w.occupied[level])
+			shift := uint(wheelBits * level)
+			w.cursor = (w.cursor >> (shift + wheelBits) << (shift + wheelBits)) | uint64(slot)<<shift
+
+			// Items are always moved to lower levels, so the slot can be re-used
+			items := w.slots[level][slot]
+			w.occupied[level] &^= 1 << slot
+			w.wheelLen -= len(items)
+			for _, item := range items {
+				w.addToSlot(item)
+			}
+			clear(items)
+			w.slots[level][slot] = items[:0]
+			break
+		}
+	}
+}
+
+type wheelItem[K comparable, T Queueable[K]] struct {
+	value T
+
+	// The time the item is scheduled at. This is normally the value's
+	// ScheduledTime, but may differ for items being retried.
+	scheduledTime time.Time
+
+	// The tick of scheduledTime.
+	tick uint64
+
+	// The position of the item in the wheel.
+	level int
+	slot  int
+	index int
+}
+
+// wheelSlot contains the items of a slot of the timing wheel.
+// Slots in the first level are heaps ordered by scheduled time; slots in the
+// other levels are unordered.
+type wheelSlot[K comparable, T Queueable[K]] []*wheelItem[K, T]
+
+func (s *wheelSlot[K, T]) append(item *wheelItem[K, T]) {
+	item.index = len(*s)
+	*s = append(*s, item)
+}
+
+func (s *wheelSlot[K, T]) remove(i int) {
+	old := *s
+	n := len(old) - 1
+	if i != n {
+		old[i] = old[n]
+		old[i].index = i
+	}
+	old[n] = nil // Avoid memory leak
+	*s = old[:n]
+}
+
+func (s wheelSlot[K, T]) Len() int {
+	return len(s)
+}
+
+func (s wheelSlot[K, T]) Less(i, j int) bool {
+	return s[i].scheduledTime.Before(s[j].scheduledTime)
+}
+
+func (s wheelSlot[K, T]) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+	s[i].index = i
+	s[j].index = j
+}
+
+func (s *wheelSlot[K, T]) Push(x any) {
+	s.append(x.(*wheelItem[K, T]))
+}
+
+func (s *wheelSlot[K, T]) Pop() any {
+	old := *s
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil  // Avoid memory leak
+	item.index = -1 // For safety
+	*s = old[0 : n-1]
+	return item
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestTimingWheel(t *testing.T) {
+	t.Run("items are returned in order", func(t *testing.T) {
+		w := newTimingWheel[string, *queueableItem](time.Second)
+
+		// Add items which are not in order, including some within the same tick
+		w.Insert(newTestItem(3, "2023-03-03T03:03:03Z"), false)
+		w.Insert(newTestItem(5, "2029-09-09T09:09:09Z"), false)
+		w.Insert(newTestItem(1, "1960-01-01T01:01:01Z"), false)
+		w.Insert(newTestItem(4, time.Date(2023, 3, 3, 3, 3, 3, 500, time.UTC)), false)
+		w.Insert(newTestItem(2, "2022-02-02T02:02:02Z"), false)
+		w.Insert(newTestItem(6, "2424-04-04T04:04:04Z"), false)
+		require.Equal(t, 6, w.Len())
+
+		for i := 1; i <= 6; i++ {
+			r, ok := w.Pop()
+			require.True(t, ok)
+			assert.Equal(t, strconv.Itoa(i), r.Name)
+		}
+		_, ok := w.Pop()
+		require.False(t, ok)
+		assert.Equal(t, 0, w.Len())
+	})
+
+	t.Run("items before the cursor", func(t *testing.T) {
+		w := newTimingWheel[string, *queueableItem](time.Millisecond)
+
+		w.Insert(newTestItem(3, "2023-03-03T03:03:03Z"), false)
+		w.Insert(newTestItem(4, "2024-04-04T04:04:04Z"), false)
+
+		// Move the cursor forward
+		r, ok := w.Peek()
+		require.True(t, ok)
+		require.Equal(t, "3", r.Name)
+
+		// Add items before the cursor, which move it back
+		w.Insert(newTestItem(2, "2022-02-02T02:02:02Z"), false)
+		w.Insert(newTestItem(1, "2021-01-01T01:01:01Z"), false)
+		assert.Equal(t, w.tick(time.Date(2021, 1, 1, 1, 1, 1, 0, time.UTC)), w.cursor)
+
+		for i := 1; i <= 4; i++ {
+			r, ok := w.Pop()
+			require.True(t, ok)
+			assert.Equal(t, strconv.Itoa(i), r.Name)
+		}
+	})
+
+	t.Run("replace, update and remove", func(t *testing.T) {
+		w := newTimingWheel[string, *queueableItem](time.Millisecond)
+
+		w.Insert(newTestItem(1, "2021-01-01T01:01:01Z"), false)
+		w.Insert(newTestItem(2, "2022-02-02T02:02:02Z"), false)
+		w.Insert(newTestItem(3, "2023-03-03T03:03:03Z"), false)
+
+		// Not replaced
+		assert.False(t, w.InsertAt(newTestItem(1, "2029-09-09T09:09:09Z"), time.Now(), false))
+		r, scheduled, ok := w.PeekScheduled()
+		require.True(t, ok)
+		assert.Equal(t, "1", r.Name)
+		assert.Equal(t, "2021-01-01T01:01:01Z", scheduled.Format(time.RFC3339))
+
+		// Replaced
+		assert.True(t, w.InsertAt(newTestItem(1, "2021-01-01T01:01:01Z"), time.Date(2029, 9, 9, 9, 9, 9, 0, time.UTC), true))
+		r, ok = w.Peek()
+		require.True(t, ok)
+		assert.Equal(t, "2", r.Name)
+
+		w.Update(newTestItem(3, "2020-01-01T01:01:01Z"))
+		w.Remove("2")
+		w.Remove("not-found")
+		require.Equal(t, 2, w.Len())
+
+		scheduledTime, ok := w.ScheduledTime("1")
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2029, 9, 9, 9, 9, 9, 0, time.UTC), scheduledTime)
+		_, ok = w.ScheduledTime("2")
+		assert.False(t, ok)
+
+		assert.Equal(t, []ItemInfo[string]{
+			{Key: "3", ScheduledTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)},
+			{Key: "1", ScheduledTime: time.Date(2029, 9, 9, 9, 9, 9, 0, time.UTC)},
+		}, w.Snapshot(0))
+		assert.Len(t, w.Snapshot(1), 1)
+
+		r, ok = w.Pop()
+		require.True(t, ok)
+		assert.Equal(t, "3", r.Name)
+		r, ok = w.Pop()
+		require.True(t, ok)
+		assert.Equal(t, "1", r.Name)
+	})
+
+	t.Run("same order as the heap", func(t *testing.T) {
+		//nolint:gosec
+		rnd := rand.New(rand.NewSource(1))
+		now := time.Now()
+		heapQueue := newQueue[string, *queueableItem]()
+		queues := []itemQueue[string, *queueableItem]{
+			&heapQueue,
+			newTimingWheel[string, *queueableItem](time.Millisecond),
+		}
+
+		randomTime := func() time.Time {
+			switch rnd.Intn(4) {
+			case 0:
+				// Within a few milliseconds
+				return now.Add(time.Duration(rnd.Int63n(int64(10 * time.Millisecond))))
+			case 1:
+				// Within hours
+				return now.Add(time.Duration(rnd.Int63n(int64(10 * time.Hour))))
+			case 2:
+				// Within years
+				return now.Add(time.Duration(rnd.Int63n(int64(10 * 365 * 24 * time.Hour))))
+			default:
+				// In the past
+				return now.Add(-time.Duration(rnd.Int63n(int64(time.Hour))))
+			}
+		}
+
+		for i := range 20_000 {
+			key := strconv.Itoa(rnd.Intn(2_000))
+			switch op := rnd.Intn(10); {
+			case op < 5:
+				item := newTestItem(0, randomTime())
+				item.Name = key
+				for _, q := range queues {
+					q.Insert(item, op%2 == 0)
+				}
+			case op < 6:
+				for _, q := range queues {
+					q.Remove(key)
+				}
+			default:
+				expect, expectOK := queues[0].Pop()
+				got, gotOK := queues[1].Pop()
+				require.Equal(t, expectOK, gotOK, "operation %d", i)
+				if expectOK {
+					// Items with the same time can be returned in any order
+					require.True(t, expect.ExecutionTime.Equal(got.ExecutionTime), "operation %d", i)
+					if expect != got {
+						queues[0].Insert(expect, false)
+						queues[0].Remove(got.Name)
+					}
+				}
+			}
+			require.Equal(t, queues[0].Len(), queues[1].Len())
+		}
+
+		for queues[0].Len() > 0 {
+			expect, _ := queues[0].Pop()
+			got, ok := queues[1].Pop()
+			require.True(t, ok)
+			require.True(t, expect.ExecutionTime.Equal(got.ExecutionTime))
+		}
+	})
+}
+
+func TestProcessorTimingWheel(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	executeCh := make(chan *queueableItem)
+	processor := NewProcessorWithOptions(ProcessorOptions[string, *queueableItem]{
+		ExecuteFn: func(r *queueableItem) {
+			executeCh <- r
+		},
+		QueueImplementation: QueueImplementationTimingWheel,
+		Clock:               clock,
+	})
+	defer processor.Close()
+
+	for i := 5; i >= 1; i-- {
+		processor.Enqueue(newTestItem(i, clock.Now().Add(time.Second*time.Duration(i))))
+	}
+	// Far-future item which is never executed
+	processor.Enqueue(newTestItem(100, clock.Now().Add(24*365*time.Hour)))
+
+	for i := 1; i <= 5; i++ {
+		assert.Eventually(t, clock.HasWaiters, time.Second, 10*time.Millisecond)
+		clock.Step(time.Second)
+		select {
+		case r := <-executeCh:
+			assert.Equal(t, strconv.Itoa(i), r.Name)
+		case <-time.After(time.Second):
+			t.Fatalf("item %d was not executed", i)
+		}
+	}
+
+	r, _, ok := processor.Peek()
+	require.True(t, ok)
+	assert.Equal(t, "100", r.Name)
+}
+
+func BenchmarkQueue(b *testing.B) {
+	const n = 100_000
+	now := time.Now()
+	items := make([]*queueableItem, n)
+	//nolint:gosec
+	rnd := rand.New(rand.NewSource(1))
+	for i := range items {
+		// Items scheduled up to 1 year in the future
+		items[i] = newTestItem(i, now.Add(time.Duration(rnd.Int63n(int64(365*24*time.Hour)))))
+	}
+
+	// Items in reverse order, so each is inserted before the first one
+	reversed := slices.Clone(items)
+	slices.SortFunc(reversed, func(a, b *queueableItem) int {
+		return b.ExecutionTime.Compare(a.ExecutionTime)
+	})
+
+	run := func(b *testing.B, items []*queueableItem, newQueueFn func() itemQueue[string, *queueableItem]) {
+		b.ReportAllocs()
+		for range b.N {
+			q := newQueueFn()
+			for _, item := range items {
+				q.Insert(item, false)
+			}
+			for q.Len() > 0 {
+				q.Pop()
+			}
+		}
+	}
+
+	newHeap := func() itemQueue[string, *queueableItem] {
+		q := newQueue[string, *queueableItem]()
+		return &q
+	}
+	newWheel := func() itemQueue[string, *queueableItem] {
+		return newTimingWheel[string, *queueableItem](time.Millisecond)
+	}
+
+	b.Run("heap", func(b *testing.B) {
+		run(b, items, newHeap)
+	})
+	b.Run("timing wheel", func(b *testing.B) {
+		run(b, items, newWheel)
+	})
+	b.Run("heap reverse order", func(b *testing.B) {
+		run(b, reversed, newHeap)
+	})
+	b.Run("timing wheel reverse order", func(b *testing.B) {
+		run(b, reversed, newWheel)
+	})
+}
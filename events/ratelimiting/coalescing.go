@@ -16,6 +16,7 @@ package ratelimiting
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -40,21 +41,114 @@ type OptionsCoalescing struct {
 	// limiter never firing events in a high throughput scenario.
 	// Defaults to unlimited.
 	MaxPendingEvents *int
+
+	// OnFire is an optional callback invoked each time the rate limiter fires
+	// an event, with the statistics at the time of firing.
+	OnFire func(stats CoalescingStats)
+
+	// SaveState is an optional callback invoked with the state of the rate
+	// limiter each time it changes, so it can be persisted and restored with
+	// LoadState after a restart.
+	// It is invoked synchronously while the rate limiter is locked, so it must
+	// not block, nor call methods of the rate limiter.
+	SaveState func(state CoalescingState)
+
+	// LoadState is an optional function invoked by NewCoalescing to restore the
+	// state previously saved with SaveState. If it returns nil, the rate limiter
+	// starts from the initial state.
+	// When the rate limiter is run, the rate limiting window of the restored
+	// state is resumed if it has not expired yet, and pending events are fired
+	// when it expires, so consumers are not flooded with events after a restart.
+	LoadState func() (*CoalescingState, error)
+
+	// Clock is the clock used for the rate limiting window.
+	// Defaults to the real clock; set it to a fake clock for deterministic
+	// tests.
+	Clock clock.WithTicker
+}
+
+// CoalescingStats are the statistics of a Coalescing RateLimiter.
+type CoalescingStats struct {
+	// CurrentDelay is the current delay of the rate limiting window.
+	CurrentDelay time.Duration
+
+	// PendingEvents is the number of events received which have not been
+	// fired yet.
+	PendingEvents int
+
+	// SuppressedEvents is the number of events which were coalesced into the
+	// last fired event, i.e. that didn't result in an event of their own.
+	SuppressedEvents int
+
+	// TotalEvents is the total number of events received.
+	TotalEvents uint64
+
+	// FiredEvents is the total number of events fired.
+	FiredEvents uint64
+}
+
+// CoalescingState is the state of a Coalescing RateLimiter which can be
+// persisted across restarts.
+type CoalescingState struct {
+	// CurrentDelay is the current delay of the rate limiting window.
+	CurrentDelay time.Duration `json:"currentDelay"`
+
+	// PendingEvents is the number of events received which have not been
+	// fired yet.
+	PendingEvents int `json:"pendingEvents"`
+
+	// LastFired is the time the last event was fired, or the zero value if no
+	// event has been fired.
+	LastFired time.Time `json:"lastFired"`
+}
+
+// Coalescing is a RateLimiter which coalesces events, and reports statistics
+// about it.
+type Coalescing interface {
+	RateLimiter
+
+	// Stats returns the current statistics of the rate limiter.
+	Stats() CoalescingStats
+
+	// State returns the current state of the rate limiter.
+	State() CoalescingState
 }
 
 // coalescing is a rate limiter that rate limits events. It coalesces events
 // that occur within a rate limiting window.
 type coalescing struct {
+	*coalescer[struct{}]
+}
+
+// coalescer implements the coalescing of events, optionally carrying a
+// payload which is reduced and delivered when the event is fired.
+type coalescer[T any] struct {
 	initialDelay     time.Duration
 	maxDelay         time.Duration
 	maxPendingEvents *int
-
-	pendingEvents int
-	timer         clock.Timer
-	hasTimer      atomic.Bool
-	inputCh       chan struct{}
-	currentDur    time.Duration
-	backoffFactor int
+	onFire           func(stats CoalescingStats)
+	saveState        func(state CoalescingState)
+	reduce           func(acc T, v T) T
+
+	pendingEvents    int
+	payload          T
+	suppressedEvents int
+	totalEvents      uint64
+	firedEvents      uint64
+	timer            clock.Timer
+	hasTimer         atomic.Bool
+	inputCh          chan struct{}
+	currentDur       time.Duration
+	backoffFactor    int
+	lastFired        time.Time
+	// restored is the state restored with LoadState, if the rate limiting
+	// window is yet to be resumed by Run.
+	restored *CoalescingState
+	// deliveries are the fired events which are yet to be delivered, in the
+	// order they were fired. They are delivered by a single goroutine, signaled
+	// with deliverCh, so consumers receive them in order.
+	deliveries []delivery[T]
+	deliverCh  chan struct{}
 
 	wg      sync.WaitGroup
 	lock    sync.RWMutex
@@ -64,7 +158,21 @@ type coalescing struct {
 	closed  atomic.Bool
 }
 
-func NewCoalescing(opts OptionsCoalescing) (RateLimiter, error) {
+// delivery is a fired event to deliver to the consumer.
+type delivery[T any] struct {
+	stats   CoalescingStats
+	payload T
+}
+
+func NewCoalescing(opts OptionsCoalescing) (Coalescing, error) {
+	c, err := newCoalescer[struct{}](opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &coalescing{coalescer: c}, nil
+}
+
+func newCoalescer[T any](opts OptionsCoalescing, reduce func(acc T, v T) T) (*coalescer[T], error) {
 	initialDelay := time.Millisecond * 500
 	if opts.InitialDelay != nil {
 		initialDelay = *opts.InitialDelay
@@ -89,27 +197,98 @@ func NewCoalescing(opts OptionsCoalescing) (RateLimiter, error) {
 		return nil, errors.New("max pending events must be > 0")
 	}
 
-	return &coalescing{
+	cl := opts.Clock
+	if cl == nil {
+		cl = clock.RealClock{}
+	}
+
+	c := &coalescer[T]{
 		initialDelay:     initialDelay,
 		maxDelay:         maxDelay,
 		maxPendingEvents: opts.MaxPendingEvents,
+		onFire:           opts.OnFire,
+		saveState:        opts.SaveState,
+		reduce:           reduce,
 		currentDur:       initialDelay,
 		backoffFactor:    1,
 		inputCh:          make(chan struct{}),
+		deliverCh:        make(chan struct{}, 1),
 		closeCh:          make(chan struct{}),
-		clock:            clock.RealClock{},
-	}, nil
+		clock:            cl,
+	}
+
+	if opts.LoadState != nil {
+		state, err := opts.LoadState()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rate limiter state: %w", err)
+		}
+		if state != nil {
+			c.restoreState(*state)
+		}
+	}
+
+	return c, nil
+}
+
+// restoreState sets the state of the rate limiter to the given one. Delays are
+// capped to the rate limiter's bounds.
+func (c *coalescer[T]) restoreState(state CoalescingState) {
+	c.currentDur = min(max(state.CurrentDelay, c.initialDelay), c.maxDelay)
+	for time.Duration(c.backoffFactor)*c.initialDelay < c.currentDur {
+		c.backoffFactor *= 2
+	}
+	c.pendingEvents = max(state.PendingEvents, 0)
+	c.lastFired = state.LastFired
+	c.restored = &state
+}
+
+// resumeRestoredWindow resumes the rate limiting window of the restored state.
+// If the window has expired, the restored pending events are fired
+// immediately, as if they had just been received.
+func (c *coalescer[T]) resumeRestoredWindow() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.restored == nil {
+		return
+	}
+	restored := c.restored
+	c.restored = nil
+
+	var remaining time.Duration
+	if !restored.LastFired.IsZero() {
+		remaining = restored.LastFired.Add(c.currentDur).Sub(c.clock.Now())
+	}
+	if remaining > 0 {
+		c.timer = c.clock.NewTimer(remaining)
+		c.hasTimer.Store(true)
+		return
+	}
+
+	// The window has expired: start from the initial state
+	c.currentDur = c.initialDelay
+	c.backoffFactor = 1
+	if restored.PendingEvents > 0 {
+		c.timer = c.clock.NewTimer(c.initialDelay)
+		c.hasTimer.Store(true)
+		c.fireEvent()
+	}
+	c.notifyState()
 }
 
 // Run runs the rate limiter. It will begin rate limiting events after the
 // first event is received.
-func (c *coalescing) Run(ctx context.Context, ch chan<- struct{}) error {
+func (c *coalescer[T]) Run(ctx context.Context, ch chan<- T) error {
 	if !c.running.CompareAndSwap(false, true) {
 		return errors.New("already running")
 	}
 
 	// Prevent wg race condition on Close and Run.
 	c.lock.Lock()
+	if c.closed.Load() {
+		c.lock.Unlock()
+		return nil
+	}
 	c.wg.Add(1)
 	c.lock.Unlock()
 	defer c.wg.Done()
@@ -117,6 +296,14 @@ func (c *coalescing) Run(ctx context.Context, ch chan<- struct{}) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.deliver(ctx, ch)
+	}()
+
+	c.resumeRestoredWindow()
+
 	for {
 		// If the timer doesn't exist yet, we're waiting for the first event (which
 		// will fire immediately when received).
@@ -135,17 +322,18 @@ func (c *coalescing) Run(ctx context.Context, ch chan<- struct{}) error {
 			return nil
 
 		case <-c.inputCh:
-			c.handleInputCh(ctx, ch)
+			c.handleInputCh()
 
 		case <-timerCh:
-			c.handleTimerFired(ctx, ch)
+			c.handleTimerFired()
 		}
 	}
 }
 
-func (c *coalescing) handleInputCh(ctx context.Context, ch chan<- struct{}) {
+func (c *coalescer[T]) handleInputCh() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	defer c.notifyState()
 
 	switch {
 	case !c.hasTimer.Load():
@@ -154,13 +342,13 @@ func (c *coalescing) handleInputCh(ctx context.Context, ch chan<- struct{}) {
 		// initial delay.
 		c.timer = c.clock.NewTimer(c.initialDelay)
 		c.hasTimer.Store(true)
-		c.fireEvent(ctx, ch)
+		c.fireEvent()
 
 	default:
 		// If maxPendingEvents is set and we have reached it then fire the event
 		// immediately.
 		if c.maxPendingEvents != nil && c.pendingEvents >= *c.maxPendingEvents {
-			c.fireEvent(ctx, ch)
+			c.fireEvent()
 			return
 		}
 
@@ -183,31 +371,73 @@ func (c *coalescing) handleInputCh(ctx context.Context, ch chan<- struct{}) {
 	}
 }
 
-func (c *coalescing) handleTimerFired(ctx context.Context, ch chan<- struct{}) {
+func (c *coalescer[T]) handleTimerFired() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.fireEvent(ctx, ch)
+	c.fireEvent()
 	c.reset()
+	c.notifyState()
 }
 
-func (c *coalescing) fireEvent(ctx context.Context, ch chan<- struct{}) {
+// fireEvent queues an event for delivery, if there are pending events.
+// The caller must hold the lock.
+func (c *coalescer[T]) fireEvent() {
 	// Important to only send on the channel if there are pending events,
 	// otherwise we will double send an event, for example if only a single event
 	// was sent and then the rate limiting window expired with no new events.
 	if c.pendingEvents > 0 {
+		c.suppressedEvents = c.pendingEvents - 1
 		c.pendingEvents = 0
-		c.wg.Add(1)
-		go func() {
-			defer c.wg.Done()
+		c.firedEvents++
+		c.lastFired = c.clock.Now()
+		c.deliveries = append(c.deliveries, delivery[T]{
+			stats:   c.stats(),
+			payload: c.payload,
+		})
+		var zero T
+		c.payload = zero
+		select {
+		case c.deliverCh <- struct{}{}:
+		default:
+			// The delivery goroutine has been signaled already
+		}
+	}
+}
+
+// deliver delivers the fired events to ch, in the order they were fired,
+// until ctx is done. Events which are not delivered by then are discarded.
+func (c *coalescer[T]) deliver(ctx context.Context, ch chan<- T) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.deliverCh:
+		}
+
+		for {
+			c.lock.Lock()
+			if len(c.deliveries) == 0 {
+				c.lock.Unlock()
+				break
+			}
+			d := c.deliveries[0]
+			c.deliveries[0] = delivery[T]{}
+			c.deliveries = c.deliveries[1:]
+			c.lock.Unlock()
+
+			if c.onFire != nil {
+				c.onFire(d.stats)
+			}
 			select {
-			case ch <- struct{}{}:
+			case ch <- d.payload:
 			case <-ctx.Done():
+				return
 			}
-		}()
+		}
 	}
 }
 
-func (c *coalescing) reset() {
+func (c *coalescer[T]) reset() {
 	if !c.timer.Stop() {
 		select {
 		case <-c.timer.C():
@@ -216,6 +446,8 @@ func (c *coalescing) reset() {
 	}
 
 	c.pendingEvents = 0
+	var zero T
+	c.payload = zero
 	c.currentDur = c.initialDelay
 	c.backoffFactor = 1
 	c.hasTimer.Store(false)
@@ -223,9 +455,25 @@ func (c *coalescing) reset() {
 }
 
 func (c *coalescing) Add() {
+	c.add(struct{}{})
+}
+
+// add adds a new event with the given payload, which is reduced with the
+// payload of the pending events, if any.
+func (c *coalescer[T]) add(v T) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	if c.pendingEvents > 0 && c.reduce != nil {
+		c.payload = c.reduce(c.payload, v)
+	} else {
+		c.payload = v
+	}
 	c.pendingEvents++
+	c.totalEvents++
+	c.notifyState()
+	if c.closed.Load() {
+		return
+	}
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
@@ -236,16 +484,56 @@ func (c *coalescing) Add() {
 	}()
 }
 
-func (c *coalescing) Close() {
-	defer func() {
-		// Prevent wg race condition on Close and Run.
-		c.lock.Lock()
-		c.wg.Wait()
-		c.lock.Unlock()
-	}()
+// Stats returns the current statistics of the rate limiter.
+func (c *coalescer[T]) Stats() CoalescingStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.stats()
+}
+
+// State returns the current state of the rate limiter.
+func (c *coalescer[T]) State() CoalescingState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.state()
+}
+
+func (c *coalescer[T]) state() CoalescingState {
+	return CoalescingState{
+		CurrentDelay:  c.currentDur,
+		PendingEvents: c.pendingEvents,
+		LastFired:     c.lastFired,
+	}
+}
+
+// notifyState invokes the SaveState callback, if any, with the current state.
+// The caller must hold the lock.
+func (c *coalescer[T]) notifyState() {
+	if c.saveState != nil {
+		c.saveState(c.state())
+	}
+}
+
+func (c *coalescer[T]) stats() CoalescingStats {
+	return CoalescingStats{
+		CurrentDelay:     c.currentDur,
+		PendingEvents:    c.pendingEvents,
+		SuppressedEvents: c.suppressedEvents,
+		TotalEvents:      c.totalEvents,
+		FiredEvents:      c.firedEvents,
+	}
+}
+
+func (c *coalescer[T]) Close() {
+	// Prevent wg race condition on Close and Run: once closed is set, no new
+	// goroutines are added to the wait group from zero.
+	// The lock must not be held while waiting, as Run may need it to return.
+	c.lock.Lock()
 	if c.closed.CompareAndSwap(false, true) {
 		close(c.closeCh)
 	}
+	c.lock.Unlock()
+	c.wg.Wait()
 }
 
-var _ RateLimiter = (*coalescing)(nil)
+var _ Coalescing = (*coalescing)(nil)
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiting
+
+import "context"
+
+// OptionsCoalescingPayload configures a CoalescingPayload RateLimiter.
+type OptionsCoalescingPayload[T any] struct {
+	OptionsCoalescing
+
+	// Reduce combines the payload of the pending events, acc, with the payload
+	// of a new event, v, returning the payload to deliver when the event is
+	// fired. It is invoked synchronously while the rate limiter is locked, so it
+	// must not block, nor call methods of the rate limiter.
+	// The payload of the state restored with LoadState is not persisted, so
+	// restored pending events are delivered, or reduced, with the zero value.
+	// Defaults to KeepLatest.
+	Reduce func(acc T, v T) T
+}
+
+// CoalescingPayload is a rate limiter which coalesces events like Coalescing,
+// but where each event carries a payload. The payloads of the coalesced
+// events are reduced, and the reduced value is delivered when the event is
+// fired.
+type CoalescingPayload[T any] interface {
+	// Run starts the rate limiter. The given channel will have the reduced
+	// payloads sent to it, according to the rate limited parameters.
+	Run(ctx context.Context, eventCh chan<- T) error
+
+	// Add adds a new event with the given payload to the rate limiter.
+	Add(v T)
+
+	// Close closes the rate limiter and waits for all resources to be released.
+	Close()
+
+	// Stats returns the current statistics of the rate limiter.
+	Stats() CoalescingStats
+
+	// State returns the current state of the rate limiter.
+	State() CoalescingState
+}
+
+// coalescingPayload is a CoalescingPayload rate limiter.
+type coalescingPayload[T any] struct {
+	*coalescer[T]
+}
+
+// NewCoalescingPayload returns a new CoalescingPayload rate limiter.
+func NewCoalescingPayload[T any](opts OptionsCoalescingPayload[T]) (CoalescingPayload[T], error) {
+	reduce := opts.Reduce
+	if reduce == nil {
+		reduce = KeepLatest[T]
+	}
+
+	c, err := newCoalescer(opts.OptionsCoalescing, reduce)
+	if err != nil {
+		return nil, err
+	}
+	return &coalescingPayload[T]{coalescer: c}, nil
+}
+
+// Add adds a new event with the given payload to the rate limiter.
+func (c *coalescingPayload[T]) Add(v T) {
+	c.add(v)
+}
+
+// KeepLatest is a reducer which keeps the payload of the latest event.
+func KeepLatest[T any](_ T, v T) T {
+	return v
+}
+
+// KeepFirst is a reducer which keeps the payload of the first event.
+func KeepFirst[T any](acc T, _ T) T {
+	return acc
+}
+
+var _ CoalescingPayload[any] = (*coalescingPayload[any])(nil)
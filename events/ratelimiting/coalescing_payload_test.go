@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/kit/ptr"
+)
+
+func TestCoalescingPayload(t *testing.T) {
+	runPayloadTests := func(t *testing.T, opts OptionsCoalescingPayload[[]int]) (CoalescingPayload[[]int], *clocktesting.FakeClock, chan []int) {
+		t.Helper()
+		clock := clocktesting.NewFakeClock(time.Now())
+		opts.Clock = clock
+		opts.InitialDelay = ptr.Of(time.Second)
+		opts.MaxDelay = ptr.Of(time.Second * 2)
+		c, err := NewCoalescingPayload(opts)
+		require.NoError(t, err)
+
+		ch := make(chan []int)
+		errCh := make(chan error)
+		go func() {
+			errCh <- c.Run(context.Background(), ch)
+		}()
+
+		t.Cleanup(func() {
+			c.Close()
+
+			select {
+			case err := <-errCh:
+				require.NoError(t, err)
+			case <-time.After(time.Second):
+				require.Fail(t, "timeout")
+			}
+		})
+
+		return c, clock, ch
+	}
+
+	assertPayload := func(t *testing.T, ch chan []int, expect []int) {
+		t.Helper()
+		select {
+		case v := <-ch:
+			assert.Equal(t, expect, v)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout")
+		}
+	}
+
+	assertNoPayload := func(t *testing.T, ch chan []int) {
+		t.Helper()
+		select {
+		case v := <-ch:
+			require.Fail(t, "should not have received event", "%v", v)
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	t.Run("keeps the latest payload by default", func(t *testing.T) {
+		c, clock, ch := runPayloadTests(t, OptionsCoalescingPayload[[]int]{})
+
+		c.Add([]int{1})
+		assertPayload(t, ch, []int{1})
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second / 2)
+
+		c.Add([]int{2})
+		c.Add([]int{3})
+		c.Add([]int{4})
+		assertNoPayload(t, ch)
+		assert.Equal(t, 3, c.Stats().PendingEvents)
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second * 2)
+		assertPayload(t, ch, []int{4})
+		assertNoPayload(t, ch)
+		assert.Equal(t, 2, c.Stats().SuppressedEvents)
+	})
+
+	t.Run("reduces the payloads of coalesced events", func(t *testing.T) {
+		c, clock, ch := runPayloadTests(t, OptionsCoalescingPayload[[]int]{
+			Reduce: func(acc []int, v []int) []int {
+				return append(acc, v...)
+			},
+		})
+
+		c.Add([]int{1})
+		assertPayload(t, ch, []int{1})
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second / 2)
+
+		c.Add([]int{2})
+		c.Add([]int{3, 4})
+		assertNoPayload(t, ch)
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second * 2)
+		assertPayload(t, ch, []int{2, 3, 4})
+
+		// A new window starts from a new payload
+		c.Add([]int{5})
+		assertPayload(t, ch, []int{5})
+	})
+
+	t.Run("keep first", func(t *testing.T) {
+		c, clock, ch := runPayloadTests(t, OptionsCoalescingPayload[[]int]{
+			Reduce: KeepFirst[[]int],
+		})
+
+		c.Add([]int{1})
+		assertPayload(t, ch, []int{1})
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second / 2)
+
+		c.Add([]int{2})
+		c.Add([]int{3})
+
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second * 2)
+		assertPayload(t, ch, []int{2})
+	})
+
+	t.Run("max pending events fires the reduced payload", func(t *testing.T) {
+		c, clock, ch := runPayloadTests(t, OptionsCoalescingPayload[[]int]{
+			OptionsCoalescing: OptionsCoalescing{
+				MaxPendingEvents: ptr.Of(2),
+			},
+			Reduce: func(acc []int, v []int) []int {
+				return append(acc, v...)
+			},
+		})
+
+		c.Add([]int{1})
+		assertPayload(t, ch, []int{1})
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+
+		// The event is fired without waiting for the window to expire
+		c.Add([]int{2})
+		c.Add([]int{3})
+		assertPayload(t, ch, []int{2, 3})
+	})
+
+	t.Run("payloads are delivered in order to a slow consumer", func(t *testing.T) {
+		c, _, ch := runPayloadTests(t, OptionsCoalescingPayload[[]int]{
+			OptionsCoalescing: OptionsCoalescing{
+				MaxPendingEvents: ptr.Of(1),
+				// Callbacks which take a variable time must not reorder events
+				OnFire: func(stats CoalescingStats) {
+					if stats.FiredEvents%2 == 0 {
+						time.Sleep(2 * time.Millisecond)
+					}
+				},
+			},
+		})
+
+		// Events are fired faster than they are consumed
+		const n = 20
+		go func() {
+			for i := 1; i <= n; i++ {
+				c.Add([]int{i})
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+
+		var last int
+		for last < n {
+			select {
+			case v := <-ch:
+				require.Len(t, v, 1)
+				require.Greater(t, v[0], last, "payload delivered out of order")
+				last = v[0]
+				time.Sleep(5 * time.Millisecond)
+			case <-time.After(time.Second):
+				require.Fail(t, "timeout")
+			}
+		}
+	})
+
+	t.Run("options are validated", func(t *testing.T) {
+		_, err := NewCoalescingPayload(OptionsCoalescingPayload[string]{
+			OptionsCoalescing: OptionsCoalescing{
+				InitialDelay: ptr.Of(-time.Second),
+			},
+		})
+		require.Error(t, err)
+	})
+}
@@ -15,6 +15,8 @@ package ratelimiting
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -355,4 +357,178 @@ func TestCoalescing(t *testing.T) {
 		assert.False(t, clock.HasWaiters())
 		assertNoChannel(t, ch)
 	})
+
+	t.Run("stats should report coalesced events", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		firedStats := make(chan CoalescingStats, 2)
+		c, ch := runCoalescingTests(t, clock, OptionsCoalescing{
+			InitialDelay: ptr.Of(time.Second),
+			MaxDelay:     ptr.Of(time.Second * 5),
+			OnFire: func(stats CoalescingStats) {
+				firedStats <- stats
+			},
+		})
+
+		c.Add()
+		assertChannel(t, ch)
+		assert.Equal(t, CoalescingStats{
+			CurrentDelay: time.Second,
+			TotalEvents:  1,
+			FiredEvents:  1,
+		}, <-firedStats)
+
+		assert.Eventually(t, c.hasTimer.Load, time.Second, time.Millisecond)
+		for i := 0; i < 10; i++ {
+			c.Add()
+		}
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		stats := c.Stats()
+		assert.Equal(t, 10, stats.PendingEvents)
+		assert.Equal(t, uint64(11), stats.TotalEvents)
+		assert.Equal(t, uint64(1), stats.FiredEvents)
+
+		assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+			assert.Equal(ct, time.Second*5, c.Stats().CurrentDelay)
+		}, time.Second, time.Millisecond)
+		clock.Step(time.Second * 5)
+		assertChannel(t, ch)
+
+		fired := <-firedStats
+		assert.Equal(t, 0, fired.PendingEvents)
+		assert.Equal(t, 9, fired.SuppressedEvents)
+		assert.Equal(t, uint64(11), fired.TotalEvents)
+		assert.Equal(t, uint64(2), fired.FiredEvents)
+	})
+
+	t.Run("state is saved and restored", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		var (
+			lock  sync.Mutex
+			saved CoalescingState
+		)
+		c, ch := runCoalescingTests(t, clock, OptionsCoalescing{
+			InitialDelay: ptr.Of(time.Second),
+			MaxDelay:     ptr.Of(time.Second * 5),
+			SaveState: func(state CoalescingState) {
+				lock.Lock()
+				saved = state
+				lock.Unlock()
+			},
+		})
+
+		c.Add()
+		assertChannel(t, ch)
+		assert.Eventually(t, c.hasTimer.Load, time.Second, time.Millisecond)
+		firedAt := clock.Now()
+		for i := 0; i < 3; i++ {
+			c.Add()
+		}
+		expect := CoalescingState{
+			CurrentDelay:  time.Second * 5,
+			PendingEvents: 3,
+			LastFired:     firedAt,
+		}
+		assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+			lock.Lock()
+			defer lock.Unlock()
+			assert.Equal(ct, expect, saved)
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, expect, c.State())
+
+		// Restore the state in a new rate limiter: the window is resumed, so
+		// new events are coalesced with the pending ones
+		clock.Step(time.Second * 2)
+		restored, restoredCh := runCoalescingTests(t, clock, OptionsCoalescing{
+			InitialDelay: ptr.Of(time.Second),
+			MaxDelay:     ptr.Of(time.Second * 5),
+			LoadState: func() (*CoalescingState, error) {
+				return &expect, nil
+			},
+		})
+		assert.Eventually(t, restored.hasTimer.Load, time.Second, time.Millisecond)
+		assert.Equal(t, 8, restored.backoffFactor)
+
+		restored.Add()
+		assertNoChannel(t, restoredCh)
+		assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+			assert.Equal(ct, 4, restored.State().PendingEvents)
+		}, time.Second, time.Millisecond)
+		clock.Step(time.Second * 5)
+		assertChannel(t, restoredCh)
+		assertNoChannel(t, restoredCh)
+	})
+
+	t.Run("restored window which has expired is not resumed", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		c, ch := runCoalescingTests(t, clock, OptionsCoalescing{
+			InitialDelay: ptr.Of(time.Second),
+			MaxDelay:     ptr.Of(time.Second * 5),
+			LoadState: func() (*CoalescingState, error) {
+				return &CoalescingState{
+					CurrentDelay: time.Second * 5,
+					LastFired:    clock.Now().Add(-time.Minute),
+				}, nil
+			},
+		})
+
+		// The first event is fired immediately
+		c.Add()
+		assertChannel(t, ch)
+		assert.Equal(t, time.Second, c.State().CurrentDelay)
+	})
+
+	t.Run("restored pending events are fired when the window expires", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		_, ch := runCoalescingTests(t, clock, OptionsCoalescing{
+			InitialDelay: ptr.Of(time.Second),
+			MaxDelay:     ptr.Of(time.Second * 5),
+			LoadState: func() (*CoalescingState, error) {
+				return &CoalescingState{
+					CurrentDelay:  time.Second * 2,
+					PendingEvents: 2,
+					LastFired:     clock.Now().Add(-time.Second),
+				}, nil
+			},
+		})
+
+		assertNoChannel(t, ch)
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Second)
+		assertChannel(t, ch)
+		assertNoChannel(t, ch)
+	})
+
+	t.Run("restored pending events are fired immediately if the window has expired", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		c, ch := runCoalescingTests(t, clock, OptionsCoalescing{
+			InitialDelay: ptr.Of(time.Second),
+			MaxDelay:     ptr.Of(time.Second * 5),
+			LoadState: func() (*CoalescingState, error) {
+				return &CoalescingState{
+					CurrentDelay:  time.Second * 5,
+					PendingEvents: 2,
+					LastFired:     clock.Now().Add(-time.Minute),
+				}, nil
+			},
+		})
+
+		assertChannel(t, ch)
+		assert.Equal(t, CoalescingState{
+			CurrentDelay: time.Second,
+			LastFired:    clock.Now(),
+		}, c.State())
+
+		// A new window has started
+		c.Add()
+		assertNoChannel(t, ch)
+	})
+
+	t.Run("error loading the state", func(t *testing.T) {
+		_, err := NewCoalescing(OptionsCoalescing{
+			LoadState: func() (*CoalescingState, error) {
+				return nil, errors.New("failed")
+			},
+		})
+		require.ErrorContains(t, err, "failed to load rate limiter state: failed")
+	})
 }
@@ -27,3 +27,28 @@ type RateLimiter interface {
 	// Close closes the rate limiter and waits for all resources to be released.
 	Close()
 }
+
+// RunWithCallback runs the rate limiter, invoking fn each time an event is
+// fired instead of sending it to a channel. fn is invoked sequentially, so
+// events fired while fn is running are delivered once it returns.
+// This method blocks until the context is canceled or the rate limiter is
+// closed.
+func RunWithCallback(ctx context.Context, rl RateLimiter, fn func(ctx context.Context)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eventCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- rl.Run(ctx, eventCh)
+	}()
+
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case <-eventCh:
+			fn(ctx)
+		}
+	}
+}
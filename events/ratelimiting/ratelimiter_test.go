@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiting
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRunWithCallback(t *testing.T) {
+	t.Run("callback is invoked when events fire", func(t *testing.T) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		c, err := NewCoalescing(OptionsCoalescing{Clock: clock})
+		require.NoError(t, err)
+
+		var calls atomic.Int32
+		errCh := make(chan error)
+		go func() {
+			errCh <- RunWithCallback(context.Background(), c, func(context.Context) {
+				calls.Add(1)
+			})
+		}()
+
+		c.Add()
+		assert.Eventually(t, func() bool {
+			return calls.Load() == 1
+		}, time.Second, time.Millisecond*10)
+
+		c.Add()
+		c.Add()
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond*10)
+		assert.Eventually(t, func() bool {
+			return c.(*coalescing).Stats().PendingEvents == 2
+		}, time.Second, time.Millisecond*10)
+		clock.Step(c.(*coalescing).Stats().CurrentDelay)
+		assert.Eventually(t, func() bool {
+			return calls.Load() == 2
+		}, time.Second, time.Millisecond*10)
+
+		c.Close()
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout")
+		}
+	})
+
+	t.Run("canceling the context returns", func(t *testing.T) {
+		c, err := NewCoalescing(OptionsCoalescing{})
+		require.NoError(t, err)
+		t.Cleanup(c.Close)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() {
+			errCh <- RunWithCallback(ctx, c, func(context.Context) {
+				// Block until the context is canceled, with an event still pending
+				<-ctx.Done()
+			})
+		}()
+
+		c.Add()
+		c.Add()
+		cancel()
+
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout")
+		}
+	})
+}
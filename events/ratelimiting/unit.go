@@ -25,7 +25,7 @@ type RateLimiterWithTicker interface {
 	WithTicker(c clock.WithTicker)
 }
 
-func (c *coalescing) WithTicker(clock clock.WithTicker) {
+func (c *coalescer[T]) WithTicker(clock clock.WithTicker) {
 	c.clock = clock
 }
 
@@ -17,8 +17,14 @@ package fswatcher
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -35,16 +41,44 @@ type Options struct {
 	// Interval is the interval to wait before sending a notification after a file has changed.
 	// Default to 500ms.
 	Interval *time.Duration
+
+	// HashContents, if true, computes a hash of the contents of files when they
+	// change, and only sends a notification if the contents actually changed.
+	// Events which don't change the contents of a file, such as touch or chmod,
+	// are suppressed.
+	HashContents bool
+
+	// InitialEvent, if true, sends a notification for each target when the
+	// watcher starts running. This can be used to load the initial state of
+	// the targets without racing with changes.
+	InitialEvent bool
+}
+
+// Event is a notification of a change to a file.
+type Event struct {
+	// Path is the path of the file that changed. For targets that are
+	// directories, this is the path of the file inside the directory.
+	Path string
 }
 
 // FSWatcher watches for changes to a directory on the filesystem and sends a notification to eventCh every time a file in the folder is changed.
 // Although it's possible to watch for individual files, that's not recommended; watch for the file's parent folder instead.
 // That is because, like in Kubernetes which uses system links on mounted volumes, the file may be deleted and recreated with a different inode.
 // Note that changes are batched for 0.5 seconds before notifications are sent as events on a single file often come in batches.
+// Targets can be added and removed while the watcher is running.
 type FSWatcher struct {
 	w       *fsnotify.Watcher
 	running atomic.Bool
-	batcher *batcher.Batcher[string, struct{}]
+	batcher *batcher.Batcher[string, string]
+
+	initialEvent bool
+
+	// lock protects targets and hashes.
+	lock    sync.Mutex
+	targets []string
+	// hashes contains the hashes of the contents of the watched files, if
+	// HashContents is enabled.
+	hashes map[string][sha256.Size]byte
 }
 
 func New(opts Options) (*FSWatcher, error) {
@@ -67,21 +101,131 @@ func New(opts Options) (*FSWatcher, error) {
 		return nil, errors.New("interval must be positive")
 	}
 
-	return &FSWatcher{
+	f := &FSWatcher{
 		w: w,
 		// Often the case, writes to files are not atomic and involve multiple file system events.
 		// We want to hold off on sending events until we are sure that the file has been written to completion. We do this by waiting for a period of time after the last event has been received for a file name.
-		batcher: batcher.New[string, struct{}](interval),
-	}, nil
+		batcher:      batcher.New[string, string](interval),
+		targets:      slices.Clone(opts.Targets),
+		initialEvent: opts.InitialEvent,
+	}
+
+	if opts.HashContents {
+		f.hashes = make(map[string][sha256.Size]byte)
+		for _, target := range f.targets {
+			if err = f.hashTarget(target); err != nil {
+				return nil, errors.Join(err, w.Close())
+			}
+		}
+	}
+
+	return f, nil
 }
 
+// AddPath adds a target to watch. It can be invoked while the watcher is
+// running. Adding a target which is already watched is a nop.
+func (f *FSWatcher) AddPath(path string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if slices.Contains(f.targets, path) {
+		return nil
+	}
+
+	if err := f.w.Add(path); err != nil {
+		return fmt.Errorf("failed to add target %s: %w", path, err)
+	}
+	if f.hashes != nil {
+		if err := f.hashTarget(path); err != nil {
+			return errors.Join(err, f.w.Remove(path))
+		}
+	}
+
+	f.targets = append(f.targets, path)
+	return nil
+}
+
+// RemovePath stops watching a target. It can be invoked while the watcher is
+// running. Events for the target which are already pending may still be sent.
+func (f *FSWatcher) RemovePath(path string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	i := slices.Index(f.targets, path)
+	if i < 0 {
+		return fmt.Errorf("target %s is not watched", path)
+	}
+
+	if err := f.w.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove target %s: %w", path, err)
+	}
+
+	f.targets = slices.Delete(f.targets, i, i+1)
+	for name := range f.hashes {
+		if name == path || filepath.Dir(name) == path {
+			delete(f.hashes, name)
+		}
+	}
+	return nil
+}
+
+// Targets returns the targets which are currently watched.
+func (f *FSWatcher) Targets() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return slices.Clone(f.targets)
+}
+
+// Run runs the watcher, sending a notification to eventCh when a file
+// changes. It returns when ctx is canceled.
 func (f *FSWatcher) Run(ctx context.Context, eventCh chan<- struct{}) error {
+	return f.run(ctx, func(string) bool {
+		select {
+		case eventCh <- struct{}{}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// RunWithEvents runs the watcher like Run, but the notifications sent to
+// eventCh include the path of the file that changed.
+func (f *FSWatcher) RunWithEvents(ctx context.Context, eventCh chan<- Event) error {
+	return f.run(ctx, func(path string) bool {
+		select {
+		case eventCh <- Event{Path: path}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// run is the loop of the watcher. send is invoked with the path of each file
+// that changed, and returns false if the context is canceled.
+// If HashContents is enabled, files are hashed once their batch of events
+// fires rather than on each event, so that non-atomic writes are observed
+// only once complete.
+func (f *FSWatcher) run(ctx context.Context, send func(path string) bool) error {
 	if !f.running.CompareAndSwap(false, true) {
 		return errors.New("watcher already running")
 	}
 	defer f.batcher.Close()
 
-	f.batcher.Subscribe(ctx, eventCh)
+	batchCh := make(chan string)
+	f.batcher.Subscribe(ctx, batchCh)
+
+	// The initial events are sent regardless of the contents.
+	var forced map[string]struct{}
+	if f.initialEvent {
+		targets := f.Targets()
+		forced = make(map[string]struct{}, len(targets))
+		for _, target := range targets {
+			forced[target] = struct{}{}
+			f.batcher.Batch(target, target)
+		}
+	}
 
 	for {
 		select {
@@ -90,7 +234,88 @@ func (f *FSWatcher) Run(ctx context.Context, eventCh chan<- struct{}) error {
 		case err := <-f.w.Errors:
 			return errors.Join(fmt.Errorf("watcher error: %w", err), f.w.Close())
 		case event := <-f.w.Events:
-			f.batcher.Batch(event.Name, struct{}{})
+			f.batcher.Batch(event.Name, event.Name)
+		case path := <-batchCh:
+			_, force := forced[path]
+			delete(forced, path)
+			if !force && !f.contentsChanged(path) {
+				continue
+			}
+
+			if !send(path) {
+				return f.w.Close()
+			}
 		}
 	}
 }
+
+// hashTarget records the hashes of the target file, or of the files inside
+// the target directory.
+// This must be invoked while the caller has a lock.
+func (f *FSWatcher) hashTarget(target string) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("failed to stat target %s: %w", target, err)
+	}
+
+	if !info.IsDir() {
+		f.updateHash(target)
+		return nil
+	}
+
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		return fmt.Errorf("failed to read target directory %s: %w", target, err)
+	}
+	for _, entry := range entries {
+		f.updateHash(filepath.Join(target, entry.Name()))
+	}
+
+	return nil
+}
+
+// contentsChanged updates the recorded hash of the given file, returning true
+// if its contents changed. Files which can't be hashed, for example because
+// they have been removed or are directories, are always considered changed.
+// If HashContents is disabled, it always returns true.
+func (f *FSWatcher) contentsChanged(name string) bool {
+	if f.hashes == nil {
+		return true
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.updateHash(name)
+}
+
+// updateHash is like contentsChanged, but must be invoked while the caller has
+// a lock.
+func (f *FSWatcher) updateHash(name string) bool {
+	sum, err := hashFile(name)
+	if err != nil {
+		delete(f.hashes, name)
+		return true
+	}
+
+	prev, ok := f.hashes[name]
+	f.hashes[name] = sum
+	return !ok || prev != sum
+}
+
+func hashFile(name string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	file, err := os.Open(name)
+	if err != nil {
+		return sum, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return sum, err
+	}
+
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
@@ -32,7 +32,7 @@ import (
 )
 
 func TestFSWatcher(t *testing.T) {
-	runWatcher := func(t *testing.T, opts Options, bacher *batcher.Batcher[string, struct{}]) <-chan struct{} {
+	runWatcher := func(t *testing.T, opts Options, bacher *batcher.Batcher[string, string]) <-chan struct{} {
 		t.Helper()
 
 		f, err := New(opts)
@@ -191,7 +191,7 @@ func TestFSWatcher(t *testing.T) {
 
 	t.Run("should batch events of the same file for multiple events", func(t *testing.T) {
 		clock := clocktesting.NewFakeClock(time.Time{})
-		batcher := batcher.New[string, struct{}](time.Millisecond * 500)
+		batcher := batcher.New[string, string](time.Millisecond * 500)
 		batcher.WithClock(clock)
 		dir1 := t.TempDir()
 		dir2 := t.TempDir()
@@ -243,4 +243,185 @@ func TestFSWatcher(t *testing.T) {
 			clock.Step(1)
 		}
 	})
+
+	t.Run("should not fire event when contents do not change with HashContents", func(t *testing.T) {
+		dir := t.TempDir()
+		fp := filepath.Join(dir, "test.txt")
+		require.NoError(t, os.WriteFile(fp, []byte("hello"), 0o644))
+		eventsCh := runWatcher(t, Options{
+			Targets:      []string{dir},
+			Interval:     ptr.Of(time.Millisecond * 50),
+			HashContents: true,
+		}, nil)
+
+		if runtime.GOOS == "windows" {
+			// If running in windows, wait for notify to be ready.
+			time.Sleep(time.Second)
+		}
+
+		require.NoError(t, os.WriteFile(fp, []byte("hello"), 0o644))
+		require.NoError(t, os.Chmod(fp, 0o600))
+		select {
+		case <-eventsCh:
+			assert.Fail(t, "unexpected event")
+		case <-time.After(time.Millisecond * 300):
+		}
+
+		require.NoError(t, os.WriteFile(fp, []byte("world"), 0o644))
+		select {
+		case <-eventsCh:
+		case <-time.After(time.Second):
+			assert.Fail(t, "timeout waiting for event")
+		}
+	})
+
+	t.Run("should fire initial event with InitialEvent", func(t *testing.T) {
+		eventsCh := runWatcher(t, Options{
+			Targets:      []string{t.TempDir()},
+			Interval:     ptr.Of(time.Duration(1)),
+			InitialEvent: true,
+		}, nil)
+
+		select {
+		case <-eventsCh:
+		case <-time.After(time.Second):
+			assert.Fail(t, "timeout waiting for initial event")
+		}
+	})
+}
+
+func TestFSWatcherDynamicTargets(t *testing.T) {
+	runWatcher := func(t *testing.T, f *FSWatcher) <-chan Event {
+		t.Helper()
+
+		errCh := make(chan error)
+		ctx, cancel := context.WithCancel(context.Background())
+		eventsCh := make(chan Event)
+		go func() {
+			errCh <- f.RunWithEvents(ctx, eventsCh)
+		}()
+
+		t.Cleanup(func() {
+			cancel()
+			select {
+			case err := <-errCh:
+				require.NoError(t, err)
+			case <-time.After(time.Second):
+				assert.Fail(t, "timeout waiting for watcher to stop")
+			}
+		})
+
+		assert.Eventually(t, f.running.Load, time.Second, time.Millisecond*10)
+		if runtime.GOOS == "windows" {
+			// If running in windows, wait for notify to be ready.
+			time.Sleep(time.Second)
+		}
+		return eventsCh
+	}
+
+	assertEvent := func(t *testing.T, eventsCh <-chan Event, path string) {
+		t.Helper()
+		select {
+		case ev := <-eventsCh:
+			assert.Equal(t, path, ev.Path)
+		case <-time.After(time.Second):
+			assert.Fail(t, "timeout waiting for event")
+		}
+	}
+
+	assertNoEvent := func(t *testing.T, eventsCh <-chan Event) {
+		t.Helper()
+		select {
+		case ev := <-eventsCh:
+			assert.Fail(t, "unexpected event", "path: %s", ev.Path)
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+
+	// assertNoEventFor asserts that there is no event for the path; events for
+	// other paths, such as late events of previous writes, are ignored.
+	assertNoEventFor := func(t *testing.T, eventsCh <-chan Event, path string) {
+		t.Helper()
+		timeoutCh := time.After(time.Millisecond * 100)
+		for {
+			select {
+			case ev := <-eventsCh:
+				assert.NotEqual(t, path, ev.Path, "unexpected event")
+			case <-timeoutCh:
+				return
+			}
+		}
+	}
+
+	t.Run("events include the path of the file", func(t *testing.T) {
+		dir := t.TempDir()
+		fp := filepath.Join(dir, "test.txt")
+		f, err := New(Options{
+			Targets:  []string{dir},
+			Interval: ptr.Of(time.Duration(1)),
+		})
+		require.NoError(t, err)
+		eventsCh := runWatcher(t, f)
+
+		require.NoError(t, os.WriteFile(fp, []byte{}, 0o644))
+		assertEvent(t, eventsCh, fp)
+	})
+
+	t.Run("targets can be added and removed while running", func(t *testing.T) {
+		dir1, dir2 := t.TempDir(), t.TempDir()
+		fp1, fp2 := filepath.Join(dir1, "test1.txt"), filepath.Join(dir2, "test2.txt")
+		f, err := New(Options{
+			Targets:  []string{dir1},
+			Interval: ptr.Of(time.Duration(1)),
+		})
+		require.NoError(t, err)
+		eventsCh := runWatcher(t, f)
+
+		// Not watched yet
+		require.NoError(t, os.WriteFile(fp2, []byte{}, 0o644))
+		assertNoEvent(t, eventsCh)
+
+		require.NoError(t, f.AddPath(dir2))
+		require.NoError(t, f.AddPath(dir2))
+		assert.Equal(t, []string{dir1, dir2}, f.Targets())
+		require.NoError(t, os.WriteFile(fp2, []byte("a"), 0o644))
+		assertEvent(t, eventsCh, fp2)
+
+		require.NoError(t, f.RemovePath(dir1))
+		assert.Equal(t, []string{dir2}, f.Targets())
+		// Writing the file can cause more than one event, so other events for
+		// fp2 may still be delivered
+		require.NoError(t, os.WriteFile(fp1, []byte{}, 0o644))
+		assertNoEventFor(t, eventsCh, fp1)
+
+		require.Error(t, f.RemovePath(dir1))
+	})
+
+	t.Run("added targets are hashed with HashContents", func(t *testing.T) {
+		dir := t.TempDir()
+		fp := filepath.Join(dir, "test.txt")
+		require.NoError(t, os.WriteFile(fp, []byte("hello"), 0o644))
+		f, err := New(Options{
+			Interval:     ptr.Of(time.Millisecond * 10),
+			HashContents: true,
+		})
+		require.NoError(t, err)
+		eventsCh := runWatcher(t, f)
+
+		require.NoError(t, f.AddPath(dir))
+		require.NoError(t, os.WriteFile(fp, []byte("hello"), 0o644))
+		assertNoEvent(t, eventsCh)
+
+		require.NoError(t, os.WriteFile(fp, []byte("world"), 0o644))
+		assertEvent(t, eventsCh, fp)
+	})
+
+	t.Run("adding a non-existent target should error", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.RemoveAll(dir))
+		f, err := New(Options{})
+		require.NoError(t, err)
+		require.Error(t, f.AddPath(dir))
+		assert.Empty(t, f.Targets())
+	})
 }
@@ -17,12 +17,13 @@ limitations under the License.
 // - A path on the local disk. This is watched with fsnotify to automatically reload the JWKS when the file changes on disk.
 // - A HTTP(S) URL. This is automatically refreshed if a caller requests a key that isn't in the cached set.
 // - A JWKS passed during initialization, optionally base64-encoded.
+// - A custom Fetcher, such as a command or a file mounted from a Kubernetes Secret.
+//
+// Keys removed from the JWKS can be retained for a grace period, so tokens signed right before a rotation can still be validated.
 package jwkscache
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -36,10 +37,10 @@ import (
 
 	"github.com/lestrrat-go/httprc"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"k8s.io/utils/clock"
 
 	"github.com/dapr/kit/fswatcher"
 	"github.com/dapr/kit/logger"
-	"github.com/dapr/kit/utils"
 )
 
 const (
@@ -56,13 +57,36 @@ type JWKSCache struct {
 	requestTimeout     time.Duration
 	minRefreshInterval time.Duration
 	caCertificate      string
+	appendSystemRoots  bool
+	clientCertificate  string
+	clientKey          string
+	proxyURL           string
+	fetcher            Fetcher
 
 	jwks    jwk.Set
+	cache   *jwk.Cache
 	logger  logger.Logger
 	lock    sync.RWMutex
 	client  *http.Client
 	running atomic.Bool
 	initCh  chan error
+
+	refreshLock sync.Mutex
+	lastRefresh time.Time
+
+	clock          clock.Clock
+	gracePeriod    time.Duration
+	graceLock      sync.Mutex
+	knownKeys      map[string]jwk.Key
+	retiredKeys    map[string]retiredKey
+	graceKeyUses   atomic.Uint64
+	onGraceKeyUsed func(kid string)
+}
+
+// retiredKey is a key removed from the JWKS, which is retained for the grace period.
+type retiredKey struct {
+	key       jwk.Key
+	removedAt time.Time
 }
 
 // NewJWKSCache creates a new JWKSCache object.
@@ -74,10 +98,19 @@ func NewJWKSCache(location string, logger logger.Logger) *JWKSCache {
 		requestTimeout:     defaultRequestTimeout,
 		minRefreshInterval: defaultMinRefreshInterval,
 
+		clock:  clock.RealClock{},
 		initCh: make(chan error, 1),
 	}
 }
 
+// NewJWKSCacheWithFetcher creates a new JWKSCache object that retrieves the JWKS using the given Fetcher.
+// If the fetcher implements WatchingFetcher, the JWKS is fetched again every time a change is signaled.
+func NewJWKSCacheWithFetcher(fetcher Fetcher, logger logger.Logger) *JWKSCache {
+	c := NewJWKSCache("", logger)
+	c.fetcher = fetcher
+	return c
+}
+
 // Start the JWKS cache.
 // This method blocks until the context is canceled.
 func (c *JWKSCache) Start(ctx context.Context) error {
@@ -121,11 +154,47 @@ func (c *JWKSCache) SetCACertificate(caCertificate string) {
 	c.caCertificate = caCertificate
 }
 
+// SetAppendSystemRoots sets whether the system's root CAs are trusted in addition to the CA certificate set with SetCACertificate.
+func (c *JWKSCache) SetAppendSystemRoots(appendSystemRoots bool) {
+	c.appendSystemRoots = appendSystemRoots
+}
+
+// SetClientCertificate sets the certificate and private key to use for TLS client authentication.
+// Each can be a path to a local file or an actual, PEM-encoded value.
+func (c *JWKSCache) SetClientCertificate(certificate string, key string) {
+	c.clientCertificate = certificate
+	c.clientKey = key
+}
+
+// SetProxyURL sets the URL of the proxy to use for network requests.
+// By default, the proxy is configured from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func (c *JWKSCache) SetProxyURL(proxyURL string) {
+	c.proxyURL = proxyURL
+}
+
 // SetHTTPClient sets the HTTP client object to use.
+// If set, the options for the CA certificate, client certificate, and proxy are ignored.
 func (c *JWKSCache) SetHTTPClient(client *http.Client) {
 	c.client = client
 }
 
+// SetGracePeriod sets the period for which keys removed from the JWKS are retained, so tokens signed with them can still be validated by ValidateToken.
+// Keys are retained only if they have a key ID. Default is 0, which disables retaining keys.
+// For JWKS fetched from a URL, removed keys are detected when the JWKS is refreshed or a token is validated, so the grace period may start later than the key's removal.
+func (c *JWKSCache) SetGracePeriod(gracePeriod time.Duration) {
+	c.gracePeriod = gracePeriod
+}
+
+// SetGraceKeyUsedHandler sets a function which is invoked with the key ID every time a token is validated with a key retained for the grace period, for example to emit metrics.
+func (c *JWKSCache) SetGraceKeyUsedHandler(fn func(kid string)) {
+	c.onGraceKeyUsed = fn
+}
+
+// GraceKeyUses returns the number of tokens validated with keys retained for the grace period.
+func (c *JWKSCache) GraceKeyUses() uint64 {
+	return c.graceKeyUses.Load()
+}
+
 // KeySet returns the jwk.Set with the current keys.
 func (c *JWKSCache) KeySet() jwk.Set {
 	c.lock.RLock()
@@ -147,6 +216,10 @@ func (c *JWKSCache) WaitForCacheReady(ctx context.Context) error {
 
 // Init the cache from the given location.
 func (c *JWKSCache) initCache(ctx context.Context) error {
+	if c.fetcher != nil {
+		return c.initJWKSFromFetcher(ctx, c.fetcher)
+	}
+
 	if len(c.location) == 0 {
 		return errors.New("property 'location' must not be empty")
 	}
@@ -192,36 +265,30 @@ func (c *JWKSCache) initJWKSFromURL(ctx context.Context, url string) error {
 
 	// We also need to create a custom HTTP client (if we don't have one already) because otherwise there's no timeout.
 	if c.client == nil {
-		tlsConfig := &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		}
-
-		// Load CA certificates if we have one
-		if c.caCertificate != "" {
-			caCert, err := utils.GetPEM(c.caCertificate)
-			if err != nil {
-				return fmt.Errorf("failed to load CA certificate: %w", err)
-			}
-
-			caCertPool := x509.NewCertPool()
-			if !caCertPool.AppendCertsFromPEM(caCert) {
-				return errors.New("failed to add root certificate to certificate pool")
-			}
-			tlsConfig.RootCAs = caCertPool
-		}
-
-		c.client = &http.Client{
-			Timeout: c.requestTimeout,
-			Transport: &http.Transport{
-				TLSClientConfig: tlsConfig,
-			},
+		client, err := NewHTTPClient(HTTPClientOptions{
+			Timeout:           c.requestTimeout,
+			CACertificate:     c.caCertificate,
+			AppendSystemRoots: c.appendSystemRoots,
+			ClientCertificate: c.clientCertificate,
+			ClientKey:         c.clientKey,
+			ProxyURL:          c.proxyURL,
+		})
+		if err != nil {
+			return err
 		}
+		c.client = client
 	}
 
 	// Register the cache
+	// Keys are tracked every time the JWKS is fetched, including when it's
+	// refreshed in background
 	err := cache.Register(url,
 		jwk.WithMinRefreshInterval(c.minRefreshInterval),
 		jwk.WithHTTPClient(c.client),
+		jwk.WithPostFetcher(jwk.PostFetchFunc(func(_ string, set jwk.Set) (jwk.Set, error) {
+			c.trackKeys(set)
+			return set, nil
+		})),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to register JWKS cache: %w", err)
@@ -235,6 +302,7 @@ func (c *JWKSCache) initJWKSFromURL(ctx context.Context, url string) error {
 		return fmt.Errorf("failed to fetch JWKS: %w", err)
 	}
 
+	c.cache = cache
 	c.jwks = jwk.NewCachedSet(cache, url)
 	return nil
 }
@@ -301,6 +369,64 @@ func (c *JWKSCache) initJWKSFromFile(ctx context.Context, file string) error {
 	}
 }
 
+func (c *JWKSCache) initJWKSFromFetcher(ctx context.Context, fetcher Fetcher) error {
+	err := c.fetchJWKS(ctx, fetcher)
+	if err != nil {
+		return err
+	}
+
+	watcher, ok := fetcher.(WatchingFetcher)
+	if !ok {
+		return nil
+	}
+
+	eventCh := make(chan struct{})
+	go func() {
+		// Log errors only
+		if err := watcher.Watch(ctx, eventCh); err != nil {
+			c.logger.Errorf("Error while watching for changes to the JWKS: %v", err)
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case <-eventCh:
+				c.logger.Debug("Reloading JWKS")
+				if err := c.fetchJWKS(ctx, fetcher); err != nil {
+					// Log errors only
+					c.logger.Errorf("Error reloading JWKS: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Used by initJWKSFromFetcher to fetch and parse the JWKS every time it's changed
+func (c *JWKSCache) fetchJWKS(ctx context.Context, fetcher Fetcher) error {
+	fetchCtx, fetchCancel := context.WithTimeout(ctx, c.requestTimeout)
+	read, err := fetcher.Fetch(fetchCtx)
+	fetchCancel()
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	jwks, err := jwk.Parse(read)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	c.lock.Lock()
+	c.jwks = jwks
+	c.lock.Unlock()
+	c.trackKeys(jwks)
+
+	return nil
+}
+
 // Used by initJWKSFromFile to parse a JWKS file every time it's changed
 func (c *JWKSCache) parseJWKSFile(file string) error {
 	read, err := os.ReadFile(file)
@@ -316,6 +442,60 @@ func (c *JWKSCache) parseJWKSFile(file string) error {
 	c.lock.Lock()
 	c.jwks = jwks
 	c.lock.Unlock()
+	c.trackKeys(jwks)
 
 	return nil
 }
+
+// Updates the keys retained for the grace period with the keys removed from the JWKS since the last time it was tracked.
+func (c *JWKSCache) trackKeys(set jwk.Set) {
+	if c.gracePeriod <= 0 || set == nil {
+		return
+	}
+
+	c.graceLock.Lock()
+	defer c.graceLock.Unlock()
+
+	now := c.clock.Now()
+	current := make(map[string]jwk.Key, set.Len())
+	for i := range set.Len() {
+		key, ok := set.Key(i)
+		if ok && key.KeyID() != "" {
+			current[key.KeyID()] = key
+		}
+	}
+
+	if c.retiredKeys == nil {
+		c.retiredKeys = make(map[string]retiredKey)
+	}
+	for kid, key := range c.knownKeys {
+		if _, ok := current[kid]; !ok {
+			c.logger.Debugf("Key '%s' removed from the JWKS: retaining it for %v", kid, c.gracePeriod)
+			c.retiredKeys[kid] = retiredKey{key: key, removedAt: now}
+		}
+	}
+	for kid, retired := range c.retiredKeys {
+		_, ok := current[kid]
+		if ok || now.Sub(retired.removedAt) >= c.gracePeriod {
+			delete(c.retiredKeys, kid)
+		}
+	}
+
+	c.knownKeys = current
+}
+
+// Returns the key with the given ID if it was removed from the JWKS within the grace period.
+func (c *JWKSCache) retiredKey(kid string) (jwk.Key, bool) {
+	if c.gracePeriod <= 0 {
+		return nil, false
+	}
+
+	c.graceLock.Lock()
+	defer c.graceLock.Unlock()
+
+	retired, ok := c.retiredKeys[kid]
+	if !ok || c.clock.Since(retired.removedAt) >= c.gracePeriod {
+		return nil, false
+	}
+	return retired.key, true
+}
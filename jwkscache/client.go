@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwkscache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dapr/kit/utils"
+)
+
+// HTTPClientOptions contains the options for the HTTP client used to fetch a JWKS from a URL.
+type HTTPClientOptions struct {
+	// Timeout for requests. If 0, requests have no timeout.
+	Timeout time.Duration
+
+	// CACertificate contains the CA certificates to trust, as a PEM-encoded bundle or a path to a local file.
+	// If empty, the system's root CAs are trusted.
+	CACertificate string
+
+	// AppendSystemRoots makes the client trust the system's root CAs in addition to the ones in CACertificate.
+	AppendSystemRoots bool
+
+	// ClientCertificate and ClientKey contain the certificate and private key used for TLS client authentication,
+	// as PEM-encoded values or paths to local files. Both must be set, or neither.
+	ClientCertificate string
+	ClientKey         string
+
+	// ProxyURL is the URL of the proxy used for requests.
+	// If empty, the proxy is configured from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+}
+
+// NewHTTPClient returns a HTTP client for fetching a JWKS from a URL, which requires TLS 1.2 or higher.
+// The client can be passed to JWKSCache.SetHTTPClient or NewURLFetcher.
+func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// Load CA certificates if we have them
+	if opts.CACertificate != "" {
+		caCert, err := utils.GetPEM(opts.CACertificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if opts.AppendSystemRoots {
+			caCertPool, err = x509.SystemCertPool()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load system root certificates: %w", err)
+			}
+		}
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to add root certificate to certificate pool")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load the client certificate if we have one
+	if opts.ClientCertificate != "" || opts.ClientKey != "" {
+		if opts.ClientCertificate == "" || opts.ClientKey == "" {
+			return nil, errors.New("client certificate and client key must be set together")
+		}
+		certPEM, err := utils.GetPEM(opts.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		keyPEM, err := utils.GetPEM(opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client key: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: %s", opts.ProxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
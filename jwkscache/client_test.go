@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwkscache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	clientCert, clientKey := newTestClientCertificate(t)
+
+	// Server which requires a client certificate
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "jwks-client" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, testJWKS1)
+	}))
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAnyClientCert,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	t.Run("CA and client certificate", func(t *testing.T) {
+		client, err := NewHTTPClient(HTTPClientOptions{
+			Timeout:           5 * time.Second,
+			CACertificate:     caCert,
+			ClientCertificate: clientCert,
+			ClientKey:         clientKey,
+		})
+		require.NoError(t, err)
+
+		res, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("CA and client certificate from files", func(t *testing.T) {
+		dir := t.TempDir()
+		caPath := filepath.Join(dir, "ca.pem")
+		certPath := filepath.Join(dir, "cert.pem")
+		keyPath := filepath.Join(dir, "key.pem")
+		require.NoError(t, os.WriteFile(caPath, []byte(caCert), 0o600))
+		require.NoError(t, os.WriteFile(certPath, []byte(clientCert), 0o600))
+		require.NoError(t, os.WriteFile(keyPath, []byte(clientKey), 0o600))
+
+		client, err := NewHTTPClient(HTTPClientOptions{
+			CACertificate:     caPath,
+			AppendSystemRoots: true,
+			ClientCertificate: certPath,
+			ClientKey:         keyPath,
+		})
+		require.NoError(t, err)
+
+		res, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("server not trusted", func(t *testing.T) {
+		client, err := NewHTTPClient(HTTPClientOptions{
+			ClientCertificate: clientCert,
+			ClientKey:         clientKey,
+		})
+		require.NoError(t, err)
+
+		//nolint:bodyclose
+		_, err = client.Get(srv.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("requires TLS 1.2", func(t *testing.T) {
+		client, err := NewHTTPClient(HTTPClientOptions{})
+		require.NoError(t, err)
+
+		transport := client.Transport.(*http.Transport)
+		assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Requests sent to a proxy have the absolute URL
+			proxied = r.URL.String()
+			io.WriteString(w, testJWKS1)
+		}))
+		t.Cleanup(proxy.Close)
+
+		client, err := NewHTTPClient(HTTPClientOptions{
+			ProxyURL: proxy.URL,
+		})
+		require.NoError(t, err)
+
+		res, err := client.Get("http://jwks.example.com/jwks.json")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "http://jwks.example.com/jwks.json", proxied)
+	})
+
+	t.Run("proxy from environment", func(t *testing.T) {
+		client, err := NewHTTPClient(HTTPClientOptions{})
+		require.NoError(t, err)
+		assert.NotNil(t, client.Transport.(*http.Transport).Proxy)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		tests := map[string]HTTPClientOptions{
+			"invalid CA certificate":    {CACertificate: "not a certificate"},
+			"client key missing":        {ClientCertificate: clientCert},
+			"client certificate absent": {ClientKey: clientKey},
+			"mismatched client key":     {ClientCertificate: clientCert, ClientKey: caCert},
+			"invalid proxy URL":         {ProxyURL: "not a URL"},
+		}
+		for name, opts := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := NewHTTPClient(opts)
+				require.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("JWKS cache", func(t *testing.T) {
+		cache := NewJWKSCache(srv.URL, logger.NewLogger("test"))
+		cache.SetCACertificate(caCert)
+		cache.SetClientCertificate(clientCert, clientKey)
+		err := cache.initCache(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, cache.KeySet().Len())
+	})
+}
+
+// Returns a PEM-encoded self-signed certificate and private key for TLS client authentication.
+func newTestClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jwks-client"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwkscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/dapr/kit/fswatcher"
+)
+
+// Fetcher retrieves the raw JWKS from a custom source.
+// The returned bytes must be a JSON-encoded JWKS.
+type Fetcher interface {
+	Fetch(ctx context.Context) ([]byte, error)
+}
+
+// WatchingFetcher is a Fetcher that can also notify when the JWKS has changed
+// and needs to be fetched again.
+type WatchingFetcher interface {
+	Fetcher
+
+	// Watch sends a message on eventCh every time the JWKS has changed.
+	// This method blocks until the context is canceled.
+	Watch(ctx context.Context, eventCh chan<- struct{}) error
+}
+
+// FetcherFunc is a function that implements the Fetcher interface.
+type FetcherFunc func(ctx context.Context) ([]byte, error)
+
+// Fetch implements the Fetcher interface.
+func (f FetcherFunc) Fetch(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// FileFetcher is a WatchingFetcher that reads the JWKS from a file on disk.
+// It watches the folder containing the file, so it supports files that are
+// updated with atomic renames, such as Kubernetes Secrets mounted as volumes.
+type FileFetcher struct {
+	path string
+}
+
+// NewFileFetcher returns a new FileFetcher for the file at the given path.
+func NewFileFetcher(path string) *FileFetcher {
+	return &FileFetcher{path: path}
+}
+
+// Fetch implements the Fetcher interface.
+func (f *FileFetcher) Fetch(context.Context) ([]byte, error) {
+	read, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return read, nil
+}
+
+// Watch implements the WatchingFetcher interface.
+func (f *FileFetcher) Watch(ctx context.Context, eventCh chan<- struct{}) error {
+	fw, err := fswatcher.New(fswatcher.Options{
+		Targets: []string{filepath.Dir(f.path)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch JWKS file: %w", err)
+	}
+	return fw.Run(ctx, eventCh)
+}
+
+// CommandFetcher is a Fetcher that retrieves the JWKS from the standard output
+// of a command.
+type CommandFetcher struct {
+	name string
+	args []string
+}
+
+// NewCommandFetcher returns a new CommandFetcher that invokes the command with
+// the given name and arguments.
+func NewCommandFetcher(name string, args ...string) *CommandFetcher {
+	return &CommandFetcher{
+		name: name,
+		args: args,
+	}
+}
+
+// Fetch implements the Fetcher interface.
+func (f *CommandFetcher) Fetch(ctx context.Context) ([]byte, error) {
+	//nolint:gosec
+	out, err := exec.CommandContext(ctx, f.name, f.args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("failed to execute command: %w: %s", err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("failed to execute command: %w", err)
+	}
+	return out, nil
+}
+
+// URLFetcher is a Fetcher that retrieves the JWKS from a HTTP(S) URL.
+// Unlike the built-in support for URLs in JWKSCache, the JWKS is fetched only
+// when the cache is initialized.
+type URLFetcher struct {
+	url    string
+	client *http.Client
+}
+
+// NewURLFetcher returns a new URLFetcher for the given URL.
+// If client is nil, http.DefaultClient is used.
+func NewURLFetcher(url string, client *http.Client) *URLFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &URLFetcher{
+		url:    url,
+		client: client,
+	}
+}
+
+// Fetch implements the Fetcher interface.
+func (f *URLFetcher) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: invalid response status code %d", res.StatusCode)
+	}
+
+	read, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return read, nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwkscache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestJWKSCacheWithFetcher(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	t.Run("init with fetcher func", func(t *testing.T) {
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(context.Context) ([]byte, error) {
+			return []byte(testJWKS1), nil
+		}), log)
+		err := cache.initCache(context.Background())
+		require.NoError(t, err)
+
+		set := cache.KeySet()
+		require.Equal(t, 1, set.Len())
+
+		key, ok := set.LookupKeyID("mykey")
+		require.True(t, ok)
+		require.NotNil(t, key)
+	})
+
+	t.Run("fetcher returns an error", func(t *testing.T) {
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(context.Context) ([]byte, error) {
+			return nil, errors.New("simulated")
+		}), log)
+		err := cache.initCache(context.Background())
+		require.ErrorContains(t, err, "failed to fetch JWKS: simulated")
+	})
+
+	t.Run("fetcher returns an invalid JWKS", func(t *testing.T) {
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(context.Context) ([]byte, error) {
+			return []byte("not a JWKS"), nil
+		}), log)
+		err := cache.initCache(context.Background())
+		require.ErrorContains(t, err, "failed to parse JWKS")
+	})
+
+	t.Run("init with file fetcher", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "jwks.json")
+		err := os.WriteFile(path, []byte(testJWKS1), 0o666)
+		require.NoError(t, err)
+
+		cache := NewJWKSCacheWithFetcher(NewFileFetcher(path), log)
+		err = cache.initCache(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, cache.KeySet().Len())
+
+		// Sleep 1s before writing the file
+		time.Sleep(time.Second)
+
+		// Update the file and verify it's picked up
+		err = os.WriteFile(path, []byte(testJWKS2), 0o666)
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			return cache.KeySet().Len() == 2
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
+
+func TestCommandFetcher(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo command not available")
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := NewCommandFetcher("echo", testJWKS1)
+		read, err := f.Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, testJWKS1, strings.TrimSpace(string(read)))
+	})
+
+	t.Run("command not found", func(t *testing.T) {
+		f := NewCommandFetcher("dapr-kit-command-not-found")
+		_, err := f.Fetch(context.Background())
+		require.ErrorContains(t, err, "failed to execute command")
+	})
+}
+
+func TestURLFetcher(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripFn(func(r *http.Request) *http.Response {
+			if r.URL.Path != "/jwks.json" {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       http.NoBody,
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(testJWKS1)),
+			}
+		}),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		read, err := NewURLFetcher("https://localhost/jwks.json", client).Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, testJWKS1, string(read))
+	})
+
+	t.Run("invalid status code", func(t *testing.T) {
+		_, err := NewURLFetcher("https://localhost/notfound", client).Fetch(context.Background())
+		require.ErrorContains(t, err, "invalid response status code 404")
+	})
+}
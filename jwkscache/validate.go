@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwkscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Default clock skew tolerated when validating the time-based claims of a token.
+const defaultAcceptableSkew = time.Minute
+
+// ValidateToken parses the token, verifies its signature with the cached key set, and validates its claims.
+// By default, a clock skew of 1 minute is tolerated; this and other validation settings (such as the expected audience or issuer) can be changed with opts.
+// If the token is signed with a key ID that isn't in the cached set, the JWKS is refreshed before validating the token; refreshes are performed at most once per minimum refresh interval.
+func (c *JWKSCache) ValidateToken(ctx context.Context, token string, opts ...jwt.ValidateOption) (jwt.Token, error) {
+	kid, err := tokenKeyID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	set := c.KeySet()
+	if set == nil {
+		return nil, errors.New("cache is not initialized")
+	}
+
+	var graceKey bool
+	if _, ok := set.LookupKeyID(kid); !ok && kid != "" {
+		if key, ok := c.retiredKey(kid); ok {
+			// The key was removed from the JWKS, but it's still within the grace period
+			set = jwk.NewSet()
+			if err = set.AddKey(key); err != nil {
+				return nil, fmt.Errorf("failed to add retired key: %w", err)
+			}
+			graceKey = true
+		} else {
+			c.logger.Debugf("Key '%s' not found in the JWKS: refreshing", kid)
+			if err = c.refresh(ctx); err != nil {
+				// Log errors only: the token is validated against the current keys
+				c.logger.Warnf("Error refreshing JWKS: %v", err)
+			}
+			set = c.KeySet()
+		}
+	}
+
+	parseOpts := make([]jwt.ParseOption, 0, len(opts)+3)
+	parseOpts = append(parseOpts,
+		jwt.WithKeySet(set, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithValidate(true),
+		jwt.WithAcceptableSkew(defaultAcceptableSkew),
+	)
+	for _, o := range opts {
+		parseOpts = append(parseOpts, o)
+	}
+
+	t, err := jwt.ParseString(token, parseOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate token: %w", err)
+	}
+
+	if graceKey {
+		c.logger.Debugf("Token validated with key '%s' retained for the grace period", kid)
+		c.graceKeyUses.Add(1)
+		if c.onGraceKeyUsed != nil {
+			c.onGraceKeyUsed(kid)
+		}
+	}
+	return t, nil
+}
+
+// Returns the key ID from the protected headers of the token's signature.
+func tokenKeyID(token string) (string, error) {
+	msg, err := jws.ParseString(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse token: %w", err)
+	}
+	sigs := msg.Signatures()
+	if len(sigs) == 0 {
+		return "", errors.New("failed to parse token: no signatures")
+	}
+	return sigs[0].ProtectedHeaders().KeyID(), nil
+}
+
+// Refreshes the JWKS from its source, if the source supports it and the last refresh was at least minRefreshInterval ago.
+// JWKS loaded from a file are reloaded automatically when the file changes, so they are not refreshed.
+func (c *JWKSCache) refresh(ctx context.Context) error {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	if !c.lastRefresh.IsZero() && c.clock.Since(c.lastRefresh) < c.minRefreshInterval {
+		return nil
+	}
+
+	switch {
+	case c.fetcher != nil:
+		c.lastRefresh = c.clock.Now()
+		return c.fetchJWKS(ctx, c.fetcher)
+	case c.cache != nil:
+		// Keys are tracked by the cache's post-fetch hook
+		c.lastRefresh = c.clock.Now()
+		refreshCtx, refreshCancel := context.WithTimeout(ctx, c.requestTimeout)
+		defer refreshCancel()
+		_, err := c.cache.Refresh(refreshCtx, c.location)
+		if err != nil {
+			return fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
+	}
+
+	return nil
+}
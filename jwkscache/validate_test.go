@@ -0,0 +1,291 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwkscache
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestValidateToken(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	newKey := func(t *testing.T, kid string) jwk.Key {
+		t.Helper()
+		pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		key, err := jwk.FromRaw(pk)
+		require.NoError(t, err)
+		require.NoError(t, key.Set(jwk.KeyIDKey, kid))
+		require.NoError(t, key.Set(jwk.AlgorithmKey, jwa.ES256))
+		return key
+	}
+	newJWKS := func(t *testing.T, keys ...jwk.Key) []byte {
+		t.Helper()
+		set := jwk.NewSet()
+		for _, k := range keys {
+			pub, err := k.PublicKey()
+			require.NoError(t, err)
+			require.NoError(t, set.AddKey(pub))
+		}
+		enc, err := json.Marshal(set)
+		require.NoError(t, err)
+		return enc
+	}
+	sign := func(t *testing.T, key jwk.Key, build func(b *jwt.Builder)) string {
+		t.Helper()
+		b := jwt.NewBuilder().
+			Issuer("dapr").
+			Audience([]string{"myapp"}).
+			IssuedAt(time.Now()).
+			Expiration(time.Now().Add(time.Hour))
+		if build != nil {
+			build(b)
+		}
+		tok, err := b.Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, key))
+		require.NoError(t, err)
+		return string(signed)
+	}
+
+	key1 := newKey(t, "key1")
+	key2 := newKey(t, "key2")
+
+	t.Run("valid token", func(t *testing.T) {
+		cache := NewJWKSCache(string(newJWKS(t, key1)), log)
+		require.NoError(t, cache.initCache(context.Background()))
+
+		tok, err := cache.ValidateToken(context.Background(), sign(t, key1, nil), jwt.WithAudience("myapp"), jwt.WithIssuer("dapr"))
+		require.NoError(t, err)
+		assert.Equal(t, "dapr", tok.Issuer())
+	})
+
+	t.Run("invalid audience", func(t *testing.T) {
+		cache := NewJWKSCache(string(newJWKS(t, key1)), log)
+		require.NoError(t, cache.initCache(context.Background()))
+
+		_, err := cache.ValidateToken(context.Background(), sign(t, key1, nil), jwt.WithAudience("otherapp"))
+		require.Error(t, err)
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		cache := NewJWKSCache(string(newJWKS(t, key1)), log)
+		require.NoError(t, cache.initCache(context.Background()))
+
+		// Expired 30s ago is within the default skew
+		token := sign(t, key1, func(b *jwt.Builder) {
+			b.Expiration(time.Now().Add(-30 * time.Second))
+		})
+		_, err := cache.ValidateToken(context.Background(), token)
+		require.NoError(t, err)
+
+		// The default skew can be overridden
+		_, err = cache.ValidateToken(context.Background(), token, jwt.WithAcceptableSkew(0))
+		require.Error(t, err)
+	})
+
+	t.Run("unknown key without refresh", func(t *testing.T) {
+		cache := NewJWKSCache(string(newJWKS(t, key1)), log)
+		require.NoError(t, cache.initCache(context.Background()))
+
+		_, err := cache.ValidateToken(context.Background(), sign(t, key2, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		cache := NewJWKSCache(string(newJWKS(t, key1)), log)
+		require.NoError(t, cache.initCache(context.Background()))
+
+		_, err := cache.ValidateToken(context.Background(), "not-a-token")
+		require.ErrorContains(t, err, "failed to parse token")
+	})
+
+	t.Run("refresh on unknown key", func(t *testing.T) {
+		var fetches atomic.Int32
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(ctx context.Context) ([]byte, error) {
+			// The second key is added after the first fetch
+			if fetches.Add(1) == 1 {
+				return newJWKS(t, key1), nil
+			}
+			return newJWKS(t, key1, key2), nil
+		}), log)
+		require.NoError(t, cache.initCache(context.Background()))
+		require.Equal(t, int32(1), fetches.Load())
+
+		// Known key does not trigger a refresh
+		_, err := cache.ValidateToken(context.Background(), sign(t, key1, nil))
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), fetches.Load())
+
+		_, err = cache.ValidateToken(context.Background(), sign(t, key2, nil))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+
+		// Refreshes are limited by the minimum refresh interval
+		_, err = cache.ValidateToken(context.Background(), sign(t, newKey(t, "key3"), nil))
+		require.Error(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+
+		cache.SetMinRefreshInterval(0)
+		_, err = cache.ValidateToken(context.Background(), sign(t, newKey(t, "key3"), nil))
+		require.Error(t, err)
+		assert.Equal(t, int32(3), fetches.Load())
+	})
+
+	t.Run("refreshes are limited using the clock", func(t *testing.T) {
+		var fetches atomic.Int32
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(ctx context.Context) ([]byte, error) {
+			fetches.Add(1)
+			return newJWKS(t, key1), nil
+		}), log)
+		clock := clocktesting.NewFakeClock(time.Now())
+		cache.clock = clock
+		cache.SetMinRefreshInterval(time.Minute)
+		require.NoError(t, cache.initCache(context.Background()))
+		require.Equal(t, int32(1), fetches.Load())
+
+		token := sign(t, key2, nil)
+		_, err := cache.ValidateToken(context.Background(), token)
+		require.Error(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+
+		clock.Step(59 * time.Second)
+		_, err = cache.ValidateToken(context.Background(), token)
+		require.Error(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+
+		clock.Step(time.Second)
+		_, err = cache.ValidateToken(context.Background(), token)
+		require.Error(t, err)
+		assert.Equal(t, int32(3), fetches.Load())
+	})
+
+	t.Run("grace period for removed keys", func(t *testing.T) {
+		var fetches atomic.Int32
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(ctx context.Context) ([]byte, error) {
+			// The first key is rotated after the first fetch
+			if fetches.Add(1) == 1 {
+				return newJWKS(t, key1), nil
+			}
+			return newJWKS(t, key2), nil
+		}), log)
+		clock := clocktesting.NewFakeClock(time.Now())
+		cache.clock = clock
+		cache.SetGracePeriod(5 * time.Minute)
+		var used []string
+		cache.SetGraceKeyUsedHandler(func(kid string) {
+			used = append(used, kid)
+		})
+		require.NoError(t, cache.initCache(context.Background()))
+
+		token1 := sign(t, key1, nil)
+		_, err := cache.ValidateToken(context.Background(), token1)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), cache.GraceKeyUses())
+
+		// Validating a token signed with the new key refreshes the JWKS
+		_, err = cache.ValidateToken(context.Background(), sign(t, key2, nil))
+		require.NoError(t, err)
+		require.Equal(t, int32(2), fetches.Load())
+		_, ok := cache.KeySet().LookupKeyID("key1")
+		require.False(t, ok)
+
+		// The removed key is still accepted, without refreshing again
+		clock.Step(4 * time.Minute)
+		cache.SetMinRefreshInterval(0)
+		_, err = cache.ValidateToken(context.Background(), token1)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+		assert.Equal(t, uint64(1), cache.GraceKeyUses())
+		assert.Equal(t, []string{"key1"}, used)
+
+		// After the grace period, the key is not accepted anymore
+		clock.Step(time.Minute)
+		_, err = cache.ValidateToken(context.Background(), token1)
+		require.Error(t, err)
+		assert.Equal(t, uint64(1), cache.GraceKeyUses())
+	})
+
+	t.Run("grace period for keys removed from a JWKS URL", func(t *testing.T) {
+		var fetches atomic.Int32
+		client := &http.Client{
+			Transport: roundTripFn(func(r *http.Request) *http.Response {
+				body := newJWKS(t, key2)
+				if fetches.Add(1) == 1 {
+					body = newJWKS(t, key1)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header: http.Header{
+						"content-type": []string{"application/json"},
+					},
+					Body: io.NopCloser(bytes.NewReader(body)),
+				}
+			}),
+		}
+		cache := NewJWKSCache("http://localhost/jwks.json", log)
+		cache.SetHTTPClient(client)
+		cache.SetGracePeriod(5 * time.Minute)
+		cache.SetMinRefreshInterval(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		require.NoError(t, cache.initCache(ctx))
+
+		// Validating a token signed with the new key refreshes the JWKS
+		_, err := cache.ValidateToken(context.Background(), sign(t, key2, nil))
+		require.NoError(t, err)
+		require.Equal(t, int32(2), fetches.Load())
+
+		_, err = cache.ValidateToken(context.Background(), sign(t, key1, nil))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), cache.GraceKeyUses())
+	})
+
+	t.Run("removed keys are not retained without grace period", func(t *testing.T) {
+		var fetches atomic.Int32
+		cache := NewJWKSCacheWithFetcher(FetcherFunc(func(ctx context.Context) ([]byte, error) {
+			if fetches.Add(1) == 1 {
+				return newJWKS(t, key1), nil
+			}
+			return newJWKS(t, key2), nil
+		}), log)
+		cache.SetMinRefreshInterval(0)
+		require.NoError(t, cache.initCache(context.Background()))
+
+		_, err := cache.ValidateToken(context.Background(), sign(t, key2, nil))
+		require.NoError(t, err)
+		_, err = cache.ValidateToken(context.Background(), sign(t, key1, nil))
+		require.Error(t, err)
+		assert.Equal(t, uint64(0), cache.GraceKeyUses())
+	})
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultBufferSize is the default number of records held by a BufferedWriter.
+const defaultBufferSize = 1024
+
+// ErrBufferedWriterClosed is returned when writing to a BufferedWriter which
+// has been closed.
+var ErrBufferedWriterClosed = errors.New("buffered writer is closed")
+
+// OverflowPolicy determines what a BufferedWriter does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the caller until there's room in the buffer.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop discards the record, which is counted in Dropped.
+	OverflowDrop
+)
+
+// BufferedWriterOptions configures a BufferedWriter.
+type BufferedWriterOptions struct {
+	// Size is the maximum number of records held in the buffer.
+	// Defaults to 1024.
+	Size int
+
+	// Overflow is the policy applied when the buffer is full.
+	// Defaults to OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+// BufferedWriter is an io.WriteCloser which writes records to the destination
+// asynchronously, so callers are not stalled by slow sinks.
+// Each call to Write is a record: loggers write each log entry with a single
+// call.
+// It is safe for concurrent use.
+type BufferedWriter struct {
+	dst      io.Writer
+	overflow OverflowPolicy
+	records  chan []byte
+	stop     chan struct{}
+	stopped  chan struct{}
+	dropped  atomic.Uint64
+
+	lock    sync.Mutex
+	cond    *sync.Cond
+	pending int
+	err     error
+	closed  bool
+}
+
+// NewBufferedWriter returns a new BufferedWriter which writes to dst, and
+// starts its background goroutine. Close must be invoked to stop it.
+func NewBufferedWriter(dst io.Writer, opts BufferedWriterOptions) *BufferedWriter {
+	if opts.Size <= 0 {
+		opts.Size = defaultBufferSize
+	}
+
+	w := &BufferedWriter{
+		dst:      dst,
+		overflow: opts.Overflow,
+		records:  make(chan []byte, opts.Size),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.lock)
+
+	go w.run()
+
+	return w
+}
+
+// Write adds a copy of p to the buffer. It never returns errors from the
+// destination, which are returned by Flush instead.
+func (w *BufferedWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return 0, ErrBufferedWriterClosed
+	}
+	w.pending++
+	w.lock.Unlock()
+
+	// Loggers may re-use the buffer after Write returns
+	record := bytes.Clone(p)
+
+	if w.overflow == OverflowDrop {
+		select {
+		case w.records <- record:
+		default:
+			w.dropped.Add(1)
+			w.done(nil)
+		}
+	} else {
+		w.records <- record
+	}
+
+	return len(p), nil
+}
+
+// Flush blocks until all the records in the buffer have been written to the
+// destination.
+// It returns the last error returned by the destination since the previous
+// call to Flush, if any.
+func (w *BufferedWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for w.pending > 0 {
+		w.cond.Wait()
+	}
+
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// Dropped returns the number of records discarded because the buffer was full.
+func (w *BufferedWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Close flushes the buffer and stops the background goroutine.
+// It does not close the destination.
+func (w *BufferedWriter) Close() error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.lock.Unlock()
+
+	err := w.Flush()
+	close(w.stop)
+	<-w.stopped
+	return err
+}
+
+// run writes the records in the buffer to the destination until the writer is
+// stopped.
+func (w *BufferedWriter) run() {
+	defer close(w.stopped)
+
+	for {
+		select {
+		case record := <-w.records:
+			_, err := w.dst.Write(record)
+			w.done(err)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// done marks a pending record as processed.
+func (w *BufferedWriter) done(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if err != nil {
+		w.err = err
+	}
+	w.pending--
+	if w.pending == 0 {
+		w.cond.Broadcast()
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedWriter is an io.Writer which blocks every Write until it's released.
+type gatedWriter struct {
+	gate chan struct{}
+	err  error
+
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.gate
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.buf.Write(p)
+	return len(p), g.err
+}
+
+func (g *gatedWriter) String() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.buf.String()
+}
+
+func TestBufferedWriter(t *testing.T) {
+	t.Run("writes records in order and flushes", func(t *testing.T) {
+		dst := &gatedWriter{gate: make(chan struct{})}
+		close(dst.gate)
+		w := NewBufferedWriter(dst, BufferedWriterOptions{})
+		t.Cleanup(func() { require.NoError(t, w.Close()) })
+
+		p := []byte("one\n")
+		_, err := w.Write(p)
+		require.NoError(t, err)
+		// The caller may re-use the buffer
+		copy(p, "two\n")
+		_, err = w.Write(p)
+		require.NoError(t, err)
+
+		require.NoError(t, w.Flush())
+		assert.Equal(t, "one\ntwo\n", dst.String())
+		assert.Zero(t, w.Dropped())
+	})
+
+	t.Run("drops records when the buffer is full", func(t *testing.T) {
+		dst := &gatedWriter{gate: make(chan struct{})}
+		w := NewBufferedWriter(dst, BufferedWriterOptions{
+			Size:     2,
+			Overflow: OverflowDrop,
+		})
+
+		// The first record is picked up by the background goroutine, which
+		// blocks on the destination; 2 more fill up the buffer
+		for range 8 {
+			n, err := w.Write([]byte("x"))
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+		}
+
+		close(dst.gate)
+		require.NoError(t, w.Close())
+
+		written := uint64(len(dst.String()))
+		assert.GreaterOrEqual(t, written, uint64(2))
+		assert.LessOrEqual(t, written, uint64(3))
+		assert.Equal(t, uint64(8), written+w.Dropped())
+	})
+
+	t.Run("blocks when the buffer is full", func(t *testing.T) {
+		dst := &gatedWriter{gate: make(chan struct{})}
+		w := NewBufferedWriter(dst, BufferedWriterOptions{
+			Size:     1,
+			Overflow: OverflowBlock,
+		})
+
+		writeDone := make(chan struct{})
+		go func() {
+			defer close(writeDone)
+			for range 4 {
+				_, _ = w.Write([]byte("x"))
+			}
+		}()
+
+		select {
+		case <-writeDone:
+			t.Fatal("writes should block while the buffer is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(dst.gate)
+		select {
+		case <-writeDone:
+		case <-time.After(5 * time.Second):
+			t.Fatal("writes should have been unblocked")
+		}
+
+		require.NoError(t, w.Close())
+		assert.Equal(t, "xxxx", dst.String())
+		assert.Zero(t, w.Dropped())
+	})
+
+	t.Run("flush returns write errors", func(t *testing.T) {
+		errSimulated := errors.New("simulated")
+		dst := &gatedWriter{gate: make(chan struct{}), err: errSimulated}
+		close(dst.gate)
+		w := NewBufferedWriter(dst, BufferedWriterOptions{})
+		t.Cleanup(func() { require.NoError(t, w.Close()) })
+
+		_, err := w.Write([]byte("x"))
+		require.NoError(t, err)
+		require.ErrorIs(t, w.Flush(), errSimulated)
+		require.NoError(t, w.Flush())
+	})
+
+	t.Run("write after close fails", func(t *testing.T) {
+		dst := &gatedWriter{gate: make(chan struct{})}
+		close(dst.gate)
+		w := NewBufferedWriter(dst, BufferedWriterOptions{})
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+
+		_, err := w.Write([]byte("x"))
+		require.ErrorIs(t, err, ErrBufferedWriterClosed)
+	})
+}
@@ -14,8 +14,10 @@ limitations under the License.
 package logger
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -27,6 +29,14 @@ type daprLogger struct {
 	name string
 	// loger is the instance of logrus logger
 	logger *logrus.Entry
+	// errOutput is the hook which copies error-and-above logs to a separate destination
+	errOutput *errorOutputHook
+	// sinks is the hook which routes logs to destinations by level
+	sinks *sinkHook
+	// jsonSchema customizes the JSON formatted output log
+	jsonSchema JSONSchema
+	// dedup collapses identical consecutive messages, if enabled
+	dedup *deduplicator
 }
 
 var DaprVersion = "unknown"
@@ -35,12 +45,26 @@ func newDaprLogger(name string) *daprLogger {
 	newLogger := logrus.New()
 	newLogger.SetOutput(os.Stdout)
 
+	newLogger.ExitFunc = exit
+
+	// Redaction must happen before the entries are written by other hooks
+	newLogger.AddHook(redactionHook{})
+	errOutput := &errorOutputHook{}
+	newLogger.AddHook(errOutput)
+	sinks := &sinkHook{}
+	newLogger.AddHook(sinks)
+	newLogger.AddHook(recordHook{})
+	newLogger.AddHook(fatalHook{})
+
 	dl := &daprLogger{
 		name: name,
 		logger: newLogger.WithFields(logrus.Fields{
 			logFieldScope: name,
 			logFieldType:  LogTypeLog,
 		}),
+		errOutput: errOutput,
+		sinks:     sinks,
+		dedup:     newDeduplicator(),
 	}
 
 	dl.EnableJSONOutput(defaultJSONOutput)
@@ -69,10 +93,10 @@ func (l *daprLogger) EnableJSONOutput(enabled bool) {
 	}
 
 	if enabled {
-		formatter = &logrus.JSONFormatter{ //nolint: exhaustruct
+		formatter = l.jsonSchema.formatter(&logrus.JSONFormatter{ //nolint: exhaustruct
 			TimestampFormat: time.RFC3339Nano,
-			FieldMap:        fieldMap,
-		}
+			FieldMap:        l.jsonSchema.fieldMap(),
+		})
 	} else {
 		formatter = &logrus.TextFormatter{ //nolint: exhaustruct
 			TimestampFormat: time.RFC3339Nano,
@@ -81,6 +105,14 @@ func (l *daprLogger) EnableJSONOutput(enabled bool) {
 	}
 
 	l.logger.Logger.SetFormatter(formatter)
+	l.sinks.setFormatter(formatter)
+}
+
+// SetJSONSchema sets the field names and the schema version of the JSON
+// formatted output log. It is applied the next time EnableJSONOutput is
+// invoked.
+func (l *daprLogger) SetJSONSchema(schema JSONSchema) {
+	l.jsonSchema = schema
 }
 
 // SetAppID sets app_id field in the log. Default value is empty string.
@@ -109,68 +141,156 @@ func (l *daprLogger) SetOutput(dst io.Writer) {
 	l.logger.Logger.SetOutput(dst)
 }
 
+// SetErrorOutput sets an additional destination for logs at level Error and
+// above. Passing nil disables it.
+func (l *daprLogger) SetErrorOutput(dst io.Writer) {
+	l.errOutput.setOutput(dst)
+}
+
+// SetSinks sets destinations for the logs within ranges of levels, in
+// addition to the output set with SetOutput; to only write to the sinks, set
+// the output to io.Discard. Passing no sinks removes them.
+// The sinks must be valid.
+func (l *daprLogger) SetSinks(sinks []Sink) {
+	l.sinks.setSinks(sinks, l.logger.Logger.Formatter)
+}
+
+// SetDeduplicationWindow enables deduplication of identical consecutive
+// messages within window. A window of 0 disables it.
+// See the SetDeduplicationWindow function for details.
+func (l *daprLogger) SetDeduplicationWindow(window time.Duration) {
+	l.dedup.setWindow(window)
+}
+
 // WithLogType specify the log_type field in log. Default value is LogTypeLog.
 func (l *daprLogger) WithLogType(logType string) Logger {
 	return &daprLogger{
-		name:   l.name,
-		logger: l.logger.WithField(logFieldType, logType),
+		name:       l.name,
+		logger:     l.logger.WithField(logFieldType, logType),
+		errOutput:  l.errOutput,
+		sinks:      l.sinks,
+		jsonSchema: l.jsonSchema,
+		dedup:      l.dedup,
 	}
 }
 
 // WithFields returns a logger with the added structured fields.
 func (l *daprLogger) WithFields(fields map[string]any) Logger {
 	return &daprLogger{
-		name:   l.name,
-		logger: l.logger.WithFields(fields),
+		name:       l.name,
+		logger:     l.logger.WithFields(fields),
+		errOutput:  l.errOutput,
+		sinks:      l.sinks,
+		jsonSchema: l.jsonSchema,
+		dedup:      l.dedup,
 	}
 }
 
 // Info logs a message at level Info.
 func (l *daprLogger) Info(args ...interface{}) {
-	l.logger.Log(logrus.InfoLevel, args...)
+	l.log(logrus.InfoLevel, args...)
 }
 
 // Infof logs a message at level Info.
 func (l *daprLogger) Infof(format string, args ...interface{}) {
-	l.logger.Logf(logrus.InfoLevel, format, args...)
+	l.logf(logrus.InfoLevel, format, args...)
 }
 
 // Debug logs a message at level Debug.
 func (l *daprLogger) Debug(args ...interface{}) {
-	l.logger.Log(logrus.DebugLevel, args...)
+	l.log(logrus.DebugLevel, args...)
 }
 
 // Debugf logs a message at level Debug.
 func (l *daprLogger) Debugf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.DebugLevel, format, args...)
+	l.logf(logrus.DebugLevel, format, args...)
 }
 
 // Warn logs a message at level Warn.
 func (l *daprLogger) Warn(args ...interface{}) {
-	l.logger.Log(logrus.WarnLevel, args...)
+	l.log(logrus.WarnLevel, args...)
 }
 
 // Warnf logs a message at level Warn.
 func (l *daprLogger) Warnf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.WarnLevel, format, args...)
+	l.logf(logrus.WarnLevel, format, args...)
 }
 
 // Error logs a message at level Error.
 func (l *daprLogger) Error(args ...interface{}) {
-	l.logger.Log(logrus.ErrorLevel, args...)
+	l.log(logrus.ErrorLevel, args...)
 }
 
 // Errorf logs a message at level Error.
 func (l *daprLogger) Errorf(format string, args ...interface{}) {
-	l.logger.Logf(logrus.ErrorLevel, format, args...)
+	l.logf(logrus.ErrorLevel, format, args...)
 }
 
 // Fatal logs a message at level Fatal then the process will exit with status set to 1.
+// The registered FatalHooks are invoked before exiting; see RegisterFatalHook and SetExitFunc.
 func (l *daprLogger) Fatal(args ...interface{}) {
+	l.dedup.flush()
 	l.logger.Fatal(args...)
 }
 
 // Fatalf logs a message at level Fatal then the process will exit with status set to 1.
+// The registered FatalHooks are invoked before exiting; see RegisterFatalHook and SetExitFunc.
 func (l *daprLogger) Fatalf(format string, args ...interface{}) {
+	l.dedup.flush()
 	l.logger.Fatalf(format, args...)
 }
+
+func (l *daprLogger) log(level logrus.Level, args ...interface{}) {
+	if !l.dedup.enabled() {
+		l.logger.Log(level, args...)
+		return
+	}
+	if l.logger.Logger.IsLevelEnabled(level) {
+		l.dedup.log(l.name, l.logger, level, fmt.Sprint(args...))
+	}
+}
+
+func (l *daprLogger) logf(level logrus.Level, format string, args ...interface{}) {
+	if !l.dedup.enabled() {
+		l.logger.Logf(level, format, args...)
+		return
+	}
+	if l.logger.Logger.IsLevelEnabled(level) {
+		l.dedup.log(l.name, l.logger, level, fmt.Sprintf(format, args...))
+	}
+}
+
+// errorOutputHook is a logrus hook which writes entries at level Error and
+// above to a separate destination.
+type errorOutputHook struct {
+	lock sync.RWMutex
+	dst  io.Writer
+}
+
+func (h *errorOutputHook) setOutput(dst io.Writer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.dst = dst
+}
+
+// Levels implements logrus.Hook.
+func (h *errorOutputHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel}
+}
+
+// Fire implements logrus.Hook.
+func (h *errorOutputHook) Fire(entry *logrus.Entry) error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if h.dst == nil {
+		return nil
+	}
+
+	b, err := entry.Logger.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.dst.Write(b)
+	return err
+}
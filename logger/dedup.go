@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/utils/clock"
+)
+
+// logFieldRepeated is the field of the summary records of deduplicated
+// messages, with the number of times the message was repeated.
+const logFieldRepeated = "repeated"
+
+// SetDeduplicationWindow enables deduplication of identical consecutive
+// messages for the logger: when a message is logged again at the same level,
+// by a logger with the same name, within window from its first occurrence,
+// it's not written; instead, once the window ends or a different message is
+// logged, a single record "last message repeated N times" is written.
+// A window of 0 disables deduplication, which is the default.
+// Deduplication applies to the loggers derived from the logger with
+// WithFields and WithLogType too. Fatal messages are never deduplicated.
+// It returns false if the logger doesn't support deduplication.
+func SetDeduplicationWindow(l Logger, window time.Duration) bool {
+	dl, ok := l.(deduplicationSetter)
+	if ok {
+		dl.SetDeduplicationWindow(window)
+	}
+	return ok
+}
+
+// deduplicationSetter is implemented by loggers which support deduplicating
+// identical consecutive messages.
+type deduplicationSetter interface {
+	SetDeduplicationWindow(window time.Duration)
+}
+
+// deduplicator collapses runs of identical consecutive messages.
+// It is shared by a logger and the loggers derived from it.
+type deduplicator struct {
+	clock clock.WithDelayedExecution
+	// window is stored as an atomic value so loggers can check if
+	// deduplication is enabled without acquiring the lock
+	window atomic.Int64
+	lock   sync.Mutex
+	run    *dedupRun
+}
+
+// dedupRun is a run of identical messages.
+// Messages are identical when they're logged by loggers with the same name,
+// at the same level and with the same text; entry is the entry of the first
+// message of the run, used to write the summary.
+type dedupRun struct {
+	name  string
+	level logrus.Level
+	msg   string
+	entry *logrus.Entry
+	start time.Time
+	count int
+	timer clock.Timer
+}
+
+func newDeduplicator() *deduplicator {
+	return &deduplicator{
+		clock: clock.RealClock{},
+	}
+}
+
+func (d *deduplicator) enabled() bool {
+	return d.window.Load() > 0
+}
+
+func (d *deduplicator) setWindow(window time.Duration) {
+	d.lock.Lock()
+	run := d.endRunLocked()
+	d.window.Store(int64(window))
+	d.lock.Unlock()
+
+	run.writeSummary()
+}
+
+// log writes the message with the entry of the logger with the given name,
+// unless it's a repetition of the last message within the window.
+// Records are written after releasing the lock, so hooks can log through the
+// same logger.
+func (d *deduplicator) log(name string, entry *logrus.Entry, level logrus.Level, msg string) {
+	d.lock.Lock()
+
+	window := time.Duration(d.window.Load())
+	now := d.clock.Now()
+	run := d.run
+	if window > 0 && run != nil &&
+		run.name == name && run.level == level && run.msg == msg &&
+		now.Sub(run.start) < window {
+		run.count++
+		if run.timer == nil {
+			run.timer = d.clock.AfterFunc(run.start.Add(window).Sub(now), func() {
+				d.lock.Lock()
+				var ended *dedupRun
+				if d.run == run {
+					// The timer has fired, so it doesn't need to be stopped
+					run.timer = nil
+					ended = d.endRunLocked()
+				}
+				d.lock.Unlock()
+
+				ended.writeSummary()
+			})
+		}
+		d.lock.Unlock()
+		return
+	}
+
+	ended := d.endRunLocked()
+	if window > 0 {
+		d.run = &dedupRun{
+			name:  name,
+			level: level,
+			msg:   msg,
+			entry: entry,
+			start: now,
+		}
+	}
+	d.lock.Unlock()
+
+	ended.writeSummary()
+	entry.Log(level, msg)
+}
+
+// flush writes the summary of the current run, if any.
+func (d *deduplicator) flush() {
+	d.lock.Lock()
+	run := d.endRunLocked()
+	d.lock.Unlock()
+
+	run.writeSummary()
+}
+
+// endRunLocked ends the current run and returns it, or nil if there's none.
+// The caller must write its summary after releasing the lock.
+func (d *deduplicator) endRunLocked() *dedupRun {
+	run := d.run
+	if run == nil {
+		return nil
+	}
+	d.run = nil
+
+	if run.timer != nil {
+		run.timer.Stop()
+	}
+	return run
+}
+
+// writeSummary writes the summary of the run, if the message was repeated.
+func (run *dedupRun) writeSummary() {
+	if run == nil || run.count == 0 {
+		return
+	}
+	run.entry.
+		WithField(logFieldRepeated, run.count).
+		Log(run.level, fmt.Sprintf("last message repeated %d times", run.count))
+}
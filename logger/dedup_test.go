@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// syncBuffer is a bytes.Buffer which is safe for concurrent use.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+// hookFunc is a logrus hook which calls the function for every entry.
+type hookFunc func(entry *logrus.Entry)
+
+func (f hookFunc) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (f hookFunc) Fire(entry *logrus.Entry) error {
+	f(entry)
+	return nil
+}
+
+// records returns the JSON records written to the buffer.
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var res []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var r map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		res = append(res, r)
+	}
+	return res
+}
+
+func TestDeduplication(t *testing.T) {
+	newLogger := func(t *testing.T) (*daprLogger, *syncBuffer, *clocktesting.FakeClock) {
+		t.Helper()
+
+		buf := &syncBuffer{}
+		l := getTestLogger(buf)
+		l.EnableJSONOutput(true)
+		clock := clocktesting.NewFakeClock(time.Now())
+		l.dedup.clock = clock
+		require.True(t, SetDeduplicationWindow(l, time.Minute))
+		return l, buf, clock
+	}
+
+	messages := func(records []map[string]any) []string {
+		res := make([]string, len(records))
+		for i, r := range records {
+			res[i] = r[logFieldMessage].(string)
+		}
+		return res
+	}
+
+	t.Run("collapses identical messages until a different one", func(t *testing.T) {
+		l, buf, _ := newLogger(t)
+
+		for range 5 {
+			l.Errorf("connection to %s failed", "db")
+		}
+		l.Info("connected")
+
+		records := buf.records(t)
+		assert.Equal(t, []string{
+			"connection to db failed",
+			"last message repeated 4 times",
+			"connected",
+		}, messages(records))
+		assert.Equal(t, "error", records[1][logFieldLevel])
+		assert.InDelta(t, 4, records[1][logFieldRepeated], 0)
+	})
+
+	t.Run("writes the summary when the window ends", func(t *testing.T) {
+		l, buf, clock := newLogger(t)
+
+		l.Warn("retrying")
+		l.Warn("retrying")
+		l.Warn("retrying")
+		assert.Len(t, buf.records(t), 1)
+
+		clock.Step(time.Minute)
+		assert.Eventually(t, func() bool {
+			return len(buf.records(t)) == 2
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "last message repeated 2 times", messages(buf.records(t))[1])
+
+		// A new run starts after the window
+		l.Warn("retrying")
+		l.Warn("retrying")
+		assert.Equal(t, []string{
+			"retrying",
+			"last message repeated 2 times",
+			"retrying",
+		}, messages(buf.records(t)))
+	})
+
+	t.Run("different levels are not identical", func(t *testing.T) {
+		l, buf, _ := newLogger(t)
+
+		l.Info("hello")
+		l.Warn("hello")
+		l.Warn("hello")
+		l.Info("hello")
+
+		assert.Equal(t, []string{
+			"hello",
+			"hello",
+			"last message repeated 1 times",
+			"hello",
+		}, messages(buf.records(t)))
+	})
+
+	t.Run("loggers with the same name are identical", func(t *testing.T) {
+		l, buf, _ := newLogger(t)
+		l.SetAppID("myapp")
+
+		l.Warn("hello")
+		l.WithFields(map[string]any{"component": "mystore"}).Warn("hello")
+		l.WithFields(map[string]any{"component": "mystore"}).Warn("hello")
+		l.Info("bye")
+
+		records := buf.records(t)
+		assert.Equal(t, []string{
+			"hello",
+			"last message repeated 2 times",
+			"bye",
+		}, messages(records))
+		assert.Equal(t, "myapp", records[1][logFieldAppID])
+	})
+
+	t.Run("hooks can log through the same logger", func(t *testing.T) {
+		l, buf, _ := newLogger(t)
+		var fired atomic.Bool
+		l.logger.Logger.AddHook(hookFunc(func(entry *logrus.Entry) {
+			if entry.Message == "hello" && fired.CompareAndSwap(false, true) {
+				l.Info("from hook")
+			}
+		}))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			l.Info("hello")
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("logging from a hook deadlocked")
+		}
+		assert.ElementsMatch(t, []string{"hello", "from hook"}, messages(buf.records(t)))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		l, buf, _ := newLogger(t)
+		l.Info("hello")
+		l.Info("hello")
+
+		SetDeduplicationWindow(l, 0)
+		l.Info("hello")
+		l.Info("hello")
+
+		assert.Equal(t, []string{
+			"hello",
+			"last message repeated 1 times",
+			"hello",
+			"hello",
+		}, messages(buf.records(t)))
+	})
+
+	t.Run("messages below the output level are ignored", func(t *testing.T) {
+		l, buf, _ := newLogger(t)
+		l.Info("hello")
+		l.Debug("hello")
+		l.Info("bye")
+
+		assert.Equal(t, []string{"hello", "bye"}, messages(buf.records(t)))
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		assert.False(t, SetDeduplicationWindow(&nopLogger{}, time.Minute))
+	})
+}
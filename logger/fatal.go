@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FatalHook is a function invoked when a logger logs a message at level Fatal,
+// before the process exits.
+// scope is the name of the logger.
+type FatalHook func(scope string, message string)
+
+type registeredFatalHook struct {
+	id   uint64
+	hook FatalHook
+}
+
+var (
+	fatalLock   sync.RWMutex
+	fatalHooks  []registeredFatalHook
+	fatalHookID uint64
+	exitFunc    = os.Exit
+)
+
+// RegisterFatalHook registers a hook that is invoked, for every logger, when a
+// message is logged at level Fatal, before the process exits.
+// Hooks are invoked in the order they were registered.
+// It returns a function that unregisters the hook.
+func RegisterFatalHook(hook FatalHook) (unregister func()) {
+	fatalLock.Lock()
+	defer fatalLock.Unlock()
+
+	fatalHookID++
+	id := fatalHookID
+	fatalHooks = append(fatalHooks, registeredFatalHook{id: id, hook: hook})
+
+	return func() {
+		fatalLock.Lock()
+		defer fatalLock.Unlock()
+
+		for i, h := range fatalHooks {
+			if h.id == id {
+				fatalHooks = append(fatalHooks[:i:i], fatalHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SetExitFunc replaces the function invoked to terminate the process after a
+// message is logged at level Fatal, which is os.Exit by default.
+// This is useful in tests, or to perform a graceful shutdown instead of
+// exiting right away. Passing nil restores os.Exit.
+func SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		fn = os.Exit
+	}
+
+	fatalLock.Lock()
+	exitFunc = fn
+	fatalLock.Unlock()
+}
+
+// exit is the ExitFunc of all logrus loggers.
+// It flushes the buffered outputs first, so the fatal message isn't lost.
+func exit(code int) {
+	_ = FlushOutputs()
+
+	fatalLock.RLock()
+	fn := exitFunc
+	fatalLock.RUnlock()
+
+	fn(code)
+}
+
+// fatalHook is a logrus hook which invokes the registered FatalHooks.
+type fatalHook struct{}
+
+// Levels implements logrus.Hook.
+func (fatalHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.FatalLevel, logrus.PanicLevel}
+}
+
+// Fire implements logrus.Hook.
+func (fatalHook) Fire(entry *logrus.Entry) error {
+	fatalLock.RLock()
+	hooks := make([]FatalHook, len(fatalHooks))
+	for i, h := range fatalHooks {
+		hooks[i] = h.hook
+	}
+	fatalLock.RUnlock()
+
+	scope, _ := entry.Data[logFieldScope].(string)
+	for _, hook := range hooks {
+		hook(scope, entry.Message)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFatalHooks(t *testing.T) {
+	var exitCodes []int
+	SetExitFunc(func(code int) {
+		exitCodes = append(exitCodes, code)
+	})
+	t.Cleanup(func() { SetExitFunc(nil) })
+
+	var buf bytes.Buffer
+	l := newDaprLogger(fakeLoggerName)
+	l.SetOutput(&buf)
+
+	var calls []string
+	unregister1 := RegisterFatalHook(func(scope string, message string) {
+		// The hook is invoked before exiting
+		assert.Empty(t, exitCodes)
+		calls = append(calls, "1:"+scope+":"+message)
+	})
+	unregister2 := RegisterFatalHook(func(scope string, message string) {
+		calls = append(calls, "2:"+scope+":"+message)
+	})
+
+	l.Fatal("boom")
+	assert.Equal(t, []string{"1:fakeLogger:boom", "2:fakeLogger:boom"}, calls)
+	assert.Equal(t, []int{1}, exitCodes)
+	assert.Contains(t, buf.String(), "boom")
+
+	unregister1()
+	unregister1() // No-op
+	calls = nil
+	l.Fatalf("boom %d", 2)
+	assert.Equal(t, []string{"2:fakeLogger:boom 2"}, calls)
+	assert.Equal(t, []int{1, 1}, exitCodes)
+
+	unregister2()
+	calls = nil
+	l.Error("not fatal")
+	l.Fatal("boom")
+	assert.Empty(t, calls)
+	assert.Equal(t, []int{1, 1, 1}, exitCodes)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// backupTimeFormat is the layout used in the file name of rotated log files.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// RotatingFileOptions configures a RotatingFileWriter.
+type RotatingFileOptions struct {
+	// Filename is the path of the active log file.
+	Filename string
+
+	// MaxSize is the maximum size in bytes of the active log file before it is
+	// rotated. A value of 0 disables size-based rotation.
+	MaxSize int64
+
+	// MaxAge is the maximum age of the active log file before it is rotated.
+	// A value of 0 disables age-based rotation.
+	MaxAge time.Duration
+
+	// MaxBackups is the maximum number of rotated files to retain. Older
+	// files are deleted. A value of 0 retains all rotated files.
+	MaxBackups int
+
+	// Clock is the clock used to determine file age. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// RotatingFileWriter is an io.WriteCloser which writes to a file, rotating it
+// when it exceeds a maximum size or age.
+// Rotated files are renamed by appending a timestamp to the file name, before
+// the extension.
+// It is safe for concurrent use.
+type RotatingFileWriter struct {
+	opts RotatingFileOptions
+
+	lock     sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+}
+
+// NewRotatingFileWriter returns a new RotatingFileWriter. The file, and its
+// parent directory, are created if they don't exist.
+func NewRotatingFileWriter(opts RotatingFileOptions) (*RotatingFileWriter, error) {
+	if opts.Filename == "" {
+		return nil, errors.New("log file name is required")
+	}
+	if opts.MaxSize < 0 || opts.MaxAge < 0 || opts.MaxBackups < 0 {
+		return nil, errors.New("log file rotation options must not be negative")
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.RealClock{}
+	}
+
+	w := &RotatingFileWriter{opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write writes p to the active log file, rotating it first if needed.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate forces the rotation of the active log file.
+func (w *RotatingFileWriter) Rotate() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+
+	return w.rotate()
+}
+
+// Close closes the active log file.
+func (w *RotatingFileWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	return w.file.Close()
+}
+
+func (w *RotatingFileWriter) shouldRotate(n int64) bool {
+	// Never rotate an empty file, even if a single write exceeds the max size.
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	if w.opts.MaxAge > 0 && w.opts.Clock.Since(w.openedAt) >= w.opts.MaxAge {
+		return true
+	}
+	return false
+}
+
+func (w *RotatingFileWriter) open() error {
+	err := os.MkdirAll(filepath.Dir(w.opts.Filename), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(w.opts.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.openedAt = w.opts.Clock.Now()
+
+	return nil
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	ext := filepath.Ext(w.opts.Filename)
+	base := strings.TrimSuffix(w.opts.Filename, ext)
+	backup := base + "-" + w.opts.Clock.Now().UTC().Format(backupTimeFormat) + ext
+	if err := os.Rename(w.opts.Filename, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	return w.removeOldBackups()
+}
+
+func (w *RotatingFileWriter) removeOldBackups() error {
+	if w.opts.MaxBackups == 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(w.opts.Filename)
+	base := strings.TrimSuffix(w.opts.Filename, ext)
+	matches, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+
+	// Only consider files whose suffix is a timestamp written by the rotator,
+	// so unrelated files sharing the prefix (e.g. "dapr-error.log") are kept.
+	backups := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ts := strings.TrimSuffix(strings.TrimPrefix(m, base+"-"), ext)
+		if parsed, err := time.Parse(backupTimeFormat, ts); err == nil && parsed.Format(backupTimeFormat) == ts {
+			backups = append(backups, m)
+		}
+	}
+
+	if len(backups) <= w.opts.MaxBackups {
+		return nil
+	}
+
+	// The timestamp format sorts lexicographically.
+	sort.Strings(backups)
+	var errs []error
+	for _, b := range backups[:len(backups)-w.opts.MaxBackups] {
+		if err := os.Remove(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
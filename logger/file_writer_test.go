@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	t.Run("requires a file name", func(t *testing.T) {
+		_, err := NewRotatingFileWriter(RotatingFileOptions{})
+		require.Error(t, err)
+	})
+
+	t.Run("creates the file and its directory", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "logs", "dapr.log")
+		w, err := NewRotatingFileWriter(RotatingFileOptions{Filename: filename})
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+
+		_, err = w.Write([]byte("hello\n"))
+		require.NoError(t, err)
+
+		b, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", string(b))
+	})
+
+	t.Run("rotates on size", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "dapr.log")
+		clock := clocktesting.NewFakeClock(time.Now())
+		w, err := NewRotatingFileWriter(RotatingFileOptions{
+			Filename: filename,
+			MaxSize:  10,
+			Clock:    clock,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+
+		_, err = w.Write([]byte("12345678\n"))
+		require.NoError(t, err)
+		clock.Step(time.Second)
+		_, err = w.Write([]byte("abc\n"))
+		require.NoError(t, err)
+
+		b, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "abc\n", string(b))
+
+		backups, err := filepath.Glob(filepath.Join(dir, "dapr-*.log"))
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		b, err = os.ReadFile(backups[0])
+		require.NoError(t, err)
+		assert.Equal(t, "12345678\n", string(b))
+	})
+
+	t.Run("rotates on age", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "dapr.log")
+		clock := clocktesting.NewFakeClock(time.Now())
+		w, err := NewRotatingFileWriter(RotatingFileOptions{
+			Filename: filename,
+			MaxAge:   time.Hour,
+			Clock:    clock,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+
+		_, err = w.Write([]byte("1\n"))
+		require.NoError(t, err)
+		clock.Step(time.Minute)
+		_, err = w.Write([]byte("2\n"))
+		require.NoError(t, err)
+		clock.Step(time.Hour)
+		_, err = w.Write([]byte("3\n"))
+		require.NoError(t, err)
+
+		b, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "3\n", string(b))
+
+		backups, err := filepath.Glob(filepath.Join(dir, "dapr-*.log"))
+		require.NoError(t, err)
+		assert.Len(t, backups, 1)
+	})
+
+	t.Run("retains max backups", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "dapr.log")
+		clock := clocktesting.NewFakeClock(time.Now())
+		w, err := NewRotatingFileWriter(RotatingFileOptions{
+			Filename:   filename,
+			MaxBackups: 2,
+			Clock:      clock,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+
+		for i := 0; i < 5; i++ {
+			_, err = w.Write([]byte("x\n"))
+			require.NoError(t, err)
+			clock.Step(time.Second)
+			require.NoError(t, w.Rotate())
+		}
+
+		backups, err := filepath.Glob(filepath.Join(dir, "dapr-*.log"))
+		require.NoError(t, err)
+		assert.Len(t, backups, 2)
+	})
+
+	t.Run("does not remove unrelated files", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "dapr.log")
+		unrelated := []string{
+			filepath.Join(dir, "dapr-error.log"),
+			filepath.Join(dir, "dapr-audit.log"),
+		}
+		for _, f := range unrelated {
+			require.NoError(t, os.WriteFile(f, []byte("keep\n"), 0o600))
+		}
+
+		clock := clocktesting.NewFakeClock(time.Now())
+		w, err := NewRotatingFileWriter(RotatingFileOptions{
+			Filename:   filename,
+			MaxBackups: 1,
+			Clock:      clock,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+
+		for i := 0; i < 3; i++ {
+			_, err = w.Write([]byte("x\n"))
+			require.NoError(t, err)
+			clock.Step(time.Second)
+			require.NoError(t, w.Rotate())
+		}
+
+		for _, f := range unrelated {
+			assert.FileExists(t, f)
+		}
+		backups, err := filepath.Glob(filepath.Join(dir, "dapr-*.log"))
+		require.NoError(t, err)
+		assert.Len(t, backups, 1+len(unrelated))
+	})
+
+	t.Run("write after close fails", func(t *testing.T) {
+		w, err := NewRotatingFileWriter(RotatingFileOptions{
+			Filename: filepath.Join(t.TempDir(), "dapr.log"),
+		})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		_, err = w.Write([]byte("x"))
+		require.ErrorIs(t, err, os.ErrClosed)
+	})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Default name of the field containing the schema version.
+const defaultJSONSchemaVersionKey = "schema_version"
+
+// JSONSchema customizes the records of the JSON formatted output log, so they
+// conform to logging standards such as ECS or Stackdriver.
+// Empty values keep the default field names.
+type JSONSchema struct {
+	// TimeKey is the name of the field containing the time of the record.
+	// Default is "time".
+	TimeKey string
+	// LevelKey is the name of the field containing the level of the record.
+	// Default is "level".
+	LevelKey string
+	// MessageKey is the name of the field containing the message.
+	// Default is "msg".
+	MessageKey string
+	// ScopeKey is the name of the field containing the name of the logger.
+	// Default is "scope".
+	ScopeKey string
+
+	// Version is the version of the schema, which is added to every record if
+	// not empty.
+	Version string
+	// VersionKey is the name of the field containing the version of the
+	// schema. Default is "schema_version".
+	VersionKey string
+}
+
+// fieldMap returns the names of the fields set by logrus.
+func (s JSONSchema) fieldMap() logrus.FieldMap {
+	return logrus.FieldMap{
+		logrus.FieldKeyTime:  valueOrDefault(s.TimeKey, logFieldTimeStamp),
+		logrus.FieldKeyLevel: valueOrDefault(s.LevelKey, logFieldLevel),
+		logrus.FieldKeyMsg:   valueOrDefault(s.MessageKey, logFieldMessage),
+	}
+}
+
+// formatter wraps the JSON formatter f to apply the rest of the schema, if
+// needed.
+func (s JSONSchema) formatter(f *logrus.JSONFormatter) logrus.Formatter {
+	scopeKey := valueOrDefault(s.ScopeKey, logFieldScope)
+	if scopeKey == logFieldScope && s.Version == "" {
+		return f
+	}
+
+	return &schemaFormatter{
+		JSONFormatter: f,
+		scopeKey:      scopeKey,
+		version:       s.Version,
+		versionKey:    valueOrDefault(s.VersionKey, defaultJSONSchemaVersionKey),
+	}
+}
+
+// schemaFormatter is a JSON formatter which renames the scope field and adds
+// the schema version to the records.
+type schemaFormatter struct {
+	*logrus.JSONFormatter
+
+	scopeKey   string
+	version    string
+	versionKey string
+}
+
+// Format implements logrus.Formatter.
+func (f *schemaFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// The entry is shared with the hooks, so its data is not modified
+	data := make(logrus.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		if k == logFieldScope {
+			k = f.scopeKey
+		}
+		data[k] = v
+	}
+	if f.version != "" {
+		data[f.versionKey] = f.version
+	}
+
+	e := *entry
+	e.Data = data
+	return f.JSONFormatter.Format(&e)
+}
+
+// jsonSchemaSetter is implemented by loggers which support customizing the
+// schema of the JSON formatted output log.
+type jsonSchemaSetter interface {
+	SetJSONSchema(schema JSONSchema)
+}
+
+func valueOrDefault(val string, def string) string {
+	if val == "" {
+		return def
+	}
+	return val
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	readRecord := func(t *testing.T, buf *bytes.Buffer) map[string]any {
+		t.Helper()
+		b, err := buf.ReadBytes('\n')
+		require.NoError(t, err)
+		var o map[string]any
+		require.NoError(t, json.Unmarshal(b, &o))
+		return o
+	}
+
+	t.Run("default schema", func(t *testing.T) {
+		var buf bytes.Buffer
+		testLogger := getTestLogger(&buf)
+		testLogger.SetJSONSchema(JSONSchema{})
+		testLogger.EnableJSONOutput(true)
+
+		// No additional formatter is used
+		_, ok := testLogger.logger.Logger.Formatter.(*logrus.JSONFormatter)
+		assert.True(t, ok)
+
+		testLogger.Info("King Dapr")
+		o := readRecord(t, &buf)
+		assert.Equal(t, "King Dapr", o[logFieldMessage])
+		assert.Equal(t, fakeLoggerName, o[logFieldScope])
+		assert.NotContains(t, o, defaultJSONSchemaVersionKey)
+	})
+
+	t.Run("custom field names and version", func(t *testing.T) {
+		var buf bytes.Buffer
+		testLogger := getTestLogger(&buf)
+		testLogger.SetJSONSchema(JSONSchema{
+			TimeKey:    "@timestamp",
+			LevelKey:   "log.level",
+			MessageKey: "message",
+			ScopeKey:   "log.logger",
+			Version:    "8.11",
+			VersionKey: "ecs.version",
+		})
+		testLogger.EnableJSONOutput(true)
+
+		testLogger.WithFields(map[string]any{"answer": 42}).Warn("King Dapr")
+		o := readRecord(t, &buf)
+		assert.Equal(t, "King Dapr", o["message"])
+		assert.Equal(t, "warning", o["log.level"])
+		assert.Equal(t, fakeLoggerName, o["log.logger"])
+		assert.Equal(t, "8.11", o["ecs.version"])
+		assert.InDelta(t, 42, o["answer"], 0)
+		assert.Equal(t, LogTypeLog, o[logFieldType])
+		_, err := time.Parse(time.RFC3339, o["@timestamp"].(string))
+		require.NoError(t, err)
+		for _, k := range []string{logFieldMessage, logFieldLevel, logFieldScope, logFieldTimeStamp} {
+			assert.NotContains(t, o, k)
+		}
+
+		// The schema is not used by the text output
+		testLogger.EnableJSONOutput(false)
+		testLogger.Info("King Dapr")
+		line, err := buf.ReadString('\n')
+		require.NoError(t, err)
+		assert.Contains(t, line, "scope="+fakeLoggerName)
+		assert.NotContains(t, line, "ecs.version")
+	})
+
+	t.Run("version with default key", func(t *testing.T) {
+		var buf bytes.Buffer
+		testLogger := getTestLogger(&buf)
+		testLogger.SetJSONSchema(JSONSchema{Version: "1"})
+		testLogger.EnableJSONOutput(true)
+
+		testLogger.WithLogType(LogTypeRequest).Info("King Dapr")
+		o := readRecord(t, &buf)
+		assert.Equal(t, "1", o[defaultJSONSchemaVersionKey])
+		assert.Equal(t, fakeLoggerName, o[logFieldScope])
+		assert.Equal(t, LogTypeRequest, o[logFieldType])
+	})
+
+	t.Run("applied with options", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := NewLogger("testJSONSchemaLogger")
+		l.SetOutput(&buf)
+		t.Cleanup(func() {
+			defaults := DefaultOptions()
+			require.NoError(t, ApplyOptionsToLoggers(&defaults))
+		})
+
+		opts := DefaultOptions()
+		opts.JSONFormatEnabled = true
+		opts.JSONSchema = JSONSchema{MessageKey: "message"}
+		require.NoError(t, ApplyOptionsToLoggers(&opts))
+
+		l.Info("King Dapr")
+		o := readRecord(t, &buf)
+		assert.Equal(t, "King Dapr", o["message"])
+	})
+}
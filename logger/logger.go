@@ -35,6 +35,9 @@ const (
 	logFieldInstance  = "instance"
 	logFieldDaprVer   = "ver"
 	logFieldAppID     = "app_id"
+	logFieldTraceID   = "trace_id"
+
+	logFieldCallerAppID = "caller_app_id"
 )
 
 type logContextKeyType struct{}
@@ -164,6 +167,24 @@ func NewContext(ctx context.Context, logger Logger) context.Context {
 	return context.WithValue(ctx, logContextKey, logger)
 }
 
+// FromContext returns the Logger carried by ctx, and a boolean indicating
+// whether a Logger was found.
+func FromContext(ctx context.Context) (Logger, bool) {
+	v, ok := ctx.Value(logContextKey).(Logger)
+	return v, ok && v != nil
+}
+
+// WithContextFields returns a new Context, derived from ctx, which carries
+// the Logger of ctx with the added structured fields.
+// If ctx doesn't carry a Logger, ctx is returned as-is.
+func WithContextFields(ctx context.Context, fields map[string]any) context.Context {
+	l, ok := FromContext(ctx)
+	if !ok || len(fields) == 0 {
+		return ctx
+	}
+	return NewContext(ctx, l.WithFields(fields))
+}
+
 // FromContextOrDiscard returns a Logger from ctx.  If no Logger is found, this
 // returns a Logger that discards all log messages.
 func FromContextOrDefault(ctx context.Context) Logger {
@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package loggertest contains a logger.Logger which captures the records it
+// logs, for use in tests.
+package loggertest
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dapr/kit/logger"
+)
+
+// fieldAppID is the name of the field set by SetAppID.
+const fieldAppID = "app_id"
+
+// levelSeverity orders the log levels, from the most to the least severe.
+var levelSeverity = map[logger.LogLevel]int{
+	logger.FatalLevel: 1,
+	logger.ErrorLevel: 2,
+	logger.WarnLevel:  3,
+	logger.InfoLevel:  4,
+	logger.DebugLevel: 5,
+}
+
+// isLevelEnabled returns true if records with the given level are captured
+// when the output level is outputLevel.
+func isLevelEnabled(level, outputLevel logger.LogLevel) bool {
+	return levelSeverity[level] <= levelSeverity[outputLevel]
+}
+
+// Record is a log record captured by a Logger.
+type Record struct {
+	// Level is the level of the record.
+	Level logger.LogLevel
+	// Message is the formatted message.
+	Message string
+	// Type is the log type, such as logger.LogTypeLog or logger.LogTypeRequest.
+	Type string
+	// Fields contains the structured fields of the record, including the app
+	// ID if set.
+	Fields map[string]any
+}
+
+// recordSink collects the records of a Logger and of the loggers derived
+// from it.
+type recordSink struct {
+	t           testing.TB
+	lock        sync.Mutex
+	records     []Record
+	outputLevel logger.LogLevel
+	done        bool
+}
+
+// Logger is a logger.Logger which captures the records it logs, so tests can
+// assert on them. Records are also written to the test log.
+// Fatal and Fatalf don't exit the process: they are captured as records with
+// level logger.FatalLevel.
+// It is safe for concurrent use.
+type Logger struct {
+	sink    *recordSink
+	logType string
+	fields  map[string]any
+}
+
+// New returns a new Logger which writes records to the log of t.
+// All levels are captured by default.
+func New(t testing.TB) *Logger {
+	sink := &recordSink{
+		t:           t,
+		outputLevel: logger.DebugLevel,
+	}
+	t.Cleanup(func() {
+		// Records logged after the test has completed are captured, but not
+		// written to the test log, which would panic
+		sink.lock.Lock()
+		sink.done = true
+		sink.lock.Unlock()
+	})
+
+	return &Logger{
+		sink:    sink,
+		logType: logger.LogTypeLog,
+	}
+}
+
+// Records returns a copy of the records captured so far, in order.
+func (l *Logger) Records() []Record {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	res := make([]Record, len(l.sink.records))
+	for i, r := range l.sink.records {
+		r.Fields = maps.Clone(r.Fields)
+		res[i] = r
+	}
+	return res
+}
+
+// RecordsMatching returns the records with the given level whose message
+// contains substr.
+func (l *Logger) RecordsMatching(level logger.LogLevel, substr string) []Record {
+	var res []Record
+	for _, r := range l.Records() {
+		if r.Level == level && strings.Contains(r.Message, substr) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// Reset removes all the captured records.
+func (l *Logger) Reset() {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	l.sink.records = nil
+}
+
+// EnableJSONOutput is a nop, as records are captured in structured form.
+func (l *Logger) EnableJSONOutput(_ bool) {}
+
+// SetAppID sets the app_id field of the records.
+func (l *Logger) SetAppID(id string) {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	l.fields = maps.Clone(l.fields)
+	if l.fields == nil {
+		l.fields = make(map[string]any, 1)
+	}
+	l.fields[fieldAppID] = id
+}
+
+// SetOutputLevel sets the minimum level of the captured records.
+func (l *Logger) SetOutputLevel(outputLevel logger.LogLevel) {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	l.sink.outputLevel = outputLevel
+}
+
+// SetOutput is a nop, as records are captured and written to the test log.
+func (l *Logger) SetOutput(_ io.Writer) {}
+
+// IsOutputLevelEnabled returns true if the logger will capture this level.
+func (l *Logger) IsOutputLevelEnabled(level logger.LogLevel) bool {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	return isLevelEnabled(level, l.sink.outputLevel)
+}
+
+// WithLogType specifies the log type of the records. Default value is logger.LogTypeLog.
+func (l *Logger) WithLogType(logType string) logger.Logger {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	return &Logger{
+		sink:    l.sink,
+		logType: logType,
+		fields:  l.fields,
+	}
+}
+
+// WithFields returns a logger with the added structured fields.
+func (l *Logger) WithFields(fields map[string]any) logger.Logger {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	merged := make(map[string]any, len(l.fields)+len(fields))
+	maps.Copy(merged, l.fields)
+	maps.Copy(merged, fields)
+	return &Logger{
+		sink:    l.sink,
+		logType: l.logType,
+		fields:  merged,
+	}
+}
+
+// Info logs a message at level Info.
+func (l *Logger) Info(args ...interface{}) {
+	l.log(logger.InfoLevel, fmt.Sprint(args...))
+}
+
+// Infof logs a message at level Info.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(logger.InfoLevel, fmt.Sprintf(format, args...))
+}
+
+// Debug logs a message at level Debug.
+func (l *Logger) Debug(args ...interface{}) {
+	l.log(logger.DebugLevel, fmt.Sprint(args...))
+}
+
+// Debugf logs a message at level Debug.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(logger.DebugLevel, fmt.Sprintf(format, args...))
+}
+
+// Warn logs a message at level Warn.
+func (l *Logger) Warn(args ...interface{}) {
+	l.log(logger.WarnLevel, fmt.Sprint(args...))
+}
+
+// Warnf logs a message at level Warn.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(logger.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+// Error logs a message at level Error.
+func (l *Logger) Error(args ...interface{}) {
+	l.log(logger.ErrorLevel, fmt.Sprint(args...))
+}
+
+// Errorf logs a message at level Error.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(logger.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+// Fatal logs a message at level Fatal. The process does not exit.
+func (l *Logger) Fatal(args ...interface{}) {
+	l.log(logger.FatalLevel, fmt.Sprint(args...))
+}
+
+// Fatalf logs a message at level Fatal. The process does not exit.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.log(logger.FatalLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) log(level logger.LogLevel, msg string) {
+	l.sink.lock.Lock()
+	defer l.sink.lock.Unlock()
+
+	if !isLevelEnabled(level, l.sink.outputLevel) {
+		return
+	}
+
+	fields := maps.Clone(l.fields)
+	msg = logger.Redact(msg, fields)
+
+	l.sink.records = append(l.sink.records, Record{
+		Level:   level,
+		Message: msg,
+		Type:    l.logType,
+		Fields:  fields,
+	})
+
+	if !l.sink.done {
+		if len(fields) > 0 {
+			l.sink.t.Logf("[%s] %s %v", level, msg, fields)
+		} else {
+			l.sink.t.Logf("[%s] %s", level, msg)
+		}
+	}
+}
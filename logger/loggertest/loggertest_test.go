@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggertest
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("captures records", func(t *testing.T) {
+		log := New(t)
+
+		log.Info("hello ", "world")
+		log.Warnf("warning %d", 1)
+		log.WithFields(map[string]any{"foo": "bar"}).Errorf("failed: %s", "oops")
+		log.WithLogType(logger.LogTypeRequest).Debug("request")
+		log.Fatal("fatal")
+
+		assert.Equal(t, []Record{
+			{Level: logger.InfoLevel, Message: "hello world", Type: logger.LogTypeLog},
+			{Level: logger.WarnLevel, Message: "warning 1", Type: logger.LogTypeLog},
+			{Level: logger.ErrorLevel, Message: "failed: oops", Type: logger.LogTypeLog, Fields: map[string]any{"foo": "bar"}},
+			{Level: logger.DebugLevel, Message: "request", Type: logger.LogTypeRequest},
+			{Level: logger.FatalLevel, Message: "fatal", Type: logger.LogTypeLog},
+		}, log.Records())
+
+		assert.Len(t, log.RecordsMatching(logger.WarnLevel, "warning"), 1)
+		assert.Empty(t, log.RecordsMatching(logger.InfoLevel, "warning"))
+
+		log.Reset()
+		assert.Empty(t, log.Records())
+	})
+
+	t.Run("fields are merged", func(t *testing.T) {
+		log := New(t)
+		log.SetAppID("myapp")
+
+		child := log.WithFields(map[string]any{"a": 1})
+		child.WithFields(map[string]any{"b": 2, "a": 3}).Info("msg")
+		child.Info("msg")
+
+		records := log.Records()
+		require.Len(t, records, 2)
+		assert.Equal(t, map[string]any{"app_id": "myapp", "a": 3, "b": 2}, records[0].Fields)
+		assert.Equal(t, map[string]any{"app_id": "myapp", "a": 1}, records[1].Fields)
+	})
+
+	t.Run("output level", func(t *testing.T) {
+		log := New(t)
+		assert.True(t, log.IsOutputLevelEnabled(logger.DebugLevel))
+
+		log.SetOutputLevel(logger.WarnLevel)
+		assert.False(t, log.IsOutputLevelEnabled(logger.InfoLevel))
+		assert.True(t, log.IsOutputLevelEnabled(logger.ErrorLevel))
+
+		log.Info("info")
+		log.Warn("warn")
+		log.Error("error")
+
+		records := log.Records()
+		require.Len(t, records, 2)
+		assert.Equal(t, logger.WarnLevel, records[0].Level)
+		assert.Equal(t, logger.ErrorLevel, records[1].Level)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		log := New(t)
+		log.SetOutputLevel(logger.ErrorLevel)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 10 {
+					log.WithFields(map[string]any{"k": "v"}).Error("error")
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, log.Records(), 100)
+	})
+
+	t.Run("records are redacted", func(t *testing.T) {
+		unregister := logger.RegisterRedactionFilter(logger.RedactionFilter{
+			Pattern: regexp.MustCompile(`secret`),
+			Keys:    []string{"password"},
+		})
+		t.Cleanup(unregister)
+
+		l := New(t)
+		l.WithFields(map[string]any{"password": "hunter2"}).Info("a secret")
+		records := l.Records()
+		require.Len(t, records, 1)
+		assert.Equal(t, "a "+logger.RedactedValue, records[0].Message)
+		assert.Equal(t, logger.RedactedValue, records[0].Fields["password"])
+	})
+
+	t.Run("implements Logger", func(t *testing.T) {
+		var _ logger.Logger = New(t)
+	})
+}
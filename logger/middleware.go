@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// AppIDHeader is the header containing the ID of the app that sent the request.
+	AppIDHeader = "dapr-app-id"
+	// TraceparentHeader is the W3C Trace Context header.
+	TraceparentHeader = "traceparent"
+)
+
+// HTTPRequestFieldsFunc returns the request-scoped fields to add to the logger
+// for an HTTP request.
+type HTTPRequestFieldsFunc func(r *http.Request) map[string]any
+
+// GRPCRequestFieldsFunc returns the request-scoped fields to add to the logger
+// for a gRPC request.
+type GRPCRequestFieldsFunc func(ctx context.Context, fullMethod string) map[string]any
+
+// HTTPMiddleware returns a middleware that stores log in the context of each
+// request, with the request-scoped fields returned by fieldsFn added.
+// If fieldsFn is nil, DefaultHTTPRequestFields is used.
+// Handlers can retrieve the logger with FromContextOrDefault.
+func HTTPMiddleware(log Logger, fieldsFn HTTPRequestFieldsFunc) func(http.Handler) http.Handler {
+	if fieldsFn == nil {
+		fieldsFn = DefaultHTTPRequestFields
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithContextFields(NewContext(r.Context(), log), fieldsFn(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC unary server interceptor that stores
+// log in the context of each request, with the request-scoped fields returned
+// by fieldsFn added.
+// If fieldsFn is nil, DefaultGRPCRequestFields is used.
+func UnaryServerInterceptor(log Logger, fieldsFn GRPCRequestFieldsFunc) grpc.UnaryServerInterceptor {
+	if fieldsFn == nil {
+		fieldsFn = DefaultGRPCRequestFields
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = WithContextFields(NewContext(ctx, log), fieldsFn(ctx, info.FullMethod))
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC stream server interceptor that stores
+// log in the context of each stream, with the request-scoped fields returned
+// by fieldsFn added.
+// If fieldsFn is nil, DefaultGRPCRequestFields is used.
+func StreamServerInterceptor(log Logger, fieldsFn GRPCRequestFieldsFunc) grpc.StreamServerInterceptor {
+	if fieldsFn == nil {
+		fieldsFn = DefaultGRPCRequestFields
+	}
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := WithContextFields(NewContext(ss.Context(), log), fieldsFn(ss.Context(), info.FullMethod))
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// DefaultHTTPRequestFields returns the caller's app ID and the trace ID of the
+// request, if present in the headers.
+func DefaultHTTPRequestFields(r *http.Request) map[string]any {
+	return requestFields(r.Header.Get(AppIDHeader), r.Header.Get(TraceparentHeader))
+}
+
+// DefaultGRPCRequestFields returns the caller's app ID and the trace ID of the
+// request, if present in the incoming metadata.
+func DefaultGRPCRequestFields(ctx context.Context, _ string) map[string]any {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	return requestFields(get(AppIDHeader), get(TraceparentHeader))
+}
+
+func requestFields(callerAppID string, traceparent string) map[string]any {
+	fields := make(map[string]any, 2)
+	// The caller's app ID is logged under its own key, so it can't override
+	// the app ID of the logger, which is set with SetAppID.
+	if callerAppID != "" {
+		fields[logFieldCallerAppID] = callerAppID
+	}
+	if traceID := traceIDFromTraceparent(traceparent); traceID != "" {
+		fields[logFieldTraceID] = traceID
+	}
+	return fields
+}
+
+// traceIDFromTraceparent returns the trace ID from a W3C traceparent header
+// value, in the format "version-traceid-parentid-flags".
+func traceIDFromTraceparent(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
+
+// contextServerStream is a grpc.ServerStream with a custom context.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
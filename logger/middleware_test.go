@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newJSONTestLogger(buf *bytes.Buffer) Logger {
+	l := newDaprLogger("dapr.test")
+	l.EnableJSONOutput(true)
+	l.SetOutput(buf)
+	return l
+}
+
+func decodeLogLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var o map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &o))
+	return o
+}
+
+func TestWithContextFields(t *testing.T) {
+	t.Run("no logger in context", func(t *testing.T) {
+		ctx := context.Background()
+		assert.Equal(t, ctx, WithContextFields(ctx, map[string]any{"foo": "bar"}))
+
+		_, ok := FromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("fields are added to the logger in context", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := NewContext(context.Background(), newJSONTestLogger(&buf))
+		ctx = WithContextFields(ctx, map[string]any{"foo": "bar"})
+
+		l, ok := FromContext(ctx)
+		require.True(t, ok)
+		l.Info("hello")
+
+		o := decodeLogLine(t, &buf)
+		assert.Equal(t, "bar", o["foo"])
+		assert.Equal(t, "hello", o[logFieldMessage])
+	})
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	log := newJSONTestLogger(&buf)
+	log.SetAppID("self")
+	h := HTTPMiddleware(log, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContextOrDefault(r.Context()).Info("handling request")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(AppIDHeader, "myapp")
+	req.Header.Set(TraceparentHeader, testTraceparent)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	o := decodeLogLine(t, &buf)
+	assert.Equal(t, "self", o[logFieldAppID])
+	assert.Equal(t, "myapp", o[logFieldCallerAppID])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", o[logFieldTraceID])
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := UnaryServerInterceptor(newJSONTestLogger(&buf), func(ctx context.Context, fullMethod string) map[string]any {
+		fields := DefaultGRPCRequestFields(ctx, fullMethod)
+		fields["method"] = fullMethod
+		return fields
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		AppIDHeader, "myapp",
+		TraceparentHeader, testTraceparent,
+	))
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, func(ctx context.Context, _ any) (any, error) {
+		FromContextOrDefault(ctx).Info("handling request")
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	o := decodeLogLine(t, &buf)
+	assert.Equal(t, "myapp", o[logFieldCallerAppID])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", o[logFieldTraceID])
+	assert.Equal(t, "/test.Service/Method", o["method"])
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := StreamServerInterceptor(newJSONTestLogger(&buf), nil)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AppIDHeader, "myapp"))
+	err := interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(_ any, ss grpc.ServerStream) error {
+		FromContextOrDefault(ss.Context()).Info("handling stream")
+		return nil
+	})
+	require.NoError(t, err)
+
+	o := decodeLogLine(t, &buf)
+	assert.Equal(t, "myapp", o[logFieldCallerAppID])
+	assert.NotContains(t, o, logFieldTraceID)
+}
+
+func TestTraceIDFromTraceparent(t *testing.T) {
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceIDFromTraceparent(testTraceparent))
+	assert.Empty(t, traceIDFromTraceparent(""))
+	assert.Empty(t, traceIDFromTraceparent("00-invalid-00f067aa0ba902b7-01"))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
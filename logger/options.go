@@ -14,13 +14,33 @@ limitations under the License.
 package logger
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
 )
 
 const (
 	defaultJSONOutput  = false
 	defaultOutputLevel = "info"
 	undefinedAppID     = ""
+
+	defaultOutputFileMaxSizeMB = 100
+)
+
+var (
+	// fileOutputs contains the file writers opened by ApplyOptionsToLoggers,
+	// which are closed when options are applied again.
+	fileOutputs     []io.Closer
+	fileOutputsLock sync.Mutex
+
+	// sinksApplied is true if sinks were set by ApplyOptionsToLoggers, so
+	// they are removed when options are applied again.
+	// Protected by fileOutputsLock.
+	sinksApplied bool
 )
 
 // Options defines the sets of options for Dapr logging.
@@ -31,8 +51,54 @@ type Options struct {
 	// JSONFormatEnabled is the flag to enable JSON formatted log
 	JSONFormatEnabled bool
 
+	// JSONSchema customizes the field names and the schema version of the
+	// JSON formatted log.
+	JSONSchema JSONSchema
+
 	// OutputLevel is the level of logging
 	OutputLevel string
+
+	// DeduplicationWindow enables the deduplication of identical consecutive
+	// messages within the window, which are collapsed into a single record
+	// with the repeat count. 0 disables deduplication.
+	DeduplicationWindow time.Duration
+
+	// OutputFile is the path of the file logs are written to instead of stdout.
+	// If empty, logs are written to stdout.
+	OutputFile string
+
+	// ErrorOutputFile is the path of a file logs at level error and above are
+	// additionally written to.
+	// If empty, error logs are only written to the main output.
+	ErrorOutputFile string
+
+	// OutputFileMaxSizeMB is the maximum size in megabytes of a log file
+	// before it is rotated. 0 disables size-based rotation.
+	OutputFileMaxSizeMB int
+
+	// OutputFileMaxAge is the maximum age of a log file before it is rotated.
+	// 0 disables age-based rotation.
+	OutputFileMaxAge time.Duration
+
+	// OutputFileMaxBackups is the maximum number of rotated log files to retain.
+	// 0 retains all rotated files.
+	OutputFileMaxBackups int
+
+	// OutputBufferSize is the number of log records buffered in memory and
+	// written to the outputs asynchronously.
+	// 0 disables buffering, so logs are written synchronously.
+	OutputBufferSize int
+
+	// OutputBufferOverflow is the policy applied when the buffer of log
+	// records is full.
+	OutputBufferOverflow OverflowPolicy
+
+	// Sinks route the logs to different destinations by level, for example
+	// with ConsoleSinks. If set, logs are written to the sinks whose range
+	// includes their level instead of stdout, and OutputFile must be empty.
+	// Logs are still written to ErrorOutputFile, if set, and the sinks are
+	// buffered if OutputBufferSize is set.
+	Sinks []Sink
 }
 
 // SetOutputLevel sets the log output level.
@@ -68,6 +134,18 @@ func (o *Options) AttachCmdFlags(
 			defaultJSONOutput,
 			"print log as JSON (default false)")
 	}
+	if stringVar != nil {
+		stringVar(
+			&o.OutputFile,
+			"log-file",
+			"",
+			"Path of the file logs are written to, with rotation (default stdout)")
+		stringVar(
+			&o.ErrorOutputFile,
+			"log-error-file",
+			"",
+			"Path of a file logs at level error and above are additionally written to")
+	}
 }
 
 // DefaultOptions returns default values of Options.
@@ -76,6 +154,8 @@ func DefaultOptions() Options {
 		JSONFormatEnabled: defaultJSONOutput,
 		appID:             undefinedAppID,
 		OutputLevel:       defaultOutputLevel,
+
+		OutputFileMaxSizeMB: defaultOutputFileMaxSizeMB,
 	}
 }
 
@@ -85,7 +165,11 @@ func ApplyOptionsToLoggers(options *Options) error {
 
 	// Apply formatting options first
 	for _, v := range internalLoggers {
+		if sl, ok := v.(jsonSchemaSetter); ok {
+			sl.SetJSONSchema(options.JSONSchema)
+		}
 		v.EnableJSONOutput(options.JSONFormatEnabled)
+		SetDeduplicationWindow(v, options.DeduplicationWindow)
 
 		if options.appID != undefinedAppID {
 			v.SetAppID(options.appID)
@@ -97,8 +181,144 @@ func ApplyOptionsToLoggers(options *Options) error {
 		return fmt.Errorf("invalid value for --log-level: %s", options.OutputLevel)
 	}
 
+	if len(options.Sinks) > 0 && options.OutputFile != "" {
+		return errors.New("log sinks can't be used with --log-file")
+	}
+	for _, s := range options.Sinks {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("invalid log sink: %w", err)
+		}
+	}
+
+	if err := applyFileOutputs(options, internalLoggers); err != nil {
+		return err
+	}
+
 	for _, v := range internalLoggers {
 		v.SetOutputLevel(daprLogLevel)
 	}
 	return nil
 }
+
+// applyFileOutputs opens the log files configured in options, wrapping them
+// in buffered writers if enabled, and sets them as the outputs of all loggers,
+// closing the outputs opened by a previous call.
+func applyFileOutputs(options *Options, loggers map[string]Logger) error {
+	fileOutputsLock.Lock()
+	defer fileOutputsLock.Unlock()
+
+	if options.OutputFile == "" && options.ErrorOutputFile == "" && options.OutputBufferSize <= 0 && len(fileOutputs) == 0 &&
+		len(options.Sinks) == 0 && !sinksApplied {
+		return nil
+	}
+
+	var (
+		out    io.Writer = os.Stdout
+		errOut io.Writer
+		sinks  []Sink
+		opened []io.Closer
+	)
+	if len(options.Sinks) > 0 {
+		out = io.Discard
+		sinks = slices.Clone(options.Sinks)
+	}
+
+	newWriter := func(filename string) (*RotatingFileWriter, error) {
+		w, err := NewRotatingFileWriter(RotatingFileOptions{
+			Filename:   filename,
+			MaxSize:    int64(options.OutputFileMaxSizeMB) * 1024 * 1024,
+			MaxAge:     options.OutputFileMaxAge,
+			MaxBackups: options.OutputFileMaxBackups,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opened = append(opened, w)
+		return w, nil
+	}
+
+	closeOpened := func() {
+		for i := len(opened) - 1; i >= 0; i-- {
+			opened[i].Close()
+		}
+	}
+
+	if options.OutputFile != "" {
+		w, err := newWriter(options.OutputFile)
+		if err != nil {
+			return fmt.Errorf("invalid value for --log-file: %w", err)
+		}
+		out = w
+	}
+
+	if options.ErrorOutputFile != "" {
+		w, err := newWriter(options.ErrorOutputFile)
+		if err != nil {
+			closeOpened()
+			return fmt.Errorf("invalid value for --log-error-file: %w", err)
+		}
+		errOut = w
+	}
+
+	// Buffered writers are appended after the files they write to, so they
+	// are closed (and flushed) first
+	if options.OutputBufferSize > 0 {
+		newBuffered := func(dst io.Writer) io.Writer {
+			w := NewBufferedWriter(dst, BufferedWriterOptions{
+				Size:     options.OutputBufferSize,
+				Overflow: options.OutputBufferOverflow,
+			})
+			opened = append(opened, w)
+			return w
+		}
+		if out != io.Discard {
+			out = newBuffered(out)
+		}
+		if errOut != nil {
+			errOut = newBuffered(errOut)
+		}
+		for i := range sinks {
+			sinks[i].Writer = newBuffered(sinks[i].Writer)
+		}
+	}
+
+	for _, v := range loggers {
+		v.SetOutput(out)
+		if el, ok := v.(errorOutputSetter); ok {
+			el.SetErrorOutput(errOut)
+		}
+		if sl, ok := v.(sinksSetter); ok {
+			sl.SetSinks(sinks)
+		}
+	}
+	sinksApplied = len(sinks) > 0
+
+	errs := make([]error, 0, len(fileOutputs))
+	for i := len(fileOutputs) - 1; i >= 0; i-- {
+		errs = append(errs, fileOutputs[i].Close())
+	}
+	fileOutputs = opened
+
+	return errors.Join(errs...)
+}
+
+// FlushOutputs blocks until the buffered log records, if any, have been
+// written to the outputs set by ApplyOptionsToLoggers.
+func FlushOutputs() error {
+	fileOutputsLock.Lock()
+	defer fileOutputsLock.Unlock()
+
+	var errs []error
+	for _, c := range fileOutputs {
+		if w, ok := c.(*BufferedWriter); ok {
+			errs = append(errs, w.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// errorOutputSetter is implemented by loggers which support a separate
+// destination for error logs.
+type errorOutputSetter interface {
+	SetErrorOutput(dst io.Writer)
+}
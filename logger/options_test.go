@@ -14,7 +14,10 @@ limitations under the License.
 package logger
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -63,10 +66,16 @@ func TestOptions(t *testing.T) {
 
 func TestApplyOptionsToLoggers(t *testing.T) {
 	testOptions := Options{
-		JSONFormatEnabled: true,
-		appID:             "dapr-app",
-		OutputLevel:       "debug",
+		JSONFormatEnabled:   true,
+		appID:               "dapr-app",
+		OutputLevel:         "debug",
+		DeduplicationWindow: time.Minute,
 	}
+	t.Cleanup(func() {
+		for _, l := range getLoggers() {
+			SetDeduplicationWindow(l, 0)
+		}
+	})
 
 	// Create two loggers
 	testLoggers := []Logger{
@@ -90,5 +99,56 @@ func TestApplyOptionsToLoggers(t *testing.T) {
 			t,
 			toLogrusLevel(DebugLevel),
 			(l.(*daprLogger)).logger.Logger.GetLevel())
+		assert.True(t, (l.(*daprLogger)).dedup.enabled())
+	}
+}
+
+func TestApplyOptionsToLoggersFileOutput(t *testing.T) {
+	dir := t.TempDir()
+	testOptions := Options{
+		OutputLevel:     "info",
+		OutputFile:      filepath.Join(dir, "dapr.log"),
+		ErrorOutputFile: filepath.Join(dir, "dapr-error.log"),
 	}
+
+	l := NewLogger("testFileLogger")
+	require.NoError(t, ApplyOptionsToLoggers(&testOptions))
+	t.Cleanup(func() {
+		require.NoError(t, ApplyOptionsToLoggers(&Options{OutputLevel: "info"}))
+	})
+
+	l.Info("info message")
+	l.Error("error message")
+
+	b, err := os.ReadFile(testOptions.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "info message")
+	assert.Contains(t, string(b), "error message")
+
+	b, err = os.ReadFile(testOptions.ErrorOutputFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "info message")
+	assert.Contains(t, string(b), "error message")
+}
+
+func TestApplyOptionsToLoggersBufferedOutput(t *testing.T) {
+	dir := t.TempDir()
+	testOptions := Options{
+		OutputLevel:      "info",
+		OutputFile:       filepath.Join(dir, "dapr.log"),
+		OutputBufferSize: 16,
+	}
+
+	l := NewLogger("testBufferedLogger")
+	require.NoError(t, ApplyOptionsToLoggers(&testOptions))
+	t.Cleanup(func() {
+		require.NoError(t, ApplyOptionsToLoggers(&Options{OutputLevel: "info"}))
+	})
+
+	l.Info("buffered message")
+	require.NoError(t, FlushOutputs())
+
+	b, err := os.ReadFile(testOptions.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "buffered message")
 }
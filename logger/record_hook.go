@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordHookBufferSize is the number of records which can be queued for the
+// RecordHook before new records are dropped.
+const recordHookBufferSize = 1024
+
+// RecordHook is notified of every record written by the loggers, for example
+// to export counters of the warnings and errors per logger into a metrics
+// pipeline. It is not notified of records below the output level of the
+// logger.
+// Records are delivered to the hook in order, from a background goroutine, so
+// a slow hook never blocks logging: if the hook falls behind, new records are
+// dropped and counted by DroppedRecords.
+// The hook must not log with the loggers of this package.
+type RecordHook interface {
+	// OnRecord is invoked for each record with its level, and the name of the
+	// logger that wrote it.
+	OnRecord(level LogLevel, scope string)
+}
+
+// RecordHookFunc is a function which implements RecordHook.
+type RecordHookFunc func(level LogLevel, scope string)
+
+// OnRecord implements RecordHook.
+func (f RecordHookFunc) OnRecord(level LogLevel, scope string) {
+	f(level, scope)
+}
+
+// logRecord is a record queued for the RecordHook.
+type logRecord struct {
+	level LogLevel
+	scope string
+}
+
+// recordDispatcher delivers the queued records to a RecordHook.
+type recordDispatcher struct {
+	hook   RecordHook
+	queue  chan logRecord
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+var (
+	recordHookLock sync.Mutex
+	// recordHookDispatcher is nil when no hook is set, which is the default,
+	// so logging doesn't incur any overhead
+	recordHookDispatcher atomic.Pointer[recordDispatcher]
+	droppedRecords       atomic.Uint64
+)
+
+// SetRecordHook sets the hook notified of the records written by all loggers,
+// replacing the previous one. Passing nil removes the hook, which is the
+// default.
+// The records queued for the previous hook are delivered to it before this
+// function returns.
+func SetRecordHook(hook RecordHook) {
+	recordHookLock.Lock()
+	defer recordHookLock.Unlock()
+
+	var d *recordDispatcher
+	if hook != nil {
+		d = &recordDispatcher{
+			hook:   hook,
+			queue:  make(chan logRecord, recordHookBufferSize),
+			stopCh: make(chan struct{}),
+			doneCh: make(chan struct{}),
+		}
+		go d.run()
+	}
+
+	if old := recordHookDispatcher.Swap(d); old != nil {
+		close(old.stopCh)
+		<-old.doneCh
+	}
+}
+
+// DroppedRecords returns the number of records which were not delivered to
+// the RecordHook because it fell behind.
+func DroppedRecords() uint64 {
+	return droppedRecords.Load()
+}
+
+func (d *recordDispatcher) run() {
+	defer close(d.doneCh)
+
+	for {
+		select {
+		case r := <-d.queue:
+			d.hook.OnRecord(r.level, r.scope)
+		case <-d.stopCh:
+			// Deliver the records which are still queued
+			for {
+				select {
+				case r := <-d.queue:
+					d.hook.OnRecord(r.level, r.scope)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// recordHook is a logrus hook which queues the records for the RecordHook.
+type recordHook struct{}
+
+// Levels implements logrus.Hook.
+func (recordHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (recordHook) Fire(entry *logrus.Entry) error {
+	d := recordHookDispatcher.Load()
+	if d == nil {
+		return nil
+	}
+
+	scope, _ := entry.Data[logFieldScope].(string)
+	select {
+	case d.queue <- logRecord{level: fromLogrusLevel(entry.Level), scope: scope}:
+	default:
+		droppedRecords.Add(1)
+	}
+	return nil
+}
+
+// fromLogrusLevel converts a logrus level to a LogLevel.
+func fromLogrusLevel(level logrus.Level) LogLevel {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return DebugLevel
+	case logrus.InfoLevel:
+		return InfoLevel
+	case logrus.WarnLevel:
+		return WarnLevel
+	case logrus.ErrorLevel:
+		return ErrorLevel
+	default:
+		return FatalLevel
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordCounter struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func (c *recordCounter) OnRecord(level LogLevel, scope string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.counts == nil {
+		c.counts = map[string]int{}
+	}
+	c.counts[scope+":"+string(level)]++
+}
+
+func TestRecordHook(t *testing.T) {
+	t.Cleanup(func() { SetRecordHook(nil) })
+
+	t.Run("records are counted per level and logger", func(t *testing.T) {
+		l1 := newDaprLogger("logger1")
+		l1.SetOutput(io.Discard)
+		l2 := newDaprLogger("logger2")
+		l2.SetOutput(io.Discard)
+
+		counter := &recordCounter{}
+		SetRecordHook(counter)
+
+		l1.Info("info")
+		l1.Warn("warn")
+		l1.Warnf("warn %d", 2)
+		l1.Debug("debug is not enabled")
+		l2.Errorf("error %d", 1)
+		l2.WithFields(map[string]any{"foo": "bar"}).Error("error with fields")
+
+		// Removing the hook delivers the queued records
+		SetRecordHook(nil)
+		assert.Equal(t, map[string]int{
+			"logger1:info":  1,
+			"logger1:warn":  2,
+			"logger2:error": 2,
+		}, counter.counts)
+
+		// Records are not delivered once the hook is removed
+		l1.Warn("warn")
+		assert.Equal(t, 2, counter.counts["logger1:warn"])
+	})
+
+	t.Run("hook function", func(t *testing.T) {
+		l := newDaprLogger(fakeLoggerName)
+		l.SetOutput(io.Discard)
+		l.SetOutputLevel(DebugLevel)
+
+		var levels []LogLevel
+		SetRecordHook(RecordHookFunc(func(level LogLevel, scope string) {
+			assert.Equal(t, fakeLoggerName, scope)
+			levels = append(levels, level)
+		}))
+
+		l.Debug("debug")
+		l.Info("info")
+		l.Error("error")
+
+		SetRecordHook(nil)
+		assert.Equal(t, []LogLevel{DebugLevel, InfoLevel, ErrorLevel}, levels)
+	})
+
+	t.Run("slow hook does not block logging", func(t *testing.T) {
+		l := newDaprLogger(fakeLoggerName)
+		l.SetOutput(io.Discard)
+
+		blockCh := make(chan struct{})
+		var delivered int
+		SetRecordHook(RecordHookFunc(func(LogLevel, string) {
+			<-blockCh
+			delivered++
+		}))
+
+		dropped := DroppedRecords()
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			for range recordHookBufferSize + 10 {
+				l.Warn("warn")
+			}
+		}()
+		select {
+		case <-doneCh:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "logging was blocked by the hook")
+		}
+		assert.GreaterOrEqual(t, DroppedRecords()-dropped, uint64(9))
+
+		close(blockCh)
+		SetRecordHook(nil)
+		assert.Equal(t, recordHookBufferSize+10, delivered+int(DroppedRecords()-dropped))
+	})
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue is the value that replaces redacted data in logs.
+const RedactedValue = "[REDACTED]"
+
+// RedactionFilter describes sensitive data which is redacted from log messages
+// and structured fields before they are written.
+type RedactionFilter struct {
+	// Pattern matches sensitive data in messages and in the values of string
+	// and error fields.
+	// If the pattern contains capturing groups, only the text matched by the
+	// groups is redacted, so for example `(?i)bearer\s+(\S+)` keeps the
+	// "Bearer" prefix; otherwise, the entire match is redacted.
+	Pattern *regexp.Regexp
+
+	// Keys are the names of structured fields whose values are always
+	// redacted, compared case-insensitively.
+	Keys []string
+}
+
+type registeredRedactionFilter struct {
+	id     uint64
+	filter RedactionFilter
+}
+
+// redactor applies a set of redaction filters.
+type redactor struct {
+	patterns []*regexp.Regexp
+	keys     map[string]struct{}
+}
+
+var (
+	redactionLock     sync.Mutex
+	redactionFilters  []registeredRedactionFilter
+	redactionFilterID uint64
+	activeRedactor    atomic.Pointer[redactor]
+)
+
+// RegisterRedactionFilter registers a filter that is applied, for every
+// logger, to the messages and structured fields before they are written.
+// It returns a function that unregisters the filter.
+// When no filter is registered, logging has no additional overhead.
+func RegisterRedactionFilter(filter RedactionFilter) (unregister func()) {
+	redactionLock.Lock()
+	defer redactionLock.Unlock()
+
+	redactionFilterID++
+	id := redactionFilterID
+	redactionFilters = append(redactionFilters, registeredRedactionFilter{id: id, filter: filter})
+	updateRedactor()
+
+	return func() {
+		redactionLock.Lock()
+		defer redactionLock.Unlock()
+
+		for i, f := range redactionFilters {
+			if f.id == id {
+				redactionFilters = append(redactionFilters[:i:i], redactionFilters[i+1:]...)
+				updateRedactor()
+				return
+			}
+		}
+	}
+}
+
+// updateRedactor replaces the active redactor with one that applies the
+// registered filters, or with nil if there are none.
+// This must be invoked while the caller has a lock.
+func updateRedactor() {
+	if len(redactionFilters) == 0 {
+		activeRedactor.Store(nil)
+		return
+	}
+
+	r := &redactor{
+		keys: make(map[string]struct{}),
+	}
+	for _, f := range redactionFilters {
+		if f.filter.Pattern != nil {
+			r.patterns = append(r.patterns, f.filter.Pattern)
+		}
+		for _, k := range f.filter.Keys {
+			r.keys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+	activeRedactor.Store(r)
+}
+
+// Redact applies the registered redaction filters to a log message, which is
+// returned, and to its fields, which are modified in place.
+// It's meant for Logger implementations outside of this package.
+func Redact(msg string, fields map[string]any) string {
+	r := activeRedactor.Load()
+	if r == nil {
+		return msg
+	}
+	r.redactFields(fields)
+	return r.redactString(msg)
+}
+
+// redactString redacts the sensitive data matched by the patterns in s.
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, RedactedValue)
+			continue
+		}
+
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var (
+			b    strings.Builder
+			last int
+		)
+		for _, m := range matches {
+			// Indexes of the groups start after the indexes of the entire match
+			for g := 2; g+1 < len(m); g += 2 {
+				// Skip groups which didn't participate in the match, or which are
+				// nested in a previous group
+				if m[g] < last {
+					continue
+				}
+				b.WriteString(s[last:m[g]])
+				b.WriteString(RedactedValue)
+				last = m[g+1]
+			}
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// redactFields redacts the values of the sensitive fields, and the sensitive
+// data in string and error values, in place.
+func (r *redactor) redactFields(fields map[string]any) {
+	for k, v := range fields {
+		if _, ok := r.keys[strings.ToLower(k)]; ok {
+			fields[k] = RedactedValue
+			continue
+		}
+
+		switch val := v.(type) {
+		case string:
+			fields[k] = r.redactString(val)
+		case error:
+			if redacted := r.redactString(val.Error()); redacted != val.Error() {
+				fields[k] = redacted
+			}
+		}
+	}
+}
+
+// redactionHook is a logrus hook which applies the registered redaction
+// filters to the entries.
+// It must be registered before the hooks which write the entries.
+type redactionHook struct{}
+
+// Levels implements logrus.Hook.
+func (redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (redactionHook) Fire(entry *logrus.Entry) error {
+	r := activeRedactor.Load()
+	if r == nil {
+		return nil
+	}
+
+	// The entry's fields are a copy of the logger's, so they can be modified
+	entry.Message = r.redactString(entry.Message)
+	r.redactFields(entry.Data)
+	return nil
+}
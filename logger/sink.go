@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is a destination for the logs within a range of levels.
+type Sink struct {
+	// Writer is the destination of the logs.
+	Writer io.Writer
+
+	// MinLevel is the lowest level written to the sink.
+	// Defaults to DebugLevel.
+	MinLevel LogLevel
+
+	// MaxLevel is the highest level written to the sink.
+	// Defaults to FatalLevel.
+	MaxLevel LogLevel
+
+	// Color enables colorized output. It's ignored for JSON formatted logs.
+	Color bool
+}
+
+// ConsoleSinks returns sinks which write logs at level debug and info to
+// stdout, and logs at level warn and above to stderr.
+func ConsoleSinks(color bool) []Sink {
+	return []Sink{
+		{Writer: os.Stdout, MaxLevel: InfoLevel, Color: color},
+		{Writer: os.Stderr, MinLevel: WarnLevel, Color: color},
+	}
+}
+
+// SetSinks sets destinations for the logs of the logger within ranges of
+// levels, in addition to its output; to only write to the sinks, set the
+// output to io.Discard. Passing no sinks removes them.
+// Sinks apply to the loggers derived from the logger with WithFields and
+// WithLogType too.
+// It returns false if the logger doesn't support sinks.
+func SetSinks(l Logger, sinks []Sink) (bool, error) {
+	for _, s := range sinks {
+		if err := s.validate(); err != nil {
+			return false, err
+		}
+	}
+	sl, ok := l.(sinksSetter)
+	if ok {
+		sl.SetSinks(sinks)
+	}
+	return ok, nil
+}
+
+// levels returns the range of logrus levels of the sink.
+// Note that logrus levels are in descending order of severity.
+func (s Sink) levels() (minLevel logrus.Level, maxLevel logrus.Level) {
+	minLevel, maxLevel = logrus.DebugLevel, logrus.PanicLevel
+	if s.MinLevel != "" {
+		minLevel = toLogrusLevel(s.MinLevel)
+	}
+	if s.MaxLevel != "" && s.MaxLevel != FatalLevel {
+		maxLevel = toLogrusLevel(s.MaxLevel)
+	}
+	return minLevel, maxLevel
+}
+
+// validate returns an error if the sink is not valid.
+func (s Sink) validate() error {
+	if s.Writer == nil {
+		return fmt.Errorf("sink has no writer")
+	}
+	for _, lvl := range []LogLevel{s.MinLevel, s.MaxLevel} {
+		if lvl != "" && toLogLevel(string(lvl)) == UndefinedLevel {
+			return fmt.Errorf("undefined sink log level: %s", lvl)
+		}
+	}
+	if minLevel, maxLevel := s.levels(); minLevel < maxLevel {
+		return fmt.Errorf("sink min level %s is higher than max level %s", s.MinLevel, s.MaxLevel)
+	}
+	return nil
+}
+
+// sinksSetter is implemented by loggers which support sinks.
+type sinksSetter interface {
+	SetSinks(sinks []Sink)
+}
+
+// sinkHook is a logrus hook which writes entries to the sinks whose range
+// includes their level.
+type sinkHook struct {
+	lock  sync.RWMutex
+	sinks []sinkOutput
+}
+
+// sinkOutput is a sink with the formatter used for it.
+type sinkOutput struct {
+	writer    io.Writer
+	minLevel  logrus.Level
+	maxLevel  logrus.Level
+	color     bool
+	formatter logrus.Formatter
+}
+
+func (h *sinkHook) setSinks(sinks []Sink, formatter logrus.Formatter) {
+	outputs := make([]sinkOutput, len(sinks))
+	for i, s := range sinks {
+		outputs[i] = sinkOutput{
+			writer: s.Writer,
+			color:  s.Color,
+		}
+		outputs[i].minLevel, outputs[i].maxLevel = s.levels()
+		outputs[i].formatter = sinkFormatter(formatter, s.Color)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.sinks = outputs
+}
+
+// setFormatter updates the formatters of the sinks after the formatter of
+// the logger changed.
+func (h *sinkHook) setFormatter(formatter logrus.Formatter) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for i := range h.sinks {
+		h.sinks[i].formatter = sinkFormatter(formatter, h.sinks[i].color)
+	}
+}
+
+// sinkFormatter returns the formatter used for a sink: text formatters are
+// copied to set colorization, while the others are used as they are.
+func sinkFormatter(formatter logrus.Formatter, color bool) logrus.Formatter {
+	tf, ok := formatter.(*logrus.TextFormatter)
+	if !ok {
+		return formatter
+	}
+	return &logrus.TextFormatter{ //nolint: exhaustruct
+		TimestampFormat: tf.TimestampFormat,
+		FieldMap:        tf.FieldMap,
+		ForceColors:     color,
+		DisableColors:   !color,
+	}
+}
+
+// Levels implements logrus.Hook.
+func (h *sinkHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (h *sinkHook) Fire(entry *logrus.Entry) error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	for _, s := range h.sinks {
+		if entry.Level > s.minLevel || entry.Level < s.maxLevel {
+			continue
+		}
+		b, err := s.formatter.Format(entry)
+		if err != nil {
+			return err
+		}
+		if _, err = s.writer.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
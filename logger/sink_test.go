@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinks(t *testing.T) {
+	t.Run("logs are routed by level", func(t *testing.T) {
+		var low, high bytes.Buffer
+		l := newDaprLogger("test")
+		l.SetOutput(io.Discard)
+		l.SetOutputLevel(DebugLevel)
+		ok, err := SetSinks(l, []Sink{
+			{Writer: &low, MaxLevel: InfoLevel},
+			{Writer: &high, MinLevel: WarnLevel},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		l.Debug("debug message")
+		l.Info("info message")
+		l.Warn("warn message")
+		l.Error("error message")
+
+		assert.Contains(t, low.String(), "debug message")
+		assert.Contains(t, low.String(), "info message")
+		assert.NotContains(t, low.String(), "warn message")
+		assert.NotContains(t, low.String(), "error message")
+
+		assert.NotContains(t, high.String(), "debug message")
+		assert.NotContains(t, high.String(), "info message")
+		assert.Contains(t, high.String(), "warn message")
+		assert.Contains(t, high.String(), "error message")
+	})
+
+	t.Run("overlapping sinks both receive the logs", func(t *testing.T) {
+		var all, errs bytes.Buffer
+		l := newDaprLogger("test")
+		l.SetOutput(io.Discard)
+		l.SetSinks([]Sink{
+			{Writer: &all},
+			{Writer: &errs, MinLevel: ErrorLevel},
+		})
+
+		l.Info("info message")
+		l.Error("error message")
+
+		assert.Contains(t, all.String(), "info message")
+		assert.Contains(t, all.String(), "error message")
+		assert.NotContains(t, errs.String(), "info message")
+		assert.Contains(t, errs.String(), "error message")
+	})
+
+	t.Run("colors are set per sink", func(t *testing.T) {
+		var color, plain bytes.Buffer
+		l := newDaprLogger("test")
+		l.SetOutput(io.Discard)
+		l.EnableJSONOutput(false)
+		l.SetSinks([]Sink{
+			{Writer: &color, Color: true},
+			{Writer: &plain},
+		})
+
+		l.Info("info message")
+
+		assert.Contains(t, color.String(), "\x1b[")
+		assert.NotContains(t, plain.String(), "\x1b[")
+		assert.Contains(t, plain.String(), "msg=\"info message\"")
+	})
+
+	t.Run("JSON output applies to the sinks", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := newDaprLogger("test")
+		l.SetOutput(io.Discard)
+		l.SetSinks([]Sink{{Writer: &buf, Color: true}})
+		l.EnableJSONOutput(true)
+
+		l.Info("info message")
+
+		var o map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &o))
+		assert.Equal(t, "info message", o[logFieldMessage])
+		assert.Equal(t, "info", o[logFieldLevel])
+	})
+
+	t.Run("derived loggers share the sinks", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := newDaprLogger("test")
+		l.SetOutput(io.Discard)
+		derived := l.WithLogType(LogTypeRequest).WithFields(map[string]any{"key": "value"})
+		l.SetSinks([]Sink{{Writer: &buf}})
+
+		derived.Info("derived message")
+		assert.Contains(t, buf.String(), "derived message")
+
+		l.SetSinks(nil)
+		buf.Reset()
+		derived.Info("derived message")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("invalid sinks", func(t *testing.T) {
+		l := newDaprLogger("test")
+		tests := map[string]Sink{
+			"no writer":       {},
+			"undefined level": {Writer: io.Discard, MinLevel: "verbose"},
+			"min above max":   {Writer: io.Discard, MinLevel: ErrorLevel, MaxLevel: InfoLevel},
+		}
+		for name, s := range tests {
+			t.Run(name, func(t *testing.T) {
+				ok, err := SetSinks(l, []Sink{s})
+				require.Error(t, err)
+				assert.False(t, ok)
+			})
+		}
+	})
+
+	t.Run("console sinks", func(t *testing.T) {
+		sinks := ConsoleSinks(true)
+		require.Len(t, sinks, 2)
+		assert.Equal(t, os.Stdout, sinks[0].Writer)
+		assert.Equal(t, os.Stderr, sinks[1].Writer)
+		for _, s := range sinks {
+			require.NoError(t, s.validate())
+			assert.True(t, s.Color)
+		}
+	})
+}
+
+func TestApplyOptionsToLoggersSinks(t *testing.T) {
+	var low, high bytes.Buffer
+	testOptions := Options{
+		OutputLevel: "debug",
+		Sinks: []Sink{
+			{Writer: &low, MaxLevel: InfoLevel},
+			{Writer: &high, MinLevel: WarnLevel},
+		},
+	}
+
+	l := NewLogger("testSinksLogger")
+	require.NoError(t, ApplyOptionsToLoggers(&testOptions))
+	t.Cleanup(func() {
+		require.NoError(t, ApplyOptionsToLoggers(&Options{OutputLevel: "info"}))
+	})
+
+	l.Info("info message")
+	l.Error("error message")
+
+	assert.Contains(t, low.String(), "info message")
+	assert.NotContains(t, low.String(), "error message")
+	assert.NotContains(t, high.String(), "info message")
+	assert.Contains(t, high.String(), "error message")
+
+	t.Run("sinks can't be used with an output file", func(t *testing.T) {
+		err := ApplyOptionsToLoggers(&Options{
+			OutputLevel: "info",
+			OutputFile:  "dapr.log",
+			Sinks:       testOptions.Sinks,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid sinks are rejected", func(t *testing.T) {
+		err := ApplyOptionsToLoggers(&Options{
+			OutputLevel: "info",
+			Sinks:       []Sink{{Writer: &low, MinLevel: FatalLevel, MaxLevel: DebugLevel}},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("sinks are removed when options are applied again", func(t *testing.T) {
+		require.NoError(t, ApplyOptionsToLoggers(&Options{OutputLevel: "info"}))
+		low.Reset()
+		high.Reset()
+
+		l.Info("info message")
+		l.Error("error message")
+
+		assert.Empty(t, low.String())
+		assert.Empty(t, high.String())
+		assert.Empty(t, l.(*daprLogger).sinks.sinks)
+	})
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseConnectionString parses a connection string made of "key=value" pairs separated by semicolons, and decodes it into a struct like DecodeMetadata, including support for aliases.
+// See SplitConnectionString for the syntax of the connection string.
+func ParseConnectionString(cs string, result any) error {
+	props, err := SplitConnectionString(cs)
+	if err != nil {
+		return err
+	}
+
+	return decodeMetadataMap(props, result, nil, nil)
+}
+
+// SplitConnectionString parses a connection string made of "key=value" pairs separated by semicolons, returning a map of all pairs.
+// The syntax follows the rules of ADO.NET connection strings:
+//
+//   - Whitespace around keys and unquoted values is ignored, and so are empty pairs.
+//   - Values can be enclosed in single or double quotes to include semicolons or leading/trailing whitespace.
+//     Inside a quoted value, the quote character is escaped by doubling it (e.g. "a""b" is a"b).
+//   - An equal sign in a key is escaped by doubling it (e.g. "a==b=c" is the key "a=b").
+//
+// Keys are case-insensitive, and it's an error for a key to appear more than once.
+func SplitConnectionString(cs string) (map[string]string, error) {
+	res := map[string]string{}
+	seen := map[string]struct{}{}
+
+	i, n := 0, len(cs)
+	for i < n {
+		// Skip empty pairs and whitespace before the key
+		for i < n && (cs[i] == ';' || isConnectionStringSpace(cs[i])) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+
+		// Read the key until the first "=" that is not doubled
+		var key strings.Builder
+		for {
+			if i >= n || cs[i] == ';' {
+				return nil, fmt.Errorf("invalid connection string: missing '=' after key %q", strings.TrimSpace(key.String()))
+			}
+			if cs[i] == '=' {
+				if i+1 < n && cs[i+1] == '=' {
+					key.WriteByte('=')
+					i += 2
+					continue
+				}
+				i++
+				break
+			}
+			key.WriteByte(cs[i])
+			i++
+		}
+		k := strings.TrimSpace(key.String())
+		if k == "" {
+			return nil, errors.New("invalid connection string: empty key")
+		}
+
+		// Skip whitespace before the value
+		for i < n && isConnectionStringSpace(cs[i]) {
+			i++
+		}
+
+		var val string
+		if i < n && (cs[i] == '"' || cs[i] == '\'') {
+			// Quoted value
+			quote := cs[i]
+			i++
+			var b strings.Builder
+			closed := false
+			for i < n {
+				if cs[i] == quote {
+					if i+1 < n && cs[i+1] == quote {
+						b.WriteByte(quote)
+						i += 2
+						continue
+					}
+					i++
+					closed = true
+					break
+				}
+				b.WriteByte(cs[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("invalid connection string: unterminated quoted value for key %q", k)
+			}
+
+			// Only whitespace is allowed until the next separator
+			for i < n && isConnectionStringSpace(cs[i]) {
+				i++
+			}
+			if i < n && cs[i] != ';' {
+				return nil, fmt.Errorf("invalid connection string: unexpected character after quoted value for key %q", k)
+			}
+			val = b.String()
+		} else {
+			start := i
+			for i < n && cs[i] != ';' {
+				i++
+			}
+			val = strings.TrimSpace(cs[start:i])
+		}
+
+		lk := strings.ToLower(k)
+		if _, ok := seen[lk]; ok {
+			return nil, fmt.Errorf("invalid connection string: key %q is duplicate", k)
+		}
+		seen[lk] = struct{}{}
+		res[k] = val
+	}
+
+	return res, nil
+}
+
+func isConnectionStringSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitConnectionString(t *testing.T) {
+	tests := []struct {
+		name    string
+		cs      string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "empty",
+			cs:   "",
+			want: map[string]string{},
+		},
+		{
+			name: "simple pairs",
+			cs:   "Server=myserver;Database=mydb",
+			want: map[string]string{"Server": "myserver", "Database": "mydb"},
+		},
+		{
+			name: "whitespace and empty pairs",
+			cs:   " Server = myserver ;; Database=my db ; ",
+			want: map[string]string{"Server": "myserver", "Database": "my db"},
+		},
+		{
+			name: "empty value",
+			cs:   "Password=;User=foo",
+			want: map[string]string{"Password": "", "User": "foo"},
+		},
+		{
+			name: "quoted values",
+			cs:   `Password="a;b=c";Name=' spaced ';Escaped="say ""hi""";Single='it''s'`,
+			want: map[string]string{"Password": "a;b=c", "Name": " spaced ", "Escaped": `say "hi"`, "Single": "it's"},
+		},
+		{
+			name: "equal signs in values and keys",
+			cs:   "SharedAccessKey=abc==;Key==Name=1",
+			want: map[string]string{"SharedAccessKey": "abc==", "Key=Name": "1"},
+		},
+		{
+			name:    "missing equal sign",
+			cs:      "Server=foo;Database",
+			wantErr: `missing '=' after key "Database"`,
+		},
+		{
+			name:    "empty key",
+			cs:      "=foo",
+			wantErr: "empty key",
+		},
+		{
+			name:    "unterminated quote",
+			cs:      `Password="abc;Server=foo`,
+			wantErr: `unterminated quoted value for key "Password"`,
+		},
+		{
+			name:    "characters after quoted value",
+			cs:      `Password="abc"def;Server=foo`,
+			wantErr: `unexpected character after quoted value for key "Password"`,
+		},
+		{
+			name:    "duplicate keys",
+			cs:      "Server=foo;server=bar",
+			wantErr: `key "server" is duplicate`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitConnectionString(tt.cs)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseConnectionString(t *testing.T) {
+	type connectionString struct {
+		Endpoint   string        `mapstructure:"endpoint"`
+		KeyName    string        `mapstructure:"sharedAccessKeyName"`
+		Key        string        `mapstructure:"sharedAccessKey"`
+		EntityPath string        `mapstructure:"entityPath" mapstructurealiases:"queue,topic"`
+		Timeout    time.Duration `mapstructure:"timeout"`
+		Enabled    bool          `mapstructure:"enabled"`
+	}
+
+	var res connectionString
+	err := ParseConnectionString(`Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=root;SharedAccessKey="ab;c=";Queue=myqueue;Timeout=5s;Enabled=y`, &res)
+	require.NoError(t, err)
+	assert.Equal(t, connectionString{
+		Endpoint:   "sb://foo.servicebus.windows.net/",
+		KeyName:    "root",
+		Key:        "ab;c=",
+		EntityPath: "myqueue",
+		Timeout:    5 * time.Second,
+		Enabled:    true,
+	}, res)
+
+	err = ParseConnectionString("Endpoint", &res)
+	require.Error(t, err)
+}
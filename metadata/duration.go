@@ -23,6 +23,8 @@ import (
 	"time"
 
 	"github.com/mitchellh/mapstructure"
+
+	kittime "github.com/dapr/kit/time"
 )
 
 type Duration struct {
@@ -44,7 +46,7 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 		return nil
 	case string:
 		var err error
-		d.Duration, err = time.ParseDuration(value)
+		d.Duration, err = parseDuration(value)
 		return err
 	default:
 		return errors.New("invalid duration")
@@ -78,14 +80,9 @@ func toTimeDurationHookFunc() mapstructure.DecodeHookFunc {
 			var val time.Duration
 			if data.(string) != "" {
 				var err error
-				val, err = time.ParseDuration(data.(string))
+				val, err = parseDurationOrSeconds(data.(string))
 				if err != nil {
-					// If we can't parse the duration, try parsing it as int64 seconds
-					seconds, errParse := strconv.ParseInt(data.(string), 10, 0)
-					if errParse != nil {
-						return nil, errors.Join(err, errParse)
-					}
-					val = time.Duration(seconds * int64(time.Second))
+					return nil, err
 				}
 			}
 			if t != reflect.TypeOf(Duration{}) {
@@ -110,6 +107,39 @@ func toTimeDurationHookFunc() mapstructure.DecodeHookFunc {
 	}
 }
 
+// parseDuration parses a duration in the Go format (e.g. "1h30m") or in the
+// ISO 8601 format (e.g. "P1DT2H"), where days are 24 hours long.
+// ISO 8601 durations with years, months, or repetitions are not supported,
+// because their length is not fixed.
+func parseDuration(value string) (time.Duration, error) {
+	years, months, days, dur, repetition, err := kittime.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if years != 0 || months != 0 {
+		return 0, errors.New("durations with years or months are not supported: " + value)
+	}
+	if repetition != -1 {
+		return 0, errors.New("durations with repetitions are not supported: " + value)
+	}
+	return time.Duration(days)*24*time.Hour + dur, nil
+}
+
+// parseDurationOrSeconds parses a duration like parseDuration, or else as a
+// number of seconds.
+func parseDurationOrSeconds(value string) (time.Duration, error) {
+	val, err := parseDuration(value)
+	if err != nil {
+		// If we can't parse the duration, try parsing it as int64 seconds
+		seconds, errParse := strconv.ParseInt(value, 10, 0)
+		if errParse != nil {
+			return 0, errors.Join(err, errParse)
+		}
+		val = time.Duration(seconds * int64(time.Second))
+	}
+	return val, nil
+}
+
 // ToISOString returns the duration formatted as a ISO-8601 duration string (-ish).
 // This methods supports days, hours, minutes, and seconds. It assumes all durations are in UTC time and are not impacted by DST (so all days are 24-hours long).
 // This method does not support fractions of seconds, and durations are truncated to seconds.
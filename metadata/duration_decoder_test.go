@@ -14,8 +14,12 @@ limitations under the License.
 package metadata
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDurationToISOString(t *testing.T) {
@@ -85,3 +89,59 @@ func TestDurationToISOString(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeDuration(t *testing.T) {
+	type testMetadata struct {
+		Duration      Duration        `mapstructure:"duration"`
+		TimeDuration  time.Duration   `mapstructure:"timeduration"`
+		DurationArray []time.Duration `mapstructure:"durationarray"`
+	}
+
+	tests := map[string]time.Duration{
+		"1h30m":      90 * time.Minute,
+		"-5s":        -5 * time.Second,
+		"30":         30 * time.Second,
+		"P1DT2H":     26 * time.Hour,
+		"PT1H30M":    90 * time.Minute,
+		"PT0.5S":     500 * time.Millisecond,
+		"P1W":        7 * 24 * time.Hour,
+		"P0.5D":      12 * time.Hour,
+		"P2DT3M0.5S": 48*time.Hour + 3*time.Minute + 500*time.Millisecond,
+	}
+	for value, expect := range tests {
+		t.Run(value, func(t *testing.T) {
+			var m testMetadata
+			err := DecodeMetadata(map[string]string{
+				"duration":      value,
+				"timeduration":  value,
+				"durationarray": value + "," + value,
+			}, &m)
+			require.NoError(t, err)
+			assert.Equal(t, expect, m.Duration.Duration)
+			assert.Equal(t, expect, m.TimeDuration)
+			assert.Equal(t, []time.Duration{expect, expect}, m.DurationArray)
+		})
+	}
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, value := range []string{"P1Y", "P2M", "R2/PT1H", "1x", "P"} {
+			var m testMetadata
+			err := DecodeMetadata(map[string]string{"timeduration": value}, &m)
+			require.Error(t, err, value)
+
+			err = DecodeMetadata(map[string]string{"durationarray": "1s," + value}, &m)
+			require.Error(t, err, value)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var d Duration
+		require.NoError(t, json.Unmarshal([]byte(`"P1DT2H"`), &d))
+		assert.Equal(t, 26*time.Hour, d.Duration)
+
+		require.NoError(t, json.Unmarshal([]byte(`"1m"`), &d))
+		assert.Equal(t, time.Minute, d.Duration)
+
+		require.Error(t, json.Unmarshal([]byte(`"P1M"`), &d))
+	})
+}
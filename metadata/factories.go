@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	factoriesLock sync.RWMutex
+	// factories contains the registered factories, keyed by interface type and
+	// by the lowercased discriminator value.
+	factories = map[reflect.Type]map[string]func() any{}
+)
+
+// RegisterFactories registers factories of the implementations of the
+// interface type T, keyed by the value of a discriminator.
+//
+// When DecodeMetadata decodes a struct with a field of type T, the value of the
+// field's metadata property is the discriminator: it selects the factory,
+// whose result is populated by decoding the same metadata, and then assigned
+// to the field. For example, with:
+//
+//	type Metadata struct {
+//		Auth AuthConfig `mapstructure:"authType"`
+//	}
+//
+//	metadata.RegisterFactories(map[string]func() AuthConfig{
+//		"aad": func() AuthConfig { return &AADAuthConfig{} },
+//		"key": func() AuthConfig { return &KeyAuthConfig{} },
+//	})
+//
+// the metadata property "authType: aad" causes the field Auth to be set to an
+// *AADAuthConfig decoded from the metadata.
+// Factories must return a pointer to a struct. Discriminators are
+// case-insensitive; an empty discriminator leaves the field nil, and an
+// unknown one causes an error.
+// Registering factories for an interface type replaces those registered
+// before. It panics if T is not an interface type.
+func RegisterFactories[T any](typeFactories map[string]func() T) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Interface {
+		panic("metadata: factories can only be registered for interface types, got " + t.String())
+	}
+
+	m := make(map[string]func() any, len(typeFactories))
+	for k, fn := range typeFactories {
+		m[strings.ToLower(k)] = func() any {
+			return fn()
+		}
+	}
+
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	factories[t] = m
+}
+
+// toFactoryHookFunc returns a hook which decodes fields whose type has
+// registered factories, and invokes next for all other fields. The
+// implementation selected by the discriminator is decoded from inputMap.
+// If usedKeys is not nil, the keys and aliases used by the implementations are
+// added to it.
+// This hook wraps the others, rather than being composed with them, since it
+// may return nil, which can't be passed to other hooks.
+func toFactoryHookFunc(inputMap map[string]string, usedKeys map[string]struct{}, next mapstructure.DecodeHookFunc) mapstructure.DecodeHookFunc {
+	return func(
+		from reflect.Value,
+		to reflect.Value,
+	) (any, error) {
+		if to.Kind() != reflect.Interface || from.Kind() != reflect.String {
+			return mapstructure.DecodeHookExec(next, from, to)
+		}
+
+		factoriesLock.RLock()
+		typeFactories, ok := factories[to.Type()]
+		factoriesLock.RUnlock()
+		if !ok {
+			return mapstructure.DecodeHookExec(next, from, to)
+		}
+
+		discriminator := from.String()
+		if discriminator == "" {
+			return nil, nil
+		}
+		factory, ok := typeFactories[strings.ToLower(discriminator)]
+		if !ok {
+			return nil, fmt.Errorf("unknown value '%s': supported values are %s", discriminator, strings.Join(slices.Sorted(maps.Keys(typeFactories)), ", "))
+		}
+
+		val := factory()
+		var md *mapstructure.Metadata
+		if usedKeys != nil {
+			md = &mapstructure.Metadata{}
+		}
+		err := decodeMetadataMap(inputMap, val, md, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode '%s': %w", discriminator, err)
+		}
+
+		if usedKeys != nil {
+			for _, k := range md.Keys {
+				usedKeys[strings.ToLower(k)] = struct{}{}
+			}
+			collectAliasesInType(usedKeys, reflect.TypeOf(val))
+		}
+
+		return val, nil
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAuthConfig interface {
+	isTestAuthConfig()
+}
+
+type testAADAuth struct {
+	ClientID string        `mapstructure:"clientId" mapstructurealiases:"azureClientId"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+func (*testAADAuth) isTestAuthConfig() {}
+
+type testKeyAuth struct {
+	Key string `mapstructure:"accountKey"`
+}
+
+func (*testKeyAuth) isTestAuthConfig() {}
+
+type testFactoryMetadata struct {
+	Name string         `mapstructure:"name"`
+	Auth testAuthConfig `mapstructure:"authType"`
+}
+
+func TestFactories(t *testing.T) {
+	RegisterFactories(map[string]func() testAuthConfig{
+		"aad": func() testAuthConfig { return &testAADAuth{} },
+		"key": func() testAuthConfig { return &testKeyAuth{} },
+	})
+
+	t.Run("implementation is selected by the discriminator", func(t *testing.T) {
+		var m testFactoryMetadata
+		err := DecodeMetadata(map[string]string{
+			"name":          "test",
+			"authType":      "AAD",
+			"azureClientId": "myclient",
+			"timeout":       "5s",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "test", m.Name)
+		assert.Equal(t, &testAADAuth{ClientID: "myclient", Timeout: 5 * time.Second}, m.Auth)
+
+		m = testFactoryMetadata{}
+		err = DecodeMetadata(map[string]string{
+			"authType":   "key",
+			"accountKey": "secret",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, &testKeyAuth{Key: "secret"}, m.Auth)
+	})
+
+	t.Run("missing or empty discriminator leaves the field nil", func(t *testing.T) {
+		var m testFactoryMetadata
+		require.NoError(t, DecodeMetadata(map[string]string{"name": "test"}, &m))
+		assert.Nil(t, m.Auth)
+
+		require.NoError(t, DecodeMetadata(map[string]string{"authType": ""}, &m))
+		assert.Nil(t, m.Auth)
+	})
+
+	t.Run("unknown discriminator", func(t *testing.T) {
+		var m testFactoryMetadata
+		err := DecodeMetadata(map[string]string{"authType": "oauth"}, &m)
+		require.ErrorContains(t, err, "unknown value 'oauth': supported values are aad, key")
+	})
+
+	t.Run("decoding errors are returned", func(t *testing.T) {
+		var m testFactoryMetadata
+		err := DecodeMetadata(map[string]string{"authType": "aad", "timeout": "nope"}, &m)
+		require.ErrorContains(t, err, "failed to decode 'aad'")
+	})
+
+	t.Run("strict decoding accepts the keys of the implementation", func(t *testing.T) {
+		var m testFactoryMetadata
+		err := DecodeMetadataStrict(map[string]string{
+			"authType":      "aad",
+			"azureClientId": "myclient",
+			"accountKey":    "secret",
+		}, &m)
+		var unknownErr *UnknownKeysError
+		require.ErrorAs(t, err, &unknownErr)
+		assert.Equal(t, []string{"accountKey"}, unknownErr.Keys)
+		assert.Equal(t, &testAADAuth{ClientID: "myclient"}, m.Auth)
+	})
+
+	t.Run("only interface types are supported", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterFactories(map[string]func() *testKeyAuth{
+				"key": func() *testKeyAuth { return &testKeyAuth{} },
+			})
+		})
+	})
+}
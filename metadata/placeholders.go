@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// PlaceholderSchemeEnv is the scheme of placeholders resolved from
+	// environment variables, such as "{env:MY_SECRET}".
+	PlaceholderSchemeEnv = "env"
+	// PlaceholderSchemeFile is the scheme of placeholders resolved from the
+	// contents of files, such as "{file:/var/run/secret}".
+	// It's not enabled by default: see NewFileResolver.
+	PlaceholderSchemeFile = "file"
+)
+
+// ErrPlaceholderNotFound is returned when the value of a placeholder doesn't
+// exist, such as an unset environment variable.
+var ErrPlaceholderNotFound = errors.New("placeholder value not found")
+
+// placeholderRegexp matches placeholders in the form "{scheme:ref}".
+var placeholderRegexp = regexp.MustCompile(`\{([a-zA-Z][a-zA-Z0-9_-]*):([^{}]*)\}`)
+
+// Resolver resolves the value of placeholders with a given scheme.
+// Secret stores can implement it to resolve references to their secrets.
+type Resolver interface {
+	// Resolve returns the value referenced by ref, which is the part of the
+	// placeholder after the scheme.
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc is a function which implements Resolver.
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve implements Resolver.
+func (fn ResolverFunc) Resolve(ref string) (string, error) {
+	return fn(ref)
+}
+
+// PlaceholderResolver replaces placeholders in the form "{scheme:ref}" in
+// metadata values with the value returned by the Resolver registered for the
+// scheme. Placeholders can be the whole value or part of it, such as
+// "host={env:DB_HOST};port=5432".
+// Placeholders whose scheme has no registered Resolver are left as-is, so
+// values such as JSON documents are not modified.
+// It is safe for concurrent use.
+type PlaceholderResolver struct {
+	lock      sync.RWMutex
+	resolvers map[string]Resolver
+}
+
+// NewPlaceholderResolver returns a new PlaceholderResolver which resolves the
+// "env" scheme: "{env:NAME}" is replaced with the value of the environment
+// variable NAME.
+// The "file" scheme is not enabled by default, as it would allow whoever can
+// write metadata to read any file the process can read. To enable it, register
+// a resolver returned by NewFileResolver, restricted to a root directory.
+func NewPlaceholderResolver() *PlaceholderResolver {
+	r := &PlaceholderResolver{
+		resolvers: make(map[string]Resolver, 2),
+	}
+	r.Register(PlaceholderSchemeEnv, ResolverFunc(resolveEnv))
+	return r
+}
+
+// NewFileResolver returns a Resolver for the "file" scheme, which replaces
+// "{file:PATH}" with the contents of the file at PATH, without the trailing
+// newline.
+// Relative paths are resolved from root. Paths outside of root, including
+// through symbolic links, are rejected.
+func NewFileResolver(root string) (Resolver, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root directory: %w", err)
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root directory: %w", err)
+	}
+	return ResolverFunc(func(path string) (string, error) {
+		return resolveFile(root, path)
+	}), nil
+}
+
+// Register sets the Resolver for the scheme, which is case-insensitive,
+// replacing any existing one. A nil resolver removes the scheme.
+func (r *PlaceholderResolver) Register(scheme string, resolver Resolver) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.resolvers == nil {
+		r.resolvers = make(map[string]Resolver)
+	}
+	if resolver == nil {
+		delete(r.resolvers, strings.ToLower(scheme))
+		return
+	}
+	r.resolvers[strings.ToLower(scheme)] = resolver
+}
+
+// ResolveString returns val with all its placeholders resolved.
+func (r *PlaceholderResolver) ResolveString(val string) (string, error) {
+	// Fast path for values without placeholders
+	if !strings.Contains(val, "{") {
+		return val, nil
+	}
+
+	var resolveErr error
+	res := placeholderRegexp.ReplaceAllStringFunc(val, func(placeholder string) string {
+		if resolveErr != nil {
+			return placeholder
+		}
+		m := placeholderRegexp.FindStringSubmatch(placeholder)
+		// The lock isn't held while the resolver is invoked
+		r.lock.RLock()
+		resolver, ok := r.resolvers[strings.ToLower(m[1])]
+		r.lock.RUnlock()
+		if !ok {
+			return placeholder
+		}
+		resolved, err := resolver.Resolve(m[2])
+		if err != nil {
+			resolveErr = fmt.Errorf("failed to resolve placeholder with scheme %q: %w", m[1], err)
+			return placeholder
+		}
+		return resolved
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return res, nil
+}
+
+// ResolveMap returns a copy of md with the placeholders in all values
+// resolved. md is not modified.
+// Errors include the metadata key, but never the value, which could contain
+// secrets.
+func (r *PlaceholderResolver) ResolveMap(md map[string]string) (map[string]string, error) {
+	res := make(map[string]string, len(md))
+	for k, v := range md {
+		resolved, err := r.ResolveString(v)
+		if err != nil {
+			return nil, fmt.Errorf("metadata property %s: %w", k, err)
+		}
+		res[k] = resolved
+	}
+	return res, nil
+}
+
+func resolveEnv(name string) (string, error) {
+	val, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrPlaceholderNotFound, name)
+	}
+	return val, nil
+}
+
+func resolveFile(root string, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	// Symbolic links are evaluated so they can't point outside of root
+	target, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: file %s does not exist", ErrPlaceholderNotFound, path)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %s is outside of the root directory", path)
+	}
+
+	data, err := os.ReadFile(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: file %s does not exist", ErrPlaceholderNotFound, path)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	// Files mounted from secrets often end with a newline
+	res := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(res, "\r"), nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceholderResolver(t *testing.T) {
+	t.Setenv("DAPR_KIT_TEST_SECRET", "hunter2")
+	t.Setenv("DAPR_KIT_TEST_HOST", "db.local")
+
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0o600))
+
+	r := NewPlaceholderResolver()
+	fileResolver, err := NewFileResolver(dir)
+	require.NoError(t, err)
+	r.Register(PlaceholderSchemeFile, fileResolver)
+
+	t.Run("resolves strings", func(t *testing.T) {
+		tests := map[string]string{
+			"plain":                                   "plain",
+			"{env:DAPR_KIT_TEST_SECRET}":              "hunter2",
+			"{ENV:DAPR_KIT_TEST_SECRET}":              "hunter2",
+			"{file:" + secretFile + "}":               "from-file",
+			"{file:secret}":                           "from-file",
+			"host={env:DAPR_KIT_TEST_HOST};port=5432": "host=db.local;port=5432",
+			`{"key":"value"}`:                         `{"key":"value"}`,
+			"{unknown:ref}":                           "{unknown:ref}",
+			"{env:DAPR_KIT_TEST_HOST}:{env:DAPR_KIT_TEST_SECRET}": "db.local:hunter2",
+		}
+		for in, expect := range tests {
+			res, err := r.ResolveString(in)
+			require.NoError(t, err, in)
+			assert.Equal(t, expect, res, in)
+		}
+	})
+
+	t.Run("missing values", func(t *testing.T) {
+		_, err := r.ResolveString("{env:DAPR_KIT_TEST_NOT_SET}")
+		require.ErrorIs(t, err, ErrPlaceholderNotFound)
+
+		_, err = r.ResolveString("{file:" + filepath.Join(dir, "missing") + "}")
+		require.ErrorIs(t, err, ErrPlaceholderNotFound)
+	})
+
+	t.Run("file scheme is not enabled by default", func(t *testing.T) {
+		res, err := NewPlaceholderResolver().ResolveString("{file:" + secretFile + "}")
+		require.NoError(t, err)
+		assert.Equal(t, "{file:"+secretFile+"}", res)
+	})
+
+	t.Run("files outside of the root are rejected", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "outside")
+		require.NoError(t, os.WriteFile(outside, []byte("outside"), 0o600))
+		link := filepath.Join(dir, "link")
+		require.NoError(t, os.Symlink(outside, link))
+
+		relOutside, err := filepath.Rel(dir, outside)
+		require.NoError(t, err)
+
+		for _, ref := range []string{outside, relOutside, link, "link"} {
+			_, err := r.ResolveString("{file:" + ref + "}")
+			require.ErrorContains(t, err, "outside of the root directory", ref)
+		}
+	})
+
+	t.Run("resolvers are invoked without holding the lock", func(t *testing.T) {
+		r := NewPlaceholderResolver()
+		r.Register("register", ResolverFunc(func(ref string) (string, error) {
+			r.Register(ref, nil)
+			return "ok", nil
+		}))
+
+		res, err := r.ResolveString("{register:env}")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res)
+	})
+
+	t.Run("custom resolver", func(t *testing.T) {
+		r := NewPlaceholderResolver()
+		errStore := errors.New("store unavailable")
+		r.Register("secret", ResolverFunc(func(ref string) (string, error) {
+			if ref == "mystore/password" {
+				return "s3cr3t", nil
+			}
+			return "", errStore
+		}))
+
+		res, err := r.ResolveString("{secret:mystore/password}")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cr3t", res)
+
+		_, err = r.ResolveString("{secret:mystore/other}")
+		require.ErrorIs(t, err, errStore)
+
+		r.Register(PlaceholderSchemeEnv, nil)
+		res, err = r.ResolveString("{env:DAPR_KIT_TEST_SECRET}")
+		require.NoError(t, err)
+		assert.Equal(t, "{env:DAPR_KIT_TEST_SECRET}", res)
+	})
+
+	t.Run("resolves properties before decoding", func(t *testing.T) {
+		props := Properties{
+			"password": "{env:DAPR_KIT_TEST_SECRET}",
+			"host":     "{env:DAPR_KIT_TEST_HOST}",
+		}
+		resolved, err := props.ResolvePlaceholders(r)
+		require.NoError(t, err)
+
+		// The original properties are not modified
+		assert.Equal(t, "{env:DAPR_KIT_TEST_SECRET}", props["password"])
+
+		var md struct {
+			Password string `mapstructure:"password"`
+			Host     string `mapstructure:"host"`
+		}
+		require.NoError(t, resolved.Decode(&md))
+		assert.Equal(t, "hunter2", md.Password)
+		assert.Equal(t, "db.local", md.Host)
+	})
+
+	t.Run("errors include the key but not the value", func(t *testing.T) {
+		_, err := Properties{
+			"password": "prefix-secret-{env:DAPR_KIT_TEST_NOT_SET}",
+		}.ResolvePlaceholders(r)
+		require.ErrorIs(t, err, ErrPlaceholderNotFound)
+		assert.Contains(t, err.Error(), "password")
+		assert.NotContains(t, err.Error(), "prefix-secret")
+	})
+}
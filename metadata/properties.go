@@ -30,5 +30,23 @@ func (p Properties) GetPropertyWithMatchedKey(keys ...string) (key string, val s
 // Decode decodes  metadata into a struct.
 // This is an extension of mitchellh/mapstructure which also supports decoding durations.
 func (p Properties) Decode(result any) error {
-	return decodeMetadataMap(p, result)
+	return decodeMetadataMap(p, result, nil, nil)
+}
+
+// DecodeStrict decodes metadata into a struct, returning an UnknownKeysError if the metadata contains keys that don't match any field or alias.
+// Keys in allowedKeys are ignored.
+func (p Properties) DecodeStrict(result any, allowedKeys ...string) error {
+	return decodeMetadataMapStrict(p, result, allowedKeys)
+}
+
+// DecodeWithReport decodes metadata into a struct, like Decode, and returns a report of how the metadata keys were used.
+func (p Properties) DecodeWithReport(result any) (*DecodeReport, error) {
+	return decodeMetadataMapWithReport(p, result)
+}
+
+// ResolvePlaceholders returns a copy of the metadata with the placeholders in
+// the values, such as "{env:MY_SECRET}", replaced by resolver.
+// It should be invoked before Decode or DecodeStrict.
+func (p Properties) ResolvePlaceholders(resolver *PlaceholderResolver) (Properties, error) {
+	return resolver.ResolveMap(p)
 }
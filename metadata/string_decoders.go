@@ -14,9 +14,7 @@ limitations under the License.
 package metadata
 
 import (
-	"errors"
 	"reflect"
-	"strconv"
 	"strings"
 	"time"
 
@@ -75,14 +73,9 @@ func toTimeDurationArrayHookFunc() mapstructure.DecodeHookFunc {
 			if input == "" {
 				continue
 			}
-			val, err := time.ParseDuration(input)
+			val, err := parseDurationOrSeconds(input)
 			if err != nil {
-				// If we can't parse the duration, try parsing it as int64 seconds
-				seconds, errParse := strconv.ParseInt(input, 10, 0)
-				if errParse != nil {
-					return nil, errors.Join(err, errParse)
-				}
-				val = time.Duration(seconds * int64(time.Second))
+				return nil, err
 			}
 			res = append(res, val)
 		}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+)
+
+// Unix timestamps with an absolute value of at least this are interpreted as
+// milliseconds rather than seconds.
+// As seconds, this would be a date in the year 33658; as milliseconds, it's in
+// September 2001.
+const unixMillisThreshold = 1e12
+
+// This helper function is used to decode time.Time and *time.Time values
+// within a map[string]any into a struct.
+// It accepts strings formatted as RFC3339 (with optional fractional seconds),
+// and Unix timestamps in seconds or milliseconds. Timestamps whose absolute
+// value is 1e12 or greater are interpreted as milliseconds.
+// Empty strings are decoded as the zero time, or as nil for pointers.
+// This is used in utils.DecodeMetadata to decode times in metadata.
+func toTimeHookFunc() mapstructure.DecodeHookFunc {
+	timeType := reflect.TypeOf(time.Time{})
+	timePtrType := reflect.TypeOf(&time.Time{})
+
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		var isPtr bool
+		switch t {
+		case timeType:
+			// Nop
+		case timePtrType:
+			isPtr = true
+		default:
+			// Not a type we support with this hook
+			return data, nil
+		}
+
+		// Pointers are decoded in two steps, so the value can already be a time
+		switch val := data.(type) {
+		case time.Time:
+			if isPtr {
+				return &val, nil
+			}
+			return val, nil
+		case *time.Time:
+			if isPtr {
+				return val, nil
+			}
+			if val == nil {
+				return time.Time{}, nil
+			}
+			return *val, nil
+		}
+
+		str, err := cast.ToStringE(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cast value to string: %w", err)
+		}
+
+		if str == "" {
+			if isPtr {
+				return nil, nil
+			}
+			return time.Time{}, nil
+		}
+
+		val, err := parseTime(str)
+		if err != nil {
+			return nil, err
+		}
+
+		if isPtr {
+			return &val, nil
+		}
+		return val, nil
+	}
+}
+
+// parseTime parses a string as a RFC3339 time or a Unix timestamp.
+func parseTime(str string) (time.Time, error) {
+	// time.RFC3339 accepts fractional seconds too when parsing
+	val, err := time.Parse(time.RFC3339, str)
+	if err == nil {
+		return val, nil
+	}
+
+	ts, errParse := strconv.ParseInt(str, 10, 64)
+	if errParse != nil {
+		return time.Time{}, fmt.Errorf("value is not a valid RFC3339 time or Unix timestamp: %w", err)
+	}
+
+	if ts >= unixMillisThreshold || ts <= -unixMillisThreshold {
+		return time.UnixMilli(ts), nil
+	}
+	return time.Unix(ts, 0), nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeDecoding(t *testing.T) {
+	type testMetadata struct {
+		Time    time.Time  `mapstructure:"time"`
+		TimePtr *time.Time `mapstructure:"timeptr"`
+	}
+
+	expect := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := map[string]struct {
+		value string
+		want  time.Time
+		err   string
+	}{
+		"RFC3339": {
+			value: "2024-03-04T05:06:07Z",
+			want:  expect,
+		},
+		"RFC3339 with offset": {
+			value: "2024-03-04T07:06:07+02:00",
+			want:  expect,
+		},
+		"RFC3339Nano": {
+			value: "2024-03-04T05:06:07.123456789Z",
+			want:  expect.Add(123456789 * time.Nanosecond),
+		},
+		"Unix seconds": {
+			value: "1709528767",
+			want:  expect,
+		},
+		"Unix milliseconds": {
+			value: "1709528767123",
+			want:  expect.Add(123 * time.Millisecond),
+		},
+		"negative Unix seconds": {
+			value: "-86400",
+			want:  time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+		"invalid": {
+			value: "yesterday",
+			err:   "value is not a valid RFC3339 time or Unix timestamp",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var m testMetadata
+			err := DecodeMetadata(map[string]string{
+				"time":    tc.value,
+				"timeptr": tc.value,
+			}, &m)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.True(t, tc.want.Equal(m.Time), "expected %v, got %v", tc.want, m.Time)
+			require.NotNil(t, m.TimePtr)
+			assert.True(t, tc.want.Equal(*m.TimePtr), "expected %v, got %v", tc.want, *m.TimePtr)
+		})
+	}
+
+	t.Run("empty value", func(t *testing.T) {
+		m := testMetadata{
+			Time: expect,
+		}
+		err := DecodeMetadata(map[string]string{
+			"time":    "",
+			"timeptr": "",
+		}, &m)
+		require.NoError(t, err)
+		assert.True(t, m.Time.IsZero())
+		assert.Nil(t, m.TimePtr)
+	})
+
+	t.Run("unset value", func(t *testing.T) {
+		m := testMetadata{
+			Time: expect,
+		}
+		err := DecodeMetadata(map[string]string{}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, expect, m.Time)
+		assert.Nil(t, m.TimePtr)
+	})
+
+	t.Run("from map of any", func(t *testing.T) {
+		var m testMetadata
+		err := DecodeMetadata(map[string]any{
+			"time":    int64(1709528767123),
+			"timeptr": int64(1709528767),
+		}, &m)
+		require.NoError(t, err)
+		assert.True(t, expect.Add(123*time.Millisecond).Equal(m.Time))
+		require.NotNil(t, m.TimePtr)
+		assert.True(t, expect.Equal(*m.TimePtr))
+	})
+}
@@ -15,7 +15,10 @@ package metadata
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/mitchellh/mapstructure"
@@ -62,36 +65,234 @@ func DecodeMetadata(input any, result any) error {
 		return fmt.Errorf("input object cannot be cast to map[string]string: %w", err)
 	}
 
-	return decodeMetadataMap(inputMap, result)
+	return decodeMetadataMap(inputMap, result, nil, nil)
 }
 
-func decodeMetadataMap(inputMap map[string]string, result any) error {
+// DecodeMetadataStrict decodes a component metadata into a struct, like DecodeMetadata.
+// Additionally, it returns an UnknownKeysError if the metadata contains keys that don't match any field or alias in the struct.
+// Keys in allowedKeys are ignored; all comparisons are case-insensitive.
+// The result is populated even when an UnknownKeysError is returned.
+func DecodeMetadataStrict(input any, result any, allowedKeys ...string) error {
+	v := reflect.ValueOf(input)
+	if v.Kind() == reflect.Struct {
+		f := v.FieldByName("Properties")
+		if f.IsValid() && f.Kind() == reflect.Map {
+			input = f.Interface().(map[string]string)
+		}
+	}
+
+	inputMap, err := cast.ToStringMapStringE(input)
+	if err != nil {
+		return fmt.Errorf("input object cannot be cast to map[string]string: %w", err)
+	}
+
+	return decodeMetadataMapStrict(inputMap, result, allowedKeys)
+}
+
+// DecodeMetadataWithReport decodes a component metadata into a struct, like DecodeMetadata, and returns a report
+// of how the metadata keys were used, which can be logged or used to check that all the documented keys are decoded.
+func DecodeMetadataWithReport(input any, result any) (*DecodeReport, error) {
+	v := reflect.ValueOf(input)
+	if v.Kind() == reflect.Struct {
+		f := v.FieldByName("Properties")
+		if f.IsValid() && f.Kind() == reflect.Map {
+			input = f.Interface().(map[string]string)
+		}
+	}
+
+	inputMap, err := cast.ToStringMapStringE(input)
+	if err != nil {
+		return nil, fmt.Errorf("input object cannot be cast to map[string]string: %w", err)
+	}
+
+	return decodeMetadataMapWithReport(inputMap, result)
+}
+
+// DecodeReport describes how the keys of a metadata were used by DecodeMetadataWithReport.
+// Keys are reported as they appear in the metadata.
+type DecodeReport struct {
+	// Used contains the keys that were decoded into a field, sorted alphabetically.
+	Used []string
+	// Aliases contains the keys that were decoded into a field as an alias, mapped to the key of the field.
+	Aliases map[string]string
+	// Unused contains the keys that were ignored, sorted alphabetically.
+	// These include keys that don't match any field or alias, and aliases of fields whose key is set too.
+	Unused []string
+}
+
+// String returns a one-line summary of the report.
+func (r *DecodeReport) String() string {
+	aliases := make([]string, 0, len(r.Aliases))
+	for _, k := range slices.Sorted(maps.Keys(r.Aliases)) {
+		aliases = append(aliases, k+"->"+r.Aliases[k])
+	}
+	return fmt.Sprintf("used keys: [%s]; aliases: [%s]; unused keys: [%s]",
+		strings.Join(r.Used, ", "), strings.Join(aliases, ", "), strings.Join(r.Unused, ", "))
+}
+
+func decodeMetadataMapWithReport(inputMap map[string]string, result any) (*DecodeReport, error) {
+	// Copy the map, since resolving aliases adds keys to it
+	md := maps.Clone(inputMap)
+
+	decoderMd := &mapstructure.Metadata{}
+	aliases := map[string]string{}
+	err := decodeMetadataMap(md, result, decoderMd, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	unused := make(map[string]struct{}, len(decoderMd.Unused))
+	for _, k := range decoderMd.Unused {
+		unused[k] = struct{}{}
+	}
+
+	report := &DecodeReport{
+		Used:    []string{},
+		Aliases: aliases,
+		Unused:  []string{},
+	}
+	for k := range inputMap {
+		if _, ok := aliases[k]; ok {
+			continue
+		}
+		if _, ok := unused[k]; ok {
+			report.Unused = append(report.Unused, k)
+		} else {
+			report.Used = append(report.Used, k)
+		}
+	}
+	slices.Sort(report.Used)
+	slices.Sort(report.Unused)
+
+	return report, nil
+}
+
+// UnknownKeysError is the error returned by DecodeMetadataStrict when the metadata contains unknown keys.
+type UnknownKeysError struct {
+	// Keys contains the unknown keys, sorted alphabetically.
+	Keys []string
+}
+
+// Error implements the error interface.
+func (e *UnknownKeysError) Error() string {
+	return "unknown metadata keys: " + strings.Join(e.Keys, ", ")
+}
+
+func decodeMetadataMapStrict(inputMap map[string]string, result any, allowedKeys []string) error {
+	// Copy the map, since resolving aliases adds keys to it
+	md := make(map[string]string, len(inputMap))
+	for k, v := range inputMap {
+		md[k] = v
+	}
+
+	decoderMd := &mapstructure.Metadata{}
+	err := decodeMetadataMap(md, result, decoderMd, nil)
+	if err != nil {
+		return err
+	}
+
+	// Aliases are not used by the decoder, but they are known keys
+	allowed := make(map[string]struct{}, len(allowedKeys))
+	for _, k := range allowedKeys {
+		allowed[strings.ToLower(k)] = struct{}{}
+	}
+	collectAliasesInType(allowed, reflect.TypeOf(result))
+
+	var unknown []string
+	for _, k := range decoderMd.Unused {
+		if _, ok := allowed[strings.ToLower(k)]; !ok {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &UnknownKeysError{Keys: unknown}
+	}
+
+	return nil
+}
+
+// collectAliasesInType adds the lowercased aliases of all the fields of the struct t, possibly recursively, to the aliases map.
+func collectAliasesInType(aliases map[string]struct{}, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		currentField := t.Field(i)
+
+		mapstructureTag := currentField.Tag.Get("mapstructure")
+		if !currentField.IsExported() || mapstructureTag == "" {
+			continue
+		}
+
+		if mapstructureTag == ",squash" {
+			collectAliasesInType(aliases, currentField.Type)
+			continue
+		}
+
+		aliasesTag := strings.ToLower(currentField.Tag.Get("mapstructurealiases"))
+		if aliasesTag == "" {
+			continue
+		}
+		for _, alias := range strings.Split(aliasesTag, ",") {
+			aliases[alias] = struct{}{}
+		}
+	}
+}
+
+// decodeMetadataMap decodes the metadata map into result.
+// If decoderMd is not nil, it's populated with the keys used by the decoder.
+// If resolvedAliases is not nil, the keys resolved as aliases are added to it, mapped to the key of the field.
+func decodeMetadataMap(inputMap map[string]string, result any, decoderMd *mapstructure.Metadata, resolvedAliases map[string]string) error {
 	// Handle aliases
-	err := resolveAliases(inputMap, reflect.TypeOf(result))
+	err := resolveAliases(inputMap, reflect.TypeOf(result), resolvedAliases)
 	if err != nil {
 		return fmt.Errorf("failed to resolve aliases: %w", err)
 	}
 
+	// Keys used by the implementations created by factories, which are unused
+	// by this decoder
+	var factoryKeys map[string]struct{}
+	if decoderMd != nil {
+		factoryKeys = map[string]struct{}{}
+	}
+
 	// Finally, decode the metadata using mapstructure
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+		DecodeHook: toFactoryHookFunc(inputMap, factoryKeys, mapstructure.ComposeDecodeHookFunc(
 			toTimeDurationArrayHookFunc(),
 			toTimeDurationHookFunc(),
 			toTruthyBoolHookFunc(),
 			toStringArrayHookFunc(),
 			toByteSizeHookFunc(),
-		),
-		Metadata:         nil,
+			toTimeHookFunc(),
+		)),
+		Metadata:         decoderMd,
 		Result:           result,
 		WeaklyTypedInput: true,
 	})
 	if err != nil {
 		return err
 	}
-	return decoder.Decode(inputMap)
+	err = decoder.Decode(inputMap)
+	if err != nil {
+		return err
+	}
+
+	if len(factoryKeys) > 0 {
+		decoderMd.Unused = slices.DeleteFunc(decoderMd.Unused, func(k string) bool {
+			_, ok := factoryKeys[strings.ToLower(k)]
+			return ok
+		})
+	}
+	return nil
 }
 
-func resolveAliases(md map[string]string, t reflect.Type) error {
+func resolveAliases(md map[string]string, t reflect.Type, resolved map[string]string) error {
 	// Get the list of all keys in the map
 	keys := make(map[string]string, len(md))
 	for k := range md {
@@ -119,12 +320,12 @@ func resolveAliases(md map[string]string, t reflect.Type) error {
 	}
 
 	// Iterate through all the properties, possibly recursively
-	resolveAliasesInType(md, keys, t)
+	resolveAliasesInType(md, keys, t, resolved)
 
 	return nil
 }
 
-func resolveAliasesInType(md map[string]string, keys map[string]string, t reflect.Type) {
+func resolveAliasesInType(md map[string]string, keys map[string]string, t reflect.Type, resolved map[string]string) {
 	// Iterate through all the properties of the type to see if anyone has the "mapstructurealiases" property
 	for i := 0; i < t.NumField(); i++ {
 		currentField := t.Field(i)
@@ -137,7 +338,7 @@ func resolveAliasesInType(md map[string]string, keys map[string]string, t reflec
 
 		// Check if this is an embedded struct
 		if mapstructureTag == ",squash" {
-			resolveAliasesInType(md, keys, currentField.Type)
+			resolveAliasesInType(md, keys, currentField.Type, resolved)
 			continue
 		}
 
@@ -163,6 +364,9 @@ func resolveAliasesInType(md map[string]string, keys map[string]string, t reflec
 
 			// We found an alias
 			md[mapstructureTag] = md[mdKey]
+			if resolved != nil {
+				resolved[mdKey] = mapstructureTag
+			}
 			break
 		}
 	}
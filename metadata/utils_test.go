@@ -383,7 +383,7 @@ func TestResolveAliases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			md := maps.Clone(tt.md)
-			err := resolveAliases(md, reflect.TypeOf(tt.result))
+			err := resolveAliases(md, reflect.TypeOf(tt.result), nil)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -439,3 +439,128 @@ func TestGetMetadataPropertyWithMatchedKey(t *testing.T) {
 		assert.Equal(t, "", val)
 	})
 }
+
+func TestDecodeMetadataStrict(t *testing.T) {
+	type TestEmbedded struct {
+		MyEmbedded string `mapstructure:"embedded" mapstructurealiases:"embalias"`
+	}
+	type testMetadata struct {
+		TestEmbedded `mapstructure:",squash"`
+
+		Mystring   string        `mapstructure:"mystring"`
+		Myduration time.Duration `mapstructure:"myduration"`
+		Aliased    string        `mapstructure:"aliasA1" mapstructurealiases:"aliasA2"`
+	}
+
+	t.Run("all keys are known", func(t *testing.T) {
+		var m testMetadata
+		err := DecodeMetadataStrict(map[string]string{
+			"MyString":   "test",
+			"myduration": "3s",
+			"aliasA2":    "hello",
+			"embalias":   "hi",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "test", m.Mystring)
+		assert.Equal(t, 3*time.Second, m.Myduration)
+		assert.Equal(t, "hello", m.Aliased)
+		assert.Equal(t, "hi", m.MyEmbedded)
+	})
+
+	t.Run("unknown keys are reported", func(t *testing.T) {
+		var m testMetadata
+		err := DecodeMetadataStrict(map[string]string{
+			"mystring":        "test",
+			"mystirng":        "typo",
+			"actorStateStore": "true",
+			"another":         "one",
+		}, &m, "actorstatestore")
+		require.Error(t, err)
+
+		var unknownErr *UnknownKeysError
+		require.ErrorAs(t, err, &unknownErr)
+		assert.Equal(t, []string{"another", "mystirng"}, unknownErr.Keys)
+		assert.Equal(t, "unknown metadata keys: another, mystirng", err.Error())
+
+		// The result is populated anyways
+		assert.Equal(t, "test", m.Mystring)
+	})
+
+	t.Run("input map is not modified", func(t *testing.T) {
+		var m testMetadata
+		input := Properties{"aliasA2": "hello"}
+		require.NoError(t, input.DecodeStrict(&m))
+		assert.Equal(t, Properties{"aliasA2": "hello"}, input)
+		assert.Equal(t, "hello", m.Aliased)
+	})
+}
+
+func TestDecodeMetadataWithReport(t *testing.T) {
+	type TestEmbedded struct {
+		MyEmbedded string `mapstructure:"embedded" mapstructurealiases:"embalias"`
+	}
+	type testMetadata struct {
+		TestEmbedded `mapstructure:",squash"`
+
+		Mystring   string        `mapstructure:"mystring"`
+		Myduration time.Duration `mapstructure:"myduration"`
+		Aliased    string        `mapstructure:"aliasA1" mapstructurealiases:"aliasA2"`
+	}
+
+	t.Run("keys are classified", func(t *testing.T) {
+		var m testMetadata
+		report, err := DecodeMetadataWithReport(map[string]string{
+			"MyString":   "test",
+			"myduration": "3s",
+			"aliasA2":    "hello",
+			"embalias":   "hi",
+			"mystirng":   "typo",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "test", m.Mystring)
+		assert.Equal(t, 3*time.Second, m.Myduration)
+		assert.Equal(t, "hello", m.Aliased)
+		assert.Equal(t, "hi", m.MyEmbedded)
+
+		assert.Equal(t, []string{"MyString", "myduration"}, report.Used)
+		assert.Equal(t, map[string]string{
+			"aliasA2":  "aliasA1",
+			"embalias": "embedded",
+		}, report.Aliases)
+		assert.Equal(t, []string{"mystirng"}, report.Unused)
+		assert.Equal(t, "used keys: [MyString, myduration]; aliases: [aliasA2->aliasA1, embalias->embedded]; unused keys: [mystirng]", report.String())
+	})
+
+	t.Run("aliases of fields whose key is set are unused", func(t *testing.T) {
+		var m testMetadata
+		report, err := DecodeMetadataWithReport(map[string]string{
+			"aliasA1": "hello",
+			"aliasA2": "ignored",
+		}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", m.Aliased)
+		assert.Equal(t, []string{"aliasA1"}, report.Used)
+		assert.Empty(t, report.Aliases)
+		assert.Equal(t, []string{"aliasA2"}, report.Unused)
+	})
+
+	t.Run("input map is not modified", func(t *testing.T) {
+		var m testMetadata
+		input := Properties{"aliasA2": "hello"}
+		report, err := input.DecodeWithReport(&m)
+		require.NoError(t, err)
+		assert.Equal(t, Properties{"aliasA2": "hello"}, input)
+		assert.Equal(t, "hello", m.Aliased)
+		assert.Empty(t, report.Used)
+		assert.Equal(t, map[string]string{"aliasA2": "aliasA1"}, report.Aliases)
+	})
+
+	t.Run("decoding errors are returned", func(t *testing.T) {
+		var m testMetadata
+		report, err := DecodeMetadataWithReport(map[string]string{
+			"myduration": "notaduration",
+		}, &m)
+		require.Error(t, err)
+		assert.Nil(t, report)
+	})
+}
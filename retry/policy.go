@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/dapr/kit/metadata"
+)
+
+// Policy creates the back offs used to retry operations.
+type Policy interface {
+	// NewBackOff returns a new BackOff instance. See Config.NewBackOff.
+	NewBackOff() backoff.BackOff
+
+	// NewBackOffWithContext returns a new BackOff instance which is stopped
+	// when ctx is canceled. See Config.NewBackOffWithContext.
+	NewBackOffWithContext(ctx context.Context) backoff.BackOff
+
+	// Config returns the configuration of the policy.
+	Config() Config
+
+	// WithMetadata returns a new Policy with the settings overridden by the
+	// metadata fields that start with prefix, such as the metadata of a single
+	// operation. See DecodePolicyFromMetadata.
+	WithMetadata(md map[string]string, prefix string) (Policy, error)
+}
+
+// configPolicy is the Policy produced from a Config.
+type configPolicy struct {
+	config Config
+}
+
+// NewPolicy returns a Policy with the configuration c.
+func (c Config) NewPolicy() Policy {
+	return &configPolicy{config: c}
+}
+
+// DecodePolicyFromMetadata returns a Policy with the default configuration,
+// overridden by the metadata fields that start with prefix.
+// The prefix is matched case-insensitively and removed from the field names,
+// which are decoded with the same conventions as component metadata. For
+// example, with the prefix "retry", the fields "retryMaxRetries" and
+// "retryBackOffPolicy" (or "retryPolicy") set MaxRetries and Policy.
+// Fields that don't start with prefix are ignored.
+func DecodePolicyFromMetadata(md map[string]string, prefix string) (Policy, error) {
+	return DefaultConfig().NewPolicy().WithMetadata(md, prefix)
+}
+
+// NewBackOff implements Policy.
+func (p *configPolicy) NewBackOff() backoff.BackOff {
+	return p.config.NewBackOff()
+}
+
+// NewBackOffWithContext implements Policy.
+func (p *configPolicy) NewBackOffWithContext(ctx context.Context) backoff.BackOff {
+	return p.config.NewBackOffWithContext(ctx)
+}
+
+// Config implements Policy.
+func (p *configPolicy) Config() Config {
+	return p.config
+}
+
+// WithMetadata implements Policy.
+func (p *configPolicy) WithMetadata(md map[string]string, prefix string) (Policy, error) {
+	lcPrefix := strings.ToLower(prefix)
+	props := make(map[string]string, len(md))
+	for k, v := range md {
+		if strings.HasPrefix(strings.ToLower(k), lcPrefix) {
+			props[k[len(prefix):]] = v
+		}
+	}
+	if len(props) == 0 {
+		return p, nil
+	}
+
+	mdConfig := newMetadataConfig(p.config)
+	err := metadata.DecodeMetadata(props, &mdConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode retry policy from metadata: %w", err)
+	}
+
+	config, err := mdConfig.toConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode retry policy from metadata: %w", err)
+	}
+	return config.NewPolicy(), nil
+}
+
+// metadataConfig is used to decode a Config from metadata.
+// Each field can also be set with the "backOff" prefix, as in the metadata of
+// components which use DecodeConfigWithPrefix.
+type metadataConfig struct {
+	Policy              string        `mapstructure:"policy" mapstructurealiases:"backOffPolicy"`
+	Duration            time.Duration `mapstructure:"duration" mapstructurealiases:"backOffDuration"`
+	InitialInterval     time.Duration `mapstructure:"initialInterval" mapstructurealiases:"backOffInitialInterval"`
+	RandomizationFactor float32       `mapstructure:"randomizationFactor" mapstructurealiases:"backOffRandomizationFactor"`
+	Multiplier          float32       `mapstructure:"multiplier" mapstructurealiases:"backOffMultiplier"`
+	MaxInterval         time.Duration `mapstructure:"maxInterval" mapstructurealiases:"backOffMaxInterval"`
+	MaxElapsedTime      time.Duration `mapstructure:"maxElapsedTime" mapstructurealiases:"backOffMaxElapsedTime"`
+	MaxRetries          int64         `mapstructure:"maxRetries" mapstructurealiases:"backOffMaxRetries"`
+}
+
+func newMetadataConfig(c Config) metadataConfig {
+	return metadataConfig{
+		Policy:              c.Policy.String(),
+		Duration:            c.Duration,
+		InitialInterval:     c.InitialInterval,
+		RandomizationFactor: c.RandomizationFactor,
+		Multiplier:          c.Multiplier,
+		MaxInterval:         c.MaxInterval,
+		MaxElapsedTime:      c.MaxElapsedTime,
+		MaxRetries:          c.MaxRetries,
+	}
+}
+
+func (m metadataConfig) toConfig() (Config, error) {
+	c := Config{
+		Duration:            m.Duration,
+		InitialInterval:     m.InitialInterval,
+		RandomizationFactor: m.RandomizationFactor,
+		Multiplier:          m.Multiplier,
+		MaxInterval:         m.MaxInterval,
+		MaxElapsedTime:      m.MaxElapsedTime,
+		MaxRetries:          m.MaxRetries,
+	}
+	err := c.Policy.DecodeString(m.Policy)
+	if err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
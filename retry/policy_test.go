@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/retry"
+)
+
+func TestDecodePolicyFromMetadata(t *testing.T) {
+	tests := map[string]struct {
+		md        map[string]string
+		overrides func(config *retry.Config)
+		err       string
+	}{
+		"no metadata": {
+			md: nil,
+		},
+		"unrelated fields are ignored": {
+			md: map[string]string{
+				"host":       "localhost",
+				"maxRetries": "3",
+			},
+		},
+		"prefixed fields": {
+			md: map[string]string{
+				"retryMaxRetries":    "3",
+				"retryBackOffPolicy": "exponential",
+				"retryMaxInterval":   "10s",
+			},
+			overrides: func(config *retry.Config) {
+				config.MaxRetries = 3
+				config.Policy = retry.PolicyExponential
+				config.MaxInterval = 10 * time.Second
+			},
+		},
+		"case-insensitive": {
+			md: map[string]string{
+				"RETRYPOLICY":          "exponential",
+				"RetryBackOffDuration": "2s",
+			},
+			overrides: func(config *retry.Config) {
+				config.Policy = retry.PolicyExponential
+				config.Duration = 2 * time.Second
+			},
+		},
+		"invalid policy": {
+			md: map[string]string{
+				"retryPolicy": "invalid",
+			},
+			err: "unexpected back off policy type: invalid",
+		},
+		"invalid max retries": {
+			md: map[string]string{
+				"retryMaxRetries": "many",
+			},
+			err: "failed to decode retry policy from metadata",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy, err := retry.DecodePolicyFromMetadata(tc.md, "retry")
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			expect := retry.DefaultConfig()
+			if tc.overrides != nil {
+				tc.overrides(&expect)
+			}
+			assert.Equal(t, expect, policy.Config())
+		})
+	}
+}
+
+func TestPolicyWithMetadata(t *testing.T) {
+	config := retry.DefaultConfig()
+	config.Policy = retry.PolicyExponential
+	config.MaxRetries = 5
+	base := config.NewPolicy()
+
+	policy, err := base.WithMetadata(map[string]string{
+		"opMaxRetries": "1",
+		"opPolicy":     "constant",
+		"opDuration":   "1ms",
+	}, "op")
+	require.NoError(t, err)
+
+	// The base policy is not modified
+	assert.Equal(t, config, base.Config())
+
+	expect := config
+	expect.MaxRetries = 1
+	expect.Policy = retry.PolicyConstant
+	expect.Duration = time.Millisecond
+	assert.Equal(t, expect, policy.Config())
+
+	attempts := 0
+	err = backoff.Retry(func() error {
+		attempts++
+		return errRetry
+	}, policy.NewBackOffWithContext(context.Background()))
+	require.ErrorIs(t, err, errRetry)
+	assert.Equal(t, 2, attempts)
+}
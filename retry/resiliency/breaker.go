@@ -0,0 +1,341 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+const (
+	defaultConsecutiveFailures = 5
+	defaultBreakerTimeout      = time.Minute
+)
+
+var (
+	// ErrCircuitOpen is returned by CircuitBreaker.Execute when the circuit
+	// breaker is open, without invoking the operation.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+
+	// ErrTooManyRequests is returned by CircuitBreaker.Execute when the
+	// circuit breaker is half-open and the maximum number of trial requests
+	// are in progress.
+	ErrTooManyRequests = errors.New("too many requests while circuit breaker is half-open")
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// StateClosed is the state in which operations are executed, and their
+	// failures are counted.
+	StateClosed BreakerState = iota
+	// StateHalfOpen is the state in which a limited number of operations are
+	// executed, to check whether the failures have stopped.
+	StateHalfOpen
+	// StateOpen is the state in which operations are rejected.
+	StateOpen
+)
+
+// String implements fmt.Stringer.
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOptions contains the options for creating a CircuitBreaker.
+type BreakerOptions struct {
+	// Name of the circuit breaker, passed to OnStateChange.
+	Name string
+
+	// ConsecutiveFailures is the number of consecutive failures which trips
+	// the circuit breaker.
+	// If both ConsecutiveFailures and FailureRate are 0, defaults to 5.
+	ConsecutiveFailures int
+
+	// FailureRate is the ratio of failed operations, between 0 and 1, which
+	// trips the circuit breaker, once at least MinRequests operations have
+	// been executed in the current interval. 0 disables it.
+	FailureRate float64
+
+	// MinRequests is the minimum number of operations executed in the
+	// current interval before FailureRate is evaluated.
+	MinRequests int
+
+	// Interval is the period after which the counts of operations are reset
+	// while the circuit breaker is closed. If 0, counts are reset only when
+	// the state changes.
+	Interval time.Duration
+
+	// Timeout is the period the circuit breaker stays open for, before
+	// becoming half-open. Default is 1 minute.
+	Timeout time.Duration
+
+	// MaxHalfOpenRequests is the number of operations allowed while the
+	// circuit breaker is half-open. The circuit breaker is closed once they
+	// all succeed, and opened again as soon as one fails. Default is 1.
+	MaxHalfOpenRequests int
+
+	// OnStateChange, if set, is invoked every time the state changes.
+	// It's invoked synchronously by the goroutine that causes the change, so
+	// it should return quickly.
+	OnStateChange func(name string, from BreakerState, to BreakerState)
+
+	// Clock is the clock used to measure the interval and the timeout.
+	// If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// CircuitBreaker stops executing operations after they fail repeatedly, to
+// give the failing resource time to recover.
+// It starts closed, and it's opened when the trip conditions are met. After
+// the timeout, it becomes half-open, and a limited number of operations are
+// executed to decide whether to close it or open it again.
+// It is safe for concurrent use.
+type CircuitBreaker struct {
+	opts  BreakerOptions
+	clock clock.Clock
+
+	lock       sync.Mutex
+	state      BreakerState
+	generation uint64
+	counts     breakerCounts
+	// End of the current interval when closed, or of the timeout when open.
+	expiry time.Time
+}
+
+// breakerCounts contains the counts of operations in the current generation.
+type breakerCounts struct {
+	requests            int
+	failures            int
+	consecutiveFailures int
+	successes           int
+}
+
+// NewCircuitBreaker returns a new CircuitBreaker, which is closed.
+func NewCircuitBreaker(opts BreakerOptions) *CircuitBreaker {
+	if opts.ConsecutiveFailures <= 0 && opts.FailureRate <= 0 {
+		opts.ConsecutiveFailures = defaultConsecutiveFailures
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultBreakerTimeout
+	}
+	if opts.MaxHalfOpenRequests <= 0 {
+		opts.MaxHalfOpenRequests = 1
+	}
+
+	cb := &CircuitBreaker{
+		opts:  opts,
+		clock: opts.Clock,
+	}
+	if cb.clock == nil {
+		cb.clock = clock.RealClock{}
+	}
+	cb.resetGeneration(cb.clock.Now())
+	return cb
+}
+
+// State returns the current state of the circuit breaker.
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.lock.Lock()
+	state, notify := cb.currentState(cb.clock.Now())
+	cb.lock.Unlock()
+
+	notify()
+	return state
+}
+
+// Execute invokes op if the circuit breaker allows it, and records its
+// result. Operations returning an error, or panicking, are counted as
+// failures; panics are propagated once the failure is recorded. Operations
+// returning context.Canceled, because the caller gave up on them, are not
+// counted.
+// It returns ErrCircuitOpen or ErrTooManyRequests without invoking op if the
+// circuit breaker doesn't allow it.
+func (cb *CircuitBreaker) Execute(op func() error) (err error) {
+	generation, err := cb.before()
+	if err != nil {
+		return err
+	}
+
+	result := resultFailure
+	defer func() {
+		// If op panics, result is still resultFailure
+		cb.after(generation, result)
+	}()
+
+	err = op()
+	switch {
+	case err == nil:
+		result = resultSuccess
+	case errors.Is(err, context.Canceled):
+		result = resultCanceled
+	}
+	return err
+}
+
+// opResult is the result of an operation executed by a CircuitBreaker.
+type opResult int
+
+const (
+	resultFailure opResult = iota
+	resultSuccess
+	// resultCanceled is the result of operations canceled by the caller,
+	// which are neither successes nor failures.
+	resultCanceled
+)
+
+func (cb *CircuitBreaker) before() (uint64, error) {
+	cb.lock.Lock()
+	state, notify := cb.currentState(cb.clock.Now())
+	defer func() {
+		cb.lock.Unlock()
+		notify()
+	}()
+
+	switch state {
+	case StateOpen:
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.counts.requests >= cb.opts.MaxHalfOpenRequests {
+			return 0, ErrTooManyRequests
+		}
+	}
+
+	cb.counts.requests++
+	return cb.generation, nil
+}
+
+func (cb *CircuitBreaker) after(generation uint64, result opResult) {
+	cb.lock.Lock()
+	now := cb.clock.Now()
+	state, notify := cb.currentState(now)
+	defer func() {
+		cb.lock.Unlock()
+		notify()
+	}()
+
+	// Ignore the results of operations started before the state changed
+	if generation != cb.generation {
+		return
+	}
+
+	switch result {
+	case resultCanceled:
+		// Release the request, so another one can be executed while half-open
+		cb.counts.requests--
+		return
+	case resultSuccess:
+		cb.counts.successes++
+		cb.counts.consecutiveFailures = 0
+		if state == StateHalfOpen && cb.counts.successes >= cb.opts.MaxHalfOpenRequests {
+			notify = chainNotify(notify, cb.setState(StateClosed, now))
+		}
+		return
+	}
+
+	cb.counts.failures++
+	cb.counts.consecutiveFailures++
+	if state == StateHalfOpen || cb.shouldTrip() {
+		notify = chainNotify(notify, cb.setState(StateOpen, now))
+	}
+}
+
+// shouldTrip returns true if the trip conditions are met.
+func (cb *CircuitBreaker) shouldTrip() bool {
+	c := cb.counts
+	if cb.opts.ConsecutiveFailures > 0 && c.consecutiveFailures >= cb.opts.ConsecutiveFailures {
+		return true
+	}
+	if cb.opts.FailureRate > 0 && c.requests >= max(cb.opts.MinRequests, 1) {
+		return float64(c.failures)/float64(c.requests) >= cb.opts.FailureRate
+	}
+	return false
+}
+
+// currentState returns the state at the time now, updating it if the
+// interval or the timeout has expired.
+// It returns a function which invokes the OnStateChange callback, to be
+// invoked after releasing the lock.
+func (cb *CircuitBreaker) currentState(now time.Time) (BreakerState, func()) {
+	notify := func() {}
+	switch cb.state {
+	case StateClosed:
+		if !cb.expiry.IsZero() && !now.Before(cb.expiry) {
+			cb.resetGeneration(now)
+		}
+	case StateOpen:
+		if !now.Before(cb.expiry) {
+			notify = cb.setState(StateHalfOpen, now)
+		}
+	}
+	return cb.state, notify
+}
+
+// setState changes the state and starts a new generation.
+func (cb *CircuitBreaker) setState(state BreakerState, now time.Time) func() {
+	if cb.state == state {
+		return func() {}
+	}
+
+	prev := cb.state
+	cb.state = state
+	cb.resetGeneration(now)
+
+	if cb.opts.OnStateChange == nil {
+		return func() {}
+	}
+	return func() {
+		cb.opts.OnStateChange(cb.opts.Name, prev, state)
+	}
+}
+
+// resetGeneration resets the counts and computes the expiry of the current
+// state.
+func (cb *CircuitBreaker) resetGeneration(now time.Time) {
+	cb.generation++
+	cb.counts = breakerCounts{}
+
+	switch cb.state {
+	case StateClosed:
+		if cb.opts.Interval > 0 {
+			cb.expiry = now.Add(cb.opts.Interval)
+		} else {
+			cb.expiry = time.Time{}
+		}
+	case StateOpen:
+		cb.expiry = now.Add(cb.opts.Timeout)
+	default:
+		cb.expiry = time.Time{}
+	}
+}
+
+func chainNotify(a func(), b func()) func() {
+	return func() {
+		a()
+		b()
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var errTest = errors.New("test error")
+
+func succeed() error { return nil }
+func fail() error    { return errTest }
+
+func TestCircuitBreaker(t *testing.T) {
+	type stateChange struct {
+		from BreakerState
+		to   BreakerState
+	}
+
+	newBreaker := func(opts BreakerOptions) (*CircuitBreaker, *clocktesting.FakeClock, *[]stateChange) {
+		clock := clocktesting.NewFakeClock(time.Now())
+		changes := &[]stateChange{}
+		opts.Name = "test"
+		opts.Clock = clock
+		opts.OnStateChange = func(name string, from BreakerState, to BreakerState) {
+			assert.Equal(t, "test", name)
+			*changes = append(*changes, stateChange{from: from, to: to})
+		}
+		return NewCircuitBreaker(opts), clock, changes
+	}
+
+	t.Run("trips on consecutive failures", func(t *testing.T) {
+		cb, _, changes := newBreaker(BreakerOptions{ConsecutiveFailures: 3})
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		// A success resets the consecutive failures
+		require.NoError(t, cb.Execute(succeed))
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateClosed, cb.State())
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateOpen, cb.State())
+		assert.Equal(t, []stateChange{{StateClosed, StateOpen}}, *changes)
+
+		invoked := false
+		err := cb.Execute(func() error {
+			invoked = true
+			return nil
+		})
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		assert.False(t, invoked)
+	})
+
+	t.Run("trips on failure rate", func(t *testing.T) {
+		cb, _, _ := newBreaker(BreakerOptions{FailureRate: 0.5, MinRequests: 4})
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		// Not enough requests yet
+		assert.Equal(t, StateClosed, cb.State())
+
+		require.NoError(t, cb.Execute(succeed))
+		// 3 failures in 4 requests, but only failures trip the breaker
+		assert.Equal(t, StateClosed, cb.State())
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("counts are reset after the interval", func(t *testing.T) {
+		cb, clock, _ := newBreaker(BreakerOptions{FailureRate: 0.5, MinRequests: 2, Interval: time.Minute})
+
+		require.NoError(t, cb.Execute(succeed))
+		require.NoError(t, cb.Execute(succeed))
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateClosed, cb.State())
+
+		clock.Step(time.Minute)
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateClosed, cb.State())
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("half-open and closed after successes", func(t *testing.T) {
+		cb, clock, changes := newBreaker(BreakerOptions{ConsecutiveFailures: 1, Timeout: 10 * time.Second, MaxHalfOpenRequests: 2})
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateOpen, cb.State())
+
+		clock.Step(9 * time.Second)
+		require.ErrorIs(t, cb.Execute(succeed), ErrCircuitOpen)
+
+		clock.Step(time.Second)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		// Only 2 requests are allowed while half-open
+		err := cb.Execute(func() error {
+			return cb.Execute(func() error {
+				require.ErrorIs(t, cb.Execute(succeed), ErrTooManyRequests)
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, StateClosed, cb.State())
+
+		assert.Equal(t, []stateChange{
+			{StateClosed, StateOpen},
+			{StateOpen, StateHalfOpen},
+			{StateHalfOpen, StateClosed},
+		}, *changes)
+	})
+
+	t.Run("half-open and opened again after a failure", func(t *testing.T) {
+		cb, clock, _ := newBreaker(BreakerOptions{ConsecutiveFailures: 1, Timeout: 10 * time.Second, MaxHalfOpenRequests: 2})
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		clock.Step(10 * time.Second)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		require.NoError(t, cb.Execute(succeed))
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateOpen, cb.State())
+
+		// The timeout starts again
+		clock.Step(9 * time.Second)
+		assert.Equal(t, StateOpen, cb.State())
+		clock.Step(time.Second)
+		assert.Equal(t, StateHalfOpen, cb.State())
+	})
+
+	t.Run("panics are counted as failures", func(t *testing.T) {
+		cb, clock, _ := newBreaker(BreakerOptions{ConsecutiveFailures: 1, Timeout: 10 * time.Second})
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		clock.Step(10 * time.Second)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		// The half-open request is released, and the breaker is opened again
+		assert.PanicsWithValue(t, "oops", func() {
+			_ = cb.Execute(func() error { panic("oops") })
+		})
+		assert.Equal(t, StateOpen, cb.State())
+
+		clock.Step(10 * time.Second)
+		require.NoError(t, cb.Execute(succeed))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("canceled operations are not counted", func(t *testing.T) {
+		cb, clock, _ := newBreaker(BreakerOptions{ConsecutiveFailures: 1, Timeout: 10 * time.Second})
+
+		canceled := func() error {
+			return fmt.Errorf("operation aborted: %w", context.Canceled)
+		}
+		require.ErrorIs(t, cb.Execute(canceled), context.Canceled)
+		assert.Equal(t, StateClosed, cb.State())
+
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		clock.Step(10 * time.Second)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		// The half-open request is released
+		require.ErrorIs(t, cb.Execute(canceled), context.Canceled)
+		assert.Equal(t, StateHalfOpen, cb.State())
+		require.NoError(t, cb.Execute(succeed))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("results from a previous state are ignored", func(t *testing.T) {
+		cb, _, _ := newBreaker(BreakerOptions{ConsecutiveFailures: 1})
+
+		err := cb.Execute(func() error {
+			// Trips the breaker while the operation is in progress
+			require.ErrorIs(t, cb.Execute(fail), errTest)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		cb := NewCircuitBreaker(BreakerOptions{})
+		for range 4 {
+			require.ErrorIs(t, cb.Execute(fail), errTest)
+		}
+		assert.Equal(t, StateClosed, cb.State())
+		require.ErrorIs(t, cb.Execute(fail), errTest)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+}
+
+func TestBreakerStateString(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "unknown", BreakerState(42).String())
+}
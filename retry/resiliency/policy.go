@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resiliency contains a Policy which composes retries, timeouts, and a
+// circuit breaker to execute operations.
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/dapr/kit/retry"
+)
+
+// PolicyOptions contains the options for creating a Policy.
+type PolicyOptions struct {
+	// Retry creates the back offs used to retry failed operations.
+	// If nil, operations are not retried.
+	Retry retry.Policy
+
+	// Timeout is the maximum duration of each attempt of an operation.
+	// If 0, attempts don't time out, other than when the context is canceled.
+	Timeout time.Duration
+
+	// CircuitBreaker, if set, is used to execute each attempt of an operation.
+	// A CircuitBreaker can be shared by multiple Policy objects, for example
+	// when they are used to access the same resource.
+	CircuitBreaker *CircuitBreaker
+
+	// OnRetry, if set, is invoked every time a failed attempt is going to be
+	// retried, with its error and the delay before the next attempt.
+	OnRetry func(err error, delay time.Duration)
+}
+
+// Policy executes operations with retries, a timeout for each attempt, and a
+// circuit breaker, all of which are optional.
+// For each attempt, the circuit breaker is checked first, then the operation
+// is invoked with a context with the timeout. Attempts rejected by the
+// circuit breaker, with ErrCircuitOpen or ErrTooManyRequests, are not retried.
+// It is safe for concurrent use.
+type Policy struct {
+	retry          retry.Policy
+	timeout        time.Duration
+	circuitBreaker *CircuitBreaker
+	onRetry        func(err error, delay time.Duration)
+}
+
+// NewPolicy returns a new Policy.
+func NewPolicy(opts PolicyOptions) *Policy {
+	return &Policy{
+		retry:          opts.Retry,
+		timeout:        opts.Timeout,
+		circuitBreaker: opts.CircuitBreaker,
+		onRetry:        opts.OnRetry,
+	}
+}
+
+// Run executes op with the policy, returning the error of the last attempt.
+// Errors wrapped with backoff.Permanent are not retried, and they are
+// returned unwrapped.
+func (p *Policy) Run(ctx context.Context, op func(ctx context.Context) error) error {
+	_, err := RunWithData(ctx, p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	})
+	return err
+}
+
+// RunWithData executes op with the policy p, returning the value and the error
+// of the last attempt.
+func RunWithData[T any](ctx context.Context, p *Policy, op func(ctx context.Context) (T, error)) (T, error) {
+	attempt := func() (T, error) {
+		var res T
+		exec := func() (err error) {
+			attemptCtx := ctx
+			if p.timeout > 0 {
+				var cancel context.CancelFunc
+				attemptCtx, cancel = context.WithTimeout(ctx, p.timeout)
+				defer cancel()
+			}
+			res, err = op(attemptCtx)
+			return err
+		}
+
+		var err error
+		if p.circuitBreaker != nil {
+			err = p.circuitBreaker.Execute(exec)
+			if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTooManyRequests) {
+				return res, backoff.Permanent(err)
+			}
+		} else {
+			err = exec()
+		}
+		return res, err
+	}
+
+	if p.retry == nil {
+		res, err := attempt()
+		var permanent *backoff.PermanentError
+		if errors.As(err, &permanent) {
+			err = permanent.Err
+		}
+		return res, err
+	}
+
+	var notify backoff.Notify
+	if p.onRetry != nil {
+		notify = p.onRetry
+	}
+	return backoff.RetryNotifyWithData(attempt, p.retry.NewBackOffWithContext(ctx), notify)
+}
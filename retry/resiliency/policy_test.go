@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/retry"
+)
+
+func TestPolicy(t *testing.T) {
+	retryPolicy := func(maxRetries int64) retry.Policy {
+		config := retry.DefaultConfig()
+		config.Duration = time.Millisecond
+		config.MaxRetries = maxRetries
+		return config.NewPolicy()
+	}
+
+	t.Run("no options", func(t *testing.T) {
+		var calls atomic.Int32
+		err := NewPolicy(PolicyOptions{}).Run(context.Background(), func(ctx context.Context) error {
+			calls.Add(1)
+			return errTest
+		})
+		require.ErrorIs(t, err, errTest)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("retries until success", func(t *testing.T) {
+		var (
+			calls   atomic.Int32
+			retries []error
+		)
+		p := NewPolicy(PolicyOptions{
+			Retry: retryPolicy(5),
+			OnRetry: func(err error, delay time.Duration) {
+				retries = append(retries, err)
+				assert.Equal(t, time.Millisecond, delay)
+			},
+		})
+		res, err := RunWithData(context.Background(), p, func(ctx context.Context) (string, error) {
+			if calls.Add(1) < 3 {
+				return "", errTest
+			}
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res)
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, []error{errTest, errTest}, retries)
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		var calls atomic.Int32
+		err := NewPolicy(PolicyOptions{Retry: retryPolicy(2)}).Run(context.Background(), func(ctx context.Context) error {
+			calls.Add(1)
+			return errTest
+		})
+		require.ErrorIs(t, err, errTest)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		for _, p := range []*Policy{NewPolicy(PolicyOptions{}), NewPolicy(PolicyOptions{Retry: retryPolicy(2)})} {
+			var calls atomic.Int32
+			err := p.Run(context.Background(), func(ctx context.Context) error {
+				calls.Add(1)
+				return backoff.Permanent(errTest)
+			})
+			require.Equal(t, errTest, err)
+			assert.Equal(t, int32(1), calls.Load())
+		}
+	})
+
+	t.Run("timeout for each attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		p := NewPolicy(PolicyOptions{
+			Retry:   retryPolicy(1),
+			Timeout: 10 * time.Millisecond,
+		})
+		err := p.Run(context.Background(), func(ctx context.Context) error {
+			calls.Add(1)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("context canceled stops retries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls atomic.Int32
+		err := NewPolicy(PolicyOptions{Retry: retryPolicy(-1)}).Run(ctx, func(ctx context.Context) error {
+			if calls.Add(1) == 3 {
+				cancel()
+			}
+			return errTest
+		})
+		require.Error(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("circuit breaker stops retries", func(t *testing.T) {
+		var changes []BreakerState
+		cb := NewCircuitBreaker(BreakerOptions{
+			ConsecutiveFailures: 2,
+			OnStateChange: func(_ string, _ BreakerState, to BreakerState) {
+				changes = append(changes, to)
+			},
+		})
+		p := NewPolicy(PolicyOptions{
+			Retry:          retryPolicy(10),
+			CircuitBreaker: cb,
+		})
+
+		var calls atomic.Int32
+		err := p.Run(context.Background(), func(ctx context.Context) error {
+			calls.Add(1)
+			return errTest
+		})
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, []BreakerState{StateOpen}, changes)
+
+		// The circuit breaker can be shared
+		err = NewPolicy(PolicyOptions{CircuitBreaker: cb}).Run(context.Background(), func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+		require.True(t, errors.Is(err, ErrCircuitOpen))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
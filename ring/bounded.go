@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ring
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Bounded is a fixed-capacity FIFO ring buffer which is safe for concurrent
+// use by multiple producers and consumers.
+// Non-blocking operations are lock-free, and no operation allocates memory
+// once the buffer has been created.
+type Bounded[T any] struct {
+	// head and tail are padded to avoid false sharing between consumers and
+	// producers.
+	_    [64]byte
+	head atomic.Uint64
+	_    [56]byte
+	tail atomic.Uint64
+	_    [56]byte
+
+	slots []boundedSlot[T]
+
+	// notEmpty and notFull wake up blocked consumers and producers. They are
+	// buffered, so a signal sent before a goroutine starts waiting isn't lost.
+	notEmpty chan struct{}
+	notFull  chan struct{}
+}
+
+// boundedSlot is a slot of the buffer. seq is twice the position of the item
+// the slot is ready to be written with (seq == 2*position) or read from
+// (seq == 2*position+1): doubling it keeps the two states distinct even when
+// the capacity is 1.
+type boundedSlot[T any] struct {
+	seq   atomic.Uint64
+	value T
+}
+
+// NewBounded creates a new Bounded ring buffer with the given capacity.
+// The capacity defaults to 1 if it's less than 1.
+func NewBounded[T any](capacity int) *Bounded[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	b := &Bounded[T]{
+		slots:    make([]boundedSlot[T], capacity),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+	for i := range b.slots {
+		b.slots[i].seq.Store(2 * uint64(i))
+	}
+	return b
+}
+
+// Cap returns the capacity of the buffer.
+func (b *Bounded[T]) Cap() int {
+	return len(b.slots)
+}
+
+// Len returns the number of items in the buffer. When the buffer is being
+// modified concurrently, the result is approximate.
+func (b *Bounded[T]) Len() int {
+	// Load head first, so the result is never negative
+	head := b.head.Load()
+	tail := b.tail.Load()
+	n := int(tail - head)
+	return min(n, len(b.slots))
+}
+
+// TryPush adds an item at the end of the buffer, returning false if the
+// buffer is full.
+func (b *Bounded[T]) TryPush(value T) bool {
+	if !b.push(value) {
+		return false
+	}
+	signal(b.notEmpty)
+	return true
+}
+
+// TryPop removes the item at the front of the buffer, returning false if the
+// buffer is empty.
+func (b *Bounded[T]) TryPop() (T, bool) {
+	value, ok := b.pop()
+	if ok {
+		signal(b.notFull)
+	}
+	return value, ok
+}
+
+// Push adds an item at the end of the buffer, blocking until there's room for
+// it or ctx is done.
+func (b *Bounded[T]) Push(ctx context.Context, value T) error {
+	for !b.push(value) {
+		select {
+		case <-b.notFull:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	signal(b.notEmpty)
+	// Wake up another producer if there's still room, since signals sent
+	// while the channel is full are coalesced
+	if b.Len() < len(b.slots) {
+		signal(b.notFull)
+	}
+	return nil
+}
+
+// Pop removes the item at the front of the buffer, blocking until there's an
+// item or ctx is done.
+func (b *Bounded[T]) Pop(ctx context.Context) (T, error) {
+	for {
+		value, ok := b.pop()
+		if ok {
+			signal(b.notFull)
+			// Wake up another consumer if there are more items, since signals
+			// sent while the channel is full are coalesced
+			if b.Len() > 0 {
+				signal(b.notEmpty)
+			}
+			return value, nil
+		}
+
+		select {
+		case <-b.notEmpty:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func (b *Bounded[T]) push(value T) bool {
+	size := uint64(len(b.slots))
+	pos := b.tail.Load()
+	for {
+		slot := &b.slots[pos%size]
+		seq := slot.seq.Load()
+		switch {
+		case seq == 2*pos:
+			// The slot is free: claim it
+			if b.tail.CompareAndSwap(pos, pos+1) {
+				slot.value = value
+				slot.seq.Store(2*pos + 1)
+				return true
+			}
+			pos = b.tail.Load()
+		case seq < 2*pos:
+			// The slot still contains the item of the previous lap: full
+			return false
+		default:
+			// Another producer claimed the slot
+			pos = b.tail.Load()
+		}
+	}
+}
+
+func (b *Bounded[T]) pop() (T, bool) {
+	size := uint64(len(b.slots))
+	pos := b.head.Load()
+	for {
+		slot := &b.slots[pos%size]
+		seq := slot.seq.Load()
+		switch {
+		case seq == 2*pos+1:
+			// The slot contains an item: claim it
+			if b.head.CompareAndSwap(pos, pos+1) {
+				value := slot.value
+				var zero T
+				slot.value = zero
+				// Make the slot available for the next lap
+				slot.seq.Store(2 * (pos + size))
+				return value, true
+			}
+			pos = b.head.Load()
+		case seq < 2*pos+1:
+			// The slot hasn't been written yet: empty
+			var zero T
+			return zero, false
+		default:
+			// Another consumer claimed the slot
+			pos = b.head.Load()
+		}
+	}
+}
+
+// signal sends a non-blocking signal on ch.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ring
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBounded(t *testing.T) {
+	t.Run("capacity", func(t *testing.T) {
+		assert.Equal(t, 1, NewBounded[int](0).Cap())
+		assert.Equal(t, 1, NewBounded[int](-1).Cap())
+		assert.Equal(t, 5, NewBounded[int](5).Cap())
+	})
+
+	t.Run("try push and pop", func(t *testing.T) {
+		b := NewBounded[int](3)
+		_, ok := b.TryPop()
+		assert.False(t, ok)
+
+		// Run a few laps around the buffer
+		for lap := range 3 {
+			for i := range 3 {
+				require.True(t, b.TryPush(lap*10+i))
+			}
+			assert.False(t, b.TryPush(100))
+			assert.Equal(t, 3, b.Len())
+
+			for i := range 3 {
+				v, ok := b.TryPop()
+				require.True(t, ok)
+				assert.Equal(t, lap*10+i, v)
+			}
+			_, ok = b.TryPop()
+			assert.False(t, ok)
+			assert.Equal(t, 0, b.Len())
+		}
+	})
+
+	t.Run("popped slots are zeroed", func(t *testing.T) {
+		b := NewBounded[*int](1)
+		v := 1
+		require.True(t, b.TryPush(&v))
+		_, ok := b.TryPop()
+		require.True(t, ok)
+		assert.Nil(t, b.slots[0].value)
+	})
+
+	t.Run("push blocks until there's room", func(t *testing.T) {
+		b := NewBounded[int](1)
+		require.NoError(t, b.Push(context.Background(), 1))
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- b.Push(context.Background(), 2)
+		}()
+
+		select {
+		case <-errCh:
+			t.Fatal("push returned while the buffer is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		v, err := b.Pop(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("push did not return")
+		}
+		v, ok := b.TryPop()
+		require.True(t, ok)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("pop blocks until there's an item", func(t *testing.T) {
+		b := NewBounded[int](1)
+
+		resCh := make(chan int)
+		go func() {
+			v, err := b.Pop(context.Background())
+			assert.NoError(t, err)
+			resCh <- v
+		}()
+
+		select {
+		case <-resCh:
+			t.Fatal("pop returned while the buffer is empty")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.True(t, b.TryPush(42))
+		select {
+		case v := <-resCh:
+			assert.Equal(t, 42, v)
+		case <-time.After(time.Second):
+			t.Fatal("pop did not return")
+		}
+	})
+
+	t.Run("blocking operations return when the context is done", func(t *testing.T) {
+		b := NewBounded[int](1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := b.Pop(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.True(t, b.TryPush(1))
+		ctx, cancel = context.WithCancel(context.Background())
+		cancel()
+		err = b.Push(ctx, 2)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, b.Len())
+	})
+
+	t.Run("multiple producers and consumers", func(t *testing.T) {
+		const (
+			producers = 4
+			consumers = 4
+			perWorker = 2_000
+		)
+		b := NewBounded[int](8)
+		ctx := context.Background()
+
+		var wg sync.WaitGroup
+		for p := range producers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range perWorker {
+					assert.NoError(t, b.Push(ctx, p*perWorker+i))
+				}
+			}()
+		}
+
+		var lock sync.Mutex
+		seen := make(map[int]int, producers*perWorker)
+		var cwg sync.WaitGroup
+		for range consumers {
+			cwg.Add(1)
+			go func() {
+				defer cwg.Done()
+				for range producers * perWorker / consumers {
+					v, err := b.Pop(ctx)
+					if !assert.NoError(t, err) {
+						return
+					}
+					lock.Lock()
+					seen[v]++
+					lock.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+		cwg.Wait()
+
+		require.Len(t, seen, producers*perWorker)
+		for v, n := range seen {
+			require.Equal(t, 1, n, "value %d", v)
+		}
+		assert.Equal(t, 0, b.Len())
+	})
+}
+
+func BenchmarkBounded(b *testing.B) {
+	r := NewBounded[int](1024)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if !r.TryPush(1) {
+				r.TryPop()
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EncryptDetached encrypts a document using the `dapr.io/enc/v1` scheme, like Encrypt, but returns the header and the binary payload separately.
+// The header (scheme name, manifest, and MAC) is returned as a byte slice, while the binary payload is written to the returned stream.
+// This allows storing the header, which is small, separately from the ciphertext, for example in a database column.
+// Concatenating the header and the binary payload produces the same document as Encrypt.
+func EncryptDetached(in io.Reader, opts EncryptOptions) (header []byte, body io.Reader, err error) {
+	// Validate the request options
+	if in == nil {
+		return nil, nil, errors.New("in stream is nil")
+	}
+
+	header, fk, segmentSize, err := prepareEncryption(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Start a background goroutine to perform the encryption, and return the stream to the caller
+	// From now on, errors are returned as errors on the stream
+	outR, outW := io.Pipe()
+	go encryptSegments(in, outW, fk, segmentSize, opts.Armored)
+
+	return header, outR, nil
+}
+
+// DecryptDetached decrypts a document using the `dapr.io/enc/v1` scheme, whose header and binary payload are stored separately, such as those returned by EncryptDetached.
+// The header must contain exactly the scheme name, the manifest, and the MAC, each terminated by a newline character.
+// The binary payload is read from the `in` stream and the plaintext is written to the returned stream.
+func DecryptDetached(header []byte, in io.Reader, opts DecryptOptions) (io.Reader, error) {
+	// Validate the request options
+	if in == nil {
+		return nil, errors.New("in stream is nil")
+	}
+	if opts.UnwrapKeyFn == nil {
+		return nil, errors.New("option UnwrapKeyFn is required")
+	}
+
+	manifest, mac, err := parseDetachedHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	fk, manifestObj, err := prepareDecryption(manifest, mac, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a background goroutine to perform the decryption, and return the stream to the caller
+	// From now on, errors are returned as errors on the stream
+	outR, outW := io.Pipe()
+	go decryptSegments(in, outW, fk, manifestObj)
+
+	return outR, nil
+}
+
+// Parses a detached header, returning the manifest and the MAC
+func parseDetachedHeader(header []byte) (manifest []byte, mac []byte, err error) {
+	if len(header) > SegmentSize {
+		return nil, nil, errors.New("header is too long")
+	}
+
+	lines := bytes.Split(header, []byte{'\n'})
+	// The header ends with a newline, so the last element is empty
+	if len(lines) != 4 || len(lines[3]) != 0 {
+		return nil, nil, errors.New("invalid format")
+	}
+	if string(lines[0]) != SchemeName {
+		return nil, nil, errors.New("unsupported scheme")
+	}
+	if len(lines[1]) == 0 {
+		return nil, nil, errors.New("manifest not found")
+	}
+	if len(lines[2]) == 0 {
+		return nil, nil, errors.New("message authentication code not found")
+	}
+
+	return lines[1], lines[2], nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetached(t *testing.T) {
+	//nolint:stylecheck,revive
+	var wrapKeyFn WrapKeyFn = func(plaintextKey []byte, algorithm, keyName string, nonce []byte) (wrappedKey []byte, tag []byte, err error) {
+		return plaintextKey, nil, nil
+	}
+	//nolint:stylecheck,revive
+	var unwrapKeyFn UnwrapKeyFn = func(wrappedKey []byte, algorithm, keyName string, nonce, tag []byte) (plaintextKey []byte, err error) {
+		return wrappedKey, nil
+	}
+
+	// Data is larger than a single segment (120KB)
+	message := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 0}, 12<<10)
+
+	encrypt := func(t *testing.T) ([]byte, []byte) {
+		t.Helper()
+		header, body, err := EncryptDetached(bytes.NewReader(message), EncryptOptions{
+			WrapKeyFn: wrapKeyFn,
+			KeyName:   "mykey",
+			Algorithm: KeyAlgorithmAES,
+			Metadata:  map[string]string{"filename": "hello.txt"},
+		})
+		require.NoError(t, err)
+		bodyData, err := io.ReadAll(body)
+		require.NoError(t, err)
+		return header, bodyData
+	}
+
+	t.Run("encrypt and decrypt detached", func(t *testing.T) {
+		header, body := encrypt(t)
+
+		// The header is small and contains the 3 lines only
+		assert.Less(t, len(header), 512)
+		assert.Equal(t, 3, bytes.Count(header, []byte{'\n'}))
+		assert.True(t, bytes.HasPrefix(header, []byte(SchemeName+"\n")))
+
+		dec, err := DecryptDetached(header, bytes.NewReader(body), DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.NoError(t, err)
+		decData, err := io.ReadAll(dec)
+		require.NoError(t, err)
+		assert.Equal(t, message, decData)
+	})
+
+	t.Run("concatenated document can be decrypted with Decrypt", func(t *testing.T) {
+		header, body := encrypt(t)
+
+		manifest, in, err := ReadManifest(io.MultiReader(bytes.NewReader(header), bytes.NewReader(body)))
+		require.NoError(t, err)
+		assert.Equal(t, "hello.txt", manifest.Metadata["filename"])
+
+		dec, err := Decrypt(in, DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.NoError(t, err)
+		decData, err := io.ReadAll(dec)
+		require.NoError(t, err)
+		assert.Equal(t, message, decData)
+	})
+
+	t.Run("document from Encrypt can be decrypted detached", func(t *testing.T) {
+		enc, err := Encrypt(bytes.NewReader(message), EncryptOptions{
+			WrapKeyFn: wrapKeyFn,
+			KeyName:   "mykey",
+			Algorithm: KeyAlgorithmAES,
+		})
+		require.NoError(t, err)
+		encData, err := io.ReadAll(enc)
+		require.NoError(t, err)
+
+		// Split after the third newline
+		var split int
+		for range 3 {
+			split += bytes.IndexByte(encData[split:], '\n') + 1
+		}
+
+		dec, err := DecryptDetached(encData[:split], bytes.NewReader(encData[split:]), DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.NoError(t, err)
+		decData, err := io.ReadAll(dec)
+		require.NoError(t, err)
+		assert.Equal(t, message, decData)
+	})
+
+	t.Run("tampered header fails decryption", func(t *testing.T) {
+		header, body := encrypt(t)
+		header = bytes.Replace(header, []byte("hello.txt"), []byte("hellO.txt"), 1)
+
+		_, err := DecryptDetached(header, bytes.NewReader(body), DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.ErrorIs(t, err, ErrDecryptionSignature)
+	})
+
+	t.Run("header from another document fails decryption", func(t *testing.T) {
+		header, _ := encrypt(t)
+		_, body := encrypt(t)
+
+		dec, err := DecryptDetached(header, bytes.NewReader(body), DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.NoError(t, err)
+		_, err = io.ReadAll(dec)
+		require.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("invalid headers", func(t *testing.T) {
+		header, body := encrypt(t)
+
+		tests := map[string][]byte{
+			"empty":                 nil,
+			"missing final newline": header[:len(header)-1],
+			"extra data":            append(bytes.Clone(header), 'a'),
+			"unsupported scheme":    bytes.Replace(header, []byte(SchemeName), []byte("dapr.io/enc/v0"), 1),
+			"too long":              bytes.Repeat([]byte{'\n'}, SegmentSize+1),
+			"empty lines":           []byte(SchemeName + "\n\n\n"),
+		}
+		for name, h := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := DecryptDetached(h, bytes.NewReader(body), DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+				require.ErrorContains(t, err, "invalid header")
+			})
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, _, err := EncryptDetached(nil, EncryptOptions{})
+		require.Error(t, err)
+		_, _, err = EncryptDetached(bytes.NewReader(message), EncryptOptions{})
+		require.ErrorContains(t, err, "option WrapKeyFn is required")
+
+		header, body := encrypt(t)
+		_, err = DecryptDetached(header, nil, DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.Error(t, err)
+		_, err = DecryptDetached(header, bytes.NewReader(body), DecryptOptions{})
+		require.ErrorContains(t, err, "option UnwrapKeyFn is required")
+	})
+}
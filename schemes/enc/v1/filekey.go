@@ -221,6 +221,30 @@ func (k fileKey) DecryptSegment(out io.Writer, data []byte, num uint32, last boo
 	return nil
 }
 
+// Verify the authenticity of a segment of data, without writing the plaintext.
+// The data is decrypted in place.
+func (k fileKey) VerifySegment(_ io.Writer, data []byte, num uint32, last bool) error {
+	if len(data) == 0 {
+		return errors.New("input ciphertext is empty")
+	}
+
+	// Get the cipher
+	aead, err := k.getCipher()
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	// Create the nonce for the segment
+	nonce := k.nonceForSegment(num, last)
+
+	// Decrypt the segment, re-using the same buffer for the output, which is discarded
+	_, err = aead.Open(data[:0], nonce, data, nil)
+	if err != nil {
+		return ErrDecryptionFailed
+	}
+	return nil
+}
+
 // Computes the nonce for a segment.
 func (k fileKey) nonceForSegment(num uint32, last bool) []byte {
 	nonce := make([]byte, 12)
@@ -31,8 +31,19 @@ type Manifest struct {
 	Cipher Cipher `json:"cph"`
 	// Random sequence of 7 bytes generated by a CSPRNG
 	NoncePrefix []byte `json:"np"`
+	// Optional metadata supplied by the user.
+	// This is authenticated but not encrypted.
+	Metadata map[string]string `json:"m,omitempty"`
+	// Size of each plaintext segment, in bytes.
+	// This is omitted when the document uses the default SegmentSize.
+	SegmentSize int `json:"ss,omitempty"`
+	// If true, the binary payload is base64-encoded.
+	Armored bool `json:"a,omitempty"`
 }
 
+// MaxManifestMetadataSize is the maximum total size, in bytes, of the keys and values in the manifest's metadata.
+const MaxManifestMetadataSize = 4 << 10
+
 // Validate the object and returns no error if everything is fine.
 // It also resolves aliases for the key algorithm and cipher.
 func (m *Manifest) Validate() (err error) {
@@ -50,6 +61,45 @@ func (m *Manifest) Validate() (err error) {
 	if len(m.NoncePrefix) != NoncePrefixLength {
 		return errors.New("nonce prefix is invalid")
 	}
+	if err = validateMetadata(m.Metadata); err != nil {
+		return fmt.Errorf("metadata is invalid: %w", err)
+	}
+	if m.SegmentSize != 0 {
+		if err = validateSegmentSize(m.SegmentSize); err != nil {
+			return fmt.Errorf("segment size is invalid: %w", err)
+		}
+	}
+
+	return nil
+}
 
+// GetSegmentSize returns the size of each plaintext segment, applying the default value if needed.
+func (m Manifest) GetSegmentSize() int {
+	if m.SegmentSize == 0 {
+		return SegmentSize
+	}
+	return m.SegmentSize
+}
+
+// validateSegmentSize validates the size of plaintext segments.
+func validateSegmentSize(size int) error {
+	if size < MinSegmentSize || size > MaxSegmentSize {
+		return fmt.Errorf("must be between %d and %d bytes", MinSegmentSize, MaxSegmentSize)
+	}
+	return nil
+}
+
+// validateMetadata validates the metadata to include in the manifest.
+func validateMetadata(metadata map[string]string) error {
+	var size int
+	for k, v := range metadata {
+		if k == "" {
+			return errors.New("keys must not be empty")
+		}
+		size += len(k) + len(v)
+	}
+	if size > MaxManifestMetadataSize {
+		return fmt.Errorf("total size of keys and values must not be larger than %d bytes", MaxManifestMetadataSize)
+	}
 	return nil
 }
@@ -48,6 +48,70 @@ func TestManifestValidate(t *testing.T) {
 				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
 			},
 		},
+		{
+			name: "with metadata",
+			manifest: &Manifest{
+				KeyWrappingAlgorithm: KeyAlgorithmAES256KW,
+				WFK:                  []byte{0x01, 0x02, 0x03},
+				Cipher:               CipherAESGCM,
+				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+				Metadata:             map[string]string{"content-type": "text/plain"},
+			},
+		},
+		{
+			name: "metadata with empty key",
+			manifest: &Manifest{
+				KeyWrappingAlgorithm: KeyAlgorithmAES256KW,
+				WFK:                  []byte{0x01, 0x02, 0x03},
+				Cipher:               CipherAESGCM,
+				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+				Metadata:             map[string]string{"": "text/plain"},
+			},
+			wantErr: "metadata is invalid: keys must not be empty",
+		},
+		{
+			name: "metadata too large",
+			manifest: &Manifest{
+				KeyWrappingAlgorithm: KeyAlgorithmAES256KW,
+				WFK:                  []byte{0x01, 0x02, 0x03},
+				Cipher:               CipherAESGCM,
+				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+				Metadata:             map[string]string{"foo": strings.Repeat("a", MaxManifestMetadataSize)},
+			},
+			wantErr: "metadata is invalid: total size",
+		},
+		{
+			name: "with segment size",
+			manifest: &Manifest{
+				KeyWrappingAlgorithm: KeyAlgorithmAES256KW,
+				WFK:                  []byte{0x01, 0x02, 0x03},
+				Cipher:               CipherAESGCM,
+				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+				SegmentSize:          MinSegmentSize,
+			},
+		},
+		{
+			name: "segment size too small",
+			manifest: &Manifest{
+				KeyWrappingAlgorithm: KeyAlgorithmAES256KW,
+				WFK:                  []byte{0x01, 0x02, 0x03},
+				Cipher:               CipherAESGCM,
+				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+				SegmentSize:          MinSegmentSize - 1,
+			},
+			wantErr: "segment size is invalid",
+		},
+		{
+			name: "segment size too large",
+			manifest: &Manifest{
+				KeyWrappingAlgorithm: KeyAlgorithmAES256KW,
+				WFK:                  []byte{0x01, 0x02, 0x03},
+				Cipher:               CipherAESGCM,
+				NoncePrefix:          []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+				SegmentSize:          MaxSegmentSize + 1,
+			},
+			wantErr: "segment size is invalid",
+		},
 		{
 			name: "missing key wrapping algorithm",
 			manifest: &Manifest{
@@ -15,6 +15,7 @@ package v1
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,10 +27,16 @@ const (
 	// SchemeName is the name of the encryption scheme.
 	SchemeName = "dapr.io/enc/v1"
 
-	// Size of each segment in the encrypted message.
+	// Default size of each segment in the encrypted message.
 	// Each segment is exactly 64KB in length, except the last one which could be shorter.
 	SegmentSize = 64 << 10
 
+	// Minimum size of segments that can be set in EncryptOptions.
+	MinSegmentSize = 16 << 10
+
+	// Maximum size of segments that can be set in EncryptOptions.
+	MaxSegmentSize = 1 << 20
+
 	// Overhead of each segment in bytes.
 	// This is equivalent to the size of the authentication tag for AES-GCM and ChaCha20-Poly1305.
 	SegmentOverhead = 16
@@ -45,6 +52,9 @@ var (
 	// Error returned when the signature of the document could not be validated.
 	ErrDecryptionSignature = errors.New("failed to validate the document's signature")
 
+	// Error returned when the document's segments require a buffer larger than the one allowed by DecryptOptions.
+	ErrSegmentSizeTooLarge = errors.New("document's segment size exceeds the maximum buffer size")
+
 	// Error returned when the deryption fails.
 	// Most commonly this happens when a segment has been tampered with.
 	ErrDecryptionFailed = errors.New("failed to decrypt segment")
@@ -77,6 +87,17 @@ type EncryptOptions struct {
 	// Cipher used to encrypt the data
 	// If nil, defaults to AES-GCM
 	Cipher *Cipher
+	// Optional metadata to include in the manifest, such as the content type or the original file name
+	// Metadata is authenticated but not encrypted, and it can be read with ReadManifest without the key
+	Metadata map[string]string
+	// Size of each plaintext segment, in bytes
+	// Must be between MinSegmentSize and MaxSegmentSize; if zero, defaults to SegmentSize
+	// Smaller segments reduce the memory used by both Encrypt and Decrypt, at the cost of throughput and a larger ciphertext
+	SegmentSize int
+	// If true, the binary payload is base64-encoded, so the entire document is ASCII text
+	// This is useful for transports and stores that don't preserve binary data, such as environment variables or JSON strings, at the cost of a ciphertext about 33% larger
+	// Decrypt detects armored documents automatically
+	Armored bool
 }
 
 // DecryptOptions contains the options passed to the Decrypt method
@@ -85,19 +106,65 @@ type DecryptOptions struct {
 	UnwrapKeyFn UnwrapKeyFn
 	// If set, uses this value as key name rather than the one included in the manifest
 	KeyName string
+	// If set, caps the size of the buffer allocated to decrypt the document, in bytes
+	// Documents whose segments (plus overhead) do not fit in the buffer are rejected with ErrSegmentSizeTooLarge before the key is unwrapped
+	// This is useful in memory-constrained environments, to prevent a document from requiring buffers of up to MaxSegmentSize
+	MaxBufferSize int
 }
 
 // BufPool is a sync.Pool that returns buffers of SegmentSize+SegmentOverhead, plus one extra byte
 var BufPool = sync.Pool{
 	New: func() any {
-		const bufSize = SegmentSize + SegmentOverhead + 1
-		// Return a pointer here
-		// See https://github.com/dominikh/go-tools/issues/1336 for explanation
-		b := make([]byte, bufSize)
-		return &b
+		return newBuffer(SegmentSize)
 	},
 }
 
+// Largest segment size whose buffers are pooled
+// Documents with larger segments are rare, so their buffers are allocated directly
+const maxPooledSegmentSize = 256 << 10
+
+// Pools of buffers for a fixed set of segment size classes, sorted by size
+// Segment sizes are rounded up to the next class, so documents can't cause an unbounded number of pools to be created
+var segmentBufPools = []struct {
+	size int
+	pool *sync.Pool
+}{
+	{size: MinSegmentSize, pool: newBufPool(MinSegmentSize)},
+	{size: 32 << 10, pool: newBufPool(32 << 10)},
+	{size: SegmentSize, pool: &BufPool},
+	{size: 128 << 10, pool: newBufPool(128 << 10)},
+	{size: maxPooledSegmentSize, pool: newBufPool(maxPooledSegmentSize)},
+}
+
+// Returns the pool of buffers to process segments of the given plaintext size
+// Buffers may be larger than needed
+// For segment sizes larger than maxPooledSegmentSize, this returns a new pool every time, so buffers are not re-used
+func bufPoolForSegmentSize(segmentSize int) *sync.Pool {
+	for _, c := range segmentBufPools {
+		if segmentSize <= c.size {
+			return c.pool
+		}
+	}
+	return newBufPool(segmentSize)
+}
+
+// Returns a pool of buffers to process segments of the given plaintext size
+func newBufPool(segmentSize int) *sync.Pool {
+	return &sync.Pool{
+		New: func() any {
+			return newBuffer(segmentSize)
+		},
+	}
+}
+
+// Allocates a buffer of segmentSize+SegmentOverhead, plus one extra byte
+func newBuffer(segmentSize int) *[]byte {
+	// Return a pointer here
+	// See https://github.com/dominikh/go-tools/issues/1336 for explanation
+	b := make([]byte, segmentSize+SegmentOverhead+1)
+	return &b
+}
+
 // Encrypt a document using the `dapr.io/enc/v1` scheme.
 // The plaintext is read from the `in` stream and written to the returned stream.
 func Encrypt(in io.Reader, opts EncryptOptions) (io.Reader, error) {
@@ -105,38 +172,74 @@ func Encrypt(in io.Reader, opts EncryptOptions) (io.Reader, error) {
 	if in == nil {
 		return nil, errors.New("in stream is nil")
 	}
+
+	header, fk, segmentSize, err := prepareEncryption(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a background goroutine to perform the encryption, and return the stream to the caller
+	// From now on, errors are returned as errors on the stream
+	outR, outW := io.Pipe()
+	go func() {
+		// Write the header
+		if !writeOrClosePipe(outW, header) {
+			return
+		}
+
+		// Proceed with processing all segments
+		encryptSegments(in, outW, fk, segmentSize, opts.Armored)
+	}()
+
+	return outR, nil
+}
+
+// Validates the encryption options, generates and wraps the file key, and returns the signed header
+func prepareEncryption(opts EncryptOptions) (header []byte, fk fileKey, segmentSize int, err error) {
 	if opts.WrapKeyFn == nil {
-		return nil, errors.New("option WrapKeyFn is required")
+		return nil, fk, 0, errors.New("option WrapKeyFn is required")
 	}
 	if opts.KeyName == "" {
-		return nil, errors.New("option KeyName is required")
+		return nil, fk, 0, errors.New("option KeyName is required")
 	}
 	if opts.Algorithm == "" {
-		return nil, errors.New("option Algorithm is required")
+		return nil, fk, 0, errors.New("option Algorithm is required")
 	}
 	keyWrapAlgorithm, err := opts.Algorithm.Validate()
 	if err != nil {
-		return nil, fmt.Errorf("option Algorithm is not valid: %w", err)
+		return nil, fk, 0, fmt.Errorf("option Algorithm is not valid: %w", err)
+	}
+	err = validateMetadata(opts.Metadata)
+	if err != nil {
+		return nil, fk, 0, fmt.Errorf("option Metadata is not valid: %w", err)
 	}
 	cipher := CipherAESGCM
 	if opts.Cipher != nil {
 		cipher, err = opts.Cipher.Validate()
 		if err != nil {
-			return nil, fmt.Errorf("option Cipher is not valid: %w", err)
+			return nil, fk, 0, fmt.Errorf("option Cipher is not valid: %w", err)
+		}
+	}
+	segmentSize = SegmentSize
+	if opts.SegmentSize != 0 {
+		err = validateSegmentSize(opts.SegmentSize)
+		if err != nil {
+			return nil, fk, 0, fmt.Errorf("option SegmentSize is not valid: %w", err)
 		}
+		segmentSize = opts.SegmentSize
 	}
 
 	// Start by generating a random file key
-	fk, err := newFileKey(cipher)
+	fk, err = newFileKey(cipher)
 	if err != nil {
-		return nil, err
+		return nil, fk, 0, err
 	}
 
 	// Wrap the file key
 	// Note: we're skipping the nonce and ignoring the tag parameter at the moment because none of the supported ciphers use them
 	wrappedFileKey, _, err := opts.WrapKeyFn(fk.GetFileKey(), string(keyWrapAlgorithm), opts.KeyName, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to wrap the file key: %w", err)
+		return nil, fk, 0, fmt.Errorf("failed to wrap the file key: %w", err)
 	}
 
 	// Create the manifest and sign it
@@ -146,35 +249,29 @@ func Encrypt(in io.Reader, opts EncryptOptions) (io.Reader, error) {
 	} else if keyName == "" {
 		keyName = opts.KeyName
 	}
-	manifest, err := json.Marshal(&Manifest{
+	manifestObj := Manifest{
 		KeyName:              keyName,
 		KeyWrappingAlgorithm: keyWrapAlgorithm,
 		WFK:                  wrappedFileKey,
 		Cipher:               cipher,
 		NoncePrefix:          fk.GetNoncePrefix(),
-	})
+		Metadata:             opts.Metadata,
+	}
+	// The segment size is included in the manifest only if it's not the default, so documents remain readable by older versions
+	if segmentSize != SegmentSize {
+		manifestObj.SegmentSize = segmentSize
+	}
+	manifestObj.Armored = opts.Armored
+	manifest, err := json.Marshal(&manifestObj)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON manifest: %w", err)
+		return nil, fk, 0, fmt.Errorf("failed to encode JSON manifest: %w", err)
 	}
-	header, err := fk.SignHeader(manifest)
+	header, err = fk.SignHeader(manifest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign header: %w", err)
+		return nil, fk, 0, fmt.Errorf("failed to sign header: %w", err)
 	}
 
-	// Start a background goroutine to perform the encryption, and return the stream to the caller
-	// From now on, errors are returned as errors on the stream
-	outR, outW := io.Pipe()
-	go func() {
-		// Write the header
-		if !writeOrClosePipe(outW, header) {
-			return
-		}
-
-		// Proceed with processing all segments
-		processSegments(in, outW, fk.EncryptSegment, SegmentSize)
-	}()
-
-	return outR, nil
+	return header, fk, segmentSize, nil
 }
 
 // Decrypt a document using the `dapr.io/enc/v1` scheme
@@ -194,12 +291,31 @@ func Decrypt(in io.Reader, opts DecryptOptions) (io.Reader, error) {
 		return nil, fmt.Errorf("invalid header: %w", err)
 	}
 
+	fk, manifestObj, err := prepareDecryption(manifest, mac, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a background goroutine to perform the encryption, and return the stream to the caller
+	// From now on, errors are returned as errors on the stream
+	outR, outW := io.Pipe()
+	go decryptSegments(in, outW, fk, manifestObj)
+
+	return outR, nil
+}
+
+// Parses and validates the manifest, unwraps the file key, and verifies the signature of the header
+func prepareDecryption(manifest []byte, mac []byte, opts DecryptOptions) (fk fileKey, manifestObj Manifest, err error) {
 	// Parse the manifest to get the key name and validate it
-	var manifestObj Manifest
 	err = json.Unmarshal(manifest, &manifestObj)
 	if err != nil || manifestObj.Validate() != nil {
 		// Do not return the exact error to avoid disclosing too much information
-		return nil, errors.New("invalid header: invalid manifest")
+		return fk, Manifest{}, errors.New("invalid header: invalid manifest")
+	}
+
+	// Ensure the segments fit in the maximum buffer size, if any
+	if opts.MaxBufferSize > 0 && manifestObj.GetSegmentSize()+SegmentOverhead+1 > opts.MaxBufferSize {
+		return fk, Manifest{}, ErrSegmentSizeTooLarge
 	}
 
 	// Get the name of the key, and check if we need to override it
@@ -207,7 +323,7 @@ func Decrypt(in io.Reader, opts DecryptOptions) (io.Reader, error) {
 	if keyName == "" {
 		keyName = manifestObj.KeyName
 		if keyName == "" {
-			return nil, ErrDecryptionKeyMissing
+			return fk, Manifest{}, ErrDecryptionKeyMissing
 		}
 	}
 
@@ -224,31 +340,139 @@ func Decrypt(in io.Reader, opts DecryptOptions) (io.Reader, error) {
 	}
 
 	// Import the file key
-	fk, err := importFileKey(fileKeyBytes, manifestObj.NoncePrefix, manifestObj.Cipher)
+	fk, err = importFileKey(fileKeyBytes, manifestObj.NoncePrefix, manifestObj.Cipher)
 	if err != nil {
-		return nil, err
+		return fk, Manifest{}, err
 	}
 
 	// Now validate the MAC of the header
 	err = fk.VerifyHeaderSignature(manifest, mac)
 	if err != nil {
-		return nil, err
+		return fk, Manifest{}, err
 	}
 
-	// Start a background goroutine to perform the encryption, and return the stream to the caller
-	// From now on, errors are returned as errors on the stream
-	outR, outW := io.Pipe()
-	go processSegments(in, outW, fk.DecryptSegment, SegmentSize+SegmentOverhead)
+	return fk, manifestObj, nil
+}
 
-	return outR, nil
+// ReadManifest reads the manifest from the header of a document encrypted with the `dapr.io/enc/v1` scheme, without decrypting it.
+// It returns the manifest and a stream that contains the entire document, including the header, which can be passed to Decrypt.
+// Note that the manifest, including its metadata, is not authenticated until the document is decrypted.
+func ReadManifest(in io.Reader) (Manifest, io.Reader, error) {
+	if in == nil {
+		return Manifest{}, nil, errors.New("in stream is nil")
+	}
+
+	manifest, mac, err := readHeader(&in)
+	if err != nil {
+		return Manifest{}, nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	// Re-create the header, since readHeader consumed it from the stream
+	header := make([]byte, 0, len(SchemeName)+len(manifest)+len(mac)+3)
+	header = append(header, SchemeName...)
+	header = append(header, '\n')
+	header = append(header, manifest...)
+	header = append(header, '\n')
+	header = append(header, mac...)
+	header = append(header, '\n')
+
+	var manifestObj Manifest
+	err = json.Unmarshal(manifest, &manifestObj)
+	if err != nil || manifestObj.Validate() != nil {
+		return Manifest{}, nil, errors.New("invalid header: invalid manifest")
+	}
+
+	return manifestObj, io.MultiReader(bytes.NewReader(header), in), nil
+}
+
+// VerifyIntegrity verifies the integrity of a document encrypted with the `dapr.io/enc/v1` scheme, without producing the plaintext.
+// It validates the MAC of the header and the authenticity of all segments, reading the document from the `in` stream until the end.
+// Segments are decrypted in place in a pooled buffer, which is discarded, so this is cheaper than decrypting the document into io.Discard.
+// It returns nil if the document is valid, or ErrDecryptionSignature or ErrDecryptionFailed (wrapped) if it has been tampered with.
+func VerifyIntegrity(in io.Reader, unwrapFn UnwrapKeyFn) error {
+	if in == nil {
+		return errors.New("in stream is nil")
+	}
+	if unwrapFn == nil {
+		return errors.New("unwrapFn is required")
+	}
+
+	// Read the header
+	manifest, mac, err := readHeader(&in)
+	if err != nil {
+		return fmt.Errorf("invalid header: %w", err)
+	}
+
+	fk, manifestObj, err := prepareDecryption(manifest, mac, DecryptOptions{
+		UnwrapKeyFn: unwrapFn,
+	})
+	if err != nil {
+		return err
+	}
+
+	segmentSize := manifestObj.GetSegmentSize()
+	return readSegments(payloadReader(in, manifestObj), io.Discard, fk.VerifySegment, segmentSize+SegmentOverhead, bufPoolForSegmentSize(segmentSize))
+}
+
+// Encrypts all segments from the input stream, base64-encoding the output if armored
+// The out stream is closed when done, with the error if any
+func encryptSegments(in io.Reader, out *io.PipeWriter, fk fileKey, segmentSize int, armored bool) {
+	pool := bufPoolForSegmentSize(segmentSize)
+	if !armored {
+		processSegments(in, out, fk.EncryptSegment, segmentSize, pool)
+		return
+	}
+
+	enc := base64.NewEncoder(base64.StdEncoding, out)
+	err := readSegments(in, enc, fk.EncryptSegment, segmentSize, pool)
+	if err == nil {
+		// Flush any partially-encoded block
+		err = enc.Close()
+	}
+	if err != nil {
+		_ = out.CloseWithError(err)
+		return
+	}
+	_ = out.Close()
+}
+
+// Decrypts all segments from the input stream, whose format is described by the manifest
+// The out stream is closed when done, with the error if any
+func decryptSegments(in io.Reader, out *io.PipeWriter, fk fileKey, manifestObj Manifest) {
+	segmentSize := manifestObj.GetSegmentSize()
+	processSegments(payloadReader(in, manifestObj), out, fk.DecryptSegment, segmentSize+SegmentOverhead, bufPoolForSegmentSize(segmentSize))
+}
+
+// Returns a stream with the binary payload of a document, decoding it if it's armored
+// Line breaks in armored payloads are ignored
+func payloadReader(in io.Reader, manifestObj Manifest) io.Reader {
+	if !manifestObj.Armored {
+		return in
+	}
+	return base64.NewDecoder(base64.StdEncoding, in)
 }
 
 // Reads all segment from the input stream, either plaintext or ciphertext, and process them (encrypt or decrypt them)
-func processSegments(in io.Reader, out *io.PipeWriter, processFn processSegmentFn, segmentSize int) {
+// The out stream is closed when done, with the error if any
+// The pool must return buffers of at least segmentSize+1 bytes
+func processSegments(in io.Reader, out *io.PipeWriter, processFn processSegmentFn, segmentSize int, pool *sync.Pool) {
+	err := readSegments(in, out, processFn, segmentSize, pool)
+	if err != nil {
+		_ = out.CloseWithError(err)
+		return
+	}
+
+	// Close the out stream as done
+	_ = out.Close()
+}
+
+// Reads all segment from the input stream and process them, returning the first error
+// The pool must return buffers of at least segmentSize+1 bytes
+func readSegments(in io.Reader, out io.Writer, processFn processSegmentFn, segmentSize int, pool *sync.Pool) error {
 	// Get a buffer from the pool
-	buf := BufPool.Get().(*[]byte)
+	buf := pool.Get().(*[]byte)
 	defer func() {
-		BufPool.Put(buf)
+		pool.Put(buf)
 	}()
 
 	// Read from the input stream till the end, one segment at a time
@@ -282,9 +506,8 @@ func processSegments(in io.Reader, out *io.PipeWriter, processFn processSegmentF
 		// Ignore EOF errors, which mean that the input stream is done
 		// We will still need to continue processing whatever data we have
 		if err != nil && !errors.Is(err, io.EOF) {
-			// In case of any other error, close the out stream with the error
-			_ = out.CloseWithError(err)
-			return
+			// In case of any other error, return it
+			return err
 		}
 
 		// If we read an extra byte, set that as carryover
@@ -300,8 +523,7 @@ func processSegments(in io.Reader, out *io.PipeWriter, processFn processSegmentF
 		// It's ok if we got less than a full segment, as long as this was the last segment (i.e. the stream is done)
 		// Realistically, this should never happen, because in this case we would have had an error returned by in.Read.
 		if n < segmentSize && !done {
-			_ = out.CloseWithError(io.ErrUnexpectedEOF)
-			return
+			return io.ErrUnexpectedEOF
 		}
 
 		// A completely empty segment is ok only if this is the first segment (i.e. the input was empty)
@@ -309,8 +531,7 @@ func processSegments(in io.Reader, out *io.PipeWriter, processFn processSegmentF
 		if n == 0 {
 			if segment != 0 {
 				// Realistically, it should be impossible for us to get to this point as well, as there would have been a carryover from the previous iteration.
-				_ = out.CloseWithError(io.ErrUnexpectedEOF)
-				return
+				return io.ErrUnexpectedEOF
 			}
 			break
 		}
@@ -318,21 +539,18 @@ func processSegments(in io.Reader, out *io.PipeWriter, processFn processSegmentF
 		// We can now process the segment
 		err = processFn(out, (*buf)[:n], segment, done)
 		if err != nil {
-			_ = out.CloseWithError(fmt.Errorf("error processing segment %d: %w", segment, err))
-			return
+			return fmt.Errorf("error processing segment %d: %w", segment, err)
 		}
 
 		// Proceed to the next segment if not done
 		if !done && segment == 1<<32-1 {
 			// We're about to overflow
-			_ = out.CloseWithError(errors.New("input stream is too large"))
-			return
+			return errors.New("input stream is too large")
 		}
 		segment++
 	}
 
-	// Close the out stream as done
-	_ = out.Close()
+	return nil
 }
 
 func readHeader(in *io.Reader) (manifest []byte, mac []byte, err error) {
@@ -412,7 +630,8 @@ func readHeader(in *io.Reader) (manifest []byte, mac []byte, err error) {
 		*in = io.MultiReader(bytes.NewReader(extraBytes), *in)
 	}
 
-	return manifest, mac, nil
+	// Copy the manifest and MAC too, since the buffer is returned to the pool
+	return bytes.Clone(manifest), bytes.Clone(mac), nil
 }
 
 func writeOrClosePipe(w *io.PipeWriter, b []byte) bool {
@@ -15,11 +15,13 @@ package v1
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -680,6 +682,222 @@ func TestScheme(t *testing.T) {
 		require.ErrorContains(t, err, "error processing segment 3")
 	})
 
+	t.Run("encryption option SegmentSize", func(t *testing.T) {
+		// 300KB of data, which is 19 segments of 16KB (the last one shorter)
+		plaintext := testData["large-file"]
+
+		for _, segmentSize := range []int{MinSegmentSize, 100_000, MaxSegmentSize} {
+			t.Run(strconv.Itoa(segmentSize), func(t *testing.T) {
+				enc, err := Encrypt(bytes.NewReader(plaintext), EncryptOptions{
+					WrapKeyFn:   wrapKeyFn,
+					KeyName:     keyName,
+					Algorithm:   algorithm,
+					SegmentSize: segmentSize,
+				})
+				require.NoError(t, err)
+				encData, err := io.ReadAll(enc)
+				require.NoError(t, err)
+
+				manifest, _, err := ReadManifest(bytes.NewReader(encData))
+				require.NoError(t, err)
+				require.Equal(t, segmentSize, manifest.SegmentSize)
+				require.Equal(t, segmentSize, manifest.GetSegmentSize())
+
+				dec, err := Decrypt(bytes.NewReader(encData), DecryptOptions{
+					UnwrapKeyFn: unwrapKeyFn,
+				})
+				require.NoError(t, err)
+				decData, err := io.ReadAll(dec)
+				require.NoError(t, err)
+				require.Equal(t, plaintext, decData)
+			})
+		}
+
+		t.Run("default segment size is omitted from the manifest", func(t *testing.T) {
+			enc, err := Encrypt(bytes.NewReader(plaintext), EncryptOptions{
+				WrapKeyFn:   wrapKeyFn,
+				KeyName:     keyName,
+				Algorithm:   algorithm,
+				SegmentSize: SegmentSize,
+			})
+			require.NoError(t, err)
+
+			manifest, _, err := ReadManifest(enc)
+			require.NoError(t, err)
+			require.Equal(t, 0, manifest.SegmentSize)
+			require.Equal(t, SegmentSize, manifest.GetSegmentSize())
+		})
+	})
+
+	t.Run("decryption option MaxBufferSize", func(t *testing.T) {
+		plaintext := testData["multi-segment"]
+
+		encrypt := func(t *testing.T, segmentSize int) []byte {
+			t.Helper()
+			enc, err := Encrypt(bytes.NewReader(plaintext), EncryptOptions{
+				WrapKeyFn:   wrapKeyFn,
+				KeyName:     keyName,
+				Algorithm:   algorithm,
+				SegmentSize: segmentSize,
+			})
+			require.NoError(t, err)
+			encData, err := io.ReadAll(enc)
+			require.NoError(t, err)
+			return encData
+		}
+
+		t.Run("segments fit in the buffer", func(t *testing.T) {
+			dec, err := Decrypt(bytes.NewReader(encrypt(t, MinSegmentSize)), DecryptOptions{
+				UnwrapKeyFn:   unwrapKeyFn,
+				MaxBufferSize: MinSegmentSize + SegmentOverhead + 1,
+			})
+			require.NoError(t, err)
+			decData, err := io.ReadAll(dec)
+			require.NoError(t, err)
+			require.Equal(t, plaintext, decData)
+		})
+
+		t.Run("segments do not fit in the buffer", func(t *testing.T) {
+			unwrapCalled := false
+			dec, err := Decrypt(bytes.NewReader(encrypt(t, 0)), DecryptOptions{
+				UnwrapKeyFn: func(wrappedKey []byte, algorithm, keyName string, nonce, tag []byte) ([]byte, error) {
+					unwrapCalled = true
+					return wrappedKey, nil
+				},
+				MaxBufferSize: 32 << 10,
+			})
+			require.ErrorIs(t, err, ErrSegmentSizeTooLarge)
+			require.Nil(t, dec)
+			require.False(t, unwrapCalled)
+		})
+	})
+
+	t.Run("encryption option Armored", func(t *testing.T) {
+		encrypt := func(t *testing.T, plaintext []byte) []byte {
+			t.Helper()
+			enc, err := Encrypt(bytes.NewReader(plaintext), EncryptOptions{
+				WrapKeyFn: wrapKeyFn,
+				KeyName:   keyName,
+				Algorithm: algorithm,
+				Armored:   true,
+			})
+			require.NoError(t, err)
+			encData, err := io.ReadAll(enc)
+			require.NoError(t, err)
+			return encData
+		}
+
+		for _, name := range []string{"single-segment", "multi-segment", "two-full-segments", "empty-message"} {
+			t.Run(name, func(t *testing.T) {
+				plaintext := testData[name]
+				encData := encrypt(t, plaintext)
+
+				// The document is ASCII text
+				for _, b := range encData {
+					require.Less(t, b, byte(0x80))
+				}
+
+				manifest, _, err := ReadManifest(bytes.NewReader(encData))
+				require.NoError(t, err)
+				require.True(t, manifest.Armored)
+
+				dec, err := Decrypt(bytes.NewReader(encData), DecryptOptions{
+					UnwrapKeyFn: unwrapKeyFn,
+				})
+				require.NoError(t, err)
+				decData, err := io.ReadAll(dec)
+				require.NoError(t, err)
+				require.Equal(t, plaintext, decData)
+
+				require.NoError(t, VerifyIntegrity(bytes.NewReader(encData), unwrapKeyFn))
+			})
+		}
+
+		t.Run("line breaks in the payload are ignored", func(t *testing.T) {
+			plaintext := testData["multi-segment"]
+			encData := encrypt(t, plaintext)
+
+			// Wrap the payload at 76 characters, like MIME does
+			header, payload := splitHeader(t, encData)
+			wrapped := bytes.NewBuffer(header)
+			for len(payload) > 76 {
+				wrapped.Write(payload[:76])
+				wrapped.WriteString("\r\n")
+				payload = payload[76:]
+			}
+			wrapped.Write(payload)
+
+			dec, err := Decrypt(wrapped, DecryptOptions{
+				UnwrapKeyFn: unwrapKeyFn,
+			})
+			require.NoError(t, err)
+			decData, err := io.ReadAll(dec)
+			require.NoError(t, err)
+			require.Equal(t, plaintext, decData)
+		})
+
+		t.Run("decryption fails with invalid base64", func(t *testing.T) {
+			encData := encrypt(t, testData["single-segment"])
+			encData[len(encData)-3] = '!'
+
+			dec, err := Decrypt(bytes.NewReader(encData), DecryptOptions{
+				UnwrapKeyFn: unwrapKeyFn,
+			})
+			require.NoError(t, err)
+			_, err = io.ReadAll(dec)
+			require.Error(t, err)
+		})
+
+		t.Run("decryption fails when a byte is changed in the ciphertext", func(t *testing.T) {
+			encData := encrypt(t, testData["single-segment"])
+			header, payload := splitHeader(t, encData)
+			binary, err := base64.StdEncoding.DecodeString(string(payload))
+			require.NoError(t, err)
+			binary[0] ^= 1
+			encData = append(header, base64.StdEncoding.EncodeToString(binary)...)
+
+			dec, err := Decrypt(bytes.NewReader(encData), DecryptOptions{
+				UnwrapKeyFn: unwrapKeyFn,
+			})
+			require.NoError(t, err)
+			_, err = io.ReadAll(dec)
+			require.ErrorIs(t, err, ErrDecryptionFailed)
+		})
+
+		t.Run("armored flag is authenticated", func(t *testing.T) {
+			encData := encrypt(t, testData["single-segment"])
+			encData = bytes.Replace(encData, []byte(`,"a":true`), nil, 1)
+
+			_, err := Decrypt(bytes.NewReader(encData), DecryptOptions{
+				UnwrapKeyFn: unwrapKeyFn,
+			})
+			require.ErrorIs(t, err, ErrDecryptionSignature)
+		})
+
+		t.Run("detached", func(t *testing.T) {
+			plaintext := testData["multi-segment"]
+			header, body, err := EncryptDetached(bytes.NewReader(plaintext), EncryptOptions{
+				WrapKeyFn: wrapKeyFn,
+				KeyName:   keyName,
+				Algorithm: algorithm,
+				Armored:   true,
+			})
+			require.NoError(t, err)
+			bodyData, err := io.ReadAll(body)
+			require.NoError(t, err)
+			_, err = base64.StdEncoding.DecodeString(string(bodyData))
+			require.NoError(t, err)
+
+			dec, err := DecryptDetached(header, bytes.NewReader(bodyData), DecryptOptions{
+				UnwrapKeyFn: unwrapKeyFn,
+			})
+			require.NoError(t, err)
+			decData, err := io.ReadAll(dec)
+			require.NoError(t, err)
+			require.Equal(t, plaintext, decData)
+		})
+	})
+
 	t.Run("init errors for Encrypt", func(t *testing.T) {
 		t.Run("input stream is nil", func(t *testing.T) {
 			out, err := Encrypt(nil, EncryptOptions{
@@ -745,6 +963,18 @@ func TestScheme(t *testing.T) {
 			require.ErrorContains(t, err, "option Cipher is not valid")
 			require.Nil(t, out)
 		})
+
+		t.Run("option SegmentSize is invalid", func(t *testing.T) {
+			out, err := Encrypt(&bytes.Buffer{}, EncryptOptions{
+				WrapKeyFn:   wrapKeyFn,
+				KeyName:     keyName,
+				Algorithm:   algorithm,
+				SegmentSize: MaxSegmentSize + 1,
+			})
+			require.Error(t, err)
+			require.ErrorContains(t, err, "option SegmentSize is not valid")
+			require.Nil(t, out)
+		})
 	})
 
 	t.Run("init errors for Decrypt", func(t *testing.T) {
@@ -766,6 +996,26 @@ func TestScheme(t *testing.T) {
 	})
 }
 
+func TestBufPoolForSegmentSize(t *testing.T) {
+	t.Run("segment sizes share size classes", func(t *testing.T) {
+		require.Same(t, &BufPool, bufPoolForSegmentSize(SegmentSize))
+		require.Same(t, &BufPool, bufPoolForSegmentSize(SegmentSize-1))
+		require.Same(t, bufPoolForSegmentSize(MinSegmentSize+1), bufPoolForSegmentSize(32<<10))
+		require.Same(t, bufPoolForSegmentSize(SegmentSize+1), bufPoolForSegmentSize(100<<10))
+	})
+
+	t.Run("buffers are large enough", func(t *testing.T) {
+		for _, size := range []int{MinSegmentSize, MinSegmentSize + 1, SegmentSize + 1, maxPooledSegmentSize, maxPooledSegmentSize + 1, MaxSegmentSize} {
+			buf := bufPoolForSegmentSize(size).Get().(*[]byte)
+			require.GreaterOrEqualf(t, len(*buf), size+SegmentOverhead+1, "size %d", size)
+		}
+	})
+
+	t.Run("large segment sizes are not pooled", func(t *testing.T) {
+		require.NotSame(t, bufPoolForSegmentSize(MaxSegmentSize), bufPoolForSegmentSize(MaxSegmentSize))
+	})
+}
+
 func TestReplaceReader(t *testing.T) {
 	const message = "Ho sceso, dandoti il braccio, almeno un milione di scale e ora che non ci sei è il vuoto ad ogni gradino."
 
@@ -893,3 +1143,162 @@ func (f *failingReader) Read(p []byte) (n int, err error) {
 
 	return 0, errSimulatedStream
 }
+
+func TestReadManifest(t *testing.T) {
+	//nolint:stylecheck,revive
+	var wrapKeyFn WrapKeyFn = func(plaintextKey []byte, algorithm, keyName string, nonce []byte) (wrappedKey []byte, tag []byte, err error) {
+		return plaintextKey, nil, nil
+	}
+	//nolint:stylecheck,revive
+	var unwrapKeyFn UnwrapKeyFn = func(wrappedKey []byte, algorithm, keyName string, nonce, tag []byte) (plaintextKey []byte, err error) {
+		return wrappedKey, nil
+	}
+
+	metadata := map[string]string{
+		"content-type": "text/plain",
+		"filename":     "hello.txt",
+	}
+	message := []byte("hello world")
+
+	encrypt := func(t *testing.T) []byte {
+		t.Helper()
+		enc, err := Encrypt(bytes.NewReader(message), EncryptOptions{
+			WrapKeyFn: wrapKeyFn,
+			KeyName:   "mykey",
+			Algorithm: KeyAlgorithmAES,
+			Metadata:  metadata,
+		})
+		require.NoError(t, err)
+		encData, err := io.ReadAll(enc)
+		require.NoError(t, err)
+		return encData
+	}
+
+	t.Run("read manifest and decrypt", func(t *testing.T) {
+		manifest, in, err := ReadManifest(bytes.NewReader(encrypt(t)))
+		require.NoError(t, err)
+		require.Equal(t, metadata, manifest.Metadata)
+		require.Equal(t, "mykey", manifest.KeyName)
+
+		// The returned stream can be decrypted
+		dec, err := Decrypt(in, DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.NoError(t, err)
+		decData, err := io.ReadAll(dec)
+		require.NoError(t, err)
+		require.Equal(t, message, decData)
+	})
+
+	t.Run("tampered metadata fails decryption", func(t *testing.T) {
+		encData := bytes.Replace(encrypt(t), []byte("hello.txt"), []byte("hellO.txt"), 1)
+
+		manifest, in, err := ReadManifest(bytes.NewReader(encData))
+		require.NoError(t, err)
+		require.Equal(t, "hellO.txt", manifest.Metadata["filename"])
+
+		_, err = Decrypt(in, DecryptOptions{UnwrapKeyFn: unwrapKeyFn})
+		require.ErrorIs(t, err, ErrDecryptionSignature)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := Encrypt(bytes.NewReader(message), EncryptOptions{
+			WrapKeyFn: wrapKeyFn,
+			KeyName:   "mykey",
+			Algorithm: KeyAlgorithmAES,
+			Metadata:  map[string]string{"": "foo"},
+		})
+		require.ErrorContains(t, err, "option Metadata is not valid")
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		_, _, err := ReadManifest(strings.NewReader("foo\nbar\nbaz\n"))
+		require.ErrorContains(t, err, "invalid header")
+	})
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	//nolint:stylecheck,revive
+	var unwrapKeyFn UnwrapKeyFn = func(wrappedKey []byte, algorithm, keyName string, nonce, tag []byte) (plaintextKey []byte, err error) {
+		return wrappedKey, nil
+	}
+
+	openTestData := func(t *testing.T, fileName string) *os.File {
+		t.Helper()
+		f, err := os.Open(filepath.Join("testdata", fileName))
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	t.Run("valid documents", func(t *testing.T) {
+		for _, fileName := range []string{"single-segment.enc", "multi-segment.enc", "one-full-segment.enc", "two-full-segments.enc", "empty-message.enc", "large-file.enc"} {
+			err := VerifyIntegrity(openTestData(t, fileName), unwrapKeyFn)
+			require.NoError(t, err, fileName)
+		}
+	})
+
+	t.Run("valid document with custom segment size", func(t *testing.T) {
+		message := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 0}, 5<<10)
+		enc, err := Encrypt(bytes.NewReader(message), EncryptOptions{
+			WrapKeyFn: func(plaintextKey []byte, algorithm, keyName string, nonce []byte) ([]byte, []byte, error) {
+				return plaintextKey, nil, nil
+			},
+			KeyName:     "mykey",
+			Algorithm:   KeyAlgorithmAES,
+			SegmentSize: MinSegmentSize,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, VerifyIntegrity(enc, unwrapKeyFn))
+	})
+
+	t.Run("tampered segment", func(t *testing.T) {
+		// Replace a byte in the second segment (segment 1)
+		rr := newReplaceReader(openTestData(t, "large-file.enc"), 100_000, 100_001, bytes.NewReader([]byte{'A'}))
+
+		err := VerifyIntegrity(rr, unwrapKeyFn)
+		require.ErrorIs(t, err, ErrDecryptionFailed)
+		require.ErrorContains(t, err, "error processing segment 1")
+	})
+
+	t.Run("truncated document", func(t *testing.T) {
+		// Remove the last segment (segment 4)
+		rr := newReplaceReader(openTestData(t, "large-file.enc"), 162+(SegmentSize+SegmentOverhead)*4, -1, &bytes.Buffer{})
+
+		err := VerifyIntegrity(rr, unwrapKeyFn)
+		require.ErrorIs(t, err, ErrDecryptionFailed)
+		require.ErrorContains(t, err, "error processing segment 3")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		err := VerifyIntegrity(openTestData(t, "single-segment.enc"), func(wrappedKey []byte, algorithm, keyName string, nonce, tag []byte) ([]byte, error) {
+			return make([]byte, 32), nil
+		})
+		require.ErrorIs(t, err, ErrDecryptionSignature)
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		err := VerifyIntegrity(strings.NewReader("foo\nbar\nbaz\n"), unwrapKeyFn)
+		require.ErrorContains(t, err, "invalid header")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		require.Error(t, VerifyIntegrity(nil, unwrapKeyFn))
+		require.Error(t, VerifyIntegrity(strings.NewReader(""), nil))
+	})
+}
+
+// Splits an encrypted document in the header, including the final newline, and the payload
+func splitHeader(t *testing.T, doc []byte) (header []byte, payload []byte) {
+	t.Helper()
+	var n int
+	for i, b := range doc {
+		if b == '\n' {
+			n++
+			if n == 3 {
+				return bytes.Clone(doc[:i+1]), bytes.Clone(doc[i+1:])
+			}
+		}
+	}
+	require.FailNow(t, "header not found")
+	return nil, nil
+}
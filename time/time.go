@@ -21,6 +21,7 @@ package time
 import (
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -74,7 +75,10 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 
 	start := i
 	isParsingTime := false
-	var tmp int
+	var (
+		whole int
+		frac  time.Duration
+	)
 	for i < l {
 		switch from[i] {
 		case 'T':
@@ -102,12 +106,13 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			tmp, err = strconv.Atoi(from[start:i])
+			whole, frac, err = parseDecimal(from[start:i], 7*24*time.Hour)
 			if err != nil {
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			days += tmp * 7
+			days += whole * 7
+			duration += frac
 			start = i + 1
 
 		case 'D':
@@ -115,12 +120,13 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			tmp, err = strconv.Atoi(from[start:i])
+			whole, frac, err = parseDecimal(from[start:i], 24*time.Hour)
 			if err != nil {
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			days += tmp
+			days += whole
+			duration += frac
 			start = i + 1
 
 		case 'H':
@@ -128,12 +134,12 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			tmp, err = strconv.Atoi(from[start:i])
+			whole, frac, err = parseDecimal(from[start:i], time.Hour)
 			if err != nil {
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			duration += time.Duration(tmp) * time.Hour
+			duration += time.Duration(whole)*time.Hour + frac
 			start = i + 1
 
 		case 'S':
@@ -141,12 +147,12 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			tmp, err = strconv.Atoi(from[start:i])
+			whole, frac, err = parseDecimal(from[start:i], time.Second)
 			if err != nil {
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			duration += time.Duration(tmp) * time.Second
+			duration += time.Duration(whole)*time.Second + frac
 			start = i + 1
 
 		case 'M': // "M" can be used for both months and minutes
@@ -154,15 +160,19 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 				err = errors.New("unsupported ISO8601 duration format: " + from)
 				return
 			}
-			tmp, err = strconv.Atoi(from[start:i])
-			if err != nil {
-				err = errors.New("unsupported ISO8601 duration format: " + from)
-				return
-			}
 			if isParsingTime {
-				duration += time.Duration(tmp) * time.Minute
+				whole, frac, err = parseDecimal(from[start:i], time.Minute)
+				if err != nil {
+					err = errors.New("unsupported ISO8601 duration format: " + from)
+					return
+				}
+				duration += time.Duration(whole)*time.Minute + frac
 			} else {
-				months = tmp
+				months, err = strconv.Atoi(from[start:i])
+				if err != nil {
+					err = errors.New("unsupported ISO8601 duration format: " + from)
+					return
+				}
 			}
 			start = i + 1
 		}
@@ -173,8 +183,62 @@ func ParseISO8601Duration(from string) (years int, months int, days int, duratio
 	return
 }
 
+// parseDecimal parses a decimal number, optionally with a fractional part
+// separated by "." or ",", as allowed by ISO8601 (e.g. "0.5").
+// It returns the integer part and the fractional part multiplied by unit.
+// Fractional parts are precise up to 9 digits.
+func parseDecimal(s string, unit time.Duration) (int, time.Duration, error) {
+	sep := strings.IndexAny(s, ".,")
+	if sep < 0 {
+		whole, err := strconv.Atoi(s)
+		return whole, 0, err
+	}
+
+	wholeStr, fracStr := s[:sep], s[sep+1:]
+	neg := strings.HasPrefix(wholeStr, "-")
+	if fracStr == "" || len(fracStr) > 9 || strings.TrimLeft(fracStr, "0123456789") != "" {
+		return 0, 0, errors.New("invalid decimal: " + s)
+	}
+
+	var whole int
+	if wholeStr != "" && wholeStr != "-" {
+		var err error
+		whole, err = strconv.Atoi(wholeStr)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	f, err := strconv.ParseInt(fracStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	scale := time.Duration(1)
+	for range fracStr {
+		scale *= 10
+	}
+
+	// Split the multiplication to avoid overflows
+	frac := (unit/scale)*time.Duration(f) + (unit%scale)*time.Duration(f)/scale
+	if neg {
+		frac = -frac
+	}
+
+	return whole, frac, nil
+}
+
+// ToDuration converts a duration with calendar components, as returned by
+// ParseDuration, into a single time.Duration. Because the length of years,
+// months, and days vary, the calendar components are anchored at the
+// reference time from.
+func ToDuration(years, months, days int, duration time.Duration, from time.Time) time.Duration {
+	return from.AddDate(years, months, days).Add(duration).Sub(from)
+}
+
 // ParseDuration creates time.Duration from either:
-// - ISO8601 duration format
+// - ISO8601 duration format; weeks, days, and time components may have a
+// fractional part (e.g. "P0.5D" or "PT0.5S"), with fractional days counted as
+// 24 hours and added to the returned duration
 // - time.Duration string format
 func ParseDuration(from string) (int, int, int, time.Duration, int, error) {
 	y, m, d, dur, r, err := ParseISO8601Duration(from)
@@ -215,3 +279,59 @@ func ParseTime(from string, offset *time.Time) (time.Time, error) {
 	}
 	return time.Time{}, errors.New("unsupported time/duration format: " + from)
 }
+
+// NextRepetition computes the next time a repeating schedule fires, given the
+// time of its first execution (start) and its duration spec, which can be in
+// either the ISO8601 duration format (including repetitions, e.g. "R5/PT10S")
+// or the time.Duration string format.
+// Occurrences are anchored at start, so errors don't accumulate over time.
+// It returns the first occurrence that is not before now, and the number of
+// repetitions remaining including that occurrence, or -1 if the schedule
+// repeats indefinitely.
+// If all repetitions have been exhausted, it returns the zero time and 0.
+func NextRepetition(start time.Time, spec string, now time.Time) (time.Time, int, error) {
+	y, m, d, dur, repetition, err := ParseDuration(spec)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if repetition == 0 {
+		return time.Time{}, 0, nil
+	}
+
+	occurrence := func(n int) time.Time {
+		return start.AddDate(y*n, m*n, d*n).Add(dur * time.Duration(n))
+	}
+	if !occurrence(1).After(start) {
+		return time.Time{}, 0, errors.New("duration must be positive: " + spec)
+	}
+
+	// Estimate the number of elapsed periods, then adjust for calendar
+	// irregularities (month lengths, leap years, DST changes).
+	// With mixed-sign components the period may not grow over time, in which
+	// case there is no well-defined next occurrence.
+	approx := time.Duration(y)*365*24*time.Hour + time.Duration(m)*30*24*time.Hour +
+		time.Duration(d)*24*time.Hour + dur
+	if approx <= 0 {
+		return time.Time{}, 0, errors.New("duration must be positive: " + spec)
+	}
+
+	var n int
+	if now.After(start) {
+		n = int(now.Sub(start) / approx)
+		for n > 0 && !occurrence(n).Before(now) {
+			n--
+		}
+		for occurrence(n).Before(now) {
+			n++
+		}
+	}
+
+	if repetition < 0 {
+		return occurrence(n), -1, nil
+	}
+	if n >= repetition {
+		return time.Time{}, 0, nil
+	}
+
+	return occurrence(n), repetition - n, nil
+}
@@ -147,6 +147,41 @@ func TestParseDuration(t *testing.T) {
 		assert.Equal(t, expect, target)
 	})
 
+	t.Run("parse ISO 8601 duration with fractional components", func(t *testing.T) {
+		y, m, d, duration, repetition, err := ParseDuration("PT0.5S")
+		require.NoError(t, err)
+		assert.Equal(t, 0, y)
+		assert.Equal(t, 0, m)
+		assert.Equal(t, 0, d)
+		assert.Equal(t, 500*time.Millisecond, duration)
+		assert.Equal(t, -1, repetition)
+
+		_, _, d, duration, _, err = ParseDuration("P1.5D")
+		require.NoError(t, err)
+		assert.Equal(t, 1, d)
+		assert.Equal(t, 12*time.Hour, duration)
+
+		_, _, d, duration, _, err = ParseDuration("P0,5W")
+		require.NoError(t, err)
+		assert.Equal(t, 0, d)
+		assert.Equal(t, 84*time.Hour, duration)
+
+		_, _, _, duration, repetition, err = ParseDuration("R3/PT1.25H0.1M1.000000001S")
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour+15*time.Minute+6*time.Second+time.Second+time.Nanosecond, duration)
+		assert.Equal(t, 3, repetition)
+
+		// Fractional years and months are not supported
+		_, _, _, _, _, err = ParseDuration("P0.5Y")
+		require.Error(t, err)
+		_, _, _, _, _, err = ParseDuration("P0.5M")
+		require.Error(t, err)
+		_, _, _, _, _, err = ParseDuration("PT1.S")
+		require.Error(t, err)
+		_, _, _, _, _, err = ParseDuration("PT1.0000000001S")
+		require.Error(t, err)
+	})
+
 	t.Run("parse RFC3339 datetime", func(t *testing.T) {
 		_, _, _, _, _, err := ParseDuration(time.Now().Add(time.Minute).Format(time.RFC3339))
 		require.Error(t, err)
@@ -205,6 +240,41 @@ func TestParseTime(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, time.Duration(0), expected.Sub(tm))
 	})
+	t.Run("parse ISO 8601 duration with fractional components", func(t *testing.T) {
+		y, m, d, duration, repetition, err := ParseDuration("PT0.5S")
+		require.NoError(t, err)
+		assert.Equal(t, 0, y)
+		assert.Equal(t, 0, m)
+		assert.Equal(t, 0, d)
+		assert.Equal(t, 500*time.Millisecond, duration)
+		assert.Equal(t, -1, repetition)
+
+		_, _, d, duration, _, err = ParseDuration("P1.5D")
+		require.NoError(t, err)
+		assert.Equal(t, 1, d)
+		assert.Equal(t, 12*time.Hour, duration)
+
+		_, _, d, duration, _, err = ParseDuration("P0,5W")
+		require.NoError(t, err)
+		assert.Equal(t, 0, d)
+		assert.Equal(t, 84*time.Hour, duration)
+
+		_, _, _, duration, repetition, err = ParseDuration("R3/PT1.25H0.1M1.000000001S")
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour+15*time.Minute+6*time.Second+time.Second+time.Nanosecond, duration)
+		assert.Equal(t, 3, repetition)
+
+		// Fractional years and months are not supported
+		_, _, _, _, _, err = ParseDuration("P0.5Y")
+		require.Error(t, err)
+		_, _, _, _, _, err = ParseDuration("P0.5M")
+		require.Error(t, err)
+		_, _, _, _, _, err = ParseDuration("PT1.S")
+		require.Error(t, err)
+		_, _, _, _, _, err = ParseDuration("PT1.0000000001S")
+		require.Error(t, err)
+	})
+
 	t.Run("parse RFC3339 datetime", func(t *testing.T) {
 		dummy := time.Now().Add(5 * time.Minute)
 		expected := time.Now().Truncate(time.Minute).Add(time.Minute)
@@ -217,3 +287,124 @@ func TestParseTime(t *testing.T) {
 		require.ErrorContains(t, err, "unsupported time/duration format")
 	})
 }
+
+func TestToDuration(t *testing.T) {
+	// 2024 is a leap year
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 29*24*time.Hour, ToDuration(0, 1, 0, 0, from))
+	assert.Equal(t, 366*24*time.Hour, ToDuration(1, 0, 0, 0, from))
+	assert.Equal(t, 2*24*time.Hour+90*time.Minute, ToDuration(0, 0, 2, 90*time.Minute, from))
+
+	from = time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 28*24*time.Hour, ToDuration(0, 1, 0, 0, from))
+
+	y, m, d, dur, _, err := ParseDuration("P1M0.5D")
+	require.NoError(t, err)
+	assert.Equal(t, 28*24*time.Hour+12*time.Hour, ToDuration(y, m, d, dur, from))
+}
+
+func TestNextRepetition(t *testing.T) {
+	start := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		spec      string
+		now       time.Time
+		expNext   time.Time
+		expRemain int
+		expErr    bool
+	}{
+		"before start returns start": {
+			spec:      "R3/PT10S",
+			now:       start.Add(-time.Hour),
+			expNext:   start,
+			expRemain: 3,
+		},
+		"at start returns start": {
+			spec:      "R3/PT10S",
+			now:       start,
+			expNext:   start,
+			expRemain: 3,
+		},
+		"between occurrences": {
+			spec:      "R3/PT10S",
+			now:       start.Add(5 * time.Second),
+			expNext:   start.Add(10 * time.Second),
+			expRemain: 2,
+		},
+		"at last occurrence": {
+			spec:      "R3/PT10S",
+			now:       start.Add(20 * time.Second),
+			expNext:   start.Add(20 * time.Second),
+			expRemain: 1,
+		},
+		"repetitions exhausted": {
+			spec:      "R3/PT10S",
+			now:       start.Add(21 * time.Second),
+			expNext:   time.Time{},
+			expRemain: 0,
+		},
+		"infinite repetitions": {
+			spec:      "PT10S",
+			now:       start.Add(time.Hour + time.Second),
+			expNext:   start.Add(time.Hour + 10*time.Second),
+			expRemain: -1,
+		},
+		"go duration": {
+			spec:      "1m",
+			now:       start.Add(90 * time.Second),
+			expNext:   start.Add(2 * time.Minute),
+			expRemain: -1,
+		},
+		"months are anchored at start": {
+			spec:      "R12/P1M",
+			now:       start.AddDate(0, 0, 40),
+			expNext:   start.AddDate(0, 2, 0),
+			expRemain: 10,
+		},
+		"mixed calendar and time components": {
+			spec:      "R100/P1DT1H",
+			now:       start.Add(24*time.Hour*30 + time.Minute),
+			expNext:   start.AddDate(0, 0, 29).Add(29 * time.Hour),
+			expRemain: 71,
+		},
+		"zero repetitions": {
+			spec:      "R0/PT1S",
+			now:       start,
+			expNext:   time.Time{},
+			expRemain: 0,
+		},
+		"zero duration": {
+			spec:   "R5/PT0S",
+			now:    start,
+			expErr: true,
+		},
+		"mixed-sign components with zero period": {
+			spec:   "R5/P1MT-720H",
+			now:    start.AddDate(0, 0, 10),
+			expErr: true,
+		},
+		"mixed-sign components with negative period": {
+			spec:   "P1MT-721H",
+			now:    start.AddDate(0, 0, 10),
+			expErr: true,
+		},
+		"invalid spec": {
+			spec:   "foo",
+			now:    start,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			next, remain, err := NextRepetition(start, test.spec, test.now)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expNext, next)
+			assert.Equal(t, test.expRemain, remain)
+		})
+	}
+}